@@ -90,4 +90,30 @@ var (
 			return nil
 		},
 	}
+
+	cmdValidate = cli.Command{
+		Name:  "validate",
+		Usage: "validate a mosn config without starting the proxy",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:   "config, c",
+				Usage:  "Load configuration from `FILE`",
+				EnvVar: "MOSN_CONFIG",
+				Value:  "configs/mosn_config.json",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			configPath := c.String("config")
+			conf := config.Load(configPath)
+			errs := mosn.ValidateConfig(conf)
+			if len(errs) > 0 {
+				for _, err := range errs {
+					fmt.Println("error:", err)
+				}
+				return fmt.Errorf("config %s is not valid, %d errors found", configPath, len(errs))
+			}
+			fmt.Printf("config %s is valid\n", configPath)
+			return nil
+		},
+	}
 )
@@ -23,20 +23,36 @@ import (
 	"strconv"
 	"time"
 
+	_ "sofastack.io/sofa-mosn/pkg/benchmark"
 	_ "sofastack.io/sofa-mosn/pkg/buffer"
+	_ "sofastack.io/sofa-mosn/pkg/filter/network/demux"
+	_ "sofastack.io/sofa-mosn/pkg/filter/network/mqttproxy"
 	_ "sofastack.io/sofa-mosn/pkg/filter/network/proxy"
+	_ "sofastack.io/sofa-mosn/pkg/filter/network/redisproxy"
 	_ "sofastack.io/sofa-mosn/pkg/filter/network/tcpproxy"
 	_ "sofastack.io/sofa-mosn/pkg/filter/stream/faultinject"
+	_ "sofastack.io/sofa-mosn/pkg/filter/stream/grpcweb"
 	_ "sofastack.io/sofa-mosn/pkg/filter/stream/healthcheck/sofarpc"
 	_ "sofastack.io/sofa-mosn/pkg/filter/stream/mixer"
+	_ "sofastack.io/sofa-mosn/pkg/filter/stream/ratelimit"
+	_ "sofastack.io/sofa-mosn/pkg/filter/stream/requestsign"
+	_ "sofastack.io/sofa-mosn/pkg/filter/stream/responsecorrupt"
+	_ "sofastack.io/sofa-mosn/pkg/filter/stream/responsevalidate"
+	_ "sofastack.io/sofa-mosn/pkg/filter/stream/xfcc"
 	_ "sofastack.io/sofa-mosn/pkg/metrics/sink"
+	_ "sofastack.io/sofa-mosn/pkg/metrics/sink/otlp"
 	_ "sofastack.io/sofa-mosn/pkg/metrics/sink/prometheus"
+	_ "sofastack.io/sofa-mosn/pkg/mtls/csr"
+	_ "sofastack.io/sofa-mosn/pkg/featuregate"
+	_ "sofastack.io/sofa-mosn/pkg/mtls/sds"
+	_ "sofastack.io/sofa-mosn/pkg/mtls/spiffe"
 	_ "sofastack.io/sofa-mosn/pkg/network"
 	_ "sofastack.io/sofa-mosn/pkg/protocol"
 	_ "sofastack.io/sofa-mosn/pkg/protocol/http/conv"
 	_ "sofastack.io/sofa-mosn/pkg/protocol/http2/conv"
 	_ "sofastack.io/sofa-mosn/pkg/protocol/rpc/sofarpc/codec"
 	_ "sofastack.io/sofa-mosn/pkg/protocol/rpc/sofarpc/conv"
+	_ "sofastack.io/sofa-mosn/pkg/protocol/rpc/xprotocol/dubbo"
 	_ "sofastack.io/sofa-mosn/pkg/protocol/rpc/xprotocol/tars"
 	_ "sofastack.io/sofa-mosn/pkg/router"
 	_ "sofastack.io/sofa-mosn/pkg/stream/http"
@@ -63,6 +79,7 @@ func main() {
 		cmdStart,
 		cmdStop,
 		cmdReload,
+		cmdValidate,
 	}
 
 	//action
@@ -0,0 +1,113 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package audit records every dynamic configuration mutation (xds push,
+// admin api call, registry update) into an append-only in-memory log so
+// operators can answer "what changed at 14:03" during incidents. The log
+// is queryable via the admin endpoint /api/v1/audit
+package audit
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// event sources
+const (
+	SourceXDS      = "xds"
+	SourceAdmin    = "admin"
+	SourceRegistry = "registry"
+)
+
+// maxEntries bounds the in-memory log, the oldest entries are dropped
+var maxEntries = 1000
+
+// Event is one recorded configuration mutation
+type Event struct {
+	Time     string `json:"time"`
+	Source   string `json:"source"`
+	Action   string `json:"action"`
+	Resource string `json:"resource,omitempty"`
+	Summary  string `json:"summary,omitempty"`
+	Result   string `json:"result"`
+}
+
+var (
+	mu     sync.RWMutex
+	events []Event
+)
+
+// Record appends a mutation to the audit log. A nil err records a success,
+// otherwise the error is kept as the result
+func Record(source, action, resource, summary string, err error) {
+	result := "success"
+	if err != nil {
+		result = "failed: " + err.Error()
+	}
+	event := Event{
+		Time:     time.Now().Format("2006-01-02 15:04:05.000"),
+		Source:   source,
+		Action:   action,
+		Resource: resource,
+		Summary:  summary,
+		Result:   result,
+	}
+	mu.Lock()
+	events = append(events, event)
+	if len(events) > maxEntries {
+		events = events[len(events)-maxEntries:]
+	}
+	mu.Unlock()
+}
+
+// Events returns the newest entries first, limit <= 0 means all
+func Events(limit int) []Event {
+	mu.RLock()
+	defer mu.RUnlock()
+	if limit <= 0 || limit > len(events) {
+		limit = len(events)
+	}
+	newest := make([]Event, 0, limit)
+	for i := len(events) - 1; i >= len(events)-limit; i-- {
+		newest = append(newest, events[i])
+	}
+	return newest
+}
+
+// DumpHandler is the admin endpoint, a "limit" query parameter caps the
+// number of entries returned
+func DumpHandler(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	buf, err := json.Marshal(Events(limit))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf)
+}
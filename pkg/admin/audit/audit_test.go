@@ -0,0 +1,105 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+)
+
+func resetAudit() {
+	mu.Lock()
+	events = nil
+	mu.Unlock()
+}
+
+func TestRecordAndQuery(t *testing.T) {
+	resetAudit()
+	Record(SourceXDS, "push_cds", "v1", "2 resources", nil)
+	Record(SourceAdmin, "update_loglevel", "stdout", "level DEBUG", nil)
+	Record(SourceXDS, "push_lds", "v2", "1 resources", errors.New("no network filter"))
+
+	all := Events(0)
+	if len(all) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(all))
+	}
+	// newest first
+	if all[0].Action != "push_lds" || all[2].Action != "push_cds" {
+		t.Errorf("unexpected order: %v", all)
+	}
+	if all[0].Result != "failed: no network filter" {
+		t.Errorf("failure result %q", all[0].Result)
+	}
+	if all[2].Result != "success" {
+		t.Errorf("success result %q", all[2].Result)
+	}
+
+	limited := Events(1)
+	if len(limited) != 1 || limited[0].Action != "push_lds" {
+		t.Errorf("limited query: %v", limited)
+	}
+}
+
+func TestLogBounded(t *testing.T) {
+	resetAudit()
+	oldMax := maxEntries
+	maxEntries = 5
+	defer func() {
+		maxEntries = oldMax
+	}()
+
+	for i := 0; i < 10; i++ {
+		Record(SourceRegistry, "update_cluster", fmt.Sprintf("c%d", i), "", nil)
+	}
+	all := Events(0)
+	if len(all) != 5 {
+		t.Fatalf("expected 5 events, got %d", len(all))
+	}
+	// oldest entries dropped, newest first
+	if all[0].Resource != "c9" || all[4].Resource != "c5" {
+		t.Errorf("unexpected retained entries: %v", all)
+	}
+}
+
+func TestDumpHandler(t *testing.T) {
+	resetAudit()
+	Record(SourceAdmin, "enable_log", "access.log", "", nil)
+	Record(SourceAdmin, "disable_log", "access.log", "", nil)
+
+	w := httptest.NewRecorder()
+	DumpHandler(w, httptest.NewRequest("GET", "/api/v1/audit?limit=1", nil))
+	if w.Code != 200 {
+		t.Fatalf("dump status %d", w.Code)
+	}
+	var got []Event
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("unmarshal dump: %v", err)
+	}
+	if len(got) != 1 || got[0].Action != "disable_log" {
+		t.Errorf("unexpected dump: %v", got)
+	}
+
+	w = httptest.NewRecorder()
+	DumpHandler(w, httptest.NewRequest("GET", "/api/v1/audit?limit=x", nil))
+	if w.Code != 400 {
+		t.Errorf("bad limit should be rejected, got %d", w.Code)
+	}
+}
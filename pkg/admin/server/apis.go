@@ -18,12 +18,14 @@
 package server
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 
 	"io/ioutil"
 
+	"sofastack.io/sofa-mosn/pkg/admin/audit"
 	"sofastack.io/sofa-mosn/pkg/admin/store"
 	"sofastack.io/sofa-mosn/pkg/log"
 	"sofastack.io/sofa-mosn/pkg/metrics"
@@ -99,6 +101,7 @@ func updateLogLevel(w http.ResponseWriter, r *http.Request) {
 		if level, ok := levelMap[data.LogLevel]; ok {
 			if log.UpdateErrorLoggerLevel(data.LogPath, level) {
 				log.DefaultLogger.Infof("[admin api] [update log level] update log: %s level as %s", data.LogPath, data.LogLevel)
+				audit.Record(audit.SourceAdmin, "update_loglevel", data.LogPath, "level "+data.LogLevel, nil)
 				w.WriteHeader(http.StatusOK)
 				fmt.Fprint(w, "update logger success\n")
 				return
@@ -106,6 +109,7 @@ func updateLogLevel(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	log.DefaultLogger.Errorf("[admin api] [update log level] update logger level failed with bad request data: %s", string(body))
+	audit.Record(audit.SourceAdmin, "update_loglevel", data.LogPath, "level "+data.LogLevel, errors.New("bad request data"))
 	w.WriteHeader(http.StatusBadRequest) // 400
 	msg := fmt.Sprintf(errMsgFmt, "update logger failed")
 	fmt.Fprint(w, msg)
@@ -129,12 +133,14 @@ func enableLogger(w http.ResponseWriter, r *http.Request) {
 	}
 	if !log.ToggleLogger(string(loggerPath), false) {
 		log.DefaultLogger.Errorf("[admin api] [enable logger] enbale logger failed, logger: %s", string(loggerPath))
+		audit.Record(audit.SourceAdmin, "enable_log", string(loggerPath), "", errors.New("enable logger failed"))
 		w.WriteHeader(http.StatusBadRequest) // 400
 		msg := fmt.Sprintf(errMsgFmt, "enable logger failed")
 		fmt.Fprint(w, msg)
 		return
 	}
 	log.DefaultLogger.Infof("[admin api] [enable logger] enable logger %s", string(loggerPath))
+	audit.Record(audit.SourceAdmin, "enable_log", string(loggerPath), "", nil)
 	w.WriteHeader(http.StatusOK)
 	fmt.Fprint(w, "enable logger success\n")
 }
@@ -155,12 +161,14 @@ func disableLogger(w http.ResponseWriter, r *http.Request) {
 	}
 	if !log.ToggleLogger(string(loggerPath), true) {
 		log.DefaultLogger.Errorf("[admin api] [disable logger] disale logger failed, logger: %s", string(loggerPath))
+		audit.Record(audit.SourceAdmin, "disable_log", string(loggerPath), "", errors.New("disable logger failed"))
 		w.WriteHeader(http.StatusBadRequest) // 400
 		msg := fmt.Sprintf(errMsgFmt, "disbale logger failed")
 		fmt.Fprint(w, msg)
 		return
 	}
 	log.DefaultLogger.Infof("[admin api] [disable logger] disable logger %s", string(loggerPath))
+	audit.Record(audit.SourceAdmin, "disable_log", string(loggerPath), "", nil)
 	w.WriteHeader(http.StatusOK)
 	fmt.Fprint(w, "disable logger success\n")
 }
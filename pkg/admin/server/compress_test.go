@@ -0,0 +1,71 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGzipHandler(t *testing.T) {
+	payload := strings.Repeat("mosn stats line\n", 1024)
+	handler := gzipHandler(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(payload))
+	})
+
+	// client accepts gzip, payload round-trips through the compressor
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/api/v1/stats", nil)
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	handler(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("gzip response status %d", w.Code)
+	}
+	if w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("content-encoding not set: %v", w.Header())
+	}
+	if w.Body.Len() >= len(payload) {
+		t.Errorf("compressed body not smaller than payload: %d >= %d", w.Body.Len(), len(payload))
+	}
+	gr, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatalf("gzip reader: %v", err)
+	}
+	b, err := ioutil.ReadAll(gr)
+	if err != nil {
+		t.Fatalf("read gzip body: %v", err)
+	}
+	if string(b) != payload {
+		t.Errorf("payload mismatch after decompress, got %d bytes", len(b))
+	}
+
+	// client without gzip support gets the plain payload
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/api/v1/stats", nil))
+	if w.Header().Get("Content-Encoding") != "" {
+		t.Errorf("unexpected content-encoding: %v", w.Header())
+	}
+	if w.Body.String() != payload {
+		t.Errorf("plain payload mismatch, got %d bytes", w.Body.Len())
+	}
+}
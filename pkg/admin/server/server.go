@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"net/http"
 
+	"sofastack.io/sofa-mosn/pkg/admin/audit"
 	"sofastack.io/sofa-mosn/pkg/admin/store"
 	"sofastack.io/sofa-mosn/pkg/log"
 	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
@@ -41,12 +42,13 @@ func RegisterAdminHandleFunc(pattern string, handler func(http.ResponseWriter, *
 func init() {
 	// default admin api
 	apiHandleFuncStore = map[string]func(http.ResponseWriter, *http.Request){
-		"/api/v1/config_dump":     configDump,
-		"/api/v1/stats":           statsDump,
+		"/api/v1/config_dump":     gzipHandler(configDump),
+		"/api/v1/stats":           gzipHandler(statsDump),
 		"/api/v1/update_loglevel": updateLogLevel,
 		"/api/v1/enable_log":      enableLogger,
 		"/api/v1/disbale_log":     disableLogger,
 		"/api/v1/states":          getState,
+		"/api/v1/audit":           audit.DumpHandler,
 	}
 }
 
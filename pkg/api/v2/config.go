@@ -30,11 +30,12 @@ type HealthCheckConfig struct {
 }
 
 type HostConfig struct {
-	Address        string          `json:"address,omitempty"`
-	Hostname       string          `json:"hostname,omitempty"`
-	Weight         uint32          `json:"weight,omitempty"`
-	MetaDataConfig *MetadataConfig `json:"metadata,omitempty"`
-	TLSDisable     bool            `json:"tls_disable,omitempty"`
+	Address               string          `json:"address,omitempty"`
+	Hostname              string          `json:"hostname,omitempty"`
+	Weight                uint32          `json:"weight,omitempty"`
+	MetaDataConfig        *MetadataConfig `json:"metadata,omitempty"`
+	TLSDisable            bool            `json:"tls_disable,omitempty"`
+	MaxConcurrentRequests uint32          `json:"max_concurrent_requests,omitempty"`
 }
 
 // ListenerType: Ingress or Egress
@@ -53,6 +54,41 @@ type ListenerConfig struct {
 	FilterChains                          []FilterChain `json:"filter_chains,omitempty"` // only one filterchains at this time
 	StreamFilters                         []Filter      `json:"stream_filters,omitempty"`
 	Inspector                             bool          `json:"inspector,omitempty"`
+	// Local marks the dedicated sidecar to local-app inbound listener.
+	// A local listener only binds a loopback address or a unix domain
+	// socket ("unix:/path"), skips tls termination and ignores stream
+	// filters, keeping the per-request overhead of the local hop low.
+	Local          bool           `json:"local,omitempty"`
+	MaxConnections uint64         `json:"max_connections,omitempty"` // 0 means no limit
+	SocketOptions  *SocketOptions `json:"socket_options,omitempty"`
+}
+
+// SocketOptions configures low level socket behaviors, applied on the raw
+// socket at listen time for listeners and at dial time for cluster hosts.
+// Zero values keep the kernel defaults
+type SocketOptions struct {
+	// TCPKeepaliveIdle enables SO_KEEPALIVE and sets TCP_KEEPIDLE, in seconds
+	TCPKeepaliveIdle uint32 `json:"tcp_keepalive_idle,omitempty"`
+	// TCPKeepaliveInterval sets TCP_KEEPINTVL, in seconds
+	TCPKeepaliveInterval uint32 `json:"tcp_keepalive_interval,omitempty"`
+	// TCPKeepaliveProbes sets TCP_KEEPCNT
+	TCPKeepaliveProbes uint32 `json:"tcp_keepalive_probes,omitempty"`
+	// TCPNoDelay sets TCP_NODELAY explicitly when configured
+	TCPNoDelay *bool `json:"tcp_nodelay,omitempty"`
+	// RecvBufSize sets SO_RCVBUF, in bytes
+	RecvBufSize uint32 `json:"recv_buf_size,omitempty"`
+	// SendBufSize sets SO_SNDBUF, in bytes
+	SendBufSize uint32 `json:"send_buf_size,omitempty"`
+	// Freebind sets IP_FREEBIND so a listener can bind a non-local address
+	Freebind bool `json:"freebind,omitempty"`
+	// IPTos sets IP_TOS, the DSCP value occupies the high six bits.
+	// Accepted sockets inherit the value from the listening socket
+	IPTos uint32 `json:"ip_tos,omitempty"`
+	// SoPriority sets SO_PRIORITY for traffic control based QoS policies
+	SoPriority uint32 `json:"so_priority,omitempty"`
+	// TCPFastOpen enables TCP_FASTOPEN on listeners and
+	// TCP_FASTOPEN_CONNECT on upstream connections
+	TCPFastOpen bool `json:"tcp_fastopen,omitempty"`
 }
 
 type TCPRouteConfig struct {
@@ -103,29 +139,57 @@ type RouterActionConfig struct {
 	MetadataConfig          *MetadataConfig      `json:"metadata_match,omitempty"`
 	TimeoutConfig           DurationConfig       `json:"timeout,omitempty"`
 	RetryPolicy             *RetryPolicy         `json:"retry_policy,omitempty"`
+	Shadow                  *ShadowPolicy        `json:"shadow,omitempty"`
 	PrefixRewrite           string               `json:"prefix_rewrite,omitempty"`
 	HostRewrite             string               `json:"host_rewrite,omitempty"`
 	AutoHostRewrite         bool                 `json:"auto_host_rewrite,omitempty"`
+	EnableWebSocket         bool                 `json:"enable_websocket,omitempty"`
 	RequestHeadersToAdd     []*HeaderValueOption `json:"request_headers_to_add,omitempty"`
 	ResponseHeadersToAdd    []*HeaderValueOption `json:"response_headers_to_add,omitempty"`
 	ResponseHeadersToRemove []string             `json:"response_headers_to_remove,omitempty"`
 }
 
 type ClusterWeightConfig struct {
-	Name           string          `json:"name,omitempty"`
-	Weight         uint32          `json:"weight,omitempty"`
-	MetadataConfig *MetadataConfig `json:"metadata_match,omitempty"`
+	Name                    string               `json:"name,omitempty"`
+	Weight                  uint32               `json:"weight,omitempty"`
+	MetadataConfig          *MetadataConfig      `json:"metadata_match,omitempty"`
+	RequestHeadersToAdd     []*HeaderValueOption `json:"request_headers_to_add,omitempty"`
+	ResponseHeadersToAdd    []*HeaderValueOption `json:"response_headers_to_add,omitempty"`
+	ResponseHeadersToRemove []string             `json:"response_headers_to_remove,omitempty"`
 }
 
 type RetryPolicyConfig struct {
 	RetryOn            bool           `json:"retry_on,omitempty"`
 	RetryTimeoutConfig DurationConfig `json:"retry_timeout,omitempty"`
 	NumRetries         uint32         `json:"num_retries,omitempty"`
+	// RetryableMethods marks the methods of this route as idempotent, only
+	// those are auto-retried after the request may have been processed.
+	// Defaults to the idempotent http methods GET/HEAD/PUT when empty
+	RetryableMethods []string `json:"retryable_methods,omitempty"`
 }
 
 type FilterChainConfig struct {
-	FilterChainMatch string      `json:"match,omitempty"`
-	TLSConfig        *TLSConfig  `json:"tls_context,omitempty"`
-	TLSConfigs       []TLSConfig `json:"tls_context_set,omitempty"`
-	Filters          []Filter    `json:"filters,omitempty"`
+	FilterChainMatch string                    `json:"match,omitempty"`
+	Matches          *FilterChainMatchCriteria `json:"matches,omitempty"`
+	TLSConfig        *TLSConfig                `json:"tls_context,omitempty"`
+	TLSConfigs       []TLSConfig               `json:"tls_context_set,omitempty"`
+	Filters          []Filter                  `json:"filters,omitempty"`
+}
+
+// FilterChainMatchCriteria selects a filter chain per connection, modeled on
+// envoy's FilterChainMatch. All specified criteria must match, a chain
+// without criteria is a catch-all
+type FilterChainMatchCriteria struct {
+	// ServerNames matches the TLS SNI server name, *.example.com wildcards are supported
+	ServerNames []string `json:"server_names,omitempty"`
+	// ApplicationProtocols matches the negotiated ALPN protocol
+	ApplicationProtocols []string `json:"application_protocols,omitempty"`
+	// TransportProtocols matches the sniffed transport, "tls" or
+	// "raw_buffer". With an inspector listener this splits permissive
+	// traffic into a mtls chain and a plaintext chain on one port
+	TransportProtocols []string `json:"transport_protocols,omitempty"`
+	// SourcePrefixRanges matches the downstream address against CIDR blocks
+	SourcePrefixRanges []string `json:"source_prefix_ranges,omitempty"`
+	// DestinationPort matches the (restored) destination port, 0 means any
+	DestinationPort uint32 `json:"destination_port,omitempty"`
 }
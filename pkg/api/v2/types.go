@@ -25,8 +25,8 @@ import (
 	"path"
 	"time"
 
-	"sofastack.io/sofa-mosn/pkg/utils"
 	"istio.io/api/mixer/v1/config/client"
+	"sofastack.io/sofa-mosn/pkg/utils"
 )
 
 // Metadata field can be used to provide additional information about the route.
@@ -41,13 +41,22 @@ const (
 	TCP_PROXY                   = "tcp_proxy"
 	FAULT_INJECT_NETWORK_FILTER = "fault_inject"
 	RPC_PROXY                   = "rpc_proxy"
+	REDIS_PROXY                 = "redis_proxy"
+	MQTT_PROXY                  = "mqtt_proxy"
 	X_PROXY                     = "x_proxy"
+	PROTOCOL_DEMUX              = "protocol_demux"
 )
 
 // Stream Filter's Type
 const (
-	MIXER       = "mixer"
-	FaultStream = "fault"
+	MIXER           = "mixer"
+	FaultStream     = "fault"
+	CorruptStream   = "response_corrupt"
+	ValidateStream  = "response_validate"
+	XFCCStream      = "x_forwarded_client_cert"
+	GrpcWebStream   = "grpc_web"
+	SignStream      = "request_sign"
+	RateLimitStream = "rate_limit"
 )
 
 // ClusterType
@@ -92,7 +101,94 @@ type Cluster struct {
 	Spec                 ClusterSpecInfo `json:"spec,omitempty"`
 	LBSubSetConfig       LBSubsetConfig  `json:"lb_subset_config,omitempty"`
 	TLS                  TLSConfig       `json:"tls_context,omitempty"`
-	Hosts                []Host          `json:"hosts,omitempty"`
+	// TLSOverrides are named alternative tls contexts of the cluster,
+	// selected per route via the tls_override metadata key. The reserved
+	// name "disable" forces a plaintext connection
+	TLSOverrides    map[string]TLSConfig `json:"tls_overrides,omitempty"`
+	Hosts           []Host               `json:"hosts,omitempty"`
+	ConnPoolRetry   ConnPoolRetry        `json:"conn_pool_retry,omitempty"`
+	SocketOptions   *SocketOptions       `json:"socket_options,omitempty"`
+	HostUpdateGuard *HostUpdateGuard     `json:"host_update_guard,omitempty"`
+	Http1Settings   *Http1Settings       `json:"http1_settings,omitempty"`
+	Http2Settings   *Http2Settings       `json:"http2_settings,omitempty"`
+	LocalityLb      *LocalityLbConfig    `json:"locality_lb,omitempty"`
+}
+
+// LocalityLbConfig biases host selection towards preferred zones, e.g. to
+// favor same-AZ traffic over billable cross-AZ traffic. A host's zone is
+// read from its metadata "zone" key
+type LocalityLbConfig struct {
+	// LocalZone is the zone this mosn instance runs in, used to tell
+	// local from cross-zone requests apart in the cluster stats
+	LocalZone string `json:"local_zone,omitempty"`
+	// ZoneWeights are relative selection preferences per zone. A zone
+	// without an entry gets weight 1, weight 0 makes the zone a last
+	// resort used only when every weighted zone is unavailable
+	ZoneWeights map[string]uint32 `json:"zone_weights,omitempty"`
+}
+
+// HostUpdateGuard protects a cluster against control plane bugs that would
+// blackhole traffic, zero values disable the corresponding guard
+type HostUpdateGuard struct {
+	// MaxHosts rejects updates growing the cluster beyond this size
+	MaxHosts uint32 `json:"max_hosts,omitempty"`
+	// MaxRemovalPercent rejects (or warns, see WarnOnly) updates removing
+	// more than this percentage of the current hosts
+	MaxRemovalPercent uint32 `json:"max_removal_percent,omitempty"`
+	// WarnOnly turns the removal percentage guard into a warning log
+	WarnOnly bool `json:"warn_only,omitempty"`
+	// EmptyUpdateProtection rejects updates that empty a non-empty cluster
+	EmptyUpdateProtection bool `json:"empty_update_protection,omitempty"`
+}
+
+// Http1Settings tunes the http1 upstream connection pools of the cluster,
+// zero values keep the built-in defaults
+type Http1Settings struct {
+	// MaxIdleConnsPerHost caps the idle keep-alive connections kept per
+	// upstream host, 0 keeps every finished connection for reuse
+	MaxIdleConnsPerHost uint32 `json:"max_idle_conns_per_host,omitempty"`
+	// IdleTimeout discards pooled connections that stayed unused for this
+	// long, 0 keeps them until the peer closes
+	IdleTimeout DurationConfig `json:"idle_timeout,omitempty"`
+	// RetryOnReuseFailure resends a request when a reused keep-alive
+	// connection turns out to be closed by the upstream before any response
+	// byte arrived, such a request was never processed so resending is safe
+	// even without a route retry policy
+	RetryOnReuseFailure bool `json:"retry_on_reuse_failure,omitempty"`
+}
+
+// Http2Settings tunes the http2 upstream connections of the cluster, both
+// for tls pools and h2c (cleartext prior-knowledge) pools. Zero values keep
+// the built-in defaults
+type Http2Settings struct {
+	// MaxConcurrentStreams caps the streams multiplexed on the upstream
+	// connection, requests beyond the cap fail with an overflow
+	MaxConcurrentStreams uint32 `json:"max_concurrent_streams,omitempty"`
+	// InitialStreamWindowSize is the per-stream flow control window
+	// advertised to the upstream
+	InitialStreamWindowSize uint32 `json:"initial_stream_window_size,omitempty"`
+	// InitialConnWindowSize is the connection-level flow control window
+	// advertised to the upstream
+	InitialConnWindowSize uint32 `json:"initial_conn_window_size,omitempty"`
+	// KeepaliveInterval enables periodic PING frames on the idle connection,
+	// 0 disables keepalive
+	KeepaliveInterval DurationConfig `json:"keepalive_interval,omitempty"`
+	// KeepaliveTimeout closes the connection when a PING ack does not arrive
+	// in time, defaults to the interval
+	KeepaliveTimeout DurationConfig `json:"keepalive_timeout,omitempty"`
+}
+
+// ConnPoolRetry configures upstream host selection retry when connection
+// pool acquisition fails for the chosen host, zero values keep the
+// built-in defaults
+type ConnPoolRetry struct {
+	// Attempts is the number of hosts tried, default is 5
+	Attempts int `json:"attempts,omitempty"`
+	// AttemptTimeout bounds the wait for the tried pools to finish
+	// tcp handshaking, default is an exponential ladder of about 1.1s
+	AttemptTimeout DurationConfig `json:"attempt_timeout,omitempty"`
+	// ExcludeTried skips hosts that are already tried in this selection
+	ExcludeTried bool `json:"exclude_tried_hosts,omitempty"`
 }
 
 // HealthCheck is a configuration of health check
@@ -207,6 +303,29 @@ func (f *FaultInject) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// StreamXFCC configures the x-forwarded-client-cert header built from
+// the client certificate a mTLS listener verified. Inbound XFCC headers
+// are always removed, the elements below select what mosn forwards.
+type StreamXFCC struct {
+	Subject bool `json:"subject,omitempty"`
+	URI     bool `json:"uri,omitempty"`
+	Hash    bool `json:"hash,omitempty"`
+	Chain   bool `json:"chain,omitempty"`
+}
+
+// StreamRateLimit configures the local rate limit stream filter.
+// Requests are grouped by a descriptor built from the listed headers
+// and optionally the downstream remote address, each descriptor owns
+// a token bucket refilled at RequestsPerSecond up to Burst tokens.
+// MaxKeys bounds the descriptor table, extra descriptors share one bucket.
+type StreamRateLimit struct {
+	RequestsPerSecond uint32   `json:"requests_per_second,omitempty"`
+	Burst             uint32   `json:"burst,omitempty"`
+	KeyHeaders        []string `json:"key_headers,omitempty"`
+	KeyRemoteAddress  bool     `json:"key_remote_address,omitempty"`
+	MaxKeys           int      `json:"max_keys,omitempty"`
+}
+
 // StreamFaultInject
 type StreamFaultInject struct {
 	Delay           *DelayInject    `json:"delay,omitempty"`
@@ -238,6 +357,39 @@ type AbortInject struct {
 	Percent uint32 `json:"percentage,omitempty"`
 }
 
+// StreamResponseCorrupt is a testing only filter config that corrupts
+// upstream responses at a configured rate
+type StreamResponseCorrupt struct {
+	Percent         uint32 `json:"percentage,omitempty"`
+	Status          int    `json:"status,omitempty"`
+	TruncateBody    bool   `json:"truncate_body,omitempty"`
+	DropTrailers    bool   `json:"drop_trailers,omitempty"`
+	UpstreamCluster string `json:"upstream_cluster,omitempty"`
+}
+
+// StreamResponseValidate validates upstream responses against the configured
+// contract, violations are converted into a local error response
+type StreamResponseValidate struct {
+	AllowedContentTypes []string `json:"allowed_content_types,omitempty"`
+	MaxBodyBytes        uint64   `json:"max_body_bytes,omitempty"`
+	RequiredHeaders     []string `json:"required_headers,omitempty"`
+	ViolationStatus     int      `json:"violation_status,omitempty"` // default 502
+	UpstreamCluster     string   `json:"upstream_cluster,omitempty"`
+}
+
+// StreamRequestSign signs outbound requests before they leave the sidecar,
+// so services calling signature protected APIs need no signing code of
+// their own. Exactly one of Key, KeyEnv and KeyFile must be set
+type StreamRequestSign struct {
+	Algorithm     string   `json:"algorithm,omitempty"` // hmac-sha256 (default) or hmac-sha1
+	Key           string   `json:"key,omitempty"`       // inline secret
+	KeyEnv        string   `json:"key_env,omitempty"`   // environment variable holding the secret
+	KeyFile       string   `json:"key_file,omitempty"`  // file holding the secret
+	SignedHeaders []string `json:"signed_headers,omitempty"`
+	SignBody      bool     `json:"sign_body,omitempty"`
+	HeaderName    string   `json:"header_name,omitempty"` // default x-mosn-signature
+}
+
 type Mixer struct {
 	client.HttpClientConfig
 }
@@ -309,6 +461,16 @@ func (cw *ClusterWeight) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// ShadowPolicy represents the mirror parameters, requests matched by the
+// route are duplicated to the shadow cluster and the mirrored responses
+// are discarded
+type ShadowPolicy struct {
+	Cluster string `json:"cluster,omitempty"`
+	// Percent of the matched requests to mirror, 0 or an invalid value
+	// (above 100) mirrors everything
+	Percent uint32 `json:"percent,omitempty"`
+}
+
 // RetryPolicy represents the retry parameters
 type RetryPolicy struct {
 	RetryPolicyConfig
@@ -370,22 +532,34 @@ type LBSubsetConfig struct {
 
 // TLSConfig is a configuration of tls context
 type TLSConfig struct {
-	Status       bool                   `json:"status,omitempty"`
-	Type         string                 `json:"type,omitempty"`
-	ServerName   string                 `json:"server_name,omitempty"`
-	CACert       string                 `json:"ca_cert,omitempty"`
-	CertChain    string                 `json:"cert_chain,omitempty"`
-	PrivateKey   string                 `json:"private_key,omitempty"`
-	VerifyClient bool                   `json:"verify_client,omitempty"`
-	InsecureSkip bool                   `json:"insecure_skip,omitempty"`
-	CipherSuites string                 `json:"cipher_suites,omitempty"`
-	EcdhCurves   string                 `json:"ecdh_curves,omitempty"`
-	MinVersion   string                 `json:"min_version,omitempty"`
-	MaxVersion   string                 `json:"max_version,omitempty"`
-	ALPN         string                 `json:"alpn,omitempty"`
-	Ticket       string                 `json:"ticket,omitempty"`
-	Fallback     bool                   `json:"fall_back, omitempty"`
-	ExtendVerify map[string]interface{} `json:"extend_verify,omitempty"`
+	Status       bool   `json:"status,omitempty"`
+	Type         string `json:"type,omitempty"`
+	ServerName   string `json:"server_name,omitempty"`
+	CACert       string `json:"ca_cert,omitempty"`
+	CertChain    string `json:"cert_chain,omitempty"`
+	PrivateKey   string `json:"private_key,omitempty"`
+	VerifyClient bool   `json:"verify_client,omitempty"`
+	InsecureSkip bool   `json:"insecure_skip,omitempty"`
+	CipherSuites string `json:"cipher_suites,omitempty"`
+	EcdhCurves   string `json:"ecdh_curves,omitempty"`
+	MinVersion   string `json:"min_version,omitempty"`
+	MaxVersion   string `json:"max_version,omitempty"`
+	ALPN         string `json:"alpn,omitempty"`
+	Ticket       string `json:"ticket,omitempty"`
+	// Renegotiation controls whether TLS renegotiation is permitted.
+	// Valid values are "never" (the default) and, for client contexts,
+	// "once" and "freely". Server contexts always reject renegotiation
+	// and post-handshake authentication, rejected attempts are counted
+	Renegotiation string `json:"renegotiation,omitempty"`
+	// OCSPStaple is the path of a der encoded ocsp response that is
+	// stapled into handshakes for the served certificate. The file is
+	// watched, a refreshed response is picked up without a restart
+	OCSPStaple string `json:"ocsp_staple,omitempty"`
+	// OCSPStapleRequired makes a missing or unreadable staple a hard
+	// configuration error instead of serving without one
+	OCSPStapleRequired bool                   `json:"ocsp_staple_required,omitempty"`
+	Fallback           bool                   `json:"fall_back, omitempty"`
+	ExtendVerify       map[string]interface{} `json:"extend_verify,omitempty"`
 }
 
 // AccessLog for making up access log
@@ -435,15 +609,52 @@ type Filter struct {
 	Config map[string]interface{} `json:"config,omitempty"`
 }
 
+// ProtocolDemux routes a connection to one of the per protocol network
+// filter stacks based on the application protocol detected from the
+// first bytes, so one listener port can serve heterogeneous clients
+type ProtocolDemux struct {
+	Protocols map[string][]Filter `json:"protocols,omitempty"`
+}
+
 // Implements of filter config
 
 // TCPProxy
 type TCPProxy struct {
-	StatPrefix         string         `json:"stat_prefix,omitempty"`
-	Cluster            string         `json:"cluster,omitempty"`
-	IdleTimeout        *time.Duration `json:"idle_timeout,omitempty"`
-	MaxConnectAttempts uint32         `json:"max_connect_attempts,omitempty"`
-	Routes             []*TCPRoute    `json:"routes,omitempty"`
+	StatPrefix         string             `json:"stat_prefix,omitempty"`
+	Cluster            string             `json:"cluster,omitempty"`
+	WeightedClusters   []*WeightedCluster `json:"weighted_clusters,omitempty"`
+	IdleTimeout        *time.Duration     `json:"idle_timeout,omitempty"`
+	MaxConnectAttempts uint32             `json:"max_connect_attempts,omitempty"`
+	Routes             []*TCPRoute        `json:"routes,omitempty"`
+	Persistence        *TCPPersistence    `json:"persistence,omitempty"`
+}
+
+// TCPPersistence pins a client ip to the upstream host it was first
+// routed to, so stateful tcp apps keep hitting the same backend across
+// reconnects. Entries expire after TTL, MaxEntries bounds the table.
+type TCPPersistence struct {
+	TTL        DurationConfig `json:"ttl,omitempty"`
+	MaxEntries int            `json:"max_entries,omitempty"`
+}
+
+// RedisProxy routes resp commands to upstream redis clusters. Read only
+// commands go to ReadCluster when it is set, everything else goes to
+// Cluster. MaxRedirections bounds MOVED/ASK redirection hops per command,
+// zero disables redirection handling
+type RedisProxy struct {
+	StatPrefix      string `json:"stat_prefix,omitempty"`
+	Cluster         string `json:"cluster,omitempty"`
+	ReadCluster     string `json:"read_cluster,omitempty"`
+	MaxRedirections int    `json:"max_redirections,omitempty"`
+}
+
+// MQTTProxy pipes mqtt 3.1.1 and 5.0 clients to upstream brokers. The
+// upstream host is selected by the cluster's load balancer with the
+// client identifier as the hash key, so a reconnecting client lands on
+// the broker holding its session
+type MQTTProxy struct {
+	StatPrefix string `json:"stat_prefix,omitempty"`
+	Cluster    string `json:"cluster,omitempty"`
 }
 
 // WebSocketProxy
@@ -461,6 +672,15 @@ type Proxy struct {
 	RouterConfigName   string                 `json:"router_config_name,omitempty"`
 	ValidateClusters   bool                   `json:"validate_clusters,omitempty"`
 	ExtendConfig       map[string]interface{} `json:"extend_config,omitempty"`
+	StreamLimits       ProxyStreamLimits      `json:"stream_limits,omitempty"`
+}
+
+// ProxyStreamLimits bounds the streams a single downstream connection may
+// open on multiplexed protocols, a connection over limit is reset.
+// Zero values mean no limit.
+type ProxyStreamLimits struct {
+	MaxConcurrentStreams uint32 `json:"max_concurrent_streams,omitempty"`
+	NewStreamsPerSecond  uint32 `json:"new_streams_per_second,omitempty"`
 }
 
 // HeaderValueOption is header name/value pair plus option to control append behavior.
@@ -658,8 +878,42 @@ type ServerConfig struct {
 	//graceful shutdown config
 	GracefulTimeout DurationConfig `json:"graceful_timeout,omitempty"`
 
+	// ListenerBleedOffTime defers dynamic listener deletion: the accept
+	// loop stops immediately, established connections get this long to
+	// finish before the force close. Zero deletes immediately
+	ListenerBleedOffTime DurationConfig `json:"listener_bleed_off_time,omitempty"`
+
 	//go processor number
 	Processor int `json:"processor,omitempty"`
 
+	// global connection budget over all listeners, 0 means no limit
+	MaxConnections uint64 `json:"max_connections,omitempty"`
+
+	// accept goroutine pool size, 0 keeps a goroutine per accepted connection
+	AcceptPoolSize int `json:"accept_pool_size,omitempty"`
+	// AcceptPoolOverflow selects the behavior when the accept pool is
+	// saturated: "queue" (default) blocks the accept loop, "reject"
+	// closes the connection immediately
+	AcceptPoolOverflow string `json:"accept_pool_overflow,omitempty"`
+
+	// ListenerDefaults are inherited by every listener of this server that
+	// does not set the corresponding field itself
+	ListenerDefaults *ListenerDefaults `json:"listener_defaults,omitempty"`
+
 	Listeners []Listener `json:"listeners,omitempty"`
 }
+
+// ListenerDefaults is a listener template, gateways declaring many similar
+// ports configure the shared parts once and each listener only overrides
+// what differs. A listener field wins whenever it is set
+type ListenerDefaults struct {
+	AccessLogs     []AccessLog    `json:"access_logs,omitempty"`
+	FilterChains   []FilterChain  `json:"filter_chains,omitempty"`
+	StreamFilters  []Filter       `json:"stream_filters,omitempty"`
+	Inspector      bool           `json:"inspector,omitempty"`
+	MaxConnections uint64         `json:"max_connections,omitempty"`
+	SocketOptions  *SocketOptions `json:"socket_options,omitempty"`
+	// TLSContext is the tls baseline, applied to listener filter chains
+	// that configure no tls context of their own
+	TLSContext *TLSConfig `json:"tls_context,omitempty"`
+}
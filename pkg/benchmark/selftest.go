@@ -0,0 +1,186 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package benchmark drives synthetic load through mosn's own routing and
+// cluster hot paths without any network involved, so a regression in the
+// per-request overhead is measurable on a running instance with a single
+// admin call instead of an external load generator.
+package benchmark
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"sofastack.io/sofa-mosn/pkg/admin/server"
+	"sofastack.io/sofa-mosn/pkg/buffer"
+	"sofastack.io/sofa-mosn/pkg/protocol"
+	"sofastack.io/sofa-mosn/pkg/router"
+	"sofastack.io/sofa-mosn/pkg/upstream/cluster"
+)
+
+func init() {
+	server.RegisterAdminHandleFunc("/api/v1/selftest", runSelfTest)
+}
+
+const (
+	defaultIterations = 10000
+	maxIterations     = 1000000
+)
+
+// stageResult reports one hot path stage. Allocation numbers are process
+// wide deltas, run the selftest on an otherwise idle instance for exact
+// figures
+type stageResult struct {
+	Iterations  int    `json:"iterations"`
+	NsPerOp     int64  `json:"ns_per_op"`
+	AllocsPerOp uint64 `json:"allocs_per_op"`
+	BytesPerOp  uint64 `json:"bytes_per_op"`
+	Skipped     string `json:"skipped,omitempty"`
+}
+
+// runSelfTest handles /api/v1/selftest. Query parameters:
+//
+//	iterations: loop count per stage, default 10000
+//	router:     router config name for the route match stage
+//	cluster:    cluster name for the snapshot and host selection stages
+//	header_X=Y: synthetic request headers the route match runs on
+func runSelfTest(w http.ResponseWriter, r *http.Request) {
+	iterations := defaultIterations
+	if v := r.URL.Query().Get("iterations"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("invalid iterations\n"))
+			return
+		}
+		if n > maxIterations {
+			n = maxIterations
+		}
+		iterations = n
+	}
+
+	headers := protocol.CommonHeader{}
+	for key, values := range r.URL.Query() {
+		if name := strings.TrimPrefix(key, "header_"); name != key && len(values) > 0 {
+			headers[name] = values[0]
+		}
+	}
+
+	results := map[string]stageResult{
+		"route_match":      measureRouteMatch(iterations, r.URL.Query().Get("router"), headers),
+		"cluster_snapshot": measureClusterSnapshot(iterations, r.URL.Query().Get("cluster")),
+		"host_select":      measureHostSelect(iterations, r.URL.Query().Get("cluster")),
+		"buffer_pool":      measureBufferPool(iterations),
+	}
+
+	buf, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf)
+}
+
+// measure runs fn iterations times and reports wall time and allocation
+// deltas per operation
+func measure(iterations int, fn func()) stageResult {
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+
+	for i := 0; i < iterations; i++ {
+		fn()
+	}
+
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	return stageResult{
+		Iterations:  iterations,
+		NsPerOp:     elapsed.Nanoseconds() / int64(iterations),
+		AllocsPerOp: (after.Mallocs - before.Mallocs) / uint64(iterations),
+		BytesPerOp:  (after.TotalAlloc - before.TotalAlloc) / uint64(iterations),
+	}
+}
+
+func measureRouteMatch(iterations int, routerName string, headers protocol.CommonHeader) stageResult {
+	if routerName == "" {
+		return stageResult{Skipped: "no router config name given"}
+	}
+	wrapper := router.GetRoutersMangerInstance().GetRouterWrapperByName(routerName)
+	if wrapper == nil {
+		return stageResult{Skipped: "router config not found: " + routerName}
+	}
+	routers := wrapper.GetRouters()
+	return measure(iterations, func() {
+		routers.MatchRoute(headers, 0)
+	})
+}
+
+func measureClusterSnapshot(iterations int, clusterName string) stageResult {
+	if clusterName == "" {
+		return stageResult{Skipped: "no cluster name given"}
+	}
+	cm := cluster.GetClusterMngAdapterInstance()
+	if cm == nil {
+		return stageResult{Skipped: "cluster manager not initialized"}
+	}
+	ctx := context.Background()
+	snapshot := cm.GetClusterSnapshot(ctx, clusterName)
+	if snapshot == nil {
+		return stageResult{Skipped: "cluster not found: " + clusterName}
+	}
+	cm.PutClusterSnapshot(snapshot)
+
+	return measure(iterations, func() {
+		s := cm.GetClusterSnapshot(ctx, clusterName)
+		cm.PutClusterSnapshot(s)
+	})
+}
+
+func measureHostSelect(iterations int, clusterName string) stageResult {
+	if clusterName == "" {
+		return stageResult{Skipped: "no cluster name given"}
+	}
+	cm := cluster.GetClusterMngAdapterInstance()
+	if cm == nil {
+		return stageResult{Skipped: "cluster manager not initialized"}
+	}
+	snapshot := cm.GetClusterSnapshot(context.Background(), clusterName)
+	if snapshot == nil {
+		return stageResult{Skipped: "cluster not found: " + clusterName}
+	}
+	defer cm.PutClusterSnapshot(snapshot)
+
+	lb := snapshot.LoadBalancer()
+	return measure(iterations, func() {
+		lb.ChooseHost(nil)
+	})
+}
+
+func measureBufferPool(iterations int) stageResult {
+	return measure(iterations, func() {
+		buf := buffer.GetIoBuffer(1 << 10)
+		buffer.PutIoBuffer(buf)
+	})
+}
@@ -0,0 +1,75 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package benchmark
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMeasureReportsPerOp(t *testing.T) {
+	count := 0
+	res := measure(100, func() {
+		count++
+	})
+	if count != 100 {
+		t.Errorf("measure ran %d iterations, want 100", count)
+	}
+	if res.Iterations != 100 {
+		t.Errorf("unexpected iteration report: %d", res.Iterations)
+	}
+}
+
+func TestRunSelfTest(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/v1/selftest?iterations=100", nil)
+	w := httptest.NewRecorder()
+
+	runSelfTest(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+	results := map[string]stageResult{}
+	if err := json.Unmarshal(w.Body.Bytes(), &results); err != nil {
+		t.Fatalf("response is not json: %v", err)
+	}
+	// no router or cluster configured in this test, those stages skip
+	if results["route_match"].Skipped == "" {
+		t.Error("route_match should be skipped without a router name")
+	}
+	if results["cluster_snapshot"].Skipped == "" {
+		t.Error("cluster_snapshot should be skipped without a cluster name")
+	}
+	// the buffer stage always runs
+	if results["buffer_pool"].Skipped != "" || results["buffer_pool"].Iterations != 100 {
+		t.Errorf("buffer_pool stage did not run: %+v", results["buffer_pool"])
+	}
+}
+
+func TestRunSelfTestInvalidIterations(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/v1/selftest?iterations=bogus", nil)
+	w := httptest.NewRecorder()
+
+	runSelfTest(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("unexpected status: %d", w.Code)
+	}
+}
@@ -25,11 +25,11 @@ import (
 	"path"
 	"time"
 
-	"sofastack.io/sofa-mosn/pkg/api/v2"
-	"sofastack.io/sofa-mosn/pkg/utils"
 	"github.com/c2h5oh/datasize"
 	xdsboot "github.com/envoyproxy/go-control-plane/envoy/config/bootstrap/v2"
 	"github.com/gogo/protobuf/jsonpb"
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+	"sofastack.io/sofa-mosn/pkg/utils"
 )
 
 type ContentKey string
@@ -38,6 +38,12 @@ type ContentKey string
 type TracingConfig struct {
 	Enable bool   `json:"enable"`
 	Tracer string `json:"tracer"`
+	// SampleRate is the trace sample rate in [0, 1], 0 means never
+	// and 1 means always, default is 1
+	SampleRate *float64 `json:"sample_rate,omitempty"`
+	// CollectorEndpoint is the span collector address for tracers
+	// reporting to an external collector, e.g. zipkin
+	CollectorEndpoint string `json:"collector_endpoint,omitempty"`
 }
 
 // MetricsConfig for metrics sinks
@@ -62,6 +68,9 @@ type ClusterManagerConfigJson struct {
 	RegistryUseHealthCheck bool         `json:"registry_use_health_check,omitempty"`
 	ClusterConfigPath      string       `json:"clusters_configs,omitempty"`
 	ClustersJson           []v2.Cluster `json:"clusters,omitempty"`
+	// NAT64Prefix enables nat64 address synthesis for ipv6-only nodes,
+	// ipv4 upstream addresses are embedded into this /96 prefix
+	NAT64Prefix string `json:"nat64_prefix,omitempty"`
 }
 
 func (cc *ClusterManagerConfig) UnmarshalJSON(b []byte) error {
@@ -146,13 +155,49 @@ type MOSNConfig struct {
 	ClusterManager  ClusterManagerConfig   `json:"cluster_manager,omitempty"` //cluster config
 	ServiceRegistry v2.ServiceRegistryInfo `json:"service_registry"`          //service registry config, used by service discovery module
 	//tracing config
-	Tracing             TracingConfig   `json:"tracing"`
-	Metrics             MetricsConfig   `json:"metrics"`
-	RawDynamicResources json.RawMessage `json:"dynamic_resources,omitempty"` //dynamic_resources raw message
-	RawStaticResources  json.RawMessage `json:"static_resources,omitempty"`  //static_resources raw message
-	RawAdmin            json.RawMessage `json:"admin,omitempty"`             // admin raw message
-	Debug               PProfConfig     `json:"pprof,omitempty"`
-	Pid                 string          `json:"pid,omitempty"` // pid file
+	Tracing             TracingConfig     `json:"tracing"`
+	Metrics             MetricsConfig     `json:"metrics"`
+	FlightRecorder      FlightRecorderConfig `json:"flight_recorder,omitempty"`
+	RawDynamicResources json.RawMessage   `json:"dynamic_resources,omitempty"` //dynamic_resources raw message
+	RawStaticResources  json.RawMessage   `json:"static_resources,omitempty"`  //static_resources raw message
+	RawAdmin            json.RawMessage   `json:"admin,omitempty"`             // admin raw message
+	Debug               PProfConfig       `json:"pprof,omitempty"`
+	Pid                 string            `json:"pid,omitempty"`         // pid file
+	InheritFds          []uint            `json:"inherit_fds,omitempty"` // listening socket fds inherited from a supervisor
+	Runtime             map[string]string `json:"runtime,omitempty"`     // static runtime layer, rtds layers override it
+	ListenerBindPolicy  []BindPolicyRuleConfig `json:"listener_bind_policy,omitempty"` // restricts dynamic listener binds
+	Sandbox             SandboxConfig     `json:"sandbox,omitempty"` // minimal privileges hardening mode
+}
+
+// BindPolicyRuleConfig is one ordered rule restricting the addresses
+// dynamic sources such as xds and the admin api may bind listeners on.
+// The first matching rule wins, everything is allowed when no rule
+// matches or the policy is empty
+type BindPolicyRuleConfig struct {
+	Action string `json:"action"`          // allow or deny
+	Cidr   string `json:"cidr,omitempty"`  // default is every address
+	Ports  string `json:"ports,omitempty"` // e.g. "80,443,8000-9000", default is every port
+}
+
+// FlightRecorderConfig enables the always-on request flight recorder,
+// a time based sample of requests written with full detail to a
+// rotating binary file for offline decoding
+type FlightRecorderConfig struct {
+	Enable           bool   `json:"enable"`
+	Path             string `json:"path,omitempty"`               // default is flight.record under the log base path
+	SampleIntervalMs int    `json:"sample_interval_ms,omitempty"` // default is 1000, one request per second
+	MaxFileSizeMb    int    `json:"max_file_size_mb,omitempty"`   // default is 64
+	MaxFiles         int    `json:"max_files,omitempty"`          // default is 4
+}
+
+// SandboxConfig enables the minimal privileges hardening mode: after
+// listeners are bound and certificates are loaded, unneeded linux
+// capabilities are dropped and runtime file writes outside the allowed
+// paths are refused. The startup log reports what was applied
+type SandboxConfig struct {
+	Enable            bool     `json:"enable"`
+	KeepCapabilities  []string `json:"keep_capabilities,omitempty"`   // default is net_bind_service
+	AllowedWritePaths []string `json:"allowed_write_paths,omitempty"` // log and config dump paths are always allowed
 }
 
 // PProfConfig is used to start a pprof server for debug
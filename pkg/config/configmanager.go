@@ -18,6 +18,10 @@
 package config
 
 import (
+	"fmt"
+	"strings"
+
+	"sofastack.io/sofa-mosn/pkg/admin/audit"
 	"sofastack.io/sofa-mosn/pkg/api/v2"
 	"sofastack.io/sofa-mosn/pkg/log"
 )
@@ -57,12 +61,25 @@ func ResetServiceRegistryInfo(appInfo v2.ApplicationInfo, subServiceList []strin
 
 	// delete subInfo / dynamic clusters
 	RemoveClusterConfig(subServiceList)
+	audit.Record(audit.SourceRegistry, "reset_service_registry", appInfo.AppName,
+		fmt.Sprintf("%d subscribed services removed", len(subServiceList)), nil)
+}
+
+// clusterNames is a short resource summary for the audit log
+func clusterNames(clusters []v2.Cluster) string {
+	names := make([]string, 0, len(clusters))
+	for _, c := range clusters {
+		names = append(names, c.Name)
+	}
+	return strings.Join(names, ",")
 }
 
 // AddOrUpdateClusterConfig
 // called when add cluster config info received
 func AddOrUpdateClusterConfig(clusters []v2.Cluster) {
 	addOrUpdateClusterConfig(clusters)
+	audit.Record(audit.SourceRegistry, "update_cluster", clusterNames(clusters),
+		fmt.Sprintf("%d clusters", len(clusters)), nil)
 	dump(true)
 }
 
@@ -94,6 +111,8 @@ func addOrUpdateClusterConfig(clusters []v2.Cluster) {
 
 func RemoveClusterConfig(clusterNames []string) {
 	if removeClusterConfig(clusterNames) {
+		audit.Record(audit.SourceRegistry, "remove_cluster", strings.Join(clusterNames, ","),
+			fmt.Sprintf("%d clusters", len(clusterNames)), nil)
 		dump(true)
 	}
 }
@@ -141,6 +160,8 @@ func AddPubInfo(pubInfoAdded map[string]string) {
 		}
 	}
 
+	audit.Record(audit.SourceRegistry, "add_pub_info", "",
+		fmt.Sprintf("%d services", len(pubInfoAdded)), nil)
 	dump(true)
 }
 
@@ -158,6 +179,9 @@ func DelPubInfo(serviceName string) {
 		}
 	}
 
+	if dirty {
+		audit.Record(audit.SourceRegistry, "del_pub_info", serviceName, "", nil)
+	}
 	dump(dirty)
 }
 
@@ -166,6 +190,8 @@ func DelPubInfo(serviceName string) {
 func AddClusterWithRouter(listenername string, clusters []v2.Cluster, routerConfig *v2.RouterConfiguration) {
 	addOrUpdateClusterConfig(clusters)
 	addOrUpdateRouterConfig(listenername, routerConfig)
+	audit.Record(audit.SourceRegistry, "update_cluster_with_router", listenername,
+		fmt.Sprintf("%d clusters: %s", len(clusters), clusterNames(clusters)), nil)
 	dump(true)
 }
 
@@ -190,6 +216,7 @@ func updateListener(idx int, ln v2.Listener) {
 // AddOrUpdateRouterConfig update the connection_manager's config
 func AddOrUpdateRouterConfig(listenername string, routerConfig *v2.RouterConfiguration) {
 	if addOrUpdateRouterConfig(listenername, routerConfig) {
+		audit.Record(audit.SourceRegistry, "update_router", listenername, "", nil)
 		dump(true)
 	}
 }
@@ -209,6 +236,7 @@ func addOrUpdateRouterConfig(listenername string, routerConfig *v2.RouterConfigu
 // AddOrUpdateStreamFilters update the stream filters config
 func AddOrUpdateStreamFilters(listenername string, typ string, cfg map[string]interface{}) {
 	if addOrUpdateStreamFilters(listenername, typ, cfg) {
+		audit.Record(audit.SourceRegistry, "update_stream_filter", listenername, "filter "+typ, nil)
 		dump(true)
 	}
 }
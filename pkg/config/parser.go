@@ -49,6 +49,12 @@ const (
 	DefaultConnBufferLimitBytes = uint32(16 * 1024)
 )
 
+// ProtocolSupported returns whether the protocol is in protocolsSupported
+func ProtocolSupported(key string) bool {
+	_, ok := protocolsSupported[key]
+	return ok
+}
+
 // RegisterProtocolParser
 // used to register parser
 func RegisterProtocolParser(key string) bool {
@@ -166,11 +172,56 @@ func ParseLogLevel(level string) log.Level {
 	return log.INFO
 }
 
+// ApplyListenerDefaults fills listener fields that are not set from the
+// server's listener defaults, must be called before ParseListenerConfig
+func ApplyListenerDefaults(lc *v2.Listener, def *v2.ListenerDefaults) {
+	if def == nil {
+		return
+	}
+	if len(lc.AccessLogs) == 0 {
+		lc.AccessLogs = def.AccessLogs
+	}
+	if len(lc.FilterChains) == 0 && len(def.FilterChains) > 0 {
+		// copy the chains, the tls baseline below edits them per listener
+		lc.FilterChains = make([]v2.FilterChain, len(def.FilterChains))
+		copy(lc.FilterChains, def.FilterChains)
+	}
+	if len(lc.StreamFilters) == 0 {
+		lc.StreamFilters = def.StreamFilters
+	}
+	if def.Inspector {
+		lc.Inspector = true
+	}
+	if lc.MaxConnections == 0 {
+		lc.MaxConnections = def.MaxConnections
+	}
+	if lc.SocketOptions == nil {
+		lc.SocketOptions = def.SocketOptions
+	}
+	if def.TLSContext != nil {
+		for i := range lc.FilterChains {
+			chain := &lc.FilterChains[i]
+			if chain.TLSConfig == nil && len(chain.TLSConfigs) == 0 {
+				chain.TLSContexts = []v2.TLSConfig{*def.TLSContext}
+			}
+		}
+	}
+}
+
 // ParseListenerConfig
 func ParseListenerConfig(lc *v2.Listener, inheritListeners []net.Listener) *v2.Listener {
 	if lc.AddrConfig == "" {
 		log.StartLogger.Fatalln("[config] [parse listener] Address is required in listener config")
 	}
+	// unix domain socket, only supported on local listeners
+	if path := strings.TrimPrefix(lc.AddrConfig, "unix:"); path != lc.AddrConfig {
+		if !lc.Local {
+			log.StartLogger.Fatalln("[config] [parse listener] unix address requires a local listener:", lc.AddrConfig)
+		}
+		lc.Addr = &net.UnixAddr{Name: path, Net: "unix"}
+		lc.PerConnBufferLimitBytes = 1 << 15
+		return lc
+	}
 	addr, err := net.ResolveTCPAddr("tcp", lc.AddrConfig)
 	if err != nil {
 		log.StartLogger.Fatalln("[config] [parse listener] Address not valid:", lc.AddrConfig)
@@ -202,6 +253,10 @@ func ParseListenerConfig(lc *v2.Listener, inheritListeners []net.Listener) *v2.L
 		}
 	}
 
+	if lc.Local && !addr.IP.IsLoopback() {
+		log.StartLogger.Fatalln("[config] [parse listener] local listener must bind a loopback address:", lc.AddrConfig)
+	}
+
 	lc.Addr = addr
 	lc.PerConnBufferLimitBytes = 1 << 15
 	lc.InheritListener = old
@@ -276,6 +331,87 @@ func ParseStreamFaultInjectFilter(cfg map[string]interface{}) (*v2.StreamFaultIn
 	return filterConfig, nil
 }
 
+// ParseStreamRateLimitFilter
+func ParseStreamRateLimitFilter(cfg map[string]interface{}) (*v2.StreamRateLimit, error) {
+	filterConfig := &v2.StreamRateLimit{}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, filterConfig); err != nil {
+		return nil, err
+	}
+	if filterConfig.RequestsPerSecond == 0 {
+		return nil, fmt.Errorf("rate limit filter needs a requests_per_second larger than zero")
+	}
+	return filterConfig, nil
+}
+
+// ParseStreamXFCCFilter
+func ParseStreamXFCCFilter(cfg map[string]interface{}) (*v2.StreamXFCC, error) {
+	filterConfig := &v2.StreamXFCC{}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, filterConfig); err != nil {
+		return nil, err
+	}
+	return filterConfig, nil
+}
+
+// ParseProtocolDemuxFilter
+func ParseProtocolDemuxFilter(cfg map[string]interface{}) (*v2.ProtocolDemux, error) {
+	filterConfig := &v2.ProtocolDemux{}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, filterConfig); err != nil {
+		return nil, err
+	}
+	return filterConfig, nil
+}
+
+// ParseStreamResponseCorruptFilter
+func ParseStreamResponseCorruptFilter(cfg map[string]interface{}) (*v2.StreamResponseCorrupt, error) {
+	filterConfig := &v2.StreamResponseCorrupt{}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, filterConfig); err != nil {
+		return nil, err
+	}
+	return filterConfig, nil
+}
+
+// ParseStreamResponseValidateFilter
+func ParseStreamResponseValidateFilter(cfg map[string]interface{}) (*v2.StreamResponseValidate, error) {
+	filterConfig := &v2.StreamResponseValidate{}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, filterConfig); err != nil {
+		return nil, err
+	}
+	return filterConfig, nil
+}
+
+// ParseStreamRequestSignFilter
+func ParseStreamRequestSignFilter(cfg map[string]interface{}) (*v2.StreamRequestSign, error) {
+	filterConfig := &v2.StreamRequestSign{}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, filterConfig); err != nil {
+		return nil, err
+	}
+	return filterConfig, nil
+}
+
 // ParseMixerFilter
 func ParseMixerFilter(cfg map[string]interface{}) *v2.Mixer {
 	mixerFilter := &v2.Mixer{}
@@ -318,6 +454,32 @@ func ParseTCPProxy(cfg map[string]interface{}) (*v2.TCPProxy, error) {
 	return proxy, nil
 }
 
+func ParseRedisProxy(cfg map[string]interface{}) (*v2.RedisProxy, error) {
+	proxy := &v2.RedisProxy{}
+	if data, err := json.Marshal(cfg); err == nil {
+		json.Unmarshal(data, proxy)
+	} else {
+		return nil, fmt.Errorf("[config] config is not a redis proxy config: %v", err)
+	}
+	if proxy.Cluster == "" {
+		return nil, fmt.Errorf("[config] redis proxy needs a cluster")
+	}
+	return proxy, nil
+}
+
+func ParseMQTTProxy(cfg map[string]interface{}) (*v2.MQTTProxy, error) {
+	proxy := &v2.MQTTProxy{}
+	if data, err := json.Marshal(cfg); err == nil {
+		json.Unmarshal(data, proxy)
+	} else {
+		return nil, fmt.Errorf("[config] config is not a mqtt proxy config: %v", err)
+	}
+	if proxy.Cluster == "" {
+		return nil, fmt.Errorf("[config] mqtt proxy needs a cluster")
+	}
+	return proxy, nil
+}
+
 func ParseServiceRegistry(src v2.ServiceRegistryInfo) {
 	//trigger all callbacks
 	if cbs, ok := configParsedCBMaps[ParseCallbackKeyServiceRgtInfo]; ok {
@@ -242,6 +242,74 @@ func TestParseListenerConfig(t *testing.T) {
 	}
 }
 
+func TestParseListenerConfigLocalUnix(t *testing.T) {
+	lc := &v2.Listener{
+		ListenerConfig: v2.ListenerConfig{
+			AddrConfig: "unix:/tmp/mosn_local.sock",
+			Local:      true,
+		},
+	}
+	ln := ParseListenerConfig(lc, nil)
+	uaddr, ok := ln.Addr.(*net.UnixAddr)
+	if !ok {
+		t.Fatalf("unix address parse unexpected: %v", ln.Addr)
+	}
+	if uaddr.Name != "/tmp/mosn_local.sock" || uaddr.Net != "unix" {
+		t.Errorf("unix address parse unexpected: %v", uaddr)
+	}
+	if ln.PerConnBufferLimitBytes != 1<<15 {
+		t.Errorf("unexpected buffer limit: %d", ln.PerConnBufferLimitBytes)
+	}
+}
+
+func TestApplyListenerDefaults(t *testing.T) {
+	def := &v2.ListenerDefaults{
+		StreamFilters:  []v2.Filter{{Type: "healthcheck"}},
+		MaxConnections: 1024,
+		Inspector:      true,
+		FilterChains: []v2.FilterChain{
+			{FilterChainConfig: v2.FilterChainConfig{Filters: []v2.Filter{{Type: "proxy"}}}},
+		},
+		TLSContext: &v2.TLSConfig{Status: true, ServerName: "gw.example.com"},
+	}
+
+	// a bare listener inherits everything
+	lc := &v2.Listener{}
+	ApplyListenerDefaults(lc, def)
+	if len(lc.StreamFilters) != 1 || lc.MaxConnections != 1024 || !lc.Inspector {
+		t.Errorf("defaults not inherited: %+v", lc.ListenerConfig)
+	}
+	if len(lc.FilterChains) != 1 || len(lc.FilterChains[0].Filters) != 1 {
+		t.Fatalf("filter chains not inherited: %+v", lc.FilterChains)
+	}
+	if tls := lc.FilterChains[0].TLSContexts; len(tls) != 1 || tls[0].ServerName != "gw.example.com" {
+		t.Errorf("tls baseline not applied: %+v", tls)
+	}
+
+	// listener level settings win over the template
+	lc = &v2.Listener{
+		ListenerConfig: v2.ListenerConfig{
+			MaxConnections: 16,
+			StreamFilters:  []v2.Filter{{Type: "faultinject"}},
+			FilterChains: []v2.FilterChain{
+				{FilterChainConfig: v2.FilterChainConfig{
+					TLSConfig: &v2.TLSConfig{Status: true, ServerName: "own.example.com"},
+				}},
+			},
+		},
+	}
+	ApplyListenerDefaults(lc, def)
+	if lc.MaxConnections != 16 || lc.StreamFilters[0].Type != "faultinject" {
+		t.Errorf("listener overrides lost: %+v", lc.ListenerConfig)
+	}
+	if len(lc.FilterChains[0].TLSContexts) != 0 {
+		t.Errorf("tls baseline must not override a chain with its own tls config")
+	}
+
+	// nil defaults is a no-op
+	ApplyListenerDefaults(lc, nil)
+}
+
 func TestParseProxyFilter(t *testing.T) {
 	proxyConfigStr := `{
 		"name": "proxy",
@@ -22,6 +22,7 @@ import (
 	"sofastack.io/sofa-mosn/pkg/types"
 	"math/rand"
 	"testing"
+	"time"
 )
 
 const testNodeNum = 10
@@ -54,6 +55,38 @@ func TestSetGet(t *testing.T) {
 
 }
 
+func TestWithDeadline(t *testing.T) {
+	expected := "egress"
+	ctx := WithValue(context.Background(), types.ContextKeyListenerType, expected)
+
+	deadline := time.Now().Add(50 * time.Millisecond)
+	ctx, cancel := WithDeadline(ctx, deadline)
+	defer cancel()
+
+	// builtin pairs stay reachable on the deadline context
+	if listenerType, ok := Get(ctx, types.ContextKeyListenerType).(string); !ok || listenerType != expected {
+		t.Errorf("builtin value lost after WithDeadline, got %v", Get(ctx, types.ContextKeyListenerType))
+	}
+	if d, ok := ctx.Deadline(); !ok || !d.Equal(deadline) {
+		t.Errorf("deadline not attached, got %v, %v", d, ok)
+	}
+
+	// a later mosn WithValue must not shadow the deadline or the old pairs
+	ctx = WithValue(ctx, types.ContextKeyConnectionID, uint64(1))
+	if listenerType, ok := Get(ctx, types.ContextKeyListenerType).(string); !ok || listenerType != expected {
+		t.Errorf("builtin value lost after WithValue, got %v", Get(ctx, types.ContextKeyListenerType))
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Error("deadline context never expired")
+	}
+	if ctx.Err() != context.DeadlineExceeded {
+		t.Errorf("unexpected context error: %v", ctx.Err())
+	}
+}
+
 func BenchmarkCompatibleGet(b *testing.B) {
 	ctx := context.Background()
 	for i := 0; i < testNodeNum; i++ {
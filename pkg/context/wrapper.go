@@ -19,6 +19,8 @@ package context
 
 import (
 	"context"
+	"time"
+
 	"sofastack.io/sofa-mosn/pkg/types"
 )
 
@@ -47,6 +49,21 @@ func WithValue(parent context.Context, key types.ContextKey, value interface{})
 	return mosnCtx
 }
 
+// WithDeadline attaches the given deadline to the context, keeping the builtin key-value
+// pairs of mosn.valueCtx reachable. The official context.WithDeadline returns a context whose
+// Value falls through to the parent, but a later mosn WithValue on it would shadow the
+// builtin pairs, so the deadline context is re-wrapped with a copy of the builtin array here.
+func WithDeadline(parent context.Context, deadline time.Time) (context.Context, context.CancelFunc) {
+	std, cancel := context.WithDeadline(parent, deadline)
+	if mosnCtx, ok := parent.(*valueCtx); ok {
+		clone := &valueCtx{Context: std}
+		// array copy assign
+		clone.builtin = mosnCtx.builtin
+		return clone, cancel
+	}
+	return std, cancel
+}
+
 // Clone copy the origin mosn value context(if it is), and return new one
 func Clone(parent context.Context) context.Context {
 	if mosnCtx, ok := parent.(*valueCtx); ok {
@@ -0,0 +1,144 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package featuregate provides named boolean gates for experimental code
+// paths. A subsystem registers its gate with a default, the effective value
+// is read through the runtime layer so a gate can be flipped in the static
+// config or over rtds without a restart. Gates can be overridden per
+// listener or per cluster for gradual rollout and instant rollback:
+//
+//	feature_gates.<gate>                          global value
+//	feature_gates.listener.<listener name>.<gate> per listener override
+//	feature_gates.cluster.<cluster name>.<gate>   per cluster override
+//
+// The admin endpoint /api/v1/features lists the registered gates and the
+// runtime overrides in effect.
+package featuregate
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"sofastack.io/sofa-mosn/pkg/admin/server"
+	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/runtime"
+)
+
+// runtimePrefix namespaces the gate keys in the runtime store
+const runtimePrefix = "feature_gates"
+
+// override scopes
+const (
+	scopeListener = "listener"
+	scopeCluster  = "cluster"
+)
+
+var (
+	mu    sync.RWMutex
+	gates = make(map[string]bool) // gate name -> default value
+)
+
+func init() {
+	server.RegisterAdminHandleFunc("/api/v1/features", dumpFeatures)
+}
+
+// Register declares a gate and its default value, called from the init of
+// the subsystem guarded by the gate. Re-registering replaces the default
+func Register(name string, def bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := gates[name]; ok {
+		log.DefaultLogger.Warnf("[featuregate] gate %s registered twice, default replaced", name)
+	}
+	gates[name] = def
+}
+
+func defaultValue(name string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return gates[name]
+}
+
+// Enabled returns the global value of a gate, the runtime layers override
+// the registered default
+func Enabled(name string) bool {
+	return runtime.GetBool(runtimePrefix+"."+name, defaultValue(name))
+}
+
+// EnabledForListener returns the gate value for one listener, a listener
+// scoped runtime key overrides the global value
+func EnabledForListener(listenerName, name string) bool {
+	return enabledScoped(scopeListener, listenerName, name)
+}
+
+// EnabledForCluster returns the gate value for one cluster, a cluster
+// scoped runtime key overrides the global value
+func EnabledForCluster(clusterName, name string) bool {
+	return enabledScoped(scopeCluster, clusterName, name)
+}
+
+func enabledScoped(scope, id, name string) bool {
+	if v, ok := runtime.Get(runtimePrefix + "." + scope + "." + id + "." + name); ok {
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return Enabled(name)
+}
+
+// featureDump is the admin endpoint payload
+type featureDump struct {
+	// Gates maps each registered gate to its effective global value
+	Gates map[string]bool `json:"gates"`
+	// Defaults maps each registered gate to its registered default
+	Defaults map[string]bool `json:"defaults"`
+	// Overrides lists the feature_gates runtime keys currently set,
+	// including the per listener and per cluster scoped ones
+	Overrides map[string]string `json:"overrides,omitempty"`
+}
+
+func dumpFeatures(w http.ResponseWriter, r *http.Request) {
+	mu.RLock()
+	dump := featureDump{
+		Gates:     make(map[string]bool, len(gates)),
+		Defaults:  make(map[string]bool, len(gates)),
+		Overrides: make(map[string]string),
+	}
+	for name, def := range gates {
+		dump.Defaults[name] = def
+	}
+	mu.RUnlock()
+	for name := range dump.Defaults {
+		dump.Gates[name] = Enabled(name)
+	}
+	for k, v := range runtime.Snapshot() {
+		if strings.HasPrefix(k, runtimePrefix+".") {
+			dump.Overrides[k] = v
+		}
+	}
+
+	buf, err := json.Marshal(dump)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf)
+}
@@ -0,0 +1,117 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package featuregate
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"sofastack.io/sofa-mosn/pkg/runtime"
+)
+
+func resetGates() {
+	mu.Lock()
+	gates = make(map[string]bool)
+	mu.Unlock()
+	runtime.ApplyStaticLayer(nil)
+	runtime.ApplyLayer("test_layer", nil)
+}
+
+func TestGateDefaultsAndOverrides(t *testing.T) {
+	resetGates()
+	Register("new_pool", false)
+	Register("new_lb", true)
+
+	if Enabled("new_pool") {
+		t.Errorf("new_pool should default to off")
+	}
+	if !Enabled("new_lb") {
+		t.Errorf("new_lb should default to on")
+	}
+	// unregistered gates are off
+	if Enabled("not_registered") {
+		t.Errorf("unregistered gate should be off")
+	}
+
+	// a runtime layer flips the global value without a restart
+	runtime.ApplyLayer("test_layer", map[string]string{
+		"feature_gates.new_pool": "true",
+	})
+	if !Enabled("new_pool") {
+		t.Errorf("runtime layer should enable new_pool")
+	}
+	// and rolls it back
+	runtime.ApplyLayer("test_layer", map[string]string{
+		"feature_gates.new_pool": "false",
+	})
+	if Enabled("new_pool") {
+		t.Errorf("runtime layer should disable new_pool")
+	}
+}
+
+func TestScopedOverrides(t *testing.T) {
+	resetGates()
+	Register("new_pool", false)
+
+	runtime.ApplyLayer("test_layer", map[string]string{
+		"feature_gates.listener.canary_listener.new_pool": "true",
+		"feature_gates.cluster.canary_cluster.new_pool":   "true",
+	})
+
+	if !EnabledForListener("canary_listener", "new_pool") {
+		t.Errorf("listener override should enable the gate")
+	}
+	if EnabledForListener("other_listener", "new_pool") {
+		t.Errorf("other listeners should keep the global value")
+	}
+	if !EnabledForCluster("canary_cluster", "new_pool") {
+		t.Errorf("cluster override should enable the gate")
+	}
+	if EnabledForCluster("other_cluster", "new_pool") {
+		t.Errorf("other clusters should keep the global value")
+	}
+}
+
+func TestDumpFeatures(t *testing.T) {
+	resetGates()
+	Register("new_pool", false)
+	runtime.ApplyLayer("test_layer", map[string]string{
+		"feature_gates.new_pool": "true",
+		"unrelated.key":          "1",
+	})
+
+	w := httptest.NewRecorder()
+	dumpFeatures(w, httptest.NewRequest("GET", "/api/v1/features", nil))
+	if w.Code != 200 {
+		t.Fatalf("dump status %d", w.Code)
+	}
+	var dump featureDump
+	if err := json.Unmarshal(w.Body.Bytes(), &dump); err != nil {
+		t.Fatalf("unmarshal dump: %v", err)
+	}
+	if dump.Defaults["new_pool"] != false || dump.Gates["new_pool"] != true {
+		t.Errorf("unexpected dump: %+v", dump)
+	}
+	if _, ok := dump.Overrides["feature_gates.new_pool"]; !ok {
+		t.Errorf("override missing from dump: %+v", dump.Overrides)
+	}
+	if _, ok := dump.Overrides["unrelated.key"]; ok {
+		t.Errorf("unrelated runtime keys should not be dumped")
+	}
+}
@@ -20,7 +20,6 @@ package originaldst
 import (
 	"errors"
 	"fmt"
-	__tl "log"
 	"net"
 	"syscall"
 
@@ -51,17 +50,17 @@ func (filter *originalDst) OnAccept(cb types.ListenerFilterCallbacks) types.Filt
 		log.DefaultLogger.Errorf("[originaldst] get original addr failed: %v", err)
 		return types.Continue
 	}
-	ips := fmt.Sprintf("%d.%d.%d.%d", ip[0], ip[1], ip[2], ip[3])
 
-	__tl.Print("ips:", ips)
-
-	cb.SetOriginalAddr(ips, port)
+	cb.SetOriginalAddr(ip.String(), port)
 
 	return types.Continue
 }
 
-func getOriginalAddr(conn net.Conn) ([]byte, int, error) {
-	tc := conn.(*net.TCPConn)
+func getOriginalAddr(conn net.Conn) (net.IP, int, error) {
+	tc, ok := conn.(*net.TCPConn)
+	if !ok {
+		return nil, 0, errors.New("conn is not a tcp connection")
+	}
 
 	f, err := tc.File()
 	if err != nil {
@@ -71,18 +70,47 @@ func getOriginalAddr(conn net.Conn) ([]byte, int, error) {
 	defer f.Close()
 
 	fd := int(f.Fd())
+	// File() puts the fd into blocking mode, restore it
+	defer syscall.SetNonblock(fd, true)
+
+	if tcpAddr, ok := tc.LocalAddr().(*net.TCPAddr); ok && tcpAddr.IP.To4() == nil {
+		// ip6tables REDIRECT stores the pre-redirect address under IPPROTO_IPV6,
+		// the returned buffer is a raw sockaddr_in6
+		mtuinfo, err := syscall.GetsockoptIPv6MTUInfo(fd, syscall.IPPROTO_IPV6, IP6T_SO_ORIGINAL_DST)
+		if err != nil {
+			return nil, 0, fmt.Errorf("getsockopt IP6T_SO_ORIGINAL_DST %v", err)
+		}
+		return parseSockaddrInet6(&mtuinfo.Addr)
+	}
+
 	addr, err := syscall.GetsockoptIPv6Mreq(fd, syscall.IPPROTO_IP, SO_ORIGINAL_DST)
+	if err != nil {
+		return nil, 0, fmt.Errorf("getsockopt SO_ORIGINAL_DST %v", err)
+	}
 
-	if err := syscall.SetNonblock(fd, true); err != nil {
-		return nil, 0, fmt.Errorf("setnonblock %v", err)
+	return parseSockaddrInet4(addr.Multiaddr[:])
+}
+
+// parseSockaddrInet4 decodes a raw sockaddr_in: the port lives in bytes 2-3
+// in network byte order, the address in bytes 4-7
+func parseSockaddrInet4(raw []byte) (net.IP, int, error) {
+	if len(raw) < 8 {
+		return nil, 0, errors.New("short sockaddr_in buffer")
 	}
 
-	p0 := int(addr.Multiaddr[2])
-	p1 := int(addr.Multiaddr[3])
+	port := int(raw[2])<<8 + int(raw[3])
+	ip := net.IPv4(raw[4], raw[5], raw[6], raw[7])
+
+	return ip, port, nil
+}
 
-	port := p0*256 + p1
+// parseSockaddrInet6 decodes a raw sockaddr_in6, the Port field keeps the
+// network byte order value as read from kernel memory
+func parseSockaddrInet6(sa *syscall.RawSockaddrInet6) (net.IP, int, error) {
+	ip := make(net.IP, net.IPv6len)
+	copy(ip, sa.Addr[:])
 
-	ip := addr.Multiaddr[4:8]
+	port := int(sa.Port>>8) + int(sa.Port&0xff)<<8
 
 	return ip, port, nil
 }
@@ -0,0 +1,56 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package originaldst
+
+import (
+	"net"
+	"syscall"
+	"testing"
+)
+
+func TestParseSockaddrInet4(t *testing.T) {
+	// sockaddr_in for 10.1.2.3:8080, 8080 = 0x1f90
+	raw := []byte{0x02, 0x00, 0x1f, 0x90, 10, 1, 2, 3, 0, 0, 0, 0, 0, 0, 0, 0}
+	ip, port, err := parseSockaddrInet4(raw)
+	if err != nil {
+		t.Fatalf("parse sockaddr_in: %v", err)
+	}
+	if !ip.Equal(net.ParseIP("10.1.2.3")) || port != 8080 {
+		t.Errorf("got %s:%d, want 10.1.2.3:8080", ip, port)
+	}
+
+	if _, _, err := parseSockaddrInet4(raw[:4]); err == nil {
+		t.Error("short buffer should be rejected")
+	}
+}
+
+func TestParseSockaddrInet6(t *testing.T) {
+	want := net.ParseIP("2001:db8::1")
+	sa := &syscall.RawSockaddrInet6{}
+	copy(sa.Addr[:], want.To16())
+	// port 8080 in network byte order as read from kernel memory
+	sa.Port = 0x901f
+
+	ip, port, err := parseSockaddrInet6(sa)
+	if err != nil {
+		t.Fatalf("parse sockaddr_in6: %v", err)
+	}
+	if !ip.Equal(want) || port != 8080 {
+		t.Errorf("got [%s]:%d, want [%s]:8080", ip, port, want)
+	}
+}
@@ -0,0 +1,190 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package demux implements a network read filter that detects the
+// application protocol from the first bytes of a connection and builds
+// the filter stack configured for that protocol, so one listener port
+// can serve HTTP, Bolt and Dubbo clients at the same time.
+package demux
+
+import (
+	"bytes"
+	"context"
+
+	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/metrics"
+	"sofastack.io/sofa-mosn/pkg/protocol"
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+// supported protocol names, the config keys of v2.ProtocolDemux
+const (
+	ProtocolHTTP1 = string(protocol.HTTP1)
+	ProtocolBolt  = string(protocol.SofaRPC)
+	ProtocolDubbo = "Dubbo"
+)
+
+// demuxSniffLen is the most bytes the sniffer needs to decide,
+// the longest http method token is "OPTIONS " with 8 bytes
+const demuxSniffLen = 8
+
+// sniff results
+const (
+	sniffMatch = iota
+	sniffNeedMoreData
+	sniffNotMatch
+)
+
+// bolt protocol codes and dubbo magic bytes
+const (
+	boltProtocolCodeV1 = 0x01
+	boltProtocolCodeV2 = 0x02
+)
+
+var dubboMagic = []byte{0xda, 0xbb}
+
+var httpMethods = []string{
+	"GET ", "PUT ", "POST ", "HEAD ", "TRACE ", "PATCH ", "DELETE ", "OPTIONS ", "CONNECT ",
+}
+
+func supportedProtocol(proto string) bool {
+	switch proto {
+	case ProtocolHTTP1, ProtocolBolt, ProtocolDubbo:
+		return true
+	}
+	return false
+}
+
+// demuxFilter is an implement of types.ReadFilter
+type demuxFilter struct {
+	ctx            context.Context
+	clusterManager types.ClusterManager
+	factories      map[string][]types.NetworkFilterChainFactory
+	cb             types.ReadFilterCallbacks
+	dispatched     bool
+}
+
+func NewDemuxFilter(ctx context.Context, clusterManager types.ClusterManager, factories map[string][]types.NetworkFilterChainFactory) types.ReadFilter {
+	return &demuxFilter{
+		ctx:            ctx,
+		clusterManager: clusterManager,
+		factories:      factories,
+	}
+}
+
+func (f *demuxFilter) InitializeReadFilterCallbacks(cb types.ReadFilterCallbacks) {
+	f.cb = cb
+}
+
+func (f *demuxFilter) OnNewConnection() types.FilterStatus {
+	return types.Continue
+}
+
+func (f *demuxFilter) OnData(buf types.IoBuffer) types.FilterStatus {
+	if f.dispatched {
+		// connection is already demuxed, pass through to the built filters
+		return types.Continue
+	}
+	proto, result := sniffProtocol(buf.Bytes())
+	if result == sniffNeedMoreData && buf.Len() < demuxSniffLen {
+		return types.Stop
+	}
+	if result != sniffMatch {
+		f.countConnection("unknown")
+		log.DefaultLogger.Errorf("[network filter] [demux] unrecognized protocol, close connection, first bytes: %v", firstBytes(buf.Bytes()))
+		f.cb.Connection().Close(types.NoFlush, types.LocalClose)
+		return types.Stop
+	}
+	factories, ok := f.factories[proto]
+	if !ok {
+		f.countConnection(proto)
+		log.DefaultLogger.Errorf("[network filter] [demux] no filter chain configured for protocol %s, close connection", proto)
+		f.cb.Connection().Close(types.NoFlush, types.LocalClose)
+		return types.Stop
+	}
+	if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
+		log.DefaultLogger.Debugf("[network filter] [demux] protocol %s detected, build filter chain", proto)
+	}
+	f.countConnection(proto)
+	// build the protocol's own filter stack on the connection, the filter
+	// manager continues reading the buffered bytes with the new filters
+	fm := f.cb.Connection().FilterManager()
+	for _, factory := range factories {
+		factory.CreateFilterChain(f.ctx, f.clusterManager, &chainCallbacks{fm: fm})
+	}
+	f.dispatched = true
+	return types.Continue
+}
+
+func (f *demuxFilter) countConnection(proto string) {
+	if m, err := metrics.NewMetrics("protocol_demux", map[string]string{"protocol": proto}); err == nil {
+		m.Counter("connections_total").Inc(1)
+	}
+}
+
+// chainCallbacks adapts the connection's filter manager to
+// types.NetWorkFilterChainFactoryCallbacks
+type chainCallbacks struct {
+	fm types.FilterManager
+}
+
+func (cb *chainCallbacks) AddReadFilter(rf types.ReadFilter) {
+	cb.fm.AddReadFilter(rf)
+}
+
+func (cb *chainCallbacks) AddWriteFilter(wf types.WriteFilter) {
+	cb.fm.AddWriteFilter(wf)
+}
+
+// sniffProtocol detects the application protocol by magic bytes
+func sniffProtocol(data []byte) (string, int) {
+	if len(data) == 0 {
+		return "", sniffNeedMoreData
+	}
+	switch data[0] {
+	case boltProtocolCodeV1, boltProtocolCodeV2:
+		return ProtocolBolt, sniffMatch
+	case dubboMagic[0]:
+		if len(data) < len(dubboMagic) {
+			return "", sniffNeedMoreData
+		}
+		if data[1] == dubboMagic[1] {
+			return ProtocolDubbo, sniffMatch
+		}
+		return "", sniffNotMatch
+	}
+	for _, method := range httpMethods {
+		if len(data) < len(method) {
+			if bytes.HasPrefix([]byte(method), data) {
+				return "", sniffNeedMoreData
+			}
+			continue
+		}
+		if bytes.HasPrefix(data, []byte(method)) {
+			return ProtocolHTTP1, sniffMatch
+		}
+	}
+	return "", sniffNotMatch
+}
+
+// firstBytes returns at most the sniff length of bytes for logging
+func firstBytes(data []byte) []byte {
+	if len(data) > demuxSniffLen {
+		return data[:demuxSniffLen]
+	}
+	return data
+}
@@ -0,0 +1,82 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package demux
+
+import (
+	"testing"
+
+	_ "sofastack.io/sofa-mosn/pkg/filter/network/proxy"
+)
+
+func TestSniffProtocol(t *testing.T) {
+	testCases := []struct {
+		data   []byte
+		proto  string
+		result int
+	}{
+		{[]byte("GET / HTTP/1.1\r\n"), ProtocolHTTP1, sniffMatch},
+		{[]byte("OPTIONS * HTTP/1.1\r\n"), ProtocolHTTP1, sniffMatch},
+		{[]byte{0x01, 0x00, 0x00}, ProtocolBolt, sniffMatch},
+		{[]byte{0x02, 0x00, 0x00}, ProtocolBolt, sniffMatch},
+		{[]byte{0xda, 0xbb, 0xc2}, ProtocolDubbo, sniffMatch},
+		// partial data needs more bytes to decide
+		{[]byte("GE"), "", sniffNeedMoreData},
+		{[]byte("OPTION"), "", sniffNeedMoreData},
+		{[]byte{0xda}, "", sniffNeedMoreData},
+		{[]byte{}, "", sniffNeedMoreData},
+		// garbage is rejected
+		{[]byte{0xff, 0xfe, 0xfd, 0xfc}, "", sniffNotMatch},
+		{[]byte("INVALID METHOD"), "", sniffNotMatch},
+	}
+	for i, tc := range testCases {
+		proto, result := sniffProtocol(tc.data)
+		if proto != tc.proto || result != tc.result {
+			t.Errorf("case %d: expected (%s, %d), got (%s, %d)", i, tc.proto, tc.result, proto, result)
+		}
+	}
+}
+
+func TestCreateDemuxFactory(t *testing.T) {
+	conf := map[string]interface{}{
+		"protocols": map[string]interface{}{
+			ProtocolHTTP1: []interface{}{
+				map[string]interface{}{
+					"type": "proxy",
+					"config": map[string]interface{}{
+						"downstream_protocol": "Http1",
+						"upstream_protocol":   "Http1",
+						"router_config_name":  "test_router",
+					},
+				},
+			},
+		},
+	}
+	if _, err := CreateDemuxFactory(conf); err != nil {
+		t.Errorf("create demux factory failed: %v", err)
+	}
+
+	// an unsupported protocol name is a config error
+	badConf := map[string]interface{}{
+		"protocols": map[string]interface{}{
+			"Smtp": []interface{}{},
+		},
+	}
+	if _, err := CreateDemuxFactory(badConf); err == nil {
+		t.Error("unsupported protocol should fail factory creation")
+	}
+}
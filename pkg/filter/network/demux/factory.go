@@ -0,0 +1,62 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package demux
+
+import (
+	"context"
+	"fmt"
+
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+	"sofastack.io/sofa-mosn/pkg/config"
+	"sofastack.io/sofa-mosn/pkg/filter"
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+func init() {
+	filter.RegisterNetwork(v2.PROTOCOL_DEMUX, CreateDemuxFactory)
+}
+
+type demuxFilterConfigFactory struct {
+	// factories holds the network filter chain factories per protocol name
+	factories map[string][]types.NetworkFilterChainFactory
+}
+
+func (f *demuxFilterConfigFactory) CreateFilterChain(context context.Context, clusterManager types.ClusterManager, callbacks types.NetWorkFilterChainFactoryCallbacks) {
+	callbacks.AddReadFilter(NewDemuxFilter(context, clusterManager, f.factories))
+}
+
+func CreateDemuxFactory(conf map[string]interface{}) (types.NetworkFilterChainFactory, error) {
+	cfg, err := config.ParseProtocolDemuxFilter(conf)
+	if err != nil {
+		return nil, err
+	}
+	factories := make(map[string][]types.NetworkFilterChainFactory)
+	for proto, filters := range cfg.Protocols {
+		if !supportedProtocol(proto) {
+			return nil, fmt.Errorf("protocol demux does not support protocol: %s", proto)
+		}
+		for _, fc := range filters {
+			factory, err := filter.CreateNetworkFilterChainFactory(fc.Type, fc.Config)
+			if err != nil {
+				return nil, err
+			}
+			factories[proto] = append(factories[proto], factory)
+		}
+	}
+	return &demuxFilterConfigFactory{factories: factories}, nil
+}
@@ -0,0 +1,200 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mqttproxy
+
+import (
+	"fmt"
+)
+
+// mqtt control packet types, the high nibble of the first byte. The
+// framing is identical for 3.1.1 and 5.0, only the variable headers
+// differ
+const (
+	packetConnect     = 1
+	packetConnack     = 2
+	packetPublish     = 3
+	packetSubscribe   = 8
+	packetUnsubscribe = 10
+	packetDisconnect  = 14
+)
+
+// protocol levels from the connect packet
+const (
+	protocolLevel311 = 4
+	protocolLevel50  = 5
+)
+
+// maxRemainingLength is the protocol limit of the four byte variable
+// length encoding
+const maxRemainingLength = 268435455
+
+// mqttFrameLen returns the length of the first complete control packet
+// in data, 0 when more bytes are needed and an error when the fixed
+// header is malformed
+func mqttFrameLen(data []byte) (int, error) {
+	if len(data) < 2 {
+		return 0, nil
+	}
+	if data[0]>>4 == 0 || data[0]>>4 == 15 {
+		return 0, fmt.Errorf("invalid mqtt packet type %d", data[0]>>4)
+	}
+	remaining := 0
+	multiplier := 1
+	for i := 1; ; i++ {
+		if i > 4 {
+			return 0, fmt.Errorf("mqtt remaining length exceeds four bytes")
+		}
+		if i >= len(data) {
+			return 0, nil
+		}
+		remaining += int(data[i]&0x7f) * multiplier
+		if data[i]&0x80 == 0 {
+			if remaining > maxRemainingLength {
+				return 0, fmt.Errorf("mqtt remaining length %d out of range", remaining)
+			}
+			total := i + 1 + remaining
+			if len(data) < total {
+				return 0, nil
+			}
+			return total, nil
+		}
+		multiplier *= 128
+	}
+}
+
+// packetType returns the control packet type of a complete frame
+func packetType(frame []byte) byte {
+	return frame[0] >> 4
+}
+
+// fixedHeaderLen returns the offset of the variable header inside a
+// complete frame
+func fixedHeaderLen(frame []byte) int {
+	for i := 1; i < len(frame); i++ {
+		if frame[i]&0x80 == 0 {
+			return i + 1
+		}
+	}
+	return len(frame)
+}
+
+// readMQTTString reads a two byte length prefixed utf8 string
+func readMQTTString(data []byte, offset int) (string, int, error) {
+	if offset+2 > len(data) {
+		return "", 0, fmt.Errorf("truncated mqtt string length")
+	}
+	length := int(data[offset])<<8 | int(data[offset+1])
+	offset += 2
+	if offset+length > len(data) {
+		return "", 0, fmt.Errorf("truncated mqtt string")
+	}
+	return string(data[offset : offset+length]), offset + length, nil
+}
+
+// readVariableInt reads the 5.0 variable byte integer used for property
+// lengths
+func readVariableInt(data []byte, offset int) (int, int, error) {
+	value := 0
+	multiplier := 1
+	for i := 0; i < 4; i++ {
+		if offset >= len(data) {
+			return 0, 0, fmt.Errorf("truncated mqtt variable integer")
+		}
+		b := data[offset]
+		offset++
+		value += int(b&0x7f) * multiplier
+		if b&0x80 == 0 {
+			return value, offset, nil
+		}
+		multiplier *= 128
+	}
+	return 0, 0, fmt.Errorf("mqtt variable integer exceeds four bytes")
+}
+
+// connectInfo is the part of the connect packet the proxy cares about
+type connectInfo struct {
+	protocolLevel byte
+	clientID      string
+}
+
+// parseConnect extracts the protocol level and client identifier from a
+// complete connect frame
+func parseConnect(frame []byte) (*connectInfo, error) {
+	offset := fixedHeaderLen(frame)
+	name, offset, err := readMQTTString(frame, offset)
+	if err != nil {
+		return nil, err
+	}
+	if name != "MQTT" && name != "MQIsdp" {
+		return nil, fmt.Errorf("unexpected mqtt protocol name %q", name)
+	}
+	if offset >= len(frame) {
+		return nil, fmt.Errorf("truncated mqtt connect header")
+	}
+	info := &connectInfo{protocolLevel: frame[offset]}
+	if info.protocolLevel != protocolLevel311 && info.protocolLevel != protocolLevel50 {
+		return nil, fmt.Errorf("unsupported mqtt protocol level %d", info.protocolLevel)
+	}
+	// connect flags and keep alive
+	offset += 1 + 1 + 2
+	if info.protocolLevel == protocolLevel50 {
+		// skip the 5.0 properties
+		length, next, err := readVariableInt(frame, offset)
+		if err != nil {
+			return nil, err
+		}
+		offset = next + length
+	}
+	info.clientID, _, err = readMQTTString(frame, offset)
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// parsePublishTopic extracts the topic name from a complete publish
+// frame, the topic leads the variable header on both protocol levels
+func parsePublishTopic(frame []byte) (string, error) {
+	topic, _, err := readMQTTString(frame, fixedHeaderLen(frame))
+	return topic, err
+}
+
+// parseSubscribeTopics extracts the topic filters from a complete
+// subscribe frame
+func parseSubscribeTopics(frame []byte, protocolLevel byte) ([]string, error) {
+	// packet identifier
+	offset := fixedHeaderLen(frame) + 2
+	if protocolLevel == protocolLevel50 {
+		length, next, err := readVariableInt(frame, offset)
+		if err != nil {
+			return nil, err
+		}
+		offset = next + length
+	}
+	var topics []string
+	for offset < len(frame) {
+		topic, next, err := readMQTTString(frame, offset)
+		if err != nil {
+			return nil, err
+		}
+		topics = append(topics, topic)
+		// subscription options byte behind every filter
+		offset = next + 1
+	}
+	return topics, nil
+}
@@ -0,0 +1,154 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mqttproxy
+
+import (
+	"testing"
+)
+
+func mqttString(s string) []byte {
+	out := []byte{byte(len(s) >> 8), byte(len(s))}
+	return append(out, s...)
+}
+
+// buildFrame prepends the fixed header to a payload
+func buildFrame(packetType byte, flags byte, payload []byte) []byte {
+	out := []byte{packetType<<4 | flags}
+	remaining := len(payload)
+	for {
+		b := byte(remaining % 128)
+		remaining /= 128
+		if remaining > 0 {
+			b |= 0x80
+		}
+		out = append(out, b)
+		if remaining == 0 {
+			break
+		}
+	}
+	return append(out, payload...)
+}
+
+func buildConnect311(clientID string) []byte {
+	payload := mqttString("MQTT")
+	payload = append(payload, protocolLevel311, 0x02, 0x00, 0x3c) // flags, keep alive 60
+	payload = append(payload, mqttString(clientID)...)
+	return buildFrame(packetConnect, 0, payload)
+}
+
+func buildConnect50(clientID string) []byte {
+	payload := mqttString("MQTT")
+	payload = append(payload, protocolLevel50, 0x02, 0x00, 0x3c)
+	payload = append(payload, 0x00) // empty properties
+	payload = append(payload, mqttString(clientID)...)
+	return buildFrame(packetConnect, 0, payload)
+}
+
+func TestMQTTFrameLen(t *testing.T) {
+	frame := buildConnect311("client-1")
+	frameLen, err := mqttFrameLen(frame)
+	if err != nil || frameLen != len(frame) {
+		t.Fatalf("frameLen=%d err=%v, expected %d", frameLen, err, len(frame))
+	}
+	// partial frames need more bytes
+	for i := 1; i < len(frame); i++ {
+		if frameLen, err := mqttFrameLen(frame[:i]); err != nil || frameLen != 0 {
+			t.Errorf("partial [:%d]: frameLen=%d err=%v", i, frameLen, err)
+		}
+	}
+	// a large remaining length spans multiple length bytes
+	big := buildFrame(packetPublish, 0, make([]byte, 1000))
+	if frameLen, err := mqttFrameLen(big); err != nil || frameLen != len(big) {
+		t.Errorf("large frame: frameLen=%d err=%v, expected %d", frameLen, err, len(big))
+	}
+	// reserved packet type 0 is malformed
+	if _, err := mqttFrameLen([]byte{0x00, 0x00}); err == nil {
+		t.Errorf("reserved packet type accepted")
+	}
+}
+
+func TestParseConnect(t *testing.T) {
+	info, err := parseConnect(buildConnect311("sensor-42"))
+	if err != nil {
+		t.Fatalf("3.1.1 connect: %v", err)
+	}
+	if info.protocolLevel != protocolLevel311 || info.clientID != "sensor-42" {
+		t.Errorf("3.1.1 connect parsed wrong: %+v", info)
+	}
+
+	info, err = parseConnect(buildConnect50("sensor-50"))
+	if err != nil {
+		t.Fatalf("5.0 connect: %v", err)
+	}
+	if info.protocolLevel != protocolLevel50 || info.clientID != "sensor-50" {
+		t.Errorf("5.0 connect parsed wrong: %+v", info)
+	}
+
+	// unknown protocol level is rejected
+	bad := mqttString("MQTT")
+	bad = append(bad, 3, 0x02, 0x00, 0x3c)
+	bad = append(bad, mqttString("old")...)
+	if _, err := parseConnect(buildFrame(packetConnect, 0, bad)); err == nil {
+		t.Errorf("protocol level 3 accepted")
+	}
+}
+
+func TestParsePublishTopic(t *testing.T) {
+	payload := mqttString("devices/sensor-42/temperature")
+	payload = append(payload, 0x00, 0x01) // packet identifier, qos 1
+	payload = append(payload, "21.5"...)
+	frame := buildFrame(packetPublish, 0x02, payload)
+	topic, err := parsePublishTopic(frame)
+	if err != nil || topic != "devices/sensor-42/temperature" {
+		t.Errorf("topic=%q err=%v", topic, err)
+	}
+}
+
+func TestParseSubscribeTopics(t *testing.T) {
+	payload := []byte{0x00, 0x0a} // packet identifier
+	payload = append(payload, mqttString("devices/+/temperature")...)
+	payload = append(payload, 0x01) // qos
+	payload = append(payload, mqttString("alerts/#")...)
+	payload = append(payload, 0x00)
+	frame := buildFrame(packetSubscribe, 0x02, payload)
+	topics, err := parseSubscribeTopics(frame, protocolLevel311)
+	if err != nil || len(topics) != 2 || topics[0] != "devices/+/temperature" || topics[1] != "alerts/#" {
+		t.Errorf("topics=%q err=%v", topics, err)
+	}
+}
+
+func TestBoundTopic(t *testing.T) {
+	topicsMux.Lock()
+	trackedTopics = make(map[string]bool)
+	topicsMux.Unlock()
+
+	if boundTopic("a/b") != "a/b" {
+		t.Errorf("first topic not admitted")
+	}
+	topicsMux.Lock()
+	for i := 0; i < maxTrackedTopics; i++ {
+		trackedTopics[string(rune(i))+"x"] = true
+	}
+	topicsMux.Unlock()
+	if boundTopic("new/topic") != overflowTopic {
+		t.Errorf("topic over cap not folded into overflow bucket")
+	}
+	if boundTopic("a/b") != "a/b" {
+		t.Errorf("admitted topic lost after cap")
+	}
+}
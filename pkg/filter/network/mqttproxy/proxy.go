@@ -0,0 +1,249 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mqttproxy
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"sync"
+
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+	"sofastack.io/sofa-mosn/pkg/buffer"
+	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/metrics"
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+// maxTrackedTopics bounds the per-topic metrics cardinality, every
+// topic past the cap lands in the shared overflow bucket
+const maxTrackedTopics = 1000
+
+const overflowTopic = "other"
+
+var (
+	topicsMux     sync.Mutex
+	trackedTopics = make(map[string]bool)
+)
+
+// boundTopic returns the topic itself while the tracked set is below
+// the cap and the overflow bucket afterwards
+func boundTopic(topic string) string {
+	topicsMux.Lock()
+	defer topicsMux.Unlock()
+	if trackedTopics[topic] {
+		return topic
+	}
+	if len(trackedTopics) >= maxTrackedTopics {
+		return overflowTopic
+	}
+	trackedTopics[topic] = true
+	return topic
+}
+
+// ReadFilter
+type proxy struct {
+	config         *v2.MQTTProxy
+	clusterManager types.ClusterManager
+	readCallbacks  types.ReadFilterCallbacks
+
+	upstreamConnection types.ClientConnection
+	protocolLevel      byte
+	clientID           string
+}
+
+func NewProxy(ctx context.Context, config *v2.MQTTProxy, clusterManager types.ClusterManager) types.ReadFilter {
+	return &proxy{
+		config:         config,
+		clusterManager: clusterManager,
+	}
+}
+
+func (p *proxy) OnData(buf types.IoBuffer) types.FilterStatus {
+	for buf.Len() > 0 {
+		frameLen, err := mqttFrameLen(buf.Bytes())
+		if err != nil {
+			log.DefaultLogger.Errorf("[mqttproxy] [ondata] decode packet failed: %v", err)
+			p.readCallbacks.Connection().Close(types.NoFlush, types.LocalClose)
+			return types.Stop
+		}
+		if frameLen == 0 {
+			// wait for more bytes
+			break
+		}
+		frame := make([]byte, frameLen)
+		copy(frame, buf.Bytes()[:frameLen])
+		buf.Drain(frameLen)
+		if !p.handlePacket(frame) {
+			return types.Stop
+		}
+	}
+	return types.Stop
+}
+
+// handlePacket inspects a downstream packet and pipes it upstream,
+// returns false when the connection was torn down
+func (p *proxy) handlePacket(frame []byte) bool {
+	switch packetType(frame) {
+	case packetConnect:
+		info, err := parseConnect(frame)
+		if err != nil {
+			log.DefaultLogger.Errorf("[mqttproxy] [connect] parse failed: %v", err)
+			p.readCallbacks.Connection().Close(types.NoFlush, types.LocalClose)
+			return false
+		}
+		p.protocolLevel = info.protocolLevel
+		p.clientID = info.clientID
+		if p.upstreamConnection == nil && !p.connectUpstream() {
+			return false
+		}
+	case packetPublish:
+		if topic, err := parsePublishTopic(frame); err == nil {
+			stats := metrics.NewMQTTStats(boundTopic(topic))
+			stats.Counter(metrics.MQTTPublishTotal).Inc(1)
+			stats.Counter(metrics.MQTTPublishBytes).Inc(int64(len(frame)))
+		}
+	case packetSubscribe:
+		if topics, err := parseSubscribeTopics(frame, p.protocolLevel); err == nil {
+			for _, topic := range topics {
+				metrics.NewMQTTStats(boundTopic(topic)).Counter(metrics.MQTTSubscribeTotal).Inc(1)
+			}
+		}
+	}
+	if p.upstreamConnection == nil {
+		// a client speaking before connect is broken, drop it
+		log.DefaultLogger.Errorf("[mqttproxy] packet type %d before connect", packetType(frame))
+		p.readCallbacks.Connection().Close(types.NoFlush, types.LocalClose)
+		return false
+	}
+	p.upstreamConnection.Write(buffer.NewIoBufferBytes(frame))
+	return true
+}
+
+// connectUpstream dials the broker the cluster's load balancer selects,
+// the client identifier is the hash key so a reconnecting client keeps
+// landing on the broker holding its session
+func (p *proxy) connectUpstream() bool {
+	snapshot := p.clusterManager.GetClusterSnapshot(context.Background(), p.config.Cluster)
+	if reflect.ValueOf(snapshot).IsNil() {
+		log.DefaultLogger.Errorf("[mqttproxy] [connect] no cluster %s", p.config.Cluster)
+		p.readCallbacks.Connection().Close(types.NoFlush, types.LocalClose)
+		return false
+	}
+	defer p.clusterManager.PutClusterSnapshot(snapshot)
+	connectionData := p.clusterManager.TCPConnForCluster(&lbContext{proxy: p}, snapshot)
+	if connectionData.Connection == nil {
+		log.DefaultLogger.Errorf("[mqttproxy] [connect] no healthy broker in cluster %s", p.config.Cluster)
+		p.readCallbacks.Connection().Close(types.NoFlush, types.LocalClose)
+		return false
+	}
+	upstream := connectionData.Connection
+	upstream.AddConnectionEventListener(&upstreamCallbacks{proxy: p})
+	upstream.FilterManager().AddReadFilter(&upstreamCallbacks{proxy: p})
+	if err := upstream.Connect(true); err != nil {
+		log.DefaultLogger.Errorf("[mqttproxy] [connect] connect broker failed: %v", err)
+		p.readCallbacks.Connection().Close(types.NoFlush, types.LocalClose)
+		return false
+	}
+	p.readCallbacks.SetUpstreamHost(connectionData.HostInfo)
+	p.upstreamConnection = upstream
+	return true
+}
+
+func (p *proxy) OnNewConnection() types.FilterStatus {
+	return types.Continue
+}
+
+func (p *proxy) InitializeReadFilterCallbacks(cb types.ReadFilterCallbacks) {
+	p.readCallbacks = cb
+	p.readCallbacks.Connection().AddConnectionEventListener(&downstreamCallbacks{proxy: p})
+}
+
+func (p *proxy) onUpstreamData(buf types.IoBuffer) {
+	p.readCallbacks.Connection().Write(buf.Clone())
+	buf.Drain(buf.Len())
+}
+
+func (p *proxy) onUpstreamEvent(event types.ConnectionEvent) {
+	if event.IsClose() || event.ConnectFailure() {
+		p.readCallbacks.Connection().Close(types.FlushWrite, types.LocalClose)
+	}
+}
+
+func (p *proxy) onDownstreamEvent(event types.ConnectionEvent) {
+	if event.IsClose() && p.upstreamConnection != nil {
+		p.upstreamConnection.Close(types.NoFlush, types.LocalClose)
+	}
+}
+
+// ConnectionEventListener
+// ReadFilter
+type upstreamCallbacks struct {
+	proxy *proxy
+}
+
+func (uc *upstreamCallbacks) OnEvent(event types.ConnectionEvent) {
+	uc.proxy.onUpstreamEvent(event)
+}
+
+func (uc *upstreamCallbacks) OnData(buf types.IoBuffer) types.FilterStatus {
+	uc.proxy.onUpstreamData(buf)
+	return types.Stop
+}
+
+func (uc *upstreamCallbacks) OnNewConnection() types.FilterStatus {
+	return types.Continue
+}
+
+func (uc *upstreamCallbacks) InitializeReadFilterCallbacks(cb types.ReadFilterCallbacks) {}
+
+// ConnectionEventListener
+type downstreamCallbacks struct {
+	proxy *proxy
+}
+
+func (dc *downstreamCallbacks) OnEvent(event types.ConnectionEvent) {
+	dc.proxy.onDownstreamEvent(event)
+}
+
+// lbContext is a types.LoadBalancerContext implementation hashing on
+// the mqtt client identifier
+type lbContext struct {
+	proxy *proxy
+}
+
+func (c *lbContext) ComputeHashKey() types.HashedValue {
+	return types.HashedValue(c.proxy.clientID)
+}
+
+func (c *lbContext) MetadataMatchCriteria() types.MetadataMatchCriteria {
+	return nil
+}
+
+func (c *lbContext) DownstreamConnection() net.Conn {
+	return c.proxy.readCallbacks.Connection().RawConn()
+}
+
+// mqtt packets carry no header
+func (c *lbContext) DownstreamHeaders() types.HeaderMap {
+	return nil
+}
+
+func (c *lbContext) DownstreamContext() context.Context {
+	return nil
+}
@@ -0,0 +1,380 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package redisproxy
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+	"sofastack.io/sofa-mosn/pkg/buffer"
+	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/metrics"
+	"sofastack.io/sofa-mosn/pkg/network"
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+// askingCommand is sent ahead of a command redirected with ASK, its +OK
+// reply is discarded
+var askingCommand = []byte("*1\r\n$6\r\nASKING\r\n")
+
+// readCommands are the commands that may be served by a replica, used
+// for read/write splitting when a read cluster is configured
+var readCommands = map[string]bool{
+	"GET": true, "MGET": true, "EXISTS": true, "TTL": true, "PTTL": true,
+	"TYPE": true, "STRLEN": true, "GETRANGE": true, "KEYS": true, "SCAN": true,
+	"HGET": true, "HGETALL": true, "HMGET": true, "HKEYS": true, "HVALS": true,
+	"HLEN": true, "HEXISTS": true, "SMEMBERS": true, "SISMEMBER": true,
+	"SCARD": true, "SRANDMEMBER": true, "LRANGE": true, "LLEN": true,
+	"LINDEX": true, "ZRANGE": true, "ZREVRANGE": true, "ZRANGEBYSCORE": true,
+	"ZSCORE": true, "ZCARD": true, "ZCOUNT": true, "ZRANK": true,
+	"BITCOUNT": true, "GETBIT": true, "RANDOMKEY": true, "DUMP": true,
+}
+
+func isReadCommand(command string) bool {
+	return readCommands[command]
+}
+
+// pendingCommand tracks one downstream command until its reply is ready,
+// replies go back to the client in request order regardless of which
+// upstream answered
+type pendingCommand struct {
+	command   string
+	raw       []byte
+	reply     []byte
+	done      bool
+	discard   bool // the ASKING handshake, its reply is never forwarded
+	start     time.Time
+	redirects int
+}
+
+// ReadFilter
+type proxy struct {
+	config         *v2.RedisProxy
+	clusterManager types.ClusterManager
+	readCallbacks  types.ReadFilterCallbacks
+
+	mu        sync.Mutex
+	replies   []*pendingCommand        // downstream request order
+	upstreams map[string]*upstreamConn // keyed by cluster name or redirect address
+	closed    bool
+}
+
+func NewProxy(ctx context.Context, config *v2.RedisProxy, clusterManager types.ClusterManager) types.ReadFilter {
+	return &proxy{
+		config:         config,
+		clusterManager: clusterManager,
+		upstreams:      make(map[string]*upstreamConn),
+	}
+}
+
+func (p *proxy) OnData(buf types.IoBuffer) types.FilterStatus {
+	for buf.Len() > 0 {
+		args, frameLen, err := decodeCommand(buf.Bytes())
+		if err != nil {
+			log.DefaultLogger.Errorf("[redisproxy] [ondata] decode command failed: %v", err)
+			p.readCallbacks.Connection().Write(buffer.NewIoBufferBytes(encodeError("protocol error")))
+			p.readCallbacks.Connection().Close(types.FlushWrite, types.LocalClose)
+			return types.Stop
+		}
+		if frameLen == 0 {
+			// wait for more bytes
+			break
+		}
+		raw := make([]byte, frameLen)
+		copy(raw, buf.Bytes()[:frameLen])
+		buf.Drain(frameLen)
+		p.handleCommand(strings.ToUpper(string(args[0])), raw)
+	}
+	return types.Stop
+}
+
+func (p *proxy) OnNewConnection() types.FilterStatus {
+	return types.Continue
+}
+
+func (p *proxy) InitializeReadFilterCallbacks(cb types.ReadFilterCallbacks) {
+	p.readCallbacks = cb
+	p.readCallbacks.Connection().AddConnectionEventListener(&downstreamCallbacks{proxy: p})
+}
+
+func (p *proxy) handleCommand(command string, raw []byte) {
+	pc := &pendingCommand{
+		command: command,
+		raw:     raw,
+		start:   time.Now(),
+	}
+	p.mu.Lock()
+	p.replies = append(p.replies, pc)
+	p.mu.Unlock()
+
+	clusterName := p.config.Cluster
+	if p.config.ReadCluster != "" && isReadCommand(command) {
+		clusterName = p.config.ReadCluster
+	}
+	uc, err := p.upstreamForCluster(clusterName)
+	if err != nil {
+		log.DefaultLogger.Errorf("[redisproxy] [command] no upstream for cluster %s: %v", clusterName, err)
+		p.finish(pc, encodeError("no upstream available"))
+		return
+	}
+	uc.send(pc)
+}
+
+// upstreamForCluster returns the connection to the given cluster, lazily
+// dialing one host selected by the cluster's load balancer
+func (p *proxy) upstreamForCluster(clusterName string) (*upstreamConn, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if uc, ok := p.upstreams[clusterName]; ok {
+		return uc, nil
+	}
+	snapshot := p.clusterManager.GetClusterSnapshot(context.Background(), clusterName)
+	if reflect.ValueOf(snapshot).IsNil() {
+		return nil, errors.New("no such cluster")
+	}
+	defer p.clusterManager.PutClusterSnapshot(snapshot)
+	connectionData := p.clusterManager.TCPConnForCluster(&lbContext{conn: p.readCallbacks}, snapshot)
+	if connectionData.Connection == nil {
+		return nil, errors.New("no healthy host")
+	}
+	return p.addUpstreamLocked(clusterName, connectionData.Connection)
+}
+
+// upstreamForAddress returns a connection to the exact host a MOVED or
+// ASK reply pointed at, the redirect target may live outside the
+// configured cluster
+func (p *proxy) upstreamForAddress(address string) (*upstreamConn, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if uc, ok := p.upstreams[address]; ok {
+		return uc, nil
+	}
+	addr, err := network.ParseTCPAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	return p.addUpstreamLocked(address, network.NewClientConnection(nil, nil, addr, nil))
+}
+
+func (p *proxy) addUpstreamLocked(key string, conn types.ClientConnection) (*upstreamConn, error) {
+	uc := &upstreamConn{
+		proxy: p,
+		key:   key,
+		conn:  conn,
+	}
+	conn.AddConnectionEventListener(uc)
+	conn.FilterManager().AddReadFilter(uc)
+	if err := conn.Connect(true); err != nil {
+		return nil, err
+	}
+	p.upstreams[key] = uc
+	return uc, nil
+}
+
+// finish records the command metrics and releases every reply that is
+// ready at the head of the queue, keeping the downstream order
+func (p *proxy) finish(pc *pendingCommand, reply []byte) {
+	stats := metrics.NewRedisStats(pc.command)
+	stats.Counter(metrics.RedisCommandTotal).Inc(1)
+	if len(reply) > 0 && reply[0] == respError {
+		stats.Counter(metrics.RedisCommandError).Inc(1)
+	}
+	stats.Histogram(metrics.RedisCommandDurationMs).Update(int64(time.Since(pc.start) / time.Millisecond))
+
+	p.mu.Lock()
+	pc.reply = reply
+	pc.done = true
+	var out []byte
+	for len(p.replies) > 0 && p.replies[0].done {
+		out = append(out, p.replies[0].reply...)
+		p.replies = p.replies[1:]
+	}
+	closed := p.closed
+	p.mu.Unlock()
+	if len(out) > 0 && !closed {
+		p.readCallbacks.Connection().Write(buffer.NewIoBufferBytes(out))
+	}
+}
+
+// redirect re-sends a command to the host a MOVED or ASK reply named,
+// returns false when the redirect cannot be followed
+func (p *proxy) redirect(pc *pendingCommand, r redirection) bool {
+	pc.redirects++
+	metrics.NewRedisStats(pc.command).Counter(metrics.RedisRedirectionTotal).Inc(1)
+	uc, err := p.upstreamForAddress(r.address)
+	if err != nil {
+		log.DefaultLogger.Errorf("[redisproxy] [redirect] connect %s failed: %v", r.address, err)
+		return false
+	}
+	if r.ask {
+		uc.send(&pendingCommand{command: "ASKING", raw: askingCommand, discard: true, start: time.Now()})
+	}
+	uc.send(pc)
+	return true
+}
+
+func (p *proxy) onDownstreamClose() {
+	p.mu.Lock()
+	p.closed = true
+	upstreams := p.upstreams
+	p.upstreams = make(map[string]*upstreamConn)
+	p.mu.Unlock()
+	for _, uc := range upstreams {
+		uc.conn.Close(types.NoFlush, types.LocalClose)
+	}
+}
+
+// removeUpstream drops a dead connection so the next command dials again
+func (p *proxy) removeUpstream(key string) {
+	p.mu.Lock()
+	delete(p.upstreams, key)
+	p.mu.Unlock()
+}
+
+// upstreamConn pairs one upstream connection with the fifo of commands
+// waiting for its replies, it is both the connection's read filter and
+// its event listener
+type upstreamConn struct {
+	proxy *proxy
+	key   string
+	conn  types.ClientConnection
+
+	mu      sync.Mutex
+	pending []*pendingCommand
+}
+
+func (uc *upstreamConn) send(pc *pendingCommand) {
+	uc.mu.Lock()
+	uc.pending = append(uc.pending, pc)
+	uc.mu.Unlock()
+	uc.conn.Write(buffer.NewIoBufferBytes(pc.raw))
+}
+
+func (uc *upstreamConn) pop() *pendingCommand {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	if len(uc.pending) == 0 {
+		return nil
+	}
+	pc := uc.pending[0]
+	uc.pending = uc.pending[1:]
+	return pc
+}
+
+func (uc *upstreamConn) OnData(buf types.IoBuffer) types.FilterStatus {
+	for buf.Len() > 0 {
+		frameLen, err := respFrameLen(buf.Bytes())
+		if err != nil {
+			log.DefaultLogger.Errorf("[redisproxy] [upstream] decode reply failed: %v", err)
+			uc.conn.Close(types.NoFlush, types.LocalClose)
+			return types.Stop
+		}
+		if frameLen == 0 {
+			break
+		}
+		frame := make([]byte, frameLen)
+		copy(frame, buf.Bytes()[:frameLen])
+		buf.Drain(frameLen)
+		uc.onReply(frame)
+	}
+	return types.Stop
+}
+
+func (uc *upstreamConn) onReply(frame []byte) {
+	pc := uc.pop()
+	if pc == nil {
+		log.DefaultLogger.Warnf("[redisproxy] [upstream] unexpected reply from %s", uc.key)
+		return
+	}
+	if pc.discard {
+		return
+	}
+	if r, ok := parseRedirection(frame); ok && pc.redirects < uc.proxy.config.MaxRedirections {
+		if uc.proxy.redirect(pc, r) {
+			return
+		}
+	}
+	uc.proxy.finish(pc, frame)
+}
+
+func (uc *upstreamConn) OnNewConnection() types.FilterStatus {
+	return types.Continue
+}
+
+func (uc *upstreamConn) InitializeReadFilterCallbacks(cb types.ReadFilterCallbacks) {}
+
+func (uc *upstreamConn) OnEvent(event types.ConnectionEvent) {
+	if !event.IsClose() && !event.ConnectFailure() {
+		return
+	}
+	uc.proxy.removeUpstream(uc.key)
+	// fail everything still waiting on this connection
+	for {
+		pc := uc.pop()
+		if pc == nil {
+			return
+		}
+		if pc.discard {
+			continue
+		}
+		uc.proxy.finish(pc, encodeError("upstream connection closed"))
+	}
+}
+
+// ConnectionEventListener
+type downstreamCallbacks struct {
+	proxy *proxy
+}
+
+func (dc *downstreamCallbacks) OnEvent(event types.ConnectionEvent) {
+	if event.IsClose() {
+		dc.proxy.onDownstreamClose()
+	}
+}
+
+// lbContext is a types.LoadBalancerContext implementation
+type lbContext struct {
+	conn types.ReadFilterCallbacks
+}
+
+func (c *lbContext) ComputeHashKey() types.HashedValue {
+	return ""
+}
+
+func (c *lbContext) MetadataMatchCriteria() types.MetadataMatchCriteria {
+	return nil
+}
+
+func (c *lbContext) DownstreamConnection() net.Conn {
+	return c.conn.Connection().RawConn()
+}
+
+// redis commands carry no header
+func (c *lbContext) DownstreamHeaders() types.HeaderMap {
+	return nil
+}
+
+func (c *lbContext) DownstreamContext() context.Context {
+	return nil
+}
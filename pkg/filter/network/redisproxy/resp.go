@@ -0,0 +1,182 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package redisproxy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// resp type markers, the first byte of every frame
+const (
+	respSimpleString = '+'
+	respError        = '-'
+	respInteger      = ':'
+	respBulkString   = '$'
+	respArray        = '*'
+)
+
+// readLine returns the content of the crlf terminated line starting at
+// offset and the offset right behind its crlf, next is -1 when the line
+// is not complete yet
+func readLine(data []byte, offset int) (line []byte, next int) {
+	for i := offset; i < len(data)-1; i++ {
+		if data[i] == '\r' && data[i+1] == '\n' {
+			return data[offset:i], i + 2
+		}
+	}
+	return nil, -1
+}
+
+// respFrameLen returns the length of the first complete resp frame in
+// data, 0 when the frame is not complete yet and an error when the bytes
+// are not resp at all
+func respFrameLen(data []byte) (int, error) {
+	if len(data) == 0 {
+		return 0, nil
+	}
+	switch data[0] {
+	case respSimpleString, respError, respInteger:
+		_, next := readLine(data, 1)
+		if next < 0 {
+			return 0, nil
+		}
+		return next, nil
+	case respBulkString:
+		line, next := readLine(data, 1)
+		if next < 0 {
+			return 0, nil
+		}
+		size, err := strconv.Atoi(string(line))
+		if err != nil {
+			return 0, fmt.Errorf("invalid resp bulk string length %q", line)
+		}
+		if size < 0 { // null bulk string
+			return next, nil
+		}
+		if len(data) < next+size+2 {
+			return 0, nil
+		}
+		return next + size + 2, nil
+	case respArray:
+		line, next := readLine(data, 1)
+		if next < 0 {
+			return 0, nil
+		}
+		count, err := strconv.Atoi(string(line))
+		if err != nil {
+			return 0, fmt.Errorf("invalid resp array length %q", line)
+		}
+		total := next
+		for i := 0; i < count; i++ {
+			size, err := respFrameLen(data[total:])
+			if err != nil {
+				return 0, err
+			}
+			if size == 0 {
+				return 0, nil
+			}
+			total += size
+		}
+		return total, nil
+	default:
+		return 0, fmt.Errorf("invalid resp type marker %q", data[0])
+	}
+}
+
+// decodeCommand decodes the first complete client command in data, an
+// array of bulk strings per the protocol. It returns the arguments and
+// the frame length, a zero frame length means more bytes are needed
+func decodeCommand(data []byte) (args [][]byte, frameLen int, err error) {
+	if len(data) == 0 {
+		return nil, 0, nil
+	}
+	if data[0] != respArray {
+		// inline commands are not supported, a proxied client always
+		// speaks the unified protocol
+		return nil, 0, fmt.Errorf("invalid resp command type marker %q", data[0])
+	}
+	line, next := readLine(data, 1)
+	if next < 0 {
+		return nil, 0, nil
+	}
+	count, err := strconv.Atoi(string(line))
+	if err != nil || count <= 0 {
+		return nil, 0, fmt.Errorf("invalid resp command array length %q", line)
+	}
+	args = make([][]byte, 0, count)
+	offset := next
+	for i := 0; i < count; i++ {
+		if offset >= len(data) {
+			return nil, 0, nil
+		}
+		if data[offset] != respBulkString {
+			return nil, 0, fmt.Errorf("invalid resp command argument type marker %q", data[offset])
+		}
+		line, next := readLine(data, offset+1)
+		if next < 0 {
+			return nil, 0, nil
+		}
+		size, err := strconv.Atoi(string(line))
+		if err != nil || size < 0 {
+			return nil, 0, fmt.Errorf("invalid resp command argument length %q", line)
+		}
+		if len(data) < next+size+2 {
+			return nil, 0, nil
+		}
+		args = append(args, data[next:next+size])
+		offset = next + size + 2
+	}
+	return args, offset, nil
+}
+
+// encodeError renders an error reply
+func encodeError(message string) []byte {
+	return []byte("-ERR " + message + "\r\n")
+}
+
+// redirection is a parsed MOVED or ASK error reply
+type redirection struct {
+	ask     bool
+	address string
+}
+
+// parseRedirection parses a cluster redirection out of an error frame,
+// the second return is false for every other reply
+func parseRedirection(frame []byte) (redirection, bool) {
+	if len(frame) == 0 || frame[0] != respError {
+		return redirection{}, false
+	}
+	line, next := readLine(frame, 1)
+	if next < 0 {
+		return redirection{}, false
+	}
+	// "MOVED 3999 127.0.0.1:6381" / "ASK 3999 127.0.0.1:6381"
+	fields := strings.Fields(string(line))
+	if len(fields) != 3 {
+		return redirection{}, false
+	}
+	switch fields[0] {
+	case "MOVED":
+		return redirection{address: fields[2]}, true
+	case "ASK":
+		return redirection{ask: true, address: fields[2]}, true
+	}
+	return redirection{}, false
+}
@@ -0,0 +1,125 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package redisproxy
+
+import (
+	"testing"
+)
+
+func TestDecodeCommand(t *testing.T) {
+	frame := []byte("*3\r\n$3\r\nSET\r\n$3\r\nfoo\r\n$3\r\nbar\r\n")
+	args, frameLen, err := decodeCommand(frame)
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if frameLen != len(frame) {
+		t.Errorf("expected frame length %d, got %d", len(frame), frameLen)
+	}
+	if len(args) != 3 || string(args[0]) != "SET" || string(args[1]) != "foo" || string(args[2]) != "bar" {
+		t.Errorf("unexpected arguments: %q", args)
+	}
+
+	// partial frames need more bytes, not an error
+	for i := 1; i < len(frame); i++ {
+		if _, frameLen, err := decodeCommand(frame[:i]); err != nil || frameLen != 0 {
+			t.Errorf("partial frame [:%d]: frameLen=%d err=%v", i, frameLen, err)
+		}
+	}
+
+	// inline commands are rejected
+	if _, _, err := decodeCommand([]byte("PING\r\n")); err == nil {
+		t.Errorf("inline command accepted")
+	}
+}
+
+func TestDecodeCommandPipelined(t *testing.T) {
+	first := "*1\r\n$4\r\nPING\r\n"
+	second := "*2\r\n$3\r\nGET\r\n$3\r\nfoo\r\n"
+	data := []byte(first + second)
+
+	args, frameLen, err := decodeCommand(data)
+	if err != nil || frameLen != len(first) || string(args[0]) != "PING" {
+		t.Fatalf("first command: args=%q frameLen=%d err=%v", args, frameLen, err)
+	}
+	args, frameLen, err = decodeCommand(data[frameLen:])
+	if err != nil || frameLen != len(second) || string(args[0]) != "GET" {
+		t.Fatalf("second command: args=%q frameLen=%d err=%v", args, frameLen, err)
+	}
+}
+
+func TestRespFrameLen(t *testing.T) {
+	cases := []struct {
+		frame    string
+		complete bool
+	}{
+		{"+OK\r\n", true},
+		{":1000\r\n", true},
+		{"-ERR something\r\n", true},
+		{"$3\r\nfoo\r\n", true},
+		{"$-1\r\n", true}, // null bulk string
+		{"*2\r\n$3\r\nfoo\r\n$3\r\nbar\r\n", true},
+		{"*2\r\n*2\r\n:1\r\n:2\r\n$3\r\nfoo\r\n", true}, // nested array
+		{"$3\r\nfo", false},
+		{"*2\r\n$3\r\nfoo\r\n", false},
+	}
+	for _, c := range cases {
+		frameLen, err := respFrameLen([]byte(c.frame))
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", c.frame, err)
+			continue
+		}
+		if c.complete && frameLen != len(c.frame) {
+			t.Errorf("%q: expected frame length %d, got %d", c.frame, len(c.frame), frameLen)
+		}
+		if !c.complete && frameLen != 0 {
+			t.Errorf("%q: incomplete frame got length %d", c.frame, frameLen)
+		}
+	}
+	if _, err := respFrameLen([]byte("?what\r\n")); err == nil {
+		t.Errorf("invalid type marker accepted")
+	}
+}
+
+func TestParseRedirection(t *testing.T) {
+	r, ok := parseRedirection([]byte("-MOVED 3999 127.0.0.1:6381\r\n"))
+	if !ok || r.ask || r.address != "127.0.0.1:6381" {
+		t.Errorf("moved not parsed: %v %v", r, ok)
+	}
+	r, ok = parseRedirection([]byte("-ASK 3999 127.0.0.1:6381\r\n"))
+	if !ok || !r.ask || r.address != "127.0.0.1:6381" {
+		t.Errorf("ask not parsed: %v %v", r, ok)
+	}
+	for _, frame := range []string{"-ERR unknown command\r\n", "+OK\r\n", "-MOVED 3999\r\n"} {
+		if _, ok := parseRedirection([]byte(frame)); ok {
+			t.Errorf("%q: parsed as redirection", frame)
+		}
+	}
+}
+
+func TestIsReadCommand(t *testing.T) {
+	for _, command := range []string{"GET", "MGET", "HGETALL", "ZRANGE"} {
+		if !isReadCommand(command) {
+			t.Errorf("%s: read command not recognized", command)
+		}
+	}
+	for _, command := range []string{"SET", "DEL", "INCR", "EVAL"} {
+		if isReadCommand(command) {
+			t.Errorf("%s: write command classified as read", command)
+		}
+	}
+}
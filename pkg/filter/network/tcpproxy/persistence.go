@@ -0,0 +1,143 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tcpproxy
+
+import (
+	"sync"
+	"time"
+
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+)
+
+// Some legacy stateful tcp apps require connection affinity: a client
+// reconnecting must reach the backend that holds its state. The
+// persistence table remembers the upstream host chosen for a client ip
+// and pins later connections from the same ip to it, bounded by a ttl
+// and a max entry count.
+
+const (
+	defaultPersistenceTTL        = time.Hour
+	defaultPersistenceMaxEntries = 10000
+)
+
+type persistenceEntry struct {
+	hostAddress string
+	expireAt    time.Time
+}
+
+type persistenceTable struct {
+	ttl        time.Duration
+	maxEntries int
+	mutex      sync.Mutex
+	entries    map[string]*persistenceEntry
+}
+
+func newPersistenceTable(cfg *v2.TCPPersistence) *persistenceTable {
+	ttl := cfg.TTL.Duration
+	if ttl <= 0 {
+		ttl = defaultPersistenceTTL
+	}
+	maxEntries := cfg.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = defaultPersistenceMaxEntries
+	}
+	return &persistenceTable{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*persistenceEntry),
+	}
+}
+
+// Get returns the pinned host address of the client ip, expired entries
+// are removed on access
+func (t *persistenceTable) Get(ip string, now time.Time) (string, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	entry, ok := t.entries[ip]
+	if !ok {
+		return "", false
+	}
+	if now.After(entry.expireAt) {
+		delete(t.entries, ip)
+		return "", false
+	}
+	return entry.hostAddress, true
+}
+
+// Put pins the client ip to the host address and restarts its ttl
+func (t *persistenceTable) Put(ip, hostAddress string, now time.Time) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if entry, ok := t.entries[ip]; ok {
+		entry.hostAddress = hostAddress
+		entry.expireAt = now.Add(t.ttl)
+		return
+	}
+	if len(t.entries) >= t.maxEntries {
+		t.evict(now)
+	}
+	if len(t.entries) >= t.maxEntries {
+		// still full after eviction, a new client loses affinity rather
+		// than growing the table without bound
+		return
+	}
+	t.entries[ip] = &persistenceEntry{
+		hostAddress: hostAddress,
+		expireAt:    now.Add(t.ttl),
+	}
+}
+
+// evict drops all expired entries, or an arbitrary one when nothing
+// expired yet
+func (t *persistenceTable) evict(now time.Time) {
+	evicted := false
+	for ip, entry := range t.entries {
+		if now.After(entry.expireAt) {
+			delete(t.entries, ip)
+			evicted = true
+		}
+	}
+	if evicted {
+		return
+	}
+	for ip := range t.entries {
+		delete(t.entries, ip)
+		return
+	}
+}
+
+// persistenceTables shares one table between all connections created
+// from the same filter config
+var (
+	persistenceTablesMutex sync.Mutex
+	persistenceTables      = make(map[*v2.TCPProxy]*persistenceTable)
+)
+
+func persistenceTableFor(config *v2.TCPProxy) *persistenceTable {
+	if config.Persistence == nil {
+		return nil
+	}
+	persistenceTablesMutex.Lock()
+	defer persistenceTablesMutex.Unlock()
+	if table, ok := persistenceTables[config]; ok {
+		return table
+	}
+	table := newPersistenceTable(config.Persistence)
+	persistenceTables[config] = table
+	return table
+}
@@ -0,0 +1,67 @@
+package tcpproxy
+
+import (
+	"testing"
+	"time"
+
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+)
+
+func Test_PersistenceTable_TTL(t *testing.T) {
+	table := newPersistenceTable(&v2.TCPPersistence{
+		TTL: v2.DurationConfig{Duration: time.Minute},
+	})
+	now := time.Now()
+	table.Put("10.0.0.1", "127.0.0.1:8080", now)
+	if addr, ok := table.Get("10.0.0.1", now); !ok || addr != "127.0.0.1:8080" {
+		t.Errorf("expected pinned host, got %q, %v", addr, ok)
+	}
+	// refreshing the pin restarts the ttl
+	table.Put("10.0.0.1", "127.0.0.1:8081", now.Add(30*time.Second))
+	if addr, ok := table.Get("10.0.0.1", now.Add(80*time.Second)); !ok || addr != "127.0.0.1:8081" {
+		t.Errorf("expected refreshed pin, got %q, %v", addr, ok)
+	}
+	if _, ok := table.Get("10.0.0.1", now.Add(3*time.Minute)); ok {
+		t.Error("expected pin to expire")
+	}
+}
+
+func Test_PersistenceTable_MaxEntries(t *testing.T) {
+	table := newPersistenceTable(&v2.TCPPersistence{
+		TTL:        v2.DurationConfig{Duration: time.Minute},
+		MaxEntries: 2,
+	})
+	now := time.Now()
+	table.Put("10.0.0.1", "h1", now)
+	table.Put("10.0.0.2", "h2", now.Add(30*time.Second))
+	// the table is full and nothing expired, an arbitrary entry is evicted
+	table.Put("10.0.0.3", "h3", now.Add(40*time.Second))
+	if len(table.entries) != 2 {
+		t.Errorf("table should stay bounded, got %d entries", len(table.entries))
+	}
+	if _, ok := table.Get("10.0.0.3", now.Add(40*time.Second)); !ok {
+		t.Error("new client should be pinned after eviction")
+	}
+	// expired entries are preferred for eviction
+	table.Put("10.0.0.4", "h4", now.Add(2*time.Minute))
+	if _, ok := table.Get("10.0.0.4", now.Add(2*time.Minute)); !ok {
+		t.Error("new client should replace expired pins")
+	}
+}
+
+func Test_PersistenceTableFor(t *testing.T) {
+	cfg := &v2.TCPProxy{
+		Persistence: &v2.TCPPersistence{},
+	}
+	t1 := persistenceTableFor(cfg)
+	t2 := persistenceTableFor(cfg)
+	if t1 == nil || t1 != t2 {
+		t.Error("connections of one filter config should share a table")
+	}
+	if t1.ttl != defaultPersistenceTTL || t1.maxEntries != defaultPersistenceMaxEntries {
+		t.Error("zero config values should fall back to defaults")
+	}
+	if persistenceTableFor(&v2.TCPProxy{}) != nil {
+		t.Error("no persistence config means no table")
+	}
+}
@@ -19,10 +19,12 @@ package tcpproxy
 
 import (
 	"context"
+	"math/rand"
 	"net"
 	"reflect"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"sofastack.io/sofa-mosn/pkg/api/v2"
@@ -46,6 +48,14 @@ type proxy struct {
 	upstreamConnecting bool
 
 	accessLogs []types.AccessLog
+
+	// idle timeout enforcement, lastActive is the unix nano of the last
+	// byte seen in either direction
+	lastActive int64
+	idleTimer  *time.Timer
+
+	// client ip -> upstream host affinity, nil when not configured
+	persistence *persistenceTable
 }
 
 func NewProxy(ctx context.Context, config *v2.TCPProxy, clusterManager types.ClusterManager) Proxy {
@@ -54,6 +64,7 @@ func NewProxy(ctx context.Context, config *v2.TCPProxy, clusterManager types.Clu
 		clusterManager: clusterManager,
 		requestInfo:    network.NewRequestInfo(),
 		accessLogs:     mosnctx.Get(ctx, types.ContextKeyAccessLogs).([]types.AccessLog),
+		persistence:    persistenceTableFor(config),
 	}
 
 	p.upstreamCallbacks = &upstreamCallbacks{
@@ -72,6 +83,7 @@ func (p *proxy) OnData(buffer types.IoBuffer) types.FilterStatus {
 	}
 	bytesRecved := p.requestInfo.BytesReceived() + uint64(buffer.Len())
 	p.requestInfo.SetBytesReceived(bytesRecved)
+	p.recordActivity()
 
 	p.upstreamConnection.Write(buffer.Clone())
 	buffer.Drain(buffer.Len())
@@ -119,10 +131,16 @@ func (p *proxy) initializeUpstreamConnection() types.FilterStatus {
 		return types.Stop
 	}
 
-	ctx := &LbContext{
-		conn: p.readCallbacks,
+	var connectionData types.CreateConnectionData
+	if host := p.persistentHost(clusterSnapshot); host != nil {
+		connectionData = host.CreateConnection(context.Background())
+	} else {
+		ctx := &LbContext{
+			conn: p.readCallbacks,
+		}
+		connectionData = p.clusterManager.TCPConnForCluster(ctx, clusterSnapshot)
+		p.rememberHost(connectionData.HostInfo)
 	}
-	connectionData := p.clusterManager.TCPConnForCluster(ctx, clusterSnapshot)
 	if connectionData.Connection == nil {
 		p.requestInfo.SetResponseFlag(types.NoHealthyUpstream)
 		p.onInitFailure(NoHealthyUpstream)
@@ -162,6 +180,57 @@ func (p *proxy) getUpstreamCluster() string {
 	return p.config.GetRouteFromEntries(downstreamConnection)
 }
 
+// persistentHost returns the pinned upstream host of the downstream
+// client ip, a host that left the cluster or turned unhealthy releases
+// the pin
+func (p *proxy) persistentHost(clusterSnapshot types.ClusterSnapshot) types.Host {
+	if p.persistence == nil {
+		return nil
+	}
+	ip := p.clientIP()
+	if ip == "" {
+		return nil
+	}
+	addr, ok := p.persistence.Get(ip, time.Now())
+	if !ok {
+		return nil
+	}
+	for _, hostSet := range clusterSnapshot.PrioritySet().HostSetsByPriority() {
+		for _, host := range hostSet.HealthyHosts() {
+			if host.AddressString() == addr {
+				if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
+					log.DefaultLogger.Debugf("[tcpproxy] client %s pinned to upstream %s", ip, addr)
+				}
+				return host
+			}
+		}
+	}
+	return nil
+}
+
+func (p *proxy) rememberHost(host types.HostInfo) {
+	if p.persistence == nil || host == nil {
+		return
+	}
+	ip := p.clientIP()
+	if ip == "" {
+		return
+	}
+	p.persistence.Put(ip, host.AddressString(), time.Now())
+}
+
+func (p *proxy) clientIP() string {
+	remote := p.readCallbacks.Connection().RemoteAddr()
+	if remote == nil {
+		return ""
+	}
+	addr := remote.String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
 func (p *proxy) onInitFailure(reason UpstreamFailureReason) {
 	p.readCallbacks.Connection().Close(types.NoFlush, types.LocalClose)
 }
@@ -170,6 +239,7 @@ func (p *proxy) onUpstreamData(buffer types.IoBuffer) {
 	log.DefaultLogger.Tracef("Tcp Proxy :: read upstream data , len = %v", buffer.Len())
 	bytesSent := p.requestInfo.BytesSent() + uint64(buffer.Len())
 	p.requestInfo.SetBytesSent(bytesSent)
+	p.recordActivity()
 
 	p.readCallbacks.Connection().Write(buffer.Clone())
 	buffer.Drain(buffer.Len())
@@ -206,6 +276,40 @@ func (p *proxy) finalizeUpstreamConnectionStats() {
 
 func (p *proxy) onConnectionSuccess() {
 	log.DefaultLogger.Debugf("new upstream connection %d created", p.upstreamConnection.ID())
+	p.startIdleChecker()
+}
+
+func (p *proxy) recordActivity() {
+	atomic.StoreInt64(&p.lastActive, time.Now().UnixNano())
+}
+
+// startIdleChecker closes both connections when no bytes are sent or
+// received in either direction within the configured idle timeout
+func (p *proxy) startIdleChecker() {
+	idleTimeout := p.config.GetIdleTimeout()
+	if idleTimeout == nil || *idleTimeout <= 0 {
+		return
+	}
+	timeout := *idleTimeout
+	p.recordActivity()
+	p.idleTimer = time.AfterFunc(timeout, func() {
+		p.onIdleChecker(timeout)
+	})
+}
+
+func (p *proxy) onIdleChecker(timeout time.Duration) {
+	idle := time.Since(time.Unix(0, atomic.LoadInt64(&p.lastActive)))
+	if idle >= timeout {
+		log.DefaultLogger.Infof("[tcpproxy] connection idle for %v, close", idle)
+		p.requestInfo.SetResponseFlag(types.UpstreamRequestTimeout)
+		// closing downstream tears the upstream down via onDownstreamEvent
+		p.readCallbacks.Connection().Close(types.NoFlush, types.LocalClose)
+		return
+	}
+	// not idle long enough yet, re-arm for the remaining time
+	p.idleTimer = time.AfterFunc(timeout-idle, func() {
+		p.onIdleChecker(timeout)
+	})
 }
 
 func (p *proxy) onDownstreamEvent(event types.ConnectionEvent) {
@@ -218,6 +322,9 @@ func (p *proxy) onDownstreamEvent(event types.ConnectionEvent) {
 	}
 
 	if event.IsClose() {
+		if p.idleTimer != nil {
+			p.idleTimer.Stop()
+		}
 		for _, al := range p.accessLogs {
 			al.Log(nil, nil, p.requestInfo)
 		}
@@ -235,11 +342,18 @@ func (p *proxy) ReadDisableDownstream(disable bool) {
 type proxyConfig struct {
 	statPrefix         string
 	cluster            string
+	weightedClusters   []weightedClusterEntry
+	totalClusterWeight uint32
 	idleTimeout        *time.Duration
 	maxConnectAttempts uint32
 	routes             []*route
 }
 
+type weightedClusterEntry struct {
+	clusterName string
+	weight      uint32
+}
+
 type IpRangeList struct {
 	cidrRanges []v2.CidrRange
 }
@@ -337,21 +451,56 @@ func NewProxyConfig(config *v2.TCPProxy) ProxyConfig {
 		routes = append(routes, route)
 	}
 
+	var weightedClusters []weightedClusterEntry
+	var totalWeight uint32
+	for _, weightedCluster := range config.WeightedClusters {
+		totalWeight += weightedCluster.Cluster.Weight
+		weightedClusters = append(weightedClusters, weightedClusterEntry{
+			clusterName: weightedCluster.Cluster.Name,
+			weight:      weightedCluster.Cluster.Weight,
+		})
+	}
+
 	return &proxyConfig{
 		statPrefix:         config.StatPrefix,
 		cluster:            config.Cluster,
+		weightedClusters:   weightedClusters,
+		totalClusterWeight: totalWeight,
 		idleTimeout:        config.IdleTimeout,
 		maxConnectAttempts: config.MaxConnectAttempts,
 		routes:             routes,
 	}
 }
 
+func (pc *proxyConfig) GetIdleTimeout() *time.Duration {
+	return pc.idleTimeout
+}
+
+// pickWeightedCluster selects a cluster based on the configured weights
+func (pc *proxyConfig) pickWeightedCluster() string {
+	random := rand.Uint32() % pc.totalClusterWeight
+	for _, entry := range pc.weightedClusters {
+		if random < entry.weight {
+			return entry.clusterName
+		}
+		random -= entry.weight
+	}
+	// never happen
+	return pc.weightedClusters[len(pc.weightedClusters)-1].clusterName
+}
+
 func (pc *proxyConfig) GetRouteFromEntries(connection types.Connection) string {
 	if pc.cluster != "" {
 		log.DefaultLogger.Tracef("Tcp Proxy get cluster from config , cluster name = %v", pc.cluster)
 		return pc.cluster
 	}
 
+	if len(pc.weightedClusters) > 0 && pc.totalClusterWeight > 0 {
+		cluster := pc.pickWeightedCluster()
+		log.DefaultLogger.Tracef("Tcp Proxy get weighted cluster , cluster name = %v", cluster)
+		return cluster
+	}
+
 	log.DefaultLogger.Tracef("Tcp Proxy get route from entries , connection = %v", connection)
 	for _, r := range pc.routes {
 		log.DefaultLogger.Tracef("Tcp Proxy check one route = %v", r)
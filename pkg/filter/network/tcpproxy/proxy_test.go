@@ -39,3 +39,34 @@ func Test_ParsePortRangeList(t *testing.T) {
 		t.Errorf("test  port range fail")
 	}
 }
+
+func Test_WeightedClusterRoute(t *testing.T) {
+	config := NewProxyConfig(&v2.TCPProxy{
+		WeightedClusters: []*v2.WeightedCluster{
+			{Cluster: v2.ClusterWeight{ClusterWeightConfig: v2.ClusterWeightConfig{Name: "cluster-a", Weight: 90}}},
+			{Cluster: v2.ClusterWeight{ClusterWeightConfig: v2.ClusterWeightConfig{Name: "cluster-b", Weight: 10}}},
+		},
+	})
+	counts := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		counts[config.GetRouteFromEntries(nil)]++
+	}
+	if counts["cluster-a"] == 0 || counts["cluster-b"] == 0 {
+		t.Errorf("both weighted clusters should be selected, got %v", counts)
+	}
+	if counts["cluster-a"] <= counts["cluster-b"] {
+		t.Errorf("selection should follow weights, got %v", counts)
+	}
+}
+
+func Test_ExplicitClusterBeatsWeighted(t *testing.T) {
+	config := NewProxyConfig(&v2.TCPProxy{
+		Cluster: "explicit",
+		WeightedClusters: []*v2.WeightedCluster{
+			{Cluster: v2.ClusterWeight{ClusterWeightConfig: v2.ClusterWeightConfig{Name: "cluster-a", Weight: 100}}},
+		},
+	})
+	if got := config.GetRouteFromEntries(nil); got != "explicit" {
+		t.Errorf("explicit cluster should win, got %q", got)
+	}
+}
@@ -18,6 +18,8 @@
 package tcpproxy
 
 import (
+	"time"
+
 	"sofastack.io/sofa-mosn/pkg/types"
 )
 
@@ -33,6 +35,7 @@ type Proxy interface {
 // ProxyConfig
 type ProxyConfig interface {
 	GetRouteFromEntries(connection types.Connection) string
+	GetIdleTimeout() *time.Duration
 }
 
 // UpstreamCallbacks for upstream's callbacks
@@ -0,0 +1,202 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package grpcweb bridges gRPC-Web browser clients to native gRPC
+// upstreams. Requests with an application/grpc-web content-type are
+// rewritten to application/grpc (base64-decoded for the -text variant),
+// and response trailers are folded back into the body as the trailer
+// frame the gRPC-Web protocol expects.
+package grpcweb
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"net/http"
+	"strings"
+
+	"sofastack.io/sofa-mosn/pkg/buffer"
+	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+const (
+	grpcContentType        = "application/grpc"
+	grpcWebContentType     = "application/grpc-web"
+	grpcWebTextContentType = "application/grpc-web-text"
+
+	// trailerFrameFlag marks the gRPC-Web frame carrying the trailers
+	trailerFrameFlag = 0x80
+)
+
+// grpcWebFilter implements both the receiver and the sender side of the
+// bridge, the sender side only acts on streams the receiver side marked
+// as gRPC-Web
+type grpcWebFilter struct {
+	ctx             context.Context
+	receiverHandler types.StreamReceiverFilterHandler
+	senderHandler   types.StreamSenderFilterHandler
+
+	active bool
+	text   bool
+	suffix string // content-type suffix, e.g. "+proto"
+}
+
+func NewFilter(ctx context.Context) *grpcWebFilter {
+	if log.Proxy.GetLogLevel() >= log.DEBUG {
+		log.Proxy.Debugf(ctx, "[stream filter] [grpc_web] create a new grpc-web filter")
+	}
+	return &grpcWebFilter{
+		ctx: ctx,
+	}
+}
+
+func (f *grpcWebFilter) SetReceiveFilterHandler(handler types.StreamReceiverFilterHandler) {
+	f.receiverHandler = handler
+}
+
+func (f *grpcWebFilter) SetSenderFilterHandler(handler types.StreamSenderFilterHandler) {
+	f.senderHandler = handler
+}
+
+// parseWebContentType splits a gRPC-Web content-type into its text mode
+// and suffix, ok is false for anything that is not gRPC-Web
+func parseWebContentType(ct string) (text bool, suffix string, ok bool) {
+	for _, base := range []string{grpcWebTextContentType, grpcWebContentType} {
+		if !strings.HasPrefix(ct, base) {
+			continue
+		}
+		rest := ct[len(base):]
+		if rest == "" || rest[0] == '+' {
+			return base == grpcWebTextContentType, rest, true
+		}
+	}
+	return false, "", false
+}
+
+// decodeWebText decodes a gRPC-Web text body. Clients may base64-encode
+// each frame on its own, so the body can be several padded base64 chunks
+// back to back
+func decodeWebText(in []byte) ([]byte, error) {
+	out := make([]byte, 0, base64.StdEncoding.DecodedLen(len(in)))
+	for len(in) > 0 {
+		chunk := in
+		if idx := bytes.IndexByte(in, '='); idx >= 0 {
+			end := idx + 1
+			for end < len(in) && in[end] == '=' {
+				end++
+			}
+			chunk = in[:end]
+		}
+		decoded, err := base64.StdEncoding.DecodeString(string(chunk))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, decoded...)
+		in = in[len(chunk):]
+	}
+	return out, nil
+}
+
+// encodeTrailerFrame renders the trailers into the length-prefixed frame
+// gRPC-Web clients read them from, the MSB of the flag marks trailers
+func encodeTrailerFrame(trailers types.HeaderMap) []byte {
+	var payload bytes.Buffer
+	trailers.Range(func(key, value string) bool {
+		payload.WriteString(strings.ToLower(key))
+		payload.WriteString(": ")
+		payload.WriteString(value)
+		payload.WriteString("\r\n")
+		return true
+	})
+
+	frame := make([]byte, 5+payload.Len())
+	frame[0] = trailerFrameFlag
+	binary.BigEndian.PutUint32(frame[1:5], uint32(payload.Len()))
+	copy(frame[5:], payload.Bytes())
+	return frame
+}
+
+func (f *grpcWebFilter) OnReceive(ctx context.Context, headers types.HeaderMap, buf types.IoBuffer, trailers types.HeaderMap) types.StreamFilterStatus {
+	if headers == nil {
+		return types.StreamFilterContinue
+	}
+	ct, _ := headers.Get("content-type")
+	text, suffix, ok := parseWebContentType(ct)
+	if !ok {
+		return types.StreamFilterContinue
+	}
+	f.active = true
+	f.text = text
+	f.suffix = suffix
+
+	// the rewritten content-type also keeps a second filter pass a no-op
+	headers.Set("content-type", grpcContentType+suffix)
+	headers.Del("content-length")
+	headers.Set("te", "trailers")
+
+	if text && buf != nil && buf.Len() > 0 {
+		decoded, err := decodeWebText(buf.Bytes())
+		if err != nil {
+			log.Proxy.Errorf(ctx, "[stream filter] [grpc_web] invalid base64 body: %v", err)
+			f.receiverHandler.SendHijackReply(http.StatusBadRequest, headers)
+			return types.StreamFilterStop
+		}
+		buf.Drain(buf.Len())
+		buf.Write(decoded)
+	}
+	return types.StreamFilterContinue
+}
+
+func (f *grpcWebFilter) Append(ctx context.Context, headers types.HeaderMap, buf types.IoBuffer, trailers types.HeaderMap) types.StreamFilterStatus {
+	if !f.active || headers == nil {
+		return types.StreamFilterContinue
+	}
+
+	if ct, _ := headers.Get("content-type"); strings.HasPrefix(ct, grpcContentType) {
+		base := grpcWebContentType
+		if f.text {
+			base = grpcWebTextContentType
+		}
+		headers.Set("content-type", base+f.suffix)
+	}
+	// the body is rewritten below, any upstream length is stale
+	headers.Del("content-length")
+
+	out := buf
+	if out == nil {
+		out = buffer.NewIoBuffer(0)
+	}
+	if trailers != nil {
+		// browsers cannot read http trailers, fold them into the body
+		out.Write(encodeTrailerFrame(trailers))
+		f.senderHandler.SetResponseTrailers(nil)
+	}
+	if f.text && out.Len() > 0 {
+		encoded := make([]byte, base64.StdEncoding.EncodedLen(out.Len()))
+		base64.StdEncoding.Encode(encoded, out.Bytes())
+		out.Drain(out.Len())
+		out.Write(encoded)
+	}
+	if buf == nil && out.Len() > 0 {
+		f.senderHandler.SetResponseData(out)
+	}
+	return types.StreamFilterContinue
+}
+
+func (f *grpcWebFilter) OnDestroy() {}
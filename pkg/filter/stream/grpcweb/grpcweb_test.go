@@ -0,0 +1,165 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package grpcweb
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"sofastack.io/sofa-mosn/pkg/buffer"
+	"sofastack.io/sofa-mosn/pkg/protocol"
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+// mockSenderHandler implements types.StreamSenderFilterHandler for test
+type mockSenderHandler struct {
+	data     types.IoBuffer
+	trailers types.HeaderMap
+}
+
+func (m *mockSenderHandler) Route() types.Route                         { return nil }
+func (m *mockSenderHandler) RequestInfo() types.RequestInfo             { return nil }
+func (m *mockSenderHandler) Connection() types.Connection               { return nil }
+func (m *mockSenderHandler) GetResponseHeaders() types.HeaderMap        { return nil }
+func (m *mockSenderHandler) SetResponseHeaders(headers types.HeaderMap) {}
+func (m *mockSenderHandler) GetResponseData() types.IoBuffer            { return m.data }
+func (m *mockSenderHandler) SetResponseData(buf types.IoBuffer)         { m.data = buf }
+func (m *mockSenderHandler) GetResponseTrailers() types.HeaderMap       { return m.trailers }
+func (m *mockSenderHandler) SetResponseTrailers(trailers types.HeaderMap) {
+	m.trailers = trailers
+}
+
+func grpcFrame(payload []byte) []byte {
+	frame := make([]byte, 5+len(payload))
+	frame[4] = byte(len(payload))
+	copy(frame[5:], payload)
+	return frame
+}
+
+func TestParseWebContentType(t *testing.T) {
+	cases := []struct {
+		ct     string
+		text   bool
+		suffix string
+		ok     bool
+	}{
+		{"application/grpc-web", false, "", true},
+		{"application/grpc-web+proto", false, "+proto", true},
+		{"application/grpc-web-text", true, "", true},
+		{"application/grpc-web-text+proto", true, "+proto", true},
+		{"application/grpc", false, "", false},
+		{"application/json", false, "", false},
+	}
+	for _, c := range cases {
+		text, suffix, ok := parseWebContentType(c.ct)
+		if text != c.text || suffix != c.suffix || ok != c.ok {
+			t.Errorf("%q: expected (%v, %q, %v), got (%v, %q, %v)", c.ct, c.text, c.suffix, c.ok, text, suffix, ok)
+		}
+	}
+}
+
+func TestDecodeWebTextChunks(t *testing.T) {
+	// two frames, each base64 encoded on its own as browsers may send them
+	frame1 := grpcFrame([]byte("hello"))
+	frame2 := grpcFrame([]byte("world!"))
+	encoded := base64.StdEncoding.EncodeToString(frame1) + base64.StdEncoding.EncodeToString(frame2)
+
+	decoded, err := decodeWebText([]byte(encoded))
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if !bytes.Equal(decoded, append(frame1, frame2...)) {
+		t.Errorf("decoded body mismatch: %v", decoded)
+	}
+
+	if _, err := decodeWebText([]byte("not@base64")); err == nil {
+		t.Error("invalid base64 should be rejected")
+	}
+}
+
+func TestGrpcWebRequestTranslation(t *testing.T) {
+	f := NewFilter(context.Background())
+	headers := protocol.CommonHeader(map[string]string{
+		"content-type":   "application/grpc-web-text+proto",
+		"content-length": "20",
+	})
+	frame := grpcFrame([]byte("request"))
+	buf := buffer.NewIoBufferString(base64.StdEncoding.EncodeToString(frame))
+
+	if status := f.OnReceive(context.Background(), headers, buf, nil); status != types.StreamFilterContinue {
+		t.Fatalf("unexpected filter status: %v", status)
+	}
+	if ct, _ := headers.Get("content-type"); ct != "application/grpc+proto" {
+		t.Errorf("content-type not rewritten: %s", ct)
+	}
+	if _, ok := headers.Get("content-length"); ok {
+		t.Error("content-length should be removed")
+	}
+	if !bytes.Equal(buf.Bytes(), frame) {
+		t.Errorf("body not decoded: %v", buf.Bytes())
+	}
+}
+
+func TestGrpcWebResponseTranslation(t *testing.T) {
+	f := NewFilter(context.Background())
+	handler := &mockSenderHandler{}
+	f.SetSenderFilterHandler(handler)
+
+	reqHeaders := protocol.CommonHeader(map[string]string{"content-type": "application/grpc-web"})
+	f.OnReceive(context.Background(), reqHeaders, nil, nil)
+
+	respHeaders := protocol.CommonHeader(map[string]string{"content-type": "application/grpc"})
+	frame := grpcFrame([]byte("response"))
+	buf := buffer.NewIoBufferBytes(append([]byte(nil), frame...))
+	trailers := protocol.CommonHeader(map[string]string{"grpc-status": "0"})
+	handler.trailers = trailers
+
+	if status := f.Append(context.Background(), respHeaders, buf, trailers); status != types.StreamFilterContinue {
+		t.Fatalf("unexpected filter status: %v", status)
+	}
+	if ct, _ := respHeaders.Get("content-type"); ct != "application/grpc-web" {
+		t.Errorf("content-type not rewritten: %s", ct)
+	}
+	if handler.trailers != nil {
+		t.Error("trailers should be folded into the body")
+	}
+	body := buf.Bytes()
+	if !bytes.HasPrefix(body, frame) {
+		t.Fatalf("data frame should be kept: %v", body)
+	}
+	trailerFrame := body[len(frame):]
+	if len(trailerFrame) < 5 || trailerFrame[0] != trailerFrameFlag {
+		t.Fatalf("missing trailer frame: %v", trailerFrame)
+	}
+	if !bytes.Contains(trailerFrame[5:], []byte("grpc-status: 0\r\n")) {
+		t.Errorf("trailer payload mismatch: %q", trailerFrame[5:])
+	}
+}
+
+func TestGrpcWebResponsePassthrough(t *testing.T) {
+	// responses of non gRPC-Web streams are untouched
+	f := NewFilter(context.Background())
+	headers := protocol.CommonHeader(map[string]string{"content-type": "application/grpc"})
+	trailers := protocol.CommonHeader(map[string]string{"grpc-status": "0"})
+	f.Append(context.Background(), headers, nil, trailers)
+	if ct, _ := headers.Get("content-type"); ct != "application/grpc" {
+		t.Errorf("passthrough response modified: %s", ct)
+	}
+}
@@ -0,0 +1,265 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+	"sofastack.io/sofa-mosn/pkg/config"
+	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/metrics"
+	mosnhttp "sofastack.io/sofa-mosn/pkg/protocol/http"
+	"sofastack.io/sofa-mosn/pkg/types"
+	"github.com/json-iterator/go"
+	gometrics "github.com/rcrowley/go-metrics"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// rateLimitStats counts the token bucket activity of the filter
+type rateLimitStats struct {
+	tokensFilled    gometrics.Counter
+	tokensConsumed  gometrics.Counter
+	requestsBlocked gometrics.Counter
+}
+
+var stats = func() *rateLimitStats {
+	m, _ := metrics.NewMetrics("stream_filter", map[string]string{"filter": v2.RateLimitStream})
+	return &rateLimitStats{
+		tokensFilled:    m.Counter("tokens_filled"),
+		tokensConsumed:  m.Counter("tokens_consumed"),
+		requestsBlocked: m.Counter("requests_blocked"),
+	}
+}()
+
+// defaultMaxKeys bounds the descriptor table when max_keys is not configured
+const defaultMaxKeys = 10000
+
+// tokenBucket holds the tokens of one descriptor, refilled lazily on access
+type tokenBucket struct {
+	tokens   float64
+	lastFill time.Time
+}
+
+// rateLimiter owns the token buckets built from one filter config.
+// All streams sharing the config consume from the same buckets.
+type rateLimiter struct {
+	rate    float64 // tokens refilled per second
+	burst   float64 // bucket capacity
+	maxKeys int
+	mutex   sync.Mutex
+	buckets map[string]*tokenBucket
+	// descriptors beyond maxKeys share one bucket, keeps the table bounded
+	overflow *tokenBucket
+}
+
+func newRateLimiter(cfg *v2.StreamRateLimit) *rateLimiter {
+	burst := float64(cfg.Burst)
+	if burst < 1 {
+		burst = float64(cfg.RequestsPerSecond)
+	}
+	maxKeys := cfg.MaxKeys
+	if maxKeys <= 0 {
+		maxKeys = defaultMaxKeys
+	}
+	return &rateLimiter{
+		rate:    float64(cfg.RequestsPerSecond),
+		burst:   burst,
+		maxKeys: maxKeys,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// allow consumes one token from the bucket of the descriptor key,
+// returns false if the bucket is empty
+func (l *rateLimiter) allow(key string, now time.Time) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	bucket, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) < l.maxKeys {
+			bucket = l.newBucket(now)
+			l.buckets[key] = bucket
+		} else {
+			if l.overflow == nil {
+				l.overflow = l.newBucket(now)
+			}
+			bucket = l.overflow
+		}
+	}
+	l.fill(bucket, now)
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	stats.tokensConsumed.Inc(1)
+	return true
+}
+
+// a new bucket starts full so a burst of new descriptors is not rejected
+func (l *rateLimiter) newBucket(now time.Time) *tokenBucket {
+	stats.tokensFilled.Inc(int64(l.burst))
+	return &tokenBucket{
+		tokens:   l.burst,
+		lastFill: now,
+	}
+}
+
+func (l *rateLimiter) fill(bucket *tokenBucket, now time.Time) {
+	added := now.Sub(bucket.lastFill).Seconds() * l.rate
+	if added <= 0 {
+		return
+	}
+	if bucket.tokens+added > l.burst {
+		added = l.burst - bucket.tokens
+	}
+	bucket.tokens += added
+	bucket.lastFill = now
+	stats.tokensFilled.Inc(int64(added))
+}
+
+// limiters shares one rateLimiter between identical configs, so the
+// per route override of every stream consumes from the same buckets
+var (
+	limitersMutex sync.Mutex
+	limiters      = make(map[string]*rateLimiter)
+)
+
+func limiterForConfig(cfg *v2.StreamRateLimit) *rateLimiter {
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		return newRateLimiter(cfg)
+	}
+	key := string(b)
+	limitersMutex.Lock()
+	defer limitersMutex.Unlock()
+	if limiter, ok := limiters[key]; ok {
+		return limiter
+	}
+	limiter := newRateLimiter(cfg)
+	limiters[key] = limiter
+	return limiter
+}
+
+// TODO: this is a hack for per route config parse
+// delete it later, when per route config changes to map[string]interface{}
+func parseStreamRateLimitConfig(c interface{}) (*v2.StreamRateLimit, bool) {
+	conf := make(map[string]interface{})
+	b, err := json.Marshal(c)
+	if err != nil {
+		log.DefaultLogger.Errorf("config is not a json, %v", err)
+		return nil, false
+	}
+	json.Unmarshal(b, &conf)
+	cfg, err := config.ParseStreamRateLimitFilter(conf)
+	if err != nil {
+		log.DefaultLogger.Errorf("config is not stream rate limit", err)
+		return nil, false
+	}
+	return cfg, true
+}
+
+// rateLimitFilter is an implement of types.StreamReceiverFilter
+type rateLimitFilter struct {
+	ctx     context.Context
+	handler types.StreamReceiverFilterHandler
+	config  *v2.StreamRateLimit
+	limiter *rateLimiter
+}
+
+func NewFilter(ctx context.Context, cfg *v2.StreamRateLimit) types.StreamReceiverFilter {
+	if log.Proxy.GetLogLevel() >= log.DEBUG {
+		log.Proxy.Debugf(ctx, "[stream filter] [rate limit] create a new rate limit filter")
+	}
+	return &rateLimitFilter{
+		ctx:     ctx,
+		config:  cfg,
+		limiter: limiterForConfig(cfg),
+	}
+}
+
+// ReadPerRouteConfig makes route-level configuration override filter-level configuration
+func (f *rateLimitFilter) ReadPerRouteConfig(cfg map[string]interface{}) {
+	if cfg == nil {
+		return
+	}
+	if limit, ok := cfg[v2.RateLimitStream]; ok {
+		if config, ok := parseStreamRateLimitConfig(limit); ok {
+			if log.Proxy.GetLogLevel() >= log.DEBUG {
+				log.Proxy.Debugf(f.ctx, "[stream filter] [rate limit] use router config to replace stream filter config, config: %v", limit)
+			}
+			f.config = config
+			f.limiter = limiterForConfig(config)
+		}
+	}
+}
+
+func (f *rateLimitFilter) SetReceiveFilterHandler(handler types.StreamReceiverFilterHandler) {
+	f.handler = handler
+}
+
+func (f *rateLimitFilter) OnReceive(ctx context.Context, headers types.HeaderMap, buf types.IoBuffer, trailers types.HeaderMap) types.StreamFilterStatus {
+	if route := f.handler.Route(); route != nil {
+		// TODO: makes ReadPerRouteConfig as the StreamReceiverFilter's function
+		f.ReadPerRouteConfig(route.RouteRule().PerFilterConfig())
+	}
+	if f.limiter.allow(f.descriptorKey(headers), time.Now()) {
+		return types.StreamFilterContinue
+	}
+	if log.Proxy.GetLogLevel() >= log.DEBUG {
+		log.Proxy.Debugf(f.ctx, "[stream filter] [rate limit] request is rate limited")
+	}
+	stats.requestsBlocked.Inc(1)
+	f.handler.RequestInfo().SetResponseFlag(types.RateLimited)
+	// sofarpc maps 429 to a threadpool busy response status
+	f.handler.SendHijackReply(mosnhttp.TooManyRequests, headers)
+	return types.StreamFilterStop
+}
+
+func (f *rateLimitFilter) OnDestroy() {}
+
+// descriptorKey builds the bucket key from the configured headers and
+// the downstream remote address
+func (f *rateLimitFilter) descriptorKey(headers types.HeaderMap) string {
+	parts := make([]string, 0, len(f.config.KeyHeaders)+1)
+	for _, name := range f.config.KeyHeaders {
+		value, _ := headers.Get(name)
+		parts = append(parts, name+"="+value)
+	}
+	if f.config.KeyRemoteAddress {
+		parts = append(parts, "addr="+f.remoteAddress())
+	}
+	return strings.Join(parts, "|")
+}
+
+func (f *rateLimitFilter) remoteAddress() string {
+	conn := f.handler.Connection()
+	if conn == nil || conn.RemoteAddr() == nil {
+		return ""
+	}
+	addr := conn.RemoteAddr().String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
@@ -0,0 +1,228 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+	"sofastack.io/sofa-mosn/pkg/protocol"
+	mosnhttp "sofastack.io/sofa-mosn/pkg/protocol/http"
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+// this file mocks the interface that used for test
+// only implement the function that used in test
+type mockStreamReceiverFilterHandler struct {
+	types.StreamReceiverFilterHandler
+	route      *mockRoute
+	conn       *mockConnection
+	hijackCode int
+	info       *mockRequestInfo
+}
+
+func (cb *mockStreamReceiverFilterHandler) Route() types.Route {
+	if cb.route == nil {
+		return nil
+	}
+	return cb.route
+}
+func (cb *mockStreamReceiverFilterHandler) RequestInfo() types.RequestInfo {
+	return cb.info
+}
+func (cb *mockStreamReceiverFilterHandler) Connection() types.Connection {
+	if cb.conn == nil {
+		return nil
+	}
+	return cb.conn
+}
+func (cb *mockStreamReceiverFilterHandler) SendHijackReply(code int, headers types.HeaderMap) {
+	cb.hijackCode = code
+}
+
+type mockRoute struct {
+	types.Route
+	rule *mockRouteRule
+}
+
+func (r *mockRoute) RouteRule() types.RouteRule {
+	return r.rule
+}
+
+type mockRouteRule struct {
+	types.RouteRule
+	config map[string]interface{}
+}
+
+func (r *mockRouteRule) PerFilterConfig() map[string]interface{} {
+	return r.config
+}
+
+type mockRequestInfo struct {
+	types.RequestInfo
+	flag types.ResponseFlag
+}
+
+func (info *mockRequestInfo) SetResponseFlag(flag types.ResponseFlag) {
+	info.flag = flag
+}
+
+type mockConnection struct {
+	types.Connection
+	remote net.Addr
+}
+
+func (c *mockConnection) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+func TestTokenBucketRefill(t *testing.T) {
+	limiter := newRateLimiter(&v2.StreamRateLimit{
+		RequestsPerSecond: 10,
+		Burst:             2,
+	})
+	now := time.Now()
+	if !limiter.allow("key", now) || !limiter.allow("key", now) {
+		t.Fatal("burst tokens should be allowed")
+	}
+	if limiter.allow("key", now) {
+		t.Error("empty bucket should reject the request")
+	}
+	// 100ms at 10 rps refills one token
+	now = now.Add(100 * time.Millisecond)
+	if !limiter.allow("key", now) {
+		t.Error("refilled token should be allowed")
+	}
+	if limiter.allow("key", now) {
+		t.Error("only one token should be refilled")
+	}
+	// a long idle period refills up to burst only
+	now = now.Add(time.Minute)
+	if !limiter.allow("key", now) || !limiter.allow("key", now) {
+		t.Error("idle bucket should refill up to burst")
+	}
+	if limiter.allow("key", now) {
+		t.Error("refill should be capped at burst")
+	}
+}
+
+func TestRateLimiterKeys(t *testing.T) {
+	limiter := newRateLimiter(&v2.StreamRateLimit{
+		RequestsPerSecond: 1,
+		Burst:             1,
+		MaxKeys:           2,
+	})
+	now := time.Now()
+	if !limiter.allow("a", now) {
+		t.Fatal("first request of key a should be allowed")
+	}
+	if limiter.allow("a", now) {
+		t.Error("key a is exhausted")
+	}
+	if !limiter.allow("b", now) {
+		t.Error("key b owns its own bucket")
+	}
+	// table is full, extra keys share the overflow bucket
+	if !limiter.allow("c", now) {
+		t.Fatal("first overflow request should be allowed")
+	}
+	if limiter.allow("d", now) {
+		t.Error("overflow keys should share one bucket")
+	}
+}
+
+func TestRateLimitFilter(t *testing.T) {
+	cb := &mockStreamReceiverFilterHandler{
+		info: &mockRequestInfo{},
+		conn: &mockConnection{
+			remote: &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 12200},
+		},
+	}
+	f := NewFilter(context.Background(), &v2.StreamRateLimit{
+		RequestsPerSecond: 1,
+		Burst:             1,
+		KeyHeaders:        []string{"x-user"},
+		KeyRemoteAddress:  true,
+		MaxKeys:           100,
+	})
+	f.SetReceiveFilterHandler(cb)
+	filter := f.(*rateLimitFilter)
+	headers := protocol.CommonHeader{"x-user": "alice"}
+	if key := filter.descriptorKey(headers); key != "x-user=alice|addr=127.0.0.1" {
+		t.Errorf("unexpected descriptor key: %s", key)
+	}
+	if status := f.OnReceive(context.Background(), headers, nil, nil); status != types.StreamFilterContinue {
+		t.Fatal("request within the limit should continue")
+	}
+	if status := f.OnReceive(context.Background(), headers, nil, nil); status != types.StreamFilterStop {
+		t.Fatal("request over the limit should be stopped")
+	}
+	if cb.hijackCode != mosnhttp.TooManyRequests {
+		t.Errorf("expected hijack with 429, got %d", cb.hijackCode)
+	}
+	if cb.info.flag != types.RateLimited {
+		t.Error("rate limited response flag should be set")
+	}
+	// another user owns its own bucket
+	if status := f.OnReceive(context.Background(), protocol.CommonHeader{"x-user": "bob"}, nil, nil); status != types.StreamFilterContinue {
+		t.Error("a different descriptor should not be limited")
+	}
+}
+
+func TestRateLimitFilterPerRouteConfig(t *testing.T) {
+	cb := &mockStreamReceiverFilterHandler{
+		info: &mockRequestInfo{},
+		route: &mockRoute{
+			rule: &mockRouteRule{
+				config: map[string]interface{}{
+					v2.RateLimitStream: v2.StreamRateLimit{
+						RequestsPerSecond: 1,
+						Burst:             1,
+					},
+				},
+			},
+		},
+	}
+	f := NewFilter(context.Background(), &v2.StreamRateLimit{
+		RequestsPerSecond: 100,
+		Burst:             100,
+	})
+	f.SetReceiveFilterHandler(cb)
+	headers := protocol.CommonHeader{}
+	if status := f.OnReceive(context.Background(), headers, nil, nil); status != types.StreamFilterContinue {
+		t.Fatal("first request should continue")
+	}
+	if status := f.OnReceive(context.Background(), headers, nil, nil); status != types.StreamFilterStop {
+		t.Fatal("route config should override the filter config")
+	}
+}
+
+func TestCreateRateLimitFilterFactory(t *testing.T) {
+	if _, err := CreateRateLimitFilterFactory(map[string]interface{}{
+		"requests_per_second": 10,
+		"burst":               20,
+	}); err != nil {
+		t.Errorf("create factory failed: %v", err)
+	}
+	if _, err := CreateRateLimitFilterFactory(map[string]interface{}{}); err == nil {
+		t.Error("factory without requests_per_second should be rejected")
+	}
+}
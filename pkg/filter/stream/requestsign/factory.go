@@ -0,0 +1,68 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package requestsign
+
+import (
+	"context"
+	"hash"
+
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+	"sofastack.io/sofa-mosn/pkg/config"
+	"sofastack.io/sofa-mosn/pkg/filter"
+	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+func init() {
+	filter.RegisterStream(v2.SignStream, CreateRequestSignFilterFactory)
+}
+
+// FilterConfigFactory resolves the key material and the algorithm once
+// at creation, a misconfigured key source fails the listener config
+// instead of every request
+type FilterConfigFactory struct {
+	Config  *v2.StreamRequestSign
+	key     []byte
+	newHash func() hash.Hash
+}
+
+func (f *FilterConfigFactory) CreateFilterChain(context context.Context, callbacks types.StreamFilterChainFactoryCallbacks) {
+	filter := NewFilter(context, f.Config, f.key, f.newHash)
+	callbacks.AddStreamReceiverFilter(filter, types.DownFilter)
+}
+
+func CreateRequestSignFilterFactory(conf map[string]interface{}) (types.StreamFilterChainFactory, error) {
+	log.DefaultLogger.Debugf("create request sign stream filter factory")
+	cfg, err := config.ParseStreamRequestSignFilter(conf)
+	if err != nil {
+		return nil, err
+	}
+	key, err := resolveKey(cfg)
+	if err != nil {
+		return nil, err
+	}
+	newHash, err := newHashFunc(cfg.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	return &FilterConfigFactory{
+		Config:  cfg,
+		key:     key,
+		newHash: newHash,
+	}, nil
+}
@@ -0,0 +1,141 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package requestsign
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"testing"
+
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+	"sofastack.io/sofa-mosn/pkg/buffer"
+	"sofastack.io/sofa-mosn/pkg/protocol"
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+func TestResolveKey(t *testing.T) {
+	// inline key wins
+	key, err := resolveKey(&v2.StreamRequestSign{Key: "secret"})
+	if err != nil || string(key) != "secret" {
+		t.Errorf("inline key not resolved: %v %q", err, key)
+	}
+	// environment variable
+	os.Setenv("MOSN_TEST_SIGN_KEY", "envsecret")
+	defer os.Unsetenv("MOSN_TEST_SIGN_KEY")
+	key, err = resolveKey(&v2.StreamRequestSign{KeyEnv: "MOSN_TEST_SIGN_KEY"})
+	if err != nil || string(key) != "envsecret" {
+		t.Errorf("env key not resolved: %v %q", err, key)
+	}
+	if _, err := resolveKey(&v2.StreamRequestSign{KeyEnv: "MOSN_TEST_SIGN_KEY_MISSING"}); err == nil {
+		t.Errorf("missing env variable accepted")
+	}
+	// no source configured
+	if _, err := resolveKey(&v2.StreamRequestSign{}); err == nil {
+		t.Errorf("empty key source accepted")
+	}
+}
+
+func TestNewHashFunc(t *testing.T) {
+	for _, algorithm := range []string{"", AlgorithmHmacSHA256, AlgorithmHmacSHA1} {
+		if _, err := newHashFunc(algorithm); err != nil {
+			t.Errorf("%q: valid algorithm rejected: %v", algorithm, err)
+		}
+	}
+	if _, err := newHashFunc("hmac-md5"); err == nil {
+		t.Errorf("unknown algorithm accepted")
+	}
+}
+
+func TestOnReceiveSignsRequest(t *testing.T) {
+	cfg := &v2.StreamRequestSign{
+		SignedHeaders: []string{"Host", "x-request-id"},
+		SignBody:      true,
+	}
+	key := []byte("secret")
+	newHash, _ := newHashFunc(cfg.Algorithm)
+	f := NewFilter(context.Background(), cfg, key, newHash)
+
+	headers := protocol.CommonHeader(map[string]string{
+		protocol.MosnHeaderMethod:         "POST",
+		protocol.MosnHeaderPathKey:        "/api/v1/items",
+		protocol.MosnHeaderQueryStringKey: "page=1",
+		"host":                            "api.example.com",
+		"x-request-id":                    "abc-123",
+	})
+	body := buffer.NewIoBufferString("{\"name\":\"demo\"}")
+	if status := f.OnReceive(context.Background(), headers, body, nil); status != types.StreamFilterContinue {
+		t.Fatalf("unexpected filter status: %v", status)
+	}
+
+	if algorithm, _ := headers.Get(HeaderSignAlgorithm); algorithm != AlgorithmHmacSHA256 {
+		t.Errorf("unexpected algorithm header: %q", algorithm)
+	}
+	if signed, _ := headers.Get(HeaderSignedHeaders); signed != "host;x-request-id" {
+		t.Errorf("unexpected signed headers: %q", signed)
+	}
+
+	// recompute the expected signature from the documented canonical form
+	bodySum := sha256.Sum256([]byte("{\"name\":\"demo\"}"))
+	canonical := "POST\n/api/v1/items\npage=1\n" +
+		"host:api.example.com\n" +
+		"x-request-id:abc-123\n" +
+		hex.EncodeToString(bodySum[:]) + "\n"
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(canonical))
+	expected := hex.EncodeToString(mac.Sum(nil))
+	if signature, _ := headers.Get(defaultSignatureHeader); signature != expected {
+		t.Errorf("signature mismatch, expected %s got %s", expected, signature)
+	}
+}
+
+func TestCustomSignatureHeader(t *testing.T) {
+	cfg := &v2.StreamRequestSign{HeaderName: "x-api-signature"}
+	newHash, _ := newHashFunc(cfg.Algorithm)
+	f := NewFilter(context.Background(), cfg, []byte("secret"), newHash)
+
+	headers := protocol.CommonHeader(map[string]string{
+		protocol.MosnHeaderMethod:  "GET",
+		protocol.MosnHeaderPathKey: "/",
+	})
+	f.OnReceive(context.Background(), headers, nil, nil)
+	if _, ok := headers.Get("x-api-signature"); !ok {
+		t.Errorf("signature not set on configured header")
+	}
+}
+
+func TestCreateRequestSignFilterFactory(t *testing.T) {
+	if _, err := CreateRequestSignFilterFactory(map[string]interface{}{
+		"key":            "secret",
+		"signed_headers": []string{"host"},
+	}); err != nil {
+		t.Errorf("valid config rejected: %v", err)
+	}
+	// key source and algorithm are checked at creation
+	if _, err := CreateRequestSignFilterFactory(map[string]interface{}{}); err == nil {
+		t.Errorf("config without key source accepted")
+	}
+	if _, err := CreateRequestSignFilterFactory(map[string]interface{}{
+		"key":       "secret",
+		"algorithm": "hmac-md5",
+	}); err == nil {
+		t.Errorf("unknown algorithm accepted")
+	}
+}
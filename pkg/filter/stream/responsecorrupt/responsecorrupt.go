@@ -0,0 +1,108 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package responsecorrupt implements a testing only stream sender filter
+// that corrupts upstream responses at a configured rate: truncate the
+// response body, overwrite the response status and drop response trailers.
+// It extends the abort/delay fault filter for resilience tests of client
+// side handling and proxy decode robustness, never enable it in production.
+package responsecorrupt
+
+import (
+	"context"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+// responseCorruptFilter is an implement of types.StreamSenderFilter
+type responseCorruptFilter struct {
+	ctx     context.Context
+	handler types.StreamSenderFilterHandler
+	config  *v2.StreamResponseCorrupt
+	rander  *rand.Rand
+}
+
+func NewFilter(ctx context.Context, cfg *v2.StreamResponseCorrupt) types.StreamSenderFilter {
+	if log.Proxy.GetLogLevel() >= log.DEBUG {
+		log.Proxy.Debugf(ctx, "[stream filter] [response corrupt] create a new response corrupt filter")
+	}
+	return &responseCorruptFilter{
+		ctx:    ctx,
+		config: cfg,
+		rander: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+func (f *responseCorruptFilter) SetSenderFilterHandler(handler types.StreamSenderFilterHandler) {
+	f.handler = handler
+}
+
+func (f *responseCorruptFilter) Append(ctx context.Context, headers types.HeaderMap, buf types.IoBuffer, trailers types.HeaderMap) types.StreamFilterStatus {
+	if !f.matchUpstream() {
+		return types.StreamFilterContinue
+	}
+	if !f.isCorrupt() {
+		return types.StreamFilterContinue
+	}
+	if log.Proxy.GetLogLevel() >= log.DEBUG {
+		log.Proxy.Debugf(f.ctx, "[stream filter] [response corrupt] corrupt the response")
+	}
+	if f.config.Status != 0 && headers != nil {
+		headers.Set(types.HeaderStatus, strconv.Itoa(f.config.Status))
+	}
+	if f.config.TruncateBody && buf != nil && buf.Len() > 0 {
+		f.truncate(buf)
+	}
+	if f.config.DropTrailers && trailers != nil {
+		f.handler.SetResponseTrailers(nil)
+	}
+	return types.StreamFilterContinue
+}
+
+func (f *responseCorruptFilter) OnDestroy() {}
+
+func (f *responseCorruptFilter) matchUpstream() bool {
+	if f.config.UpstreamCluster != "" {
+		if route := f.handler.Route(); route != nil {
+			return route.RouteRule().ClusterName() == f.config.UpstreamCluster
+		}
+	}
+	return true
+}
+
+func (f *responseCorruptFilter) isCorrupt() bool {
+	// percent is 0 means no corruption
+	if f.config.Percent == 0 {
+		return false
+	}
+	// rander generates 0~99, if greater than percent means no corruption
+	return (f.rander.Uint32() % 100) < f.config.Percent
+}
+
+// truncate cuts the response body to its first half in place
+func (f *responseCorruptFilter) truncate(buf types.IoBuffer) {
+	data := buf.Bytes()
+	keep := make([]byte, len(data)/2)
+	copy(keep, data)
+	buf.Reset()
+	buf.Write(keep)
+}
@@ -0,0 +1,92 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package responsecorrupt
+
+import (
+	"context"
+	"testing"
+
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+	"sofastack.io/sofa-mosn/pkg/buffer"
+	"sofastack.io/sofa-mosn/pkg/protocol"
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+// mockSenderHandler implements types.StreamSenderFilterHandler for test
+type mockSenderHandler struct {
+	trailers types.HeaderMap
+}
+
+func (m *mockSenderHandler) Route() types.Route               { return nil }
+func (m *mockSenderHandler) RequestInfo() types.RequestInfo   { return nil }
+func (m *mockSenderHandler) Connection() types.Connection     { return nil }
+func (m *mockSenderHandler) GetResponseHeaders() types.HeaderMap {
+	return nil
+}
+func (m *mockSenderHandler) SetResponseHeaders(headers types.HeaderMap) {}
+func (m *mockSenderHandler) GetResponseData() types.IoBuffer            { return nil }
+func (m *mockSenderHandler) SetResponseData(buf types.IoBuffer)         {}
+func (m *mockSenderHandler) GetResponseTrailers() types.HeaderMap {
+	return m.trailers
+}
+func (m *mockSenderHandler) SetResponseTrailers(trailers types.HeaderMap) {
+	m.trailers = trailers
+}
+
+func TestResponseCorrupt(t *testing.T) {
+	cfg := &v2.StreamResponseCorrupt{
+		Percent:      100,
+		Status:       502,
+		TruncateBody: true,
+		DropTrailers: true,
+	}
+	handler := &mockSenderHandler{
+		trailers: protocol.CommonHeader(map[string]string{"x-trailer": "v"}),
+	}
+	f := NewFilter(context.Background(), cfg)
+	f.SetSenderFilterHandler(handler)
+
+	headers := protocol.CommonHeader(map[string]string{})
+	buf := buffer.GetIoBuffer(16)
+	buf.WriteString("helloworld")
+	if status := f.Append(context.Background(), headers, buf, handler.trailers); status != types.StreamFilterContinue {
+		t.Errorf("corrupt filter should continue the stream, got %v", status)
+	}
+	if code, ok := headers.Get(types.HeaderStatus); !ok || code != "502" {
+		t.Errorf("status should be overwritten to 502, got %s", code)
+	}
+	if buf.String() != "hello" {
+		t.Errorf("body should be truncated to the first half, got %s", buf.String())
+	}
+	if handler.trailers != nil {
+		t.Errorf("trailers should be dropped, got %v", handler.trailers)
+	}
+}
+
+func TestResponseCorruptZeroPercent(t *testing.T) {
+	f := NewFilter(context.Background(), &v2.StreamResponseCorrupt{Status: 502})
+	f.SetSenderFilterHandler(&mockSenderHandler{})
+
+	headers := protocol.CommonHeader(map[string]string{})
+	for i := 0; i < 100; i++ {
+		f.Append(context.Background(), headers, nil, nil)
+	}
+	if _, ok := headers.Get(types.HeaderStatus); ok {
+		t.Error("zero percent should never corrupt the response")
+	}
+}
@@ -0,0 +1,157 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package responsevalidate implements a stream sender filter that validates
+// upstream responses against a configured contract: allowed content types,
+// a body size ceiling and required headers. Violations are counted and the
+// response is replaced with a local error before it reaches the client,
+// so a misbehaving backend cannot leak malformed payloads downstream.
+package responsevalidate
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/metrics"
+	"sofastack.io/sofa-mosn/pkg/types"
+	gometrics "github.com/rcrowley/go-metrics"
+)
+
+// violation kinds, used as metrics key suffix
+const (
+	violationContentType   = "content_type"
+	violationBodySize      = "body_size"
+	violationMissingHeader = "missing_header"
+)
+
+var violationStats = func() types.Metrics {
+	s, _ := metrics.NewMetrics("stream_filter", map[string]string{"filter": v2.ValidateStream})
+	return s
+}()
+
+func violationCounter(kind string) gometrics.Counter {
+	return violationStats.Counter("violation_total." + kind)
+}
+
+// responseValidateFilter is an implement of types.StreamSenderFilter
+type responseValidateFilter struct {
+	ctx     context.Context
+	handler types.StreamSenderFilterHandler
+	config  *v2.StreamResponseValidate
+}
+
+func NewFilter(ctx context.Context, cfg *v2.StreamResponseValidate) types.StreamSenderFilter {
+	if log.Proxy.GetLogLevel() >= log.DEBUG {
+		log.Proxy.Debugf(ctx, "[stream filter] [response validate] create a new response validate filter")
+	}
+	return &responseValidateFilter{
+		ctx:    ctx,
+		config: cfg,
+	}
+}
+
+func (f *responseValidateFilter) SetSenderFilterHandler(handler types.StreamSenderFilterHandler) {
+	f.handler = handler
+}
+
+func (f *responseValidateFilter) Append(ctx context.Context, headers types.HeaderMap, buf types.IoBuffer, trailers types.HeaderMap) types.StreamFilterStatus {
+	if !f.matchUpstream() {
+		return types.StreamFilterContinue
+	}
+	kind := f.validate(headers, buf)
+	if kind == "" {
+		return types.StreamFilterContinue
+	}
+
+	violationCounter(kind).Inc(1)
+	log.Proxy.Errorf(f.ctx, "[stream filter] [response validate] upstream response violates contract: %s", kind)
+	f.rejectResponse(headers, buf, trailers)
+
+	return types.StreamFilterContinue
+}
+
+func (f *responseValidateFilter) OnDestroy() {}
+
+func (f *responseValidateFilter) matchUpstream() bool {
+	if f.config.UpstreamCluster != "" {
+		if route := f.handler.Route(); route != nil {
+			return route.RouteRule().ClusterName() == f.config.UpstreamCluster
+		}
+	}
+	return true
+}
+
+// validate returns the violation kind, empty string when the response is fine
+func (f *responseValidateFilter) validate(headers types.HeaderMap, buf types.IoBuffer) string {
+	if headers != nil {
+		if len(f.config.AllowedContentTypes) > 0 && !f.contentTypeAllowed(headers) {
+			return violationContentType
+		}
+		for _, required := range f.config.RequiredHeaders {
+			if _, ok := headers.Get(required); !ok {
+				return violationMissingHeader
+			}
+		}
+	}
+	if f.config.MaxBodyBytes > 0 && buf != nil && uint64(buf.Len()) > f.config.MaxBodyBytes {
+		return violationBodySize
+	}
+	return ""
+}
+
+func (f *responseValidateFilter) contentTypeAllowed(headers types.HeaderMap) bool {
+	contentType, ok := headers.Get("content-type")
+	if !ok {
+		contentType, ok = headers.Get("Content-Type")
+	}
+	if !ok {
+		return false
+	}
+	// ignore parameters like "; charset=utf-8"
+	if idx := strings.IndexByte(contentType, ';'); idx >= 0 {
+		contentType = contentType[:idx]
+	}
+	contentType = strings.TrimSpace(contentType)
+	for _, allowed := range f.config.AllowedContentTypes {
+		if strings.EqualFold(contentType, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// rejectResponse replaces the upstream response with a local error
+func (f *responseValidateFilter) rejectResponse(headers types.HeaderMap, buf types.IoBuffer, trailers types.HeaderMap) {
+	f.handler.RequestInfo().SetErrorCode(types.ErrCodeInvalidResponse)
+
+	status := f.config.ViolationStatus
+	if status == 0 {
+		status = types.NoHealthUpstreamCode
+	}
+	if headers != nil {
+		headers.Set(types.HeaderStatus, strconv.Itoa(status))
+	}
+	if buf != nil {
+		buf.Reset()
+	}
+	if trailers != nil {
+		f.handler.SetResponseTrailers(nil)
+	}
+}
@@ -0,0 +1,129 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package responsevalidate
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+	"sofastack.io/sofa-mosn/pkg/buffer"
+	"sofastack.io/sofa-mosn/pkg/network"
+	"sofastack.io/sofa-mosn/pkg/protocol"
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+// mockSenderHandler implements types.StreamSenderFilterHandler for test
+type mockSenderHandler struct {
+	info     types.RequestInfo
+	trailers types.HeaderMap
+}
+
+func newMockSenderHandler() *mockSenderHandler {
+	return &mockSenderHandler{info: network.NewRequestInfo()}
+}
+
+func (m *mockSenderHandler) Route() types.Route                         { return nil }
+func (m *mockSenderHandler) RequestInfo() types.RequestInfo             { return m.info }
+func (m *mockSenderHandler) Connection() types.Connection               { return nil }
+func (m *mockSenderHandler) GetResponseHeaders() types.HeaderMap        { return nil }
+func (m *mockSenderHandler) SetResponseHeaders(headers types.HeaderMap) {}
+func (m *mockSenderHandler) GetResponseData() types.IoBuffer            { return nil }
+func (m *mockSenderHandler) SetResponseData(buf types.IoBuffer)         {}
+func (m *mockSenderHandler) GetResponseTrailers() types.HeaderMap       { return m.trailers }
+func (m *mockSenderHandler) SetResponseTrailers(trailers types.HeaderMap) {
+	m.trailers = trailers
+}
+
+func runFilter(t *testing.T, cfg *v2.StreamResponseValidate, headers types.HeaderMap, buf types.IoBuffer) *mockSenderHandler {
+	t.Helper()
+	handler := newMockSenderHandler()
+	f := NewFilter(context.Background(), cfg)
+	f.SetSenderFilterHandler(handler)
+	if status := f.Append(context.Background(), headers, buf, nil); status != types.StreamFilterContinue {
+		t.Fatalf("validate filter should continue the stream, got %v", status)
+	}
+	return handler
+}
+
+func TestValidResponsePassesThrough(t *testing.T) {
+	cfg := &v2.StreamResponseValidate{
+		AllowedContentTypes: []string{"application/json"},
+		MaxBodyBytes:        100,
+		RequiredHeaders:     []string{"x-request-id"},
+	}
+	headers := protocol.CommonHeader(map[string]string{
+		"content-type": "application/json; charset=utf-8",
+		"x-request-id": "1",
+	})
+	buf := buffer.GetIoBuffer(16)
+	buf.WriteString(`{"ok":true}`)
+
+	handler := runFilter(t, cfg, headers, buf)
+	if _, ok := headers.Get(types.HeaderStatus); ok {
+		t.Error("valid response should not be rewritten")
+	}
+	if buf.Len() == 0 {
+		t.Error("valid response body should be kept")
+	}
+	if handler.info.ErrorCode() != types.ErrCodeNone {
+		t.Errorf("unexpected error code %q", handler.info.ErrorCode())
+	}
+}
+
+func TestContentTypeViolation(t *testing.T) {
+	cfg := &v2.StreamResponseValidate{
+		AllowedContentTypes: []string{"application/json"},
+		ViolationStatus:     520,
+	}
+	headers := protocol.CommonHeader(map[string]string{"content-type": "text/html"})
+	buf := buffer.GetIoBuffer(16)
+	buf.WriteString("<html></html>")
+
+	handler := runFilter(t, cfg, headers, buf)
+	if code, _ := headers.Get(types.HeaderStatus); code != "520" {
+		t.Errorf("status should be rewritten to 520, got %s", code)
+	}
+	if buf.Len() != 0 {
+		t.Error("violating response body should be dropped")
+	}
+	if handler.info.ErrorCode() != types.ErrCodeInvalidResponse {
+		t.Errorf("error code should be invalid_response, got %q", handler.info.ErrorCode())
+	}
+}
+
+func TestBodySizeAndRequiredHeaderViolations(t *testing.T) {
+	// body over the limit
+	cfg := &v2.StreamResponseValidate{MaxBodyBytes: 4}
+	buf := buffer.GetIoBuffer(16)
+	buf.WriteString("too large body")
+	headers := protocol.CommonHeader(map[string]string{})
+	runFilter(t, cfg, headers, buf)
+	if code, _ := headers.Get(types.HeaderStatus); code != strconv.Itoa(types.NoHealthUpstreamCode) {
+		t.Errorf("default violation status should be %d, got %s", types.NoHealthUpstreamCode, code)
+	}
+
+	// required header missing
+	cfg = &v2.StreamResponseValidate{RequiredHeaders: []string{"x-trace-id"}}
+	headers = protocol.CommonHeader(map[string]string{})
+	runFilter(t, cfg, headers, nil)
+	if _, ok := headers.Get(types.HeaderStatus); !ok {
+		t.Error("missing required header should be rejected")
+	}
+}
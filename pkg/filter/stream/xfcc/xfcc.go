@@ -0,0 +1,125 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xfcc
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/mtls"
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+// HeaderXForwardedClientCert carries the client certificate information
+// a mTLS listener verified, so upstream applications can make authz
+// decisions on the proxied identity.
+const HeaderXForwardedClientCert = "x-forwarded-client-cert"
+
+// streamXFCCFilter is an implement of types.StreamReceiverFilter.
+// The inbound xfcc header is always removed, a client on an untrusted
+// network must not be able to spoof a verified identity. A new header
+// is only injected when the downstream connection terminated mTLS.
+type streamXFCCFilter struct {
+	ctx     context.Context
+	handler types.StreamReceiverFilterHandler
+	config  *v2.StreamXFCC
+}
+
+func NewFilter(ctx context.Context, cfg *v2.StreamXFCC) types.StreamReceiverFilter {
+	if log.Proxy.GetLogLevel() >= log.DEBUG {
+		log.Proxy.Debugf(ctx, "[stream filter] [xfcc] create a new xfcc filter")
+	}
+	return &streamXFCCFilter{
+		ctx:    ctx,
+		config: cfg,
+	}
+}
+
+func (f *streamXFCCFilter) SetReceiveFilterHandler(handler types.StreamReceiverFilterHandler) {
+	f.handler = handler
+}
+
+func (f *streamXFCCFilter) OnReceive(ctx context.Context, headers types.HeaderMap, buf types.IoBuffer, trailers types.HeaderMap) types.StreamFilterStatus {
+	if headers == nil {
+		return types.StreamFilterContinue
+	}
+	// sanitize whatever the client claimed
+	headers.Del(HeaderXForwardedClientCert)
+
+	conn := f.handler.Connection()
+	if conn == nil {
+		return types.StreamFilterContinue
+	}
+	tlsConn, ok := conn.RawConn().(*mtls.TLSConn)
+	if !ok {
+		return types.StreamFilterContinue
+	}
+	state := tlsConn.ConnectionState()
+	if len(state.PeerCertificates) == 0 {
+		return types.StreamFilterContinue
+	}
+	if value := buildXFCC(f.config, state.PeerCertificates); value != "" {
+		headers.Set(HeaderXForwardedClientCert, value)
+	}
+	return types.StreamFilterContinue
+}
+
+func (f *streamXFCCFilter) OnDestroy() {}
+
+// buildXFCC renders the configured elements of the verified certificate
+// chain into a xfcc element, certs[0] is the leaf the client presented.
+func buildXFCC(cfg *v2.StreamXFCC, certs []*x509.Certificate) string {
+	leaf := certs[0]
+	var parts []string
+	if cfg.Hash {
+		sum := sha256.Sum256(leaf.Raw)
+		parts = append(parts, "Hash="+hex.EncodeToString(sum[:]))
+	}
+	if cfg.Subject {
+		parts = append(parts, "Subject="+quoteXFCCValue(leaf.Subject.String()))
+	}
+	if cfg.URI {
+		for _, uri := range leaf.URIs {
+			parts = append(parts, "URI="+quoteXFCCValue(uri.String()))
+		}
+	}
+	if cfg.Chain {
+		var chain strings.Builder
+		for _, cert := range certs {
+			pem.Encode(&chain, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+		}
+		parts = append(parts, "Chain="+url.QueryEscape(chain.String()))
+	}
+	return strings.Join(parts, ";")
+}
+
+// quoteXFCCValue quotes values that would break the key=value;... syntax
+func quoteXFCCValue(v string) string {
+	if strings.ContainsAny(v, ",;=\"") {
+		return strconv.Quote(v)
+	}
+	return v
+}
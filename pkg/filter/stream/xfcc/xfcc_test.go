@@ -0,0 +1,108 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xfcc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"math/big"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+)
+
+func makeTestCert(t *testing.T) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spiffeURI, _ := url.Parse("spiffe://cluster.local/ns/default/sa/client")
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject: pkix.Name{
+			CommonName:   "test client",
+			Organization: []string{"mosn"},
+		},
+		URIs:      []*url.URL{spiffeURI},
+		NotBefore: time.Now().Add(-time.Hour),
+		NotAfter:  time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestBuildXFCC(t *testing.T) {
+	cert := makeTestCert(t)
+	cfg := &v2.StreamXFCC{
+		Subject: true,
+		URI:     true,
+		Hash:    true,
+		Chain:   true,
+	}
+	value := buildXFCC(cfg, []*x509.Certificate{cert})
+
+	sum := sha256.Sum256(cert.Raw)
+	if !strings.Contains(value, "Hash="+hex.EncodeToString(sum[:])) {
+		t.Errorf("xfcc misses the leaf hash: %s", value)
+	}
+	if !strings.Contains(value, "URI=spiffe://cluster.local/ns/default/sa/client") {
+		t.Errorf("xfcc misses the san uri: %s", value)
+	}
+	// the subject contains ',' and '=' and must be quoted
+	if !strings.Contains(value, "Subject=\"") {
+		t.Errorf("xfcc subject is not quoted: %s", value)
+	}
+	if !strings.Contains(value, "Chain="+url.QueryEscape("-----BEGIN CERTIFICATE-----")[:20]) {
+		t.Errorf("xfcc misses the pem chain: %s", value)
+	}
+}
+
+func TestBuildXFCCSelectsElements(t *testing.T) {
+	cert := makeTestCert(t)
+	value := buildXFCC(&v2.StreamXFCC{Hash: true}, []*x509.Certificate{cert})
+	if !strings.HasPrefix(value, "Hash=") || strings.Contains(value, ";") {
+		t.Errorf("hash only config should produce a single element: %s", value)
+	}
+	if value := buildXFCC(&v2.StreamXFCC{}, []*x509.Certificate{cert}); value != "" {
+		t.Errorf("empty config should produce no header, got %s", value)
+	}
+}
+
+func TestQuoteXFCCValue(t *testing.T) {
+	if got := quoteXFCCValue("spiffe://foo/bar"); got != "spiffe://foo/bar" {
+		t.Errorf("plain value should not be quoted: %s", got)
+	}
+	if got := quoteXFCCValue("CN=test,O=mosn"); got != `"CN=test,O=mosn"` {
+		t.Errorf("value with separators should be quoted: %s", got)
+	}
+}
@@ -0,0 +1,196 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package flightrecorder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+// recordMagic starts every record, a decoder hitting anything else knows
+// the file is truncated or corrupt
+const recordMagic uint32 = 0x4d465231 // "MFR1"
+
+const recordVersion byte = 1
+
+// Record is one sampled request with full detail, the binary layout is
+// the magic, a big endian uint32 payload length and the payload itself:
+// version byte, fixed width numeric fields and uvarint length prefixed
+// strings
+type Record struct {
+	Version          byte
+	StartTime        time.Time
+	Duration         time.Duration
+	Protocol         string
+	DownstreamRemote string
+	DownstreamLocal  string
+	UpstreamAddress  string
+	ResponseCode     int
+	BytesReceived    uint64
+	BytesSent        uint64
+	Headers          map[string]string
+}
+
+func newRecord(headers types.HeaderMap, requestInfo types.RequestInfo) *Record {
+	record := &Record{
+		Version:       recordVersion,
+		StartTime:     requestInfo.StartTime(),
+		Duration:      requestInfo.Duration(),
+		Protocol:      string(requestInfo.Protocol()),
+		ResponseCode:  requestInfo.ResponseCode(),
+		BytesReceived: requestInfo.BytesReceived(),
+		BytesSent:     requestInfo.BytesSent(),
+	}
+	if addr := requestInfo.DownstreamRemoteAddress(); addr != nil {
+		record.DownstreamRemote = addr.String()
+	}
+	if addr := requestInfo.DownstreamLocalAddress(); addr != nil {
+		record.DownstreamLocal = addr.String()
+	}
+	if host := requestInfo.UpstreamHost(); host != nil {
+		record.UpstreamAddress = host.AddressString()
+	}
+	if headers != nil {
+		record.Headers = make(map[string]string)
+		headers.Range(func(key, value string) bool {
+			record.Headers[key] = value
+			return true
+		})
+	}
+	return record
+}
+
+func putString(b *bytes.Buffer, s string) {
+	var length [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(length[:], uint64(len(s)))
+	b.Write(length[:n])
+	b.WriteString(s)
+}
+
+func encodeRecord(record *Record) []byte {
+	var payload bytes.Buffer
+	payload.WriteByte(record.Version)
+	binary.Write(&payload, binary.BigEndian, record.StartTime.UnixNano())
+	binary.Write(&payload, binary.BigEndian, int64(record.Duration))
+	putString(&payload, record.Protocol)
+	putString(&payload, record.DownstreamRemote)
+	putString(&payload, record.DownstreamLocal)
+	putString(&payload, record.UpstreamAddress)
+	binary.Write(&payload, binary.BigEndian, uint32(record.ResponseCode))
+	binary.Write(&payload, binary.BigEndian, record.BytesReceived)
+	binary.Write(&payload, binary.BigEndian, record.BytesSent)
+	var count [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(count[:], uint64(len(record.Headers)))
+	payload.Write(count[:n])
+	for key, value := range record.Headers {
+		putString(&payload, key)
+		putString(&payload, value)
+	}
+
+	out := make([]byte, 8+payload.Len())
+	binary.BigEndian.PutUint32(out, recordMagic)
+	binary.BigEndian.PutUint32(out[4:], uint32(payload.Len()))
+	copy(out[8:], payload.Bytes())
+	return out
+}
+
+func readString(b *bytes.Reader) (string, error) {
+	length, err := binary.ReadUvarint(b)
+	if err != nil {
+		return "", err
+	}
+	if length > uint64(b.Len()) {
+		return "", fmt.Errorf("string length %d exceeds payload", length)
+	}
+	data := make([]byte, length)
+	io.ReadFull(b, data)
+	return string(data), nil
+}
+
+// ReadRecord decodes the next record from r for offline inspection,
+// io.EOF marks the clean end of the file
+func ReadRecord(r io.Reader) (*Record, error) {
+	var header [8]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, fmt.Errorf("truncated record header")
+		}
+		return nil, err
+	}
+	if binary.BigEndian.Uint32(header[:]) != recordMagic {
+		return nil, fmt.Errorf("bad record magic %x", header[:4])
+	}
+	payload := make([]byte, binary.BigEndian.Uint32(header[4:]))
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, fmt.Errorf("truncated record payload: %v", err)
+	}
+
+	b := bytes.NewReader(payload)
+	record := &Record{}
+	version, err := b.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	record.Version = version
+	var startNano, durationNano int64
+	binary.Read(b, binary.BigEndian, &startNano)
+	binary.Read(b, binary.BigEndian, &durationNano)
+	record.StartTime = time.Unix(0, startNano)
+	record.Duration = time.Duration(durationNano)
+	if record.Protocol, err = readString(b); err != nil {
+		return nil, err
+	}
+	if record.DownstreamRemote, err = readString(b); err != nil {
+		return nil, err
+	}
+	if record.DownstreamLocal, err = readString(b); err != nil {
+		return nil, err
+	}
+	if record.UpstreamAddress, err = readString(b); err != nil {
+		return nil, err
+	}
+	var code uint32
+	binary.Read(b, binary.BigEndian, &code)
+	record.ResponseCode = int(code)
+	binary.Read(b, binary.BigEndian, &record.BytesReceived)
+	binary.Read(b, binary.BigEndian, &record.BytesSent)
+	count, err := binary.ReadUvarint(b)
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		record.Headers = make(map[string]string, count)
+		for i := uint64(0); i < count; i++ {
+			key, err := readString(b)
+			if err != nil {
+				return nil, err
+			}
+			value, err := readString(b)
+			if err != nil {
+				return nil, err
+			}
+			record.Headers[key] = value
+		}
+	}
+	return record, nil
+}
@@ -0,0 +1,174 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package flightrecorder samples a small fraction of requests with full
+// detail into a rotating binary file, giving post-incident visibility
+// even when debug logging was off. Sampling is time based: at most one
+// request is recorded per interval, so the overhead stays flat no matter
+// the traffic rate.
+package flightrecorder
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+const (
+	defaultSampleInterval = time.Second
+	defaultMaxFileSize    = 64 << 20
+	defaultMaxFiles       = 4
+)
+
+// Config carries the resolved flight recorder settings, zero values
+// fall back to the defaults
+type Config struct {
+	Path           string
+	SampleInterval time.Duration
+	MaxFileSize    int64
+	MaxFiles       int
+}
+
+// Recorder appends sampled request records to the active file and
+// rotates it by size, keeping a bounded number of older files behind
+// numbered suffixes
+type Recorder struct {
+	config     Config
+	lastSample int64 // unix nano of the last sampled request, atomic
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+var recorder atomic.Value // *Recorder
+
+// Enable opens the recorder and installs it globally, requests flow
+// through Sample from then on
+func Enable(config Config) error {
+	r, err := NewRecorder(config)
+	if err != nil {
+		return err
+	}
+	recorder.Store(r)
+	return nil
+}
+
+// Get returns the installed recorder, nil when recording is off
+func Get() *Recorder {
+	if r, ok := recorder.Load().(*Recorder); ok {
+		return r
+	}
+	return nil
+}
+
+func NewRecorder(config Config) (*Recorder, error) {
+	if config.Path == "" {
+		return nil, errors.New("flight recorder needs a file path")
+	}
+	if config.SampleInterval <= 0 {
+		config.SampleInterval = defaultSampleInterval
+	}
+	if config.MaxFileSize <= 0 {
+		config.MaxFileSize = defaultMaxFileSize
+	}
+	if config.MaxFiles <= 0 {
+		config.MaxFiles = defaultMaxFiles
+	}
+	r := &Recorder{config: config}
+	if err := r.openFile(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Sample records the request when the sampling interval has elapsed,
+// the fast path on every other request is one atomic load
+func (r *Recorder) Sample(headers types.HeaderMap, requestInfo types.RequestInfo) {
+	now := time.Now().UnixNano()
+	last := atomic.LoadInt64(&r.lastSample)
+	if now-last < int64(r.config.SampleInterval) {
+		return
+	}
+	if !atomic.CompareAndSwapInt64(&r.lastSample, last, now) {
+		// another request claimed this interval
+		return
+	}
+	r.write(encodeRecord(newRecord(headers, requestInfo)))
+}
+
+func (r *Recorder) write(data []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return errors.New("flight recorder is closed")
+	}
+	if r.size+int64(len(data)) > r.config.MaxFileSize {
+		if err := r.rotateLocked(); err != nil {
+			return err
+		}
+	}
+	n, err := r.file.Write(data)
+	r.size += int64(n)
+	return err
+}
+
+// Close flushes and closes the active file
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+func (r *Recorder) openFile() error {
+	file, err := os.OpenFile(r.config.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("open flight recorder file %s: %v", r.config.Path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	r.file = file
+	r.size = info.Size()
+	return nil
+}
+
+// rotateLocked shifts path.1 .. path.N-1 one suffix up, moves the active
+// file behind path.1 and starts a fresh one, the oldest file is dropped
+func (r *Recorder) rotateLocked() error {
+	r.file.Close()
+	r.file = nil
+	os.Remove(r.config.Path + "." + strconv.Itoa(r.config.MaxFiles-1))
+	for i := r.config.MaxFiles - 1; i > 1; i-- {
+		os.Rename(r.config.Path+"."+strconv.Itoa(i-1), r.config.Path+"."+strconv.Itoa(i))
+	}
+	os.Rename(r.config.Path, r.config.Path+".1")
+	return r.openFile()
+}
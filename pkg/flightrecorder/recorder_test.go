@@ -0,0 +1,156 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package flightrecorder
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"sofastack.io/sofa-mosn/pkg/network"
+)
+
+func TestRecordRoundTrip(t *testing.T) {
+	in := &Record{
+		Version:          recordVersion,
+		StartTime:        time.Unix(0, 1567000000000000000),
+		Duration:         25 * time.Millisecond,
+		Protocol:         "Http1",
+		DownstreamRemote: "10.0.0.1:34567",
+		DownstreamLocal:  "10.0.0.2:80",
+		UpstreamAddress:  "10.0.1.1:8080",
+		ResponseCode:     200,
+		BytesReceived:    128,
+		BytesSent:        4096,
+		Headers:          map[string]string{"host": "example.com", "x-request-id": "abc"},
+	}
+	out, err := ReadRecord(bytes.NewReader(encodeRecord(in)))
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if !out.StartTime.Equal(in.StartTime) || out.Duration != in.Duration {
+		t.Errorf("timing fields mismatch: %+v", out)
+	}
+	if out.Protocol != in.Protocol || out.DownstreamRemote != in.DownstreamRemote ||
+		out.DownstreamLocal != in.DownstreamLocal || out.UpstreamAddress != in.UpstreamAddress {
+		t.Errorf("address fields mismatch: %+v", out)
+	}
+	if out.ResponseCode != in.ResponseCode || out.BytesReceived != in.BytesReceived || out.BytesSent != in.BytesSent {
+		t.Errorf("numeric fields mismatch: %+v", out)
+	}
+	if len(out.Headers) != 2 || out.Headers["host"] != "example.com" || out.Headers["x-request-id"] != "abc" {
+		t.Errorf("headers mismatch: %v", out.Headers)
+	}
+}
+
+func TestReadRecordErrors(t *testing.T) {
+	if _, err := ReadRecord(bytes.NewReader(nil)); err != io.EOF {
+		t.Errorf("empty reader: expected io.EOF, got %v", err)
+	}
+	if _, err := ReadRecord(bytes.NewReader([]byte("notarecordatall"))); err == nil {
+		t.Errorf("bad magic accepted")
+	}
+	// a truncated payload is an error, not a short record
+	encoded := encodeRecord(&Record{Version: recordVersion, Protocol: "Http1"})
+	if _, err := ReadRecord(bytes.NewReader(encoded[:len(encoded)-3])); err == nil {
+		t.Errorf("truncated payload accepted")
+	}
+}
+
+func TestRecorderWriteAndRotate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "flightrecorder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "flight.record")
+
+	record := encodeRecord(&Record{Version: recordVersion, Protocol: "Http1"})
+	r, err := NewRecorder(Config{
+		Path:        path,
+		MaxFileSize: int64(len(record))*2 + 1, // two records per file
+		MaxFiles:    2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	for i := 0; i < 5; i++ {
+		if err := r.write(record); err != nil {
+			t.Fatalf("write %d failed: %v", i, err)
+		}
+	}
+	// five records, two per file, the active file holds the fifth and
+	// the rotation kept exactly one older file
+	if count := countRecords(t, path); count != 1 {
+		t.Errorf("active file: expected 1 record, got %d", count)
+	}
+	if count := countRecords(t, path+".1"); count != 2 {
+		t.Errorf("rotated file: expected 2 records, got %d", count)
+	}
+	if _, err := os.Stat(path + ".2"); !os.IsNotExist(err) {
+		t.Errorf("more files kept than configured")
+	}
+}
+
+func countRecords(t *testing.T, path string) int {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open %s: %v", path, err)
+	}
+	defer file.Close()
+	count := 0
+	for {
+		if _, err := ReadRecord(file); err != nil {
+			if err != io.EOF {
+				t.Fatalf("decode %s: %v", path, err)
+			}
+			return count
+		}
+		count++
+	}
+}
+
+func TestSampleInterval(t *testing.T) {
+	dir, err := ioutil.TempDir("", "flightrecorder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "flight.record")
+
+	r, err := NewRecorder(Config{Path: path, SampleInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	for i := 0; i < 10; i++ {
+		r.Sample(nil, network.NewRequestInfo())
+	}
+	// only the first request of the interval is recorded
+	if count := countRecords(t, path); count != 1 {
+		t.Errorf("expected 1 sampled record, got %d", count)
+	}
+}
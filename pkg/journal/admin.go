@@ -0,0 +1,56 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package journal
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"sofastack.io/sofa-mosn/pkg/admin/server"
+)
+
+func init() {
+	server.RegisterAdminHandleFunc("/api/v1/events", dumpEvents)
+}
+
+// dumpEvents handles /api/v1/events. Query parameters:
+//
+//	type:  only events of this type, e.g. route_miss
+//	limit: at most this many events, newest first
+func dumpEvents(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("invalid limit\n"))
+			return
+		}
+		limit = n
+	}
+
+	entries := Entries(r.URL.Query().Get("type"), limit)
+	buf, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf)
+}
@@ -0,0 +1,111 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package journal keeps a bounded in-memory ring of recent notable proxy
+// events (route misses, retries, health transitions, rejected config
+// updates), giving a quick "what just happened" view over the admin api
+// without trawling logs.
+package journal
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Well-known event types, callers may record their own types as well
+const (
+	TypeRouteMiss    = "route_miss"
+	TypeRetry        = "retry"
+	TypeCircuitBreak = "circuit_break"
+	TypeHealthChange = "health_change"
+	TypeConfigReject = "config_reject"
+)
+
+// defaultCapacity bounds the journal memory, the oldest entries are
+// overwritten once the ring is full
+const defaultCapacity = 512
+
+// Entry is one recorded event
+type Entry struct {
+	Time    time.Time `json:"time"`
+	Type    string    `json:"type"`
+	Message string    `json:"message"`
+}
+
+type journal struct {
+	mu      sync.Mutex
+	entries []Entry
+	next    int
+	full    bool
+}
+
+var defaultJournal = &journal{
+	entries: make([]Entry, defaultCapacity),
+}
+
+// Record appends an event to the journal, overwriting the oldest entry
+// when the ring is full. It is safe for concurrent use and cheap enough
+// for request-path error branches
+func Record(eventType string, format string, args ...interface{}) {
+	defaultJournal.record(Entry{
+		Time:    time.Now(),
+		Type:    eventType,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// Entries returns the recorded events, newest first. An empty eventType
+// matches all types, limit <= 0 returns everything kept
+func Entries(eventType string, limit int) []Entry {
+	return defaultJournal.list(eventType, limit)
+}
+
+func (j *journal) record(e Entry) {
+	j.mu.Lock()
+	j.entries[j.next] = e
+	j.next++
+	if j.next == len(j.entries) {
+		j.next = 0
+		j.full = true
+	}
+	j.mu.Unlock()
+}
+
+func (j *journal) list(eventType string, limit int) []Entry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	count := j.next
+	if j.full {
+		count = len(j.entries)
+	}
+	out := make([]Entry, 0, count)
+	// walk backwards from the newest entry
+	for i := 0; i < count; i++ {
+		idx := (j.next - 1 - i + len(j.entries)) % len(j.entries)
+		e := j.entries[idx]
+		if eventType != "" && e.Type != eventType {
+			continue
+		}
+		out = append(out, e)
+		if limit > 0 && len(out) == limit {
+			break
+		}
+	}
+	return out
+}
@@ -0,0 +1,93 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package journal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestJournal(capacity int) *journal {
+	return &journal{entries: make([]Entry, capacity)}
+}
+
+func (j *journal) recordMessage(eventType, msg string) {
+	j.record(Entry{Time: time.Now(), Type: eventType, Message: msg})
+}
+
+func TestJournalNewestFirst(t *testing.T) {
+	j := newTestJournal(8)
+	j.recordMessage(TypeRouteMiss, "first")
+	j.recordMessage(TypeRetry, "second")
+	j.recordMessage(TypeRouteMiss, "third")
+
+	all := j.list("", 0)
+	if len(all) != 3 || all[0].Message != "third" || all[2].Message != "first" {
+		t.Errorf("unexpected order: %+v", all)
+	}
+	misses := j.list(TypeRouteMiss, 0)
+	if len(misses) != 2 || misses[0].Message != "third" {
+		t.Errorf("type filter failed: %+v", misses)
+	}
+	if limited := j.list("", 1); len(limited) != 1 || limited[0].Message != "third" {
+		t.Errorf("limit failed: %+v", limited)
+	}
+}
+
+func TestJournalRingOverwrite(t *testing.T) {
+	j := newTestJournal(4)
+	for i := 0; i < 10; i++ {
+		j.recordMessage(TypeRetry, fmt.Sprintf("event-%d", i))
+	}
+	all := j.list("", 0)
+	if len(all) != 4 {
+		t.Fatalf("ring should keep capacity entries, got %d", len(all))
+	}
+	if all[0].Message != "event-9" || all[3].Message != "event-6" {
+		t.Errorf("oldest entries should be overwritten: %+v", all)
+	}
+}
+
+func TestDumpEvents(t *testing.T) {
+	Record(TypeConfigReject, "update for cluster %s rejected", "test")
+
+	req := httptest.NewRequest("GET", "/api/v1/events?type=config_reject&limit=5", nil)
+	w := httptest.NewRecorder()
+	dumpEvents(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+	var entries []Entry
+	if err := json.Unmarshal(w.Body.Bytes(), &entries); err != nil {
+		t.Fatalf("response is not json: %v", err)
+	}
+	if len(entries) == 0 || entries[0].Type != TypeConfigReject {
+		t.Errorf("recorded event not returned: %+v", entries)
+	}
+
+	w = httptest.NewRecorder()
+	dumpEvents(w, httptest.NewRequest("GET", "/api/v1/events?limit=bogus", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("invalid limit should be rejected, got %d", w.Code)
+	}
+}
@@ -46,10 +46,13 @@ func init() {
 		types.LogResponseCode:               ResponseCodeGetter,
 		types.LogDuration:                   DurationGetter,
 		types.LogResponseFlag:               GetResponseFlagGetter,
+		types.LogErrorCode:                  ErrorCodeGetter,
 		types.LogUpstreamLocalAddress:       UpstreamLocalAddressGetter,
 		types.LogDownstreamLocalAddress:     DownstreamLocalAddressGetter,
 		types.LogDownstreamRemoteAddress:    DownstreamRemoteAddressGetter,
 		types.LogUpstreamHostSelectedGetter: UpstreamHostSelectedGetter,
+		types.LogGrpcStatus:                 GrpcStatusGetter,
+		types.LogGrpcMessage:                GrpcMessageGetter,
 	}
 	accessLogs = []*accesslog{}
 }
@@ -67,6 +70,7 @@ type accesslog struct {
 	filter    types.AccessLogFilter
 	formatter types.AccessLogFormatter
 	logger    *Logger
+	ring      *accessLogRing
 }
 
 // NewAccessLog
@@ -81,10 +85,12 @@ func NewAccessLog(output string, filter types.AccessLogFilter,
 		filter:    filter,
 		formatter: NewAccessLogFormatter(format),
 		logger:    lg,
+		ring:      newAccessLogRing(AccessLogRingSize),
 	}
 	if DefaultDisableAccessLog {
 		lg.Toggle(true) // disable accesslog by default
 	}
+	go l.flusher()
 	// save all access logs
 	accessLogs = append(accessLogs, l)
 
@@ -109,7 +115,8 @@ func (l *accesslog) Log(reqHeaders types.HeaderMap, respHeaders types.HeaderMap,
 		buf.Drain(1)
 	}
 	buf.WriteString("\n")
-	l.logger.Print(buf, true)
+	// enqueue only, the writer goroutine flushes entries to the logger
+	l.ring.enqueue(buf)
 }
 
 // types.AccessLogFormatter
@@ -123,6 +130,16 @@ func NewAccessLogFormatter(format string) types.AccessLogFormatter {
 		format = types.DefaultAccessLogFormat
 	}
 
+	// a JSON object format string selects the JSON output mode
+	if strings.HasPrefix(strings.TrimSpace(format), "{") {
+		if formatter, err := newJSONAccessLogFormatter(format); err == nil {
+			return formatter
+		} else {
+			DefaultLogger.Errorf("parse JSON access log format failed, fallback to default format: %v", err)
+			format = types.DefaultAccessLogFormat
+		}
+	}
+
 	return &accesslogformatter{
 		formatters: formatToFormatter(format),
 	}
@@ -323,6 +340,15 @@ func GetResponseFlagGetter(info types.RequestInfo) string {
 	return strconv.FormatBool(info.GetResponseFlag(0))
 }
 
+// ErrorCodeGetter
+// get request's machine readable failure reason, "-" on success
+func ErrorCodeGetter(info types.RequestInfo) string {
+	if code := info.ErrorCode(); code != types.ErrCodeNone {
+		return string(code)
+	}
+	return "-"
+}
+
 // UpstreamLocalAddressGetter
 // get upstream's local address
 func UpstreamLocalAddressGetter(info types.RequestInfo) string {
@@ -358,3 +384,15 @@ func UpstreamHostSelectedGetter(info types.RequestInfo) string {
 	}
 	return ""
 }
+
+// GrpcStatusGetter
+// get the grpc-status the upstream responded with, "-" for non-gRPC requests
+func GrpcStatusGetter(info types.RequestInfo) string {
+	return info.GrpcStatus()
+}
+
+// GrpcMessageGetter
+// get the grpc-message paired with the grpc-status
+func GrpcMessageGetter(info types.RequestInfo) string {
+	return info.GrpcMessage()
+}
@@ -0,0 +1,135 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package log
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+// JSON access log output. A format string that is a JSON object switches
+// the access log into JSON mode: the object keys name the output fields and
+// the values reference log fields the same way the plain format does, e.g.
+//
+//	{"time": "%StartTime%", "status": "%ResponseCode%", "svc": "%REQ.service%"}
+//
+// values without the %...% wrapper are written as literals.
+
+// fieldResolver resolves one output field of a log entry
+type fieldResolver func(reqHeaders types.HeaderMap, respHeaders types.HeaderMap, requestInfo types.RequestInfo) string
+
+// jsonEntry is one key of the JSON output object
+type jsonEntry struct {
+	key     string
+	resolve fieldResolver
+}
+
+// types.AccessLogFormatter
+type jsonAccessLogFormatter struct {
+	entries []jsonEntry
+}
+
+// newJSONAccessLogFormatter parses a JSON object format string into a
+// formatter with a user-defined key mapping
+func newJSONAccessLogFormatter(format string) (types.AccessLogFormatter, error) {
+	mapping := make(map[string]string)
+	if err := json.Unmarshal([]byte(format), &mapping); err != nil {
+		return nil, err
+	}
+
+	// stable field order in the output
+	keys := make([]string, 0, len(mapping))
+	for key := range mapping {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	entries := make([]jsonEntry, 0, len(keys))
+	for _, key := range keys {
+		entries = append(entries, jsonEntry{
+			key:     key,
+			resolve: resolveField(mapping[key]),
+		})
+	}
+	return &jsonAccessLogFormatter{entries: entries}, nil
+}
+
+// resolveField maps a %...% field reference to its getter, other values are
+// written as literals
+func resolveField(value string) fieldResolver {
+	if !(len(value) > 2 && strings.HasPrefix(value, "%") && strings.HasSuffix(value, "%")) {
+		return func(_ types.HeaderMap, _ types.HeaderMap, _ types.RequestInfo) string {
+			return value
+		}
+	}
+	field := value[1 : len(value)-1]
+
+	if strings.HasPrefix(field, types.ReqHeaderPrefix) {
+		header := field[len(types.ReqHeaderPrefix):]
+		return func(reqHeaders types.HeaderMap, _ types.HeaderMap, _ types.RequestInfo) string {
+			if reqHeaders != nil {
+				if v, ok := reqHeaders.Get(header); ok {
+					return v
+				}
+			}
+			return "-"
+		}
+	}
+	if strings.HasPrefix(field, types.RespHeaderPrefix) {
+		header := field[len(types.RespHeaderPrefix):]
+		return func(_ types.HeaderMap, respHeaders types.HeaderMap, _ types.RequestInfo) string {
+			if respHeaders != nil {
+				if v, ok := respHeaders.Get(header); ok {
+					return v
+				}
+			}
+			return "-"
+		}
+	}
+	if vFunc, ok := RequestInfoFuncMap[field]; ok {
+		return func(_ types.HeaderMap, _ types.HeaderMap, requestInfo types.RequestInfo) string {
+			if s := vFunc(requestInfo); s != "" {
+				return s
+			}
+			return "-"
+		}
+	}
+
+	DefaultLogger.Debugf("Invalid JSON access log field: %s", field)
+	return func(_ types.HeaderMap, _ types.HeaderMap, _ types.RequestInfo) string {
+		return "-"
+	}
+}
+
+func (f *jsonAccessLogFormatter) Format(buf types.IoBuffer, reqHeaders types.HeaderMap, respHeaders types.HeaderMap, requestInfo types.RequestInfo) {
+	// the leading space is drained by accesslog.Log, same as the plain format
+	buf.WriteString(" {")
+	for i, entry := range f.entries {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		buf.WriteString(strconv.Quote(entry.key))
+		buf.WriteString(":")
+		buf.WriteString(strconv.Quote(entry.resolve(reqHeaders, respHeaders, requestInfo)))
+	}
+	buf.WriteString("}")
+}
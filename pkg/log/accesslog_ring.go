@@ -0,0 +1,169 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package log
+
+import (
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+
+	"sofastack.io/sofa-mosn/pkg/buffer"
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+// AccessLogRingSize is the capacity of the per access log entry ring,
+// must be a power of two
+const AccessLogRingSize = 1 << 10
+
+// accessLogFlushInterval is the backstop interval the writer goroutine
+// flushes buffered entries on, entries are usually flushed immediately
+var accessLogFlushInterval = time.Second
+
+// SetAccessLogFlushInterval configures the access log flush interval
+func SetAccessLogFlushInterval(interval time.Duration) {
+	if interval > 0 {
+		accessLogFlushInterval = interval
+	}
+}
+
+// AccessLogDroppedCount returns the total number of access log entries
+// dropped because the entry ring was full
+func AccessLogDroppedCount() uint64 {
+	var dropped uint64
+	for _, lg := range accessLogs {
+		if lg.ring != nil {
+			dropped += atomic.LoadUint64(&lg.ring.dropped)
+		}
+	}
+	return dropped
+}
+
+// ringSlot is a single cell of the entry ring, seq implements the
+// slot state machine: seq == pos means writable, seq == pos+1 means readable
+type ringSlot struct {
+	seq uint64
+	buf types.IoBuffer
+}
+
+// accessLogRing is a bounded lock-free multi-producer single-consumer ring.
+// producers are the request goroutines, the consumer is the writer goroutine.
+// when the ring is full new entries are dropped and counted instead of
+// blocking the request path
+type accessLogRing struct {
+	slots   []ringSlot
+	mask    uint64
+	write   uint64
+	read    uint64
+	dropped uint64
+	notify  chan struct{}
+}
+
+func newAccessLogRing(size uint64) *accessLogRing {
+	r := &accessLogRing{
+		slots:  make([]ringSlot, size),
+		mask:   size - 1,
+		notify: make(chan struct{}, 1),
+	}
+	for i := range r.slots {
+		r.slots[i].seq = uint64(i)
+	}
+	return r
+}
+
+// enqueue adds an entry to the ring, never blocks.
+// returns false and frees the buffer if the ring is full
+func (r *accessLogRing) enqueue(buf types.IoBuffer) bool {
+	for {
+		pos := atomic.LoadUint64(&r.write)
+		slot := &r.slots[pos&r.mask]
+		seq := atomic.LoadUint64(&slot.seq)
+		switch {
+		case seq == pos:
+			if atomic.CompareAndSwapUint64(&r.write, pos, pos+1) {
+				slot.buf = buf
+				atomic.StoreUint64(&slot.seq, pos+1)
+				// wake up the writer goroutine, lossy signal is fine
+				// since the flush ticker drains leftovers
+				select {
+				case r.notify <- struct{}{}:
+				default:
+				}
+				return true
+			}
+		case seq < pos:
+			// ring is full, drop the entry
+			atomic.AddUint64(&r.dropped, 1)
+			buffer.PutIoBuffer(buf)
+			return false
+		default:
+			// another producer won the slot, retry
+		}
+	}
+}
+
+// dequeue removes an entry from the ring, only the writer goroutine calls it
+func (r *accessLogRing) dequeue() types.IoBuffer {
+	pos := r.read
+	slot := &r.slots[pos&r.mask]
+	if atomic.LoadUint64(&slot.seq) != pos+1 {
+		return nil
+	}
+	buf := slot.buf
+	slot.buf = nil
+	atomic.StoreUint64(&slot.seq, pos+r.mask+1)
+	r.read = pos + 1
+	return buf
+}
+
+// flusher consumes the entry ring and hands batched entries to the
+// underlying async logger, so a stalled disk backs up the ring
+// (and drops entries) instead of the request path
+func (l *accesslog) flusher() {
+	defer func() {
+		if p := recover(); p != nil {
+			debug.PrintStack()
+			go l.flusher()
+		}
+	}()
+	ticker := time.NewTicker(accessLogFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.ring.notify:
+		case <-ticker.C:
+		}
+		l.flush()
+	}
+}
+
+// flush drains all readable entries into a single buffer and writes it
+func (l *accesslog) flush() {
+	buf := l.ring.dequeue()
+	if buf == nil {
+		return
+	}
+	for {
+		b := l.ring.dequeue()
+		if b == nil {
+			break
+		}
+		buf.Write(b.Bytes())
+		buffer.PutIoBuffer(b)
+	}
+	l.logger.Print(buf, true)
+}
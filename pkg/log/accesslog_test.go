@@ -18,6 +18,7 @@
 package log
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net"
@@ -28,6 +29,7 @@ import (
 	"os"
 	"regexp"
 
+	"sofastack.io/sofa-mosn/pkg/buffer"
 	"sofastack.io/sofa-mosn/pkg/protocol"
 	"sofastack.io/sofa-mosn/pkg/types"
 )
@@ -336,6 +338,13 @@ func (r *mock_requestInfo) SetResponseFlag(flag types.ResponseFlag) {
 	r.responseFlag |= flag
 }
 
+func (r *mock_requestInfo) ErrorCode() types.ProxyErrorCode {
+	return types.ErrorCodeForResponseFlag(r.responseFlag)
+}
+
+func (r *mock_requestInfo) SetErrorCode(code types.ProxyErrorCode) {
+}
+
 func (r *mock_requestInfo) UpstreamHost() types.HostInfo {
 	return r.upstreamHost
 }
@@ -376,6 +385,13 @@ func (r *mock_requestInfo) SetDownstreamRemoteAddress(addr net.Addr) {
 	r.downstreamRemoteAddress = addr
 }
 
+func (r *mock_requestInfo) UpstreamCluster() string {
+	return ""
+}
+
+func (r *mock_requestInfo) SetUpstreamCluster(cluster string) {
+}
+
 func (r *mock_requestInfo) RouteEntry() types.RouteRule {
 	return r.routerRule
 }
@@ -383,3 +399,100 @@ func (r *mock_requestInfo) RouteEntry() types.RouteRule {
 func (r *mock_requestInfo) SetRouteEntry(routerRule types.RouteRule) {
 	r.routerRule = routerRule
 }
+
+func (r *mock_requestInfo) AttemptCount() uint32 {
+	return 1
+}
+
+func (r *mock_requestInfo) SetAttemptCount(count uint32) {
+}
+
+func (r *mock_requestInfo) UpstreamServerTiming() string {
+	return ""
+}
+
+func (r *mock_requestInfo) SetUpstreamServerTiming(timing string) {
+}
+
+func (r *mock_requestInfo) GrpcStatus() string {
+	return ""
+}
+
+func (r *mock_requestInfo) GrpcMessage() string {
+	return ""
+}
+
+func (r *mock_requestInfo) SetGrpcStatus(status, message string) {
+}
+
+func TestAccessLogJSONFormat(t *testing.T) {
+	format := `{"time": "%StartTime%", "status": "%ResponseCode%", "bytes_sent": "%BytesSent%", "service": "%REQ.service%", "server": "%RESP.Server%", "app": "mosn"}`
+	logName := "/tmp/mosn_accesslog/test_json.log"
+	os.Remove(logName)
+	accessLog, err := NewAccessLog(logName, nil, format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// make sure the logger is enabled even if a former test disabled all
+	ToggleLogger(logName, false)
+	reqHeaders := map[string]string{
+		"service": "test",
+	}
+	respHeaders := map[string]string{
+		"Server": "MOSN",
+	}
+	requestInfo := newRequestInfo()
+	requestInfo.SetBytesSent(2048)
+
+	accessLog.Log(protocol.CommonHeader(reqHeaders), protocol.CommonHeader(respHeaders), requestInfo)
+	time.Sleep(time.Second)
+
+	b, err := ioutil.ReadFile(logName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := make(map[string]string)
+	if err := json.Unmarshal(b, &entry); err != nil {
+		t.Fatalf("access log is not valid JSON: %v, data: %s", err, b)
+	}
+	if !(entry["status"] == "0" &&
+		entry["bytes_sent"] == "2048" &&
+		entry["service"] == "test" &&
+		entry["server"] == "MOSN" &&
+		entry["app"] == "mosn") {
+		t.Errorf("unexpected JSON access log entry: %v", entry)
+	}
+
+	// an invalid JSON format falls back to the default format
+	if formatter := NewAccessLogFormatter(`{"broken": `); formatter == nil {
+		t.Error("invalid JSON format should fallback, not fail")
+	}
+}
+
+func TestAccessLogRingDrop(t *testing.T) {
+	ring := newAccessLogRing(4)
+	for i := 0; i < 4; i++ {
+		if !ring.enqueue(buffer.GetIoBuffer(1)) {
+			t.Fatalf("enqueue %d should succeed", i)
+		}
+	}
+	// ring is full now, the entry is dropped and counted
+	if ring.enqueue(buffer.GetIoBuffer(1)) {
+		t.Error("enqueue on a full ring should drop")
+	}
+	if ring.dropped != 1 {
+		t.Errorf("dropped count expected 1, got %d", ring.dropped)
+	}
+	for i := 0; i < 4; i++ {
+		if ring.dequeue() == nil {
+			t.Fatalf("dequeue %d should return an entry", i)
+		}
+	}
+	if ring.dequeue() != nil {
+		t.Error("dequeue on an empty ring should return nil")
+	}
+	// slots are reusable after a full wrap
+	if !ring.enqueue(buffer.GetIoBuffer(1)) {
+		t.Error("enqueue after wrap should succeed")
+	}
+}
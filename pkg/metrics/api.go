@@ -0,0 +1,39 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+// APIType represents logical operation level metrics type
+const APIType = "api"
+
+// metrics key in operation
+const (
+	APIRequestTotal    = "request_total"
+	APIResponseSuccess = "response_success"
+	APIResponseFailed  = "response_failed"
+)
+
+// NewAPIStats returns a stats with the logical operation as its label. The
+// operation set must be bounded by the classifier that produced it
+func NewAPIStats(operation string) types.Metrics {
+	metrics, _ := NewMetrics(APIType, map[string]string{"operation": operation})
+	return metrics
+}
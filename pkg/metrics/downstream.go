@@ -29,6 +29,7 @@ const (
 	DownstreamConnectionTotal    = "connection_total"
 	DownstreamConnectionDestroy  = "connection_destroy"
 	DownstreamConnectionActive   = "connection_active"
+	DownstreamConnectionOverflow = "connection_overflow"
 	DownstreamBytesReadTotal     = "bytes_read_total"
 	DownstreamBytesReadBuffered  = "bytes_read_buffered"
 	DownstreamBytesWriteTotal    = "bytes_write_total"
@@ -38,6 +39,28 @@ const (
 	DownstreamRequestReset       = "request_reset"
 	DownstreamRequestTime        = "request_time"
 	DownstreamRequestTimeTotal   = "request_time_total"
+	DownstreamStreamLimitReset   = "stream_limit_reset"
+	DownstreamAcceptOverflow     = "accept_overflow"
+	// remaining connections on a listener whose deletion is bleeding off
+	DownstreamConnectionPendingDelete = "connection_pending_delete"
+	// websocket upgrade accounting, active counts spliced connections
+	DownstreamWebSocketUpgradeTotal  = "websocket_upgrade_total"
+	DownstreamWebSocketUpgradeReject = "websocket_upgrade_reject"
+	DownstreamWebSocketActive        = "websocket_active"
+	// accept-time durations, recorded in milliseconds
+	DownstreamAcceptToFirstByteDuration = "accept_to_first_byte_duration"
+	DownstreamTLSHandshakeDuration      = "tls_handshake_duration"
+	DownstreamTLSRenegotiationRejected  = "tls_renegotiation_rejected"
+	// protocol translation accounting, the pass-through counter tracks
+	// inputs forwarded untranslated after a failure
+	DownstreamTranslationFailed      = "translation_failed"
+	DownstreamTranslationPassThrough = "translation_pass_through"
+	// request shadowing accounting, failed counts mirrors dropped before
+	// reaching the shadow cluster (overflow, no snapshot, no pool)
+	DownstreamShadowRequestTotal  = "shadow_request_total"
+	DownstreamShadowRequestFailed = "shadow_request_failed"
+	// DownstreamRequestErrorPrefix is completed with a types.ProxyErrorCode
+	DownstreamRequestErrorPrefix = "request_error_total."
 )
 
 // NewProxyStats returns a stats with namespace prefix proxy
@@ -0,0 +1,39 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+// MQTTType represents mqtt proxy metrics type
+const MQTTType = "mqtt"
+
+// metrics key in topic
+const (
+	MQTTPublishTotal   = "publish_total"
+	MQTTPublishBytes   = "publish_bytes"
+	MQTTSubscribeTotal = "subscribe_total"
+)
+
+// NewMQTTStats returns a stats with the mqtt topic as its label, the
+// topic set must be bounded by the caller
+func NewMQTTStats(topic string) types.Metrics {
+	metrics, _ := NewMetrics(MQTTType, map[string]string{"topic": topic})
+	return metrics
+}
@@ -0,0 +1,40 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+// RedisType represents redis proxy metrics type
+const RedisType = "redis"
+
+// metrics key in command
+const (
+	RedisCommandTotal      = "command_total"
+	RedisCommandError      = "command_error"
+	RedisCommandDurationMs = "command_duration_ms"
+	RedisRedirectionTotal  = "redirection_total"
+)
+
+// NewRedisStats returns a stats with the redis command name as its label,
+// the command set is bounded by the protocol itself
+func NewRedisStats(command string) types.Metrics {
+	metrics, _ := NewMetrics(RedisType, map[string]string{"command": command})
+	return metrics
+}
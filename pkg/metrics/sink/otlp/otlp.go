@@ -0,0 +1,225 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package otlp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/metrics"
+	"sofastack.io/sofa-mosn/pkg/metrics/sink"
+	"sofastack.io/sofa-mosn/pkg/types"
+	"sofastack.io/sofa-mosn/pkg/utils"
+	gometrics "github.com/rcrowley/go-metrics"
+	"google.golang.org/grpc"
+)
+
+var (
+	sinkType = "otlp"
+
+	exportMethod = "/opentelemetry.proto.collector.metrics.v1.MetricsService/Export"
+
+	defaultFlushInterval = 15 * time.Second
+	defaultExportTimeout = 5 * time.Second
+	defaultServiceName   = "mosn"
+)
+
+func init() {
+	sink.RegisterSink(sinkType, builder)
+}
+
+// otlpConfig contains config for OtlpSink
+type otlpConfig struct {
+	Address       string `json:"address"`        // collector otlp/grpc endpoint, e.g. 127.0.0.1:4317
+	ServiceName   string `json:"service_name"`   // resource service.name attribute
+	FlushInterval string `json:"flush_interval"` // push interval, duration string, default 15s
+}
+
+// otlpSink pushes metrics to an OpenTelemetry collector with specified interval
+type otlpSink struct {
+	config *otlpConfig
+
+	conn     *grpc.ClientConn
+	interval time.Duration
+	ticker   *utils.Ticker
+}
+
+// ~ MetricsSink
+// Flush converts metrics into an OTLP export request and pushes it to the
+// collector, the writer is ignored.
+func (osink *otlpSink) Flush(_ io.Writer, ms []types.Metrics) {
+	req := makeExportRequest(osink.config.ServiceName, ms)
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultExportTimeout)
+	defer cancel()
+
+	if err := osink.conn.Invoke(ctx, exportMethod, req, &ExportMetricsServiceResponse{}); err != nil {
+		log.DefaultLogger.Errorf("[otlp] export metrics to %s failed: %v", osink.config.Address, err)
+	}
+}
+
+// makeExportRequest maps MOSN metrics into OTLP resource metrics.
+// The labels from Metrics.Labels() become data point attributes, counters
+// are exported as cumulative sums and gauges as gauges, histograms are
+// exported as min/max gauges consistent with the other sinks.
+func makeExportRequest(serviceName string, ms []types.Metrics) *ExportMetricsServiceRequest {
+	now := uint64(time.Now().UnixNano())
+
+	otlpMetrics := make([]*Metric, 0, len(ms))
+	for _, m := range ms {
+		typ := m.Type()
+		labelKeys, labelVals := m.SortedLabels()
+		if sink.IsExclusionLabels(labelKeys) {
+			continue
+		}
+		attrs := makeAttributes(labelKeys, labelVals)
+
+		m.Each(func(name string, i interface{}) {
+			if sink.IsExclusionKeys(name) {
+				return
+			}
+			switch metric := i.(type) {
+			case gometrics.Counter:
+				otlpMetrics = append(otlpMetrics, makeSum(typ+"."+name, attrs, now, metric.Count()))
+			case gometrics.Gauge:
+				otlpMetrics = append(otlpMetrics, makeGauge(typ+"."+name, attrs, now, metric.Value()))
+			case gometrics.Histogram:
+				h := metric.Snapshot()
+				otlpMetrics = append(otlpMetrics,
+					makeGauge(typ+"."+name+".min", attrs, now, h.Min()),
+					makeGauge(typ+"."+name+".max", attrs, now, h.Max()))
+			}
+		})
+	}
+
+	return &ExportMetricsServiceRequest{
+		ResourceMetrics: []*ResourceMetrics{
+			{
+				Resource: &Resource{
+					Attributes: []*KeyValue{
+						{Key: "service.name", Value: &AnyValue{StringValue: serviceName}},
+					},
+				},
+				ScopeMetrics: []*ScopeMetrics{
+					{
+						Scope:   &InstrumentationScope{Name: "mosn.metrics"},
+						Metrics: otlpMetrics,
+					},
+				},
+			},
+		},
+	}
+}
+
+func makeAttributes(keys, values []string) (attrs []*KeyValue) {
+	if length := len(keys); length == len(values) {
+		attrs = make([]*KeyValue, length)
+		for i := 0; i < length; i++ {
+			attrs[i] = &KeyValue{
+				Key:   keys[i],
+				Value: &AnyValue{StringValue: values[i]},
+			}
+		}
+	}
+	return
+}
+
+func makeSum(name string, attrs []*KeyValue, now uint64, val int64) *Metric {
+	return &Metric{
+		Name: name,
+		Sum: &Sum{
+			DataPoints: []*NumberDataPoint{
+				{TimeUnixNano: now, AsInt: val, Attributes: attrs},
+			},
+			AggregationTemporality: AggregationTemporalityCumulative,
+			// MOSN counters can be decremented (e.g. active requests),
+			// so they are not monotonic sums
+			IsMonotonic: false,
+		},
+	}
+}
+
+func makeGauge(name string, attrs []*KeyValue, now uint64, val int64) *Metric {
+	return &Metric{
+		Name: name,
+		Gauge: &Gauge{
+			DataPoints: []*NumberDataPoint{
+				{TimeUnixNano: now, AsInt: val, Attributes: attrs},
+			},
+		},
+	}
+}
+
+// NewOtlpSink returns a metrics sink that pushes metrics to an OpenTelemetry
+// collector over OTLP/gRPC with the configured interval
+func NewOtlpSink(config *otlpConfig, interval time.Duration) (types.MetricsSink, error) {
+	conn, err := grpc.Dial(config.Address, grpc.WithInsecure())
+	if err != nil {
+		return nil, err
+	}
+
+	osink := &otlpSink{
+		config:   config,
+		conn:     conn,
+		interval: interval,
+	}
+	osink.ticker = utils.NewTicker(func() {
+		osink.Flush(ioutil.Discard, metrics.GetAll())
+	})
+	osink.ticker.Start(interval)
+
+	return osink, nil
+}
+
+// factory
+func builder(cfg map[string]interface{}) (types.MetricsSink, error) {
+	// parse config
+	otlpCfg := &otlpConfig{}
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("parsing otlp sink error, err: %v, cfg: %v", err, cfg)
+	}
+	if err := json.Unmarshal(data, otlpCfg); err != nil {
+		return nil, fmt.Errorf("parsing otlp sink error, err: %v, cfg: %v", err, cfg)
+	}
+
+	if otlpCfg.Address == "" {
+		return nil, errors.New("otlp sink's address is not specified")
+	}
+	if otlpCfg.ServiceName == "" {
+		otlpCfg.ServiceName = defaultServiceName
+	}
+
+	interval := defaultFlushInterval
+	if otlpCfg.FlushInterval != "" {
+		d, err := time.ParseDuration(otlpCfg.FlushInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid otlp sink flush_interval: %s", otlpCfg.FlushInterval)
+		}
+		interval = d
+	}
+
+	return NewOtlpSink(otlpCfg, interval)
+}
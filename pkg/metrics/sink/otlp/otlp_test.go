@@ -0,0 +1,105 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package otlp
+
+import (
+	"testing"
+
+	"sofastack.io/sofa-mosn/pkg/metrics"
+	"github.com/golang/protobuf/proto"
+)
+
+func TestMakeExportRequest(t *testing.T) {
+	metrics.ResetAll()
+	m, _ := metrics.NewMetrics("t1", map[string]string{"lbk1": "lbv1"})
+	m.Counter("k1").Inc(2)
+	m.Gauge("k2").Update(3)
+	m.Histogram("k3").Update(5)
+
+	req := makeExportRequest("mosn-test", metrics.GetAll())
+
+	if len(req.ResourceMetrics) != 1 {
+		t.Fatalf("unexpected resource metrics count: %d", len(req.ResourceMetrics))
+	}
+	rm := req.ResourceMetrics[0]
+	if !(len(rm.Resource.Attributes) == 1 &&
+		rm.Resource.Attributes[0].Key == "service.name" &&
+		rm.Resource.Attributes[0].Value.StringValue == "mosn-test") {
+		t.Errorf("unexpected resource attributes: %v", rm.Resource.Attributes)
+	}
+	if len(rm.ScopeMetrics) != 1 {
+		t.Fatalf("unexpected scope metrics count: %d", len(rm.ScopeMetrics))
+	}
+
+	// counter + gauge + histogram min/max
+	otlpMetrics := rm.ScopeMetrics[0].Metrics
+	if len(otlpMetrics) != 4 {
+		t.Fatalf("unexpected metrics count: %d", len(otlpMetrics))
+	}
+	byName := make(map[string]*Metric, len(otlpMetrics))
+	for _, om := range otlpMetrics {
+		byName[om.Name] = om
+	}
+
+	if sum := byName["t1.k1"]; sum == nil || sum.Sum == nil {
+		t.Error("counter k1 is not exported as sum")
+	} else {
+		dp := sum.Sum.DataPoints[0]
+		if dp.AsInt != 2 {
+			t.Errorf("counter value not match, got %d", dp.AsInt)
+		}
+		if !(len(dp.Attributes) == 1 &&
+			dp.Attributes[0].Key == "lbk1" &&
+			dp.Attributes[0].Value.StringValue == "lbv1") {
+			t.Errorf("labels are not mapped to attributes: %v", dp.Attributes)
+		}
+		if sum.Sum.AggregationTemporality != AggregationTemporalityCumulative {
+			t.Error("counter should be exported as cumulative sum")
+		}
+	}
+	if gauge := byName["t1.k2"]; gauge == nil || gauge.Gauge == nil {
+		t.Error("gauge k2 is not exported as gauge")
+	} else if gauge.Gauge.DataPoints[0].AsInt != 3 {
+		t.Errorf("gauge value not match, got %d", gauge.Gauge.DataPoints[0].AsInt)
+	}
+	if byName["t1.k3.min"] == nil || byName["t1.k3.max"] == nil {
+		t.Error("histogram k3 is not exported as min/max gauges")
+	}
+}
+
+// the hand-written messages should survive a marshal/unmarshal roundtrip,
+// which guards the protobuf struct tags
+func TestExportRequestRoundTrip(t *testing.T) {
+	metrics.ResetAll()
+	m, _ := metrics.NewMetrics("t2", map[string]string{"cluster": "c1"})
+	m.Counter("requests").Inc(10)
+
+	req := makeExportRequest("mosn", metrics.GetAll())
+	data, err := proto.Marshal(req)
+	if err != nil {
+		t.Fatalf("marshal export request failed: %v", err)
+	}
+
+	decoded := &ExportMetricsServiceRequest{}
+	if err := proto.Unmarshal(data, decoded); err != nil {
+		t.Fatalf("unmarshal export request failed: %v", err)
+	}
+	if !proto.Equal(req, decoded) {
+		t.Errorf("roundtrip not equal, got %v, want %v", decoded, req)
+	}
+}
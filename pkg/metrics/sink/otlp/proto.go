@@ -0,0 +1,157 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package otlp
+
+import (
+	"github.com/golang/protobuf/proto"
+)
+
+// Hand-written subset of the OpenTelemetry metrics protocol
+// (opentelemetry.proto.metrics.v1 / opentelemetry.proto.collector.metrics.v1).
+// Only the fields MOSN exports are declared; field numbers and wire types
+// follow the upstream proto definitions, so the payload is wire-compatible
+// with any OTLP/gRPC collector without vendoring the generated code.
+
+// AggregationTemporalityCumulative marks data points that are accumulated
+// from process start, which matches go-metrics counters.
+const AggregationTemporalityCumulative = int32(2)
+
+// AnyValue is opentelemetry.proto.common.v1.AnyValue, string values only.
+type AnyValue struct {
+	StringValue string `protobuf:"bytes,1,opt,name=string_value,json=stringValue,proto3"`
+}
+
+func (m *AnyValue) Reset()         { *m = AnyValue{} }
+func (m *AnyValue) String() string { return proto.CompactTextString(m) }
+func (*AnyValue) ProtoMessage()    {}
+
+// KeyValue is opentelemetry.proto.common.v1.KeyValue.
+type KeyValue struct {
+	Key   string    `protobuf:"bytes,1,opt,name=key,proto3"`
+	Value *AnyValue `protobuf:"bytes,2,opt,name=value,proto3"`
+}
+
+func (m *KeyValue) Reset()         { *m = KeyValue{} }
+func (m *KeyValue) String() string { return proto.CompactTextString(m) }
+func (*KeyValue) ProtoMessage()    {}
+
+// InstrumentationScope is opentelemetry.proto.common.v1.InstrumentationScope.
+type InstrumentationScope struct {
+	Name    string `protobuf:"bytes,1,opt,name=name,proto3"`
+	Version string `protobuf:"bytes,2,opt,name=version,proto3"`
+}
+
+func (m *InstrumentationScope) Reset()         { *m = InstrumentationScope{} }
+func (m *InstrumentationScope) String() string { return proto.CompactTextString(m) }
+func (*InstrumentationScope) ProtoMessage()    {}
+
+// Resource is opentelemetry.proto.resource.v1.Resource.
+type Resource struct {
+	Attributes []*KeyValue `protobuf:"bytes,1,rep,name=attributes,proto3"`
+}
+
+func (m *Resource) Reset()         { *m = Resource{} }
+func (m *Resource) String() string { return proto.CompactTextString(m) }
+func (*Resource) ProtoMessage()    {}
+
+// NumberDataPoint is opentelemetry.proto.metrics.v1.NumberDataPoint with
+// integer values only, since go-metrics values are all int64.
+type NumberDataPoint struct {
+	StartTimeUnixNano uint64      `protobuf:"fixed64,2,opt,name=start_time_unix_nano,json=startTimeUnixNano,proto3"`
+	TimeUnixNano      uint64      `protobuf:"fixed64,3,opt,name=time_unix_nano,json=timeUnixNano,proto3"`
+	AsInt             int64       `protobuf:"fixed64,6,opt,name=as_int,json=asInt,proto3"`
+	Attributes        []*KeyValue `protobuf:"bytes,7,rep,name=attributes,proto3"`
+}
+
+func (m *NumberDataPoint) Reset()         { *m = NumberDataPoint{} }
+func (m *NumberDataPoint) String() string { return proto.CompactTextString(m) }
+func (*NumberDataPoint) ProtoMessage()    {}
+
+// Gauge is opentelemetry.proto.metrics.v1.Gauge.
+type Gauge struct {
+	DataPoints []*NumberDataPoint `protobuf:"bytes,1,rep,name=data_points,json=dataPoints,proto3"`
+}
+
+func (m *Gauge) Reset()         { *m = Gauge{} }
+func (m *Gauge) String() string { return proto.CompactTextString(m) }
+func (*Gauge) ProtoMessage()    {}
+
+// Sum is opentelemetry.proto.metrics.v1.Sum.
+type Sum struct {
+	DataPoints             []*NumberDataPoint `protobuf:"bytes,1,rep,name=data_points,json=dataPoints,proto3"`
+	AggregationTemporality int32              `protobuf:"varint,2,opt,name=aggregation_temporality,json=aggregationTemporality,proto3"`
+	IsMonotonic            bool               `protobuf:"varint,3,opt,name=is_monotonic,json=isMonotonic,proto3"`
+}
+
+func (m *Sum) Reset()         { *m = Sum{} }
+func (m *Sum) String() string { return proto.CompactTextString(m) }
+func (*Sum) ProtoMessage()    {}
+
+// Metric is opentelemetry.proto.metrics.v1.Metric. Gauge and Sum are
+// declared as plain fields instead of a oneof, which is wire-compatible
+// as long as only one of them is set.
+type Metric struct {
+	Name        string `protobuf:"bytes,1,opt,name=name,proto3"`
+	Description string `protobuf:"bytes,2,opt,name=description,proto3"`
+	Unit        string `protobuf:"bytes,3,opt,name=unit,proto3"`
+	Gauge       *Gauge `protobuf:"bytes,5,opt,name=gauge,proto3"`
+	Sum         *Sum   `protobuf:"bytes,7,opt,name=sum,proto3"`
+}
+
+func (m *Metric) Reset()         { *m = Metric{} }
+func (m *Metric) String() string { return proto.CompactTextString(m) }
+func (*Metric) ProtoMessage()    {}
+
+// ScopeMetrics is opentelemetry.proto.metrics.v1.ScopeMetrics.
+type ScopeMetrics struct {
+	Scope   *InstrumentationScope `protobuf:"bytes,1,opt,name=scope,proto3"`
+	Metrics []*Metric             `protobuf:"bytes,2,rep,name=metrics,proto3"`
+}
+
+func (m *ScopeMetrics) Reset()         { *m = ScopeMetrics{} }
+func (m *ScopeMetrics) String() string { return proto.CompactTextString(m) }
+func (*ScopeMetrics) ProtoMessage()    {}
+
+// ResourceMetrics is opentelemetry.proto.metrics.v1.ResourceMetrics.
+type ResourceMetrics struct {
+	Resource     *Resource       `protobuf:"bytes,1,opt,name=resource,proto3"`
+	ScopeMetrics []*ScopeMetrics `protobuf:"bytes,2,rep,name=scope_metrics,json=scopeMetrics,proto3"`
+}
+
+func (m *ResourceMetrics) Reset()         { *m = ResourceMetrics{} }
+func (m *ResourceMetrics) String() string { return proto.CompactTextString(m) }
+func (*ResourceMetrics) ProtoMessage()    {}
+
+// ExportMetricsServiceRequest is
+// opentelemetry.proto.collector.metrics.v1.ExportMetricsServiceRequest.
+type ExportMetricsServiceRequest struct {
+	ResourceMetrics []*ResourceMetrics `protobuf:"bytes,1,rep,name=resource_metrics,json=resourceMetrics,proto3"`
+}
+
+func (m *ExportMetricsServiceRequest) Reset()         { *m = ExportMetricsServiceRequest{} }
+func (m *ExportMetricsServiceRequest) String() string { return proto.CompactTextString(m) }
+func (*ExportMetricsServiceRequest) ProtoMessage()    {}
+
+// ExportMetricsServiceResponse is
+// opentelemetry.proto.collector.metrics.v1.ExportMetricsServiceResponse.
+type ExportMetricsServiceResponse struct {
+}
+
+func (m *ExportMetricsServiceResponse) Reset()         { *m = ExportMetricsServiceResponse{} }
+func (m *ExportMetricsServiceResponse) String() string { return proto.CompactTextString(m) }
+func (*ExportMetricsServiceResponse) ProtoMessage()    {}
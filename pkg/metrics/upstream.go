@@ -61,6 +61,15 @@ const (
 	UpstreamBytesReadBuffered    = "connection_bytes_read_buffered"
 	UpstreamBytesWriteTotal      = "connection_bytes_write"
 	UpstreamBytesWriteBuffered   = "connection_bytes_write_buffered"
+	// UpstreamAddressFlap counts logical hosts whose resolved address changed
+	UpstreamAddressFlap = "address_flap"
+	// locality lb counters, their ratio is the cross-zone request share
+	UpstreamRequestLocalZone = "request_local_zone"
+	UpstreamRequestCrossZone = "request_cross_zone"
+	// slo compliance over the rolling window, rates are permille so they fit a gauge
+	UpstreamSLOSuccessRate = "slo_success_rate"
+	UpstreamSLOLatencyRate = "slo_latency_rate"
+	UpstreamSLOBreached    = "slo_breached"
 )
 
 // NewHostStats returns a stats that namespace contains cluster and host address
@@ -853,6 +853,12 @@ func (sc *MServerConn) resetStream(se StreamError) error {
 	return nil
 }
 
+// GoAway signals a graceful shutdown to the client, streams already
+// accepted keep running until they finish
+func (sc *MServerConn) GoAway() {
+	sc.goAway(ErrCodeNo, []byte("graceful shutdown"))
+}
+
 func (sc *MServerConn) goAway(code ErrCode, debugData []byte) {
 	if sc.inGoAway {
 		return
@@ -872,12 +878,30 @@ type MClientConn struct {
 
 	slock sync.Mutex
 
+	streamRecvWindow uint32 // per-stream receive window advertised to the peer
+	connRecvWindow   uint32 // connection-level receive window target
+	nextPingID       uint64 // guarded by mu
+
 	Framer *MFramer
 	types.Connection
 }
 
+// ConnSettings overrides the stream limit and the flow control windows
+// advertised on a new http2 client connection, zero values keep the defaults
+type ConnSettings struct {
+	MaxConcurrentStreams    uint32
+	InitialStreamWindowSize uint32
+	InitialConnWindowSize   uint32
+}
+
 // NewClientConn return Http2 Client conncetion
 func NewClientConn(conn types.Connection) *MClientConn {
+	return NewClientConnWithSettings(conn, nil)
+}
+
+// NewClientConnWithSettings returns a Http2 Client connection with the
+// initial SETTINGS and window updates taken from opts
+func NewClientConnWithSettings(conn types.Connection, opts *ConnSettings) *MClientConn {
 	cc := new(MClientConn)
 	cc.Connection = conn
 
@@ -905,9 +929,32 @@ func NewClientConn(conn types.Connection) *MClientConn {
 	// henc in response to SETTINGS frames?
 	cc.henc = hpack.NewEncoder(&cc.hbuf)
 
+	cc.streamRecvWindow = transportDefaultStreamFlow
+	connWindowExtra := uint32(transportDefaultConnFlow)
+	if opts != nil {
+		if opts.MaxConcurrentStreams > 0 {
+			cc.ClientConn.maxConcurrentStreams = opts.MaxConcurrentStreams
+		}
+		if v := opts.InitialStreamWindowSize; v > 0 {
+			if v > math.MaxInt32 {
+				v = math.MaxInt32
+			}
+			cc.streamRecvWindow = v
+		}
+		// the connection starts with the spec-defined initialWindowSize,
+		// the configured total is reached via a window update
+		if v := opts.InitialConnWindowSize; v > initialWindowSize {
+			if v > math.MaxInt32 {
+				v = math.MaxInt32
+			}
+			connWindowExtra = v - initialWindowSize
+		}
+	}
+	cc.connRecvWindow = connWindowExtra
+
 	initialSettings := []Setting{
 		{ID: SettingEnablePush, Val: 0},
-		{ID: SettingInitialWindowSize, Val: transportDefaultStreamFlow},
+		{ID: SettingInitialWindowSize, Val: cc.streamRecvWindow},
 	}
 	if max := http.DefaultMaxHeaderBytes; max != 0 {
 		initialSettings = append(initialSettings, Setting{ID: SettingMaxHeaderListSize, Val: uint32(max)})
@@ -915,8 +962,8 @@ func NewClientConn(conn types.Connection) *MClientConn {
 
 	cc.Connection.Write(buffer.NewIoBufferBytes(clientPreface))
 	cc.Framer.writeSettings(initialSettings)
-	cc.Framer.writeWindowUpdate(0, transportDefaultConnFlow)
-	cc.inflow.add(transportDefaultConnFlow + initialWindowSize)
+	cc.Framer.writeWindowUpdate(0, cc.connRecvWindow)
+	cc.inflow.add(int32(cc.connRecvWindow) + initialWindowSize)
 
 	return cc
 }
@@ -1053,7 +1100,7 @@ func (cc *MClientConn) newStream() *clientStream {
 	}
 	cs.flow.add(int32(cc.initialWindowSize))
 	cs.flow.setConnFlow(&cc.flow)
-	cs.inflow.add(transportDefaultStreamFlow)
+	cs.inflow.add(int32(cc.streamRecvWindow))
 	cs.inflow.setConnFlow(&cc.inflow)
 	cc.streams[cs.ID] = cs
 	cc.nextStreamID += 2
@@ -1293,14 +1340,14 @@ func (cc *MClientConn) processData(ctx context.Context, f *DataFrame) (bool, err
 
 	var connAdd, streamAdd int32
 	// Check the conn-level first, before the stream-level.
-	if v := cc.inflow.available(); v < transportDefaultConnFlow/2 {
-		connAdd = transportDefaultConnFlow - v
+	if v := cc.inflow.available(); v < int32(cc.connRecvWindow)/2 {
+		connAdd = int32(cc.connRecvWindow) - v
 		cc.inflow.add(connAdd)
 	}
 
 	v := int(cs.inflow.available())
-	if v < transportDefaultStreamFlow-transportDefaultStreamMinRefresh {
-		streamAdd = int32(transportDefaultStreamFlow - v)
+	if v < int(cc.streamRecvWindow)-transportDefaultStreamMinRefresh {
+		streamAdd = int32(int(cc.streamRecvWindow) - v)
 		cs.inflow.add(streamAdd)
 	}
 	if connAdd != 0 || streamAdd != 0 {
@@ -1388,6 +1435,34 @@ func (cc *MClientConn) processWindowUpdate(f *WindowUpdateFrame) error {
 	return nil
 }
 
+// Ping sends a PING frame to the peer, the returned channel is closed when
+// the ack arrives
+func (cc *MClientConn) Ping() (<-chan struct{}, error) {
+	c := make(chan struct{})
+	var data [8]byte
+
+	cc.mu.Lock()
+	if cc.closed {
+		cc.mu.Unlock()
+		return nil, errClientConnClosed
+	}
+	cc.nextPingID++
+	binary.BigEndian.PutUint64(data[:], cc.nextPingID)
+	cc.pings[data] = c
+	cc.mu.Unlock()
+
+	buf := buffer.NewIoBuffer(frameHeaderLen + 8)
+	cc.Framer.startWrite(buf, FramePing, 0, 0)
+	cc.Framer.writeBytes(buf, data[:])
+	if err := cc.Framer.endWrite(buf); err != nil {
+		cc.mu.Lock()
+		delete(cc.pings, data)
+		cc.mu.Unlock()
+		return nil, err
+	}
+	return c, nil
+}
+
 // processPing processes Ping Frame for Http2 Client
 func (cc *MClientConn) processPing(f *PingFrame) error {
 	if f.IsAck() {
@@ -0,0 +1,167 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mosn
+
+import (
+	"sofastack.io/sofa-mosn/pkg/config"
+	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/runtime"
+)
+
+// NewMosn startup is split into init modules. A module declares the
+// modules it requires and whether the config enables it at all, the
+// runner initializes the enabled modules of a stage in dependency
+// order. Disabled modules are skipped entirely, so a minimal tcp proxy
+// deployment does not allocate subsystems it never uses.
+
+type initStage int
+
+const (
+	// stageBootstrap runs before the config may be reloaded for a
+	// graceful restart, only path and process level modules belong here
+	stageBootstrap initStage = iota
+	// stageService runs after the final config is known
+	stageService
+)
+
+type initModule struct {
+	name     string
+	stage    initStage
+	requires []string
+	// enabled reports whether the config needs this module,
+	// nil means always enabled
+	enabled func(c *config.MOSNConfig) bool
+	init    func(c *config.MOSNConfig)
+}
+
+// startupModules lists the init units of NewMosn
+func startupModules() []initModule {
+	return []initModule{
+		{
+			name:  "default-path",
+			stage: stageBootstrap,
+			init: func(c *config.MOSNConfig) {
+				initializeDefaultPath(config.GetConfigPath())
+			},
+		},
+		{
+			name:     "pid-file",
+			stage:    stageBootstrap,
+			requires: []string{"default-path"},
+			init: func(c *config.MOSNConfig) {
+				initializePidFile(c.Pid)
+			},
+		},
+		{
+			// tracing defaults to disabled, skipping the module means
+			// no tracer is allocated at all
+			name:  "tracing",
+			stage: stageBootstrap,
+			enabled: func(c *config.MOSNConfig) bool {
+				return c.Tracing.Enable && c.Tracing.Tracer != ""
+			},
+			init: func(c *config.MOSNConfig) {
+				initializeTracing(c.Tracing)
+			},
+		},
+		{
+			name:     "metrics",
+			stage:    stageService,
+			requires: []string{"default-path"},
+			init: func(c *config.MOSNConfig) {
+				initializeMetrics(c.Metrics)
+			},
+		},
+		{
+			name:     "flight-recorder",
+			stage:    stageService,
+			requires: []string{"default-path", "metrics"},
+			enabled: func(c *config.MOSNConfig) bool {
+				return c.FlightRecorder.Enable
+			},
+			init: func(c *config.MOSNConfig) {
+				initializeFlightRecorder(c.FlightRecorder)
+			},
+		},
+		{
+			name:  "listener-bind-policy",
+			stage: stageService,
+			enabled: func(c *config.MOSNConfig) bool {
+				return len(c.ListenerBindPolicy) > 0
+			},
+			init: func(c *config.MOSNConfig) {
+				initializeListenerBindPolicy(c.ListenerBindPolicy)
+			},
+		},
+		{
+			// static runtime layer, rtds layers pushed later override it
+			name:  "runtime",
+			stage: stageService,
+			init: func(c *config.MOSNConfig) {
+				runtime.ApplyStaticLayer(c.Runtime)
+			},
+		},
+	}
+}
+
+// runInitModules initializes the stage's enabled modules in dependency
+// order. A disabled or earlier-stage module satisfies its dependents
+// without running again.
+func runInitModules(c *config.MOSNConfig, stage initStage, modules []initModule) {
+	index := make(map[string]*initModule, len(modules))
+	for i := range modules {
+		index[modules[i].name] = &modules[i]
+	}
+	visited := make(map[string]bool, len(modules))
+	visiting := make(map[string]bool)
+	var run func(m *initModule)
+	run = func(m *initModule) {
+		if visited[m.name] {
+			return
+		}
+		if visiting[m.name] {
+			log.StartLogger.Fatalln("[mosn] [init module] dependency cycle on module: ", m.name)
+		}
+		visiting[m.name] = true
+		for _, name := range m.requires {
+			dep, ok := index[name]
+			if !ok {
+				log.StartLogger.Fatalln("[mosn] [init module] unknown module required: ", name)
+			}
+			if dep.stage > stage {
+				log.StartLogger.Fatalln("[mosn] [init module] module requires a later stage: ", name)
+			}
+			if dep.stage == stage {
+				run(dep)
+			}
+		}
+		delete(visiting, m.name)
+		visited[m.name] = true
+		if m.enabled != nil && !m.enabled(c) {
+			log.StartLogger.Infof("[mosn] [init module] %s is not needed, skip", m.name)
+			return
+		}
+		m.init(c)
+	}
+	for i := range modules {
+		if modules[i].stage != stage {
+			continue
+		}
+		run(&modules[i])
+	}
+}
@@ -0,0 +1,120 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mosn
+
+import (
+	"reflect"
+	"testing"
+
+	"sofastack.io/sofa-mosn/pkg/config"
+)
+
+func TestRunInitModulesOrder(t *testing.T) {
+	var order []string
+	record := func(name string) func(c *config.MOSNConfig) {
+		return func(c *config.MOSNConfig) {
+			order = append(order, name)
+		}
+	}
+	modules := []initModule{
+		{name: "c", stage: stageService, requires: []string{"b"}, init: record("c")},
+		{name: "a", stage: stageService, init: record("a")},
+		{name: "b", stage: stageService, requires: []string{"a"}, init: record("b")},
+	}
+	runInitModules(&config.MOSNConfig{}, stageService, modules)
+	if !reflect.DeepEqual(order, []string{"a", "b", "c"}) {
+		t.Errorf("modules should run in dependency order, got %v", order)
+	}
+}
+
+func TestRunInitModulesSkipDisabled(t *testing.T) {
+	var order []string
+	modules := []initModule{
+		{
+			name:  "disabled",
+			stage: stageService,
+			enabled: func(c *config.MOSNConfig) bool {
+				return false
+			},
+			init: func(c *config.MOSNConfig) {
+				order = append(order, "disabled")
+			},
+		},
+		{
+			name:     "dependent",
+			stage:    stageService,
+			requires: []string{"disabled"},
+			init: func(c *config.MOSNConfig) {
+				order = append(order, "dependent")
+			},
+		},
+	}
+	runInitModules(&config.MOSNConfig{}, stageService, modules)
+	if !reflect.DeepEqual(order, []string{"dependent"}) {
+		t.Errorf("disabled module should be skipped but satisfy dependents, got %v", order)
+	}
+}
+
+func TestRunInitModulesStageFilter(t *testing.T) {
+	var order []string
+	modules := []initModule{
+		{
+			name:  "bootstrap",
+			stage: stageBootstrap,
+			init: func(c *config.MOSNConfig) {
+				order = append(order, "bootstrap")
+			},
+		},
+		{
+			name:     "service",
+			stage:    stageService,
+			requires: []string{"bootstrap"},
+			init: func(c *config.MOSNConfig) {
+				order = append(order, "service")
+			},
+		},
+	}
+	runInitModules(&config.MOSNConfig{}, stageBootstrap, modules)
+	if !reflect.DeepEqual(order, []string{"bootstrap"}) {
+		t.Errorf("only bootstrap modules should run in the bootstrap stage, got %v", order)
+	}
+	runInitModules(&config.MOSNConfig{}, stageService, modules)
+	if !reflect.DeepEqual(order, []string{"bootstrap", "service"}) {
+		t.Errorf("earlier stage dependencies should be treated as satisfied, got %v", order)
+	}
+}
+
+func TestStartupModulesResolve(t *testing.T) {
+	modules := startupModules()
+	index := make(map[string]initModule, len(modules))
+	for _, m := range modules {
+		index[m.name] = m
+	}
+	for _, m := range modules {
+		for _, name := range m.requires {
+			dep, ok := index[name]
+			if !ok {
+				t.Errorf("module %s requires unknown module %s", m.name, name)
+				continue
+			}
+			if dep.stage > m.stage {
+				t.Errorf("module %s requires later stage module %s", m.name, name)
+			}
+		}
+	}
+}
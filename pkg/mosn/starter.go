@@ -18,19 +18,23 @@
 package mosn
 
 import (
+	"os"
 	"sync"
+	"time"
 
 	admin "sofastack.io/sofa-mosn/pkg/admin/server"
 	"sofastack.io/sofa-mosn/pkg/admin/store"
 	v2 "sofastack.io/sofa-mosn/pkg/api/v2"
 	"sofastack.io/sofa-mosn/pkg/config"
 	_ "sofastack.io/sofa-mosn/pkg/filter/network/connectionmanager"
+	"sofastack.io/sofa-mosn/pkg/flightrecorder"
 	"sofastack.io/sofa-mosn/pkg/log"
 	"sofastack.io/sofa-mosn/pkg/metrics"
 	"sofastack.io/sofa-mosn/pkg/metrics/shm"
 	"sofastack.io/sofa-mosn/pkg/metrics/sink"
 	"sofastack.io/sofa-mosn/pkg/network"
 	"sofastack.io/sofa-mosn/pkg/router"
+	"sofastack.io/sofa-mosn/pkg/sandbox"
 	"sofastack.io/sofa-mosn/pkg/server"
 	"sofastack.io/sofa-mosn/pkg/server/keeper"
 	"sofastack.io/sofa-mosn/pkg/trace"
@@ -52,15 +56,15 @@ type Mosn struct {
 // NewMosn
 // Create server from mosn config
 func NewMosn(c *config.MOSNConfig) *Mosn {
-	initializeDefaultPath(config.GetConfigPath())
-	initializePidFile(c.Pid)
-	initializeTracing(c.Tracing)
+	runInitModules(c, stageBootstrap, startupModules())
 
 	//get inherit fds
 	inheritListeners, reconfigure, err := server.GetInheritListeners()
 	if err != nil {
 		log.StartLogger.Fatalln("[mosn] [NewMosn] getInheritListeners failed, exit")
 	}
+	// get listeners from socket activation (systemd LISTEN_FDS or configured fds)
+	inheritListeners = append(inheritListeners, server.GetActivatedListeners(c.InheritFds)...)
 	if reconfigure != nil {
 		log.StartLogger.Infof("[mosn] [NewMosn] active reconfiguring")
 		// set Mosn Active_Reconfiguring
@@ -75,7 +79,7 @@ func NewMosn(c *config.MOSNConfig) *Mosn {
 		}
 	}
 
-	initializeMetrics(c.Metrics)
+	runInitModules(c, stageService, startupModules())
 
 	m := &Mosn{
 		config: c,
@@ -110,6 +114,13 @@ func NewMosn(c *config.MOSNConfig) *Mosn {
 	//cluster manager filter
 	cmf := &clusterManagerFilter{}
 
+	// optional nat64 synthesis for ipv6-only nodes
+	if prefix := c.ClusterManager.NAT64Prefix; prefix != "" {
+		if err := network.SetNAT64Prefix(prefix); err != nil {
+			log.StartLogger.Fatalln("[mosn] [NewMosn] invalid nat64 prefix: ", err)
+		}
+	}
+
 	// parse cluster all in one
 	clusters, clusterMap := config.ParseClusterConfig(c.ClusterManager.Clusters)
 	// create cluster manager
@@ -146,7 +157,8 @@ func NewMosn(c *config.MOSNConfig) *Mosn {
 			}
 
 			for idx, _ := range serverConfig.Listeners {
-				// parse ListenerConfig
+				// inherit template defaults, then parse ListenerConfig
+				config.ApplyListenerDefaults(&serverConfig.Listeners[idx], serverConfig.ListenerDefaults)
 				lc := config.ParseListenerConfig(&serverConfig.Listeners[idx], inheritListeners)
 				lc.DisableConnIo = config.GetListenerDisableIO(&lc.FilterChains[0])
 
@@ -184,6 +196,7 @@ func NewMosn(c *config.MOSNConfig) *Mosn {
 
 	// SetTransferTimeout
 	network.SetTransferTimeout(server.GracefulTimeout)
+	network.SetDrainTimeout(server.GracefulTimeout)
 
 	if store.GetMosnState() == store.Active_Reconfiguring {
 		// start other services
@@ -202,6 +215,8 @@ func NewMosn(c *config.MOSNConfig) *Mosn {
 		utils.GoWithRecover(func() {
 			network.TransferServer(m.servers[0].Handler())
 		}, nil)
+		// receive idle upstream connections from the old mosn
+		utils.GoWithRecover(network.UpstreamTransferServer, nil)
 	} else {
 		// start other services
 		if err := store.StartService(nil); err != nil {
@@ -218,6 +233,9 @@ func NewMosn(c *config.MOSNConfig) *Mosn {
 		}
 	}
 
+	// drop privileges once listeners are bound and certs are loaded
+	initializeSandbox(c.Sandbox)
+
 	// start dump config process
 	utils.GoWithRecover(func() {
 		config.DumpConfigHandler()
@@ -228,6 +246,11 @@ func NewMosn(c *config.MOSNConfig) *Mosn {
 		server.ReconfigureHandler()
 	}, nil)
 
+	// start socket transfer domain socket
+	utils.GoWithRecover(func() {
+		server.SocketTransferHandler()
+	}, nil)
+
 	return m
 }
 
@@ -249,6 +272,9 @@ func (m *Mosn) Close() {
 	// stop reconfigure domain socket
 	server.StopReconfigureHandler()
 
+	// stop socket transfer domain socket
+	server.StopSocketTransferHandler()
+
 	// stop mosn server
 	for _, srv := range m.servers {
 		srv.Close()
@@ -268,16 +294,33 @@ func Start(c *config.MOSNConfig, serviceCluster string, serviceNode string) {
 	Mosn := NewMosn(c)
 	Mosn.Start()
 	////get xds config
-	xdsClient := xds.Client{}
-	xdsClient.Start(c, serviceCluster, serviceNode)
+	// no xds resources configured means no client at all, a static
+	// deployment does not pay for the xds goroutines
+	var xdsClient *xds.Client
+	if len(c.RawDynamicResources) > 0 || len(c.RawStaticResources) > 0 {
+		xdsClient = &xds.Client{}
+		xdsClient.Start(c, serviceCluster, serviceNode)
+	} else {
+		log.StartLogger.Infof("[mosn] [start] no xds config found, xds client is not created")
+	}
 	//
 	////todo: daemon running
 	wg.Wait()
-	xdsClient.Stop()
+	if xdsClient != nil {
+		xdsClient.Stop()
+	}
 }
 
 func initializeTracing(config config.TracingConfig) {
 	if config.Enable && config.Tracer != "" {
+		if config.SampleRate != nil {
+			trace.SetSampleRate(*config.SampleRate)
+		}
+		if config.CollectorEndpoint != "" {
+			trace.SetZipkinCollectorEndpoint(config.CollectorEndpoint)
+			trace.SetSkyWalkingBackendEndpoint(config.CollectorEndpoint)
+			trace.SetJaegerEndpoint(config.CollectorEndpoint)
+		}
 		tracer := trace.CreateTracer(config.Tracer)
 		if tracer != nil {
 			trace.SetTracer(tracer)
@@ -315,6 +358,62 @@ func initializeMetrics(config config.MetricsConfig) {
 	}
 }
 
+func initializeFlightRecorder(config config.FlightRecorderConfig) {
+	if !config.Enable {
+		return
+	}
+	path := config.Path
+	if path == "" {
+		path = types.MosnLogBasePath + string(os.PathSeparator) + "flight.record"
+	}
+	err := flightrecorder.Enable(flightrecorder.Config{
+		Path:           path,
+		SampleInterval: time.Duration(config.SampleIntervalMs) * time.Millisecond,
+		MaxFileSize:    int64(config.MaxFileSizeMb) << 20,
+		MaxFiles:       config.MaxFiles,
+	})
+	if err != nil {
+		log.StartLogger.Errorf("[mosn] [init flight recorder] %v, flight recorder is turned off", err)
+		return
+	}
+	log.StartLogger.Infof("[mosn] [init flight recorder] recording to %s", path)
+}
+
+func initializeListenerBindPolicy(rules []config.BindPolicyRuleConfig) {
+	if len(rules) == 0 {
+		return
+	}
+	policyRules := make([]server.BindPolicyRule, 0, len(rules))
+	for _, rule := range rules {
+		policyRules = append(policyRules, server.BindPolicyRule{
+			Action: rule.Action,
+			Cidr:   rule.Cidr,
+			Ports:  rule.Ports,
+		})
+	}
+	if err := server.SetListenerBindPolicy(policyRules); err != nil {
+		log.StartLogger.Fatalln("[mosn] [NewMosn] invalid listener bind policy: ", err)
+	}
+}
+
+func initializeSandbox(cfg config.SandboxConfig) {
+	if !cfg.Enable {
+		return
+	}
+	// the log base and config dump paths must stay writable for the
+	// process to keep functioning
+	allowed := append([]string{}, cfg.AllowedWritePaths...)
+	allowed = append(allowed, types.MosnLogBasePath, config.GetConfigPath())
+	report, err := sandbox.Apply(sandbox.Config{
+		KeepCapabilities:  cfg.KeepCapabilities,
+		AllowedWritePaths: allowed,
+	})
+	if err != nil {
+		log.StartLogger.Fatalln("[mosn] [sandbox] invalid sandbox config: ", err)
+	}
+	log.StartLogger.Infof("[mosn] [sandbox] enabled: %s", report.String())
+}
+
 func initializePidFile(pid string) {
 	keeper.SetPid(pid)
 }
@@ -0,0 +1,152 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mosn
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+	"sofastack.io/sofa-mosn/pkg/config"
+	"sofastack.io/sofa-mosn/pkg/filter"
+	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/mtls"
+	"sofastack.io/sofa-mosn/pkg/network"
+	"sofastack.io/sofa-mosn/pkg/router"
+)
+
+// ValidateConfig builds the cluster, listener, filter, route and tls
+// pieces of a config in dry run without opening any socket and returns
+// all errors found, used by the validate command for pre-deployment
+// gating of config changes
+func ValidateConfig(c *config.MOSNConfig) []error {
+	var errs []error
+	if prefix := c.ClusterManager.NAT64Prefix; prefix != "" {
+		if _, err := network.ParseNAT64Prefix(prefix); err != nil {
+			errs = append(errs, fmt.Errorf("cluster manager: nat64 prefix is not valid: %v", err))
+		}
+	}
+	errs = append(errs, validateClusters(c.ClusterManager.Clusters)...)
+	if len(c.Servers) == 0 {
+		errs = append(errs, fmt.Errorf("no server config found"))
+	}
+	for _, server := range c.Servers {
+		for idx := range server.Listeners {
+			errs = append(errs, validateListener(&server.Listeners[idx])...)
+		}
+	}
+	return errs
+}
+
+func validateClusters(clusters []v2.Cluster) []error {
+	var errs []error
+	names := make(map[string]bool, len(clusters))
+	for idx := range clusters {
+		cluster := &clusters[idx]
+		if cluster.Name == "" {
+			errs = append(errs, fmt.Errorf("cluster[%d]: name is required", idx))
+			continue
+		}
+		if names[cluster.Name] {
+			errs = append(errs, fmt.Errorf("cluster %s: duplicated cluster name", cluster.Name))
+		}
+		names[cluster.Name] = true
+		for _, host := range cluster.Hosts {
+			if _, err := network.ParseTCPAddress(host.Address); err != nil {
+				errs = append(errs, fmt.Errorf("cluster %s: host address %s is not valid: %v", cluster.Name, host.Address, err))
+			}
+		}
+		if cluster.TLS.Status {
+			if _, err := mtls.NewTLSClientContextManager(&cluster.TLS, nil); err != nil {
+				errs = append(errs, fmt.Errorf("cluster %s: tls context is not valid: %v", cluster.Name, err))
+			}
+		}
+	}
+	return errs
+}
+
+func validateListener(lc *v2.Listener) []error {
+	var errs []error
+	name := lc.Name
+	if name == "" {
+		name = lc.AddrConfig
+	}
+	if lc.AddrConfig == "" {
+		errs = append(errs, fmt.Errorf("listener %s: address is required", name))
+	} else if _, err := network.ParseTCPAddress(lc.AddrConfig); err != nil {
+		errs = append(errs, fmt.Errorf("listener %s: address %s is not valid: %v", name, lc.AddrConfig, err))
+	}
+	// server side tls contexts
+	if _, err := mtls.NewTLSServerContextManager(lc, nil, log.DefaultLogger); err != nil {
+		errs = append(errs, fmt.Errorf("listener %s: tls context is not valid: %v", name, err))
+	}
+	for _, chain := range lc.FilterChains {
+		for _, f := range chain.Filters {
+			errs = append(errs, validateNetworkFilter(name, f)...)
+		}
+		// routes configured on the connection manager
+		routerConfig := config.ParseRouterConfiguration(&chain)
+		if routerConfig.RouterConfigName != "" {
+			if _, err := router.NewRouters(routerConfig); err != nil {
+				errs = append(errs, fmt.Errorf("listener %s: router config %s is not valid: %v", name, routerConfig.RouterConfigName, err))
+			}
+		}
+	}
+	for _, f := range lc.StreamFilters {
+		if _, err := filter.CreateStreamFilterChainFactory(f.Type, f.Config); err != nil {
+			errs = append(errs, fmt.Errorf("listener %s: stream filter %s is not valid: %v", name, f.Type, err))
+		}
+	}
+	return errs
+}
+
+// validateNetworkFilter validates a network filter config, the proxy and
+// connection manager filters are checked directly since their parsers
+// exit the process on error
+func validateNetworkFilter(listener string, f v2.Filter) []error {
+	var errs []error
+	switch f.Type {
+	case v2.DEFAULT_NETWORK_FILTER:
+		proxyConfig := &v2.Proxy{}
+		data, err := json.Marshal(f.Config)
+		if err == nil {
+			err = json.Unmarshal(data, proxyConfig)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("listener %s: proxy filter config is not valid: %v", listener, err))
+			break
+		}
+		if proxyConfig.DownstreamProtocol == "" || proxyConfig.UpstreamProtocol == "" {
+			errs = append(errs, fmt.Errorf("listener %s: proxy filter needs downstream and upstream protocol", listener))
+		} else {
+			if !config.ProtocolSupported(proxyConfig.DownstreamProtocol) {
+				errs = append(errs, fmt.Errorf("listener %s: proxy downstream protocol %s is not supported", listener, proxyConfig.DownstreamProtocol))
+			}
+			if !config.ProtocolSupported(proxyConfig.UpstreamProtocol) {
+				errs = append(errs, fmt.Errorf("listener %s: proxy upstream protocol %s is not supported", listener, proxyConfig.UpstreamProtocol))
+			}
+		}
+	case v2.CONNECTION_MANAGER:
+		// validated with the filter chain's router configuration
+	default:
+		if _, err := filter.CreateNetworkFilterChainFactory(f.Type, f.Config); err != nil {
+			errs = append(errs, fmt.Errorf("listener %s: network filter %s is not valid: %v", listener, f.Type, err))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,160 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mtls
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	gometrics "github.com/rcrowley/go-metrics"
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/metrics"
+	"sofastack.io/sofa-mosn/pkg/utils"
+)
+
+// WatchInterval is the period certificate files are checked for changes
+var WatchInterval = 10 * time.Second
+
+// certWatcher rebuilds tls contexts when certificate, key or ca files
+// rotate on disk (e.g. cert-manager rotation), without a restart
+type certWatcher struct {
+	mux     sync.Mutex
+	entries map[string]*watchEntry
+	started bool
+	success gometrics.Counter
+	failed  gometrics.Counter
+}
+
+type watchEntry struct {
+	files  map[string]time.Time // path -> last seen modification time
+	reload func() error
+}
+
+var watcher = &certWatcher{entries: make(map[string]*watchEntry)}
+
+// certificateFiles returns the cert/key/ca entries of the configs that are
+// files on disk, inline pem strings cannot rotate and are not watched
+func certificateFiles(configs []*v2.TLSConfig) []string {
+	var files []string
+	for _, c := range configs {
+		if c == nil || !c.Status {
+			continue
+		}
+		for _, path := range []string{c.CertChain, c.PrivateKey, c.CACert, c.OCSPStaple} {
+			if path == "" || strings.Contains(path, "-----BEGIN") {
+				continue
+			}
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// WatchCertificates registers the certificate files of the configs for hot
+// reload, reload rebuilds the tls contexts and is called when any of the
+// files changes on disk. Registering the same key replaces the former entry
+func WatchCertificates(key string, configs []*v2.TLSConfig, reload func() error) {
+	files := certificateFiles(configs)
+	w := watcher
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	if len(files) == 0 {
+		delete(w.entries, key)
+		return
+	}
+	entry := &watchEntry{
+		files:  make(map[string]time.Time, len(files)),
+		reload: reload,
+	}
+	for _, f := range files {
+		if info, err := os.Stat(f); err == nil {
+			entry.files[f] = info.ModTime()
+		} else {
+			entry.files[f] = time.Time{}
+		}
+	}
+	w.entries[key] = entry
+	w.startLocked()
+}
+
+func (w *certWatcher) startLocked() {
+	if w.started {
+		return
+	}
+	w.started = true
+	if m, err := metrics.NewMetrics("mtls", map[string]string{"component": "certwatcher"}); err == nil {
+		w.success = m.Counter("reload_success")
+		w.failed = m.Counter("reload_failed")
+	}
+	utils.GoWithRecover(w.loop, func(r interface{}) {
+		utils.GoWithRecover(w.loop, nil)
+	})
+}
+
+func (w *certWatcher) loop() {
+	for {
+		time.Sleep(WatchInterval)
+		w.checkOnce()
+	}
+}
+
+func (w *certWatcher) checkOnce() {
+	type pending struct {
+		key    string
+		reload func() error
+	}
+	var reloads []pending
+
+	w.mux.Lock()
+	for key, e := range w.entries {
+		changed := false
+		for f, mod := range e.files {
+			info, err := os.Stat(f)
+			if err != nil {
+				// rotation may replace files non-atomically, wait for
+				// the file to reappear instead of reloading a half state
+				continue
+			}
+			if !info.ModTime().Equal(mod) {
+				e.files[f] = info.ModTime()
+				changed = true
+			}
+		}
+		if changed {
+			reloads = append(reloads, pending{key: key, reload: e.reload})
+		}
+	}
+	w.mux.Unlock()
+
+	for _, p := range reloads {
+		if err := p.reload(); err != nil {
+			if w.failed != nil {
+				w.failed.Inc(1)
+			}
+			log.DefaultLogger.Errorf("[mtls] [cert watcher] reload tls context %s failed: %v", p.key, err)
+			continue
+		}
+		if w.success != nil {
+			w.success.Inc(1)
+		}
+		log.DefaultLogger.Infof("[mtls] [cert watcher] certificate changed, tls context %s reloaded", p.key)
+	}
+}
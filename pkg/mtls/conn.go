@@ -42,40 +42,42 @@ type TLSConn struct {
 // It implements the net.Conn interface.
 type Conn struct {
 	net.Conn
-	peek    [1]byte
-	haspeek bool
+	peeked []byte
 }
 
-// Peek returns 1 byte from connection, without draining any buffered data.
-func (c *Conn) Peek() []byte {
-	b := make([]byte, 1, 1)
-	n, err := c.Conn.Read(b)
-	if n == 0 {
+// Peek returns up to n bytes from the connection without draining any
+// buffered data. Less than n bytes are returned when the peer's first
+// segment is shorter, nil when nothing could be read at all.
+func (c *Conn) Peek(n int) []byte {
+	if len(c.peeked) >= n {
+		return c.peeked[:n]
+	}
+	b := make([]byte, n-len(c.peeked))
+	rn, err := c.Conn.Read(b)
+	if rn > 0 {
+		c.peeked = append(c.peeked, b[:rn]...)
+	}
+	if len(c.peeked) == 0 {
 		if log.DefaultLogger.GetLogLevel() >= log.INFO {
 			log.DefaultLogger.Infof("[mtls] TLS Peek() error: %v", err)
 		}
 		return nil
 	}
-	c.peek[0] = b[0]
-	c.haspeek = true
-	return b
+	return c.peeked
 }
 
-// Read reads data from the connection.
+// Read reads data from the connection, peeked bytes are replayed first.
 func (c *Conn) Read(b []byte) (int, error) {
-	peek := 0
-	if c.haspeek {
-		c.haspeek = false
-		b[0] = c.peek[0]
-		if len(b) == 1 {
-			return 1, nil
+	if len(c.peeked) > 0 {
+		n := copy(b, c.peeked)
+		c.peeked = c.peeked[n:]
+		if len(c.peeked) == 0 {
+			c.peeked = nil
 		}
-		peek = 1
-		b = b[peek:]
+		return n, nil
 	}
 
-	n, err := c.Conn.Read(b)
-	return n + peek, err
+	return c.Conn.Read(b)
 }
 
 // ConnectionState records basic TLS details about the connection.
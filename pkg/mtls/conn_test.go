@@ -0,0 +1,82 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mtls
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestConnPeekReplaysBytes(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+
+	payload := []byte("GET / HTTP/1.1\r\n\r\n")
+	go func() {
+		client.Write(payload)
+		client.Close()
+	}()
+
+	conn := &Conn{Conn: server}
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	buf := conn.Peek(tlsInspectLen)
+	if buf == nil {
+		t.Fatal("peek returned nothing")
+	}
+	if !bytes.Equal(buf, payload[:len(buf)]) {
+		t.Fatalf("peek returned unexpected bytes: %q", buf)
+	}
+
+	// the peeked bytes must be replayed on read
+	data, err := ioutil.ReadAll(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, payload) {
+		t.Errorf("read after peek lost data: %q", data)
+	}
+}
+
+func TestIsTLSClientHello(t *testing.T) {
+	testCases := []struct {
+		buf  []byte
+		want bool
+	}{
+		// record header + ClientHello handshake type
+		{[]byte{0x16, 0x03, 0x01, 0x00, 0xc8, 0x01}, true},
+		// short first segment, record header decides
+		{[]byte{0x16, 0x03, 0x03}, true},
+		{[]byte{0x16}, true},
+		// handshake record carrying something else than ClientHello
+		{[]byte{0x16, 0x03, 0x01, 0x00, 0xc8, 0x02}, false},
+		// bogus record version
+		{[]byte{0x16, 0x04, 0x00, 0x00, 0xc8, 0x01}, false},
+		// plaintext http
+		{[]byte("GET / "), false},
+		// bolt protocol code
+		{[]byte{0x01, 0x00, 0x00, 0x00, 0x00, 0x00}, false},
+	}
+	for i, tc := range testCases {
+		if got := isTLSClientHello(tc.buf); got != tc.want {
+			t.Errorf("#%d isTLSClientHello(%v) = %v, want %v", i, tc.buf, got, tc.want)
+		}
+	}
+}
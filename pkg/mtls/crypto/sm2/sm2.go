@@ -0,0 +1,190 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package sm2 implements the SM2 digital signature algorithm over the
+// sm2p256v1 curve as defined in GB/T 32918 (Chinese national standard).
+// Keys are plain ecdsa keys on the P256Sm2 curve, only the signature
+// scheme differs from ecdsa
+package sm2
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"encoding/asn1"
+	"errors"
+	"io"
+	"math/big"
+	"sync"
+
+	"sofastack.io/sofa-mosn/pkg/mtls/crypto/sm3"
+)
+
+// DefaultUID is the user identity used in the ZA digest when the peers
+// have not agreed on one, as specified in GM/T 0009
+var DefaultUID = []byte("1234567812345678")
+
+var (
+	initonce sync.Once
+	sm2Curve *elliptic.CurveParams
+)
+
+// P256Sm2 returns the sm2p256v1 curve. The curve has a = p-3, so the
+// generic elliptic.CurveParams arithmetic applies
+func P256Sm2() elliptic.Curve {
+	initonce.Do(func() {
+		sm2Curve = &elliptic.CurveParams{Name: "sm2p256v1"}
+		sm2Curve.P, _ = new(big.Int).SetString("FFFFFFFEFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF00000000FFFFFFFFFFFFFFFF", 16)
+		sm2Curve.N, _ = new(big.Int).SetString("FFFFFFFEFFFFFFFFFFFFFFFFFFFFFFFF7203DF6B21C6052B53BBF40939D54123", 16)
+		sm2Curve.B, _ = new(big.Int).SetString("28E9FA9E9D9F5E344D5A9E4BCF6509A7F39789F515AB8F92DDBCBD414D940E93", 16)
+		sm2Curve.Gx, _ = new(big.Int).SetString("32C4AE2C1F1981195F9904466A39C9948FE30BBFF2660BE1715A4589334C74C7", 16)
+		sm2Curve.Gy, _ = new(big.Int).SetString("BC3736A2F4F6779C59BDCEE36B692153D0A9877CC62A474002DF32E52139F0A0", 16)
+		sm2Curve.BitSize = 256
+	})
+	return sm2Curve
+}
+
+// GenerateKey generates an sm2 key pair
+func GenerateKey(rand io.Reader) (*ecdsa.PrivateKey, error) {
+	return ecdsa.GenerateKey(P256Sm2(), rand)
+}
+
+type sm2Signature struct {
+	R, S *big.Int
+}
+
+// za computes the user hash Z_A = SM3(ENTL || uid || a || b || Gx || Gy || Px || Py)
+func za(pub *ecdsa.PublicKey, uid []byte) []byte {
+	params := pub.Curve.Params()
+	byteLen := (params.BitSize + 7) / 8
+	pad := func(v *big.Int) []byte {
+		b := v.Bytes()
+		out := make([]byte, byteLen)
+		copy(out[byteLen-len(b):], b)
+		return out
+	}
+	entl := uint16(len(uid) * 8)
+	// a = p - 3 for sm2p256v1, elliptic.CurveParams leaves it implicit
+	a := new(big.Int).Sub(params.P, big.NewInt(3))
+
+	h := sm3.New()
+	h.Write([]byte{byte(entl >> 8), byte(entl)})
+	h.Write(uid)
+	h.Write(pad(a))
+	h.Write(pad(params.B))
+	h.Write(pad(params.Gx))
+	h.Write(pad(params.Gy))
+	h.Write(pad(pub.X))
+	h.Write(pad(pub.Y))
+	return h.Sum(nil)
+}
+
+// digest computes e = SM3(ZA || msg) as a big integer
+func digest(pub *ecdsa.PublicKey, uid, msg []byte) *big.Int {
+	h := sm3.New()
+	h.Write(za(pub, uid))
+	h.Write(msg)
+	return new(big.Int).SetBytes(h.Sum(nil))
+}
+
+// Sign signs msg with the sm2 signature scheme and returns the asn.1
+// encoded signature. An empty uid falls back to DefaultUID
+func Sign(rand io.Reader, priv *ecdsa.PrivateKey, uid, msg []byte) ([]byte, error) {
+	if len(uid) == 0 {
+		uid = DefaultUID
+	}
+	curve := priv.Curve
+	n := curve.Params().N
+	e := digest(&priv.PublicKey, uid, msg)
+
+	var r, s *big.Int
+	for {
+		k, err := randFieldElement(curve, rand)
+		if err != nil {
+			return nil, err
+		}
+		x1, _ := curve.ScalarBaseMult(k.Bytes())
+
+		r = new(big.Int).Add(e, x1)
+		r.Mod(r, n)
+		if r.Sign() == 0 {
+			continue
+		}
+		if t := new(big.Int).Add(r, k); t.Cmp(n) == 0 {
+			continue
+		}
+		// s = (1 + d)^-1 * (k - r*d) mod n
+		dInv := new(big.Int).Add(priv.D, big.NewInt(1))
+		dInv.ModInverse(dInv, n)
+		s = new(big.Int).Mul(r, priv.D)
+		s.Sub(k, s)
+		s.Mul(s, dInv)
+		s.Mod(s, n)
+		if s.Sign() != 0 {
+			break
+		}
+	}
+	return asn1.Marshal(sm2Signature{R: r, S: s})
+}
+
+// Verify checks an asn.1 encoded sm2 signature of msg. An empty uid falls
+// back to DefaultUID
+func Verify(pub *ecdsa.PublicKey, uid, msg, sig []byte) error {
+	if len(uid) == 0 {
+		uid = DefaultUID
+	}
+	var parsed sm2Signature
+	if rest, err := asn1.Unmarshal(sig, &parsed); err != nil {
+		return err
+	} else if len(rest) != 0 {
+		return errors.New("sm2: trailing bytes after signature")
+	}
+	curve := pub.Curve
+	n := curve.Params().N
+	r, s := parsed.R, parsed.S
+	if r.Sign() <= 0 || s.Sign() <= 0 || r.Cmp(n) >= 0 || s.Cmp(n) >= 0 {
+		return errors.New("sm2: signature values out of range")
+	}
+	t := new(big.Int).Add(r, s)
+	t.Mod(t, n)
+	if t.Sign() == 0 {
+		return errors.New("sm2: invalid signature")
+	}
+
+	e := digest(pub, uid, msg)
+	x1, y1 := curve.ScalarBaseMult(s.Bytes())
+	x2, y2 := curve.ScalarMult(pub.X, pub.Y, t.Bytes())
+	x, _ := curve.Add(x1, y1, x2, y2)
+
+	expected := new(big.Int).Add(e, x)
+	expected.Mod(expected, n)
+	if expected.Cmp(r) != 0 {
+		return errors.New("sm2: signature verification failed")
+	}
+	return nil
+}
+
+// randFieldElement returns a random element of [1, n-1]
+func randFieldElement(curve elliptic.Curve, rand io.Reader) (*big.Int, error) {
+	n := curve.Params().N
+	b := make([]byte, (n.BitLen()+7)/8+8) // extra bytes to reduce bias
+	if _, err := io.ReadFull(rand, b); err != nil {
+		return nil, err
+	}
+	k := new(big.Int).SetBytes(b)
+	k.Mod(k, new(big.Int).Sub(n, big.NewInt(1)))
+	return k.Add(k, big.NewInt(1)), nil
+}
@@ -0,0 +1,63 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sm2
+
+import (
+	"crypto/rand"
+	"testing"
+)
+
+func TestCurveOnCurve(t *testing.T) {
+	curve := P256Sm2()
+	if !curve.IsOnCurve(curve.Params().Gx, curve.Params().Gy) {
+		t.Fatalf("base point is not on the curve, curve parameters are wrong")
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("gm tls server key exchange")
+	sig, err := Sign(rand.Reader, priv, nil, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(&priv.PublicKey, nil, msg, sig); err != nil {
+		t.Errorf("verify failed: %v", err)
+	}
+	if err := Verify(&priv.PublicKey, nil, []byte("other message"), sig); err == nil {
+		t.Errorf("verify of a different message should fail")
+	}
+	if err := Verify(&priv.PublicKey, []byte("other-uid-value1"), msg, sig); err == nil {
+		t.Errorf("verify with a different uid should fail")
+	}
+
+	other, _ := GenerateKey(rand.Reader)
+	if err := Verify(&other.PublicKey, nil, msg, sig); err == nil {
+		t.Errorf("verify with a different key should fail")
+	}
+}
+
+func TestVerifyMalformed(t *testing.T) {
+	priv, _ := GenerateKey(rand.Reader)
+	if err := Verify(&priv.PublicKey, nil, []byte("msg"), []byte("not asn1")); err == nil {
+		t.Errorf("malformed signature should fail")
+	}
+}
@@ -0,0 +1,168 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package sm3 implements the SM3 cryptographic hash function defined in
+// GB/T 32905-2016 (Chinese national standard), used by the GM TLS cipher
+// suites in the bundled crypto/tls fork
+package sm3
+
+import (
+	"encoding/binary"
+	"hash"
+)
+
+// Size is the size of an SM3 checksum in bytes
+const Size = 32
+
+// BlockSize is the block size of SM3 in bytes
+const BlockSize = 64
+
+type digest struct {
+	h   [8]uint32
+	x   [BlockSize]byte
+	nx  int
+	len uint64
+}
+
+// New returns a new hash.Hash computing the SM3 checksum
+func New() hash.Hash {
+	d := new(digest)
+	d.Reset()
+	return d
+}
+
+// Sum returns the SM3 checksum of the data
+func Sum(data []byte) [Size]byte {
+	d := new(digest)
+	d.Reset()
+	d.Write(data)
+	var out [Size]byte
+	copy(out[:], d.checkSum())
+	return out
+}
+
+func (d *digest) Reset() {
+	d.h = [8]uint32{
+		0x7380166f, 0x4914b2b9, 0x172442d7, 0xda8a0600,
+		0xa96f30bc, 0x163138aa, 0xe38dee4d, 0xb0fb0e4e,
+	}
+	d.nx = 0
+	d.len = 0
+}
+
+func (d *digest) Size() int { return Size }
+
+func (d *digest) BlockSize() int { return BlockSize }
+
+func (d *digest) Write(p []byte) (n int, err error) {
+	n = len(p)
+	d.len += uint64(n)
+	if d.nx > 0 {
+		c := copy(d.x[d.nx:], p)
+		d.nx += c
+		if d.nx == BlockSize {
+			d.block(d.x[:])
+			d.nx = 0
+		}
+		p = p[c:]
+	}
+	if len(p) >= BlockSize {
+		n := len(p) &^ (BlockSize - 1)
+		d.block(p[:n])
+		p = p[n:]
+	}
+	if len(p) > 0 {
+		d.nx = copy(d.x[:], p)
+	}
+	return
+}
+
+func (d *digest) Sum(in []byte) []byte {
+	// make a copy so the caller can keep writing
+	d0 := *d
+	return append(in, d0.checkSum()...)
+}
+
+func (d *digest) checkSum() []byte {
+	length := d.len << 3 // length in bits
+	var pad [BlockSize + 8]byte
+	pad[0] = 0x80
+	padLen := BlockSize - int(d.len+8)%BlockSize
+	binary.BigEndian.PutUint64(pad[padLen:], length)
+	d.Write(pad[:padLen+8])
+
+	var out [Size]byte
+	for i, v := range d.h {
+		binary.BigEndian.PutUint32(out[i*4:], v)
+	}
+	return out[:]
+}
+
+func rotl(x uint32, n uint) uint32 { return x<<n | x>>(32-n) }
+
+func p0(x uint32) uint32 { return x ^ rotl(x, 9) ^ rotl(x, 17) }
+
+func p1(x uint32) uint32 { return x ^ rotl(x, 15) ^ rotl(x, 23) }
+
+func (d *digest) block(p []byte) {
+	var w [68]uint32
+	a, b, c, dd, e, f, g, h := d.h[0], d.h[1], d.h[2], d.h[3], d.h[4], d.h[5], d.h[6], d.h[7]
+	for len(p) >= BlockSize {
+		for i := 0; i < 16; i++ {
+			w[i] = binary.BigEndian.Uint32(p[i*4:])
+		}
+		for i := 16; i < 68; i++ {
+			w[i] = p1(w[i-16]^w[i-9]^rotl(w[i-3], 15)) ^ rotl(w[i-13], 7) ^ w[i-6]
+		}
+
+		A, B, C, D, E, F, G, H := a, b, c, dd, e, f, g, h
+		for j := 0; j < 64; j++ {
+			var t, ff, gg uint32
+			if j < 16 {
+				t = 0x79cc4519
+				ff = A ^ B ^ C
+				gg = E ^ F ^ G
+			} else {
+				t = 0x7a879d8a
+				ff = (A & B) | (A & C) | (B & C)
+				gg = (E & F) | (^E & G)
+			}
+			ss1 := rotl(rotl(A, 12)+E+rotl(t, uint(j)%32), 7)
+			ss2 := ss1 ^ rotl(A, 12)
+			tt1 := ff + D + ss2 + (w[j] ^ w[j+4])
+			tt2 := gg + H + ss1 + w[j]
+			D = C
+			C = rotl(B, 9)
+			B = A
+			A = tt1
+			H = G
+			G = rotl(F, 19)
+			F = E
+			E = p0(tt2)
+		}
+		a ^= A
+		b ^= B
+		c ^= C
+		dd ^= D
+		e ^= E
+		f ^= F
+		g ^= G
+		h ^= H
+		p = p[BlockSize:]
+	}
+	d.h[0], d.h[1], d.h[2], d.h[3], d.h[4], d.h[5], d.h[6], d.h[7] = a, b, c, dd, e, f, g, h
+}
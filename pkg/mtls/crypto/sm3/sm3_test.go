@@ -0,0 +1,67 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sm3
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// test vectors from GB/T 32905-2016 appendix A
+var golden = []struct {
+	in  string
+	out string
+}{
+	{"abc", "66c7f0f462eeedd9d1f2d46bdc10e4e24167c4875cf2f7a2297da02b8f4ba8e0"},
+	{strings.Repeat("abcd", 16), "debe9ff92275b8a138604889c18e5a4d6fdb70e5387e5765293dcba39c0c5732"},
+}
+
+func TestSum(t *testing.T) {
+	for _, g := range golden {
+		sum := Sum([]byte(g.in))
+		if got := hex.EncodeToString(sum[:]); got != g.out {
+			t.Errorf("Sum(%q) = %s, want %s", g.in, got, g.out)
+		}
+	}
+}
+
+func TestSplitWrites(t *testing.T) {
+	for _, g := range golden {
+		h := New()
+		for _, b := range []byte(g.in) {
+			h.Write([]byte{b})
+		}
+		want := Sum([]byte(g.in))
+		if !bytes.Equal(h.Sum(nil), want[:]) {
+			t.Errorf("byte wise write of %q differs from Sum", g.in)
+		}
+	}
+}
+
+func TestSumDoesNotChangeState(t *testing.T) {
+	h := New()
+	h.Write([]byte("ab"))
+	h.Sum(nil)
+	h.Write([]byte("c"))
+	want := Sum([]byte("abc"))
+	if !bytes.Equal(h.Sum(nil), want[:]) {
+		t.Errorf("Sum changed the hash state")
+	}
+}
@@ -0,0 +1,71 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sm4
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// test vector from GB/T 32907-2016 appendix A, example 1
+var (
+	testKey, _        = hex.DecodeString("0123456789abcdeffedcba9876543210")
+	testPlaintext, _  = hex.DecodeString("0123456789abcdeffedcba9876543210")
+	testCiphertext, _ = hex.DecodeString("681edf34d206965e86b3e94f536e4246")
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	c, err := NewCipher(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make([]byte, BlockSize)
+	c.Encrypt(out, testPlaintext)
+	if !bytes.Equal(out, testCiphertext) {
+		t.Errorf("Encrypt = %x, want %x", out, testCiphertext)
+	}
+	c.Decrypt(out, out)
+	if !bytes.Equal(out, testPlaintext) {
+		t.Errorf("Decrypt = %x, want %x", out, testPlaintext)
+	}
+}
+
+func TestMillionRounds(t *testing.T) {
+	// GB/T 32907-2016 appendix A, example 2: encrypting the plaintext
+	// 1000000 times with the same key
+	want, _ := hex.DecodeString("595298c7c6fd271f0402f804c33d3f66")
+	c, err := NewCipher(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make([]byte, BlockSize)
+	copy(out, testPlaintext)
+	for i := 0; i < 1000000; i++ {
+		c.Encrypt(out, out)
+	}
+	if !bytes.Equal(out, want) {
+		t.Errorf("iterated Encrypt = %x, want %x", out, want)
+	}
+}
+
+func TestKeySize(t *testing.T) {
+	if _, err := NewCipher(make([]byte, 24)); err == nil {
+		t.Errorf("24 byte key should be rejected")
+	}
+}
@@ -16,6 +16,8 @@ import (
 	"hash"
 
 	"golang.org/x/crypto/chacha20poly1305"
+	"sofastack.io/sofa-mosn/pkg/mtls/crypto/sm3"
+	"sofastack.io/sofa-mosn/pkg/mtls/crypto/sm4"
 )
 
 // a keyAgreement implements the client and server side of a TLS key agreement
@@ -98,6 +100,10 @@ var cipherSuites = []*cipherSuite{
 	{TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA, 24, 20, 8, ecdheRSAKA, suiteECDHE, cipher3DES, macSHA1, nil},
 	{TLS_RSA_WITH_3DES_EDE_CBC_SHA, 24, 20, 8, rsaKA, 0, cipher3DES, macSHA1, nil},
 
+	// GM cipher suite (GB/T, GM/T 0024), off by default and only selected
+	// when explicitly configured.
+	{TLS_ECDHE_SM2_WITH_SM4_SM3, 16, 32, 16, sm2KA, suiteECDHE | suiteECDSA | suiteTLS12 | suiteDefaultOff, cipherSM4, macSM3, nil},
+
 	// RC4-based cipher suites are disabled by default.
 	{TLS_RSA_WITH_RC4_128_SHA, 16, 20, 0, rsaKA, suiteDefaultOff, cipherRC4, macSHA1, nil},
 	{TLS_ECDHE_RSA_WITH_RC4_128_SHA, 16, 20, 0, ecdheRSAKA, suiteECDHE | suiteDefaultOff, cipherRC4, macSHA1, nil},
@@ -144,6 +150,20 @@ func macSHA256(version uint16, key []byte) macFunction {
 	return tls10MAC{hmac.New(sha256.New, key)}
 }
 
+func cipherSM4(key, iv []byte, isRead bool) interface{} {
+	block, _ := sm4.NewCipher(key)
+	if isRead {
+		return cipher.NewCBCDecrypter(block, iv)
+	}
+	return cipher.NewCBCEncrypter(block, iv)
+}
+
+// macSM3 returns an HMAC-SM3 based MAC. The GM cipher suite is only
+// supported in TLS 1.2 so the given version is ignored.
+func macSM3(version uint16, key []byte) macFunction {
+	return tls10MAC{hmac.New(sm3.New, key)}
+}
+
 type macFunction interface {
 	Size() int
 	MAC(digestBuf, seq, header, data, extra []byte) []byte
@@ -389,6 +409,10 @@ const (
 	TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305    uint16 = 0xcca8
 	TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305  uint16 = 0xcca9
 
+	// TLS_ECDHE_SM2_WITH_SM4_SM3 is the ECDHE_SM4_SM3 cipher suite from
+	// GM/T 0024 (Chinese national crypto standard).
+	TLS_ECDHE_SM2_WITH_SM4_SM3 uint16 = 0xe011
+
 	// TLS_FALLBACK_SCSV isn't a standard cipher suite but an indicator
 	// that the client is doing version fallback. See
 	// https://tools.ietf.org/html/rfc7507.
@@ -99,6 +99,9 @@ const (
 	CurveP384 CurveID = 24
 	CurveP521 CurveID = 25
 	X25519    CurveID = 29
+	// CurveSM2 is the sm2p256v1 curve, the codepoint is curveSM2 from
+	// RFC 8998
+	CurveSM2 CurveID = 41
 )
 
 // TLS Elliptic Curve Point Formats
@@ -500,6 +503,10 @@ type Config struct {
 	// The default, none, is correct for the vast majority of applications.
 	Renegotiation RenegotiationSupport
 
+	// RenegotiationRejectedCallback, if not nil, is called whenever a peer
+	// attempts a renegotiation that the Renegotiation policy refuses.
+	RenegotiationRejectedCallback func()
+
 	// KeyLogWriter optionally specifies a destination for TLS master secrets
 	// in NSS key log format that can be used to allow external programs
 	// such as Wireshark to decrypt TLS connections.
@@ -556,32 +563,33 @@ func (c *Config) Clone() *Config {
 	c.mutex.RUnlock()
 
 	return &Config{
-		Rand:                        c.Rand,
-		Time:                        c.Time,
-		Certificates:                c.Certificates,
-		NameToCertificate:           c.NameToCertificate,
-		GetCertificate:              c.GetCertificate,
-		GetClientCertificate:        c.GetClientCertificate,
-		GetConfigForClient:          c.GetConfigForClient,
-		VerifyPeerCertificate:       c.VerifyPeerCertificate,
-		RootCAs:                     c.RootCAs,
-		NextProtos:                  c.NextProtos,
-		ServerName:                  c.ServerName,
-		ClientAuth:                  c.ClientAuth,
-		ClientCAs:                   c.ClientCAs,
-		InsecureSkipVerify:          c.InsecureSkipVerify,
-		CipherSuites:                c.CipherSuites,
-		PreferServerCipherSuites:    c.PreferServerCipherSuites,
-		SessionTicketsDisabled:      c.SessionTicketsDisabled,
-		SessionTicketKey:            c.SessionTicketKey,
-		ClientSessionCache:          c.ClientSessionCache,
-		MinVersion:                  c.MinVersion,
-		MaxVersion:                  c.MaxVersion,
-		CurvePreferences:            c.CurvePreferences,
-		DynamicRecordSizingDisabled: c.DynamicRecordSizingDisabled,
-		Renegotiation:               c.Renegotiation,
-		KeyLogWriter:                c.KeyLogWriter,
-		sessionTicketKeys:           sessionTicketKeys,
+		Rand:                          c.Rand,
+		Time:                          c.Time,
+		Certificates:                  c.Certificates,
+		NameToCertificate:             c.NameToCertificate,
+		GetCertificate:                c.GetCertificate,
+		GetClientCertificate:          c.GetClientCertificate,
+		GetConfigForClient:            c.GetConfigForClient,
+		VerifyPeerCertificate:         c.VerifyPeerCertificate,
+		RootCAs:                       c.RootCAs,
+		NextProtos:                    c.NextProtos,
+		ServerName:                    c.ServerName,
+		ClientAuth:                    c.ClientAuth,
+		ClientCAs:                     c.ClientCAs,
+		InsecureSkipVerify:            c.InsecureSkipVerify,
+		CipherSuites:                  c.CipherSuites,
+		PreferServerCipherSuites:      c.PreferServerCipherSuites,
+		SessionTicketsDisabled:        c.SessionTicketsDisabled,
+		SessionTicketKey:              c.SessionTicketKey,
+		ClientSessionCache:            c.ClientSessionCache,
+		MinVersion:                    c.MinVersion,
+		MaxVersion:                    c.MaxVersion,
+		CurvePreferences:              c.CurvePreferences,
+		DynamicRecordSizingDisabled:   c.DynamicRecordSizingDisabled,
+		Renegotiation:                 c.Renegotiation,
+		RenegotiationRejectedCallback: c.RenegotiationRejectedCallback,
+		KeyLogWriter:                  c.KeyLogWriter,
+		sessionTicketKeys:             sessionTicketKeys,
 	}
 }
 
@@ -705,6 +705,7 @@ Again:
 	case recordTypeHandshake:
 		// TODO(rsc): Should at least pick off connection close.
 		if typ != want && !(c.isClient && c.config.Renegotiation != RenegotiateNever) {
+			c.rejectedRenegotiation()
 			return c.in.setErrorLocked(c.sendAlert(alertNoRenegotiation))
 		}
 		c.hand.Write(data)
@@ -1072,6 +1073,14 @@ func (c *Conn) Write(b []byte) (int, error) {
 	return n + m, c.out.setErrorLocked(err)
 }
 
+// rejectedRenegotiation reports a refused renegotiation attempt to the
+// config's callback, if any.
+func (c *Conn) rejectedRenegotiation() {
+	if cb := c.config.RenegotiationRejectedCallback; cb != nil {
+		cb()
+	}
+}
+
 // handleRenegotiation processes a HelloRequest handshake message.
 // c.in.Mutex <= L
 func (c *Conn) handleRenegotiation() error {
@@ -1087,14 +1096,17 @@ func (c *Conn) handleRenegotiation() error {
 	}
 
 	if !c.isClient {
+		c.rejectedRenegotiation()
 		return c.sendAlert(alertNoRenegotiation)
 	}
 
 	switch c.config.Renegotiation {
 	case RenegotiateNever:
+		c.rejectedRenegotiation()
 		return c.sendAlert(alertNoRenegotiation)
 	case RenegotiateOnceAsClient:
 		if c.handshakes > 1 {
+			c.rejectedRenegotiation()
 			return c.sendAlert(alertNoRenegotiation)
 		}
 	case RenegotiateFreelyAsClient:
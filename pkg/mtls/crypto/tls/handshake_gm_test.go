@@ -0,0 +1,141 @@
+// Copyright 2009 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tls
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+)
+
+func gmTestConfig(t *testing.T) (*Config, *Config) {
+	t.Helper()
+	// stock x509 cannot serialize certificates on the sm2 curve, the sm2
+	// signature scheme itself works over any ec key
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "gm test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	derBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := Certificate{
+		Certificate: [][]byte{derBytes},
+		PrivateKey:  priv,
+	}
+
+	serverConfig := &Config{
+		Certificates:     []Certificate{cert},
+		CipherSuites:     []uint16{TLS_ECDHE_SM2_WITH_SM4_SM3},
+		CurvePreferences: []CurveID{CurveSM2},
+		MinVersion:       VersionTLS12,
+	}
+	clientConfig := &Config{
+		CipherSuites:       []uint16{TLS_ECDHE_SM2_WITH_SM4_SM3},
+		CurvePreferences:   []CurveID{CurveSM2},
+		MinVersion:         VersionTLS12,
+		InsecureSkipVerify: true,
+	}
+	return serverConfig, clientConfig
+}
+
+// gmTestPipe returns a tls client and server connected over tcp loopback,
+// net.Pipe deadlocks when both sides write their close_notify alerts
+func gmTestPipe(t *testing.T, serverConfig, clientConfig *Config) (*Conn, *Conn) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		accepted <- conn
+	}()
+	c, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Client(c, clientConfig), Server(<-accepted, serverConfig)
+}
+
+func TestHandshakeGMCipherSuite(t *testing.T) {
+	serverConfig, clientConfig := gmTestConfig(t)
+	client, server := gmTestPipe(t, serverConfig, clientConfig)
+
+	done := make(chan error, 1)
+	go func() {
+		err := server.Handshake()
+		if err == nil {
+			var buf [32]byte
+			if n, rerr := server.Read(buf[:]); rerr == nil {
+				_, err = server.Write(buf[:n])
+			} else {
+				err = rerr
+			}
+		}
+		done <- err
+	}()
+
+	if err := client.Handshake(); err != nil {
+		t.Fatalf("client handshake failed: %v", err)
+	}
+	if got := client.ConnectionState().CipherSuite; got != TLS_ECDHE_SM2_WITH_SM4_SM3 {
+		t.Errorf("negotiated suite %#x, want %#x", got, TLS_ECDHE_SM2_WITH_SM4_SM3)
+	}
+
+	msg := []byte("gm tls roundtrip")
+	if _, err := client.Write(msg); err != nil {
+		t.Fatal(err)
+	}
+	echo := make([]byte, len(msg))
+	if _, err := client.Read(echo); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(echo, msg) {
+		t.Errorf("echo mismatch: %q", echo)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("server failed: %v", err)
+	}
+	client.Close()
+	server.Close()
+}
+
+func TestHandshakeGMRequiresCurve(t *testing.T) {
+	serverConfig, clientConfig := gmTestConfig(t)
+	// a client without the sm2 curve cannot negotiate the gm suite
+	clientConfig.CurvePreferences = []CurveID{CurveP256}
+	clientConfig.CipherSuites = append(clientConfig.CipherSuites, TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256)
+
+	client, server := gmTestPipe(t, serverConfig, clientConfig)
+	go func() {
+		server.Handshake()
+		server.Close()
+	}()
+	if err := client.Handshake(); err == nil {
+		t.Errorf("handshake should fail when the gm server has no common suite")
+	}
+	client.Close()
+}
@@ -38,9 +38,14 @@ func (zeroSource) Read(b []byte) (n int, err error) {
 var testConfig *Config
 
 func allCipherSuites() []uint16 {
-	ids := make([]uint16, len(cipherSuites))
-	for i, suite := range cipherSuites {
-		ids[i] = suite.id
+	ids := make([]uint16, 0, len(cipherSuites))
+	for _, suite := range cipherSuites {
+		// the gm suite postdates the recorded handshake transcripts in
+		// testdata, offering it would change the replayed ClientHello
+		if suite.id == TLS_ECDHE_SM2_WITH_SM4_SM3 {
+			continue
+		}
+		ids = append(ids, suite.id)
 	}
 
 	return ids
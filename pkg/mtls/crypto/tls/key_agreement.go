@@ -18,6 +18,7 @@ import (
 	"math/big"
 
 	"golang.org/x/crypto/curve25519"
+	"sofastack.io/sofa-mosn/pkg/mtls/crypto/sm2"
 )
 
 var errClientKeyExchange = errors.New("tls: invalid ClientKeyExchange message")
@@ -159,6 +160,8 @@ func pickTLS12HashForSignature(sigType uint8, clientList []signatureAndHash) (ui
 
 func curveForCurveID(id CurveID) (elliptic.Curve, bool) {
 	switch id {
+	case CurveSM2:
+		return sm2.P256Sm2(), true
 	case CurveP256:
 		return elliptic.P256(), true
 	case CurveP384:
@@ -0,0 +1,187 @@
+// Copyright 2009 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/x509"
+	"errors"
+	"math/big"
+
+	"sofastack.io/sofa-mosn/pkg/mtls/crypto/sm2"
+)
+
+// sm2SignatureScheme is the sm2sig_sm3 signature scheme codepoint defined
+// in RFC 8998, carried in the TLS 1.2 SignatureAndHashAlgorithm slot of
+// the ServerKeyExchange message
+var sm2SignatureScheme = [2]byte{0x07, 0x08}
+
+// sm2ECDHEKeyAgreement implements a TLS key agreement where the server
+// generates an ephemeral key on the sm2p256v1 curve and signs it with the
+// SM2 signature scheme. It is the GM/T 0024 ECDHE_SM4_SM3 single
+// certificate profile run over the TLS 1.2 handshake, the dual certificate
+// GMTLS record layer is not supported
+type sm2ECDHEKeyAgreement struct {
+	version    uint16
+	privateKey []byte
+	x, y       *big.Int
+}
+
+func sm2KA(version uint16) keyAgreement {
+	return &sm2ECDHEKeyAgreement{version: version}
+}
+
+func (ka *sm2ECDHEKeyAgreement) generateServerKeyExchange(config *Config, cert *Certificate, clientHello *clientHelloMsg, hello *serverHelloMsg) (*serverKeyExchangeMsg, error) {
+	supported := false
+	for _, c := range clientHello.supportedCurves {
+		if c == CurveSM2 {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return nil, errors.New("tls: client does not support the sm2 curve")
+	}
+
+	curve := sm2.P256Sm2()
+	privateKey, x, y, err := elliptic.GenerateKey(curve, config.rand())
+	if err != nil {
+		return nil, err
+	}
+	ka.privateKey = privateKey
+	ecdhePublic := elliptic.Marshal(curve, x, y)
+
+	serverECDHParams := make([]byte, 1+2+1+len(ecdhePublic))
+	serverECDHParams[0] = 3 // named curve
+	serverECDHParams[1] = byte(CurveSM2 >> 8)
+	serverECDHParams[2] = byte(CurveSM2)
+	serverECDHParams[3] = byte(len(ecdhePublic))
+	copy(serverECDHParams[4:], ecdhePublic)
+
+	priv, ok := cert.PrivateKey.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("tls: sm2 key exchange requires an ec server key")
+	}
+	var msg []byte
+	msg = append(msg, clientHello.random...)
+	msg = append(msg, hello.random...)
+	msg = append(msg, serverECDHParams...)
+	sig, err := sm2.Sign(config.rand(), priv, nil, msg)
+	if err != nil {
+		return nil, errors.New("tls: failed to sign sm2 parameters: " + err.Error())
+	}
+
+	skx := new(serverKeyExchangeMsg)
+	skx.key = make([]byte, len(serverECDHParams)+2+2+len(sig))
+	copy(skx.key, serverECDHParams)
+	k := skx.key[len(serverECDHParams):]
+	k[0] = sm2SignatureScheme[0]
+	k[1] = sm2SignatureScheme[1]
+	k[2] = byte(len(sig) >> 8)
+	k[3] = byte(len(sig))
+	copy(k[4:], sig)
+
+	return skx, nil
+}
+
+func (ka *sm2ECDHEKeyAgreement) processClientKeyExchange(config *Config, cert *Certificate, ckx *clientKeyExchangeMsg, version uint16) ([]byte, error) {
+	if len(ckx.ciphertext) == 0 || int(ckx.ciphertext[0]) != len(ckx.ciphertext)-1 {
+		return nil, errClientKeyExchange
+	}
+	curve := sm2.P256Sm2()
+	x, y := elliptic.Unmarshal(curve, ckx.ciphertext[1:])
+	if x == nil {
+		return nil, errClientKeyExchange
+	}
+	if !curve.IsOnCurve(x, y) {
+		return nil, errClientKeyExchange
+	}
+	x, _ = curve.ScalarMult(x, y, ka.privateKey)
+	preMasterSecret := make([]byte, (curve.Params().BitSize+7)>>3)
+	xBytes := x.Bytes()
+	copy(preMasterSecret[len(preMasterSecret)-len(xBytes):], xBytes)
+
+	return preMasterSecret, nil
+}
+
+func (ka *sm2ECDHEKeyAgreement) processServerKeyExchange(config *Config, clientHello *clientHelloMsg, serverHello *serverHelloMsg, cert *x509.Certificate, skx *serverKeyExchangeMsg) error {
+	if len(skx.key) < 4 {
+		return errServerKeyExchange
+	}
+	if skx.key[0] != 3 { // named curve
+		return errors.New("tls: server selected unsupported curve")
+	}
+	if CurveID(skx.key[1])<<8|CurveID(skx.key[2]) != CurveSM2 {
+		return errors.New("tls: server selected a non sm2 curve for an sm2 cipher suite")
+	}
+
+	publicLen := int(skx.key[3])
+	if publicLen+4 > len(skx.key) {
+		return errServerKeyExchange
+	}
+	serverECDHParams := skx.key[:4+publicLen]
+	publicKey := serverECDHParams[4:]
+
+	curve := sm2.P256Sm2()
+	ka.x, ka.y = elliptic.Unmarshal(curve, publicKey)
+	if ka.x == nil {
+		return errServerKeyExchange
+	}
+	if !curve.IsOnCurve(ka.x, ka.y) {
+		return errServerKeyExchange
+	}
+
+	sig := skx.key[4+publicLen:]
+	if len(sig) < 4 {
+		return errServerKeyExchange
+	}
+	if sig[0] != sm2SignatureScheme[0] || sig[1] != sm2SignatureScheme[1] {
+		return errors.New("tls: server used a non sm2 signature for an sm2 cipher suite")
+	}
+	sigLen := int(sig[2])<<8 | int(sig[3])
+	if sigLen+4 != len(sig) {
+		return errServerKeyExchange
+	}
+	sig = sig[4:]
+
+	pubKey, ok := cert.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return errors.New("tls: sm2 key exchange requires an ec server public key")
+	}
+	var msg []byte
+	msg = append(msg, clientHello.random...)
+	msg = append(msg, serverHello.random...)
+	msg = append(msg, serverECDHParams...)
+	if err := sm2.Verify(pubKey, nil, msg, sig); err != nil {
+		return errors.New("tls: sm2 verification failure: " + err.Error())
+	}
+
+	return nil
+}
+
+func (ka *sm2ECDHEKeyAgreement) generateClientKeyExchange(config *Config, clientHello *clientHelloMsg, cert *x509.Certificate) ([]byte, *clientKeyExchangeMsg, error) {
+	if ka.x == nil {
+		return nil, nil, errors.New("tls: missing ServerKeyExchange message")
+	}
+	curve := sm2.P256Sm2()
+	priv, mx, my, err := elliptic.GenerateKey(curve, config.rand())
+	if err != nil {
+		return nil, nil, err
+	}
+	x, _ := curve.ScalarMult(ka.x, ka.y, priv)
+	preMasterSecret := make([]byte, (curve.Params().BitSize+7)>>3)
+	xBytes := x.Bytes()
+	copy(preMasterSecret[len(preMasterSecret)-len(xBytes):], xBytes)
+
+	serialized := elliptic.Marshal(curve, mx, my)
+
+	ckx := new(clientKeyExchangeMsg)
+	ckx.ciphertext = make([]byte, 1+len(serialized))
+	ckx.ciphertext[0] = byte(len(serialized))
+	copy(ckx.ciphertext[1:], serialized)
+
+	return preMasterSecret, ckx, nil
+}
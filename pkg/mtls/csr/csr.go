@@ -0,0 +1,206 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package csr provisions workload certificates via the kubernetes
+// CertificateSigningRequest API. The private key is generated in memory and
+// never written to disk, so a standalone mosn gets an identity without
+// certificate files or an SDS server.
+//
+// Enabled by a tls config with type "kubernetes-csr", e.g.
+//
+//	"tls_context": {
+//	    "status": true,
+//	    "type": "kubernetes-csr",
+//	    "extend_verify": {
+//	        "common_name": "mosn.default.svc",
+//	        "dns_names": "mosn.default.svc,mosn.default",
+//	        "signer_name": "kubernetes.io/legacy-unknown"
+//	    }
+//	}
+package csr
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/mtls"
+	"sofastack.io/sofa-mosn/pkg/mtls/crypto/tls"
+	"sofastack.io/sofa-mosn/pkg/utils"
+)
+
+func init() {
+	mtls.Register("kubernetes-csr", &csrFactory{})
+}
+
+const (
+	defaultSignerName  = "kubernetes.io/legacy-unknown"
+	defaultCertTimeout = 5 * time.Minute
+	// renew when less than a third of the certificate lifetime is left
+	renewLifetimeFraction = 3
+)
+
+type csrFactory struct{}
+
+func (f *csrFactory) CreateConfigHooks(config map[string]interface{}) mtls.ConfigHooks {
+	hooks := &csrHooks{
+		ConfigHooks: mtls.DefaultConfigHooks(),
+		commonName:  getString(config, "common_name"),
+		signerName:  defaultSignerName,
+		apiserver:   getString(config, "apiserver"),
+	}
+	if v := getString(config, "signer_name"); v != "" {
+		hooks.signerName = v
+	}
+	if v := getString(config, "dns_names"); v != "" {
+		hooks.dnsNames = strings.Split(v, ",")
+	}
+	if hooks.commonName == "" {
+		hostname, _ := os.Hostname()
+		hooks.commonName = hostname
+	}
+	return hooks
+}
+
+func getString(config map[string]interface{}, key string) string {
+	if v, ok := config[key].(string); ok {
+		return v
+	}
+	return ""
+}
+
+// csrHooks provisions the certificate via the csr api, other hooks fall
+// back to the default implementation
+type csrHooks struct {
+	mtls.ConfigHooks
+	commonName string
+	dnsNames   []string
+	signerName string
+	apiserver  string
+
+	cert atomic.Value // tls.Certificate
+}
+
+// GetCertificate ignores the cert/key indexes and returns the certificate
+// issued by the platform ca, provisioning one on first use
+func (h *csrHooks) GetCertificate(certIndex, keyIndex string) (tls.Certificate, error) {
+	if cert, ok := h.cert.Load().(tls.Certificate); ok {
+		return cert, nil
+	}
+
+	cert, err := h.provision()
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	h.cert.Store(cert)
+
+	// renew in the background before the certificate expires
+	utils.GoWithRecover(func() {
+		h.renewLoop(cert)
+	}, nil)
+
+	return cert, nil
+}
+
+// provision generates an in-memory key, submits a csr object and waits for
+// the signed certificate
+func (h *csrHooks) provision() (tls.Certificate, error) {
+	client, err := newKubeClient(h.apiserver)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+
+	csrDER, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: h.commonName},
+		DNSNames: h.dnsNames,
+	}, priv)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	csrPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDER})
+
+	name := fmt.Sprintf("mosn-%s-%d", h.commonName, time.Now().UnixNano())
+	if err := client.createCSR(&csrObject{
+		APIVersion: "certificates.k8s.io/v1beta1",
+		Kind:       "CertificateSigningRequest",
+		Metadata:   csrMeta{Name: name},
+		Spec: csrSpec{
+			Request:    csrPEM,
+			SignerName: h.signerName,
+			Usages:     []string{"digital signature", "key encipherment", "server auth", "client auth"},
+		},
+	}); err != nil {
+		return tls.Certificate{}, err
+	}
+	defer client.deleteCSR(name)
+
+	certPEM, err := client.waitForCertificate(name, defaultCertTimeout)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	log.DefaultLogger.Infof("[mtls] [csr] provisioned certificate for %s via csr %s", h.commonName, name)
+	return cert, nil
+}
+
+// renewLoop re-provisions the certificate before it expires
+func (h *csrHooks) renewLoop(cert tls.Certificate) {
+	for {
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			log.DefaultLogger.Errorf("[mtls] [csr] parse issued certificate failed: %v", err)
+			return
+		}
+		lifetime := leaf.NotAfter.Sub(leaf.NotBefore)
+		renewAt := leaf.NotAfter.Add(-lifetime / renewLifetimeFraction)
+		if wait := time.Until(renewAt); wait > 0 {
+			time.Sleep(wait)
+		}
+
+		renewed, err := h.provision()
+		if err != nil {
+			log.DefaultLogger.Errorf("[mtls] [csr] renew certificate failed, retry later: %v", err)
+			time.Sleep(time.Minute)
+			continue
+		}
+		h.cert.Store(renewed)
+		cert = renewed
+	}
+}
@@ -0,0 +1,183 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package csr
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeAPIServer mimics the csr api: created requests are signed with a test
+// ca on the first get
+type fakeAPIServer struct {
+	caCert *x509.Certificate
+	caKey  *ecdsa.PrivateKey
+
+	mutex sync.Mutex
+	csrs  map[string]*csrObject
+}
+
+func newFakeAPIServer(t *testing.T) *fakeAPIServer {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, template, template, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert, _ := x509.ParseCertificate(caDER)
+	return &fakeAPIServer{
+		caCert: caCert,
+		caKey:  caKey,
+		csrs:   make(map[string]*csrObject),
+	}
+}
+
+func (s *fakeAPIServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	switch {
+	case r.Method == http.MethodPost:
+		csr := &csrObject{}
+		if err := json.NewDecoder(r.Body).Decode(csr); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.csrs[csr.Metadata.Name] = csr
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodGet:
+		name := strings.TrimPrefix(r.URL.Path, csrAPIPath+"/")
+		csr, ok := s.csrs[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if len(csr.Status.Certificate) == 0 {
+			csr.Status.Certificate = s.sign(csr.Spec.Request)
+		}
+		json.NewEncoder(w).Encode(csr)
+	case r.Method == http.MethodDelete:
+		name := strings.TrimPrefix(r.URL.Path, csrAPIPath+"/")
+		delete(s.csrs, name)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func (s *fakeAPIServer) sign(csrPEM []byte) []byte {
+	block, _ := pem.Decode(csrPEM)
+	if block == nil {
+		return nil
+	}
+	request, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      request.Subject,
+		DNSNames:     request.DNSNames,
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, s.caCert, request.PublicKey, s.caKey)
+	if err != nil {
+		return nil
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+}
+
+func TestCSRProvision(t *testing.T) {
+	apiserver := newFakeAPIServer(t)
+	server := httptest.NewServer(apiserver)
+	defer server.Close()
+
+	// fake service account token
+	tokenFile, err := ioutil.TempFile("", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tokenFile.Name())
+	tokenFile.WriteString("test-token")
+	tokenFile.Close()
+
+	oldToken, oldInterval := serviceAccountTokenPath, csrPollInterval
+	serviceAccountTokenPath = tokenFile.Name()
+	csrPollInterval = 10 * time.Millisecond
+	defer func() {
+		serviceAccountTokenPath, csrPollInterval = oldToken, oldInterval
+	}()
+
+	factory := &csrFactory{}
+	hooks := factory.CreateConfigHooks(map[string]interface{}{
+		"common_name": "mosn.default.svc",
+		"dns_names":   "mosn.default.svc,mosn.default",
+		"apiserver":   server.URL,
+	})
+
+	cert, err := hooks.GetCertificate("", "")
+	if err != nil {
+		t.Fatalf("provision certificate failed: %v", err)
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("issued certificate invalid: %v", err)
+	}
+	if leaf.Subject.CommonName != "mosn.default.svc" || len(leaf.DNSNames) != 2 {
+		t.Errorf("unexpected certificate subject: %v %v", leaf.Subject.CommonName, leaf.DNSNames)
+	}
+
+	// provisioned once, then served from cache
+	if _, err := hooks.GetCertificate("", ""); err != nil {
+		t.Fatalf("cached certificate failed: %v", err)
+	}
+
+	// the csr object is cleaned up after issuance
+	apiserver.mutex.Lock()
+	defer apiserver.mutex.Unlock()
+	if len(apiserver.csrs) != 0 {
+		t.Errorf("csr objects not cleaned up: %d left", len(apiserver.csrs))
+	}
+}
@@ -0,0 +1,174 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package csr
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Minimal in-cluster client for the certificates.k8s.io
+// CertificateSigningRequest API. Only create / get / delete of csr objects
+// are needed, so the full kubernetes client is not pulled in.
+
+const csrAPIPath = "/apis/certificates.k8s.io/v1beta1/certificatesigningrequests"
+
+// in-cluster service account files and the poll interval, vars for tests
+var (
+	serviceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	serviceAccountCAPath    = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+	csrPollInterval         = time.Second
+)
+
+// csrObject is the CertificateSigningRequest subset used by the provisioner,
+// []byte fields carry the base64 encoding in JSON
+type csrObject struct {
+	APIVersion string    `json:"apiVersion,omitempty"`
+	Kind       string    `json:"kind,omitempty"`
+	Metadata   csrMeta   `json:"metadata"`
+	Spec       csrSpec   `json:"spec,omitempty"`
+	Status     csrStatus `json:"status,omitempty"`
+}
+
+type csrMeta struct {
+	Name string `json:"name"`
+}
+
+type csrSpec struct {
+	Request    []byte   `json:"request,omitempty"`
+	SignerName string   `json:"signerName,omitempty"`
+	Usages     []string `json:"usages,omitempty"`
+}
+
+type csrStatus struct {
+	Certificate []byte `json:"certificate,omitempty"`
+}
+
+// kubeClient talks to the apiserver with the service account credentials
+type kubeClient struct {
+	endpoint string
+	token    string
+	client   *http.Client
+}
+
+// newKubeClient builds a client from the in-cluster environment, or from an
+// explicit apiserver endpoint configured in extend_verify
+func newKubeClient(endpoint string) (*kubeClient, error) {
+	if endpoint == "" {
+		host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+		if host == "" || port == "" {
+			return nil, fmt.Errorf("not running in a kubernetes cluster and no apiserver configured")
+		}
+		endpoint = "https://" + host + ":" + port
+	}
+
+	token, err := ioutil.ReadFile(serviceAccountTokenPath)
+	if err != nil {
+		return nil, fmt.Errorf("read service account token: %v", err)
+	}
+
+	transport := &http.Transport{}
+	if caData, err := ioutil.ReadFile(serviceAccountCAPath); err == nil {
+		pool := x509.NewCertPool()
+		pool.AppendCertsFromPEM(caData)
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	return &kubeClient{
+		endpoint: endpoint,
+		token:    string(bytes.TrimSpace(token)),
+		client:   &http.Client{Transport: transport, Timeout: 30 * time.Second},
+	}, nil
+}
+
+func (c *kubeClient) do(method, path string, body interface{}, out interface{}) error {
+	var reqBody *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	} else {
+		reqBody = bytes.NewReader(nil)
+	}
+
+	req, err := http.NewRequest(method, c.endpoint+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		data, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("apiserver %s %s failed, status %d: %s", method, path, resp.StatusCode, string(data))
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
+
+func (c *kubeClient) createCSR(csr *csrObject) error {
+	return c.do(http.MethodPost, csrAPIPath, csr, nil)
+}
+
+func (c *kubeClient) getCSR(name string) (*csrObject, error) {
+	csr := &csrObject{}
+	if err := c.do(http.MethodGet, csrAPIPath+"/"+name, nil, csr); err != nil {
+		return nil, err
+	}
+	return csr, nil
+}
+
+func (c *kubeClient) deleteCSR(name string) error {
+	return c.do(http.MethodDelete, csrAPIPath+"/"+name, nil, nil)
+}
+
+// waitForCertificate polls the csr object until a signed certificate is
+// issued by the approver, or the timeout elapses
+func (c *kubeClient) waitForCertificate(name string, timeout time.Duration) ([]byte, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		csr, err := c.getCSR(name)
+		if err != nil {
+			return nil, err
+		}
+		if len(csr.Status.Certificate) > 0 {
+			return csr.Status.Certificate, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("csr %s not signed within %v", name, timeout)
+		}
+		time.Sleep(csrPollInterval)
+	}
+}
@@ -0,0 +1,69 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mtls
+
+import (
+	"encoding/asn1"
+	"fmt"
+	"io/ioutil"
+
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/mtls/crypto/tls"
+)
+
+// attachOCSPStaple loads the configured der encoded ocsp response and
+// staples it onto the served certificate. The staple file is refreshed by
+// the certificate watcher: writing a fresh response (e.g. from an acme or
+// ocsp cron) rebuilds the context before the old staple expires. With
+// OCSPStapleRequired an unavailable staple fails the config, otherwise the
+// certificate is served without one
+func attachOCSPStaple(cert *tls.Certificate, c *v2.TLSConfig) error {
+	if c.OCSPStaple == "" {
+		if c.OCSPStapleRequired {
+			return fmt.Errorf("ocsp staple is required but not configured")
+		}
+		return nil
+	}
+	staple, err := ioutil.ReadFile(c.OCSPStaple)
+	if err == nil {
+		err = checkOCSPStaple(staple)
+	}
+	if err != nil {
+		if c.OCSPStapleRequired {
+			return fmt.Errorf("ocsp staple is required but unavailable: %v", err)
+		}
+		log.DefaultLogger.Warnf("[mtls] load ocsp staple %s failed: %v, serving without staple", c.OCSPStaple, err)
+		return nil
+	}
+	cert.OCSPStaple = staple
+	return nil
+}
+
+// checkOCSPStaple rejects files that cannot be a der encoded ocsp
+// response, catching truncated writes and pem files configured by mistake
+func checkOCSPStaple(staple []byte) error {
+	if len(staple) == 0 {
+		return fmt.Errorf("ocsp staple is empty")
+	}
+	var raw asn1.RawValue
+	if rest, err := asn1.Unmarshal(staple, &raw); err != nil || len(rest) != 0 {
+		return fmt.Errorf("ocsp staple is not a valid der encoded response")
+	}
+	return nil
+}
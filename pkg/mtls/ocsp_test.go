@@ -0,0 +1,101 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mtls
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"sofastack.io/sofa-mosn/pkg/log"
+)
+
+// fakeStaple returns a minimal valid der sequence standing in for an
+// ocsp response
+func fakeStaple(t *testing.T) []byte {
+	t.Helper()
+	staple, err := asn1.Marshal(struct{ Status int }{Status: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return staple
+}
+
+func TestOCSPStapleAttached(t *testing.T) {
+	f, err := ioutil.TempFile("", "ocsp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	staple := fakeStaple(t)
+	f.Write(staple)
+	f.Close()
+
+	info := &certInfo{CommonName: "ocsp", Curve: "P256"}
+	cfg, err := info.CreateCertConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.OCSPStaple = f.Name()
+	cfg.OCSPStapleRequired = true
+
+	mgr := &contextManager{logger: log.DefaultLogger}
+	tlsConfig, err := mgr.newTLSConfig(cfg)
+	if err != nil {
+		t.Fatalf("create tls config with staple failed: %v", err)
+	}
+	if len(tlsConfig.Certificates) != 1 || !bytes.Equal(tlsConfig.Certificates[0].OCSPStaple, staple) {
+		t.Errorf("ocsp staple not attached to served certificate")
+	}
+}
+
+func TestOCSPStapleRequired(t *testing.T) {
+	info := &certInfo{CommonName: "ocsp", Curve: "P256"}
+	cfg, err := info.CreateCertConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.OCSPStaple = "/path/does/not/exist"
+
+	mgr := &contextManager{logger: log.DefaultLogger}
+	// optional staple: serve without one
+	if tlsConfig, err := mgr.newTLSConfig(cfg); err != nil {
+		t.Errorf("optional staple should not fail the config: %v", err)
+	} else if len(tlsConfig.Certificates) != 1 || tlsConfig.Certificates[0].OCSPStaple != nil {
+		t.Errorf("unavailable optional staple should leave the certificate unstapled")
+	}
+	// required staple: hard fail
+	cfg.OCSPStapleRequired = true
+	if _, err := mgr.newTLSConfig(cfg); err == nil {
+		t.Errorf("required staple should fail the config when unavailable")
+	}
+}
+
+func TestCheckOCSPStaple(t *testing.T) {
+	if err := checkOCSPStaple(nil); err == nil {
+		t.Errorf("empty staple should be rejected")
+	}
+	if err := checkOCSPStaple([]byte("-----BEGIN CERTIFICATE-----")); err == nil {
+		t.Errorf("pem input should be rejected")
+	}
+	if err := checkOCSPStaple(fakeStaple(t)); err != nil {
+		t.Errorf("valid der staple rejected: %v", err)
+	}
+}
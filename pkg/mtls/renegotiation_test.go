@@ -0,0 +1,75 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mtls
+
+import (
+	"testing"
+
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/mtls/crypto/tls"
+)
+
+func TestRenegotiationPolicy(t *testing.T) {
+	testCases := []struct {
+		policy   string
+		isClient bool
+		expected tls.RenegotiationSupport
+		valid    bool
+	}{
+		{policy: "", isClient: true, expected: tls.RenegotiateNever, valid: true},
+		{policy: "never", isClient: true, expected: tls.RenegotiateNever, valid: true},
+		{policy: "once", isClient: true, expected: tls.RenegotiateOnceAsClient, valid: true},
+		{policy: "freely", isClient: true, expected: tls.RenegotiateFreelyAsClient, valid: true},
+		{policy: "never", isClient: false, expected: tls.RenegotiateNever, valid: true},
+		// servers cannot accept renegotiation, reject the config explicitly
+		{policy: "once", isClient: false, valid: false},
+		{policy: "freely", isClient: false, valid: false},
+		{policy: "sometimes", isClient: true, valid: false},
+	}
+	for _, tc := range testCases {
+		mgr := &contextManager{
+			isClient: tc.isClient,
+			logger:   log.DefaultLogger,
+		}
+		cfg := &v2.TLSConfig{
+			Status:        true,
+			Renegotiation: tc.policy,
+		}
+		tlsConfig, err := mgr.newTLSConfig(cfg)
+		if !tc.valid {
+			if err == nil {
+				t.Errorf("policy %s isClient %v should be rejected", tc.policy, tc.isClient)
+			}
+			continue
+		}
+		if tc.isClient {
+			if err != nil {
+				t.Errorf("policy %s isClient %v failed: %v", tc.policy, tc.isClient, err)
+				continue
+			}
+			if tlsConfig.Renegotiation != tc.expected {
+				t.Errorf("policy %s mapped to %v, expected %v", tc.policy, tlsConfig.Renegotiation, tc.expected)
+			}
+		} else if err != nil && err != ErrorGetCertificateFailed {
+			// server config without certificates fails later on the
+			// certificate, the policy itself must not be the error
+			t.Errorf("policy %s isClient %v failed: %v", tc.policy, tc.isClient, err)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package sds fetches tls certificates and validation contexts from a
+// secret discovery service (istio citadel agent style), over a unix domain
+// socket or a tcp grpc stream. Keys stay in memory and rotated secrets
+// take effect on the next handshake without restarting mosn.
+//
+// Enabled by a tls config with type "sds", e.g.
+//
+//	"tls_context": {
+//	    "status": true,
+//	    "type": "sds",
+//	    "extend_verify": {
+//	        "sds_endpoint": "unix:///etc/istio/proxy/SDS",
+//	        "certificate_name": "default",
+//	        "validation_context_name": "ROOTCA"
+//	    }
+//	}
+package sds
+
+import (
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/mtls"
+	"sofastack.io/sofa-mosn/pkg/mtls/crypto/tls"
+	auth "github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+)
+
+func init() {
+	mtls.Register("sds", &sdsFactory{})
+}
+
+const (
+	defaultCertificateName = "default"
+	defaultValidationName  = "ROOTCA"
+)
+
+// sdsFetchTimeout bounds the wait for the first secret of a name,
+// a variable so tests can shorten it
+var sdsFetchTimeout = 10 * time.Second
+
+type sdsFactory struct{}
+
+// NewConfigHooks creates the sds backed config hooks directly, extensions
+// built on top of an sds stream (e.g. spiffe) wrap the returned hooks
+func NewConfigHooks(config map[string]interface{}) mtls.ConfigHooks {
+	return (&sdsFactory{}).CreateConfigHooks(config)
+}
+
+func (f *sdsFactory) CreateConfigHooks(config map[string]interface{}) mtls.ConfigHooks {
+	hooks := &sdsHooks{
+		ConfigHooks:    mtls.DefaultConfigHooks(),
+		endpoint:       getString(config, "sds_endpoint"),
+		certName:       defaultCertificateName,
+		validationName: defaultValidationName,
+		certReady:      make(chan struct{}),
+		poolReady:      make(chan struct{}),
+	}
+	if v := getString(config, "certificate_name"); v != "" {
+		hooks.certName = v
+	}
+	if v := getString(config, "validation_context_name"); v != "" {
+		hooks.validationName = v
+	}
+	return hooks
+}
+
+type sdsHooks struct {
+	mtls.ConfigHooks
+	endpoint       string
+	certName       string
+	validationName string
+
+	startOnce sync.Once
+	cert      atomic.Value // tls.Certificate
+	pool      atomic.Value // *x509.CertPool
+	certOnce  sync.Once
+	poolOnce  sync.Once
+	certReady chan struct{}
+	poolReady chan struct{}
+}
+
+// GetCertificate returns the certificate delivered by the sds server,
+// the cert/key index from the tls config is ignored
+func (h *sdsHooks) GetCertificate(certIndex, keyIndex string) (tls.Certificate, error) {
+	h.start()
+	select {
+	case <-h.certReady:
+	case <-time.After(sdsFetchTimeout):
+		return tls.Certificate{}, fmt.Errorf("get certificate %s from sds %s timeout", h.certName, h.endpoint)
+	}
+	return h.cert.Load().(tls.Certificate), nil
+}
+
+// GetX509Pool returns the validation context delivered by the sds server
+func (h *sdsHooks) GetX509Pool(caIndex string) (*x509.CertPool, error) {
+	h.start()
+	select {
+	case <-h.poolReady:
+	case <-time.After(sdsFetchTimeout):
+		return nil, fmt.Errorf("get validation context %s from sds %s timeout", h.validationName, h.endpoint)
+	}
+	return h.pool.Load().(*x509.CertPool), nil
+}
+
+func (h *sdsHooks) start() {
+	h.startOnce.Do(func() {
+		sub := newSdsSubscriber(h.endpoint, []string{h.certName, h.validationName}, h.onUpdate)
+		sub.start()
+	})
+}
+
+// onUpdate accepts a pushed secret, a certificate secret replaces the
+// served certificate and a validation secret replaces the trusted ca pool
+func (h *sdsHooks) onUpdate(name string, secret *auth.Secret) {
+	switch name {
+	case h.certName:
+		tlsCert := secret.GetTlsCertificate()
+		if tlsCert == nil {
+			log.DefaultLogger.Errorf("[mtls] [sds] secret %s is not a tls certificate", name)
+			return
+		}
+		chain, err := dataSourceBytes(tlsCert.CertificateChain)
+		if err != nil {
+			log.DefaultLogger.Errorf("[mtls] [sds] read certificate chain of %s failed: %v", name, err)
+			return
+		}
+		key, err := dataSourceBytes(tlsCert.PrivateKey)
+		if err != nil {
+			log.DefaultLogger.Errorf("[mtls] [sds] read private key of %s failed: %v", name, err)
+			return
+		}
+		cert, err := tls.X509KeyPair(chain, key)
+		if err != nil {
+			log.DefaultLogger.Errorf("[mtls] [sds] parse certificate %s failed: %v", name, err)
+			return
+		}
+		h.cert.Store(cert)
+		h.certOnce.Do(func() { close(h.certReady) })
+		log.DefaultLogger.Infof("[mtls] [sds] certificate %s updated", name)
+	case h.validationName:
+		validation := secret.GetValidationContext()
+		if validation == nil {
+			log.DefaultLogger.Errorf("[mtls] [sds] secret %s is not a validation context", name)
+			return
+		}
+		ca, err := dataSourceBytes(validation.TrustedCa)
+		if err != nil {
+			log.DefaultLogger.Errorf("[mtls] [sds] read trusted ca of %s failed: %v", name, err)
+			return
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			log.DefaultLogger.Errorf("[mtls] [sds] trusted ca of %s is not a valid pem", name)
+			return
+		}
+		h.pool.Store(pool)
+		h.poolOnce.Do(func() { close(h.poolReady) })
+		log.DefaultLogger.Infof("[mtls] [sds] validation context %s updated", name)
+	}
+}
+
+// dataSourceBytes reads an envoy data source, secrets are usually pushed
+// inline so keys never touch the disk
+func dataSourceBytes(ds *envoy_api_v2_core.DataSource) ([]byte, error) {
+	if ds == nil {
+		return nil, fmt.Errorf("data source is nil")
+	}
+	if b := ds.GetInlineBytes(); len(b) > 0 {
+		return b, nil
+	}
+	if s := ds.GetInlineString(); s != "" {
+		return []byte(s), nil
+	}
+	if f := ds.GetFilename(); f != "" {
+		return ioutil.ReadFile(f)
+	}
+	return nil, fmt.Errorf("data source is empty")
+}
+
+func getString(config map[string]interface{}, key string) string {
+	if v, ok := config[key]; ok {
+		if s, ok := v.(string); ok {
+			return s
+		}
+	}
+	return ""
+}
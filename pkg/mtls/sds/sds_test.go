@@ -0,0 +1,223 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sds
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	auth "github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	sdsapi "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
+	gogotypes "github.com/gogo/protobuf/types"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// fakeSdsServer pushes an initial certificate and root ca on the first
+// request and rotated secrets sent through the push channel afterwards
+type fakeSdsServer struct {
+	initial []*auth.Secret
+	push    chan *auth.Secret
+}
+
+func (s *fakeSdsServer) StreamSecrets(stream sdsapi.SecretDiscoveryService_StreamSecretsServer) error {
+	if _, err := stream.Recv(); err != nil {
+		return err
+	}
+	// drain acks so the client loop keeps running
+	go func() {
+		for {
+			if _, err := stream.Recv(); err != nil {
+				return
+			}
+		}
+	}()
+	if err := stream.Send(secretsResponse("1", s.initial...)); err != nil {
+		return err
+	}
+	version := 1
+	for secret := range s.push {
+		version++
+		if err := stream.Send(secretsResponse(string(rune('0'+version)), secret)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *fakeSdsServer) FetchSecrets(ctx context.Context, req *envoy_api_v2.DiscoveryRequest) (*envoy_api_v2.DiscoveryResponse, error) {
+	return secretsResponse("1", s.initial...), nil
+}
+
+func secretsResponse(version string, secrets ...*auth.Secret) *envoy_api_v2.DiscoveryResponse {
+	resp := &envoy_api_v2.DiscoveryResponse{
+		VersionInfo: version,
+		TypeUrl:     SecretTypeURL,
+		Nonce:       version,
+	}
+	for _, secret := range secrets {
+		data, _ := secret.Marshal()
+		resp.Resources = append(resp.Resources, gogotypes.Any{
+			TypeUrl: SecretTypeURL,
+			Value:   data,
+		})
+	}
+	return resp
+}
+
+func inlineBytes(b []byte) *envoy_api_v2_core.DataSource {
+	return &envoy_api_v2_core.DataSource{
+		Specifier: &envoy_api_v2_core.DataSource_InlineBytes{InlineBytes: b},
+	}
+}
+
+func certificateSecret(t *testing.T, name, commonName string) (*auth.Secret, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	secret := &auth.Secret{
+		Name: name,
+		Type: &auth.Secret_TlsCertificate{
+			TlsCertificate: &auth.TlsCertificate{
+				CertificateChain: inlineBytes(certPEM),
+				PrivateKey:       inlineBytes(keyPEM),
+			},
+		},
+	}
+	return secret, certPEM
+}
+
+func TestSdsSecretDelivery(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sds")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "sds.sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	certSecret, _ := certificateSecret(t, "default", "mosn-test")
+	caSecret, caPEM := certificateSecret(t, "ROOTCA", "test-root")
+	caSecret.Type = &auth.Secret_ValidationContext{
+		ValidationContext: &auth.CertificateValidationContext{
+			TrustedCa: inlineBytes(caPEM),
+		},
+	}
+	server := &fakeSdsServer{
+		initial: []*auth.Secret{certSecret, caSecret},
+		push:    make(chan *auth.Secret, 1),
+	}
+	grpcServer := grpc.NewServer()
+	sdsapi.RegisterSecretDiscoveryServiceServer(grpcServer, server)
+	go grpcServer.Serve(ln)
+	defer grpcServer.Stop()
+
+	oldTimeout, oldInterval := sdsFetchTimeout, sdsReconnectInterval
+	sdsFetchTimeout = 5 * time.Second
+	sdsReconnectInterval = 10 * time.Millisecond
+	defer func() {
+		sdsFetchTimeout, sdsReconnectInterval = oldTimeout, oldInterval
+	}()
+
+	factory := &sdsFactory{}
+	hooks := factory.CreateConfigHooks(map[string]interface{}{
+		"sds_endpoint": "unix://" + socket,
+	}).(*sdsHooks)
+
+	cert, err := hooks.GetCertificate("", "")
+	if err != nil {
+		t.Fatalf("get certificate from sds failed: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("delivered certificate invalid: %v", err)
+	}
+	if leaf.Subject.CommonName != "mosn-test" {
+		t.Errorf("unexpected certificate subject: %s", leaf.Subject.CommonName)
+	}
+
+	pool, err := hooks.GetX509Pool("")
+	if err != nil {
+		t.Fatalf("get validation context from sds failed: %v", err)
+	}
+	if pool == nil || len(pool.Subjects()) != 1 {
+		t.Errorf("unexpected trusted ca pool: %v", pool)
+	}
+
+	// the secrets stay in memory, no key material on disk
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 1 { // the socket only
+		t.Errorf("unexpected files written: %d", len(files))
+	}
+
+	// push a rotated certificate, the hooks serve it without reconnecting
+	rotated, _ := certificateSecret(t, "default", "mosn-rotated")
+	server.push <- rotated
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		cert, err := hooks.GetCertificate("", "")
+		if err != nil {
+			t.Fatalf("get rotated certificate failed: %v", err)
+		}
+		leaf, _ := x509.ParseCertificate(cert.Certificate[0])
+		if leaf != nil && leaf.Subject.CommonName == "mosn-rotated" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("certificate not rotated in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
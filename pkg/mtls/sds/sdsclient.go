@@ -0,0 +1,151 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sds
+
+import (
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/utils"
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	auth "github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
+	envoy_api_v2_core1 "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	sdsapi "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// SecretTypeURL is the type url of envoy auth secrets
+const SecretTypeURL = "type.googleapis.com/envoy.api.v2.auth.Secret"
+
+// sdsReconnectInterval is the wait before the stream is re-established
+var sdsReconnectInterval = time.Second
+
+// sdsSubscriber subscribes secrets by name on a secret discovery stream
+// and pushes every accepted secret to the update callback, it reconnects
+// forever so certificate rotation keeps working after control plane restarts
+type sdsSubscriber struct {
+	endpoint string
+	names    []string
+	onUpdate func(name string, secret *auth.Secret)
+	stopChan chan struct{}
+}
+
+func newSdsSubscriber(endpoint string, names []string, onUpdate func(name string, secret *auth.Secret)) *sdsSubscriber {
+	return &sdsSubscriber{
+		endpoint: endpoint,
+		names:    names,
+		onUpdate: onUpdate,
+		stopChan: make(chan struct{}),
+	}
+}
+
+func (s *sdsSubscriber) start() {
+	utils.GoWithRecover(func() {
+		s.loop()
+	}, nil)
+}
+
+func (s *sdsSubscriber) stop() {
+	close(s.stopChan)
+}
+
+func (s *sdsSubscriber) loop() {
+	for {
+		select {
+		case <-s.stopChan:
+			return
+		default:
+		}
+		if err := s.streamSecrets(); err != nil {
+			log.DefaultLogger.Warnf("[mtls] [sds] stream secrets from %s failed: %v, retry after %v", s.endpoint, err, sdsReconnectInterval)
+		}
+		time.Sleep(sdsReconnectInterval)
+	}
+}
+
+func (s *sdsSubscriber) streamSecrets() error {
+	conn, err := dialSds(s.endpoint)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := sdsapi.NewSecretDiscoveryServiceClient(conn).StreamSecrets(ctx)
+	if err != nil {
+		return err
+	}
+	version, nonce := "", ""
+	if err := s.sendRequest(stream, version, nonce); err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-s.stopChan:
+			return nil
+		default:
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		for _, res := range resp.Resources {
+			secret := &auth.Secret{}
+			if err := secret.Unmarshal(res.GetValue()); err != nil {
+				log.DefaultLogger.Errorf("[mtls] [sds] unmarshal secret failed: %v", err)
+				continue
+			}
+			s.onUpdate(secret.Name, secret)
+		}
+		// ack the response
+		version, nonce = resp.VersionInfo, resp.Nonce
+		if err := s.sendRequest(stream, version, nonce); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *sdsSubscriber) sendRequest(stream sdsapi.SecretDiscoveryService_StreamSecretsClient, version, nonce string) error {
+	node, _ := os.Hostname()
+	return stream.Send(&envoy_api_v2.DiscoveryRequest{
+		VersionInfo:   version,
+		ResourceNames: s.names,
+		TypeUrl:       SecretTypeURL,
+		ResponseNonce: nonce,
+		Node: &envoy_api_v2_core1.Node{
+			Id: node,
+		},
+	})
+}
+
+// dialSds connects the sds server, a "unix://" prefixed endpoint uses a
+// unix domain socket, anything else is treated as a tcp address
+func dialSds(endpoint string) (*grpc.ClientConn, error) {
+	if strings.HasPrefix(endpoint, "unix://") {
+		path := strings.TrimPrefix(endpoint, "unix://")
+		return grpc.Dial(path, grpc.WithInsecure(),
+			grpc.WithDialer(func(addr string, timeout time.Duration) (net.Conn, error) {
+				return net.DialTimeout("unix", addr, timeout)
+			}))
+	}
+	return grpc.Dial(endpoint, grpc.WithInsecure())
+}
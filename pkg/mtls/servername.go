@@ -0,0 +1,67 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mtls
+
+import (
+	"net"
+
+	"sofastack.io/sofa-mosn/pkg/mtls/crypto/tls"
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+// serverNameContextManager wraps a client context manager and overrides
+// the SNI / certificate verification name. Hosts discovered with their
+// own hostname (e.g. dns endpoints) verify against that name instead of
+// a single cluster-wide server name.
+type serverNameContextManager struct {
+	types.TLSContextManager
+	serverName string
+}
+
+// NewServerNameContextManager returns a client tls context manager whose
+// connections use serverName for SNI and certificate verification, the
+// wrapped manager is returned unchanged when there is nothing to override
+func NewServerNameContextManager(mng types.TLSContextManager, serverName string) types.TLSContextManager {
+	if mng == nil || !mng.Enabled() || serverName == "" {
+		return mng
+	}
+	return &serverNameContextManager{
+		TLSContextManager: mng,
+		serverName:        serverName,
+	}
+}
+
+func (mng *serverNameContextManager) Config() *tls.Config {
+	config := mng.TLSContextManager.Config()
+	if config == nil {
+		return nil
+	}
+	// client managers return a clone, overriding is connection local
+	config.ServerName = mng.serverName
+	return config
+}
+
+func (mng *serverNameContextManager) Conn(c net.Conn) net.Conn {
+	if _, ok := c.(*net.TCPConn); !ok {
+		return c
+	}
+	if !mng.Enabled() {
+		return c
+	}
+	return getTLSConn(c, mng.Config(), true)
+}
@@ -0,0 +1,59 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mtls
+
+import (
+	"testing"
+
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+)
+
+func TestServerNameContextManager(t *testing.T) {
+	cfg := &v2.TLSConfig{
+		Status:       true,
+		ServerName:   "cluster.example.com",
+		InsecureSkip: true,
+	}
+	cltMng, err := NewTLSClientContextManager(cfg, nil)
+	if err != nil {
+		t.Fatalf("create client context manager failed %v", err)
+	}
+	wrapped := NewServerNameContextManager(cltMng, "host1.example.com")
+	if wrapped == cltMng {
+		t.Fatal("expected a wrapping manager")
+	}
+	if sn := wrapped.Config().ServerName; sn != "host1.example.com" {
+		t.Errorf("expected overridden server name, got %s", sn)
+	}
+	// the wrapped manager stays untouched, other hosts keep the cluster name
+	if sn := cltMng.Config().ServerName; sn != "cluster.example.com" {
+		t.Errorf("cluster manager should keep its server name, got %s", sn)
+	}
+
+	// nothing to override returns the original manager
+	if NewServerNameContextManager(cltMng, "") != cltMng {
+		t.Error("empty server name should not wrap")
+	}
+	if NewServerNameContextManager(nil, "host1.example.com") != nil {
+		t.Error("nil manager should stay nil")
+	}
+	disabled, _ := NewTLSClientContextManager(&v2.TLSConfig{}, nil)
+	if NewServerNameContextManager(disabled, "host1.example.com") != disabled {
+		t.Error("disabled manager should not wrap")
+	}
+}
@@ -0,0 +1,169 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package spiffe integrates mosn with a spiffe/spire deployment. SVIDs are
+// obtained from the spire agent workload endpoint, which serves the envoy
+// sds api over the workload unix domain socket, and peer certificates are
+// verified to carry an allowed spiffe id. Renewed svids are pushed on the
+// same stream, so rotation works without a restart.
+//
+// Enabled by a tls config with type "spiffe", e.g.
+//
+//	"tls_context": {
+//	    "status": true,
+//	    "type": "spiffe",
+//	    "verify_client": true,
+//	    "extend_verify": {
+//	        "workload_api_socket": "unix:///tmp/spire-agent/public/api.sock",
+//	        "trust_domain": "example.org",
+//	        "match_spiffe_ids": [ "spiffe://example.org/ns/default/sa/*" ]
+//	    }
+//	}
+package spiffe
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net/url"
+	"path"
+
+	"sofastack.io/sofa-mosn/pkg/mtls"
+	"sofastack.io/sofa-mosn/pkg/mtls/sds"
+)
+
+func init() {
+	mtls.Register("spiffe", &spiffeFactory{})
+}
+
+// defaultWorkloadSocket is the default spire agent workload endpoint
+const defaultWorkloadSocket = "unix:///tmp/spire-agent/public/api.sock"
+
+type spiffeFactory struct{}
+
+func (f *spiffeFactory) CreateConfigHooks(config map[string]interface{}) mtls.ConfigHooks {
+	endpoint := getString(config, "workload_api_socket")
+	if endpoint == "" {
+		endpoint = defaultWorkloadSocket
+	}
+	return &spiffeHooks{
+		ConfigHooks: sds.NewConfigHooks(map[string]interface{}{
+			"sds_endpoint": endpoint,
+		}),
+		trustDomain: getString(config, "trust_domain"),
+		matchIDs:    getStrings(config, "match_spiffe_ids"),
+	}
+}
+
+// spiffeHooks serves svids delivered by the workload api and replaces the
+// standard certificate verification with a spiffe id check
+type spiffeHooks struct {
+	mtls.ConfigHooks
+	trustDomain string
+	matchIDs    []string
+}
+
+// VerifyPeerCertificate verifies the peer chain against the workload api
+// trust bundle and requires the leaf to carry an allowed spiffe id
+func (h *spiffeHooks) VerifyPeerCertificate() func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return fmt.Errorf("spiffe: no peer certificate")
+		}
+		certs := make([]*x509.Certificate, 0, len(rawCerts))
+		for _, raw := range rawCerts {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return fmt.Errorf("spiffe: parse peer certificate failed: %v", err)
+			}
+			certs = append(certs, cert)
+		}
+		pool, err := h.ConfigHooks.GetX509Pool("")
+		if err != nil {
+			return fmt.Errorf("spiffe: get trust bundle failed: %v", err)
+		}
+		opts := x509.VerifyOptions{
+			Roots:         pool,
+			Intermediates: x509.NewCertPool(),
+			// svids are used for both client and server auth
+			KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		}
+		for _, cert := range certs[1:] {
+			opts.Intermediates.AddCert(cert)
+		}
+		if _, err := certs[0].Verify(opts); err != nil {
+			return fmt.Errorf("spiffe: verify peer certificate failed: %v", err)
+		}
+		id, err := peerSpiffeID(certs[0])
+		if err != nil {
+			return err
+		}
+		return h.checkID(id)
+	}
+}
+
+// peerSpiffeID extracts the spiffe id of a leaf certificate, a valid svid
+// carries exactly one uri san with the spiffe scheme
+func peerSpiffeID(leaf *x509.Certificate) (*url.URL, error) {
+	if len(leaf.URIs) != 1 {
+		return nil, fmt.Errorf("spiffe: peer certificate has %d uri sans, expect exactly one", len(leaf.URIs))
+	}
+	id := leaf.URIs[0]
+	if id.Scheme != "spiffe" {
+		return nil, fmt.Errorf("spiffe: peer certificate uri san %s is not a spiffe id", id)
+	}
+	return id, nil
+}
+
+func (h *spiffeHooks) checkID(id *url.URL) error {
+	if h.trustDomain != "" && id.Host != h.trustDomain {
+		return fmt.Errorf("spiffe: peer id %s is not in trust domain %s", id, h.trustDomain)
+	}
+	if len(h.matchIDs) == 0 {
+		return nil
+	}
+	for _, pattern := range h.matchIDs {
+		// path.Match wildcards do not cross '/', so a pattern matches
+		// within a single workload path segment
+		if ok, err := path.Match(pattern, id.String()); err == nil && ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("spiffe: peer id %s matches none of the configured patterns", id)
+}
+
+func getString(config map[string]interface{}, key string) string {
+	if v, ok := config[key]; ok {
+		if s, ok := v.(string); ok {
+			return s
+		}
+	}
+	return ""
+}
+
+func getStrings(config map[string]interface{}, key string) []string {
+	var result []string
+	if v, ok := config[key]; ok {
+		if list, ok := v.([]interface{}); ok {
+			for _, item := range list {
+				if s, ok := item.(string); ok {
+					result = append(result, s)
+				}
+			}
+		}
+	}
+	return result
+}
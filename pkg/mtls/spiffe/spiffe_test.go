@@ -0,0 +1,174 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spiffe
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/url"
+	"testing"
+	"time"
+
+	"sofastack.io/sofa-mosn/pkg/mtls"
+)
+
+// poolHooks stubs the sds backed hooks with a fixed trust bundle
+type poolHooks struct {
+	mtls.ConfigHooks
+	pool *x509.CertPool
+}
+
+func (h *poolHooks) GetX509Pool(caIndex string) (*x509.CertPool, error) {
+	return h.pool, nil
+}
+
+// svidCert creates a self signed certificate carrying the given uri sans
+func svidCert(t *testing.T, uris ...string) ([]byte, *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "svid"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+	}
+	for _, u := range uris {
+		parsed, err := url.Parse(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tmpl.URIs = append(tmpl.URIs, parsed)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return der, pool
+}
+
+func TestVerifyPeerSpiffeID(t *testing.T) {
+	testCases := []struct {
+		name        string
+		uris        []string
+		trustDomain string
+		matchIDs    []string
+		valid       bool
+	}{
+		{
+			name:  "no restrictions accepts any spiffe id",
+			uris:  []string{"spiffe://example.org/ns/default/sa/demo"},
+			valid: true,
+		},
+		{
+			name:        "trust domain match",
+			uris:        []string{"spiffe://example.org/ns/default/sa/demo"},
+			trustDomain: "example.org",
+			valid:       true,
+		},
+		{
+			name:        "trust domain mismatch",
+			uris:        []string{"spiffe://other.org/ns/default/sa/demo"},
+			trustDomain: "example.org",
+			valid:       false,
+		},
+		{
+			name:     "pattern match",
+			uris:     []string{"spiffe://example.org/ns/default/sa/demo"},
+			matchIDs: []string{"spiffe://example.org/ns/default/sa/*"},
+			valid:    true,
+		},
+		{
+			name:     "pattern does not cross path segments",
+			uris:     []string{"spiffe://example.org/ns/other/sa/demo"},
+			matchIDs: []string{"spiffe://example.org/ns/default/sa/*"},
+			valid:    false,
+		},
+		{
+			name:  "no uri san",
+			uris:  nil,
+			valid: false,
+		},
+		{
+			name:  "non spiffe uri san",
+			uris:  []string{"https://example.org/whatever"},
+			valid: false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			der, pool := svidCert(t, tc.uris...)
+			hooks := &spiffeHooks{
+				ConfigHooks: &poolHooks{pool: pool},
+				trustDomain: tc.trustDomain,
+				matchIDs:    tc.matchIDs,
+			}
+			err := hooks.VerifyPeerCertificate()([][]byte{der}, nil)
+			if tc.valid && err != nil {
+				t.Errorf("expected valid peer, got %v", err)
+			}
+			if !tc.valid && err == nil {
+				t.Errorf("expected verification failure")
+			}
+		})
+	}
+}
+
+func TestVerifyPeerUntrustedChain(t *testing.T) {
+	der, _ := svidCert(t, "spiffe://example.org/ns/default/sa/demo")
+	// trust bundle of another issuer
+	_, pool := svidCert(t, "spiffe://example.org/ns/default/sa/other")
+	hooks := &spiffeHooks{ConfigHooks: &poolHooks{pool: pool}}
+	if err := hooks.VerifyPeerCertificate()([][]byte{der}, nil); err == nil {
+		t.Errorf("expected chain verification failure")
+	}
+}
+
+func TestCreateConfigHooks(t *testing.T) {
+	f := &spiffeFactory{}
+	hooks := f.CreateConfigHooks(map[string]interface{}{
+		"trust_domain":     "example.org",
+		"match_spiffe_ids": []interface{}{"spiffe://example.org/a", "spiffe://example.org/b"},
+	})
+	h, ok := hooks.(*spiffeHooks)
+	if !ok {
+		t.Fatal("expected spiffe hooks")
+	}
+	if h.trustDomain != "example.org" {
+		t.Errorf("trust domain not parsed, got %s", h.trustDomain)
+	}
+	if len(h.matchIDs) != 2 {
+		t.Errorf("match ids not parsed, got %v", h.matchIDs)
+	}
+}
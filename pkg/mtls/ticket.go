@@ -0,0 +1,153 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mtls
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"sofastack.io/sofa-mosn/pkg/admin/server"
+	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/mtls/crypto/tls"
+	"sofastack.io/sofa-mosn/pkg/utils"
+)
+
+func init() {
+	server.RegisterAdminHandleFunc("/api/v1/tls_ticket_rotate", rotateTicketKeys)
+}
+
+// TicketRotationInterval is the period after which a fresh session ticket
+// key is derived, a variable so tests can shorten it
+var TicketRotationInterval = 6 * time.Hour
+
+// ticketRotator derives session ticket keys from the ticket seed of a tls
+// config and rotates them periodically. Keys are a deterministic function
+// of seed and time, so a fleet of instances sharing a seed accepts each
+// other's session tickets and stateless resumption survives restarts
+type ticketRotator struct {
+	mux     sync.Mutex
+	configs map[*tls.Config]string // server tls config -> ticket seed
+	epoch   int64
+	// generation is bumped by the admin rotate endpoint to force new keys
+	// before the interval elapses, e.g. after a suspected seed leak
+	generation uint64
+	started    bool
+}
+
+var ticketKeys = &ticketRotator{configs: make(map[*tls.Config]string)}
+
+// registerTicketSeed derives and installs the current session ticket keys
+// and keeps the config registered for periodic rotation. Stale configs of
+// rebuilt contexts stay registered, rotating them is harmless
+func registerTicketSeed(config *tls.Config, seed string) {
+	t := ticketKeys
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	t.configs[config] = seed
+	t.epoch = currentEpoch()
+	config.SetSessionTicketKeys(deriveTicketKeys(seed, t.epoch, t.generation))
+	t.startLocked()
+}
+
+func (t *ticketRotator) startLocked() {
+	if t.started {
+		return
+	}
+	t.started = true
+	utils.GoWithRecover(t.loop, func(r interface{}) {
+		utils.GoWithRecover(t.loop, nil)
+	})
+}
+
+func (t *ticketRotator) loop() {
+	for {
+		time.Sleep(time.Minute)
+		t.mux.Lock()
+		if epoch := currentEpoch(); epoch != t.epoch {
+			t.epoch = epoch
+			t.applyLocked()
+		}
+		t.mux.Unlock()
+	}
+}
+
+// rotateNow forces new keys without waiting for the epoch boundary and
+// returns the number of rotated configs
+func (t *ticketRotator) rotateNow() int {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	t.generation++
+	t.epoch = currentEpoch()
+	t.applyLocked()
+	return len(t.configs)
+}
+
+func (t *ticketRotator) applyLocked() {
+	for config, seed := range t.configs {
+		config.SetSessionTicketKeys(deriveTicketKeys(seed, t.epoch, t.generation))
+	}
+	log.DefaultLogger.Infof("[mtls] [ticket] session ticket keys rotated, epoch %d generation %d, %d configs", t.epoch, t.generation, len(t.configs))
+}
+
+func currentEpoch() int64 {
+	return time.Now().Unix() / int64(TicketRotationInterval/time.Second)
+}
+
+// deriveTicketKeys returns the accepted ticket keys, newest first. The
+// previous epoch and the previous generation stay accepted so resumption
+// keeps working across a rotation
+func deriveTicketKeys(seed string, epoch int64, generation uint64) [][32]byte {
+	keys := [][32]byte{
+		deriveTicketKey(seed, epoch, generation),
+		deriveTicketKey(seed, epoch-1, generation),
+	}
+	if generation > 0 {
+		keys = append(keys, deriveTicketKey(seed, epoch, generation-1))
+	}
+	return keys
+}
+
+func deriveTicketKey(seed string, epoch int64, generation uint64) [32]byte {
+	h := hmac.New(sha256.New, []byte(seed))
+	var buf [16]byte
+	binary.BigEndian.PutUint64(buf[:8], uint64(epoch))
+	binary.BigEndian.PutUint64(buf[8:], generation)
+	h.Write(buf[:])
+	var key [32]byte
+	copy(key[:], h.Sum(nil))
+	return key
+}
+
+// rotateTicketKeys is the admin endpoint forcing an immediate session
+// ticket key rotation on every registered server tls config
+func rotateTicketKeys(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	rotated := ticketKeys.rotateNow()
+	log.DefaultLogger.Infof("[admin api] [tls ticket rotate] rotated %d tls configs", rotated)
+	w.WriteHeader(http.StatusOK)
+	buf, _ := json.Marshal(map[string]int{"rotated": rotated})
+	w.Write(buf)
+}
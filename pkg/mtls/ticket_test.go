@@ -0,0 +1,69 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mtls
+
+import (
+	"testing"
+
+	"sofastack.io/sofa-mosn/pkg/mtls/crypto/tls"
+)
+
+func TestDeriveTicketKeys(t *testing.T) {
+	// instances sharing a seed derive identical keys
+	k1 := deriveTicketKeys("seed", 100, 0)
+	k2 := deriveTicketKeys("seed", 100, 0)
+	if len(k1) != 2 || k1[0] != k2[0] || k1[1] != k2[1] {
+		t.Errorf("same seed and epoch should derive identical keys")
+	}
+	// a new epoch keeps the previous key as fallback
+	next := deriveTicketKeys("seed", 101, 0)
+	if next[1] != k1[0] {
+		t.Errorf("previous epoch key should stay accepted after rotation")
+	}
+	if next[0] == k1[0] {
+		t.Errorf("new epoch should derive a new primary key")
+	}
+	// different seeds never share keys
+	other := deriveTicketKeys("other", 100, 0)
+	if other[0] == k1[0] {
+		t.Errorf("different seeds must derive different keys")
+	}
+	// a forced rotation keeps the previous generation accepted
+	forced := deriveTicketKeys("seed", 100, 1)
+	if len(forced) != 3 || forced[2] != k1[0] {
+		t.Errorf("previous generation key should stay accepted after a forced rotation")
+	}
+}
+
+func TestTicketRotateNow(t *testing.T) {
+	config := &tls.Config{}
+	registerTicketSeed(config, "unit-test-seed")
+	defer func() {
+		ticketKeys.mux.Lock()
+		delete(ticketKeys.configs, config)
+		ticketKeys.mux.Unlock()
+	}()
+
+	gen := ticketKeys.generation
+	if rotated := ticketKeys.rotateNow(); rotated < 1 {
+		t.Errorf("expected at least the registered config rotated, got %d", rotated)
+	}
+	if ticketKeys.generation != gen+1 {
+		t.Errorf("forced rotation should bump the generation")
+	}
+}
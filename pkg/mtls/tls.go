@@ -24,8 +24,10 @@ import (
 	"net"
 	"strings"
 
+	gometrics "github.com/rcrowley/go-metrics"
 	"sofastack.io/sofa-mosn/pkg/api/v2"
 	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/metrics"
 	"sofastack.io/sofa-mosn/pkg/mtls/crypto/tls"
 	"sofastack.io/sofa-mosn/pkg/types"
 )
@@ -72,6 +74,9 @@ type contextManager struct {
 	inspector bool
 	listener  types.Listener
 	server    *tls.Config
+	// renegotiationRejected counts refused renegotiation attempts, a
+	// burst usually points at an old java client retrying in a loop
+	renegotiationRejected gometrics.Counter
 	//	mutex sync.RWMutex
 }
 
@@ -89,6 +94,9 @@ func NewTLSServerContextManager(config *v2.Listener, l types.Listener, logger lo
 	mgr.server = &tls.Config{
 		GetConfigForClient: mgr.GetConfigForClient,
 	}
+	if l != nil {
+		mgr.renegotiationRejected = metrics.NewListenerStats(l.Name()).Counter(metrics.DownstreamTLSRenegotiationRejected)
+	}
 	for _, c := range config.FilterChains {
 		for _, tlsCfg := range c.TLSContexts {
 			if err := mgr.AddContext(&tlsCfg); err != nil {
@@ -166,6 +174,28 @@ func (mgr *contextManager) newTLSConfig(c *v2.TLSConfig) (*tls.Config, error) {
 			tlsConfig.MinVersion = protocol
 		}
 	}
+	switch strings.ToLower(c.Renegotiation) {
+	case "", "never":
+		tlsConfig.Renegotiation = tls.RenegotiateNever
+	case "once":
+		if !mgr.isClient {
+			return nil, errors.New("server side renegotiation is not supported")
+		}
+		tlsConfig.Renegotiation = tls.RenegotiateOnceAsClient
+	case "freely":
+		if !mgr.isClient {
+			return nil, errors.New("server side renegotiation is not supported")
+		}
+		tlsConfig.Renegotiation = tls.RenegotiateFreelyAsClient
+	default:
+		return nil, fmt.Errorf("renegotiation policy %s is not supported", c.Renegotiation)
+	}
+	if !mgr.isClient && mgr.renegotiationRejected != nil {
+		counter := mgr.renegotiationRejected
+		tlsConfig.RenegotiationRejectedCallback = func() {
+			counter.Inc(1)
+		}
+	}
 	if c.ALPN != "" {
 		protocols := strings.Split(c.ALPN, ",")
 		for _, p := range protocols {
@@ -183,6 +213,9 @@ func (mgr *contextManager) newTLSConfig(c *v2.TLSConfig) (*tls.Config, error) {
 			return nil, ErrorGetCertificateFailed
 		}
 	case nil:
+		if err := attachOCSPStaple(&cert, c); err != nil {
+			return nil, err
+		}
 		tlsConfig.Certificates = append(tlsConfig.Certificates, cert)
 	default: //other error
 		return nil, ErrorGetCertificateFailed
@@ -232,6 +265,11 @@ func (mgr *contextManager) AddContext(c *v2.TLSConfig) error {
 		}
 		return err
 	}
+	if !mgr.isClient && c.Ticket != "" {
+		// the ticket seed enables stateless resumption with rotating keys,
+		// instances sharing a seed accept each other's session tickets
+		registerTicketSeed(tlsConfig, c.Ticket)
+	}
 	ctx := &context{
 		listener:   mgr.listener,
 		serverName: c.ServerName,
@@ -318,18 +356,38 @@ func (mgr *contextManager) Conn(c net.Conn) net.Conn {
 	conn := &Conn{
 		Conn: c,
 	}
-	buf := conn.Peek()
+	buf := conn.Peek(tlsInspectLen)
 	if buf == nil {
 		return getTLSConn(conn, mgr.Config(), mgr.isClient)
 	}
-	switch buf[0] {
-	// TLS handshake
-	case 0x16:
+	if isTLSClientHello(buf) {
 		return getTLSConn(conn, mgr.Config(), mgr.isClient)
-	// Non TLS
-	default:
-		return conn
 	}
+	// Non TLS
+	return conn
+}
+
+// tlsInspectLen covers the 5 byte tls record header plus the handshake
+// message type, enough to tell a ClientHello from plaintext
+const tlsInspectLen = 6
+
+// isTLSClientHello reports whether buf starts a tls handshake. A full
+// check needs tlsInspectLen bytes, with a shorter first segment the
+// record header alone decides
+func isTLSClientHello(buf []byte) bool {
+	// content type handshake
+	if buf[0] != 0x16 {
+		return false
+	}
+	// legacy record version 0x03 0x00 - 0x03 0x04
+	if len(buf) >= 3 && (buf[1] != 0x03 || buf[2] > 0x04) {
+		return false
+	}
+	// the first handshake message a client sends is ClientHello
+	if len(buf) >= tlsInspectLen && buf[5] != 0x01 {
+		return false
+	}
+	return true
 }
 
 func getTLSConn(c net.Conn, config *tls.Config, isClient bool) net.Conn {
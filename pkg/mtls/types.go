@@ -45,10 +45,11 @@ var (
 		tls.CurveP256,
 	}
 	allCurves = map[string]tls.CurveID{
-		"x25519": tls.X25519,
-		"p256":   tls.CurveP256,
-		"p384":   tls.CurveP384,
-		"p521":   tls.CurveP521,
+		"x25519":  tls.X25519,
+		"p256":    tls.CurveP256,
+		"p384":    tls.CurveP384,
+		"p521":    tls.CurveP521,
+		"sm2p256": tls.CurveSM2,
 	}
 )
 
@@ -90,6 +91,8 @@ var (
 		"RSA-AES128-CBC-SHA":                 tls.TLS_RSA_WITH_AES_128_CBC_SHA,
 		"ECDHE-RSA-3DES-EDE-CBC-SHA":         tls.TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA,
 		"RSA-3DES-EDE-CBC-SHA":               tls.TLS_RSA_WITH_3DES_EDE_CBC_SHA,
+		// GM/T 0024 national crypto suite, requires an sm2p256 ecdh curve
+		"ECDHE-SM2-SM4-CBC-SM3": tls.TLS_ECDHE_SM2_WITH_SM4_SM3,
 	}
 )
 
@@ -0,0 +1,101 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package network
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+)
+
+// ParseTCPAddress resolves a "host:port" string into a TCP address. On top
+// of the standard resolver it accepts unbracketed IPv6 literals such as
+// "2001:db8::1:8080" by treating the last colon as the port separator
+func ParseTCPAddress(address string) (*net.TCPAddr, error) {
+	addr, err := net.ResolveTCPAddr("tcp", address)
+	if err == nil {
+		return addr, nil
+	}
+	if idx := strings.LastIndexByte(address, ':'); idx > 0 &&
+		strings.Count(address, ":") > 1 && !strings.Contains(address, "[") {
+		if ip := net.ParseIP(address[:idx]); ip != nil {
+			return net.ResolveTCPAddr("tcp", net.JoinHostPort(address[:idx], address[idx+1:]))
+		}
+	}
+	return nil, err
+}
+
+// nat64Prefix holds the synthesis prefix when nat64 mode is enabled,
+// guarded by nat64Mutex since hosts are created from several goroutines
+var (
+	nat64Mutex  sync.RWMutex
+	nat64Prefix net.IP
+)
+
+// ParseNAT64Prefix validates a NAT64 synthesis prefix, per RFC 6052 it
+// must be an IPv6 /96, e.g. the well-known "64:ff9b::/96"
+func ParseNAT64Prefix(prefix string) (net.IP, error) {
+	ip, ipNet, err := net.ParseCIDR(prefix)
+	if err != nil {
+		return nil, err
+	}
+	if ones, bits := ipNet.Mask.Size(); bits != 128 || ones != 96 {
+		return nil, fmt.Errorf("nat64 prefix must be an ipv6 /96, got %s", prefix)
+	}
+	return ip.To16(), nil
+}
+
+// SetNAT64Prefix enables NAT64 address synthesis with the given prefix,
+// an empty prefix disables it
+func SetNAT64Prefix(prefix string) error {
+	if prefix == "" {
+		nat64Mutex.Lock()
+		nat64Prefix = nil
+		nat64Mutex.Unlock()
+		return nil
+	}
+	ip, err := ParseNAT64Prefix(prefix)
+	if err != nil {
+		return err
+	}
+	nat64Mutex.Lock()
+	nat64Prefix = ip
+	nat64Mutex.Unlock()
+	return nil
+}
+
+// SynthesizeNAT64 embeds an IPv4 address into the configured NAT64 prefix
+// so IPv6-only nodes can reach IPv4-only upstreams. Addresses pass through
+// unchanged when synthesis is disabled or the address is already IPv6
+func SynthesizeNAT64(addr *net.TCPAddr) *net.TCPAddr {
+	nat64Mutex.RLock()
+	prefix := nat64Prefix
+	nat64Mutex.RUnlock()
+	if prefix == nil || addr == nil {
+		return addr
+	}
+	v4 := addr.IP.To4()
+	if v4 == nil {
+		return addr
+	}
+	ip := make(net.IP, net.IPv6len)
+	copy(ip, prefix)
+	copy(ip[12:], v4)
+	return &net.TCPAddr{IP: ip, Port: addr.Port, Zone: addr.Zone}
+}
@@ -0,0 +1,87 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package network
+
+import (
+	"net"
+	"testing"
+)
+
+func TestParseTCPAddress(t *testing.T) {
+	cases := []struct {
+		address string
+		ip      string
+		port    int
+		valid   bool
+	}{
+		{"127.0.0.1:8080", "127.0.0.1", 8080, true},
+		{"[2001:db8::1]:8080", "2001:db8::1", 8080, true},
+		{"2001:db8::1:8080", "2001:db8::1", 8080, true},
+		{"[::]:80", "::", 80, true},
+		{"127.0.0.1", "", 0, false},
+		{"nonsense", "", 0, false},
+	}
+	for _, c := range cases {
+		addr, err := ParseTCPAddress(c.address)
+		if c.valid != (err == nil) {
+			t.Errorf("%q: unexpected error state: %v", c.address, err)
+			continue
+		}
+		if !c.valid {
+			continue
+		}
+		if !addr.IP.Equal(net.ParseIP(c.ip)) || addr.Port != c.port {
+			t.Errorf("%q: expected %s:%d, got %v", c.address, c.ip, c.port, addr)
+		}
+	}
+}
+
+func TestNAT64Synthesis(t *testing.T) {
+	if err := SetNAT64Prefix("64:ff9b::/96"); err != nil {
+		t.Fatalf("set prefix failed: %v", err)
+	}
+	defer SetNAT64Prefix("")
+
+	v4 := &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 80}
+	mapped := SynthesizeNAT64(v4)
+	if !mapped.IP.Equal(net.ParseIP("64:ff9b::192.0.2.1")) || mapped.Port != 80 {
+		t.Errorf("unexpected synthesized address: %v", mapped)
+	}
+
+	// ipv6 upstreams pass through unchanged
+	v6 := &net.TCPAddr{IP: net.ParseIP("2001:db8::1"), Port: 80}
+	if passed := SynthesizeNAT64(v6); !passed.IP.Equal(v6.IP) {
+		t.Errorf("ipv6 address rewritten: %v", passed)
+	}
+
+	SetNAT64Prefix("")
+	if passed := SynthesizeNAT64(v4); !passed.IP.Equal(v4.IP) {
+		t.Errorf("synthesis still active after disable: %v", passed)
+	}
+}
+
+func TestParseNAT64Prefix(t *testing.T) {
+	if _, err := ParseNAT64Prefix("64:ff9b::/96"); err != nil {
+		t.Errorf("well-known prefix rejected: %v", err)
+	}
+	for _, bad := range []string{"64:ff9b::/64", "10.0.0.0/8", "not-a-prefix"} {
+		if _, err := ParseNAT64Prefix(bad); err == nil {
+			t.Errorf("%q: invalid prefix accepted", bad)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package network
+
+import (
+	"sync"
+	"time"
+
+	"sofastack.io/sofa-mosn/pkg/log"
+)
+
+// Connect failure fast blacklisting.
+//
+// When connections to an upstream address fail repeatedly within a window,
+// the address is temporarily blacklisted at the connection pool level,
+// independent of health checking, so the load balancer stops paying the
+// connect-timeout latency on a clearly dead endpoint. The blackout duration
+// grows exponentially on consecutive ejections and after it expires a single
+// probe connection is let through to detect recovery.
+
+const (
+	// blacklistFailureThreshold is the count of connect failures within
+	// blacklistFailureWindow that triggers an ejection
+	blacklistFailureThreshold = 3
+	blacklistFailureWindow    = 10 * time.Second
+
+	// blackout duration is blacklistBaseBlackout << (ejections-1), capped
+	// by blacklistMaxBlackout
+	blacklistBaseBlackout = 1 * time.Second
+	blacklistMaxBlackout  = 30 * time.Second
+)
+
+type blacklistEntry struct {
+	mux sync.Mutex
+
+	failures    int
+	windowStart time.Time
+
+	ejections        int
+	blacklistedUntil time.Time
+	probing          bool
+}
+
+var connectBlacklist sync.Map // address string -> *blacklistEntry
+
+func getBlacklistEntry(address string) *blacklistEntry {
+	if v, ok := connectBlacklist.Load(address); ok {
+		return v.(*blacklistEntry)
+	}
+	v, _ := connectBlacklist.LoadOrStore(address, &blacklistEntry{})
+	return v.(*blacklistEntry)
+}
+
+// ReportConnectFailure records a connect failure for the address, and ejects
+// the address once failures reach the threshold within the window.
+func ReportConnectFailure(address string) {
+	entry := getBlacklistEntry(address)
+	entry.mux.Lock()
+	defer entry.mux.Unlock()
+
+	now := time.Now()
+
+	// a failed recovery probe re-ejects immediately with a longer blackout
+	if entry.probing {
+		entry.probing = false
+		entry.eject(address, now)
+		return
+	}
+
+	if now.Sub(entry.windowStart) > blacklistFailureWindow {
+		entry.windowStart = now
+		entry.failures = 0
+	}
+	entry.failures++
+
+	if entry.failures >= blacklistFailureThreshold {
+		entry.eject(address, now)
+	}
+}
+
+// eject is called with entry.mux held
+func (entry *blacklistEntry) eject(address string, now time.Time) {
+	entry.ejections++
+	blackout := blacklistBaseBlackout << uint(entry.ejections-1)
+	if blackout > blacklistMaxBlackout || blackout <= 0 {
+		blackout = blacklistMaxBlackout
+	}
+	entry.blacklistedUntil = now.Add(blackout)
+	entry.failures = 0
+	entry.windowStart = now
+
+	log.DefaultLogger.Warnf("[network] [blacklist] upstream address %s blacklisted for %v after repeated connect failures", address, blackout)
+}
+
+// ReportConnectSuccess clears the failure record of the address.
+func ReportConnectSuccess(address string) {
+	if v, ok := connectBlacklist.Load(address); ok {
+		entry := v.(*blacklistEntry)
+		entry.mux.Lock()
+		entry.failures = 0
+		entry.ejections = 0
+		entry.probing = false
+		entry.blacklistedUntil = time.Time{}
+		entry.mux.Unlock()
+	}
+}
+
+// IsAddressBlacklisted returns whether the address is currently ejected.
+// Once the blackout expires, a single caller gets false as a recovery probe,
+// the others keep the address blacklisted until the probe result is reported.
+func IsAddressBlacklisted(address string) bool {
+	v, ok := connectBlacklist.Load(address)
+	if !ok {
+		return false
+	}
+	entry := v.(*blacklistEntry)
+	entry.mux.Lock()
+	defer entry.mux.Unlock()
+
+	if entry.blacklistedUntil.IsZero() {
+		return false
+	}
+	if time.Now().Before(entry.blacklistedUntil) {
+		return true
+	}
+	// blackout expired, allow a single probe through
+	if entry.probing {
+		return true
+	}
+	entry.probing = true
+	return false
+}
@@ -0,0 +1,80 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package network
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConnectFailureBlacklist(t *testing.T) {
+	addr := "127.0.0.1:18080"
+
+	if IsAddressBlacklisted(addr) {
+		t.Fatal("address blacklisted without any failure")
+	}
+
+	// failures below the threshold should not eject
+	for i := 0; i < blacklistFailureThreshold-1; i++ {
+		ReportConnectFailure(addr)
+	}
+	if IsAddressBlacklisted(addr) {
+		t.Error("address blacklisted below failure threshold")
+	}
+
+	// reaching the threshold ejects the address
+	ReportConnectFailure(addr)
+	if !IsAddressBlacklisted(addr) {
+		t.Error("address not blacklisted after reaching failure threshold")
+	}
+
+	// a connect success clears the record
+	ReportConnectSuccess(addr)
+	if IsAddressBlacklisted(addr) {
+		t.Error("address still blacklisted after connect success")
+	}
+}
+
+func TestConnectFailureBlacklistRecoveryProbe(t *testing.T) {
+	addr := "127.0.0.2:18080"
+
+	for i := 0; i < blacklistFailureThreshold; i++ {
+		ReportConnectFailure(addr)
+	}
+	if !IsAddressBlacklisted(addr) {
+		t.Fatal("address not blacklisted after reaching failure threshold")
+	}
+	defer ReportConnectSuccess(addr)
+
+	// wait for the first blackout to expire
+	time.Sleep(blacklistBaseBlackout + 100*time.Millisecond)
+
+	// a single probe is let through, others keep blacklisted
+	if IsAddressBlacklisted(addr) {
+		t.Error("no recovery probe allowed after blackout expired")
+	}
+	if !IsAddressBlacklisted(addr) {
+		t.Error("more than one recovery probe allowed")
+	}
+
+	// a failed probe ejects immediately with a longer blackout
+	ReportConnectFailure(addr)
+	if !IsAddressBlacklisted(addr) {
+		t.Error("address not re-blacklisted after failed recovery probe")
+	}
+}
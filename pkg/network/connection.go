@@ -32,6 +32,7 @@ import (
 	"time"
 
 	"github.com/rcrowley/go-metrics"
+	"sofastack.io/sofa-mosn/pkg/api/v2"
 	"sofastack.io/sofa-mosn/pkg/buffer"
 	mosnctx "sofastack.io/sofa-mosn/pkg/context"
 	"sofastack.io/sofa-mosn/pkg/log"
@@ -67,6 +68,7 @@ type connection struct {
 	bytesReadCallbacks   []func(bytesRead uint64)
 	bytesSendCallbacks   []func(bytesSent uint64)
 	transferCallbacks    func() bool
+	drainCallbacks       func()
 	filterManager        types.FilterManager
 
 	stopChan           chan struct{}
@@ -275,6 +277,7 @@ func (c *connection) scheduleWrite() {
 
 func (c *connection) startReadLoop() {
 	var transferTime time.Time
+	var draining bool
 	for {
 		// exit loop asap. one receive & one default block will be optimized by go compiler
 		select {
@@ -290,6 +293,14 @@ func (c *connection) startReadLoop() {
 					randTime := time.Duration(rand.Intn(int(TransferTimeout.Nanoseconds())))
 					transferTime = time.Now().Add(TransferTimeout).Add(randTime)
 					log.DefaultLogger.Infof("[network] [read loop] transferTime: Wait %d Second", (TransferTimeout+randTime)/1e9)
+				} else if c.drainCallbacks != nil {
+					// the protocol cannot transfer the connection but supports a
+					// graceful close signal, drain in-flight streams before closing
+					draining = true
+					c.drainCallbacks()
+					transferTime = time.Now().Add(DrainTimeout)
+					log.DefaultLogger.Infof("[network] [read loop] graceful drain signaled, Connection = %d, Remote Address = %+v, close in %v",
+						c.id, c.RemoteAddr(), DrainTimeout)
 				} else {
 					// set a long time, not transfer connection, wait mosn exit.
 					transferTime = time.Now().Add(10 * TransferTimeout)
@@ -298,6 +309,10 @@ func (c *connection) startReadLoop() {
 				}
 			} else {
 				if transferTime.Before(time.Now()) {
+					if draining {
+						c.Close(types.FlushWrite, types.LocalClose)
+						return
+					}
 					goto transfer
 				}
 			}
@@ -798,10 +813,21 @@ func (c *connection) SetTransferEventListener(listener func() bool) {
 	c.transferCallbacks = listener
 }
 
+func (c *connection) SetGracefulDrainListener(listener func()) {
+	c.drainCallbacks = listener
+}
+
 type clientConnection struct {
 	connection
 
-	connectOnce sync.Once
+	connectOnce   sync.Once
+	socketOptions *v2.SocketOptions
+}
+
+// SetSocketOptions configures socket options applied at dial time,
+// must be called before Connect
+func (cc *clientConnection) SetSocketOptions(opts *v2.SocketOptions) {
+	cc.socketOptions = opts
 }
 
 // NewClientConnection new client-side connection
@@ -838,7 +864,21 @@ func (cc *clientConnection) Connect(ioEnabled bool) (err error) {
 	cc.connectOnce.Do(func() {
 		var event types.ConnectionEvent
 
-		cc.rawConnection, err = net.DialTimeout("tcp", cc.RemoteAddr().String(), time.Second*3)
+		// a connection transferred from the old process during hot upgrade
+		// is adopted instead of dialing, plain tcp upstreams only
+		if cc.tlsMng == nil || !cc.tlsMng.Enabled() {
+			if rawc := takeTransferredUpstreamConn(cc.RemoteAddr().String()); rawc != nil {
+				log.DefaultLogger.Infof("[network] [client connection connect] adopt transferred connection to %s", cc.remoteAddr.String())
+				cc.rawConnection = rawc
+			}
+		}
+		if cc.rawConnection == nil {
+			dialer := &net.Dialer{Timeout: time.Second * 3}
+			if cc.socketOptions != nil {
+				dialer.Control = SocketOptionsControl(cc.socketOptions, false)
+			}
+			cc.rawConnection, err = dialer.Dial("tcp", cc.RemoteAddr().String())
+		}
 
 		if err != nil {
 			if err == io.EOF {
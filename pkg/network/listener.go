@@ -24,12 +24,47 @@ import (
 	"runtime/debug"
 	"time"
 
+	gometrics "github.com/rcrowley/go-metrics"
 	"sofastack.io/sofa-mosn/pkg/api/v2"
 	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/metrics"
+	mosnsync "sofastack.io/sofa-mosn/pkg/sync"
 	"sofastack.io/sofa-mosn/pkg/types"
 	"sofastack.io/sofa-mosn/pkg/utils"
 )
 
+// accept pool overflow policies
+const (
+	AcceptOverflowQueue  = "queue"
+	AcceptOverflowReject = "reject"
+)
+
+// accept goroutine pool shared by all listeners, nil means a goroutine
+// is spawned per accepted connection
+var (
+	acceptPool           mosnsync.WorkerPool
+	acceptOverflowReject bool
+)
+
+// InitAcceptPool bounds the goroutines handling accepted connections,
+// called once at server startup before listeners start accepting
+func InitAcceptPool(size int, overflow string) {
+	if size <= 0 {
+		return
+	}
+	acceptPool = mosnsync.NewWorkerPool(size)
+	acceptOverflowReject = overflow == AcceptOverflowReject
+}
+
+// rawListener is the subset of net.TCPListener and net.UnixListener the
+// listener relies on, deadlines stop the accept loop and files feed the
+// smooth upgrade handoff
+type rawListener interface {
+	net.Listener
+	SetDeadline(t time.Time) error
+	File() (*os.File, error)
+}
+
 // listener impl based on golang net package
 type listener struct {
 	name                                  string
@@ -39,8 +74,9 @@ type listener struct {
 	perConnBufferLimitBytes               uint32
 	handOffRestoredDestinationConnections bool
 	cb                                    types.ListenerEventListener
-	rawl                                  *net.TCPListener
+	rawl                                  rawListener
 	config                                *v2.Listener
+	acceptOverflow                        gometrics.Counter
 }
 
 func NewListener(lc *v2.Listener) types.Listener {
@@ -52,7 +88,7 @@ func NewListener(lc *v2.Listener) types.Listener {
 		listenerTag:                           lc.ListenerTag,
 		perConnBufferLimitBytes:               lc.PerConnBufferLimitBytes,
 		handOffRestoredDestinationConnections: lc.HandOffRestoredDestinationConnections,
-		config: lc,
+		config:                                lc,
 	}
 
 	if lc.InheritListener != nil {
@@ -168,8 +204,26 @@ func (l *listener) Close(lctx context.Context) error {
 func (l *listener) listen(lctx context.Context) error {
 	var err error
 
+	if uaddr, ok := l.localAddress.(*net.UnixAddr); ok {
+		// a stale socket file from a crashed process blocks the bind
+		os.Remove(uaddr.Name)
+		var unixl *net.UnixListener
+		if unixl, err = net.ListenUnix("unix", uaddr); err != nil {
+			return err
+		}
+		l.rawl = unixl
+		return nil
+	}
+
 	var rawl *net.TCPListener
-	if rawl, err = net.ListenTCP("tcp", l.localAddress.(*net.TCPAddr)); err != nil {
+	if opts := l.config.SocketOptions; opts != nil {
+		lc := net.ListenConfig{Control: SocketOptionsControl(opts, true)}
+		var nl net.Listener
+		if nl, err = lc.Listen(lctx, "tcp", l.localAddress.String()); err != nil {
+			return err
+		}
+		rawl = nl.(*net.TCPListener)
+	} else if rawl, err = net.ListenTCP("tcp", l.localAddress.(*net.TCPAddr)); err != nil {
 		return err
 	}
 
@@ -185,10 +239,34 @@ func (l *listener) accept(lctx context.Context) error {
 		return err
 	}
 
-	// TODO: use thread pool
-	utils.GoWithRecover(func() {
+	handle := func() {
 		l.cb.OnAccept(rawc, l.handOffRestoredDestinationConnections, nil, nil, nil)
-	}, nil)
+	}
+
+	if acceptPool != nil {
+		if acceptOverflowReject {
+			if !acceptPool.TrySchedule(handle) {
+				l.acceptOverflowStat().Inc(1)
+				log.DefaultLogger.Warnf("[network] [listener accept] accept pool saturated on %s, reject connection from %v", l.name, rawc.RemoteAddr())
+				rawc.Close()
+			}
+		} else {
+			// queue policy backpressures the accept loop
+			acceptPool.Schedule(handle)
+		}
+		return nil
+	}
+
+	utils.GoWithRecover(handle, nil)
 
 	return nil
 }
+
+// acceptOverflowStat lazily creates the overflow counter, the accept loop
+// runs on a single goroutine per listener
+func (l *listener) acceptOverflowStat() gometrics.Counter {
+	if l.acceptOverflow == nil {
+		l.acceptOverflow = metrics.NewListenerStats(l.name).Counter(metrics.DownstreamAcceptOverflow)
+	}
+	return l.acceptOverflow
+}
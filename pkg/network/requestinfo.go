@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"sofastack.io/sofa-mosn/pkg/types"
+	"sofastack.io/sofa-mosn/pkg/utils"
 )
 
 // RequestInfo
@@ -40,21 +41,29 @@ type RequestInfo struct {
 	downstreamLocalAddress   net.Addr
 	downstreamRemoteAddress  net.Addr
 	isHealthCheckRequest     bool
+	upstreamCluster          string
 	routerRule               types.RouteRule
+	errorCode                types.ProxyErrorCode
+	attemptCount             uint32
+	upstreamServerTiming     string
+	grpcStatus               string
+	grpcMessage              string
 }
 
 // todo check
 func newRequestInfoWithPort(protocol types.Protocol) types.RequestInfo {
 	return &RequestInfo{
-		protocol:  protocol,
-		startTime: time.Now(),
+		protocol:     protocol,
+		startTime:    utils.Now(),
+		attemptCount: 1,
 	}
 }
 
 // NewrequestInfo
 func NewRequestInfo() types.RequestInfo {
 	return &RequestInfo{
-		startTime: time.Now(),
+		startTime:    utils.Now(),
+		attemptCount: 1,
 	}
 }
 
@@ -63,7 +72,7 @@ func (r *RequestInfo) StartTime() time.Time {
 }
 
 func (r *RequestInfo) SetStartTime() {
-	r.startTime = time.Now()
+	r.startTime = utils.Now()
 }
 
 func (r *RequestInfo) RequestReceivedDuration() time.Duration {
@@ -120,7 +129,7 @@ func (r *RequestInfo) SetResponseCode(code int) {
 }
 
 func (r *RequestInfo) Duration() time.Duration {
-	return time.Now().Sub(r.startTime)
+	return utils.Since(r.startTime)
 }
 
 func (r *RequestInfo) GetResponseFlag(flag types.ResponseFlag) bool {
@@ -129,6 +138,20 @@ func (r *RequestInfo) GetResponseFlag(flag types.ResponseFlag) bool {
 
 func (r *RequestInfo) SetResponseFlag(flag types.ResponseFlag) {
 	r.responseFlag |= flag
+	// keep the first failure reason, flag based call sites get a code for free
+	if r.errorCode == types.ErrCodeNone {
+		r.errorCode = types.ErrorCodeForResponseFlag(flag)
+	}
+}
+
+func (r *RequestInfo) ErrorCode() types.ProxyErrorCode {
+	return r.errorCode
+}
+
+func (r *RequestInfo) SetErrorCode(code types.ProxyErrorCode) {
+	if r.errorCode == types.ErrCodeNone {
+		r.errorCode = code
+	}
 }
 
 func (r *RequestInfo) UpstreamHost() types.HostInfo {
@@ -171,6 +194,14 @@ func (r *RequestInfo) SetDownstreamRemoteAddress(addr net.Addr) {
 	r.downstreamRemoteAddress = addr
 }
 
+func (r *RequestInfo) UpstreamCluster() string {
+	return r.upstreamCluster
+}
+
+func (r *RequestInfo) SetUpstreamCluster(cluster string) {
+	r.upstreamCluster = cluster
+}
+
 func (r *RequestInfo) RouteEntry() types.RouteRule {
 	return r.routerRule
 }
@@ -178,3 +209,32 @@ func (r *RequestInfo) RouteEntry() types.RouteRule {
 func (r *RequestInfo) SetRouteEntry(routerRule types.RouteRule) {
 	r.routerRule = routerRule
 }
+
+func (r *RequestInfo) AttemptCount() uint32 {
+	return r.attemptCount
+}
+
+func (r *RequestInfo) SetAttemptCount(count uint32) {
+	r.attemptCount = count
+}
+
+func (r *RequestInfo) UpstreamServerTiming() string {
+	return r.upstreamServerTiming
+}
+
+func (r *RequestInfo) SetUpstreamServerTiming(timing string) {
+	r.upstreamServerTiming = timing
+}
+
+func (r *RequestInfo) GrpcStatus() string {
+	return r.grpcStatus
+}
+
+func (r *RequestInfo) GrpcMessage() string {
+	return r.grpcMessage
+}
+
+func (r *RequestInfo) SetGrpcStatus(status, message string) {
+	r.grpcStatus = status
+	r.grpcMessage = message
+}
@@ -0,0 +1,44 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package network
+
+import (
+	"testing"
+)
+
+func TestRequestInfoAttemptCount(t *testing.T) {
+	info := NewRequestInfo()
+	if info.AttemptCount() != 1 {
+		t.Errorf("first attempt should count as 1, got %d", info.AttemptCount())
+	}
+	info.SetAttemptCount(info.AttemptCount() + 1)
+	if info.AttemptCount() != 2 {
+		t.Errorf("attempt count after a retry should be 2, got %d", info.AttemptCount())
+	}
+}
+
+func TestRequestInfoUpstreamServerTiming(t *testing.T) {
+	info := NewRequestInfo()
+	if info.UpstreamServerTiming() != "" {
+		t.Errorf("server timing should be empty before a response, got %s", info.UpstreamServerTiming())
+	}
+	info.SetUpstreamServerTiming("app;dur=12.3")
+	if info.UpstreamServerTiming() != "app;dur=12.3" {
+		t.Errorf("unexpected server timing: %s", info.UpstreamServerTiming())
+	}
+}
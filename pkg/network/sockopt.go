@@ -0,0 +1,115 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package network
+
+import (
+	"syscall"
+
+	"golang.org/x/sys/unix"
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+	"sofastack.io/sofa-mosn/pkg/log"
+)
+
+// tcpFastOpenQueue is the pending TFO connection queue length used when
+// tcp_fastopen is enabled on a listener
+const tcpFastOpenQueue = 256
+
+// SocketOptionsControl returns a control function suitable for
+// net.ListenConfig and net.Dialer that applies the configured socket
+// options on the raw socket before it is bound or connected. listening
+// selects the listener side variant of the fastopen option
+func SocketOptionsControl(opts *v2.SocketOptions, listening bool) func(network, address string, rc syscall.RawConn) error {
+	return func(network, address string, rc syscall.RawConn) error {
+		var sockErr error
+		err := rc.Control(func(fd uintptr) {
+			sockErr = applySocketOptions(int(fd), opts, listening)
+		})
+		if err != nil {
+			return err
+		}
+		return sockErr
+	}
+}
+
+func applySocketOptions(fd int, opts *v2.SocketOptions, listening bool) error {
+	if opts.TCPKeepaliveIdle > 0 {
+		if err := unix.SetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_KEEPALIVE, 1); err != nil {
+			return err
+		}
+		if err := unix.SetsockoptInt(fd, unix.IPPROTO_TCP, unix.TCP_KEEPIDLE, int(opts.TCPKeepaliveIdle)); err != nil {
+			return err
+		}
+	}
+	if opts.TCPKeepaliveInterval > 0 {
+		if err := unix.SetsockoptInt(fd, unix.IPPROTO_TCP, unix.TCP_KEEPINTVL, int(opts.TCPKeepaliveInterval)); err != nil {
+			return err
+		}
+	}
+	if opts.TCPKeepaliveProbes > 0 {
+		if err := unix.SetsockoptInt(fd, unix.IPPROTO_TCP, unix.TCP_KEEPCNT, int(opts.TCPKeepaliveProbes)); err != nil {
+			return err
+		}
+	}
+	if opts.TCPNoDelay != nil {
+		nodelay := 0
+		if *opts.TCPNoDelay {
+			nodelay = 1
+		}
+		if err := unix.SetsockoptInt(fd, unix.IPPROTO_TCP, unix.TCP_NODELAY, nodelay); err != nil {
+			return err
+		}
+	}
+	if opts.RecvBufSize > 0 {
+		if err := unix.SetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_RCVBUF, int(opts.RecvBufSize)); err != nil {
+			return err
+		}
+	}
+	if opts.SendBufSize > 0 {
+		if err := unix.SetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_SNDBUF, int(opts.SendBufSize)); err != nil {
+			return err
+		}
+	}
+	if opts.IPTos > 0 {
+		if err := unix.SetsockoptInt(fd, unix.IPPROTO_IP, unix.IP_TOS, int(opts.IPTos)); err != nil {
+			return err
+		}
+	}
+	if opts.SoPriority > 0 {
+		if err := unix.SetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_PRIORITY, int(opts.SoPriority)); err != nil {
+			return err
+		}
+	}
+	if opts.Freebind {
+		if err := unix.SetsockoptInt(fd, unix.IPPROTO_IP, unix.IP_FREEBIND, 1); err != nil {
+			return err
+		}
+	}
+	if opts.TCPFastOpen {
+		var err error
+		if listening {
+			err = unix.SetsockoptInt(fd, unix.IPPROTO_TCP, unix.TCP_FASTOPEN, tcpFastOpenQueue)
+		} else {
+			err = unix.SetsockoptInt(fd, unix.IPPROTO_TCP, unix.TCP_FASTOPEN_CONNECT, 1)
+		}
+		if err != nil {
+			// fastopen needs kernel support, keep the connection usable
+			log.DefaultLogger.Warnf("[network] [sockopt] enable tcp fastopen failed: %v", err)
+		}
+	}
+	return nil
+}
@@ -52,6 +52,16 @@ func SetTransferTimeout(time time.Duration) {
 	}
 }
 
+// DrainTimeout is the time a connection that cannot be transferred keeps
+// serving in-flight streams after the graceful close signal is sent
+var DrainTimeout = time.Second * 30 //default 30s
+
+func SetDrainTimeout(time time.Duration) {
+	if time != 0 {
+		DrainTimeout = time
+	}
+}
+
 // TransferServer is called on new mosn start
 func TransferServer(handler types.ConnectionHandler) {
 	defer func() {
@@ -0,0 +1,172 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package network
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"syscall"
+
+	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/types"
+	"sofastack.io/sofa-mosn/pkg/utils"
+)
+
+/*
+upstream connection transfer during hot upgrade
+
+Only idle multiplexed upstream connections are transferred, so there is no
+partial frame state to carry: the old process sends the raw FDs over a unix
+domain socket and the new process parks them keyed by remote address. When a
+connection pool in the new process connects to the same upstream host the
+parked connection is adopted instead of dialing, so an upgrade does not
+trigger a reconnect storm on large clusters.
+*/
+
+// upstreamTransferFuncs are registered by protocol connection pools that
+// support handing their idle connections to the new process
+var (
+	upstreamTransferMux   sync.Mutex
+	upstreamTransferFuncs []func()
+)
+
+// RegisterUpstreamTransferFunc registers a pool drainer called on the old
+// process when it gracefully shuts down during hot upgrade
+func RegisterUpstreamTransferFunc(f func()) {
+	upstreamTransferMux.Lock()
+	defer upstreamTransferMux.Unlock()
+	upstreamTransferFuncs = append(upstreamTransferFuncs, f)
+}
+
+// TransferUpstreamConnections is called on the old process after downstream
+// connections are done, every registered pool sends its idle connections
+func TransferUpstreamConnections() {
+	upstreamTransferMux.Lock()
+	funcs := upstreamTransferFuncs
+	upstreamTransferMux.Unlock()
+	for _, f := range funcs {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.DefaultLogger.Errorf("[network] [transfer] [upstream] transfer func panic %v", r)
+				}
+			}()
+			f()
+		}()
+	}
+}
+
+// TransferUpstreamConn sends one upstream connection FD to the new process,
+// the caller must make sure the connection is idle and stops using it
+func TransferUpstreamConn(conn net.Conn) error {
+	tc, ok := conn.(*net.TCPConn)
+	if !ok {
+		return errors.New("transfer upstream conn is not tcp")
+	}
+	file, err := tc.File()
+	if err != nil {
+		return err
+	}
+	c, err := net.Dial("unix", types.TransferUpstreamConnDomainSocket)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	defer c.Close()
+	uc := c.(*net.UnixConn)
+	// transferSendFD closes the file
+	return transferSendFD(uc, file)
+}
+
+// UpstreamTransferServer is started on the new process during hot upgrade,
+// received connections are parked until a pool connects to the same host
+func UpstreamTransferServer() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.DefaultLogger.Errorf("[network] [transfer] [upstream] UpstreamTransferServer panic %v", r)
+		}
+	}()
+
+	syscall.Unlink(types.TransferUpstreamConnDomainSocket)
+	l, err := net.Listen("unix", types.TransferUpstreamConnDomainSocket)
+	if err != nil {
+		log.DefaultLogger.Errorf("[network] [transfer] [upstream] listen error %v", err)
+		return
+	}
+	defer l.Close()
+
+	log.DefaultLogger.Infof("[network] [transfer] [upstream] UpstreamTransferServer start")
+
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			if ope, ok := err.(*net.OpError); ok && (ope.Op == "accept") {
+				log.DefaultLogger.Infof("[network] [transfer] [upstream] listener %s closed", l.Addr())
+			} else {
+				log.DefaultLogger.Errorf("[network] [transfer] [upstream] accept error :%v", err)
+			}
+			return
+		}
+		utils.GoWithRecover(func() {
+			defer c.Close()
+			uc := c.(*net.UnixConn)
+			conn, err := transferRecvType(uc)
+			if err != nil || conn == nil {
+				log.DefaultLogger.Errorf("[network] [transfer] [upstream] recv fd error: %v", err)
+				return
+			}
+			storeTransferredUpstreamConn(conn)
+			log.DefaultLogger.Infof("[network] [transfer] [upstream] parked connection to %s", conn.RemoteAddr())
+		}, nil)
+	}
+}
+
+// parked upstream connections keyed by remote address
+var (
+	parkedUpstreamMux   sync.Mutex
+	parkedUpstreamConns map[string][]net.Conn
+)
+
+func storeTransferredUpstreamConn(conn net.Conn) {
+	parkedUpstreamMux.Lock()
+	defer parkedUpstreamMux.Unlock()
+	if parkedUpstreamConns == nil {
+		parkedUpstreamConns = make(map[string][]net.Conn)
+	}
+	addr := conn.RemoteAddr().String()
+	parkedUpstreamConns[addr] = append(parkedUpstreamConns[addr], conn)
+}
+
+// takeTransferredUpstreamConn pops a parked connection to the address,
+// nil means the pool should dial as usual
+func takeTransferredUpstreamConn(addr string) net.Conn {
+	parkedUpstreamMux.Lock()
+	defer parkedUpstreamMux.Unlock()
+	conns := parkedUpstreamConns[addr]
+	if len(conns) == 0 {
+		return nil
+	}
+	conn := conns[0]
+	if len(conns) == 1 {
+		delete(parkedUpstreamConns, addr)
+	} else {
+		parkedUpstreamConns[addr] = conns[1:]
+	}
+	return conn
+}
@@ -0,0 +1,111 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package network
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+func TestTransferUpstreamConn(t *testing.T) {
+	dir, err := ioutil.TempDir("", "upstream-transfer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	oldSocket := types.TransferUpstreamConnDomainSocket
+	types.TransferUpstreamConnDomainSocket = filepath.Join(dir, "upstream.sock")
+	defer func() {
+		types.TransferUpstreamConnDomainSocket = oldSocket
+	}()
+
+	// an upstream server echoing one byte
+	upstream, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer upstream.Close()
+	go func() {
+		for {
+			c, err := upstream.Accept()
+			if err != nil {
+				return
+			}
+			go func(c net.Conn) {
+				buf := make([]byte, 1)
+				if _, err := c.Read(buf); err == nil {
+					c.Write(buf)
+				}
+				c.Close()
+			}(c)
+		}
+	}()
+
+	go UpstreamTransferServer()
+	// wait for the transfer socket
+	for i := 0; i < 100; i++ {
+		if _, err := os.Stat(types.TransferUpstreamConnDomainSocket); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// the "old process" side dials the upstream and transfers the idle conn
+	conn, err := net.Dial("tcp", upstream.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := TransferUpstreamConn(conn); err != nil {
+		t.Fatalf("transfer upstream conn failed: %v", err)
+	}
+
+	// the "new process" side adopts the parked connection
+	var adopted net.Conn
+	for i := 0; i < 100; i++ {
+		if adopted = takeTransferredUpstreamConn(upstream.Addr().String()); adopted != nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if adopted == nil {
+		t.Fatal("no parked connection to adopt")
+	}
+	defer adopted.Close()
+
+	// the adopted connection still talks to the upstream
+	if _, err := adopted.Write([]byte{'x'}); err != nil {
+		t.Fatalf("write on adopted conn failed: %v", err)
+	}
+	buf := make([]byte, 1)
+	adopted.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := adopted.Read(buf); err != nil || buf[0] != 'x' {
+		t.Fatalf("read on adopted conn failed: %v, got %q", err, buf)
+	}
+
+	// nothing left parked for the address
+	if extra := takeTransferredUpstreamConn(upstream.Addr().String()); extra != nil {
+		t.Errorf("unexpected extra parked connection")
+	}
+}
@@ -87,7 +87,11 @@ func ConvertHeader(ctx context.Context, src, dst types.Protocol, srcHeader types
 	// 1. try direct path
 	if sub, subOk := protoConvFactory[src]; subOk {
 		if f, ok := sub[dst]; ok {
-			return f.ConvHeader(ctx, srcHeader)
+			dstHeader, err := f.ConvHeader(ctx, srcHeader)
+			if err == nil {
+				applyTranscodeRules(src, dst, dstHeader)
+			}
+			return dstHeader, err
 		}
 	}
 
@@ -95,6 +99,7 @@ func ConvertHeader(ctx context.Context, src, dst types.Protocol, srcHeader types
 	if src != common && dst != common {
 		if src2Common, serr := ConvertHeader(ctx, src, common, srcHeader); serr == nil {
 			if common2dst, derr := ConvertHeader(ctx, common, dst, src2Common); derr == nil {
+				applyTranscodeRules(src, dst, common2dst)
 				return common2dst, derr
 			} else {
 				return nil, derr
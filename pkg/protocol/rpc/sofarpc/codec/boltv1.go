@@ -120,7 +120,9 @@ func encodeRequest(ctx context.Context, cmd *sofarpc.BoltRequest) (types.IoBuffe
 
 	if cmd.RequestHeader != nil {
 		l := buf.Len()
-		serialize.Instance.SerializeMap(cmd.RequestHeader, buf)
+		if err := serialize.Instance.SerializeMap(cmd.RequestHeader, buf); err != nil {
+			return nil, err
+		}
 		headerLen = buf.Len() - l
 
 		// reset HeaderLen
@@ -188,7 +190,9 @@ func encodeResponse(ctx context.Context, cmd *sofarpc.BoltResponse) (types.IoBuf
 
 	if cmd.ResponseHeader != nil {
 		l := buf.Len()
-		serialize.Instance.SerializeMap(cmd.ResponseHeader, buf)
+		if err := serialize.Instance.SerializeMap(cmd.ResponseHeader, buf); err != nil {
+			return nil, err
+		}
 		headerLen = buf.Len() - l
 
 		// reset HeaderLen
@@ -403,6 +407,14 @@ func (sb *BoltV1SpanBuilder) BuildSpan(args ...interface{}) types.Span {
 	span := trace.Tracer().Start(time.Now())
 
 	traceId := request.RequestHeader[models.TRACER_ID_KEY]
+	if sw8 := request.RequestHeader[trace.Sw8Header]; sw8 != "" {
+		// fallback to the SkyWalking sw8 propagation header
+		span.SetTag(trace.SW8_PARENT_CONTEXT, sw8)
+		if swTraceId, _, swParentSpanId := trace.DecodeSw8(sw8); traceId == "" && swTraceId != "" {
+			traceId = swTraceId
+			span.SetTag(trace.PARENT_SPAN_ID, swParentSpanId)
+		}
+	}
 	if traceId == "" {
 		// TODO: set generated traceId into header?
 		traceId = trace.IdGen().GenerateTraceId()
@@ -118,7 +118,9 @@ func encodeRequestV2(ctx context.Context, cmd *sofarpc.BoltRequestV2) (types.IoB
 
 	if cmd.RequestHeader != nil {
 		l := buf.Len()
-		serialize.Instance.SerializeMap(cmd.RequestHeader, buf)
+		if err := serialize.Instance.SerializeMap(cmd.RequestHeader, buf); err != nil {
+			return nil, err
+		}
 		headerLen = buf.Len() - l
 
 		// reset HeaderLen
@@ -188,7 +190,9 @@ func encodeResponseV2(ctx context.Context, cmd *sofarpc.BoltResponseV2) (types.I
 
 	if cmd.ResponseHeader != nil {
 		l := buf.Len()
-		serialize.Instance.SerializeMap(cmd.ResponseHeader, buf)
+		if err := serialize.Instance.SerializeMap(cmd.ResponseHeader, buf); err != nil {
+			return nil, err
+		}
 		headerLen = buf.Len() - l
 
 		// reset HeaderLen
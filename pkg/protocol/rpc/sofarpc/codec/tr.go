@@ -0,0 +1,236 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codec
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"sofastack.io/sofa-mosn/pkg/buffer"
+	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/protocol"
+	"sofastack.io/sofa-mosn/pkg/protocol/rpc"
+	"sofastack.io/sofa-mosn/pkg/protocol/rpc/sofarpc"
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+var (
+	TRCodec = &trCodec{}
+)
+
+func init() {
+	sofarpc.RegisterProtocol(sofarpc.PROTOCOL_CODE_TR, TRCodec, TRCodec, &TRSpanBuilder{})
+	sofarpc.RegisterResponseBuilder(sofarpc.PROTOCOL_CODE_TR, TRCodec)
+	sofarpc.RegisterHeartbeatBuilder(sofarpc.PROTOCOL_CODE_TR, TRCodec)
+}
+
+// ~~ types.Encoder
+// ~~ types.Decoder
+type trCodec struct{}
+
+func (c *trCodec) Encode(ctx context.Context, model interface{}) (types.IoBuffer, error) {
+	switch cmd := model.(type) {
+	case *sofarpc.TRRequest:
+		return encodeTR(ctx, true, cmd.Direction, 0, cmd.Serialization, cmd.ReqID, cmd.RequestClass, cmd.ClassName, cmd.ContentLen)
+	case *sofarpc.TRResponse:
+		return encodeTR(ctx, false, cmd.Direction, cmd.Status, cmd.Serialization, cmd.ReqID, cmd.ResponseClass, cmd.ClassName, cmd.ContentLen)
+	default:
+		log.Proxy.Errorf(ctx, "[protocol][sofarpc] tr encode with unknown command : %+v", model)
+		return nil, rpc.ErrUnknownType
+	}
+}
+
+func encodeTR(ctx context.Context, isRequest bool, direction, status, serialization byte, reqID uint32, class string, className []byte, contentLen int) (types.IoBuffer, error) {
+	if class != "" {
+		className = []byte(class)
+	}
+	if len(className) > 0xff {
+		return nil, fmt.Errorf("tr class name too long: %d", len(className))
+	}
+
+	var b [4]byte
+
+	size := sofarpc.TR_PROTOCOL_HEADER_LEN + len(className)
+	protocolCtx := protocol.ProtocolBuffersByContext(ctx)
+	var buf types.IoBuffer
+	if isRequest {
+		buf = protocolCtx.GetReqHeader(size)
+	} else {
+		buf = protocolCtx.GetRspHeader(size)
+	}
+
+	b[0] = sofarpc.PROTOCOL_CODE_TR
+	buf.Write(b[0:1])
+	b[0] = sofarpc.PROTOCOL_VERSION_TR
+	buf.Write(b[0:1])
+	b[0] = direction
+	buf.Write(b[0:1])
+	b[0] = serialization
+	buf.Write(b[0:1])
+	b[0] = status
+	buf.Write(b[0:1])
+
+	binary.BigEndian.PutUint32(b[0:], reqID)
+	buf.Write(b[0:4])
+
+	b[0] = byte(len(className))
+	buf.Write(b[0:1])
+
+	binary.BigEndian.PutUint32(b[0:], uint32(contentLen))
+	buf.Write(b[0:4])
+
+	if len(className) > 0 {
+		buf.Write(className)
+	}
+
+	return buf, nil
+}
+
+func (c *trCodec) Decode(ctx context.Context, data types.IoBuffer) (interface{}, error) {
+	readableBytes := data.Len()
+	var cmd interface{}
+
+	if readableBytes < sofarpc.TR_PROTOCOL_HEADER_LEN {
+		return cmd, nil
+	}
+
+	bytesData := data.Bytes()
+	direction := bytesData[2]
+	serialization := bytesData[3]
+	status := bytesData[4]
+	requestID := binary.BigEndian.Uint32(bytesData[5:9])
+	classLen := int(bytesData[9])
+	contentLen := int(binary.BigEndian.Uint32(bytesData[10:14]))
+
+	read := sofarpc.TR_PROTOCOL_HEADER_LEN
+	if readableBytes < read+classLen+contentLen {
+		// not enough data
+		log.Proxy.Debugf(ctx, "[protocol][sofarpc] tr decode, no enough data for fully decode")
+		return cmd, nil
+	}
+
+	var class, content []byte
+	if classLen > 0 {
+		class = bytesData[read : read+classLen]
+		read += classLen
+	}
+	if contentLen > 0 {
+		content = bytesData[read : read+contentLen]
+		read += contentLen
+	}
+	data.Drain(read)
+
+	switch direction {
+	case sofarpc.TR_DIR_REQUEST, sofarpc.TR_DIR_HEARTBEAT:
+		request := &sofarpc.TRRequest{
+			Protocol:      sofarpc.PROTOCOL_CODE_TR,
+			HeaderVersion: sofarpc.PROTOCOL_VERSION_TR,
+			Direction:     direction,
+			Serialization: serialization,
+			ReqID:         requestID,
+			ClassLen:      byte(classLen),
+			ContentLen:    contentLen,
+			ClassName:     class,
+			Timeout:       -1,
+			RequestClass:  string(class),
+			RequestHeader: make(map[string]string, 4),
+		}
+		if content != nil {
+			request.Content = buffer.NewIoBufferBytes(content)
+		}
+		// mirror the className so legacy TR services are routable
+		if request.RequestClass != "" {
+			request.RequestHeader[sofarpc.HeaderTRServiceName] = request.RequestClass
+		}
+		cmd = request
+	case sofarpc.TR_DIR_RESPONSE:
+		response := &sofarpc.TRResponse{
+			Protocol:       sofarpc.PROTOCOL_CODE_TR,
+			HeaderVersion:  sofarpc.PROTOCOL_VERSION_TR,
+			Direction:      direction,
+			Serialization:  serialization,
+			Status:         status,
+			ReqID:          requestID,
+			ClassLen:       byte(classLen),
+			ContentLen:     contentLen,
+			ClassName:      class,
+			ResponseClass:  string(class),
+			ResponseHeader: make(map[string]string, 4),
+		}
+		if content != nil {
+			response.Content = buffer.NewIoBufferBytes(content)
+		}
+		cmd = response
+	default:
+		return nil, fmt.Errorf("Decode Error, type = %s, value = %d", sofarpc.UnKnownCmdType, direction)
+	}
+
+	return cmd, nil
+}
+
+// ~ HeartbeatBuilder
+func (c *trCodec) Trigger() sofarpc.SofaRpcCmd {
+	return &sofarpc.TRRequest{
+		Protocol:      sofarpc.PROTOCOL_CODE_TR,
+		HeaderVersion: sofarpc.PROTOCOL_VERSION_TR,
+		Direction:     sofarpc.TR_DIR_HEARTBEAT,
+		Serialization: sofarpc.TR_HESSIAN2_SERIALIZE,
+		ReqID:         0, // this would be overwrite by stream layer
+		Timeout:       -1,
+		RequestHeader: make(map[string]string),
+	}
+}
+
+func (c *trCodec) Reply() sofarpc.SofaRpcCmd {
+	return &sofarpc.TRResponse{
+		Protocol:       sofarpc.PROTOCOL_CODE_TR,
+		HeaderVersion:  sofarpc.PROTOCOL_VERSION_TR,
+		Direction:      sofarpc.TR_DIR_HEARTBEAT,
+		Serialization:  sofarpc.TR_HESSIAN2_SERIALIZE,
+		Status:         byte(sofarpc.RESPONSE_STATUS_SUCCESS),
+		ReqID:          0, // this would be overwrite by stream layer
+		ResponseHeader: make(map[string]string),
+	}
+}
+
+// ~ ResponseBuilder
+func (c *trCodec) BuildResponse(respStatus int16) sofarpc.SofaRpcCmd {
+	status := byte(sofarpc.RESPONSE_STATUS_ERROR)
+	if respStatus >= 0 && respStatus <= 0xff {
+		status = byte(respStatus)
+	}
+	return &sofarpc.TRResponse{
+		Protocol:       sofarpc.PROTOCOL_CODE_TR,
+		HeaderVersion:  sofarpc.PROTOCOL_VERSION_TR,
+		Direction:      sofarpc.TR_DIR_RESPONSE,
+		Serialization:  sofarpc.TR_HESSIAN2_SERIALIZE,
+		Status:         status,
+		ReqID:          0, // this would be overwrite by stream layer
+		ResponseHeader: make(map[string]string),
+	}
+}
+
+// TRSpanBuilder builds no spans, legacy TR requests carry no trace
+// context mosn understands
+type TRSpanBuilder struct {
+}
+
+func (sb *TRSpanBuilder) BuildSpan(args ...interface{}) types.Span {
+	return nil
+}
@@ -0,0 +1,146 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codec
+
+import (
+	"context"
+	"testing"
+
+	"sofastack.io/sofa-mosn/pkg/buffer"
+	"sofastack.io/sofa-mosn/pkg/protocol/rpc/sofarpc"
+)
+
+func TestDecodeAndEncode_TR(t *testing.T) {
+	req := &sofarpc.TRRequest{
+		Protocol:      sofarpc.PROTOCOL_CODE_TR,
+		HeaderVersion: sofarpc.PROTOCOL_VERSION_TR,
+		Direction:     sofarpc.TR_DIR_REQUEST,
+		Serialization: sofarpc.TR_HESSIAN2_SERIALIZE,
+		ReqID:         7,
+		RequestClass:  "com.taobao.test.TestService",
+		RequestHeader: make(map[string]string),
+	}
+	content := []byte("content-bytes")
+	req.ContentLen = len(content)
+
+	ctx := context.Background()
+	buf, err := TRCodec.Encode(ctx, req)
+	if err != nil {
+		t.Fatal("Encode tr request failed", err)
+	}
+	buf.Write(content)
+
+	v, err := TRCodec.Decode(context.Background(), buf)
+	if err != nil {
+		t.Fatal("Decode tr data failed", err)
+	}
+	req1, ok := v.(*sofarpc.TRRequest)
+	if !ok {
+		t.Fatal("Decode tr request failed")
+	}
+	if req1.ReqID != req.ReqID {
+		t.Errorf("decode request id is not equal origin, origin: %d, got: %d", req.ReqID, req1.ReqID)
+	}
+	if req1.RequestClass != req.RequestClass {
+		t.Errorf("decode class is not equal origin, origin: %s, got: %s", req.RequestClass, req1.RequestClass)
+	}
+	if service := req1.RequestHeader[sofarpc.HeaderTRServiceName]; service != req.RequestClass {
+		t.Errorf("decoded request should mirror the class into %s, got: %s", sofarpc.HeaderTRServiceName, service)
+	}
+	if req1.Content == nil || string(req1.Content.Bytes()) != string(content) {
+		t.Errorf("decode content is not equal origin")
+	}
+}
+
+func TestDecode_TR_NotEnoughData(t *testing.T) {
+	req := &sofarpc.TRRequest{
+		Protocol:      sofarpc.PROTOCOL_CODE_TR,
+		Direction:     sofarpc.TR_DIR_REQUEST,
+		Serialization: sofarpc.TR_HESSIAN2_SERIALIZE,
+		ReqID:         1,
+		RequestClass:  "com.taobao.test.TestService",
+		RequestHeader: make(map[string]string),
+	}
+	req.ContentLen = 10 // announced but not written
+
+	buf, err := TRCodec.Encode(context.Background(), req)
+	if err != nil {
+		t.Fatal("Encode tr request failed", err)
+	}
+	data := buffer.NewIoBufferBytes(buf.Bytes())
+	v, err := TRCodec.Decode(context.Background(), data)
+	if v != nil || err != nil {
+		t.Errorf("partial frame should decode to nil without error, got: %v, %v", v, err)
+	}
+	if data.Len() != len(buf.Bytes()) {
+		t.Errorf("partial frame should not be drained")
+	}
+}
+
+func TestTRBoltBridge(t *testing.T) {
+	req := &sofarpc.TRRequest{
+		Protocol:      sofarpc.PROTOCOL_CODE_TR,
+		Direction:     sofarpc.TR_DIR_REQUEST,
+		Serialization: sofarpc.TR_HESSIAN2_SERIALIZE,
+		ReqID:         9,
+		Timeout:       -1,
+		RequestClass:  "com.taobao.test.TestService",
+		RequestHeader: map[string]string{"k": "v"},
+	}
+	boltReq := sofarpc.TRRequestToBolt(req)
+	if boltReq.Protocol != sofarpc.PROTOCOL_CODE_V1 {
+		t.Errorf("bridged request should be bolt v1, got: %d", boltReq.Protocol)
+	}
+	if boltReq.ReqID != req.ReqID || boltReq.RequestClass != req.RequestClass {
+		t.Errorf("bridged request lost fields: %+v", boltReq)
+	}
+	if boltReq.Codec != sofarpc.HESSIAN2_SERIALIZE {
+		t.Errorf("hessian2 serialization should map to the bolt codec byte, got: %d", boltReq.Codec)
+	}
+
+	resp := &sofarpc.BoltResponse{
+		Protocol:       sofarpc.PROTOCOL_CODE_V1,
+		CmdType:        sofarpc.RESPONSE,
+		CmdCode:        sofarpc.RPC_RESPONSE,
+		ReqID:          9,
+		ResponseStatus: sofarpc.RESPONSE_STATUS_SUCCESS,
+		ResponseHeader: make(map[string]string),
+	}
+	trResp := sofarpc.BoltResponseToTR(resp)
+	if trResp.Protocol != sofarpc.PROTOCOL_CODE_TR || trResp.Direction != sofarpc.TR_DIR_RESPONSE {
+		t.Errorf("bridged response should be a tr response, got: %+v", trResp)
+	}
+	if trResp.ReqID != resp.ReqID || trResp.RespStatus() != uint32(sofarpc.RESPONSE_STATUS_SUCCESS) {
+		t.Errorf("bridged response lost fields: %+v", trResp)
+	}
+}
+
+func TestTRBuilders(t *testing.T) {
+	hb := TRCodec.Trigger()
+	if hb.CommandCode() != sofarpc.HEARTBEAT {
+		t.Errorf("trigger should build a heartbeat, got: %d", hb.CommandCode())
+	}
+	ack := TRCodec.Reply()
+	if ack.CommandType() != sofarpc.RESPONSE || ack.CommandCode() != sofarpc.HEARTBEAT {
+		t.Errorf("reply should build a heartbeat response")
+	}
+	resp := TRCodec.BuildResponse(sofarpc.RESPONSE_STATUS_CONNECTION_CLOSED)
+	if resp.(*sofarpc.TRResponse).Status != byte(sofarpc.RESPONSE_STATUS_CONNECTION_CLOSED) {
+		t.Errorf("build response status mismatch")
+	}
+}
@@ -0,0 +1,111 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package conv
+
+import (
+	"context"
+	"strconv"
+
+	"sofastack.io/sofa-mosn/pkg/protocol"
+	"sofastack.io/sofa-mosn/pkg/protocol/rpc"
+	"sofastack.io/sofa-mosn/pkg/protocol/rpc/sofarpc"
+)
+
+var tr = new(trconv)
+
+func init() {
+	sofarpc.RegisterConv(sofarpc.PROTOCOL_CODE_TR, tr)
+}
+
+type trconv struct{}
+
+func (t *trconv) MapToCmd(ctx context.Context, headers map[string]string) (sofarpc.SofaRpcCmd, error) {
+	value := sofarpc.GetPropertyValue(PropertyHeaders, headers, sofarpc.HeaderCmdType)
+	cmdType := sofarpc.ConvertPropertyValueUint8(value)
+	value = sofarpc.GetPropertyValue(PropertyHeaders, headers, sofarpc.HeaderReqID)
+	requestID := sofarpc.ConvertPropertyValueUint32(value)
+	value = sofarpc.GetPropertyValue(PropertyHeaders, headers, sofarpc.HeaderCodec)
+	serialization := sofarpc.ConvertPropertyValueUint8(value)
+	value = sofarpc.GetPropertyValue(PropertyHeaders, headers, sofarpc.HeaderContentLen)
+	contentLength := sofarpc.ConvertPropertyValueInt(value)
+	className := sofarpc.GetPropertyValue(PropertyHeaders, headers, sofarpc.HeaderClassName)
+
+	// consume the protocol code entry like the other converters do
+	sofarpc.GetPropertyValue(PropertyHeaders, headers, sofarpc.HeaderProtocolCode)
+
+	if cmdType == sofarpc.REQUEST || cmdType == sofarpc.REQUEST_ONEWAY {
+		request := &sofarpc.TRRequest{
+			Protocol:      sofarpc.PROTOCOL_CODE_TR,
+			HeaderVersion: sofarpc.PROTOCOL_VERSION_TR,
+			Direction:     sofarpc.TR_DIR_REQUEST,
+			Serialization: serialization,
+			ReqID:         requestID,
+			ContentLen:    contentLength,
+			Timeout:       -1,
+			RequestClass:  className,
+			RequestHeader: headers,
+		}
+		return request, nil
+	} else if cmdType == sofarpc.RESPONSE {
+		value = sofarpc.GetPropertyValue(PropertyHeaders, headers, sofarpc.HeaderRespStatus)
+		responseStatus := sofarpc.ConvertPropertyValueInt16(value)
+
+		response := &sofarpc.TRResponse{
+			Protocol:       sofarpc.PROTOCOL_CODE_TR,
+			HeaderVersion:  sofarpc.PROTOCOL_VERSION_TR,
+			Direction:      sofarpc.TR_DIR_RESPONSE,
+			Serialization:  serialization,
+			Status:         byte(responseStatus),
+			ReqID:          requestID,
+			ContentLen:     contentLength,
+			ResponseClass:  className,
+			ResponseHeader: headers,
+		}
+		return response, nil
+	}
+
+	return nil, rpc.ErrUnknownType
+}
+
+func (t *trconv) MapToFields(ctx context.Context, cmd sofarpc.SofaRpcCmd) (map[string]string, error) {
+	switch c := cmd.(type) {
+	case *sofarpc.TRRequest:
+		headers := c.RequestHeader
+		headers[sofarpc.SofaPropertyHeader(sofarpc.HeaderProtocolCode)] = strconv.FormatUint(uint64(c.Protocol), 10)
+		headers[sofarpc.SofaPropertyHeader(sofarpc.HeaderCmdType)] = strconv.FormatUint(uint64(sofarpc.REQUEST), 10)
+		headers[sofarpc.SofaPropertyHeader(sofarpc.HeaderReqID)] = strconv.FormatUint(uint64(c.ReqID), 10)
+		headers[sofarpc.SofaPropertyHeader(sofarpc.HeaderCodec)] = strconv.FormatUint(uint64(c.Serialization), 10)
+		headers[sofarpc.SofaPropertyHeader(sofarpc.HeaderContentLen)] = strconv.FormatUint(uint64(c.ContentLen), 10)
+		headers[sofarpc.SofaPropertyHeader(sofarpc.HeaderClassName)] = c.RequestClass
+		headers[protocol.MosnHeaderDirection] = protocol.Request
+		return headers, nil
+	case *sofarpc.TRResponse:
+		headers := c.ResponseHeader
+		headers[sofarpc.SofaPropertyHeader(sofarpc.HeaderProtocolCode)] = strconv.FormatUint(uint64(c.Protocol), 10)
+		headers[sofarpc.SofaPropertyHeader(sofarpc.HeaderCmdType)] = strconv.FormatUint(uint64(sofarpc.RESPONSE), 10)
+		headers[sofarpc.SofaPropertyHeader(sofarpc.HeaderReqID)] = strconv.FormatUint(uint64(c.ReqID), 10)
+		headers[sofarpc.SofaPropertyHeader(sofarpc.HeaderCodec)] = strconv.FormatUint(uint64(c.Serialization), 10)
+		headers[sofarpc.SofaPropertyHeader(sofarpc.HeaderContentLen)] = strconv.FormatUint(uint64(c.ContentLen), 10)
+		headers[sofarpc.SofaPropertyHeader(sofarpc.HeaderRespStatus)] = strconv.FormatUint(uint64(c.Status), 10)
+		headers[sofarpc.SofaPropertyHeader(sofarpc.HeaderClassName)] = c.ResponseClass
+		headers[protocol.MosnHeaderDirection] = protocol.Response
+		return headers, nil
+	}
+
+	return nil, rpc.ErrUnknownType
+}
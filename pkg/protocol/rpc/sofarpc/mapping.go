@@ -75,6 +75,9 @@ func MappingFromHttpStatus(code int) int16 {
 	case types.TimeoutExceptionCode:
 		//Response Timeout
 		return RESPONSE_STATUS_TIMEOUT
+	case http.StatusTooManyRequests, types.LimitExceededCode:
+		//Rate Limited
+		return RESPONSE_STATUS_SERVER_THREADPOOL_BUSY
 	default:
 		return RESPONSE_STATUS_UNKNOWN
 	}
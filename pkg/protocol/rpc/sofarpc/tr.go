@@ -0,0 +1,348 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sofarpc
+
+import (
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+// TR is the legacy Taobao Remoting protocol still spoken by old SOFA
+// applications. MOSN decodes TR on the downstream side and bridges the
+// requests to bolt upstreams, encoding TR upstream is not supported.
+//
+/**
+ * TR command protocol, requests and responses share the fixed header
+ * 0     1     2     3     4     5                       9     10          14
+ * +-----+-----+-----+-----+-----+-----+-----+-----+-----+-----+-----+-----+
+ * |proto| ver | dir |seri |stat |      requestID        |clsLen| contentLen|
+ * +-----------+-----------------+-----------------------+------------------+
+ * |                className + content bytes                               |
+ * +------------------------------------------------------------------------+
+ *
+ * proto: protocol code, 13
+ * ver: header version, 13
+ * dir: request(0) / response(1) / heartbeat(2)
+ * seri: serialization, hessian(1) / java(2) / hessian2(4)
+ * stat: response status, reserved on requests
+ * requestID: id of request
+ * clsLen: length of className
+ * contentLen: length of content
+ */
+const (
+	PROTOCOL_CODE_TR    byte = 13
+	PROTOCOL_VERSION_TR byte = 13
+
+	TR_PROTOCOL_HEADER_LEN int = 14
+
+	TR_DIR_REQUEST   byte = 0
+	TR_DIR_RESPONSE  byte = 1
+	TR_DIR_HEARTBEAT byte = 2
+
+	TR_HESSIAN_SERIALIZE  byte = 1
+	TR_JAVA_SERIALIZE     byte = 2
+	TR_HESSIAN2_SERIALIZE byte = 4
+
+	// header key the decoded className is mirrored into, so routing can
+	// match legacy TR services without touching the content
+	HeaderTRServiceName string = "trservice"
+)
+
+// TRRequest is the cmd struct of a TR request or heartbeat
+type TRRequest struct {
+	Protocol      byte
+	HeaderVersion byte
+	Direction     byte // request(0) / heartbeat(2)
+	Serialization byte
+	ReqID         uint32
+	ClassLen      byte
+	ContentLen    int
+	ClassName     []byte
+	Content       types.IoBuffer
+
+	Timeout       int    // not on the wire, TR relies on client side timeout
+	RequestClass  string // deserialize fields
+	RequestHeader map[string]string
+}
+
+// ~ RpcCmd
+func (t *TRRequest) ProtocolCode() byte {
+	return t.Protocol
+}
+
+func (t *TRRequest) RequestID() uint64 {
+	return uint64(t.ReqID)
+}
+
+func (t *TRRequest) Header() map[string]string {
+	return t.RequestHeader
+}
+
+func (t *TRRequest) Data() types.IoBuffer {
+	return t.Content
+}
+
+func (t *TRRequest) SetRequestID(requestID uint64) {
+	t.ReqID = uint32(requestID)
+}
+
+func (t *TRRequest) SetHeader(header map[string]string) {
+	t.RequestHeader = header
+}
+
+func (t *TRRequest) SetData(data types.IoBuffer) {
+	t.Content = data
+}
+
+func (t *TRRequest) GetTimeout() int {
+	return t.Timeout
+}
+
+// ~ SofaRpcCmd
+func (t *TRRequest) CommandType() byte {
+	// TR does not distinguish oneway on the wire
+	return REQUEST
+}
+
+func (t *TRRequest) CommandCode() int16 {
+	if t.Direction == TR_DIR_HEARTBEAT {
+		return HEARTBEAT
+	}
+	return RPC_REQUEST
+}
+
+// ~ HeaderMap
+func (t *TRRequest) Get(key string) (value string, ok bool) {
+	value, ok = t.RequestHeader[key]
+	return
+}
+
+func (t *TRRequest) Set(key string, value string) {
+	t.RequestHeader[key] = value
+}
+
+func (t *TRRequest) Add(key string, value string) {
+	panic("not supported")
+}
+
+func (t *TRRequest) Del(key string) {
+	delete(t.RequestHeader, key)
+}
+
+func (t *TRRequest) Range(f func(key, value string) bool) {
+	for k, v := range t.RequestHeader {
+		// stop if f return false
+		if !f(k, v) {
+			break
+		}
+	}
+}
+
+func (t *TRRequest) Clone() types.HeaderMap {
+	copy := &TRRequest{}
+	*copy = *t
+
+	// deep copy
+	requestHeader := make(map[string]string, len(t.RequestHeader))
+	for k, v := range t.RequestHeader {
+		requestHeader[k] = v
+	}
+	copy.RequestHeader = requestHeader
+
+	return copy
+}
+
+func (t *TRRequest) ByteSize() (size uint64) {
+	for k, v := range t.RequestHeader {
+		size += uint64(len(k) + len(v))
+	}
+	return
+}
+
+// TRResponse is the cmd struct of a TR response
+type TRResponse struct {
+	Protocol      byte
+	HeaderVersion byte
+	Direction     byte // response(1) / heartbeat(2)
+	Serialization byte
+	Status        byte
+	ReqID         uint32
+	ClassLen      byte
+	ContentLen    int
+	ClassName     []byte
+	Content       types.IoBuffer
+
+	ResponseClass  string // deserialize fields
+	ResponseHeader map[string]string
+}
+
+// ~ RpcCmd
+func (t *TRResponse) ProtocolCode() byte {
+	return t.Protocol
+}
+
+func (t *TRResponse) RequestID() uint64 {
+	return uint64(t.ReqID)
+}
+
+func (t *TRResponse) Header() map[string]string {
+	return t.ResponseHeader
+}
+
+func (t *TRResponse) Data() types.IoBuffer {
+	return t.Content
+}
+
+func (t *TRResponse) SetRequestID(requestID uint64) {
+	t.ReqID = uint32(requestID)
+}
+
+func (t *TRResponse) SetHeader(header map[string]string) {
+	t.ResponseHeader = header
+}
+
+func (t *TRResponse) SetData(data types.IoBuffer) {
+	t.Content = data
+}
+
+// response have no timeout
+func (t *TRResponse) GetTimeout() int {
+	return -1
+}
+
+// ~ ResponseStatus
+func (t *TRResponse) RespStatus() uint32 {
+	return uint32(t.Status)
+}
+
+// ~ SofaRpcCmd
+func (t *TRResponse) CommandType() byte {
+	return RESPONSE
+}
+
+func (t *TRResponse) CommandCode() int16 {
+	if t.Direction == TR_DIR_HEARTBEAT {
+		return HEARTBEAT
+	}
+	return RPC_RESPONSE
+}
+
+// ~ HeaderMap
+func (t *TRResponse) Get(key string) (value string, ok bool) {
+	value, ok = t.ResponseHeader[key]
+	return
+}
+
+func (t *TRResponse) Set(key string, value string) {
+	t.ResponseHeader[key] = value
+}
+
+func (t *TRResponse) Add(key string, value string) {
+	panic("not supported")
+}
+
+func (t *TRResponse) Del(key string) {
+	delete(t.ResponseHeader, key)
+}
+
+func (t *TRResponse) Range(f func(key, value string) bool) {
+	for k, v := range t.ResponseHeader {
+		// stop if f return false
+		if !f(k, v) {
+			break
+		}
+	}
+}
+
+func (t *TRResponse) Clone() types.HeaderMap {
+	copy := &TRResponse{}
+	*copy = *t
+
+	// deep copy
+	responseHeader := make(map[string]string, len(t.ResponseHeader))
+	for k, v := range t.ResponseHeader {
+		responseHeader[k] = v
+	}
+	copy.ResponseHeader = responseHeader
+
+	return copy
+}
+
+func (t *TRResponse) ByteSize() (size uint64) {
+	for k, v := range t.ResponseHeader {
+		size += uint64(len(k) + len(v))
+	}
+	return
+}
+
+// trSerializeToBolt maps the TR serialization byte onto the bolt codec
+// byte, both protocols agree on hessian2 which is what legacy TR
+// applications use in practice, others are forwarded untouched
+func trSerializeToBolt(serialization byte) byte {
+	if serialization == TR_HESSIAN2_SERIALIZE {
+		return HESSIAN2_SERIALIZE
+	}
+	return serialization
+}
+
+// TRRequestToBolt bridges a decoded TR request to a bolt v1 request, the
+// content bytes are forwarded untouched
+func TRRequestToBolt(req *TRRequest) *BoltRequest {
+	cmdCode := RPC_REQUEST
+	if req.Direction == TR_DIR_HEARTBEAT {
+		cmdCode = HEARTBEAT
+	}
+	return &BoltRequest{
+		Protocol:      PROTOCOL_CODE_V1,
+		CmdType:       REQUEST,
+		CmdCode:       cmdCode,
+		Version:       PROTOCOL_VERSION_1,
+		ReqID:         req.ReqID,
+		Codec:         trSerializeToBolt(req.Serialization),
+		Timeout:       req.Timeout,
+		ContentLen:    req.ContentLen,
+		RequestClass:  req.RequestClass,
+		RequestHeader: req.RequestHeader,
+		Content:       req.Content,
+	}
+}
+
+// BoltResponseToTR bridges a bolt v1 response back to TR for a downstream
+// that spoke TR, the reverse of TRRequestToBolt. Bolt status codes beyond
+// one byte are folded to the generic error status
+func BoltResponseToTR(resp *BoltResponse) *TRResponse {
+	status := RESPONSE_STATUS_ERROR
+	if resp.ResponseStatus >= 0 && resp.ResponseStatus <= 0xff {
+		status = resp.ResponseStatus
+	}
+	direction := TR_DIR_RESPONSE
+	if resp.CmdCode == HEARTBEAT {
+		direction = TR_DIR_HEARTBEAT
+	}
+	return &TRResponse{
+		Protocol:       PROTOCOL_CODE_TR,
+		HeaderVersion:  PROTOCOL_VERSION_TR,
+		Direction:      direction,
+		Serialization:  TR_HESSIAN2_SERIALIZE,
+		Status:         byte(status),
+		ReqID:          resp.ReqID,
+		ContentLen:     resp.ContentLen,
+		ResponseClass:  resp.ResponseClass,
+		ResponseHeader: resp.ResponseHeader,
+		Content:        resp.Content,
+	}
+}
@@ -0,0 +1,61 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dubbo
+
+import (
+	"encoding/binary"
+)
+
+// dubbo flag bits
+const (
+	DUBBO_FLAG_REQ    = 1 << 7
+	DUBBO_FLAG_TWOWAY = 1 << 6
+	DUBBO_FLAG_EVENT  = 1 << 5
+
+	// dubbo status 20 is OK
+	DUBBO_STATUS_OK = 20
+)
+
+// IsHeartbeatFrame reports whether the frame is a protocol level heartbeat
+// event, both directions carry the event bit
+func (d *rpcDubbo) IsHeartbeatFrame(data []byte) bool {
+	rslt, _ := isValidDubboData(data)
+	if rslt == false {
+		return false
+	}
+	return getEventPing(data[DUBBO_FLAG_IDX])
+}
+
+// HeartbeatResponseFrame builds the reply for a heartbeat request with the
+// same request id, the body is the hessian null token. A heartbeat
+// response frame gets no reply
+func (d *rpcDubbo) HeartbeatResponseFrame(data []byte) []byte {
+	rslt, _ := isValidDubboData(data)
+	if rslt == false || !isReqFrame(data[DUBBO_FLAG_IDX]) {
+		return nil
+	}
+
+	resp := make([]byte, DUBBO_HEADER_LEN+1)
+	copy(resp, DUBBO_MAGIC_TAG)
+	resp[DUBBO_FLAG_IDX] = byte(getSerializeId(data[DUBBO_FLAG_IDX])) | DUBBO_FLAG_EVENT
+	resp[DUBBO_STATUS_IDX] = DUBBO_STATUS_OK
+	copy(resp[DUBBO_ID_IDX:], data[DUBBO_ID_IDX:DUBBO_ID_IDX+DUBBO_ID_LEN])
+	binary.BigEndian.PutUint32(resp[DUBBO_DATA_LEN_IDX:], 1)
+	resp[DUBBO_HEADER_LEN] = 'N' // hessian null
+	return resp
+}
@@ -0,0 +1,123 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dubbo
+
+import (
+	"github.com/AlexStocks/dubbogo/codec/hessian"
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+// routing meta keys, service matches the sofa route key so dubbo traffic
+// routes through the same virtual host rules
+const (
+	metaKeyMethod  = "method"
+	metaKeyVersion = "version"
+)
+
+// GetMetas extracts the routing metadata of a dubbo request: service,
+// method, version and the string attachments
+func (d *rpcDubbo) GetMetas(data []byte) map[string]string {
+	rslt, bodyLen := isValidDubboData(data)
+	if rslt == false || bodyLen <= 0 {
+		return nil
+	}
+
+	flag := data[DUBBO_FLAG_IDX]
+	if getEventPing(flag) || !isReqFrame(flag) {
+		// heart-beat and response frames carry no routing metadata
+		return nil
+	}
+	if getSerializeId(flag) != 2 {
+		// not hessian, do not support
+		return nil
+	}
+
+	decoder := hessian.NewDecoder(data[DUBBO_HEADER_LEN:])
+
+	// dubbo version + path + version + method
+	fields := make([]string, 4)
+	for i := range fields {
+		field, err := decoder.Decode()
+		if err != nil {
+			return nil
+		}
+		str, ok := field.(string)
+		if !ok {
+			return nil
+		}
+		fields[i] = str
+	}
+
+	metas := map[string]string{
+		types.SofaRouteMatchKey: fields[1],
+		metaKeyMethod:           fields[3],
+	}
+	if fields[2] != "" {
+		metas[metaKeyVersion] = fields[2]
+	}
+
+	// the attachments map trails the arguments, skip the parameter types
+	// descriptor plus one value per argument
+	desc, err := decoder.Decode()
+	if err != nil {
+		return metas
+	}
+	descStr, ok := desc.(string)
+	if !ok {
+		return metas
+	}
+	for i := 0; i < countArgs(descStr); i++ {
+		if _, err := decoder.Decode(); err != nil {
+			return metas
+		}
+	}
+	attachments, err := decoder.Decode()
+	if err != nil {
+		return metas
+	}
+	if m, ok := attachments.(map[interface{}]interface{}); ok {
+		for k, v := range m {
+			key, kok := k.(string)
+			value, vok := v.(string)
+			if kok && vok {
+				metas[key] = value
+			}
+		}
+	}
+	return metas
+}
+
+// countArgs counts the arguments in a jvm method descriptor, e.g.
+// "Ljava/lang/String;[IZ" holds three
+func countArgs(desc string) int {
+	count := 0
+	for i := 0; i < len(desc); i++ {
+		switch desc[i] {
+		case '[':
+			// array dimension prefix, the element type follows
+		case 'L':
+			for i < len(desc) && desc[i] != ';' {
+				i++
+			}
+			count++
+		default:
+			count++
+		}
+	}
+	return count
+}
@@ -18,9 +18,33 @@
 package dubbo
 
 import (
+	"encoding/binary"
 	"testing"
+
+	"github.com/AlexStocks/dubbogo/codec/hessian"
 )
 
+// buildDubboRequest encodes a hessian request frame for test
+func buildDubboRequest(service, version, method string, attachments map[interface{}]interface{}) []byte {
+	enc := hessian.NewEncoder()
+	enc.Encode("2.6.2")
+	enc.Encode(service)
+	enc.Encode(version)
+	enc.Encode(method)
+	enc.Encode("Ljava/lang/String;")
+	enc.Encode("world")
+	enc.Encode(attachments)
+	body := enc.Buffer()
+
+	msg := make([]byte, DUBBO_HEADER_LEN+len(body))
+	copy(msg, DUBBO_MAGIC_TAG)
+	msg[DUBBO_FLAG_IDX] = DUBBO_FLAG_REQ | DUBBO_FLAG_TWOWAY | 2
+	binary.BigEndian.PutUint64(msg[DUBBO_ID_IDX:], 78)
+	binary.BigEndian.PutUint32(msg[DUBBO_DATA_LEN_IDX:], uint32(len(body)))
+	copy(msg[DUBBO_HEADER_LEN:], body)
+	return msg
+}
+
 func Test_dubbo_SplitFrame_01(t *testing.T) {
 	msg := []byte{0xda, 0xbb, 0x40, 0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 78, 0, 0, 0, 3, '1', '2', '3'}
 	rpc := NewRPCDubbo()
@@ -207,6 +231,84 @@ func Test_dubbo_GetServiceName_01(t *testing.T) {
 	}
 }
 
+func Test_dubbo_IsHeartbeatFrame_01(t *testing.T) {
+	msg := []byte{0xda, 0xbb, 0xe2, 0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 78, 0, 0, 0, 1, 'N'}
+	rpc := NewRPCDubbo().(*rpcDubbo)
+	if !rpc.IsHeartbeatFrame(msg) {
+		t.Error("heart-beat request not detected")
+	}
+	msg[DUBBO_FLAG_IDX] = 0xc2
+	if rpc.IsHeartbeatFrame(msg) {
+		t.Error("normal request detected as heart-beat")
+	}
+}
+
+func Test_dubbo_HeartbeatResponseFrame_01(t *testing.T) {
+	msg := []byte{0xda, 0xbb, 0xe2, 0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 78, 0, 0, 0, 1, 'N'}
+	rpc := NewRPCDubbo().(*rpcDubbo)
+	resp := rpc.HeartbeatResponseFrame(msg)
+	if resp == nil {
+		t.Fatal("no heart-beat response built")
+	}
+	rslt, bodyLen := isValidDubboData(resp)
+	if rslt != true || bodyLen != 1 {
+		t.Fatalf("invalid response frame: %v", resp)
+	}
+	flag := resp[DUBBO_FLAG_IDX]
+	if !getEventPing(flag) || isReqFrame(flag) {
+		t.Errorf("unexpected response flag: %x", flag)
+	}
+	if resp[DUBBO_STATUS_IDX] != DUBBO_STATUS_OK {
+		t.Errorf("unexpected status: %d", resp[DUBBO_STATUS_IDX])
+	}
+	if rpc.GetStreamID(resp) != "78" {
+		t.Errorf("request id not kept: %s", rpc.GetStreamID(resp))
+	}
+
+	// a heart-beat response frame gets no reply
+	if reply := rpc.HeartbeatResponseFrame(resp); reply != nil {
+		t.Errorf("heart-beat response replied: %v", reply)
+	}
+}
+
+func Test_dubbo_GetMetas_01(t *testing.T) {
+	msg := buildDubboRequest("com.test.DemoService", "1.0.1", "sayHello", map[interface{}]interface{}{
+		"interface": "com.test.DemoService",
+		"group":     "blue",
+	})
+	rpc := NewRPCDubbo().(*rpcDubbo)
+	metas := rpc.GetMetas(msg)
+	if metas == nil {
+		t.Fatal("no metas extracted")
+	}
+	expected := map[string]string{
+		"service":   "com.test.DemoService",
+		"method":    "sayHello",
+		"version":   "1.0.1",
+		"interface": "com.test.DemoService",
+		"group":     "blue",
+	}
+	for key, value := range expected {
+		if metas[key] != value {
+			t.Errorf("meta %s: expected %s, got %s", key, value, metas[key])
+		}
+	}
+}
+
+func Test_countArgs_01(t *testing.T) {
+	cases := map[string]int{
+		"":                      0,
+		"Ljava/lang/String;":    1,
+		"Ljava/lang/String;[IZ": 3,
+		"[[Ljava/lang/String;J": 2,
+	}
+	for desc, expected := range cases {
+		if got := countArgs(desc); got != expected {
+			t.Errorf("%q: expected %d, got %d", desc, expected, got)
+		}
+	}
+}
+
 func Test_dubbo_GetMethodName_01(t *testing.T) {
 	msg := []byte{218, 187, 194, 0, 0, 0, 0, 0, 0, 0, 0, 3, 0, 0, 0, 225, 5, 50, 46, 54, 46, 50, 48, 48, 99, 111, 109, 46, 97, 108, 105, 98, 97, 98, 97, 46, 98, 111, 111, 116, 46, 100, 117, 98, 98, 111, 46, 100, 101, 109, 111, 46, 99, 111, 110, 115, 117, 109, 101, 114, 46, 68, 101, 109, 111, 83, 101, 114, 118, 105, 99, 101, 5, 49, 46, 48, 46, 49, 8, 115, 97, 121, 72, 101, 108, 108, 111, 18, 76, 106, 97, 118, 97, 47, 108, 97, 110, 103, 47, 83, 116, 1, 14, 105, 110, 103, 59, 3, 120, 120, 120, 72, 4, 112, 97, 116, 104, 48, 48, 99, 111, 109, 46, 97, 108, 105, 98, 97, 98, 97, 46, 98, 111, 111, 116, 46, 100, 117, 98, 98, 111, 46, 100, 101, 109, 111, 46, 99, 111, 110, 115, 117, 109, 101, 114, 46, 68, 101, 109, 111, 83, 101, 114, 118, 105, 99, 101, 9, 105, 110, 116, 101, 114, 102, 97, 99, 101, 48, 48, 99, 111, 109, 46, 97, 108, 105, 98, 97, 98, 97, 46, 98, 111, 111, 116, 46, 100, 117, 98, 98, 111, 46, 100, 101, 109, 111, 46, 99, 111, 110, 115, 117, 109, 101, 114, 46, 68, 101, 109, 111, 83, 101, 114, 118, 105, 99, 101, 7, 118, 101, 114, 115, 105, 111, 110, 5, 49, 46, 48, 46, 49, 90}
 	rpc := NewRPCDubbo()
@@ -70,3 +70,11 @@ type ProtocolConvertor interface {
 	Multiplexing
 	Convert(data []byte) (map[string]string, []byte)
 }
+
+// HeartbeatHandler answers protocol level heartbeats locally base on Multiplexing,
+// heartbeat frames never occupy an upstream stream
+type HeartbeatHandler interface {
+	Multiplexing
+	IsHeartbeatFrame(data []byte) bool
+	HeartbeatResponseFrame(data []byte) []byte
+}
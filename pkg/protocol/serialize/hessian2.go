@@ -0,0 +1,37 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package serialize
+
+import (
+	"github.com/AlexStocks/dubbogo/codec/hessian"
+)
+
+// Hessian2 is the serialization sofarpc and dubbo payloads use in practice
+const Hessian2 = "hessian2"
+
+func init() {
+	RegisterPayloadDeserializer(Hessian2, &hessian2Deserializer{})
+}
+
+type hessian2Deserializer struct{}
+
+// Deserialize decodes the first hessian2 value of the payload, which is
+// enough for routing decisions on the request object
+func (h *hessian2Deserializer) Deserialize(b []byte) (interface{}, error) {
+	return hessian.NewDecoder(b).Decode()
+}
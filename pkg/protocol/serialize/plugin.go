@@ -0,0 +1,95 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package serialize
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// Payload deserializers decode rpc argument bytes into generic values so
+// stream filters can route or shadow on argument contents. Deserializing
+// every payload would burn CPU for nothing, so a registered deserializer
+// stays inert until config enables it explicitly, and payloads beyond the
+// size limit are rejected before any decoding work.
+
+var (
+	payloadDeserializers = make(map[string]PayloadDeserializer)
+	enabledDeserializers = make(map[string]bool)
+
+	ErrDeserializerNotFound = errors.New("no payload deserializer registered under the name")
+	ErrDeserializerDisabled = errors.New("payload deserializer is not enabled")
+	ErrPayloadTooLarge      = errors.New("payload exceeds the deserialize size limit")
+)
+
+// DefaultMaxPayloadSize bounds the payload bytes one deserialization may
+// process, keeping the CPU cost of payload-aware routing predictable
+const DefaultMaxPayloadSize = 64 << 10
+
+var maxPayloadSize = uint32(DefaultMaxPayloadSize)
+
+// PayloadDeserializer decodes serialized rpc arguments into a generic
+// value, e.g. hessian2 objects into maps and protobuf into field indexed
+// values
+type PayloadDeserializer interface {
+	Deserialize(b []byte) (interface{}, error)
+}
+
+// RegisterPayloadDeserializer register a deserializer under the given
+// name, expected to be called in init. Registration alone does not enable
+// it
+func RegisterPayloadDeserializer(name string, d PayloadDeserializer) {
+	payloadDeserializers[name] = d
+}
+
+// EnablePayloadDeserializer opt in the named deserializer, expected to be
+// called during config load by the filter that needs it
+func EnablePayloadDeserializer(name string) error {
+	if _, ok := payloadDeserializers[name]; !ok {
+		return fmt.Errorf("%v: %s", ErrDeserializerNotFound, name)
+	}
+	enabledDeserializers[name] = true
+	return nil
+}
+
+// SetMaxPayloadSize configures the payload size limit, 0 restores the
+// default
+func SetMaxPayloadSize(n uint32) {
+	if n == 0 {
+		n = DefaultMaxPayloadSize
+	}
+	atomic.StoreUint32(&maxPayloadSize, n)
+}
+
+// DeserializePayload decodes the payload with the named deserializer. It
+// refuses to work unless the deserializer was explicitly enabled, and
+// rejects payloads beyond the size limit without touching them
+func DeserializePayload(name string, b []byte) (interface{}, error) {
+	d, ok := payloadDeserializers[name]
+	if !ok {
+		return nil, fmt.Errorf("%v: %s", ErrDeserializerNotFound, name)
+	}
+	if !enabledDeserializers[name] {
+		return nil, fmt.Errorf("%v: %s", ErrDeserializerDisabled, name)
+	}
+	if limit := atomic.LoadUint32(&maxPayloadSize); uint32(len(b)) > limit {
+		return nil, fmt.Errorf("%v: %d bytes, limit %d", ErrPayloadTooLarge, len(b), limit)
+	}
+	return d.Deserialize(b)
+}
@@ -0,0 +1,112 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package serialize
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/AlexStocks/dubbogo/codec/hessian"
+)
+
+func resetDeserializers() {
+	enabledDeserializers = make(map[string]bool)
+	SetMaxPayloadSize(0)
+}
+
+func TestDeserializePayloadGate(t *testing.T) {
+	defer resetDeserializers()
+	resetDeserializers()
+
+	if _, err := DeserializePayload("nosuch", nil); err == nil || !strings.Contains(err.Error(), ErrDeserializerNotFound.Error()) {
+		t.Errorf("unknown deserializer should be rejected, got: %v", err)
+	}
+	if _, err := DeserializePayload(Hessian2, []byte{0x4e}); err == nil || !strings.Contains(err.Error(), ErrDeserializerDisabled.Error()) {
+		t.Errorf("disabled deserializer should refuse to work, got: %v", err)
+	}
+	if err := EnablePayloadDeserializer("nosuch"); err == nil {
+		t.Error("enabling an unknown deserializer should fail")
+	}
+	if err := EnablePayloadDeserializer(Hessian2); err != nil {
+		t.Fatal("enable hessian2 failed", err)
+	}
+	if _, err := DeserializePayload(Hessian2, []byte{0x4e}); err != nil {
+		t.Errorf("enabled deserializer should work, got: %v", err)
+	}
+
+	SetMaxPayloadSize(4)
+	if _, err := DeserializePayload(Hessian2, make([]byte, 5)); err == nil || !strings.Contains(err.Error(), ErrPayloadTooLarge.Error()) {
+		t.Errorf("oversized payload should be rejected, got: %v", err)
+	}
+}
+
+func TestHessian2Deserialize(t *testing.T) {
+	defer resetDeserializers()
+	resetDeserializers()
+	if err := EnablePayloadDeserializer(Hessian2); err != nil {
+		t.Fatal("enable hessian2 failed", err)
+	}
+
+	enc := hessian.NewEncoder()
+	if err := enc.Encode("payload-value"); err != nil {
+		t.Fatal("hessian encode failed", err)
+	}
+	v, err := DeserializePayload(Hessian2, enc.Buffer())
+	if err != nil {
+		t.Fatal("hessian deserialize failed", err)
+	}
+	if s, ok := v.(string); !ok || s != "payload-value" {
+		t.Errorf("unexpected hessian value: %v", v)
+	}
+}
+
+func TestProtobufDeserialize(t *testing.T) {
+	defer resetDeserializers()
+	resetDeserializers()
+	if err := EnablePayloadDeserializer(Protobuf); err != nil {
+		t.Fatal("enable protobuf failed", err)
+	}
+
+	// field 1 varint 150, field 2 string "test", field 2 string "again"
+	payload := []byte{0x08, 0x96, 0x01, 0x12, 0x04, 't', 'e', 's', 't', 0x12, 0x05, 'a', 'g', 'a', 'i', 'n'}
+	v, err := DeserializePayload(Protobuf, payload)
+	if err != nil {
+		t.Fatal("protobuf deserialize failed", err)
+	}
+	fields, ok := v.(map[uint32]interface{})
+	if !ok {
+		t.Fatalf("unexpected protobuf result type: %T", v)
+	}
+	if n, ok := fields[1].(uint64); !ok || n != 150 {
+		t.Errorf("field 1 should decode to varint 150, got: %v", fields[1])
+	}
+	repeated, ok := fields[2].([]interface{})
+	if !ok || len(repeated) != 2 {
+		t.Fatalf("field 2 should collect repeated values, got: %v", fields[2])
+	}
+	if string(repeated[0].([]byte)) != "test" || string(repeated[1].([]byte)) != "again" {
+		t.Errorf("repeated field values mismatch: %v", repeated)
+	}
+
+	if _, err := DeserializePayload(Protobuf, []byte{0x0b}); err == nil {
+		t.Error("group wire type should be rejected")
+	}
+	if _, err := DeserializePayload(Protobuf, []byte{0x12, 0xff}); err == nil {
+		t.Error("truncated length-delimited field should be rejected")
+	}
+}
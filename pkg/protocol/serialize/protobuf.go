@@ -0,0 +1,99 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package serialize
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Protobuf decodes the protobuf wire format without a schema. Values are
+// keyed by field number: varints as uint64, fixed32/fixed64 as
+// uint32/uint64, length-delimited fields as raw bytes. Repeated fields
+// collect into a slice. This is enough to match routing rules against
+// scalar arguments, callers owning the schema can unmarshal the raw bytes
+// themselves
+const Protobuf = "protobuf"
+
+var errProtobufGroup = errors.New("protobuf group encoding is not supported")
+
+func init() {
+	RegisterPayloadDeserializer(Protobuf, &protobufDeserializer{})
+}
+
+type protobufDeserializer struct{}
+
+func (p *protobufDeserializer) Deserialize(b []byte) (interface{}, error) {
+	fields := make(map[uint32]interface{})
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		if n <= 0 {
+			return nil, fmt.Errorf("protobuf decode, invalid tag varint at %d bytes left", len(b))
+		}
+		b = b[n:]
+		fieldNum := uint32(tag >> 3)
+		wireType := tag & 7
+
+		var value interface{}
+		switch wireType {
+		case 0: // varint
+			v, n := binary.Uvarint(b)
+			if n <= 0 {
+				return nil, fmt.Errorf("protobuf decode, invalid varint for field %d", fieldNum)
+			}
+			b = b[n:]
+			value = v
+		case 1: // fixed64
+			if len(b) < 8 {
+				return nil, fmt.Errorf("protobuf decode, short fixed64 for field %d", fieldNum)
+			}
+			value = binary.LittleEndian.Uint64(b)
+			b = b[8:]
+		case 2: // length-delimited
+			length, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < length {
+				return nil, fmt.Errorf("protobuf decode, invalid length for field %d", fieldNum)
+			}
+			b = b[n:]
+			value = b[:length]
+			b = b[length:]
+		case 5: // fixed32
+			if len(b) < 4 {
+				return nil, fmt.Errorf("protobuf decode, short fixed32 for field %d", fieldNum)
+			}
+			value = binary.LittleEndian.Uint32(b)
+			b = b[4:]
+		case 3, 4: // deprecated groups
+			return nil, errProtobufGroup
+		default:
+			return nil, fmt.Errorf("protobuf decode, unknown wire type %d for field %d", wireType, fieldNum)
+		}
+
+		if prev, ok := fields[fieldNum]; ok {
+			if repeated, ok := prev.([]interface{}); ok {
+				fields[fieldNum] = append(repeated, value)
+			} else {
+				fields[fieldNum] = []interface{}{prev, value}
+			}
+		} else {
+			fields[fieldNum] = value
+		}
+	}
+	return fields, nil
+}
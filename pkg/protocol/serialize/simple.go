@@ -19,9 +19,12 @@ package serialize
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"sofastack.io/sofa-mosn/pkg/types"
 	"reflect"
+	"sync"
+	"sync/atomic"
 	"unsafe"
 )
 
@@ -29,6 +32,44 @@ import (
 // singleton of simpleSerialization
 var Instance = simpleSerialization{}
 
+// ErrHeaderTooLarge is returned when a header map serializes beyond the
+// configured limit
+var ErrHeaderTooLarge = errors.New("serialized header map exceeds the size limit")
+
+// DefaultMaxHeaderSize bounds the serialized size of one header map. Bolt
+// carries the header length in an uint16, anything larger would silently
+// wrap and corrupt the frame
+const DefaultMaxHeaderSize = 1<<16 - 1
+
+var maxHeaderSize = uint32(DefaultMaxHeaderSize)
+
+// SetMaxHeaderSize configures the serialized header map size limit, 0 or
+// anything beyond the wire format maximum restores the default
+func SetMaxHeaderSize(n uint32) {
+	if n == 0 || n > DefaultMaxHeaderSize {
+		n = DefaultMaxHeaderSize
+	}
+	atomic.StoreUint32(&maxHeaderSize, n)
+}
+
+// mapSerializers pools the scratch state used while streaming a header
+// map into the outgoing IoBuffer, keeping the rpc hot path allocation free
+var mapSerializers = sync.Pool{
+	New: func() interface{} {
+		return new(mapSerializer)
+	},
+}
+
+type mapSerializer struct {
+	lenBuf [4]byte
+}
+
+func (ms *mapSerializer) writeString(v string, b types.IoBuffer) {
+	binary.BigEndian.PutUint32(ms.lenBuf[:], uint32(len(v)))
+	b.Write(ms.lenBuf[:])
+	b.Write(UnsafeStrToByte(v))
+}
+
 type simpleSerialization struct{}
 
 func (s *simpleSerialization) GetSerialNum() int {
@@ -36,24 +77,21 @@ func (s *simpleSerialization) GetSerialNum() int {
 }
 
 func (s *simpleSerialization) SerializeMap(m map[string]string, b types.IoBuffer) error {
-	lenBuf := make([]byte, 4)
-
+	// reject oversized maps before writing a single byte
+	size := 0
 	for key, value := range m {
+		size += 8 + len(key) + len(value)
+	}
+	if limit := atomic.LoadUint32(&maxHeaderSize); uint32(size) > limit {
+		return fmt.Errorf("%v: %d bytes, limit %d", ErrHeaderTooLarge, size, limit)
+	}
 
-		keyBytes := UnsafeStrToByte(key)
-		keyLen := len(keyBytes)
-
-		binary.BigEndian.PutUint32(lenBuf, uint32(keyLen))
-		b.Write(lenBuf)
-		b.Write(keyBytes)
-
-		valueBytes := UnsafeStrToByte(value)
-		valueLen := len(valueBytes)
-
-		binary.BigEndian.PutUint32(lenBuf, uint32(valueLen))
-		b.Write(lenBuf)
-		b.Write(valueBytes)
+	ms := mapSerializers.Get().(*mapSerializer)
+	for key, value := range m {
+		ms.writeString(key, b)
+		ms.writeString(value, b)
 	}
+	mapSerializers.Put(ms)
 
 	return nil
 }
@@ -18,10 +18,68 @@
 package serialize
 
 import (
-	"sofastack.io/sofa-mosn/pkg/buffer"
+	"strings"
 	"testing"
+
+	"sofastack.io/sofa-mosn/pkg/buffer"
 )
 
+func TestSerializeMapRoundTrip(t *testing.T) {
+	headers := map[string]string{
+		"service": "com.alipay.test.TestService:1.0",
+		"target":  "test-app",
+	}
+
+	buf := buffer.GetIoBuffer(128)
+	if err := Instance.SerializeMap(headers, buf); err != nil {
+		t.Fatalf("serialize failed: %v", err)
+	}
+
+	// 4 bytes of length before each key and each value
+	expected := 0
+	for key, value := range headers {
+		expected += 8 + len(key) + len(value)
+	}
+	if buf.Len() != expected {
+		t.Errorf("serialized %d bytes, expected %d", buf.Len(), expected)
+	}
+
+	decoded := make(map[string]string)
+	if err := Instance.DeserializeMap(buf.Bytes(), decoded); err != nil {
+		t.Fatalf("deserialize failed: %v", err)
+	}
+	if len(decoded) != len(headers) {
+		t.Fatalf("decoded %d entries, expected %d", len(decoded), len(headers))
+	}
+	for key, value := range headers {
+		if decoded[key] != value {
+			t.Errorf("key %s: expected %s, got %s", key, value, decoded[key])
+		}
+	}
+}
+
+func TestSerializeMapTooLarge(t *testing.T) {
+	SetMaxHeaderSize(64)
+	defer SetMaxHeaderSize(0)
+
+	headers := map[string]string{
+		"service": strings.Repeat("x", 128),
+	}
+	buf := buffer.GetIoBuffer(128)
+	if err := Instance.SerializeMap(headers, buf); err == nil {
+		t.Error("oversized header map should be rejected")
+	}
+	// nothing may be written before the rejection
+	if buf.Len() != 0 {
+		t.Errorf("rejected map wrote %d bytes", buf.Len())
+	}
+
+	small := map[string]string{"service": "test"}
+	if err := Instance.SerializeMap(small, buf); err != nil {
+		t.Errorf("map within the limit rejected: %v", err)
+	}
+}
+
 func BenchmarkSerializeMap(b *testing.B) {
 	headers := map[string]string{
 		"service": "com.alipay.test.TestService:1.0",
@@ -0,0 +1,107 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package protocol
+
+import (
+	"fmt"
+
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+// The transcoder registry generalizes the protocol conversion in conv.go.
+// A downstream request is transcoded to the upstream protocol chosen per
+// route(route rule upstream_protocol) or per proxy(proxy upstream_protocol),
+// e.g. HTTP1 -> Bolt or Bolt -> HTTP2. Implementations register either a
+// direct transcoder for a protocol pair, or from/to the common
+// representation and let ConvertHeader bridge the pair in two hops.
+//
+// On top of the registered transcoders, deployments can declare header
+// mapping rules in config which are applied to the transcoded headers of a
+// protocol pair. RPC protocol fields(e.g. sofarpc class name, request id)
+// surface as property headers in the common representation, so the rules
+// cover field mapping as well.
+var transcodeRules = make(map[types.Protocol]map[types.Protocol]*HeaderMappingRules)
+
+// RegisterTranscoder register a transcoder for the given protocol pair.
+// It is the pluggable entry for protocol pairs the common representation
+// can not bridge, and overrides a previously registered transcoder
+func RegisterTranscoder(src, dst types.Protocol, t ProtocolConv) {
+	RegisterConv(src, dst, t)
+}
+
+// TranscodeRule is the config-defined header/field mapping for one
+// protocol pair, declared in the proxy extend config
+type TranscodeRule struct {
+	SrcProtocol string              `json:"src_protocol"`
+	DstProtocol string              `json:"dst_protocol"`
+	Headers     *HeaderMappingRules `json:"headers,omitempty"`
+}
+
+// HeaderMappingRules rewrites transcoded headers. Rename moves values to
+// new keys first, then Set writes fixed values, then Remove deletes keys
+type HeaderMappingRules struct {
+	Set    map[string]string `json:"set,omitempty"`
+	Rename map[string]string `json:"rename,omitempty"`
+	Remove []string          `json:"remove,omitempty"`
+}
+
+// ApplyTo rewrites the headers in place following the rule order
+func (r *HeaderMappingRules) ApplyTo(headers types.HeaderMap) {
+	if r == nil || headers == nil {
+		return
+	}
+	for from, to := range r.Rename {
+		if value, ok := headers.Get(from); ok {
+			headers.Del(from)
+			headers.Set(to, value)
+		}
+	}
+	for key, value := range r.Set {
+		headers.Set(key, value)
+	}
+	for _, key := range r.Remove {
+		headers.Del(key)
+	}
+}
+
+// SetTranscodeRules install the config-defined mapping rules. Expected to
+// be called during config load, before traffic is served
+func SetTranscodeRules(rules []TranscodeRule) error {
+	for _, rule := range rules {
+		if rule.SrcProtocol == "" || rule.DstProtocol == "" {
+			return fmt.Errorf("transcode rule needs both src_protocol and dst_protocol, got: %+v", rule)
+		}
+		src := types.Protocol(rule.SrcProtocol)
+		dst := types.Protocol(rule.DstProtocol)
+		if _, ok := transcodeRules[src]; !ok {
+			transcodeRules[src] = make(map[types.Protocol]*HeaderMappingRules)
+		}
+		transcodeRules[src][dst] = rule.Headers
+	}
+	return nil
+}
+
+// applyTranscodeRules rewrites the transcoded headers with the rules
+// declared for the protocol pair, if any
+func applyTranscodeRules(src, dst types.Protocol, headers types.HeaderMap) {
+	if sub, ok := transcodeRules[src]; ok {
+		if rules, ok := sub[dst]; ok {
+			rules.ApplyTo(headers)
+		}
+	}
+}
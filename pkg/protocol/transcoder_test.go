@@ -0,0 +1,107 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package protocol
+
+import (
+	"context"
+	"testing"
+
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+// passThroughConv forwards the header untouched, stands in for a real
+// protocol pair transcoder
+type passThroughConv struct{}
+
+func (c *passThroughConv) ConvHeader(ctx context.Context, headerMap types.HeaderMap) (types.HeaderMap, error) {
+	return headerMap, nil
+}
+
+func (c *passThroughConv) ConvData(ctx context.Context, buffer types.IoBuffer) (types.IoBuffer, error) {
+	return buffer, nil
+}
+
+func (c *passThroughConv) ConvTrailer(ctx context.Context, headerMap types.HeaderMap) (types.HeaderMap, error) {
+	return headerMap, nil
+}
+
+func TestHeaderMappingRulesApplyTo(t *testing.T) {
+	rules := &HeaderMappingRules{
+		Set:    map[string]string{"service": "test-service"},
+		Rename: map[string]string{"x-legacy-id": "x-id"},
+		Remove: []string{"x-internal"},
+	}
+	headers := CommonHeader{
+		"x-legacy-id": "1",
+		"x-internal":  "secret",
+		"keep":        "v",
+	}
+	rules.ApplyTo(headers)
+
+	if v, ok := headers.Get("x-id"); !ok || v != "1" {
+		t.Errorf("rename rule not applied, headers: %v", headers)
+	}
+	if _, ok := headers.Get("x-legacy-id"); ok {
+		t.Errorf("renamed key should be removed, headers: %v", headers)
+	}
+	if v, ok := headers.Get("service"); !ok || v != "test-service" {
+		t.Errorf("set rule not applied, headers: %v", headers)
+	}
+	if _, ok := headers.Get("x-internal"); ok {
+		t.Errorf("remove rule not applied, headers: %v", headers)
+	}
+	if v, ok := headers.Get("keep"); !ok || v != "v" {
+		t.Errorf("unrelated key should survive, headers: %v", headers)
+	}
+}
+
+func TestSetTranscodeRulesValidation(t *testing.T) {
+	if err := SetTranscodeRules([]TranscodeRule{
+		{SrcProtocol: "testsrc"},
+	}); err == nil {
+		t.Error("rule without dst_protocol should be rejected")
+	}
+}
+
+func TestConvertHeaderAppliesTranscodeRules(t *testing.T) {
+	src := types.Protocol("testsrc")
+	dst := types.Protocol("testdst")
+	RegisterTranscoder(src, dst, &passThroughConv{})
+	if err := SetTranscodeRules([]TranscodeRule{
+		{
+			SrcProtocol: string(src),
+			DstProtocol: string(dst),
+			Headers: &HeaderMappingRules{
+				Set: map[string]string{"x-transcoded": "true"},
+			},
+		},
+	}); err != nil {
+		t.Fatal("set transcode rules failed", err)
+	}
+
+	headers, err := ConvertHeader(context.Background(), src, dst, CommonHeader{"k": "v"})
+	if err != nil {
+		t.Fatal("convert header failed", err)
+	}
+	if v, ok := headers.Get("x-transcoded"); !ok || v != "true" {
+		t.Errorf("configured mapping rules should apply to transcoded headers, got: %v", headers)
+	}
+	if v, ok := headers.Get("k"); !ok || v != "v" {
+		t.Errorf("transcoded header lost origin entries, got: %v", headers)
+	}
+}
@@ -44,6 +44,19 @@ const (
 	MosnHeaderQueryStringKey  = "x-mosn-querystring"
 	MosnHeaderMethod          = "x-mosn-method"
 	MosnOriginalHeaderPathKey = "x-mosn-original-path"
+	// MosnHeaderAttemptCount tells the upstream how many times the request
+	// was sent, the first attempt is 1, every retry increments it
+	MosnHeaderAttemptCount = "x-mosn-attempt-count"
+	// MosnHeaderRetry marks retried requests so backends and traces can
+	// distinguish them from first attempts
+	MosnHeaderRetry = "x-mosn-retry"
+	// MosnHeaderRetriable lets a caller override the idempotency check of
+	// automatic retries, "true" forces the request retriable, any other
+	// value forbids retrying it
+	MosnHeaderRetriable = "x-mosn-retriable"
+	// HeaderServerTiming is the standard response header backends use to
+	// report their own timings, mosn surfaces it into the request info
+	HeaderServerTiming = "server-timing"
 )
 
 // Hseader with special meaning in istio
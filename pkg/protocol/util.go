@@ -22,13 +22,34 @@ import (
 	"sync/atomic"
 )
 
-var defaultGenerator IDGenerator
+// StreamIDGenerator produces stream/request ids. The default is an
+// auto-increment counter, tests can inject a seeded one via SetIDGenerator
+// to get reproducible ids
+type StreamIDGenerator interface {
+	Get() uint64
+	GetString() string
+}
+
+var defaultGenerator StreamIDGenerator = &IDGenerator{}
+
+// SetIDGenerator replaces the global id source, nil restores the default
+func SetIDGenerator(g StreamIDGenerator) {
+	if g == nil {
+		g = &IDGenerator{}
+	}
+	defaultGenerator = g
+}
 
 // IDGenerator utility to generate auto-increment ids
 type IDGenerator struct {
 	counter uint64
 }
 
+// NewIDGenerator creates a generator whose first id is start + 1
+func NewIDGenerator(start uint64) *IDGenerator {
+	return &IDGenerator{counter: start}
+}
+
 // Get get id
 func (g *IDGenerator) Get() uint64 {
 	return atomic.AddUint64(&g.counter, 1)
@@ -0,0 +1,119 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"sofastack.io/sofa-mosn/pkg/metrics"
+	"sofastack.io/sofa-mosn/pkg/protocol"
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+// RequestClassifier maps a request onto a logical operation name used as a
+// metric label, e.g. a templated path or an RPC method. An empty name
+// leaves the request unclassified. Implementations must keep the result
+// set small, raw request paths are not a suitable operation name
+type RequestClassifier func(ctx context.Context, headers types.HeaderMap) string
+
+var requestClassifiers = make(map[string]RequestClassifier)
+
+// RegisterRequestClassifier registers a named classifier, should be called
+// during init. The proxy selects one by name via the extend config key
+// "request_classifier"
+func RegisterRequestClassifier(name string, classifier RequestClassifier) {
+	requestClassifiers[name] = classifier
+}
+
+func requestClassifierByName(name string) RequestClassifier {
+	return requestClassifiers[name]
+}
+
+func init() {
+	// the gRPC service/method is a naturally bounded operation name
+	RegisterRequestClassifier("grpc_method", func(ctx context.Context, headers types.HeaderMap) string {
+		if headers == nil || !isGrpcRequest(headers) {
+			return ""
+		}
+		if method, ok := grpcServiceMethod(headers); ok {
+			return method
+		}
+		return ""
+	})
+	// the http method alone, coarse but always bounded
+	RegisterRequestClassifier("http_method", func(ctx context.Context, headers types.HeaderMap) string {
+		if headers == nil {
+			return ""
+		}
+		method, _ := headers.Get(protocol.MosnHeaderMethod)
+		return method
+	})
+}
+
+// maxClassifiedOperations caps the distinct operation labels classifiers
+// may produce in total, a misbehaving classifier must not blow up the
+// metrics store
+const maxClassifiedOperations = 1000
+
+// overflowOperation collects requests classified beyond the cap
+const overflowOperation = "other"
+
+var (
+	classifiedMux        sync.Mutex
+	classifiedOperations = make(map[string]struct{})
+)
+
+// boundOperation admits at most maxClassifiedOperations distinct names,
+// anything new beyond that is folded into the overflow bucket
+func boundOperation(op string) string {
+	classifiedMux.Lock()
+	defer classifiedMux.Unlock()
+	if _, ok := classifiedOperations[op]; ok {
+		return op
+	}
+	if len(classifiedOperations) >= maxClassifiedOperations {
+		return overflowOperation
+	}
+	classifiedOperations[op] = struct{}{}
+	return op
+}
+
+// recordAPIRequest counts a classified request, the result side is counted
+// by finishAPIStats when the stream ends
+func recordAPIRequest(operation string) {
+	metrics.NewAPIStats(operation).Counter(metrics.APIRequestTotal).Inc(1)
+}
+
+// finishAPIStats counts the classified request result when the stream ends
+func (s *downStream) finishAPIStats() {
+	if s.proxy.classifier == nil || s.downstreamReqHeaders == nil {
+		return
+	}
+	op := s.proxy.classifier(s.context, s.downstreamReqHeaders)
+	if op == "" {
+		return
+	}
+	stats := metrics.NewAPIStats(boundOperation(op))
+	if s.requestInfo.ResponseCode() >= http.StatusInternalServerError {
+		stats.Counter(metrics.APIResponseFailed).Inc(1)
+	} else {
+		stats.Counter(metrics.APIResponseSuccess).Inc(1)
+	}
+}
@@ -0,0 +1,94 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"sofastack.io/sofa-mosn/pkg/protocol"
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+func TestRequestClassifierRegistry(t *testing.T) {
+	RegisterRequestClassifier("test_static", func(ctx context.Context, headers types.HeaderMap) string {
+		return "op"
+	})
+	if requestClassifierByName("test_static") == nil {
+		t.Error("registered classifier not found")
+	}
+	if requestClassifierByName("no_such_classifier") != nil {
+		t.Error("unknown classifier should be nil")
+	}
+}
+
+func TestGrpcMethodClassifier(t *testing.T) {
+	classifier := requestClassifierByName("grpc_method")
+	if classifier == nil {
+		t.Fatal("builtin grpc_method classifier missing")
+	}
+
+	headers := protocol.CommonHeader(map[string]string{
+		"content-type":             "application/grpc",
+		protocol.MosnHeaderPathKey: "/pkg.Service/Method",
+	})
+	if op := classifier(context.Background(), headers); op != "pkg.Service/Method" {
+		t.Errorf("unexpected operation: %s", op)
+	}
+
+	plain := protocol.CommonHeader(map[string]string{
+		"content-type":             "application/json",
+		protocol.MosnHeaderPathKey: "/pkg.Service/Method",
+	})
+	if op := classifier(context.Background(), plain); op != "" {
+		t.Errorf("non-grpc request classified: %s", op)
+	}
+}
+
+func TestHttpMethodClassifier(t *testing.T) {
+	classifier := requestClassifierByName("http_method")
+	if classifier == nil {
+		t.Fatal("builtin http_method classifier missing")
+	}
+	headers := protocol.CommonHeader(map[string]string{
+		protocol.MosnHeaderMethod: "GET",
+	})
+	if op := classifier(context.Background(), headers); op != "GET" {
+		t.Errorf("unexpected operation: %s", op)
+	}
+}
+
+func TestBoundOperationOverflow(t *testing.T) {
+	admitted := boundOperation("bound-op-keep")
+	if admitted != "bound-op-keep" {
+		t.Fatalf("operation not admitted: %s", admitted)
+	}
+
+	// fill the table up to the cap, everything new must fold into the
+	// overflow bucket while admitted names stay stable
+	for i := 0; len(classifiedOperations) < maxClassifiedOperations; i++ {
+		boundOperation(fmt.Sprintf("bound-op-fill-%d", i))
+	}
+	if op := boundOperation("bound-op-overflow"); op != overflowOperation {
+		t.Errorf("expected overflow bucket, got %s", op)
+	}
+	if op := boundOperation("bound-op-keep"); op != "bound-op-keep" {
+		t.Errorf("admitted operation lost: %s", op)
+	}
+}
@@ -34,6 +34,8 @@ import (
 	"runtime/debug"
 
 	"sofastack.io/sofa-mosn/pkg/buffer"
+	"sofastack.io/sofa-mosn/pkg/flightrecorder"
+	"sofastack.io/sofa-mosn/pkg/journal"
 	"sofastack.io/sofa-mosn/pkg/log"
 	"sofastack.io/sofa-mosn/pkg/protocol"
 	"sofastack.io/sofa-mosn/pkg/protocol/http"
@@ -58,12 +60,14 @@ type downStream struct {
 	bufferLimit uint32
 
 	// ~~~ control args
-	timeout    Timeout
-	retryState *retryState
+	timeout        Timeout
+	deadlineCancel context.CancelFunc
+	retryState     *retryState
 
 	requestInfo     types.RequestInfo
 	responseSender  types.StreamSender
 	upstreamRequest *upstreamRequest
+	shadowRequest   *shadowRequest
 	perRetryTimer   *utils.Timer
 	responseTimer   *utils.Timer
 
@@ -210,6 +214,21 @@ func (s *downStream) cleanStream() {
 	s.proxy.listenerStats.DownstreamRequestTime.Update(streamDurationNs)
 	s.proxy.listenerStats.DownstreamRequestTimeTotal.Inc(streamDurationNs)
 
+	// per error code counters
+	if code := s.requestInfo.ErrorCode(); code != types.ErrCodeNone {
+		s.proxy.stats.DownstreamRequestErrorTotal(code).Inc(1)
+		s.proxy.listenerStats.DownstreamRequestErrorTotal(code).Inc(1)
+	}
+
+	// per-method gRPC result counters and access log fields
+	s.finishGrpcStats()
+
+	// per-operation api result counters
+	s.finishAPIStats()
+
+	// rolling slo compliance per cluster
+	s.finishSLOStats()
+
 	// finish tracing
 	s.finishTracing()
 
@@ -246,6 +265,11 @@ func (s *downStream) writeLog() {
 			al.Log(s.downstreamReqHeaders, s.downstreamRespHeaders, s.requestInfo)
 		}
 	}
+
+	// flight recorder sampling
+	if fr := flightrecorder.Get(); fr != nil {
+		fr.Sample(s.downstreamReqHeaders, s.requestInfo)
+	}
 }
 
 // types.StreamEventListener
@@ -541,6 +565,7 @@ func (s *downStream) matchRoute() {
 	// handlerChain should never be nil
 	if handlerChain == nil {
 		log.Proxy.Errorf(s.context, "[proxy] [downstream] no route to make handler chain, headers = %v", headers)
+		journal.Record(journal.TypeRouteMiss, "no route matched, headers = %v", headers)
 		s.requestInfo.SetResponseFlag(types.NoRouteFound)
 		s.sendHijackReply(types.RouterUnavailableCode, headers)
 		return
@@ -624,12 +649,46 @@ func (s *downStream) receiveHeaders(endStream bool) {
 	}
 
 	s.cluster = s.snapshot.ClusterInfo()
+	// remember the determined cluster, e.g. for per-weighted-cluster header mutations
+	s.requestInfo.SetUpstreamCluster(s.cluster.Name())
+
+	// websocket handshakes switch the whole connection into a raw splice
+	if isWebSocketUpgrade(s.downstreamReqHeaders) {
+		s.handleWebSocketUpgrade()
+		return
+	}
+
+	// per-method gRPC call counter, the result side is counted on stream end
+	if isGrpcRequest(s.downstreamReqHeaders) {
+		if method, ok := grpcServiceMethod(s.downstreamReqHeaders); ok {
+			recordGrpcRequest(clusterName, method)
+		}
+	}
+
+	// per-operation api counter when a request classifier is configured
+	if s.proxy.classifier != nil {
+		if op := s.proxy.classifier(s.context, s.downstreamReqHeaders); op != "" {
+			recordAPIRequest(boundOperation(op))
+		}
+	}
 
 	s.requestInfo.SetRouteEntry(s.route.RouteRule())
 	s.requestInfo.SetDownstreamLocalAddress(s.proxy.readCallbacks.Connection().LocalAddr())
 	// todo: detect remote addr
 	s.requestInfo.SetDownstreamRemoteAddress(s.proxy.readCallbacks.Connection().RemoteAddr())
 
+	parseProxyTimeout(&s.timeout, s.route, s.downstreamReqHeaders)
+	if log.Proxy.GetLogLevel() >= log.DEBUG {
+		log.Proxy.Debugf(s.context, "[proxy] [downstream] timeout info: %+v", s.timeout)
+	}
+
+	// attach the request deadline to the stream context, so extension points
+	// (stream filters, load balancers, connection pools) can give up expensive
+	// work once the downstream has already timed out
+	if s.timeout.GlobalTimeout > 0 {
+		s.context, s.deadlineCancel = mosnctx.WithDeadline(s.context, time.Now().Add(s.timeout.GlobalTimeout))
+	}
+
 	pool, err := s.initializeUpstreamConnectionPool(s)
 	if err != nil {
 		log.Proxy.Errorf(s.context, "[proxy] [downstream] initialize Upstream Connection Pool error, request can't be proxyed, error = %v", err)
@@ -638,11 +697,6 @@ func (s *downStream) receiveHeaders(endStream bool) {
 		return
 	}
 
-	parseProxyTimeout(&s.timeout, s.route, s.downstreamReqHeaders)
-	if log.Proxy.GetLogLevel() >= log.DEBUG {
-		log.Proxy.Debugf(s.context, "[proxy] [downstream] timeout info: %+v", s.timeout)
-	}
-
 	prot := s.getUpstreamProtocol()
 
 	s.retryState = newRetryState(s.route.RouteRule().Policy().RetryPolicy(), s.downstreamReqHeaders, s.cluster, prot)
@@ -656,6 +710,9 @@ func (s *downStream) receiveHeaders(endStream bool) {
 	s.upstreamRequest.connPool = pool
 	s.route.RouteRule().FinalizeRequestHeaders(s.downstreamReqHeaders, s.requestInfo)
 
+	// mirror the finalized request if the route has a shadow policy
+	s.prepareShadow()
+
 	//Call upstream's append header method to build upstream's request
 	s.upstreamRequest.appendHeaders(endStream)
 
@@ -677,6 +734,8 @@ func (s *downStream) receiveData(endStream bool) {
 	s.requestInfo.SetBytesReceived(s.requestInfo.BytesReceived() + uint64(data.Len()))
 	s.downstreamRecvDone = endStream
 
+	s.shadowAppendData(data)
+
 	if endStream {
 		s.onUpstreamRequestSent()
 	}
@@ -697,6 +756,8 @@ func (s *downStream) receiveTrailers() {
 
 	s.downstreamRecvDone = true
 
+	s.shadowAppendTrailers(s.downstreamReqTrailers)
+
 	s.onUpstreamRequestSent()
 	s.upstreamRequest.appendTrailers()
 
@@ -712,6 +773,8 @@ func (s *downStream) OnDecodeError(context context.Context, err error, headers t
 		return
 	}
 
+	s.requestInfo.SetErrorCode(types.ErrCodeCodecError)
+
 	// todo: enrich headers' information to do some hijack
 	// Check headers' info to do hijack
 	switch err.Error() {
@@ -728,6 +791,9 @@ func (s *downStream) onUpstreamRequestSent() {
 	s.upstreamRequestSent = true
 	s.requestInfo.SetRequestReceivedDuration(time.Now())
 
+	// the request is received completely, send the buffered mirror
+	s.startShadowRequest()
+
 	if s.upstreamRequest != nil && !s.oneway {
 		// setup per req timeout timer
 		s.setupPerReqTimeout()
@@ -844,6 +910,8 @@ func (s *downStream) initializeUpstreamConnectionPool(lbCtx types.LoadBalancerCo
 
 func (s *downStream) appendHeaders(endStream bool) {
 	s.upstreamProcessDone = endStream
+	// route trace for trusted debug requests
+	s.appendRouteTrace(s.downstreamRespHeaders)
 	headers := s.convertHeader(s.downstreamRespHeaders)
 	//Currently, just log the error
 	if err := s.responseSender.AppendHeaders(s.context, headers, endStream); err != nil {
@@ -868,11 +936,31 @@ func (s *downStream) convertHeader(headers types.HeaderMap) types.HeaderMap {
 			return convHeader
 		} else {
 			log.Proxy.Warnf(s.context, "[proxy] [downstream] convert header from %s to %s failed, %s", up, dp, err.Error())
+			if s.proxy.translationLocalError {
+				// answer a local error instead of leaking the raw upstream
+				// response, the stream layer builds a protocol reply from
+				// the hijack status header
+				s.countTranslationFailure(false)
+				s.requestInfo.SetResponseCode(http.InternalServerError)
+				return protocol.CommonHeader{types.HeaderStatus: strconv.Itoa(http.InternalServerError)}
+			}
+			s.countTranslationFailure(true)
 		}
 	}
 	return headers
 }
 
+// countTranslationFailure records a failed protocol translation, the
+// pass-through counter tracks inputs forwarded untranslated
+func (s *downStream) countTranslationFailure(passThrough bool) {
+	s.proxy.stats.DownstreamTranslationFailed.Inc(1)
+	s.proxy.listenerStats.DownstreamTranslationFailed.Inc(1)
+	if passThrough {
+		s.proxy.stats.DownstreamTranslationPassThrough.Inc(1)
+		s.proxy.listenerStats.DownstreamTranslationPassThrough.Inc(1)
+	}
+}
+
 func (s *downStream) appendData(endStream bool) {
 	s.upstreamProcessDone = endStream
 
@@ -898,6 +986,9 @@ func (s *downStream) convertData(data types.IoBuffer) types.IoBuffer {
 			return convData
 		} else {
 			log.Proxy.Warnf(s.context, "[proxy] [downstream] convert data from %s to %s failed, %s", up, dp, err.Error())
+			// the response line is already committed, raw pass-through is
+			// the only option left
+			s.countTranslationFailure(true)
 		}
 	}
 	return data
@@ -923,6 +1014,7 @@ func (s *downStream) convertTrailer(trailers types.HeaderMap) types.HeaderMap {
 			return convTrailer
 		} else {
 			log.Proxy.Warnf(s.context, "[proxy] [downstream] convert header from %s to %s failed, %s", up, dp, err.Error())
+			s.countTranslationFailure(true)
 		}
 	}
 	return trailers
@@ -1110,6 +1202,11 @@ func (s *downStream) setupRetry(endStream bool) bool {
 func (s *downStream) doRetry() {
 	// no reuse buffer
 	atomic.StoreUint32(&s.reuseBuffer, 0)
+	s.requestInfo.SetAttemptCount(s.requestInfo.AttemptCount() + 1)
+	if s.route != nil && s.route.RouteRule() != nil {
+		journal.Record(journal.TypeRetry, "retry attempt %d, cluster = %s",
+			s.requestInfo.AttemptCount(), s.route.RouteRule().ClusterName())
+	}
 
 	pool, err := s.initializeUpstreamConnectionPool(s)
 
@@ -1212,6 +1309,11 @@ func (s *downStream) cleanUp() {
 		s.responseTimer = nil
 	}
 
+	// release the request deadline context
+	if s.deadlineCancel != nil {
+		s.deadlineCancel()
+		s.deadlineCancel = nil
+	}
 }
 
 func (s *downStream) setBufferLimit(bufferLimit uint32) {
@@ -166,3 +166,41 @@ func TestOnewayHijack(t *testing.T) {
 		t.Errorf("downStream should be cleaned")
 	}
 }
+
+func TestTranslationFallback(t *testing.T) {
+	newStream := func(name string, localError bool) *downStream {
+		return &downStream{
+			context: context.Background(),
+			proxy: &proxy{
+				config:                &v2.Proxy{DownstreamProtocol: "proto-a", UpstreamProtocol: "proto-b"},
+				translationLocalError: localError,
+				stats:                 newProxyStats(name),
+				listenerStats:         newListenerStats(name),
+			},
+			requestInfo: &network.RequestInfo{},
+		}
+	}
+	headers := protocol.CommonHeader{"k": "v"}
+
+	// the default forwards the raw headers and counts the pass-through
+	s := newStream("translation-pass", false)
+	out := s.convertHeader(headers)
+	if _, ok := out.Get("k"); !ok {
+		t.Error("pass-through fallback should keep the raw headers")
+	}
+	if s.proxy.stats.DownstreamTranslationFailed.Count() != 1 ||
+		s.proxy.stats.DownstreamTranslationPassThrough.Count() != 1 {
+		t.Error("translation failure should be counted")
+	}
+
+	// local-error answers a hijack style reply instead
+	s = newStream("translation-err", true)
+	out = s.convertHeader(headers)
+	if code, ok := out.Get(types.HeaderStatus); !ok || code != "500" {
+		t.Errorf("local-error fallback should build an error reply, got: %v", out)
+	}
+	if s.proxy.stats.DownstreamTranslationFailed.Count() != 1 ||
+		s.proxy.stats.DownstreamTranslationPassThrough.Count() != 0 {
+		t.Error("local-error fallback should not count a pass-through")
+	}
+}
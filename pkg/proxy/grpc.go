@@ -0,0 +1,155 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"sofastack.io/sofa-mosn/pkg/metrics"
+	"sofastack.io/sofa-mosn/pkg/protocol"
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+// gRPC wire headers, see the gRPC-over-HTTP2 specification
+const (
+	grpcContentType   = "application/grpc"
+	headerGrpcTimeout = "grpc-timeout"
+	headerGrpcStatus  = "grpc-status"
+	headerGrpcMessage = "grpc-message"
+)
+
+// isGrpcRequest reports whether the request is a gRPC call. gRPC is always
+// carried with an application/grpc content-type, optionally suffixed with
+// the message encoding, e.g. application/grpc+proto. A grpc-web
+// content-type deliberately does not match
+func isGrpcRequest(headers types.HeaderMap) bool {
+	ct, _ := headers.Get("content-type")
+	if ct == grpcContentType {
+		return true
+	}
+	return strings.HasPrefix(ct, grpcContentType) && len(ct) > len(grpcContentType) &&
+		(ct[len(grpcContentType)] == '+' || ct[len(grpcContentType)] == ';')
+}
+
+// grpcServiceMethod extracts "package.Service/Method" from the request
+// path, which is "/package.Service/Method" for gRPC
+func grpcServiceMethod(headers types.HeaderMap) (string, bool) {
+	path, _ := headers.Get(protocol.MosnHeaderPathKey)
+	if path == "" {
+		path, _ = headers.Get(":path")
+	}
+	if len(path) < 2 || path[0] != '/' {
+		return "", false
+	}
+	sm := path[1:]
+	if idx := strings.IndexByte(sm, '/'); idx <= 0 || idx == len(sm)-1 {
+		return "", false
+	}
+	return sm, true
+}
+
+// parseGrpcTimeout parses a grpc-timeout header value, at most 8 digits
+// followed by a single unit character
+func parseGrpcTimeout(v string) (time.Duration, bool) {
+	if len(v) < 2 || len(v) > 9 {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(v[:len(v)-1], 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	var unit time.Duration
+	switch v[len(v)-1] {
+	case 'H':
+		unit = time.Hour
+	case 'M':
+		unit = time.Minute
+	case 'S':
+		unit = time.Second
+	case 'm':
+		unit = time.Millisecond
+	case 'u':
+		unit = time.Microsecond
+	case 'n':
+		unit = time.Nanosecond
+	default:
+		return 0, false
+	}
+	return time.Duration(n) * unit, true
+}
+
+// grpcMetricsKey flattens a service/method pair into a metrics key part
+func grpcMetricsKey(serviceMethod string) string {
+	return strings.Replace(serviceMethod, "/", ".", -1)
+}
+
+// recordGrpcRequest counts a gRPC call per cluster and method, the result
+// side is counted by recordGrpcResponse when the stream ends
+func recordGrpcRequest(clusterName, serviceMethod string) {
+	metrics.NewClusterStats(clusterName).Counter("grpc." + grpcMetricsKey(serviceMethod) + ".request_total").Inc(1)
+}
+
+// recordGrpcResponse counts the call result, only grpc-status 0 is a
+// success. A missing status counts as a failure since that is what the
+// client sees, e.g. an upstream reset before the trailers
+func recordGrpcResponse(clusterName, serviceMethod, status string) {
+	suffix := ".failure_total"
+	if status == "0" {
+		suffix = ".success_total"
+	}
+	metrics.NewClusterStats(clusterName).Counter("grpc." + grpcMetricsKey(serviceMethod) + suffix).Inc(1)
+}
+
+// grpcResponseStatus reads grpc-status/grpc-message from the response. The
+// status normally arrives in the trailers, but a trailers-only response
+// carries it in the headers
+func grpcResponseStatus(trailers, headers types.HeaderMap) (status, message string) {
+	if trailers != nil {
+		if v, _ := trailers.Get(headerGrpcStatus); v != "" {
+			status = v
+			message, _ = trailers.Get(headerGrpcMessage)
+			return
+		}
+	}
+	if headers != nil {
+		if v, _ := headers.Get(headerGrpcStatus); v != "" {
+			status = v
+			message, _ = headers.Get(headerGrpcMessage)
+		}
+	}
+	return
+}
+
+// finishGrpcStats parses the gRPC result when the stream ends, surfaces it
+// into the request info for access logs and counts per-method results
+func (s *downStream) finishGrpcStats() {
+	if s.downstreamReqHeaders == nil || !isGrpcRequest(s.downstreamReqHeaders) {
+		return
+	}
+	status, message := grpcResponseStatus(s.downstreamRespTrailers, s.downstreamRespHeaders)
+	if status != "" {
+		s.requestInfo.SetGrpcStatus(status, message)
+	}
+	method, ok := grpcServiceMethod(s.downstreamReqHeaders)
+	if !ok || s.cluster == nil {
+		return
+	}
+	recordGrpcResponse(s.cluster.Name(), method, status)
+}
@@ -0,0 +1,108 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"testing"
+	"time"
+
+	"sofastack.io/sofa-mosn/pkg/protocol"
+)
+
+func TestIsGrpcRequest(t *testing.T) {
+	cases := []struct {
+		contentType string
+		expected    bool
+	}{
+		{"application/grpc", true},
+		{"application/grpc+proto", true},
+		{"application/grpc-web", false},
+		{"application/json", false},
+		{"", false},
+	}
+	for _, c := range cases {
+		headers := protocol.CommonHeader(map[string]string{"content-type": c.contentType})
+		if got := isGrpcRequest(headers); got != c.expected {
+			t.Errorf("content-type %q: expected %v, got %v", c.contentType, c.expected, got)
+		}
+	}
+}
+
+func TestGrpcServiceMethod(t *testing.T) {
+	cases := []struct {
+		path     string
+		expected string
+		ok       bool
+	}{
+		{"/helloworld.Greeter/SayHello", "helloworld.Greeter/SayHello", true},
+		{"/a/b", "a/b", true},
+		{"/nomethod", "", false},
+		{"/trailing/", "", false},
+		{"noslash", "", false},
+		{"", "", false},
+	}
+	for _, c := range cases {
+		headers := protocol.CommonHeader(map[string]string{protocol.MosnHeaderPathKey: c.path})
+		sm, ok := grpcServiceMethod(headers)
+		if ok != c.ok || sm != c.expected {
+			t.Errorf("path %q: expected (%q, %v), got (%q, %v)", c.path, c.expected, c.ok, sm, ok)
+		}
+	}
+}
+
+func TestParseGrpcTimeout(t *testing.T) {
+	cases := []struct {
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{"1S", time.Second, true},
+		{"100m", 100 * time.Millisecond, true},
+		{"2H", 2 * time.Hour, true},
+		{"3M", 3 * time.Minute, true},
+		{"500u", 500 * time.Microsecond, true},
+		{"99999999n", 99999999 * time.Nanosecond, true},
+		{"100000000m", 0, false}, // more than 8 digits
+		{"10", 0, false},         // missing unit
+		{"S", 0, false},          // missing digits
+		{"-1S", 0, false},
+		{"", 0, false},
+	}
+	for _, c := range cases {
+		d, ok := parseGrpcTimeout(c.value)
+		if ok != c.ok || d != c.expected {
+			t.Errorf("value %q: expected (%v, %v), got (%v, %v)", c.value, c.expected, c.ok, d, ok)
+		}
+	}
+}
+
+func TestGrpcResponseStatus(t *testing.T) {
+	// status in the trailers wins
+	trailers := protocol.CommonHeader(map[string]string{"grpc-status": "0"})
+	headers := protocol.CommonHeader(map[string]string{"grpc-status": "2", "grpc-message": "broken"})
+	if status, _ := grpcResponseStatus(trailers, headers); status != "0" {
+		t.Errorf("expected trailer status 0, got %q", status)
+	}
+	// trailers-only responses carry the status in the headers
+	if status, message := grpcResponseStatus(nil, headers); status != "2" || message != "broken" {
+		t.Errorf("expected header status 2/broken, got %q/%q", status, message)
+	}
+	if status, _ := grpcResponseStatus(nil, nil); status != "" {
+		t.Errorf("expected empty status, got %q", status)
+	}
+}
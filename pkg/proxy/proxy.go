@@ -23,6 +23,7 @@ import (
 	"runtime"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	v2 "sofastack.io/sofa-mosn/pkg/api/v2"
 	"sofastack.io/sofa-mosn/pkg/config"
@@ -40,6 +41,12 @@ import (
 
 var json = jsoniter.ConfigCompatibleWithStandardLibrary
 
+// fallback behaviors when a protocol translation fails
+const (
+	translationFallbackPassThrough = "pass-through"
+	translationFallbackLocalError  = "local-error"
+)
+
 var (
 	globalStats *Stats
 
@@ -86,9 +93,20 @@ type proxy struct {
 	context            context.Context
 	activeSteams       *list.List // downstream requests
 	asMux              sync.RWMutex
+	// per-connection new stream accounting, guarded by asMux
+	streamWindowStart int64
+	streamWindowCount uint32
 	stats              *Stats
 	listenerStats      *Stats
 	accessLogs         []types.AccessLog
+	classifier         RequestClassifier
+	routeTrace         *routeTraceConfig
+	slo                *sloConfig
+	// translationLocalError answers a local error instead of the raw
+	// pass-through when a protocol translation fails
+	translationLocalError bool
+	// websocketBridge is set once the connection is spliced, guarded by asMux
+	websocketBridge    *websocketBridge
 }
 
 // NewProxy create proxy instance for given v2.Proxy config
@@ -109,6 +127,61 @@ func NewProxy(ctx context.Context, config *v2.Proxy, clusterManager types.Cluste
 		json.Unmarshal([]byte(extJSON), &xProxyExtendConfig)
 		proxy.context = mosnctx.WithValue(proxy.context, types.ContextSubProtocol, xProxyExtendConfig.SubProtocol)
 		log.DefaultLogger.Tracef("[proxy] extend config subprotocol = %v", xProxyExtendConfig.SubProtocol)
+
+		// optional request classifier for per-operation api stats
+		var classifierConfig struct {
+			RequestClassifier string `json:"request_classifier,omitempty"`
+		}
+		json.Unmarshal(extJSON, &classifierConfig)
+		if classifierConfig.RequestClassifier != "" {
+			if classifier := requestClassifierByName(classifierConfig.RequestClassifier); classifier != nil {
+				proxy.classifier = classifier
+			} else {
+				log.DefaultLogger.Errorf("[proxy] unknown request classifier = %v", classifierConfig.RequestClassifier)
+			}
+		}
+
+		// optional route trace for debug requests
+		var traceConfig struct {
+			RouteTrace *routeTraceConfig `json:"route_trace,omitempty"`
+		}
+		json.Unmarshal(extJSON, &traceConfig)
+		proxy.routeTrace = traceConfig.RouteTrace
+
+		// optional rolling slo compliance tracking per cluster
+		var sloCfg struct {
+			SLO *sloConfig `json:"slo,omitempty"`
+		}
+		json.Unmarshal(extJSON, &sloCfg)
+		if sloCfg.SLO != nil {
+			sloCfg.SLO.applyDefaults()
+			proxy.slo = sloCfg.SLO
+		}
+
+		// optional fallback behavior for failed protocol translations
+		var fallbackCfg struct {
+			TranslationFallback string `json:"translation_fallback,omitempty"`
+		}
+		json.Unmarshal(extJSON, &fallbackCfg)
+		switch fallbackCfg.TranslationFallback {
+		case "", translationFallbackPassThrough:
+			// raw pass-through is the historical default
+		case translationFallbackLocalError:
+			proxy.translationLocalError = true
+		default:
+			log.DefaultLogger.Errorf("[proxy] unknown translation fallback = %v", fallbackCfg.TranslationFallback)
+		}
+
+		// optional header/field mapping rules for cross-protocol routes
+		var transcodeCfg struct {
+			Transcode []protocol.TranscodeRule `json:"transcode,omitempty"`
+		}
+		json.Unmarshal(extJSON, &transcodeCfg)
+		if len(transcodeCfg.Transcode) > 0 {
+			if err := protocol.SetTranscodeRules(transcodeCfg.Transcode); err != nil {
+				log.DefaultLogger.Errorf("[proxy] invalid transcode rules = %v", err)
+			}
+		}
 	} else {
 		log.DefaultLogger.Errorf("[proxy] get proxy extend config fail = %v", err)
 	}
@@ -130,6 +203,11 @@ func NewProxy(ctx context.Context, config *v2.Proxy, clusterManager types.Cluste
 }
 
 func (p *proxy) OnData(buf types.IoBuffer) types.FilterStatus {
+	// a spliced websocket connection bypasses the stream layer completely
+	if ws := p.websocketSplice(); ws != nil {
+		ws.onDownstreamData(buf)
+		return types.Stop
+	}
 	if p.serverStreamConn == nil {
 		var prot string
 		if conn, ok := p.readCallbacks.Connection().RawConn().(*mtls.TLSConn); ok {
@@ -164,6 +242,13 @@ func (p *proxy) onDownstreamEvent(event types.ConnectionEvent) {
 		p.stats.DownstreamConnectionActive.Dec(1)
 		p.listenerStats.DownstreamConnectionDestroy.Inc(1)
 		p.listenerStats.DownstreamConnectionActive.Dec(1)
+
+		// a spliced websocket upstream follows the downstream close
+		if ws := p.takeWebSocketBridge(); ws != nil {
+			p.stats.DownstreamWebSocketActive.Dec(1)
+			p.listenerStats.DownstreamWebSocketActive.Dec(1)
+			ws.upstream.Close(types.NoFlush, types.LocalClose)
+		}
 		var urEleNext *list.Element
 
 		p.asMux.RLock()
@@ -230,11 +315,42 @@ func (p *proxy) NewStreamDetect(ctx context.Context, responseSender types.Stream
 
 	p.asMux.Lock()
 	stream.element = p.activeSteams.PushBack(stream)
+	exceeded := p.exceedStreamLimits()
 	p.asMux.Unlock()
 
+	if exceeded {
+		p.stats.DownstreamStreamLimitReset.Inc(1)
+		p.listenerStats.DownstreamStreamLimitReset.Inc(1)
+		log.Proxy.Warnf(stream.context, "[proxy] [downstream] connection %d exceeded stream limits %+v, reset connection",
+			p.readCallbacks.Connection().ID(), p.config.StreamLimits)
+		// reset the abusive connection, the close event resets all its active streams
+		p.readCallbacks.Connection().Close(types.NoFlush, types.LocalClose)
+	}
+
 	return stream
 }
 
+// exceedStreamLimits checks the configured per-connection limits on new
+// stream rate and concurrent streams, the caller must hold asMux
+func (p *proxy) exceedStreamLimits() bool {
+	limits := p.config.StreamLimits
+	if limits.MaxConcurrentStreams > 0 && uint32(p.activeSteams.Len()) > limits.MaxConcurrentStreams {
+		return true
+	}
+	if limits.NewStreamsPerSecond > 0 {
+		now := time.Now().Unix()
+		if now != p.streamWindowStart {
+			p.streamWindowStart = now
+			p.streamWindowCount = 0
+		}
+		p.streamWindowCount++
+		if p.streamWindowCount > limits.NewStreamsPerSecond {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *proxy) OnNewConnection() types.FilterStatus {
 	return types.Continue
 }
@@ -243,7 +359,7 @@ func (p *proxy) streamResetReasonToResponseFlag(reason types.StreamResetReason)
 	switch reason {
 	case types.StreamConnectionFailed:
 		return types.UpstreamConnectionFailure
-	case types.StreamConnectionTermination:
+	case types.StreamConnectionTermination, types.StreamReusedConnectionFailed:
 		return types.UpstreamConnectionTermination
 	case types.StreamLocalReset:
 		return types.UpstreamLocalReset
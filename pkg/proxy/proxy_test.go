@@ -0,0 +1,60 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"container/list"
+	"testing"
+
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+)
+
+func TestExceedStreamLimits(t *testing.T) {
+	p := &proxy{
+		config:       &v2.Proxy{},
+		activeSteams: list.New(),
+	}
+
+	// no limits configured, nothing is rejected
+	for i := 0; i < 100; i++ {
+		p.activeSteams.PushBack(i)
+		if p.exceedStreamLimits() {
+			t.Fatal("stream rejected without limits configured")
+		}
+	}
+
+	// concurrent streams over the limit
+	p.config.StreamLimits.MaxConcurrentStreams = 50
+	if !p.exceedStreamLimits() {
+		t.Error("concurrent stream limit not enforced")
+	}
+	p.config.StreamLimits.MaxConcurrentStreams = 0
+
+	// new streams per second over the limit, the window counter rejects
+	// the 11th stream of the same second
+	p.config.StreamLimits.NewStreamsPerSecond = 10
+	rejected := 0
+	for i := 0; i < 11; i++ {
+		if p.exceedStreamLimits() {
+			rejected++
+		}
+	}
+	if rejected != 1 {
+		t.Errorf("expected 1 rejected stream, got %d", rejected)
+	}
+}
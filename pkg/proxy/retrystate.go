@@ -18,14 +18,20 @@
 package proxy
 
 import (
+	"strings"
+
 	"sofastack.io/sofa-mosn/pkg/protocol"
 	"sofastack.io/sofa-mosn/pkg/protocol/http"
 	"sofastack.io/sofa-mosn/pkg/types"
 )
 
+// idempotent http methods retried by default, routes can widen the set
+// with retryable_methods
+var defaultRetryableMethods = []string{"GET", "HEAD", "PUT"}
+
 type retryState struct {
 	retryPolicy      types.RetryPolicy
-	requestHeaders   types.HeaderMap // TODO: support retry policy by header
+	requestHeaders   types.HeaderMap
 	cluster          types.ClusterInfo
 	retryOn          bool
 	retiesRemaining  uint32
@@ -90,26 +96,36 @@ func (r *retryState) doRetryCheck(headers types.HeaderMap, reason types.StreamRe
 		return false
 	}
 
+	// a reused keep-alive connection closed before any response byte never
+	// processed the request, clusters can opt in to resend those even when
+	// the route has no retry policy
+	if reason == types.StreamReusedConnectionFailed {
+		if h1 := r.cluster.Http1Settings(); h1 != nil && h1.RetryOnReuseFailure {
+			return true
+		}
+	}
+
 	if r.retryOn {
-		// TODO: add retry policy to decide retry or not. use default policy now
 		if headers != nil {
 			// default policy , mapping all headers to http status code
 			code, err := protocol.MappingHeaderStatusCode(r.upstreamProtocol, headers)
 			if err == nil {
-				// todo: support config?
-				return code >= http.InternalServerError
+				// the upstream answered, so it may have processed the
+				// request, only idempotent requests are safe to resend
+				return code >= http.InternalServerError && r.requestRetriable()
 			}
 		}
-		if reason == types.StreamConnectionFailed {
+		// connect failures never reached the upstream, always safe
+		if reason == types.StreamConnectionFailed ||
+			reason == types.StreamReusedConnectionFailed {
 			return true
 		}
 
-		if reason == types.UpstreamPerTryTimeout {
-			return true
-		}
-
-		if reason == types.StreamConnectionTermination {
-			return true
+		// the request may have been processed before the timeout or the
+		// connection termination, check idempotency
+		if reason == types.UpstreamPerTryTimeout ||
+			reason == types.StreamConnectionTermination {
+			return r.requestRetriable()
 		}
 		// more policy
 
@@ -118,6 +134,42 @@ func (r *retryState) doRetryCheck(headers types.HeaderMap, reason types.StreamRe
 	return false
 }
 
+// requestRetriable tells whether resending the request can not cause
+// duplicate side effects. The x-mosn-retriable header overrides the
+// decision in both directions, otherwise the request method must be in the
+// route's retryable_methods annotation, or be an idempotent http method
+// when the route has none
+func (r *retryState) requestRetriable() bool {
+	if r.requestHeaders == nil {
+		return false
+	}
+
+	if override, ok := r.requestHeaders.Get(protocol.MosnHeaderRetriable); ok {
+		return override == "true"
+	}
+
+	method, ok := r.requestHeaders.Get(protocol.MosnHeaderMethod)
+	if !ok {
+		// rpc requests carry no http method, routes mark their idempotent
+		// methods via the annotation
+		method, ok = r.requestHeaders.Get(types.HeaderRPCMethod)
+	}
+	if !ok {
+		return false
+	}
+
+	retryable := r.retryPolicy.RetryableMethods()
+	if len(retryable) == 0 {
+		retryable = defaultRetryableMethods
+	}
+	for _, m := range retryable {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
 func (r *retryState) reset() {
 	r.cluster.ResourceManager().Retries().Decrease()
 }
@@ -78,7 +78,8 @@ func TestRetryState(t *testing.T) {
 	clusterInfo := &fakeClusterInfo{
 		mgr: &fakeResourceManager{},
 	}
-	rs := newRetryState(policy, nil, clusterInfo, protocol.HTTP1)
+	// GET is idempotent by default
+	rs := newRetryState(policy, protocol.CommonHeader{protocol.MosnHeaderMethod: "GET"}, clusterInfo, protocol.HTTP1)
 	headerException := protocol.CommonHeader{
 		types.HeaderStatus: "500",
 	}
@@ -100,3 +101,53 @@ func TestRetryState(t *testing.T) {
 		}
 	}
 }
+
+func TestRetryStateIdempotency(t *testing.T) {
+	clusterInfo := &fakeClusterInfo{
+		mgr: &fakeResourceManager{},
+	}
+	newPolicy := func(retryable ...string) types.RetryPolicy {
+		rcfg := &v2.Router{}
+		rcfg.Route = v2.RouteAction{}
+		rcfg.Route.RetryPolicy = &v2.RetryPolicy{
+			RetryPolicyConfig: v2.RetryPolicyConfig{
+				RetryOn:          true,
+				NumRetries:       10,
+				RetryableMethods: retryable,
+			},
+			RetryTimeout: time.Second,
+		}
+		r, _ := router.NewRouteRuleImplBase(nil, rcfg)
+		return r.Policy().RetryPolicy()
+	}
+	headerException := protocol.CommonHeader{
+		types.HeaderStatus: "500",
+	}
+	testcases := []struct {
+		Request  types.HeaderMap
+		Policy   types.RetryPolicy
+		Header   types.HeaderMap
+		Reason   types.StreamResetReason
+		Expected types.RetryCheckStatus
+	}{
+		// non-idempotent method, upstream may have processed the request
+		{protocol.CommonHeader{protocol.MosnHeaderMethod: "POST"}, newPolicy(), headerException, "", types.NoRetry},
+		{protocol.CommonHeader{protocol.MosnHeaderMethod: "POST"}, newPolicy(), nil, types.UpstreamPerTryTimeout, types.NoRetry},
+		// connect failure never reached the upstream
+		{protocol.CommonHeader{protocol.MosnHeaderMethod: "POST"}, newPolicy(), nil, types.StreamConnectionFailed, types.ShouldRetry},
+		// route annotation marks the method idempotent
+		{protocol.CommonHeader{protocol.MosnHeaderMethod: "POST"}, newPolicy("POST"), headerException, "", types.ShouldRetry},
+		// rpc method via annotation
+		{protocol.CommonHeader{types.HeaderRPCMethod: "getUser"}, newPolicy("getUser"), headerException, "", types.ShouldRetry},
+		{protocol.CommonHeader{types.HeaderRPCMethod: "createUser"}, newPolicy("getUser"), headerException, "", types.NoRetry},
+		// override header wins in both directions
+		{protocol.CommonHeader{protocol.MosnHeaderMethod: "POST", protocol.MosnHeaderRetriable: "true"}, newPolicy(), headerException, "", types.ShouldRetry},
+		{protocol.CommonHeader{protocol.MosnHeaderMethod: "GET", protocol.MosnHeaderRetriable: "false"}, newPolicy(), headerException, "", types.NoRetry},
+	}
+	for i, tc := range testcases {
+		rs := newRetryState(tc.Policy, tc.Request, clusterInfo, protocol.HTTP1)
+		if rs.retry(tc.Header, tc.Reason) != tc.Expected {
+			t.Errorf("#%d idempotency retry check failed", i)
+		}
+	}
+}
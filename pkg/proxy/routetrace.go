@@ -0,0 +1,101 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"strings"
+
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+// HeaderRouteTrace is both the debug header a client sets on the request
+// and the response header every hop appends its routing decision to, so
+// multi-hop routing problems can be traced from the client side
+const HeaderRouteTrace = "x-mosn-route-trace"
+
+// maxRouteTraceSize caps the response header, a hop that would push the
+// trace over the cap keeps the existing entries and drops its own
+const maxRouteTraceSize = 1024
+
+// routeTraceConfig is parsed from the proxy's extend config under the
+// route_trace key. When Key is set the request header value must match
+// it, keeping the routing internals away from untrusted clients
+type routeTraceConfig struct {
+	Enable bool   `json:"enable"`
+	Key    string `json:"key,omitempty"`
+}
+
+// routeTraceRequested reports whether the request asked for route
+// tracing and is trusted to receive it
+func (s *downStream) routeTraceRequested() bool {
+	cfg := s.proxy.routeTrace
+	if cfg == nil || !cfg.Enable || s.downstreamReqHeaders == nil {
+		return false
+	}
+	value, ok := s.downstreamReqHeaders.Get(HeaderRouteTrace)
+	if !ok {
+		return false
+	}
+	if cfg.Key != "" && value != cfg.Key {
+		return false
+	}
+	return true
+}
+
+// appendRouteTrace appends this hop's record to the response trace
+// header, earlier hops' entries are kept in front
+func (s *downStream) appendRouteTrace(headers types.HeaderMap) {
+	if headers == nil || !s.routeTraceRequested() {
+		return
+	}
+	record := s.routeTraceRecord()
+	existing, _ := headers.Get(HeaderRouteTrace)
+	if len(existing)+len(record)+1 > maxRouteTraceSize {
+		return
+	}
+	if existing != "" {
+		record = existing + "," + record
+	}
+	headers.Set(HeaderRouteTrace, record)
+}
+
+// routeTraceRecord renders the matched virtual host, route matcher,
+// cluster and upstream host into one compact hop entry
+func (s *downStream) routeTraceRecord() string {
+	var b strings.Builder
+	b.WriteString("vh=")
+	if s.route != nil && s.route.RouteRule() != nil {
+		rule := s.route.RouteRule()
+		if vh := rule.VirtualHost(); vh != nil {
+			b.WriteString(vh.Name())
+		}
+		if criterion := rule.PathMatchCriterion(); criterion != nil {
+			b.WriteString(";route=")
+			b.WriteString(criterion.Matcher())
+		}
+	}
+	b.WriteString(";cluster=")
+	if s.cluster != nil {
+		b.WriteString(s.cluster.Name())
+	}
+	b.WriteString(";host=")
+	if host := s.requestInfo.UpstreamHost(); host != nil {
+		b.WriteString(host.AddressString())
+	}
+	return b.String()
+}
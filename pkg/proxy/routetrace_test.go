@@ -0,0 +1,90 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"strings"
+	"testing"
+
+	"sofastack.io/sofa-mosn/pkg/network"
+	"sofastack.io/sofa-mosn/pkg/protocol"
+)
+
+func newRouteTraceStream(cfg *routeTraceConfig, reqHeaders map[string]string) *downStream {
+	return &downStream{
+		proxy:                &proxy{routeTrace: cfg},
+		downstreamReqHeaders: protocol.CommonHeader(reqHeaders),
+		requestInfo:          network.NewRequestInfo(),
+	}
+}
+
+func TestRouteTraceRequested(t *testing.T) {
+	// disabled by default
+	s := newRouteTraceStream(nil, map[string]string{HeaderRouteTrace: "1"})
+	if s.routeTraceRequested() {
+		t.Errorf("trace requested without config")
+	}
+	// enabled, no key required
+	s = newRouteTraceStream(&routeTraceConfig{Enable: true}, map[string]string{HeaderRouteTrace: "1"})
+	if !s.routeTraceRequested() {
+		t.Errorf("trace not requested with header present")
+	}
+	// header absent
+	s = newRouteTraceStream(&routeTraceConfig{Enable: true}, map[string]string{})
+	if s.routeTraceRequested() {
+		t.Errorf("trace requested without header")
+	}
+	// key must match when configured
+	s = newRouteTraceStream(&routeTraceConfig{Enable: true, Key: "secret"}, map[string]string{HeaderRouteTrace: "guess"})
+	if s.routeTraceRequested() {
+		t.Errorf("trace requested with wrong key")
+	}
+	s = newRouteTraceStream(&routeTraceConfig{Enable: true, Key: "secret"}, map[string]string{HeaderRouteTrace: "secret"})
+	if !s.routeTraceRequested() {
+		t.Errorf("trace not requested with matching key")
+	}
+}
+
+func TestAppendRouteTrace(t *testing.T) {
+	s := newRouteTraceStream(&routeTraceConfig{Enable: true}, map[string]string{HeaderRouteTrace: "1"})
+
+	// first hop writes its record
+	respHeaders := protocol.CommonHeader(map[string]string{})
+	s.appendRouteTrace(respHeaders)
+	record, ok := respHeaders.Get(HeaderRouteTrace)
+	if !ok || !strings.Contains(record, "cluster=") {
+		t.Fatalf("unexpected trace record: %q", record)
+	}
+
+	// a later hop keeps earlier entries in front
+	respHeaders = protocol.CommonHeader(map[string]string{HeaderRouteTrace: "vh=edge;cluster=c1;host=10.0.0.1:80"})
+	s.appendRouteTrace(respHeaders)
+	record, _ = respHeaders.Get(HeaderRouteTrace)
+	if !strings.HasPrefix(record, "vh=edge;cluster=c1;host=10.0.0.1:80,") {
+		t.Errorf("earlier hop entry lost: %q", record)
+	}
+
+	// the cap keeps the existing trace and drops this hop's entry
+	full := strings.Repeat("x", maxRouteTraceSize-10)
+	respHeaders = protocol.CommonHeader(map[string]string{HeaderRouteTrace: full})
+	s.appendRouteTrace(respHeaders)
+	record, _ = respHeaders.Get(HeaderRouteTrace)
+	if record != full {
+		t.Errorf("over-cap trace modified")
+	}
+}
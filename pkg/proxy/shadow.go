@@ -0,0 +1,233 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"context"
+	"math/rand"
+	"net"
+	"reflect"
+
+	"sofastack.io/sofa-mosn/pkg/buffer"
+	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/protocol"
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+// shadowBodyLimit caps how much request body is buffered per mirrored
+// request, larger requests lose their mirror instead of holding memory
+const shadowBodyLimit = 4 << 20
+
+// shadowRequest duplicates a downstream request to the shadow cluster of
+// the route, the mirrored response is discarded. The request is buffered
+// until it is received completely, so the mirror never slows down or
+// resets the real upstream request.
+//
+// types.StreamReceiveListener
+// types.PoolEventListener
+// types.LoadBalancerContext
+type shadowRequest struct {
+	ctx      context.Context
+	proxy    *proxy
+	cluster  string
+	protocol types.Protocol
+	headers  types.HeaderMap
+	data     types.IoBuffer
+	trailers types.HeaderMap
+	overflow bool
+	started  bool
+}
+
+// prepareShadow creates a shadow request if the matched route has a
+// shadow policy and the request is selected by the configured percent,
+// called after the request headers are finalized
+func (s *downStream) prepareShadow() {
+	shadow := s.route.RouteRule().Policy().ShadowPolicy()
+	if shadow == nil || reflect.ValueOf(shadow).IsNil() {
+		return
+	}
+	cluster := shadow.ClusterName()
+	if cluster == "" {
+		return
+	}
+	if percent := shadow.Percent(); percent < 100 && uint32(rand.Intn(100)) >= percent {
+		return
+	}
+	headers := s.downstreamReqHeaders.Clone()
+	if !s.noConvert {
+		if dp, up := s.convertProtocol(); dp != up {
+			convHeaders, err := protocol.ConvertHeader(s.context, dp, up, headers)
+			if err != nil {
+				log.Proxy.Warnf(s.context, "[proxy] [shadow] convert header from %s to %s failed, drop mirror, %s", dp, up, err.Error())
+				s.countShadowFailed()
+				return
+			}
+			headers = convHeaders
+		}
+	}
+	s.shadowRequest = &shadowRequest{
+		ctx:      s.context,
+		proxy:    s.proxy,
+		cluster:  cluster,
+		protocol: s.getUpstreamProtocol(),
+		headers:  headers,
+	}
+}
+
+// shadowAppendData buffers a copy of a request body chunk, the mirror is
+// dropped once the buffered body exceeds the limit
+func (s *downStream) shadowAppendData(data types.IoBuffer) {
+	sr := s.shadowRequest
+	if sr == nil || sr.overflow || data == nil || data.Len() == 0 {
+		return
+	}
+	buffered := 0
+	if sr.data != nil {
+		buffered = sr.data.Len()
+	}
+	if buffered+data.Len() > shadowBodyLimit {
+		log.Proxy.Warnf(s.context, "[proxy] [shadow] request body exceeds the shadow buffer limit, drop mirror, proxyId = %d", s.ID)
+		sr.overflow = true
+		sr.data = nil
+		s.countShadowFailed()
+		return
+	}
+	chunk := data.Clone()
+	if !s.noConvert {
+		if dp, up := s.convertProtocol(); dp != up {
+			if convData, err := protocol.ConvertData(s.context, dp, up, chunk); err == nil {
+				chunk = convData
+			} else {
+				log.Proxy.Warnf(s.context, "[proxy] [shadow] convert data from %s to %s failed, %s", dp, up, err.Error())
+			}
+		}
+	}
+	if sr.data == nil {
+		sr.data = buffer.NewIoBuffer(chunk.Len())
+	}
+	sr.data.Write(chunk.Bytes())
+}
+
+// shadowAppendTrailers buffers a copy of the request trailers
+func (s *downStream) shadowAppendTrailers(trailers types.HeaderMap) {
+	sr := s.shadowRequest
+	if sr == nil || sr.overflow || trailers == nil {
+		return
+	}
+	convTrailers := trailers.Clone()
+	if !s.noConvert {
+		if dp, up := s.convertProtocol(); dp != up {
+			if conv, err := protocol.ConvertTrailer(s.context, dp, up, convTrailers); err == nil {
+				convTrailers = conv
+			} else {
+				log.Proxy.Warnf(s.context, "[proxy] [shadow] convert trailer from %s to %s failed, %s", dp, up, err.Error())
+			}
+		}
+	}
+	sr.trailers = convTrailers
+}
+
+// startShadowRequest sends the buffered mirror once the real request has
+// been received completely
+func (s *downStream) startShadowRequest() {
+	sr := s.shadowRequest
+	if sr == nil || sr.started || sr.overflow {
+		return
+	}
+	sr.started = true
+	sr.start()
+}
+
+func (s *downStream) countShadowFailed() {
+	s.proxy.stats.DownstreamShadowRequestFailed.Inc(1)
+	s.proxy.listenerStats.DownstreamShadowRequestFailed.Inc(1)
+}
+
+func (sr *shadowRequest) start() {
+	sr.proxy.stats.DownstreamShadowRequestTotal.Inc(1)
+	sr.proxy.listenerStats.DownstreamShadowRequestTotal.Inc(1)
+
+	snapshot := sr.proxy.clusterManager.GetClusterSnapshot(context.Background(), sr.cluster)
+	if reflect.ValueOf(snapshot).IsNil() {
+		log.Proxy.Warnf(sr.ctx, "[proxy] [shadow] shadow cluster not found, cluster = %s", sr.cluster)
+		sr.countFailed()
+		return
+	}
+	pool := sr.proxy.clusterManager.ConnPoolForCluster(sr, snapshot, sr.protocol)
+	sr.proxy.clusterManager.PutClusterSnapshot(snapshot)
+	if pool == nil {
+		log.Proxy.Warnf(sr.ctx, "[proxy] [shadow] no healthy upstream in shadow cluster, cluster = %s", sr.cluster)
+		sr.countFailed()
+		return
+	}
+	pool.NewStream(sr.ctx, sr, sr)
+}
+
+func (sr *shadowRequest) countFailed() {
+	sr.proxy.stats.DownstreamShadowRequestFailed.Inc(1)
+	sr.proxy.listenerStats.DownstreamShadowRequestFailed.Inc(1)
+}
+
+// types.PoolEventListener
+func (sr *shadowRequest) OnReady(sender types.StreamSender, host types.Host) {
+	if log.Proxy.GetLogLevel() >= log.DEBUG {
+		log.Proxy.Debugf(sr.ctx, "[proxy] [shadow] mirror request to host = %s, cluster = %s", host.AddressString(), sr.cluster)
+	}
+	endStream := sr.data == nil && sr.trailers == nil
+	sender.AppendHeaders(sr.ctx, sr.headers, endStream)
+	if sr.data != nil {
+		sender.AppendData(sr.ctx, sr.data, sr.trailers == nil)
+	}
+	if sr.trailers != nil {
+		sender.AppendTrailers(sr.ctx, sr.trailers)
+	}
+}
+
+func (sr *shadowRequest) OnFailure(reason types.PoolFailureReason, host types.Host) {
+	log.Proxy.Warnf(sr.ctx, "[proxy] [shadow] mirror request failed, reason = %v, cluster = %s", reason, sr.cluster)
+	sr.countFailed()
+}
+
+// types.StreamReceiveListener
+// the mirrored response is discarded
+func (sr *shadowRequest) OnReceive(ctx context.Context, headers types.HeaderMap, data types.IoBuffer, trailers types.HeaderMap) {
+}
+
+func (sr *shadowRequest) OnDecodeError(ctx context.Context, err error, headers types.HeaderMap) {
+}
+
+// types.LoadBalancerContext
+func (sr *shadowRequest) ComputeHashKey() types.HashedValue {
+	return ""
+}
+
+func (sr *shadowRequest) MetadataMatchCriteria() types.MetadataMatchCriteria {
+	return nil
+}
+
+func (sr *shadowRequest) DownstreamConnection() net.Conn {
+	return nil
+}
+
+func (sr *shadowRequest) DownstreamHeaders() types.HeaderMap {
+	return sr.headers
+}
+
+func (sr *shadowRequest) DownstreamContext() context.Context {
+	return sr.ctx
+}
@@ -0,0 +1,242 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+	"time"
+
+	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/metrics"
+	"sofastack.io/sofa-mosn/pkg/utils"
+)
+
+// sloConfig is parsed from the proxy extend config key "slo". A request
+// counts as successful below http 500 and as within the latency budget
+// when it finishes inside latency_ms. An SLO with a zero threshold is
+// not checked
+type sloConfig struct {
+	// WindowSec is the rolling window length, default 60
+	WindowSec int `json:"window_sec,omitempty"`
+	// MinRequests is the request volume below which compliance is not
+	// evaluated, avoids alerting on a single failed request, default 10
+	MinRequests int64 `json:"min_requests,omitempty"`
+	// SuccessRate is the required success ratio, e.g. 0.999
+	SuccessRate float64 `json:"success_rate,omitempty"`
+	// LatencyMs is the per-request latency budget
+	LatencyMs int64 `json:"latency_ms,omitempty"`
+	// LatencyRate is the required ratio of requests within the budget,
+	// default 1.0 when a latency budget is set
+	LatencyRate float64 `json:"latency_rate,omitempty"`
+	// Webhook receives an SLOAlert as a json POST on every compliance
+	// transition, optional
+	Webhook string `json:"webhook,omitempty"`
+}
+
+func (c *sloConfig) applyDefaults() {
+	if c.WindowSec <= 0 {
+		c.WindowSec = 60
+	}
+	if c.MinRequests <= 0 {
+		c.MinRequests = 10
+	}
+	if c.LatencyMs > 0 && c.LatencyRate == 0 {
+		c.LatencyRate = 1.0
+	}
+}
+
+// SLOAlert describes a compliance transition of one cluster, it is passed
+// to registered handlers and posted to the configured webhook
+type SLOAlert struct {
+	Cluster     string  `json:"cluster"`
+	Breached    bool    `json:"breached"`
+	SuccessRate float64 `json:"success_rate"`
+	LatencyRate float64 `json:"latency_rate"`
+	Requests    int64   `json:"requests"`
+	Time        string  `json:"time"`
+}
+
+// SLOAlertHandler is called on every compliance transition, both into and
+// out of breach. Handlers run on their own goroutine and may block
+type SLOAlertHandler func(alert SLOAlert)
+
+var (
+	sloHandlersMux sync.Mutex
+	sloHandlers    []SLOAlertHandler
+)
+
+// RegisterSLOAlertHandler adds a callback for SLO compliance transitions,
+// intended for sidecar-local remediation hooks
+func RegisterSLOAlertHandler(handler SLOAlertHandler) {
+	sloHandlersMux.Lock()
+	defer sloHandlersMux.Unlock()
+	sloHandlers = append(sloHandlers, handler)
+}
+
+var sloWebhookClient = &http.Client{Timeout: 5 * time.Second}
+
+type sloBucket struct {
+	second  int64
+	total   int64
+	success int64
+	within  int64
+}
+
+// sloTracker keeps one second resolution buckets of request results for a
+// cluster, compliance is evaluated over the buckets inside the window
+type sloTracker struct {
+	cluster  string
+	config   *sloConfig
+	mu       sync.Mutex
+	buckets  []sloBucket
+	breached bool
+	lastEval int64
+}
+
+var (
+	sloTrackersMux sync.Mutex
+	sloTrackers    = make(map[string]*sloTracker)
+)
+
+func sloTrackerForCluster(cluster string, config *sloConfig) *sloTracker {
+	sloTrackersMux.Lock()
+	defer sloTrackersMux.Unlock()
+	if tracker, ok := sloTrackers[cluster]; ok {
+		return tracker
+	}
+	tracker := &sloTracker{
+		cluster: cluster,
+		config:  config,
+		buckets: make([]sloBucket, config.WindowSec),
+	}
+	sloTrackers[cluster] = tracker
+	return tracker
+}
+
+func (t *sloTracker) record(now int64, success bool, withinLatency bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	bucket := &t.buckets[now%int64(len(t.buckets))]
+	if bucket.second != now {
+		*bucket = sloBucket{second: now}
+	}
+	bucket.total++
+	if success {
+		bucket.success++
+	}
+	if withinLatency {
+		bucket.within++
+	}
+	// evaluating once a second keeps the hot path cheap
+	if now != t.lastEval {
+		t.lastEval = now
+		t.evaluateLocked(now)
+	}
+}
+
+func (t *sloTracker) evaluateLocked(now int64) {
+	var total, success, within int64
+	for _, bucket := range t.buckets {
+		if now-bucket.second < int64(len(t.buckets)) {
+			total += bucket.total
+			success += bucket.success
+			within += bucket.within
+		}
+	}
+	if total < t.config.MinRequests {
+		return
+	}
+	successRate := float64(success) / float64(total)
+	latencyRate := float64(within) / float64(total)
+
+	stats := metrics.NewClusterStats(t.cluster)
+	stats.Gauge(metrics.UpstreamSLOSuccessRate).Update(int64(successRate * 1000))
+	stats.Gauge(metrics.UpstreamSLOLatencyRate).Update(int64(latencyRate * 1000))
+
+	breached := false
+	if t.config.SuccessRate > 0 && successRate < t.config.SuccessRate {
+		breached = true
+	}
+	if t.config.LatencyMs > 0 && latencyRate < t.config.LatencyRate {
+		breached = true
+	}
+	if breached == t.breached {
+		return
+	}
+	t.breached = breached
+	if breached {
+		stats.Gauge(metrics.UpstreamSLOBreached).Update(1)
+	} else {
+		stats.Gauge(metrics.UpstreamSLOBreached).Update(0)
+	}
+
+	alert := SLOAlert{
+		Cluster:     t.cluster,
+		Breached:    breached,
+		SuccessRate: successRate,
+		LatencyRate: latencyRate,
+		Requests:    total,
+		Time:        time.Now().Format(time.RFC3339),
+	}
+	log.DefaultLogger.Infof("[proxy] [slo] cluster %s breached=%v success=%.4f latency=%.4f requests=%d",
+		alert.Cluster, alert.Breached, alert.SuccessRate, alert.LatencyRate, alert.Requests)
+	webhook := t.config.Webhook
+	utils.GoWithRecover(func() {
+		fireSLOAlert(alert, webhook)
+	}, nil)
+}
+
+func fireSLOAlert(alert SLOAlert, webhook string) {
+	sloHandlersMux.Lock()
+	handlers := make([]SLOAlertHandler, len(sloHandlers))
+	copy(handlers, sloHandlers)
+	sloHandlersMux.Unlock()
+	for _, handler := range handlers {
+		handler(alert)
+	}
+	if webhook == "" {
+		return
+	}
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return
+	}
+	resp, err := sloWebhookClient.Post(webhook, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.DefaultLogger.Errorf("[proxy] [slo] webhook post failed: %v", err)
+		return
+	}
+	resp.Body.Close()
+}
+
+// finishSLOStats records the request result into its cluster's slo tracker
+// when the stream ends
+func (s *downStream) finishSLOStats() {
+	if s.proxy.slo == nil || s.cluster == nil {
+		return
+	}
+	success := s.requestInfo.ResponseCode() < http.StatusInternalServerError
+	within := true
+	if s.proxy.slo.LatencyMs > 0 {
+		within = s.requestInfo.Duration() <= time.Duration(s.proxy.slo.LatencyMs)*time.Millisecond
+	}
+	tracker := sloTrackerForCluster(s.cluster.Name(), s.proxy.slo)
+	tracker.record(time.Now().Unix(), success, within)
+}
@@ -0,0 +1,117 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"testing"
+	"time"
+)
+
+func resetSLOState() {
+	sloHandlersMux.Lock()
+	sloHandlers = nil
+	sloHandlersMux.Unlock()
+	sloTrackersMux.Lock()
+	sloTrackers = make(map[string]*sloTracker)
+	sloTrackersMux.Unlock()
+}
+
+func TestSLOConfigDefaults(t *testing.T) {
+	cfg := &sloConfig{}
+	cfg.applyDefaults()
+	if cfg.WindowSec != 60 || cfg.MinRequests != 10 {
+		t.Errorf("unexpected defaults: %+v", cfg)
+	}
+	if cfg.LatencyRate != 0 {
+		t.Errorf("latency rate should stay zero without a budget, got %v", cfg.LatencyRate)
+	}
+	cfg = &sloConfig{LatencyMs: 100}
+	cfg.applyDefaults()
+	if cfg.LatencyRate != 1.0 {
+		t.Errorf("latency rate should default to 1.0 with a budget, got %v", cfg.LatencyRate)
+	}
+}
+
+func TestSLOTrackerBreachAndRecover(t *testing.T) {
+	defer resetSLOState()
+	resetSLOState()
+
+	alerts := make(chan SLOAlert, 2)
+	RegisterSLOAlertHandler(func(alert SLOAlert) {
+		alerts <- alert
+	})
+
+	cfg := &sloConfig{WindowSec: 5, MinRequests: 5, SuccessRate: 0.9}
+	tracker := sloTrackerForCluster("test_cluster", cfg)
+
+	// all failures in second 100, the evaluation at second 101 breaches
+	now := int64(100)
+	for i := 0; i < 10; i++ {
+		tracker.record(now, false, true)
+	}
+	tracker.record(now+1, true, true)
+	select {
+	case alert := <-alerts:
+		if !alert.Breached {
+			t.Errorf("expected a breach alert, got %+v", alert)
+		}
+		if alert.Cluster != "test_cluster" {
+			t.Errorf("unexpected cluster %s", alert.Cluster)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no breach alert fired")
+	}
+
+	// after the failures roll out of the window only successes remain
+	now += int64(cfg.WindowSec) + 1
+	for i := 0; i < 10; i++ {
+		tracker.record(now, true, true)
+	}
+	tracker.record(now+1, true, true)
+	select {
+	case alert := <-alerts:
+		if alert.Breached {
+			t.Errorf("expected a recovery alert, got %+v", alert)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no recovery alert fired")
+	}
+}
+
+func TestSLOTrackerBelowMinVolume(t *testing.T) {
+	defer resetSLOState()
+	resetSLOState()
+
+	alerts := make(chan SLOAlert, 1)
+	RegisterSLOAlertHandler(func(alert SLOAlert) {
+		alerts <- alert
+	})
+
+	cfg := &sloConfig{WindowSec: 5, MinRequests: 100, SuccessRate: 0.9}
+	tracker := sloTrackerForCluster("low_volume", cfg)
+	now := int64(200)
+	for i := 0; i < 10; i++ {
+		tracker.record(now, false, true)
+		now++
+	}
+	select {
+	case alert := <-alerts:
+		t.Errorf("no alert expected below min volume, got %+v", alert)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -18,12 +18,13 @@
 package proxy
 
 import (
+	gometrics "github.com/rcrowley/go-metrics"
 	"sofastack.io/sofa-mosn/pkg/metrics"
 	"sofastack.io/sofa-mosn/pkg/types"
-	gometrics "github.com/rcrowley/go-metrics"
 )
 
 type Stats struct {
+	metrics                     types.Metrics
 	DownstreamConnectionTotal   gometrics.Counter
 	DownstreamConnectionDestroy gometrics.Counter
 	DownstreamConnectionActive  gometrics.Counter
@@ -34,6 +35,17 @@ type Stats struct {
 	DownstreamRequestReset      gometrics.Counter
 	DownstreamRequestTime       gometrics.Histogram
 	DownstreamRequestTimeTotal  gometrics.Counter
+	DownstreamStreamLimitReset  gometrics.Counter
+
+	DownstreamWebSocketUpgradeTotal  gometrics.Counter
+	DownstreamWebSocketUpgradeReject gometrics.Counter
+	DownstreamWebSocketActive        gometrics.Counter
+
+	DownstreamTranslationFailed      gometrics.Counter
+	DownstreamTranslationPassThrough gometrics.Counter
+
+	DownstreamShadowRequestTotal  gometrics.Counter
+	DownstreamShadowRequestFailed gometrics.Counter
 }
 
 func newListenerStats(listenerName string) *Stats {
@@ -47,6 +59,7 @@ func newProxyStats(proxyName string) *Stats {
 
 func newStats(s types.Metrics) *Stats {
 	return &Stats{
+		metrics:                     s,
 		DownstreamConnectionTotal:   s.Counter(metrics.DownstreamConnectionTotal),
 		DownstreamConnectionDestroy: s.Counter(metrics.DownstreamConnectionDestroy),
 		DownstreamConnectionActive:  s.Counter(metrics.DownstreamConnectionActive),
@@ -57,5 +70,22 @@ func newStats(s types.Metrics) *Stats {
 		DownstreamRequestReset:      s.Counter(metrics.DownstreamRequestReset),
 		DownstreamRequestTime:       s.Histogram(metrics.DownstreamRequestTime),
 		DownstreamRequestTimeTotal:  s.Counter(metrics.DownstreamRequestTimeTotal),
+		DownstreamStreamLimitReset:  s.Counter(metrics.DownstreamStreamLimitReset),
+
+		DownstreamWebSocketUpgradeTotal:  s.Counter(metrics.DownstreamWebSocketUpgradeTotal),
+		DownstreamWebSocketUpgradeReject: s.Counter(metrics.DownstreamWebSocketUpgradeReject),
+		DownstreamWebSocketActive:        s.Counter(metrics.DownstreamWebSocketActive),
+
+		DownstreamTranslationFailed:      s.Counter(metrics.DownstreamTranslationFailed),
+		DownstreamTranslationPassThrough: s.Counter(metrics.DownstreamTranslationPassThrough),
+
+		DownstreamShadowRequestTotal:  s.Counter(metrics.DownstreamShadowRequestTotal),
+		DownstreamShadowRequestFailed: s.Counter(metrics.DownstreamShadowRequestFailed),
 	}
 }
+
+// DownstreamRequestErrorTotal returns the counter for a failure reason,
+// counters are created lazily since the code set is open for extensions
+func (s *Stats) DownstreamRequestErrorTotal(code types.ProxyErrorCode) gometrics.Counter {
+	return s.metrics.Counter(metrics.DownstreamRequestErrorPrefix + string(code))
+}
@@ -20,10 +20,12 @@ package proxy
 import (
 	"container/list"
 	"context"
+	"strconv"
 	"time"
 
 	"sync/atomic"
 
+	"sofastack.io/sofa-mosn/pkg/journal"
 	"sofastack.io/sofa-mosn/pkg/log"
 	"sofastack.io/sofa-mosn/pkg/protocol"
 	"sofastack.io/sofa-mosn/pkg/types"
@@ -110,6 +112,11 @@ func (r *upstreamRequest) OnReceive(ctx context.Context, headers types.HeaderMap
 	}
 
 	r.downStream.requestInfo.SetResponseReceivedDuration(time.Now())
+	if headers != nil {
+		if timing, ok := headers.Get(protocol.HeaderServerTiming); ok {
+			r.downStream.requestInfo.SetUpstreamServerTiming(timing)
+		}
+	}
 	r.downStream.downstreamRespHeaders = headers
 
 	if data != nil {
@@ -172,9 +179,12 @@ func (r *upstreamRequest) appendHeaders(endStream bool) {
 	}
 }
 
-func (r *upstreamRequest) convertHeader(headers types.HeaderMap) types.HeaderMap {
+// convertHeader translates the request headers to the upstream protocol,
+// ok is false when the proxy is configured to answer a local error
+// instead of forwarding the raw request
+func (r *upstreamRequest) convertHeader(headers types.HeaderMap) (conv types.HeaderMap, ok bool) {
 	if r.downStream.noConvert {
-		return headers
+		return headers, true
 	}
 
 	dp, up := r.downStream.convertProtocol()
@@ -182,12 +192,17 @@ func (r *upstreamRequest) convertHeader(headers types.HeaderMap) types.HeaderMap
 	// need protocol convert
 	if dp != up {
 		if convHeader, err := protocol.ConvertHeader(r.downStream.context, dp, up, headers); err == nil {
-			return convHeader
+			return convHeader, true
 		} else {
 			log.Proxy.Warnf(r.downStream.context, "[proxy] [upstream] convert header from %s to %s failed, %s", dp, up, err.Error())
+			if r.downStream.proxy.translationLocalError {
+				r.downStream.countTranslationFailure(false)
+				return nil, false
+			}
+			r.downStream.countTranslationFailure(true)
 		}
 	}
-	return headers
+	return headers, true
 }
 
 func (r *upstreamRequest) appendData(endStream bool) {
@@ -217,6 +232,8 @@ func (r *upstreamRequest) convertData(data types.IoBuffer) types.IoBuffer {
 			return convData
 		} else {
 			log.Proxy.Warnf(r.downStream.context, "[proxy] [upstream] convert data from %s to %s failed, %s", dp, up, err.Error())
+			// headers are already on the wire, pass the raw data through
+			r.downStream.countTranslationFailure(true)
 		}
 	}
 	return data
@@ -246,6 +263,7 @@ func (r *upstreamRequest) convertTrailer(trailers types.HeaderMap) types.HeaderM
 			return convTrailer
 		} else {
 			log.Proxy.Warnf(r.downStream.context, "[proxy] [upstream] convert header from %s to %s failed, %s", dp, up, err.Error())
+			r.downStream.countTranslationFailure(true)
 		}
 	}
 	return trailers
@@ -258,6 +276,7 @@ func (r *upstreamRequest) OnFailure(reason types.PoolFailureReason, host types.H
 	switch reason {
 	case types.Overflow:
 		resetReason = types.StreamOverflow
+		journal.Record(journal.TypeCircuitBreak, "upstream request overflow, host = %s", host.AddressString())
 	case types.ConnectionFailure:
 		resetReason = types.StreamConnectionFailed
 	}
@@ -279,7 +298,20 @@ func (r *upstreamRequest) OnReady(sender types.StreamSender, host types.Host) {
 	r.startTime = time.Now()
 
 	endStream := r.sendComplete && !r.dataSent && !r.trailerSent
-	r.requestSender.AppendHeaders(r.downStream.context, r.convertHeader(r.downStream.downstreamReqHeaders), endStream)
+	// stamp the attempt so backends and traces can tell retried traffic apart
+	attempt := r.downStream.requestInfo.AttemptCount()
+	r.downStream.downstreamReqHeaders.Set(protocol.MosnHeaderAttemptCount, strconv.FormatUint(uint64(attempt), 10))
+	if attempt > 1 {
+		r.downStream.downstreamReqHeaders.Set(protocol.MosnHeaderRetry, "true")
+	}
+	convHeaders, ok := r.convertHeader(r.downStream.downstreamReqHeaders)
+	if !ok {
+		// configured to answer a local error instead of forwarding the
+		// raw request to an upstream speaking another protocol
+		r.OnResetStream(types.StreamLocalReset)
+		return
+	}
+	r.requestSender.AppendHeaders(r.downStream.context, convHeaders, endStream)
 
 	r.downStream.requestInfo.OnUpstreamHostSelected(host)
 	r.downStream.requestInfo.SetUpstreamLocalAddress(host.Address())
@@ -45,6 +45,14 @@ func parseProxyTimeout(timeout *Timeout, route types.Route, headers types.Header
 		}
 	}
 
+	// gRPC clients propagate their deadline in grpc-timeout, honor it as
+	// the upstream timeout so mosn gives up no later than the client does
+	if gt, ok := headers.Get(headerGrpcTimeout); ok && gt != "" {
+		if d, valid := parseGrpcTimeout(gt); valid {
+			timeout.GlobalTimeout = d
+		}
+	}
+
 	if timeout.GlobalTimeout == 0 {
 		timeout.GlobalTimeout = types.GlobalTimeout
 	}
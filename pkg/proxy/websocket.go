@@ -0,0 +1,197 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+
+	"sofastack.io/sofa-mosn/pkg/buffer"
+	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/protocol"
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+// websocket handshake headers
+const (
+	headerConnection  = "connection"
+	headerUpgrade     = "upgrade"
+	websocketProtocol = "websocket"
+)
+
+// isWebSocketUpgrade reports whether the request is a websocket handshake,
+// i.e. Connection lists the upgrade token and Upgrade names websocket
+func isWebSocketUpgrade(headers types.HeaderMap) bool {
+	upgrade, _ := headers.Get(headerUpgrade)
+	if !strings.EqualFold(upgrade, websocketProtocol) {
+		return false
+	}
+	connection, _ := headers.Get(headerConnection)
+	for _, token := range strings.Split(connection, ",") {
+		if strings.EqualFold(strings.TrimSpace(token), headerUpgrade) {
+			return true
+		}
+	}
+	return false
+}
+
+// serializeUpgradeRequest renders the handshake back into wire format for
+// the raw upstream connection, mosn internal and pseudo headers are dropped
+func serializeUpgradeRequest(headers types.HeaderMap) []byte {
+	method, _ := headers.Get(protocol.MosnHeaderMethod)
+	if method == "" {
+		method = http.MethodGet
+	}
+	path, _ := headers.Get(protocol.MosnHeaderPathKey)
+	if path == "" {
+		path = "/"
+	}
+	if query, _ := headers.Get(protocol.MosnHeaderQueryStringKey); query != "" {
+		path = path + "?" + query
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(method)
+	buf.WriteString(" ")
+	buf.WriteString(path)
+	buf.WriteString(" HTTP/1.1\r\n")
+	if host, _ := headers.Get("host"); host == "" {
+		if authority, _ := headers.Get(":authority"); authority != "" {
+			buf.WriteString("host: ")
+			buf.WriteString(authority)
+			buf.WriteString("\r\n")
+		}
+	}
+	headers.Range(func(key, value string) bool {
+		lower := strings.ToLower(key)
+		if strings.HasPrefix(lower, "x-mosn-") || strings.HasPrefix(lower, ":") {
+			return true
+		}
+		buf.WriteString(lower)
+		buf.WriteString(": ")
+		buf.WriteString(value)
+		buf.WriteString("\r\n")
+		return true
+	})
+	buf.WriteString("\r\n")
+	return buf.Bytes()
+}
+
+// websocketBridge splices a spliced downstream connection and its raw
+// upstream connection, bytes flow in both directions without the stream
+// layer. It is the upstream connection's read filter and event listener
+type websocketBridge struct {
+	proxy      *proxy
+	downstream types.Connection
+	upstream   types.ClientConnection
+}
+
+// onDownstreamData forwards raw downstream bytes to the upstream
+func (ws *websocketBridge) onDownstreamData(buf types.IoBuffer) {
+	ws.upstream.Write(buf.Clone())
+	buf.Drain(buf.Len())
+}
+
+// OnData implements types.ReadFilter on the upstream connection, the 101
+// response and all websocket frames flow back through here
+func (ws *websocketBridge) OnData(buf types.IoBuffer) types.FilterStatus {
+	ws.downstream.Write(buf.Clone())
+	buf.Drain(buf.Len())
+	return types.Stop
+}
+
+func (ws *websocketBridge) OnNewConnection() types.FilterStatus { return types.Continue }
+
+func (ws *websocketBridge) InitializeReadFilterCallbacks(cb types.ReadFilterCallbacks) {}
+
+// OnEvent implements types.ConnectionEventListener on the upstream side,
+// the downstream connection follows the upstream close
+func (ws *websocketBridge) OnEvent(event types.ConnectionEvent) {
+	if event.IsClose() {
+		ws.downstream.Close(types.FlushWrite, types.LocalClose)
+	}
+}
+
+func (p *proxy) setWebSocketBridge(ws *websocketBridge) {
+	p.asMux.Lock()
+	p.websocketBridge = ws
+	p.asMux.Unlock()
+}
+
+func (p *proxy) websocketSplice() *websocketBridge {
+	p.asMux.RLock()
+	defer p.asMux.RUnlock()
+	return p.websocketBridge
+}
+
+func (p *proxy) takeWebSocketBridge() *websocketBridge {
+	p.asMux.Lock()
+	defer p.asMux.Unlock()
+	ws := p.websocketBridge
+	p.websocketBridge = nil
+	return ws
+}
+
+// handleWebSocketUpgrade switches the whole connection into a raw splice.
+// The handshake is re-serialized onto a fresh upstream TCP connection and
+// the 101 response flows back raw, the stream layer never sees either
+func (s *downStream) handleWebSocketUpgrade() {
+	if !s.route.RouteRule().SupportWebSocket() {
+		s.proxy.stats.DownstreamWebSocketUpgradeReject.Inc(1)
+		s.proxy.listenerStats.DownstreamWebSocketUpgradeReject.Inc(1)
+		s.sendHijackReply(http.StatusForbidden, s.downstreamReqHeaders)
+		return
+	}
+
+	connData := s.proxy.clusterManager.TCPConnForCluster(s, s.snapshot)
+	if connData.Connection == nil {
+		s.requestInfo.SetResponseFlag(types.NoHealthyUpstream)
+		s.sendHijackReply(types.NoHealthUpstreamCode, s.downstreamReqHeaders)
+		return
+	}
+	upstream := connData.Connection
+
+	bridge := &websocketBridge{
+		proxy:      s.proxy,
+		downstream: s.proxy.readCallbacks.Connection(),
+		upstream:   upstream,
+	}
+	upstream.AddConnectionEventListener(bridge)
+	upstream.FilterManager().AddReadFilter(bridge)
+
+	if err := upstream.Connect(true); err != nil {
+		log.Proxy.Errorf(s.context, "[proxy] [websocket] connect upstream failed: %v", err)
+		s.requestInfo.SetResponseFlag(types.UpstreamConnectionFailure)
+		s.sendHijackReply(types.NoHealthUpstreamCode, s.downstreamReqHeaders)
+		return
+	}
+	s.requestInfo.OnUpstreamHostSelected(connData.HostInfo)
+
+	// serialize before cleanStream recycles the request headers
+	upstream.Write(buffer.NewIoBufferBytes(serializeUpgradeRequest(s.downstreamReqHeaders)))
+	s.proxy.setWebSocketBridge(bridge)
+
+	s.proxy.stats.DownstreamWebSocketUpgradeTotal.Inc(1)
+	s.proxy.stats.DownstreamWebSocketActive.Inc(1)
+	s.proxy.listenerStats.DownstreamWebSocketUpgradeTotal.Inc(1)
+	s.proxy.listenerStats.DownstreamWebSocketActive.Inc(1)
+
+	// the http stream ends here, the connection now belongs to the splice
+	s.cleanStream()
+}
@@ -0,0 +1,77 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"strings"
+	"testing"
+
+	"sofastack.io/sofa-mosn/pkg/protocol"
+)
+
+func TestIsWebSocketUpgrade(t *testing.T) {
+	cases := []struct {
+		connection string
+		upgrade    string
+		expected   bool
+	}{
+		{"Upgrade", "websocket", true},
+		{"upgrade", "WebSocket", true},
+		{"keep-alive, Upgrade", "websocket", true},
+		{"keep-alive", "websocket", false},
+		{"Upgrade", "h2c", false},
+		{"", "", false},
+	}
+	for _, c := range cases {
+		headers := protocol.CommonHeader(map[string]string{
+			"connection": c.connection,
+			"upgrade":    c.upgrade,
+		})
+		if got := isWebSocketUpgrade(headers); got != c.expected {
+			t.Errorf("connection=%q upgrade=%q: expected %v, got %v", c.connection, c.upgrade, c.expected, got)
+		}
+	}
+}
+
+func TestSerializeUpgradeRequest(t *testing.T) {
+	headers := protocol.CommonHeader(map[string]string{
+		protocol.MosnHeaderMethod:  "GET",
+		protocol.MosnHeaderPathKey: "/chat",
+		"host":                     "example.com",
+		"connection":               "Upgrade",
+		"upgrade":                  "websocket",
+		"sec-websocket-key":        "dGhlIHNhbXBsZSBub25jZQ==",
+		"sec-websocket-version":    "13",
+	})
+
+	raw := string(serializeUpgradeRequest(headers))
+	if !strings.HasPrefix(raw, "GET /chat HTTP/1.1\r\n") {
+		t.Errorf("unexpected request line: %q", raw)
+	}
+	if !strings.HasSuffix(raw, "\r\n\r\n") {
+		t.Errorf("request not terminated: %q", raw)
+	}
+	for _, header := range []string{"host: example.com", "upgrade: websocket", "sec-websocket-key: dGhlIHNhbXBsZSBub25jZQ=="} {
+		if !strings.Contains(raw, header+"\r\n") {
+			t.Errorf("missing header %q in %q", header, raw)
+		}
+	}
+	if strings.Contains(raw, "x-mosn-") {
+		t.Errorf("internal headers leaked: %q", raw)
+	}
+}
@@ -68,12 +68,12 @@ func NewRouteRuleImplBase(vHost *VirtualHostImpl, route *v2.Router) (*RouteRuleI
 		autoHostRewrite:       route.Route.AutoHostRewrite,
 		requestHeadersParser:  getHeaderParser(route.Route.RequestHeadersToAdd, nil),
 		responseHeadersParser: getHeaderParser(route.Route.ResponseHeadersToAdd, route.Route.ResponseHeadersToRemove),
-		upstreamProtocol:      route.Route.UpstreamProtocol,
+		upstreamProtocol:      internString(route.Route.UpstreamProtocol),
 		perFilterConfig:       route.PerFilterConfig,
 		policy:                &policy{},
 		routerAction:          route.Route,
 		defaultCluster: &weightedClusterEntry{
-			clusterName: route.Route.ClusterName,
+			clusterName: internString(route.Route.ClusterName),
 		},
 		lock: sync.Mutex{},
 	}
@@ -85,9 +85,21 @@ func NewRouteRuleImplBase(vHost *VirtualHostImpl, route *v2.Router) (*RouteRuleI
 	// add policy
 	if route.Route.RetryPolicy != nil {
 		base.policy.retryPolicy = &retryPolicyImpl{
-			retryOn:      route.Route.RetryPolicy.RetryOn,
-			retryTimeout: route.Route.RetryPolicy.RetryTimeout,
-			numRetries:   route.Route.RetryPolicy.NumRetries,
+			retryOn:          route.Route.RetryPolicy.RetryOn,
+			retryTimeout:     route.Route.RetryPolicy.RetryTimeout,
+			numRetries:       route.Route.RetryPolicy.NumRetries,
+			retryableMethods: route.Route.RetryPolicy.RetryableMethods,
+		}
+	}
+	// add shadow policy
+	if route.Route.Shadow != nil {
+		percent := route.Route.Shadow.Percent
+		if percent == 0 || percent > 100 {
+			percent = 100
+		}
+		base.policy.shadowPolicy = &shadowPolicyImpl{
+			cluster: internString(route.Route.Shadow.Cluster),
+			percent: percent,
 		}
 	}
 	// add direct repsonse rule
@@ -131,6 +143,10 @@ func (rri *RouteRuleImplBase) UpstreamProtocol() string {
 	return rri.upstreamProtocol
 }
 
+func (rri *RouteRuleImplBase) SupportWebSocket() bool {
+	return rri.routerAction.EnableWebSocket
+}
+
 func (rri *RouteRuleImplBase) GlobalTimeout() time.Duration {
 	return rri.routerAction.Timeout
 }
@@ -194,7 +210,22 @@ func (rri *RouteRuleImplBase) FinalizeRequestHeaders(headers types.HeaderMap, re
 	rri.finalizeRequestHeaders(headers, requestInfo)
 }
 
+// selectedWeightedCluster returns the weighted cluster entry the request
+// was routed to, the selection is stamped on the request info since the
+// weighted choice is random per call
+func (rri *RouteRuleImplBase) selectedWeightedCluster(requestInfo types.RequestInfo) (weightedClusterEntry, bool) {
+	if len(rri.weightedClusters) == 0 || requestInfo == nil {
+		return weightedClusterEntry{}, false
+	}
+	entry, ok := rri.weightedClusters[requestInfo.UpstreamCluster()]
+	return entry, ok
+}
+
 func (rri *RouteRuleImplBase) finalizeRequestHeaders(headers types.HeaderMap, requestInfo types.RequestInfo) {
+	// the chosen weighted cluster mutates first, then route, virtual host and global config
+	if entry, ok := rri.selectedWeightedCluster(requestInfo); ok {
+		entry.requestHeadersParser.evaluateHeaders(headers, requestInfo)
+	}
 	rri.requestHeadersParser.evaluateHeaders(headers, requestInfo)
 	rri.vHost.requestHeadersParser.evaluateHeaders(headers, requestInfo)
 	rri.vHost.globalRouteConfig.requestHeadersParser.evaluateHeaders(headers, requestInfo)
@@ -204,6 +235,9 @@ func (rri *RouteRuleImplBase) finalizeRequestHeaders(headers types.HeaderMap, re
 }
 
 func (rri *RouteRuleImplBase) FinalizeResponseHeaders(headers types.HeaderMap, requestInfo types.RequestInfo) {
+	if entry, ok := rri.selectedWeightedCluster(requestInfo); ok {
+		entry.responseHeadersParser.evaluateHeaders(headers, requestInfo)
+	}
 	rri.responseHeadersParser.evaluateHeaders(headers, requestInfo)
 	rri.vHost.responseHeadersParser.evaluateHeaders(headers, requestInfo)
 	rri.vHost.globalRouteConfig.responseHeadersParser.evaluateHeaders(headers, requestInfo)
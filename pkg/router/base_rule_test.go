@@ -23,6 +23,7 @@ import (
 	"testing"
 
 	"sofastack.io/sofa-mosn/pkg/api/v2"
+	"sofastack.io/sofa-mosn/pkg/network"
 	"sofastack.io/sofa-mosn/pkg/protocol"
 	"sofastack.io/sofa-mosn/pkg/types"
 )
@@ -459,3 +460,103 @@ func Test_RouteRuleImplBase_FinalizeResponseHeaders(t *testing.T) {
 		})
 	}
 }
+
+func TestShadowPolicy(t *testing.T) {
+	routerMock := &v2.Router{}
+	routerMock.Route = v2.RouteAction{
+		RouterActionConfig: v2.RouterActionConfig{
+			ClusterName: "defaultCluster",
+			Shadow: &v2.ShadowPolicy{
+				Cluster: "shadowCluster",
+				Percent: 30,
+			},
+		},
+	}
+	base, err := NewRouteRuleImplBase(nil, routerMock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shadow := base.Policy().ShadowPolicy()
+	if shadow.ClusterName() != "shadowCluster" || shadow.Percent() != 30 {
+		t.Errorf("unexpected shadow policy, cluster = %s, percent = %d", shadow.ClusterName(), shadow.Percent())
+	}
+	// a zero or invalid percent mirrors everything
+	routerMock.Route.Shadow.Percent = 0
+	base, _ = NewRouteRuleImplBase(nil, routerMock)
+	if base.Policy().ShadowPolicy().Percent() != 100 {
+		t.Errorf("zero percent should default to 100, got %d", base.Policy().ShadowPolicy().Percent())
+	}
+	routerMock.Route.Shadow.Percent = 300
+	base, _ = NewRouteRuleImplBase(nil, routerMock)
+	if base.Policy().ShadowPolicy().Percent() != 100 {
+		t.Errorf("invalid percent should default to 100, got %d", base.Policy().ShadowPolicy().Percent())
+	}
+	// without a shadow config the accessors stay safe on the nil policy
+	routerMock.Route.Shadow = nil
+	base, _ = NewRouteRuleImplBase(nil, routerMock)
+	if base.Policy().ShadowPolicy().ClusterName() != "" {
+		t.Error("route without shadow config should have no shadow cluster")
+	}
+}
+
+func TestWeightedClusterHeaderMutation(t *testing.T) {
+	appendFalse := false
+	routerMock := &v2.Router{}
+	routerMock.Route = v2.RouteAction{
+		RouterActionConfig: v2.RouterActionConfig{
+			ClusterName: "defaultCluster",
+			WeightedClusters: []v2.WeightedCluster{
+				{
+					Cluster: v2.ClusterWeight{
+						ClusterWeightConfig: v2.ClusterWeightConfig{
+							Name:   "w1",
+							Weight: 50,
+							RequestHeadersToAdd: []*v2.HeaderValueOption{
+								{
+									Header: &v2.HeaderValue{Key: "x-canary", Value: "true"},
+									Append: &appendFalse,
+								},
+							},
+							ResponseHeadersToRemove: []string{"x-internal"},
+						},
+					},
+				},
+				{
+					Cluster: v2.ClusterWeight{
+						ClusterWeightConfig: v2.ClusterWeightConfig{
+							Name:   "w2",
+							Weight: 50,
+						},
+					},
+				},
+			},
+		},
+	}
+	base, err := NewRouteRuleImplBase(nil, routerMock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base.vHost = &VirtualHostImpl{globalRouteConfig: &configImpl{}}
+
+	// the request was routed to w1, its mutations apply
+	info := network.NewRequestInfo()
+	info.SetUpstreamCluster("w1")
+	headers := protocol.CommonHeader{}
+	base.FinalizeRequestHeaders(headers, info)
+	if v, ok := headers.Get("x-canary"); !ok || v != "true" {
+		t.Errorf("expected weighted cluster request header, got %v", headers)
+	}
+	respHeaders := protocol.CommonHeader{"x-internal": "secret"}
+	base.FinalizeResponseHeaders(respHeaders, info)
+	if _, ok := respHeaders.Get("x-internal"); ok {
+		t.Error("expected weighted cluster to remove the response header")
+	}
+
+	// routed to w2, no mutations configured
+	info.SetUpstreamCluster("w2")
+	headers = protocol.CommonHeader{}
+	base.FinalizeRequestHeaders(headers, info)
+	if _, ok := headers.Get("x-canary"); ok {
+		t.Error("w2 has no header mutations configured")
+	}
+}
@@ -45,12 +45,12 @@ func RegisterRouterRule(f RouterRuleFactory, order uint32) {
 }
 
 func DefaultSofaRouterRuleFactory(base *RouteRuleImplBase, headers []v2.HeaderMatcher) RouteBase {
-	for _, header := range headers {
-		if header.Name == types.SofaRouteMatchKey {
-			return &SofaRouteRuleImpl{
-				RouteRuleImplBase: base,
-				matchValue:        header.Value,
-			}
+	if matchers := sofaHeaderMatchers(headers); len(matchers) > 0 {
+		return &SofaRouteRuleImpl{
+			RouteRuleImplBase: base,
+			matchName:         matchers[0].matchName,
+			matchValue:        matchers[0].matchValue,
+			matchers:          matchers,
 		}
 	}
 	return nil
@@ -18,17 +18,17 @@
 package router
 
 import (
+	"net"
 	"strings"
+	"time"
 
 	"sofastack.io/sofa-mosn/pkg/log"
 	"sofastack.io/sofa-mosn/pkg/types"
 )
 
 func getHeaderFormatter(value string, append bool) headerFormatter {
-	// TODO: variable headers would be support very soon
 	if strings.Index(value, "%") != -1 {
-		log.DefaultLogger.Warnf("variable headers not support yet, skip, value: %s", value)
-		return nil
+		return getVariableHeaderFormatter(value, append)
 	}
 	return &plainHeaderFormatter{
 		isAppend:    append,
@@ -48,3 +48,144 @@ func (f *plainHeaderFormatter) append() bool {
 func (f *plainHeaderFormatter) format(requestInfo types.RequestInfo) string {
 	return f.staticValue
 }
+
+// headerVariable resolves a %VARIABLE% reference from the request,
+// unresolvable references (e.g. no upstream host selected yet) format to
+// an empty string
+type headerVariable func(requestInfo types.RequestInfo) string
+
+var headerVariables = map[string]headerVariable{
+	"DOWNSTREAM_REMOTE_ADDRESS": func(requestInfo types.RequestInfo) string {
+		if requestInfo == nil {
+			return ""
+		}
+		return formatAddress(requestInfo.DownstreamRemoteAddress())
+	},
+	"DOWNSTREAM_REMOTE_ADDRESS_WITHOUT_PORT": func(requestInfo types.RequestInfo) string {
+		if requestInfo == nil {
+			return ""
+		}
+		return formatAddressWithoutPort(requestInfo.DownstreamRemoteAddress())
+	},
+	"DOWNSTREAM_LOCAL_ADDRESS": func(requestInfo types.RequestInfo) string {
+		if requestInfo == nil {
+			return ""
+		}
+		return formatAddress(requestInfo.DownstreamLocalAddress())
+	},
+	"DOWNSTREAM_LOCAL_ADDRESS_WITHOUT_PORT": func(requestInfo types.RequestInfo) string {
+		if requestInfo == nil {
+			return ""
+		}
+		return formatAddressWithoutPort(requestInfo.DownstreamLocalAddress())
+	},
+	"UPSTREAM_LOCAL_ADDRESS": func(requestInfo types.RequestInfo) string {
+		if requestInfo == nil {
+			return ""
+		}
+		return formatAddress(requestInfo.UpstreamLocalAddress())
+	},
+	"UPSTREAM_REMOTE_ADDRESS": func(requestInfo types.RequestInfo) string {
+		if requestInfo == nil || requestInfo.UpstreamHost() == nil {
+			return ""
+		}
+		return requestInfo.UpstreamHost().AddressString()
+	},
+	"UPSTREAM_CLUSTER": func(requestInfo types.RequestInfo) string {
+		if requestInfo == nil {
+			return ""
+		}
+		return requestInfo.UpstreamCluster()
+	},
+	"PROTOCOL": func(requestInfo types.RequestInfo) string {
+		if requestInfo == nil {
+			return ""
+		}
+		return string(requestInfo.Protocol())
+	},
+	"START_TIME": func(requestInfo types.RequestInfo) string {
+		if requestInfo == nil {
+			return ""
+		}
+		return requestInfo.StartTime().Format(time.RFC3339)
+	},
+}
+
+func formatAddress(addr net.Addr) string {
+	if addr == nil {
+		return ""
+	}
+	return addr.String()
+}
+
+func formatAddressWithoutPort(addr net.Addr) string {
+	if addr == nil {
+		return ""
+	}
+	if host, _, err := net.SplitHostPort(addr.String()); err == nil {
+		return host
+	}
+	return addr.String()
+}
+
+// variableHeaderFormatter formats a header value mixing static text with
+// %VARIABLE% references, a literal percent is written as %%
+type variableHeaderFormatter struct {
+	isAppend bool
+	segments []headerVariable
+}
+
+func getVariableHeaderFormatter(value string, isAppend bool) headerFormatter {
+	var segments []headerVariable
+	for len(value) > 0 {
+		begin := strings.IndexByte(value, '%')
+		if begin == -1 {
+			segments = append(segments, staticSegment(value))
+			break
+		}
+		if begin > 0 {
+			segments = append(segments, staticSegment(value[:begin]))
+		}
+		end := strings.IndexByte(value[begin+1:], '%')
+		if end == -1 {
+			log.DefaultLogger.Warnf("unterminated variable in header value, skip, value: %s", value)
+			return nil
+		}
+		name := value[begin+1 : begin+1+end]
+		if name == "" {
+			// %% is an escaped literal percent
+			segments = append(segments, staticSegment("%"))
+		} else if variable, ok := headerVariables[name]; ok {
+			segments = append(segments, variable)
+		} else {
+			log.DefaultLogger.Warnf("unknown variable in header value, skip, variable: %s", name)
+			return nil
+		}
+		value = value[begin+end+2:]
+	}
+	return &variableHeaderFormatter{
+		isAppend: isAppend,
+		segments: segments,
+	}
+}
+
+func staticSegment(text string) headerVariable {
+	return func(requestInfo types.RequestInfo) string {
+		return text
+	}
+}
+
+func (f *variableHeaderFormatter) append() bool {
+	return f.isAppend
+}
+
+func (f *variableHeaderFormatter) format(requestInfo types.RequestInfo) string {
+	if len(f.segments) == 1 {
+		return f.segments[0](requestInfo)
+	}
+	var buf strings.Builder
+	for _, segment := range f.segments {
+		buf.WriteString(segment(requestInfo))
+	}
+	return buf.String()
+}
@@ -18,8 +18,11 @@
 package router
 
 import (
+	"net"
 	"reflect"
 	"testing"
+
+	"sofastack.io/sofa-mosn/pkg/network"
 )
 
 func Test_getHeaderFormatter(t *testing.T) {
@@ -110,3 +113,47 @@ func Test_plainHeaderFormatter_format(t *testing.T) {
 		})
 	}
 }
+
+func Test_getVariableHeaderFormatter(t *testing.T) {
+	info := network.NewRequestInfo()
+	remoteAddr, _ := net.ResolveTCPAddr("tcp", "10.0.0.1:34567")
+	info.SetDownstreamRemoteAddress(remoteAddr)
+	info.SetUpstreamCluster("clusterA")
+
+	cases := []struct {
+		value string
+		want  string
+	}{
+		{"%DOWNSTREAM_REMOTE_ADDRESS%", "10.0.0.1:34567"},
+		{"%DOWNSTREAM_REMOTE_ADDRESS_WITHOUT_PORT%", "10.0.0.1"},
+		{"%UPSTREAM_CLUSTER%", "clusterA"},
+		{"cluster=%UPSTREAM_CLUSTER%;addr=%DOWNSTREAM_REMOTE_ADDRESS_WITHOUT_PORT%", "cluster=clusterA;addr=10.0.0.1"},
+		{"100%%", "100%"},
+		// unset references format to an empty string
+		{"%UPSTREAM_REMOTE_ADDRESS%", ""},
+		{"%UPSTREAM_LOCAL_ADDRESS%", ""},
+	}
+	for _, tc := range cases {
+		formatter := getHeaderFormatter(tc.value, false)
+		if formatter == nil {
+			t.Errorf("value %q should create a formatter", tc.value)
+			continue
+		}
+		if got := formatter.format(info); got != tc.want {
+			t.Errorf("format(%q) = %q, want %q", tc.value, got, tc.want)
+		}
+	}
+
+	// unknown or unterminated variables skip the whole header
+	if getHeaderFormatter("%NOT_A_VARIABLE%", false) != nil {
+		t.Error("unknown variable should not create a formatter")
+	}
+	if getHeaderFormatter("50% off", false) != nil {
+		t.Error("unterminated variable should not create a formatter")
+	}
+
+	// variable formatters survive a nil request info
+	if got := getHeaderFormatter("%PROTOCOL%", true).format(nil); got != "" {
+		t.Errorf("format(nil) = %q, want empty", got)
+	}
+}
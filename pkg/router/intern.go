@@ -0,0 +1,108 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"regexp"
+	"sync"
+
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+// Gateways loading tens of thousands of RDS routes repeat the same
+// cluster names, matcher headers and domains across virtual hosts, and
+// the whole route table is rebuilt on every update. Interning those
+// config-derived strings and sharing the immutable matcher structures
+// keeps one copy alive instead of one per route. The pools only ever hold
+// strings coming from route configs, so they stay bounded by the config
+// vocabulary, not by traffic.
+
+var (
+	internMux       sync.RWMutex
+	internedStrings = make(map[string]string)
+
+	headerDataMux    sync.RWMutex
+	sharedHeaderData = make(map[headerMatcherKey]*types.HeaderData)
+)
+
+type headerMatcherKey struct {
+	name    string
+	value   string
+	isRegex bool
+}
+
+// internString returns the canonical copy of s, route tables built from
+// repetitive configs share one backing string
+func internString(s string) string {
+	internMux.RLock()
+	canonical, ok := internedStrings[s]
+	internMux.RUnlock()
+	if ok {
+		return canonical
+	}
+	internMux.Lock()
+	canonical, ok = internedStrings[s]
+	if !ok {
+		canonical = s
+		internedStrings[s] = s
+	}
+	internMux.Unlock()
+	return canonical
+}
+
+// sharedRouterHeader returns the matcher for a header matcher config,
+// identical matchers across routes and virtual hosts share one immutable
+// types.HeaderData and one compiled regex. Returns nil when the regex
+// does not compile, matching the historical skip behavior
+func sharedRouterHeader(header v2.HeaderMatcher) *types.HeaderData {
+	key := headerMatcherKey{name: header.Name, value: header.Value, isRegex: header.Regex}
+	headerDataMux.RLock()
+	headerData, ok := sharedHeaderData[key]
+	headerDataMux.RUnlock()
+	if ok {
+		return headerData
+	}
+
+	headerData = &types.HeaderData{
+		Name: &lowerCaseString{
+			internString(header.Name),
+		},
+		Value:   internString(header.Value),
+		IsRegex: header.Regex,
+	}
+	if header.Regex {
+		pattern, err := regexp.Compile(header.Value)
+		if err != nil {
+			log.DefaultLogger.Errorf("getRouterHeaders compile error")
+			return nil
+		}
+		headerData.RegexPattern = pattern
+	}
+
+	headerDataMux.Lock()
+	if exist, ok := sharedHeaderData[key]; ok {
+		// lost the race, keep the first one
+		headerData = exist
+	} else {
+		sharedHeaderData[key] = headerData
+	}
+	headerDataMux.Unlock()
+	return headerData
+}
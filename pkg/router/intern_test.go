@@ -0,0 +1,84 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+)
+
+func stringData(s string) uintptr {
+	return (*reflect.StringHeader)(unsafe.Pointer(&s)).Data
+}
+
+func TestInternString(t *testing.T) {
+	// two different backing arrays with equal content
+	s1 := internString(strings.ToLower("TEST-CLUSTER"))
+	s2 := internString(strings.ToLower("TEST-CLUSTER"))
+	if s1 != "test-cluster" || s2 != "test-cluster" {
+		t.Fatal("interned string content changed")
+	}
+	if stringData(s1) != stringData(s2) {
+		t.Error("equal strings should intern to one backing array")
+	}
+}
+
+func TestSharedRouterHeader(t *testing.T) {
+	header := v2.HeaderMatcher{Name: "x-test", Value: "expected"}
+	h1 := sharedRouterHeader(header)
+	h2 := sharedRouterHeader(header)
+	if h1 == nil || h1 != h2 {
+		t.Error("identical matcher configs should share one HeaderData")
+	}
+
+	regex := v2.HeaderMatcher{Name: "x-test", Value: "^a.*$", Regex: true}
+	r1 := sharedRouterHeader(regex)
+	r2 := sharedRouterHeader(regex)
+	if r1 == nil || r1 != r2 {
+		t.Fatal("identical regex matchers should share one HeaderData")
+	}
+	if r1.RegexPattern == nil || !r1.RegexPattern.MatchString("abc") {
+		t.Error("shared regex matcher lost its pattern")
+	}
+
+	if bad := sharedRouterHeader(v2.HeaderMatcher{Name: "x-test", Value: "(", Regex: true}); bad != nil {
+		t.Error("invalid regex should be skipped")
+	}
+}
+
+func TestGetRouterHeadersShared(t *testing.T) {
+	headers := []v2.HeaderMatcher{
+		{Name: "x-a", Value: "1"},
+		{Name: "x-bad", Value: "(", Regex: true},
+		{Name: "x-b", Value: "2"},
+	}
+	datas := getRouterHeaders(headers)
+	if len(datas) != 2 {
+		t.Fatalf("invalid regex should be skipped, got %d matchers", len(datas))
+	}
+	again := getRouterHeaders(headers)
+	for i := range datas {
+		if datas[i] != again[i] {
+			t.Error("rebuilt route tables should reuse the shared matchers")
+		}
+	}
+}
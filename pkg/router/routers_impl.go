@@ -213,7 +213,7 @@ func NewRouters(routerConfig *v2.RouterConfiguration) (types.Routers, error) {
 		routers.virtualHosts = append(routers.virtualHosts, vh)
 		vh.globalRouteConfig = configImpl
 		for _, domain := range vhConfig.Domains {
-			domain = strings.ToLower(domain) // we use domain in lowercase
+			domain = internString(strings.ToLower(domain)) // we use domain in lowercase
 			if domain == "*" {
 				if routers.defaultVirtualHostIndex != -1 {
 					log.DefaultLogger.Errorf(RouterLogFormat, "routers", "NewRouters", "duplicate default virtualhost")
@@ -23,15 +23,43 @@ import (
 	"sofastack.io/sofa-mosn/pkg/types"
 )
 
-func SofaRouterFactory(headers []v2.HeaderMatcher) RouteBase {
+// sofa route match keys: the service data id, the rpc class/interface name
+// and the unique id, all carried in bolt request headers
+var sofaRouteMatchKeys = []string{
+	types.SofaRouteMatchKey,
+	types.SofaRouteMatchClassKey,
+	types.SofaRouteMatchUniqueIdKey,
+}
+
+type sofaHeaderMatcher struct {
+	matchName  string
+	matchValue string
+}
+
+// sofaHeaderMatchers collects the sofa match fields from the route config
+func sofaHeaderMatchers(headers []v2.HeaderMatcher) []sofaHeaderMatcher {
+	var matchers []sofaHeaderMatcher
 	for _, header := range headers {
-		if header.Name == types.SofaRouteMatchKey {
-			return &SofaRouteRuleImpl{
-				matchName:  header.Name,
-				matchValue: header.Value,
+		for _, key := range sofaRouteMatchKeys {
+			if header.Name == key {
+				matchers = append(matchers, sofaHeaderMatcher{
+					matchName:  header.Name,
+					matchValue: header.Value,
+				})
 			}
 		}
 	}
+	return matchers
+}
+
+func SofaRouterFactory(headers []v2.HeaderMatcher) RouteBase {
+	if matchers := sofaHeaderMatchers(headers); len(matchers) > 0 {
+		return &SofaRouteRuleImpl{
+			matchName:  matchers[0].matchName,
+			matchValue: matchers[0].matchValue,
+			matchers:   matchers,
+		}
+	}
 	log.DefaultLogger.Errorf(RouterLogFormat, "sofa router factory", "create failed", headers)
 	return nil
 }
@@ -40,6 +68,7 @@ type SofaRouteRuleImpl struct {
 	*RouteRuleImplBase
 	matchName  string
 	matchValue string
+	matchers   []sofaHeaderMatcher
 }
 
 func (srri *SofaRouteRuleImpl) PathMatchCriterion() types.PathMatchCriterion {
@@ -62,11 +91,16 @@ func (srri *SofaRouteRuleImpl) FinalizeRequestHeaders(headers types.HeaderMap, r
 }
 
 func (srri *SofaRouteRuleImpl) Match(headers types.HeaderMap, randomValue uint64) types.Route {
-	if value, ok := headers.Get(types.SofaRouteMatchKey); ok {
-		if value == srri.matchValue || srri.matchValue == ".*" {
-			return srri
+	for _, matcher := range srri.matchers {
+		value, ok := headers.Get(matcher.matchName)
+		if !ok && matcher.matchName == types.SofaRouteMatchKey {
+			// old clients carry the service only in the target service key
+			value, ok = headers.Get(types.SofaRouteMatchClassKey)
+		}
+		if !ok || !(value == matcher.matchValue || matcher.matchValue == ".*") {
+			log.DefaultLogger.Errorf(RouterLogFormat, "sofa rotue rule", "failed match", headers)
+			return nil
 		}
 	}
-	log.DefaultLogger.Errorf(RouterLogFormat, "sofa rotue rule", "failed match", headers)
-	return nil
+	return srri
 }
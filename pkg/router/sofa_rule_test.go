@@ -0,0 +1,70 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"testing"
+
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+	"sofastack.io/sofa-mosn/pkg/protocol"
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+func TestSofaRouteRuleMatchClassAndUniqueId(t *testing.T) {
+	rule := SofaRouterFactory([]v2.HeaderMatcher{
+		{Name: types.SofaRouteMatchClassKey, Value: "com.alipay.test.SampleService"},
+		{Name: types.SofaRouteMatchUniqueIdKey, Value: "core"},
+	})
+	if rule == nil {
+		t.Fatal("create sofa route rule with class and unique id failed")
+	}
+
+	matched := map[string]string{
+		types.SofaRouteMatchClassKey:    "com.alipay.test.SampleService",
+		types.SofaRouteMatchUniqueIdKey: "core",
+	}
+	if rule.Match(protocol.CommonHeader(matched), 1) == nil {
+		t.Error("expected headers to match class and unique id rule")
+	}
+
+	// all configured fields must match
+	wrongUniqueId := map[string]string{
+		types.SofaRouteMatchClassKey:    "com.alipay.test.SampleService",
+		types.SofaRouteMatchUniqueIdKey: "gray",
+	}
+	if rule.Match(protocol.CommonHeader(wrongUniqueId), 1) != nil {
+		t.Error("expected unique id mismatch to fail")
+	}
+}
+
+func TestSofaRouteRuleServiceKeyFallback(t *testing.T) {
+	rule := SofaRouterFactory([]v2.HeaderMatcher{
+		{Name: types.SofaRouteMatchKey, Value: "com.alipay.test.SampleService:1.0"},
+	})
+	if rule == nil {
+		t.Fatal("create sofa route rule failed")
+	}
+
+	// old clients carry the service only in the target service key
+	oldHeaders := map[string]string{
+		types.SofaRouteMatchClassKey: "com.alipay.test.SampleService:1.0",
+	}
+	if rule.Match(protocol.CommonHeader(oldHeaders), 1) == nil {
+		t.Error("expected target service key fallback to match")
+	}
+}
@@ -69,6 +69,8 @@ type weightedClusterEntry struct {
 	clusterName                  string
 	clusterWeight                uint32
 	clusterMetadataMatchCriteria *MetadataMatchCriteriaImpl
+	requestHeadersParser         *headerParser
+	responseHeadersParser        *headerParser
 }
 
 type Matchable interface {
@@ -84,7 +86,7 @@ type RouteBase interface {
 // Policy
 type policy struct {
 	retryPolicy  *retryPolicyImpl
-	shadowPolicy *shadowPolicyImpl //TODO: not implement yet
+	shadowPolicy *shadowPolicyImpl
 }
 
 func (p *policy) RetryPolicy() types.RetryPolicy {
@@ -96,9 +98,10 @@ func (p *policy) ShadowPolicy() types.ShadowPolicy {
 }
 
 type retryPolicyImpl struct {
-	retryOn      bool
-	retryTimeout time.Duration
-	numRetries   uint32
+	retryOn          bool
+	retryTimeout     time.Duration
+	numRetries       uint32
+	retryableMethods []string
 }
 
 func (p *retryPolicyImpl) RetryOn() bool {
@@ -122,19 +125,40 @@ func (p *retryPolicyImpl) NumRetries() uint32 {
 	return p.numRetries
 }
 
+func (p *retryPolicyImpl) RetryableMethods() []string {
+	if p == nil {
+		return nil
+	}
+	return p.retryableMethods
+}
+
 type shadowPolicyImpl struct {
 	cluster    string
 	runtimeKey string
+	percent    uint32
 }
 
 func (spi *shadowPolicyImpl) ClusterName() string {
+	if spi == nil {
+		return ""
+	}
 	return spi.cluster
 }
 
 func (spi *shadowPolicyImpl) RuntimeKey() string {
+	if spi == nil {
+		return ""
+	}
 	return spi.runtimeKey
 }
 
+func (spi *shadowPolicyImpl) Percent() uint32 {
+	if spi == nil {
+		return 0
+	}
+	return spi.percent
+}
+
 // RouterRuleFactory creates a RouteBase
 type RouterRuleFactory func(base *RouteRuleImplBase, header []v2.HeaderMatcher) RouteBase
 
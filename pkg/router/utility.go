@@ -18,10 +18,7 @@
 package router
 
 import (
-	"regexp"
-
 	"sofastack.io/sofa-mosn/pkg/api/v2"
-	"sofastack.io/sofa-mosn/pkg/log"
 	"sofastack.io/sofa-mosn/pkg/types"
 )
 
@@ -29,10 +26,13 @@ func getWeightedClusterEntry(weightedClusters []v2.WeightedCluster) (map[string]
 	weightedClusterEntries := make(map[string]weightedClusterEntry)
 	var totalWeight uint32
 	for _, weightedCluster := range weightedClusters {
-		weightedClusterEntries[weightedCluster.Cluster.Name] = weightedClusterEntry{
-			clusterName:                  weightedCluster.Cluster.Name,
+		clusterName := internString(weightedCluster.Cluster.Name)
+		weightedClusterEntries[clusterName] = weightedClusterEntry{
+			clusterName:                  clusterName,
 			clusterWeight:                weightedCluster.Cluster.Weight,
 			clusterMetadataMatchCriteria: NewMetadataMatchCriteriaImpl(weightedCluster.Cluster.MetadataMatch),
+			requestHeadersParser:         getHeaderParser(weightedCluster.Cluster.RequestHeadersToAdd, nil),
+			responseHeadersParser:        getHeaderParser(weightedCluster.Cluster.ResponseHeadersToAdd, weightedCluster.Cluster.ResponseHeadersToRemove),
 		}
 		totalWeight = totalWeight + weightedCluster.Cluster.Weight
 	}
@@ -48,25 +48,12 @@ func getRouterHeaders(headers []v2.HeaderMatcher) []*types.HeaderData {
 	var headerDatas []*types.HeaderData
 
 	for _, header := range headers {
-		headerData := &types.HeaderData{
-			Name: &lowerCaseString{
-				header.Name,
-			},
-			Value:   header.Value,
-			IsRegex: header.Regex,
-		}
-
-		if header.Regex {
-			pattern, err := regexp.Compile(header.Value)
-			if err != nil {
-				log.DefaultLogger.Errorf("getRouterHeaders compile error")
-				continue
-			}
-			headerData.RegexPattern = pattern
+		// identical matchers share one immutable HeaderData
+		headerData := sharedRouterHeader(header)
+		if headerData == nil {
+			continue
 		}
-
 		headerDatas = append(headerDatas, headerData)
-
 	}
 
 	return headerDatas
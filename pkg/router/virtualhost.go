@@ -80,8 +80,8 @@ func (vh *VirtualHostImpl) addRouteBase(route *v2.Router) error {
 		// make fast index, used in certain scenarios
 		// TODO: rule can be extended
 		if len(route.Match.Headers) == 1 && !route.Match.Headers[0].Regex {
-			key := route.Match.Headers[0].Name
-			value := route.Match.Headers[0].Value
+			key := internString(route.Match.Headers[0].Name)
+			value := internString(route.Match.Headers[0].Value)
 			valueMap, ok := vh.fastIndex[key]
 			if !ok {
 				valueMap = make(map[string]types.Route)
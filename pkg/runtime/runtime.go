@@ -0,0 +1,167 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package runtime is a layered key value store for feature flags and numeric
+// overrides (health check intervals, retry limits and so on). The static
+// layer comes from the mosn config, dynamic layers are pushed over rtds and
+// override the static values. Other modules query the merged view, the admin
+// endpoint /api/v1/runtime dumps the layers and the merged snapshot.
+package runtime
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"sofastack.io/sofa-mosn/pkg/admin/server"
+)
+
+// StaticLayerName is the name of the lowest layer, filled from the config
+const StaticLayerName = "static"
+
+var (
+	mu          sync.RWMutex
+	staticLayer map[string]string
+	layerOrder  []string // dynamic layer names, later layers override earlier
+	layers      map[string]map[string]string
+)
+
+func init() {
+	server.RegisterAdminHandleFunc("/api/v1/runtime", dumpRuntime)
+}
+
+// ApplyStaticLayer replaces the static layer, called on config load
+func ApplyStaticLayer(values map[string]string) {
+	mu.Lock()
+	defer mu.Unlock()
+	staticLayer = copyValues(values)
+}
+
+// ApplyLayer replaces a dynamic layer, a new layer name is stacked on top
+// of the existing ones
+func ApplyLayer(name string, values map[string]string) {
+	mu.Lock()
+	defer mu.Unlock()
+	if layers == nil {
+		layers = make(map[string]map[string]string)
+	}
+	if _, ok := layers[name]; !ok {
+		layerOrder = append(layerOrder, name)
+	}
+	layers[name] = copyValues(values)
+}
+
+// Get returns the merged value of a key, dynamic layers override static
+func Get(key string) (string, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	for i := len(layerOrder) - 1; i >= 0; i-- {
+		if v, ok := layers[layerOrder[i]][key]; ok {
+			return v, true
+		}
+	}
+	v, ok := staticLayer[key]
+	return v, ok
+}
+
+// GetBool reads a feature flag, missing or unparseable keys use the default
+func GetBool(key string, def bool) bool {
+	if v, ok := Get(key); ok {
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return def
+}
+
+// GetInt reads a numeric override, missing or unparseable keys use the default
+func GetInt(key string, def int64) int64 {
+	if v, ok := Get(key); ok {
+		if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return i
+		}
+		// rtds pushes numbers as float64
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return int64(f)
+		}
+	}
+	return def
+}
+
+// GetDuration reads a duration override like "3s", missing or unparseable
+// keys use the default
+func GetDuration(key string, def time.Duration) time.Duration {
+	if v, ok := Get(key); ok {
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+	}
+	return def
+}
+
+// Snapshot returns the merged view of all layers
+func Snapshot() map[string]string {
+	mu.RLock()
+	defer mu.RUnlock()
+	merged := make(map[string]string, len(staticLayer))
+	for k, v := range staticLayer {
+		merged[k] = v
+	}
+	for _, name := range layerOrder {
+		for k, v := range layers[name] {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+func copyValues(values map[string]string) map[string]string {
+	copied := make(map[string]string, len(values))
+	for k, v := range values {
+		copied[k] = v
+	}
+	return copied
+}
+
+// runtimeDump is the admin endpoint payload
+type runtimeDump struct {
+	Layers map[string]map[string]string `json:"layers"`
+	Merged map[string]string            `json:"merged"`
+}
+
+func dumpRuntime(w http.ResponseWriter, r *http.Request) {
+	mu.RLock()
+	dump := runtimeDump{
+		Layers: make(map[string]map[string]string, len(layers)+1),
+	}
+	dump.Layers[StaticLayerName] = copyValues(staticLayer)
+	for _, name := range layerOrder {
+		dump.Layers[name] = copyValues(layers[name])
+	}
+	mu.RUnlock()
+	dump.Merged = Snapshot()
+
+	buf, err := json.Marshal(dump)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf)
+}
@@ -0,0 +1,143 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func resetRuntime() {
+	mu.Lock()
+	staticLayer = nil
+	layerOrder = nil
+	layers = nil
+	mu.Unlock()
+}
+
+func TestLayerPrecedence(t *testing.T) {
+	resetRuntime()
+	ApplyStaticLayer(map[string]string{
+		"feature.x": "false",
+		"retry.max": "3",
+	})
+	if v, ok := Get("feature.x"); !ok || v != "false" {
+		t.Errorf("static value, got %q %v", v, ok)
+	}
+
+	ApplyLayer("rtds_layer", map[string]string{
+		"feature.x": "true",
+	})
+	if v, _ := Get("feature.x"); v != "true" {
+		t.Errorf("dynamic layer should override static, got %q", v)
+	}
+	if v, _ := Get("retry.max"); v != "3" {
+		t.Errorf("untouched static key changed, got %q", v)
+	}
+
+	// a later layer overrides an earlier one
+	ApplyLayer("override_layer", map[string]string{
+		"feature.x": "false",
+	})
+	if v, _ := Get("feature.x"); v != "false" {
+		t.Errorf("later layer should win, got %q", v)
+	}
+
+	// re-applying a layer replaces it, feature.x falls through again
+	ApplyLayer("override_layer", map[string]string{})
+	if v, _ := Get("feature.x"); v != "true" {
+		t.Errorf("replaced layer should release the key, got %q", v)
+	}
+
+	if _, ok := Get("not.exists"); ok {
+		t.Errorf("missing key reported as found")
+	}
+}
+
+func TestTypedGetters(t *testing.T) {
+	resetRuntime()
+	ApplyStaticLayer(map[string]string{
+		"flag":     "true",
+		"count":    "42",
+		"float":    "42.0",
+		"interval": "3s",
+		"garbage":  "not-a-number",
+	})
+
+	if !GetBool("flag", false) {
+		t.Errorf("GetBool flag")
+	}
+	if GetBool("garbage", false) {
+		t.Errorf("GetBool should fall back on unparseable value")
+	}
+	if GetBool("not.exists", true) != true {
+		t.Errorf("GetBool default")
+	}
+	if GetInt("count", 0) != 42 {
+		t.Errorf("GetInt count")
+	}
+	if GetInt("float", 0) != 42 {
+		t.Errorf("GetInt should accept float values")
+	}
+	if GetInt("garbage", 7) != 7 {
+		t.Errorf("GetInt default")
+	}
+	if GetDuration("interval", 0) != 3*time.Second {
+		t.Errorf("GetDuration interval")
+	}
+	if GetDuration("garbage", time.Minute) != time.Minute {
+		t.Errorf("GetDuration default")
+	}
+}
+
+func TestSnapshotAndDump(t *testing.T) {
+	resetRuntime()
+	ApplyStaticLayer(map[string]string{
+		"a": "1",
+		"b": "2",
+	})
+	ApplyLayer("rtds_layer", map[string]string{
+		"b": "3",
+	})
+
+	merged := Snapshot()
+	if merged["a"] != "1" || merged["b"] != "3" {
+		t.Errorf("unexpected snapshot: %v", merged)
+	}
+
+	w := httptest.NewRecorder()
+	dumpRuntime(w, httptest.NewRequest("GET", "/api/v1/runtime", nil))
+	if w.Code != 200 {
+		t.Fatalf("dump status %d", w.Code)
+	}
+	var dump runtimeDump
+	if err := json.Unmarshal(w.Body.Bytes(), &dump); err != nil {
+		t.Fatalf("unmarshal dump: %v", err)
+	}
+	if dump.Merged["b"] != "3" {
+		t.Errorf("dump merged: %v", dump.Merged)
+	}
+	if dump.Layers[StaticLayerName]["b"] != "2" {
+		t.Errorf("dump static layer: %v", dump.Layers)
+	}
+	if dump.Layers["rtds_layer"]["b"] != "3" {
+		t.Errorf("dump rtds layer: %v", dump.Layers)
+	}
+}
@@ -0,0 +1,192 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package sandbox hardens a running mosn for security-sensitive
+// deployments. After listeners are bound and certificates are loaded the
+// process no longer needs most of its privileges, Apply drops them: linux
+// capabilities are removed from the bounding set, no_new_privs is set and
+// runtime file writes are refused outside an allowed path list. Apply
+// returns a report of what was done so operators can verify the result
+// at startup.
+package sandbox
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/sys/unix"
+	"sofastack.io/sofa-mosn/pkg/utils"
+)
+
+// Config selects what the sandbox keeps, everything else is dropped
+type Config struct {
+	// KeepCapabilities are capability names that survive the bounding set
+	// drop, e.g. "net_bind_service". Defaults to net_bind_service only,
+	// mosn needs it to bind privileged ports on listener updates
+	KeepCapabilities []string `json:"keep_capabilities,omitempty"`
+	// AllowedWritePaths are path prefixes runtime file writes may target,
+	// log and config dump paths are added automatically
+	AllowedWritePaths []string `json:"allowed_write_paths,omitempty"`
+}
+
+// capabilityNames maps the lowercase capability name (without the CAP_
+// prefix) to its number, covers all capabilities up to audit_read
+var capabilityNames = map[string]uintptr{
+	"chown": 0, "dac_override": 1, "dac_read_search": 2, "fowner": 3,
+	"fsetid": 4, "kill": 5, "setgid": 6, "setuid": 7, "setpcap": 8,
+	"linux_immutable": 9, "net_bind_service": 10, "net_broadcast": 11,
+	"net_admin": 12, "net_raw": 13, "ipc_lock": 14, "ipc_owner": 15,
+	"sys_module": 16, "sys_rawio": 17, "sys_chroot": 18, "sys_ptrace": 19,
+	"sys_pacct": 20, "sys_admin": 21, "sys_boot": 22, "sys_nice": 23,
+	"sys_resource": 24, "sys_time": 25, "sys_tty_config": 26, "mknod": 27,
+	"lease": 28, "audit_write": 29, "audit_control": 30, "setfcap": 31,
+	"mac_override": 32, "mac_admin": 33, "syslog": 34, "wake_alarm": 35,
+	"block_suspend": 36, "audit_read": 37,
+}
+
+// seccompHints are syscalls a seccomp profile for a sandboxed mosn should
+// deny, the sandbox itself cannot install one without cgo so they are
+// only reported
+var seccompHints = []string{
+	"ptrace", "mount", "umount2", "pivot_root", "chroot", "bpf",
+	"keyctl", "add_key", "kexec_load", "init_module", "delete_module",
+}
+
+// Report records what Apply did, failures are kept per item so a
+// partially privileged start (e.g. non-root) is visible but not fatal
+type Report struct {
+	NoNewPrivs          bool
+	DroppedCapabilities []string
+	KeptCapabilities    []string
+	FailedCapabilities  []string
+	AllowedWritePaths   []string
+	PathWarnings        []string
+	SeccompHints        []string
+}
+
+// String formats the report for the startup log
+func (r *Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "no_new_privs=%v", r.NoNewPrivs)
+	fmt.Fprintf(&b, ", dropped=[%s]", strings.Join(r.DroppedCapabilities, ","))
+	fmt.Fprintf(&b, ", kept=[%s]", strings.Join(r.KeptCapabilities, ","))
+	if len(r.FailedCapabilities) > 0 {
+		fmt.Fprintf(&b, ", failed=[%s]", strings.Join(r.FailedCapabilities, ","))
+	}
+	fmt.Fprintf(&b, ", writable=[%s]", strings.Join(r.AllowedWritePaths, ","))
+	if len(r.PathWarnings) > 0 {
+		fmt.Fprintf(&b, ", path warnings=[%s]", strings.Join(r.PathWarnings, ";"))
+	}
+	fmt.Fprintf(&b, ", seccomp deny hints=[%s]", strings.Join(r.SeccompHints, ","))
+	return b.String()
+}
+
+var allowedWritePrefixes []string
+
+// WriteAllowed reports whether the sandbox permits a runtime write to
+// path, it is always true before Apply enabled the sandbox
+func WriteAllowed(path string) bool {
+	if allowedWritePrefixes == nil {
+		return true
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	for _, prefix := range allowedWritePrefixes {
+		if abs == prefix || strings.HasPrefix(abs, prefix+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply enables the sandbox, it validates the configuration first and
+// only mutates process state when it is sound
+func Apply(config Config) (*Report, error) {
+	keep := config.KeepCapabilities
+	if len(keep) == 0 {
+		keep = []string{"net_bind_service"}
+	}
+	keepSet := make(map[uintptr]string, len(keep))
+	for _, name := range keep {
+		num, ok := capabilityNames[strings.ToLower(strings.TrimPrefix(strings.ToLower(name), "cap_"))]
+		if !ok {
+			return nil, fmt.Errorf("unknown capability %s", name)
+		}
+		keepSet[num] = name
+	}
+	prefixes := make([]string, 0, len(config.AllowedWritePaths))
+	for _, path := range config.AllowedWritePaths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed write path %s: %v", path, err)
+		}
+		prefixes = append(prefixes, filepath.Clean(abs))
+	}
+
+	report := &Report{
+		AllowedWritePaths: prefixes,
+		SeccompHints:      seccompHints,
+	}
+
+	// verify the allowed paths are actually writable so a misconfigured
+	// log path shows up in the startup report, not on the first rotation
+	for _, prefix := range prefixes {
+		dir := prefix
+		if unix.Access(dir, unix.W_OK) != nil {
+			dir = filepath.Dir(dir)
+		}
+		if err := unix.Access(dir, unix.W_OK); err != nil {
+			report.PathWarnings = append(report.PathWarnings, fmt.Sprintf("%s not writable: %v", prefix, err))
+		}
+	}
+
+	// drop everything outside the keep set from the bounding set, a
+	// failure (e.g. already unprivileged) is recorded, not fatal
+	for name, num := range capabilityNames {
+		if kept, ok := keepSet[num]; ok {
+			report.KeptCapabilities = append(report.KeptCapabilities, kept)
+			continue
+		}
+		if err := unix.Prctl(unix.PR_CAPBSET_DROP, num, 0, 0, 0); err != nil {
+			report.FailedCapabilities = append(report.FailedCapabilities, name)
+		} else {
+			report.DroppedCapabilities = append(report.DroppedCapabilities, name)
+		}
+	}
+
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err == nil {
+		report.NoNewPrivs = true
+	}
+	// the map iteration above is unordered, sort for a stable report
+	sort.Strings(report.DroppedCapabilities)
+	sort.Strings(report.KeptCapabilities)
+	sort.Strings(report.FailedCapabilities)
+
+	allowedWritePrefixes = prefixes
+	utils.SetWriteGuard(func(filename string) error {
+		if WriteAllowed(filename) {
+			return nil
+		}
+		return fmt.Errorf("sandbox refused write to %s", filename)
+	})
+
+	return report, nil
+}
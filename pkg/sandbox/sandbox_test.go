@@ -0,0 +1,91 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sandbox
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sofastack.io/sofa-mosn/pkg/utils"
+)
+
+func TestWriteAllowed(t *testing.T) {
+	defer func() {
+		allowedWritePrefixes = nil
+	}()
+	allowedWritePrefixes = nil
+	if !WriteAllowed("/anywhere/at/all") {
+		t.Error("everything should be allowed before the sandbox is enabled")
+	}
+	allowedWritePrefixes = []string{"/var/log/mosn", "/etc/mosn/mosn.json"}
+	testcases := []struct {
+		path    string
+		allowed bool
+	}{
+		{"/var/log/mosn/default.log", true},
+		{"/var/log/mosn", true},
+		{"/etc/mosn/mosn.json", true},
+		{"/var/log/mosnevil", false},
+		{"/etc/mosn/other.json", false},
+		{"/tmp/escape", false},
+	}
+	for i, tc := range testcases {
+		if got := WriteAllowed(tc.path); got != tc.allowed {
+			t.Errorf("#%d WriteAllowed(%s) = %v, expected %v", i, tc.path, got, tc.allowed)
+		}
+	}
+}
+
+func TestApplyRejectsUnknownCapability(t *testing.T) {
+	if _, err := Apply(Config{KeepCapabilities: []string{"fly"}}); err == nil {
+		t.Error("unknown capability should be rejected")
+	}
+}
+
+func TestApplyGuardsRuntimeWrites(t *testing.T) {
+	defer func() {
+		allowedWritePrefixes = nil
+		utils.SetWriteGuard(nil)
+	}()
+	dir, err := ioutil.TempDir("", "sandbox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	report, err := Apply(Config{AllowedWritePaths: []string{dir}})
+	if err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+	if len(report.KeptCapabilities) == 0 {
+		t.Error("the default keep set should not be empty")
+	}
+	if !strings.Contains(report.String(), "writable=["+dir+"]") {
+		t.Errorf("report does not mention the allowed path: %s", report.String())
+	}
+	if err := utils.WriteFileSafety(filepath.Join(dir, "ok"), []byte("x"), 0644); err != nil {
+		t.Errorf("write inside the allowed path refused: %v", err)
+	}
+	if err := utils.WriteFileSafety(filepath.Join(os.TempDir(), "sandbox_escape"), []byte("x"), 0644); err == nil {
+		t.Error("write outside the allowed paths should be refused")
+		os.Remove(filepath.Join(os.TempDir(), "sandbox_escape"))
+	}
+}
@@ -34,26 +34,40 @@ type ListenerAdapter struct {
 	defaultName        string
 }
 
-// todo consider to use singleton
-func initListenerAdapterInstance(name string, connHandler types.ConnectionHandler) {
-	if listenerAdapterInstance == nil {
-		listenerAdapterInstance = &ListenerAdapter{
-			connHandlerMap: make(map[string]types.ConnectionHandler),
-			// we set the first handler as the default handler
-			// the handler name should be keeped, so if the handler changed, the default handler changed too.
-			defaultName: name,
-		}
+// NewListenerAdapter returns a standalone adapter with its own conn
+// handler registry, the process-wide instance is not touched. Tests and
+// embedded instances use it to keep their listeners isolated
+func NewListenerAdapter(name string, connHandler types.ConnectionHandler) *ListenerAdapter {
+	adapter := &ListenerAdapter{
+		connHandlerMap: make(map[string]types.ConnectionHandler),
+		// we set the first handler as the default handler
+		// the handler name should be keeped, so if the handler changed, the default handler changed too.
+		defaultName: name,
 	}
+	adapter.RegisterConnHandler(name, connHandler)
+	return adapter
+}
 
+// RegisterConnHandler adds a server's connection handler to the adapter
+func (adapter *ListenerAdapter) RegisterConnHandler(name string, connHandler types.ConnectionHandler) {
 	// if the handler's name is same as default, the default handler changed too
-	if name == listenerAdapterInstance.defaultName {
-		listenerAdapterInstance.defaultConnHandler = connHandler
+	if name == adapter.defaultName {
+		adapter.defaultConnHandler = connHandler
 	}
 
-	listenerAdapterInstance.connHandlerMap[name] = connHandler
+	adapter.connHandlerMap[name] = connHandler
 	log.DefaultLogger.Debugf("[server] [init] add server conn handler, server name = %s", name)
 }
 
+// todo consider to use singleton
+func initListenerAdapterInstance(name string, connHandler types.ConnectionHandler) {
+	if listenerAdapterInstance == nil {
+		listenerAdapterInstance = NewListenerAdapter(name, connHandler)
+		return
+	}
+	listenerAdapterInstance.RegisterConnHandler(name, connHandler)
+}
+
 // FindListenerByName
 func (adapter *ListenerAdapter) FindListenerByName(serverName string, listenerName string) types.Listener {
 	var connHandler types.ConnectionHandler
@@ -101,6 +115,11 @@ func (adapter *ListenerAdapter) AddOrUpdateListener(serverName string, lc *v2.Li
 		return fmt.Errorf("AddOrUpdateListener called error, connHandler is nil")
 	}
 
+	// dynamic sources must stay inside the configured bind policy
+	if err := checkListenerBind(lc); err != nil {
+		return fmt.Errorf("AddOrUpdateListener refused: %s", err.Error())
+	}
+
 	listener, err := connHandler.AddOrUpdateListener(lc, networkFiltersFactories, streamFiltersFactories)
 
 	if err != nil {
@@ -126,25 +145,33 @@ func (adapter *ListenerAdapter) AddOrUpdateListener(serverName string, lc *v2.Li
 }
 
 func (adapter *ListenerAdapter) DeleteListener(serverName string, listenerName string) error {
-	var connHandler types.ConnectionHandler
+	var handler types.ConnectionHandler
 
 	if serverName == "" {
-		connHandler = adapter.defaultConnHandler
+		handler = adapter.defaultConnHandler
 	} else {
 		if ch, ok := adapter.connHandlerMap[serverName]; ok {
-			connHandler = ch
+			handler = ch
 		} else {
 			return fmt.Errorf("AddOrUpdateListener error, servername = %s not found", serverName)
 		}
 	}
 
+	// deferred mode lets established connections bleed off before the
+	// force close, configured with the server's listener_bleed_off_time
+	if ListenerBleedOffTime > 0 {
+		if ch, ok := handler.(*connHandler); ok {
+			return ch.DeferredDeleteListener(nil, listenerName, ListenerBleedOffTime)
+		}
+	}
+
 	// stop listener first
-	if err := connHandler.StopListener(nil, listenerName, true); err != nil {
+	if err := handler.StopListener(nil, listenerName, true); err != nil {
 		return err
 	}
 
 	// then remove it from array
-	connHandler.RemoveListeners(listenerName)
+	handler.RemoveListeners(listenerName)
 	return nil
 }
 
@@ -228,3 +228,25 @@ func TestUpdateTLS(t *testing.T) {
 		t.Fatal("listener should not be support tls any more")
 	}
 }
+
+func TestNewListenerAdapterIsolated(t *testing.T) {
+	handler := NewHandler(&mockClusterManagerFilter{}, &mockClusterManager{})
+	adapter := NewListenerAdapter("isolated_server", handler)
+	if adapter == listenerAdapterInstance {
+		t.Fatal("standalone adapter must not be the process-wide instance")
+	}
+	config := baseListenerConfig("127.0.0.1:8201", "isolated_listener")
+	nilListenerFilters := []types.NetworkFilterChainFactory{}
+	if err := adapter.AddOrUpdateListener("isolated_server", config, nilListenerFilters, nil); err != nil {
+		t.Fatalf("add listener failed: %v", err)
+	}
+	time.Sleep(time.Second) // wait listener start
+	defer adapter.DeleteListener("isolated_server", "isolated_listener")
+	if adapter.FindListenerByName("isolated_server", "isolated_listener") == nil {
+		t.Error("listener should exist in the standalone adapter")
+	}
+	// the process-wide adapter never saw the listener
+	if GetListenerAdapterInstance().FindListenerByName(testServerName, "isolated_listener") != nil {
+		t.Error("process-wide adapter should not see the listener")
+	}
+}
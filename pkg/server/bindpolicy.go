@@ -0,0 +1,152 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+	"sofastack.io/sofa-mosn/pkg/network"
+)
+
+// BindPolicyRule is one ordered rule of the listener bind policy. Action
+// is allow or deny, an empty Cidr or Ports matches every address or port.
+// The policy is checked when a dynamic source (xds, admin) adds a
+// listener, so a compromised control plane cannot open arbitrary ports
+// on the host. Static listeners from the config file are not restricted
+type BindPolicyRule struct {
+	Action string `json:"action"`
+	Cidr   string `json:"cidr,omitempty"`
+	Ports  string `json:"ports,omitempty"`
+}
+
+type portRange struct {
+	min int
+	max int
+}
+
+type bindPolicyRule struct {
+	allow bool
+	cidr  *net.IPNet
+	ports []portRange
+}
+
+func (rule *bindPolicyRule) matches(addr *net.TCPAddr) bool {
+	if rule.cidr != nil && !rule.cidr.Contains(addr.IP) {
+		return false
+	}
+	if len(rule.ports) == 0 {
+		return true
+	}
+	for _, r := range rule.ports {
+		if addr.Port >= r.min && addr.Port <= r.max {
+			return true
+		}
+	}
+	return false
+}
+
+// listenerBindPolicy is installed once at startup before any dynamic
+// source runs, nil means everything is allowed
+var listenerBindPolicy []bindPolicyRule
+
+// SetListenerBindPolicy parses and installs the bind policy rules, rules
+// are evaluated in order and the first matching rule wins
+func SetListenerBindPolicy(rules []BindPolicyRule) error {
+	parsed := make([]bindPolicyRule, 0, len(rules))
+	for idx, rule := range rules {
+		var out bindPolicyRule
+		switch rule.Action {
+		case "allow":
+			out.allow = true
+		case "deny":
+		default:
+			return fmt.Errorf("bind policy rule[%d]: action must be allow or deny, got %q", idx, rule.Action)
+		}
+		if rule.Cidr != "" {
+			_, cidr, err := net.ParseCIDR(rule.Cidr)
+			if err != nil {
+				return fmt.Errorf("bind policy rule[%d]: invalid cidr %s: %v", idx, rule.Cidr, err)
+			}
+			out.cidr = cidr
+		}
+		if rule.Ports != "" {
+			ports, err := parsePortRanges(rule.Ports)
+			if err != nil {
+				return fmt.Errorf("bind policy rule[%d]: %v", idx, err)
+			}
+			out.ports = ports
+		}
+		parsed = append(parsed, out)
+	}
+	listenerBindPolicy = parsed
+	return nil
+}
+
+// parsePortRanges parses a comma separated list of ports and ranges,
+// e.g. "80,443,8000-9000"
+func parsePortRanges(ports string) ([]portRange, error) {
+	var out []portRange
+	for _, item := range strings.Split(ports, ",") {
+		var r portRange
+		var err error
+		if strings.Contains(item, "-") {
+			pieces := strings.SplitN(item, "-", 2)
+			r.min, err = strconv.Atoi(pieces[0])
+			if err == nil {
+				r.max, err = strconv.Atoi(pieces[1])
+			}
+		} else {
+			r.min, err = strconv.Atoi(item)
+			r.max = r.min
+		}
+		if err != nil || r.min < 0 || r.max > 65535 || r.min > r.max {
+			return nil, fmt.Errorf("invalid port range %q", item)
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+// checkListenerBind reports whether the bind policy allows the
+// listener's address, no matching rule means allow
+func checkListenerBind(lc *v2.Listener) error {
+	if len(listenerBindPolicy) == 0 {
+		return nil
+	}
+	addr, ok := lc.Addr.(*net.TCPAddr)
+	if !ok {
+		var err error
+		addr, err = network.ParseTCPAddress(lc.AddrConfig)
+		if err != nil {
+			return fmt.Errorf("cannot check bind policy for address %q: %v", lc.AddrConfig, err)
+		}
+	}
+	for _, rule := range listenerBindPolicy {
+		if rule.matches(addr) {
+			if rule.allow {
+				return nil
+			}
+			return fmt.Errorf("address %s is denied by the listener bind policy", addr.String())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"testing"
+
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+)
+
+func resetBindPolicy() {
+	listenerBindPolicy = nil
+}
+
+func listenerWithAddress(address string) *v2.Listener {
+	return &v2.Listener{
+		ListenerConfig: v2.ListenerConfig{
+			AddrConfig: address,
+		},
+	}
+}
+
+func TestCheckListenerBind(t *testing.T) {
+	defer resetBindPolicy()
+	err := SetListenerBindPolicy([]BindPolicyRule{
+		{Action: "deny", Ports: "1-1023"},
+		{Action: "deny", Cidr: "127.0.0.0/8"},
+		{Action: "allow", Ports: "8000-9000"},
+		{Action: "deny"},
+	})
+	if err != nil {
+		t.Fatalf("set policy failed: %v", err)
+	}
+
+	allowed := []string{"0.0.0.0:8080", "10.0.0.1:9000"}
+	for _, address := range allowed {
+		if err := checkListenerBind(listenerWithAddress(address)); err != nil {
+			t.Errorf("%s: expected allow, got %v", address, err)
+		}
+	}
+	denied := []string{"0.0.0.0:80", "127.0.0.1:8080", "0.0.0.0:10000"}
+	for _, address := range denied {
+		if err := checkListenerBind(listenerWithAddress(address)); err == nil {
+			t.Errorf("%s: expected deny", address)
+		}
+	}
+}
+
+func TestCheckListenerBindDefaults(t *testing.T) {
+	defer resetBindPolicy()
+	// no policy configured, everything is allowed
+	resetBindPolicy()
+	if err := checkListenerBind(listenerWithAddress("0.0.0.0:80")); err != nil {
+		t.Errorf("expected allow without policy, got %v", err)
+	}
+	// no matching rule falls through to allow
+	if err := SetListenerBindPolicy([]BindPolicyRule{{Action: "deny", Ports: "1-1023"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkListenerBind(listenerWithAddress("0.0.0.0:8080")); err != nil {
+		t.Errorf("expected fall-through allow, got %v", err)
+	}
+}
+
+func TestSetListenerBindPolicyErrors(t *testing.T) {
+	defer resetBindPolicy()
+	invalid := [][]BindPolicyRule{
+		{{Action: "block"}},
+		{{Action: "deny", Cidr: "not-a-cidr"}},
+		{{Action: "deny", Ports: "90000"}},
+		{{Action: "deny", Ports: "100-1"}},
+	}
+	for _, rules := range invalid {
+		if err := SetListenerBindPolicy(rules); err == nil {
+			t.Errorf("%+v: invalid policy accepted", rules)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"net"
+	"strings"
+
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+)
+
+// specificity weights, a more specific criterion dominates any combination
+// of less specific ones, mirroring envoy's filter chain match ordering
+const (
+	scoreExactServerName    = 32
+	scoreWildcardServerName = 16
+	scoreDestinationPort    = 8
+	scoreSourcePrefix       = 4
+	scoreTransportProto     = 2
+	scoreApplicationProto   = 1
+)
+
+// transport protocol values filter chains match on, envoy's naming
+const (
+	TransportProtocolTLS       = "tls"
+	TransportProtocolRawBuffer = "raw_buffer"
+)
+
+// ChainMatchInfo carries the per-connection attributes a filter chain
+// can be selected on. Zero values mean the attribute is unknown, e.g.
+// ServerName stays empty on plain tcp connections
+type ChainMatchInfo struct {
+	ServerName          string
+	ApplicationProtocol string
+	TransportProtocol   string
+	Source              net.Addr
+	DestinationPort     int
+}
+
+// SelectFilterChain returns the index of the filter chain that matches info
+// best, or -1 when no chain matches. A chain without match criteria is a
+// catch-all. Every criterion a chain specifies must match; among matching
+// chains the most specific one wins, ties keep the first configured chain
+func SelectFilterChain(chains []v2.FilterChain, info *ChainMatchInfo) int {
+	best := -1
+	bestScore := -1
+	for i := range chains {
+		score, ok := matchChain(chains[i].Matches, info)
+		if ok && score > bestScore {
+			best = i
+			bestScore = score
+		}
+	}
+	return best
+}
+
+func matchChain(criteria *v2.FilterChainMatchCriteria, info *ChainMatchInfo) (score int, ok bool) {
+	if criteria == nil {
+		return 0, true
+	}
+	if len(criteria.ServerNames) > 0 {
+		s := matchServerName(criteria.ServerNames, info.ServerName)
+		if s == 0 {
+			return 0, false
+		}
+		score += s
+	}
+	if criteria.DestinationPort > 0 {
+		if int(criteria.DestinationPort) != info.DestinationPort {
+			return 0, false
+		}
+		score += scoreDestinationPort
+	}
+	if len(criteria.SourcePrefixRanges) > 0 {
+		if !matchSourcePrefix(criteria.SourcePrefixRanges, info.Source) {
+			return 0, false
+		}
+		score += scoreSourcePrefix
+	}
+	if len(criteria.TransportProtocols) > 0 {
+		if !matchTransportProtocol(criteria.TransportProtocols, info.TransportProtocol) {
+			return 0, false
+		}
+		score += scoreTransportProto
+	}
+	if len(criteria.ApplicationProtocols) > 0 {
+		if !matchApplicationProtocol(criteria.ApplicationProtocols, info.ApplicationProtocol) {
+			return 0, false
+		}
+		score += scoreApplicationProto
+	}
+	return score, true
+}
+
+func matchTransportProtocol(protocols []string, transport string) bool {
+	if transport == "" {
+		return false
+	}
+	for _, p := range protocols {
+		if strings.EqualFold(p, transport) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchServerName returns the server name specificity score, 0 when none
+// of the configured names covers the requested sni
+func matchServerName(names []string, serverName string) int {
+	if serverName == "" {
+		return 0
+	}
+	score := 0
+	for _, name := range names {
+		if strings.EqualFold(name, serverName) {
+			return scoreExactServerName
+		}
+		// "*.example.com" covers "www.example.com" but not "example.com"
+		// and not "a.b.example.com"
+		if strings.HasPrefix(name, "*.") {
+			suffix := name[1:] // ".example.com"
+			if len(serverName) > len(suffix) &&
+				strings.EqualFold(serverName[len(serverName)-len(suffix):], suffix) &&
+				!strings.Contains(serverName[:len(serverName)-len(suffix)], ".") {
+				score = scoreWildcardServerName
+			}
+		}
+	}
+	return score
+}
+
+func matchSourcePrefix(ranges []string, source net.Addr) bool {
+	if source == nil {
+		return false
+	}
+	host, _, err := net.SplitHostPort(source.String())
+	if err != nil {
+		host = source.String()
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, r := range ranges {
+		_, cidr, err := net.ParseCIDR(r)
+		if err != nil {
+			continue
+		}
+		if cidr.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchApplicationProtocol(protocols []string, alpn string) bool {
+	if alpn == "" {
+		return false
+	}
+	for _, p := range protocols {
+		if strings.EqualFold(p, alpn) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"net"
+	"testing"
+
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+)
+
+func chainWithMatch(m *v2.FilterChainMatchCriteria) v2.FilterChain {
+	return v2.FilterChain{
+		FilterChainConfig: v2.FilterChainConfig{Matches: m},
+	}
+}
+
+func TestSelectFilterChainServerName(t *testing.T) {
+	chains := []v2.FilterChain{
+		chainWithMatch(&v2.FilterChainMatchCriteria{ServerNames: []string{"*.example.com"}}),
+		chainWithMatch(&v2.FilterChainMatchCriteria{ServerNames: []string{"www.example.com"}}),
+		chainWithMatch(nil), // catch-all
+	}
+
+	// exact name beats the wildcard even though the wildcard chain comes first
+	if idx := SelectFilterChain(chains, &ChainMatchInfo{ServerName: "www.example.com"}); idx != 1 {
+		t.Errorf("exact server name should win, got chain %d", idx)
+	}
+	// wildcard covers one extra label only
+	if idx := SelectFilterChain(chains, &ChainMatchInfo{ServerName: "api.example.com"}); idx != 0 {
+		t.Errorf("wildcard server name should match, got chain %d", idx)
+	}
+	if idx := SelectFilterChain(chains, &ChainMatchInfo{ServerName: "a.b.example.com"}); idx != 2 {
+		t.Errorf("wildcard should not cover nested subdomains, got chain %d", idx)
+	}
+	// plain tcp connection has no sni, falls back to the catch-all
+	if idx := SelectFilterChain(chains, &ChainMatchInfo{}); idx != 2 {
+		t.Errorf("connection without sni should hit the catch-all, got chain %d", idx)
+	}
+}
+
+func TestSelectFilterChainSourceAndPort(t *testing.T) {
+	chains := []v2.FilterChain{
+		chainWithMatch(&v2.FilterChainMatchCriteria{SourcePrefixRanges: []string{"10.0.0.0/8"}}),
+		chainWithMatch(&v2.FilterChainMatchCriteria{DestinationPort: 443}),
+	}
+	source := &net.TCPAddr{IP: net.ParseIP("10.1.2.3"), Port: 54321}
+
+	// destination port is more specific than a source range
+	idx := SelectFilterChain(chains, &ChainMatchInfo{Source: source, DestinationPort: 443})
+	if idx != 1 {
+		t.Errorf("destination port should outrank source range, got chain %d", idx)
+	}
+	idx = SelectFilterChain(chains, &ChainMatchInfo{Source: source, DestinationPort: 80})
+	if idx != 0 {
+		t.Errorf("source range should match, got chain %d", idx)
+	}
+	// no chain matches and there is no catch-all
+	outside := &net.TCPAddr{IP: net.ParseIP("192.168.1.1"), Port: 1}
+	if idx := SelectFilterChain(chains, &ChainMatchInfo{Source: outside, DestinationPort: 80}); idx != -1 {
+		t.Errorf("unmatched connection should return -1, got chain %d", idx)
+	}
+}
+
+func TestSelectFilterChainTransportProtocol(t *testing.T) {
+	chains := []v2.FilterChain{
+		chainWithMatch(&v2.FilterChainMatchCriteria{TransportProtocols: []string{TransportProtocolTLS}}),
+		chainWithMatch(&v2.FilterChainMatchCriteria{TransportProtocols: []string{TransportProtocolRawBuffer}}),
+	}
+
+	// permissive mode: tls and plaintext connections on the same port
+	// land on different chains
+	if idx := SelectFilterChain(chains, &ChainMatchInfo{TransportProtocol: TransportProtocolTLS}); idx != 0 {
+		t.Errorf("tls connection should hit the tls chain, got chain %d", idx)
+	}
+	if idx := SelectFilterChain(chains, &ChainMatchInfo{TransportProtocol: TransportProtocolRawBuffer}); idx != 1 {
+		t.Errorf("plaintext connection should hit the raw_buffer chain, got chain %d", idx)
+	}
+	if idx := SelectFilterChain(chains, &ChainMatchInfo{}); idx != -1 {
+		t.Errorf("unknown transport should match no chain, got chain %d", idx)
+	}
+}
+
+func TestSelectFilterChainAllCriteria(t *testing.T) {
+	full := &v2.FilterChainMatchCriteria{
+		ServerNames:          []string{"svc.example.com"},
+		ApplicationProtocols: []string{"h2"},
+		SourcePrefixRanges:   []string{"10.0.0.0/8"},
+		DestinationPort:      443,
+	}
+	chains := []v2.FilterChain{
+		chainWithMatch(full),
+		chainWithMatch(nil),
+	}
+	info := &ChainMatchInfo{
+		ServerName:          "svc.example.com",
+		ApplicationProtocol: "h2",
+		Source:              &net.TCPAddr{IP: net.ParseIP("10.9.9.9"), Port: 2048},
+		DestinationPort:     443,
+	}
+	if idx := SelectFilterChain(chains, info); idx != 0 {
+		t.Errorf("fully matching chain should win, got chain %d", idx)
+	}
+	// one failing criterion rejects the chain entirely
+	info.ApplicationProtocol = "http/1.1"
+	if idx := SelectFilterChain(chains, info); idx != 1 {
+		t.Errorf("partially matching chain must be rejected, got chain %d", idx)
+	}
+}
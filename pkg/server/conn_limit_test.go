@@ -0,0 +1,60 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"testing"
+)
+
+func TestAcceptConnectionLimits(t *testing.T) {
+	oldLimit := GlobalConnectionLimit
+	defer func() {
+		GlobalConnectionLimit = oldLimit
+	}()
+	GlobalConnectionLimit = 0
+
+	ch := &connHandler{}
+	al := &activeListener{handler: ch}
+
+	// no limits configured
+	al.numConnections = 100
+	ch.numConnections = 100
+	if !al.acceptConnection() {
+		t.Error("unlimited listener should accept")
+	}
+
+	// per listener limit
+	al.maxConnections = 100
+	if al.acceptConnection() {
+		t.Error("listener at max connections should reject")
+	}
+	al.numConnections = 99
+	if !al.acceptConnection() {
+		t.Error("listener below max connections should accept")
+	}
+
+	// global budget
+	GlobalConnectionLimit = 100
+	if al.acceptConnection() {
+		t.Error("handler at global limit should reject")
+	}
+	ch.numConnections = 99
+	if !al.acceptConnection() {
+		t.Error("handler below global limit should accept")
+	}
+}
@@ -34,6 +34,7 @@ import (
 	"golang.org/x/sys/unix"
 	admin "sofastack.io/sofa-mosn/pkg/admin/store"
 	v2 "sofastack.io/sofa-mosn/pkg/api/v2"
+	"sofastack.io/sofa-mosn/pkg/config"
 	mosnctx "sofastack.io/sofa-mosn/pkg/context"
 	"sofastack.io/sofa-mosn/pkg/filter/accept/originaldst"
 	"sofastack.io/sofa-mosn/pkg/log"
@@ -115,9 +116,9 @@ func (ch *connHandler) AddOrUpdateListener(lc *v2.Listener, networkFiltersFactor
 			al.listener.Addr().Network() != lc.Addr.Network() {
 			return nil, errors.New("error updating listener, listen address and listen name doesn't match")
 		}
-		// currently, we just support one filter chain
-		if len(lc.FilterChains) != 1 {
-			return nil, errors.New("error updating listener, listener have filter chains count is not 1")
+		// multiple filter chains are selected per connection, see SelectFilterChain
+		if len(lc.FilterChains) == 0 {
+			return nil, errors.New("error updating listener, listener has no filter chain")
 		}
 		rawConfig := al.listener.Config()
 		// FIXME: update log level need the pkg/logger support.
@@ -126,10 +127,10 @@ func (ch *connHandler) AddOrUpdateListener(lc *v2.Listener, networkFiltersFactor
 		if networkFiltersFactories != nil {
 			log.DefaultLogger.Infof("[server] [AddOrUpdateListener] [update] update network filters")
 			al.networkFiltersFactories = networkFiltersFactories
-			rawConfig.FilterChains[0].FilterChainMatch = lc.FilterChains[0].FilterChainMatch
-			rawConfig.FilterChains[0].Filters = lc.FilterChains[0].Filters
+			rawConfig.FilterChains = lc.FilterChains
+			al.buildChainFactories(lc.FilterChains)
 		}
-		if streamFiltersFactories != nil {
+		if streamFiltersFactories != nil && !rawConfig.Local {
 			log.DefaultLogger.Infof("[server] [AddOrUpdateListener] [update] update stream filters")
 			al.streamFiltersFactoriesStore.Store(streamFiltersFactories)
 			rawConfig.StreamFilters = lc.StreamFilters
@@ -137,17 +138,25 @@ func (ch *connHandler) AddOrUpdateListener(lc *v2.Listener, networkFiltersFactor
 
 		// tls update only take effects on new connections
 		// config changed
-		rawConfig.FilterChains[0].TLSContexts = lc.FilterChains[0].TLSContexts
-		rawConfig.FilterChains[0].TLSConfig = lc.FilterChains[0].TLSConfig
-		rawConfig.FilterChains[0].TLSConfigs = lc.FilterChains[0].TLSConfigs
-		rawConfig.Inspector = lc.Inspector
-		mgr, err := mtls.NewTLSServerContextManager(rawConfig, al.listener, log.DefaultLogger)
-		if err != nil {
-			log.DefaultLogger.Errorf("[server] [conn handler] [update listener] create tls context manager failed, %v", err)
-			return nil, err
+		if !rawConfig.Local {
+			for i := range rawConfig.FilterChains {
+				if i >= len(lc.FilterChains) {
+					break
+				}
+				rawConfig.FilterChains[i].TLSContexts = lc.FilterChains[i].TLSContexts
+				rawConfig.FilterChains[i].TLSConfig = lc.FilterChains[i].TLSConfig
+				rawConfig.FilterChains[i].TLSConfigs = lc.FilterChains[i].TLSConfigs
+			}
+			rawConfig.Inspector = lc.Inspector
+			mgr, err := mtls.NewTLSServerContextManager(rawConfig, al.listener, log.DefaultLogger)
+			if err != nil {
+				log.DefaultLogger.Errorf("[server] [conn handler] [update listener] create tls context manager failed, %v", err)
+				return nil, err
+			}
+			// object changed
+			al.tlsMng = mgr
+			al.watchTLSCertificates(rawConfig)
 		}
-		// object changed
-		al.tlsMng = mgr
 		// some simle config update
 		rawConfig.PerConnBufferLimitBytes = lc.PerConnBufferLimitBytes
 		al.listener.SetPerConnBufferLimitBytes(lc.PerConnBufferLimitBytes)
@@ -155,6 +164,8 @@ func (ch *connHandler) AddOrUpdateListener(lc *v2.Listener, networkFiltersFactor
 		al.listener.SetListenerTag(lc.ListenerTag)
 		rawConfig.HandOffRestoredDestinationConnections = lc.HandOffRestoredDestinationConnections
 		al.listener.SetHandOffRestoredDestinationConnections(lc.HandOffRestoredDestinationConnections)
+		rawConfig.MaxConnections = lc.MaxConnections
+		atomic.StoreInt64(&al.maxConnections, int64(lc.MaxConnections))
 
 		al.listener.SetConfig(rawConfig)
 
@@ -259,6 +270,48 @@ func (ch *connHandler) StopListener(lctx context.Context, name string, close boo
 	return nil
 }
 
+// bleedOffCheckInterval is how often a pending-delete listener's
+// remaining connections are counted
+const bleedOffCheckInterval = time.Second
+
+// DeferredDeleteListener stops the accept loop immediately but keeps
+// established connections alive for the bleed-off period, whatever
+// remains at the deadline is force closed. The remaining connection
+// count is exported as a gauge while the delete is pending
+func (ch *connHandler) DeferredDeleteListener(lctx context.Context, name string, bleedOffTime time.Duration) error {
+	al := ch.findActiveListenerByName(name)
+	if al == nil {
+		return nil
+	}
+	// no new connections from now on
+	if err := al.listener.Stop(); err != nil {
+		return err
+	}
+	// the listener is logically gone, the bleed off only drains it
+	ch.RemoveListeners(name)
+
+	gauge := metrics.NewListenerStats(name).Gauge(metrics.DownstreamConnectionPendingDelete)
+	utils.GoWithRecover(func() {
+		deadline := time.Now().Add(bleedOffTime)
+		for {
+			remaining := atomic.LoadInt64(&al.numConnections)
+			gauge.Update(remaining)
+			if remaining == 0 || !time.Now().Before(deadline) {
+				break
+			}
+			time.Sleep(bleedOffCheckInterval)
+		}
+		if remaining := atomic.LoadInt64(&al.numConnections); remaining > 0 {
+			log.DefaultLogger.Infof("[server] [conn handler] bleed off deadline for listener %s, force closing %d connections", name, remaining)
+		}
+		// closing the stop chan tears the remaining connections down
+		close(al.stopChan)
+		al.listener.Close(lctx)
+		gauge.Update(0)
+	}, nil)
+	return nil
+}
+
 func (ch *connHandler) StopListeners(lctx context.Context, close bool) error {
 	var errGlobal error
 	for _, l := range ch.listeners {
@@ -325,9 +378,13 @@ func (ch *connHandler) StopConnection() {
 // ListenerEventListener
 type activeListener struct {
 	disableConnIo               bool
+	maxConnections              int64
+	numConnections              int64
 	listener                    types.Listener
 	networkFiltersFactories     []types.NetworkFilterChainFactory
-	streamFiltersFactoriesStore atomic.Value // store []types.StreamFilterChainFactory
+	filterChains                []v2.FilterChain                    // set when the listener has multiple filter chains
+	chainFactories              [][]types.NetworkFilterChainFactory // per filter chain factories, parallel to filterChains
+	streamFiltersFactoriesStore atomic.Value                        // store []types.StreamFilterChainFactory
 	listenIP                    string
 	listenPort                  int
 	conns                       *list.List
@@ -338,23 +395,38 @@ type activeListener struct {
 	accessLogs                  []types.AccessLog
 	updatedLabel                bool
 	tlsMng                      types.TLSContextManager
+	lastSlowHandshakeLog        int64 // unix nanos of the last sampled slow handshake log
 }
 
+// slow handshake diagnostics, a handshake slower than the threshold is
+// logged at most once per interval per listener
+const (
+	slowHandshakeThreshold   = time.Second
+	slowHandshakeLogInterval = time.Second
+)
+
 func newActiveListener(listener types.Listener, lc *v2.Listener, accessLoggers []types.AccessLog,
 	networkFiltersFactories []types.NetworkFilterChainFactory, streamFiltersFactories []types.StreamFilterChainFactory,
 	handler *connHandler, stopChan chan struct{}) (*activeListener, error) {
 	al := &activeListener{
 		disableConnIo:           lc.DisableConnIo,
+		maxConnections:          int64(lc.MaxConnections),
 		listener:                listener,
 		networkFiltersFactories: networkFiltersFactories,
-		conns:        list.New(),
-		handler:      handler,
-		stopChan:     stopChan,
-		accessLogs:   accessLoggers,
-		updatedLabel: false,
+		conns:                   list.New(),
+		handler:                 handler,
+		stopChan:                stopChan,
+		accessLogs:              accessLoggers,
+		updatedLabel:            false,
+	}
+	if lc.Local {
+		// the local hop runs with minimal filters
+		streamFiltersFactories = nil
 	}
 	al.streamFiltersFactoriesStore.Store(streamFiltersFactories)
 
+	al.buildChainFactories(lc.FilterChains)
+
 	listenPort := 0
 	var listenIP string
 	localAddr := al.listener.Addr().String()
@@ -368,16 +440,41 @@ func newActiveListener(listener types.Listener, lc *v2.Listener, accessLoggers [
 	al.listenPort = listenPort
 	al.stats = newListenerStats(al.listener.Name())
 
+	if lc.Local {
+		// local listeners terminate no tls, the loopback hop is trusted
+		return al, nil
+	}
+
 	mgr, err := mtls.NewTLSServerContextManager(lc, listener, log.DefaultLogger)
 	if err != nil {
 		log.DefaultLogger.Errorf("[server] [new listener] create tls context manager failed, %v", err)
 		return nil, err
 	}
 	al.tlsMng = mgr
+	al.watchTLSCertificates(lc)
 
 	return al, nil
 }
 
+// watchTLSCertificates rebuilds the listener's tls context manager when
+// certificate files rotate on disk, new connections pick up the new manager
+func (al *activeListener) watchTLSCertificates(lc *v2.Listener) {
+	var configs []*v2.TLSConfig
+	for i := range lc.FilterChains {
+		for j := range lc.FilterChains[i].TLSContexts {
+			configs = append(configs, &lc.FilterChains[i].TLSContexts[j])
+		}
+	}
+	mtls.WatchCertificates("listener."+lc.Name, configs, func() error {
+		mgr, err := mtls.NewTLSServerContextManager(lc, al.listener, log.DefaultLogger)
+		if err != nil {
+			return err
+		}
+		al.tlsMng = mgr
+		return nil
+	})
+}
+
 func (al *activeListener) GoStart(lctx context.Context) {
 	utils.GoWithRecover(func() {
 		al.listener.Start(lctx)
@@ -392,6 +489,17 @@ func (al *activeListener) GoStart(lctx context.Context) {
 // ListenerEventListener
 func (al *activeListener) OnAccept(rawc net.Conn, handOffRestoredDestinationConnections bool, oriRemoteAddr net.Addr, ch chan types.Connection, buf []byte) {
 	var rawf *os.File
+	acceptTime := time.Now()
+
+	// overload protection: accept and close immediately when the listener or
+	// the global connection budget is exhausted. Restored destination
+	// connections are checked on the final working listener only
+	if !handOffRestoredDestinationConnections && !al.acceptConnection() {
+		al.stats.DownstreamConnectionOverflow.Inc(1)
+		log.DefaultLogger.Warnf("[server] [listener] connection limit reached on %s, close connection from %v", al.listener.Name(), rawc.RemoteAddr())
+		rawc.Close()
+		return
+	}
 
 	// only store fd and tls conn handshake in final working listener
 	if !handOffRestoredDestinationConnections {
@@ -415,7 +523,8 @@ func (al *activeListener) OnAccept(rawc net.Conn, handOffRestoredDestinationConn
 		log.DefaultLogger.Debugf("[server] [listener] accept restored destination connection from %v, remote addr:%v, origin remote addr:%v", al.listener.Addr(), rawc.RemoteAddr(), oriRemoteAddr)
 	}
 
-	ctx := mosnctx.WithValue(context.Background(), types.ContextKeyListenerPort, al.listenPort)
+	ctx := mosnctx.WithValue(context.Background(), types.ContextKeyAcceptTime, acceptTime)
+	ctx = mosnctx.WithValue(ctx, types.ContextKeyListenerPort, al.listenPort)
 	ctx = mosnctx.WithValue(ctx, types.ContextKeyListenerType, al.listener.Config().Type)
 	ctx = mosnctx.WithValue(ctx, types.ContextKeyListenerName, al.listener.Name())
 	ctx = mosnctx.WithValue(ctx, types.ContextKeyNetworkFilterChainFactories, al.networkFiltersFactories)
@@ -436,9 +545,20 @@ func (al *activeListener) OnAccept(rawc net.Conn, handOffRestoredDestinationConn
 }
 
 func (al *activeListener) OnNewConnection(ctx context.Context, conn types.Connection) {
+	networkFiltersFactories := al.networkFiltersFactories
+	if len(al.chainFactories) > 1 {
+		idx := SelectFilterChain(al.filterChains, al.chainMatchInfo(conn))
+		if idx < 0 {
+			log.DefaultLogger.Warnf("[server] [listener] no filter chain matches connection from %s on %s, close connection", conn.RemoteAddr(), al.listener.Name())
+			conn.Close(types.NoFlush, types.LocalClose)
+			return
+		}
+		networkFiltersFactories = al.chainFactories[idx]
+	}
+
 	//Register Proxy's Filter
 	filterManager := conn.FilterManager()
-	for _, nfcf := range al.networkFiltersFactories {
+	for _, nfcf := range networkFiltersFactories {
 		nfcf.CreateFilterChain(ctx, al.handler.clusterManager, filterManager)
 	}
 	filterManager.InitializeReadFilters()
@@ -449,13 +569,18 @@ func (al *activeListener) OnNewConnection(ctx context.Context, conn types.Connec
 		conn.Close(types.NoFlush, types.LocalClose)
 		return
 	}
-	ac := newActiveConnection(al, conn)
+	var acceptTime time.Time
+	if t := mosnctx.Get(ctx, types.ContextKeyAcceptTime); t != nil {
+		acceptTime = t.(time.Time)
+	}
+	ac := newActiveConnection(al, conn, acceptTime)
 
 	al.connsMux.Lock()
 	e := al.conns.PushBack(ac)
 	al.connsMux.Unlock()
 	ac.element = e
 
+	atomic.AddInt64(&al.numConnections, 1)
 	atomic.AddInt64(&al.handler.numConnections, 1)
 
 	if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
@@ -476,10 +601,58 @@ func (al *activeListener) removeConnection(ac *activeConnection) {
 	al.conns.Remove(ac.element)
 	al.connsMux.Unlock()
 
+	atomic.AddInt64(&al.numConnections, -1)
 	atomic.AddInt64(&al.handler.numConnections, -1)
 
 }
 
+// buildChainFactories prepares per filter chain factories when the listener
+// carries more than one chain, single chain listeners keep using
+// networkFiltersFactories directly
+func (al *activeListener) buildChainFactories(chains []v2.FilterChain) {
+	if len(chains) <= 1 {
+		al.filterChains = nil
+		al.chainFactories = nil
+		return
+	}
+	al.filterChains = chains
+	factories := make([][]types.NetworkFilterChainFactory, len(chains))
+	for i := range chains {
+		factories[i] = config.GetNetworkFilters(&chains[i])
+	}
+	al.chainFactories = factories
+}
+
+// chainMatchInfo collects the connection attributes filter chain selection
+// runs on. SNI and ALPN are only available after the tls handshake, plain
+// tcp connections can still match on source address and destination port
+func (al *activeListener) chainMatchInfo(conn types.Connection) *ChainMatchInfo {
+	info := &ChainMatchInfo{
+		TransportProtocol: TransportProtocolRawBuffer,
+		Source:            conn.RemoteAddr(),
+		DestinationPort:   al.listenPort,
+	}
+	if tlsConn, ok := conn.RawConn().(*mtls.TLSConn); ok {
+		state := tlsConn.ConnectionState()
+		info.ServerName = state.ServerName
+		info.ApplicationProtocol = state.NegotiatedProtocol
+		info.TransportProtocol = TransportProtocolTLS
+	}
+	return info
+}
+
+// acceptConnection reports whether a new connection fits into the listener
+// and the global connection budgets
+func (al *activeListener) acceptConnection() bool {
+	if max := atomic.LoadInt64(&al.maxConnections); max > 0 && atomic.LoadInt64(&al.numConnections) >= max {
+		return false
+	}
+	if limit := GlobalConnectionLimit; limit > 0 && atomic.LoadInt64(&al.handler.numConnections) >= int64(limit) {
+		return false
+	}
+	return true
+}
+
 func (al *activeListener) newConnection(ctx context.Context, rawc net.Conn) {
 	conn := network.NewServerConnection(ctx, rawc, al.stopChan)
 	oriRemoteAddr := mosnctx.Get(ctx, types.ContextOriRemoteAddr)
@@ -516,7 +689,8 @@ func newActiveRawConn(rawc net.Conn, activeListener *activeListener) *activeRawC
 func (arc *activeRawConn) SetOriginalAddr(ip string, port int) {
 	arc.originalDstIP = ip
 	arc.originalDstPort = port
-	arc.oriRemoteAddr, _ = net.ResolveTCPAddr("", ip+":"+strconv.Itoa(port))
+	// JoinHostPort keeps ipv6 addresses bracketed
+	arc.oriRemoteAddr, _ = net.ResolveTCPAddr("", net.JoinHostPort(ip, strconv.Itoa(port)))
 	if log.DefaultLogger.GetLogLevel() >= log.INFO {
 		log.DefaultLogger.Infof("[server] [conn] conn set origin addr:%s:%d", ip, port)
 	}
@@ -545,18 +719,21 @@ func (arc *activeRawConn) HandOffRestoredDestinationConnectionsHandler(ctx conte
 		}
 	}
 
-	if listener != nil {
-		if log.DefaultLogger.GetLogLevel() >= log.INFO {
-			log.DefaultLogger.Infof("[server] [conn] original dst:%s:%d", listener.listenIP, listener.listenPort)
-		}
-		listener.OnAccept(arc.rawc, false, arc.oriRemoteAddr, ch, buf)
+	// an exact address match wins over the 0.0.0.0 wildcard, the connection
+	// must be handed off to a single listener only
+	if listener == nil {
+		listener = localListener
 	}
-	if localListener != nil {
-		if log.DefaultLogger.GetLogLevel() >= log.INFO {
-			log.DefaultLogger.Infof("[server] [conn] original dst:%s:%d", localListener.listenIP, localListener.listenPort)
-		}
-		localListener.OnAccept(arc.rawc, false, arc.oriRemoteAddr, ch, buf)
+	if listener == nil {
+		log.DefaultLogger.Errorf("[server] [conn] no virtual listener found for original dst %s:%d, close connection", arc.originalDstIP, arc.originalDstPort)
+		arc.rawc.Close()
+		return
 	}
+
+	if log.DefaultLogger.GetLogLevel() >= log.INFO {
+		log.DefaultLogger.Infof("[server] [conn] original dst:%s:%d", listener.listenIP, listener.listenPort)
+	}
+	listener.OnAccept(arc.rawc, false, arc.oriRemoteAddr, ch, buf)
 }
 
 func (arc *activeRawConn) ContinueFilterChain(ctx context.Context, success bool) {
@@ -589,15 +766,18 @@ func (arc *activeRawConn) Conn() net.Conn {
 // ListenerFilterManager note:unsupported now
 // ListenerFilterCallbacks note:unsupported now
 type activeConnection struct {
-	element  *list.Element
-	listener *activeListener
-	conn     types.Connection
+	element    *list.Element
+	listener   *activeListener
+	conn       types.Connection
+	acceptTime time.Time
+	firstByte  uint32
 }
 
-func newActiveConnection(listener *activeListener, conn types.Connection) *activeConnection {
+func newActiveConnection(listener *activeListener, conn types.Connection, acceptTime time.Time) *activeConnection {
 	ac := &activeConnection{
-		conn:     conn,
-		listener: listener,
+		conn:       conn,
+		listener:   listener,
+		acceptTime: acceptTime,
 	}
 
 	ac.conn.SetNoDelay(true)
@@ -606,6 +786,7 @@ func newActiveConnection(listener *activeListener, conn types.Connection) *activ
 
 		if bytesRead > 0 {
 			listener.stats.DownstreamBytesReadTotal.Inc(int64(bytesRead))
+			ac.onFirstByte()
 		}
 	})
 	ac.conn.AddBytesSentListener(func(bytesSent uint64) {
@@ -618,6 +799,33 @@ func newActiveConnection(listener *activeListener, conn types.Connection) *activ
 	return ac
 }
 
+// onFirstByte records accept-time durations when the first bytes arrive.
+// For tls connections the handshake is driven by the first read, so the
+// first byte elapsed time is the handshake duration the listener observes
+func (ac *activeConnection) onFirstByte() {
+	if ac.acceptTime.IsZero() || !atomic.CompareAndSwapUint32(&ac.firstByte, 0, 1) {
+		return
+	}
+	elapsed := time.Since(ac.acceptTime)
+	ac.listener.stats.DownstreamAcceptToFirstByteDuration.Update(elapsed.Nanoseconds() / int64(time.Millisecond))
+
+	tlsConn, ok := ac.conn.RawConn().(*mtls.TLSConn)
+	if !ok || !tlsConn.ConnectionState().HandshakeComplete {
+		return
+	}
+	ac.listener.stats.DownstreamTLSHandshakeDuration.Update(elapsed.Nanoseconds() / int64(time.Millisecond))
+
+	if elapsed < slowHandshakeThreshold {
+		return
+	}
+	now := time.Now().UnixNano()
+	last := atomic.LoadInt64(&ac.listener.lastSlowHandshakeLog)
+	if now-last >= int64(slowHandshakeLogInterval) &&
+		atomic.CompareAndSwapInt64(&ac.listener.lastSlowHandshakeLog, last, now) {
+		log.DefaultLogger.Warnf("[server] [listener] slow tls handshake on %s from %s, took %v", ac.listener.listener.Name(), ac.conn.RemoteAddr(), elapsed)
+	}
+}
+
 // ConnectionEventListener
 func (ac *activeConnection) OnEvent(event types.ConnectionEvent) {
 	if event.IsClose() {
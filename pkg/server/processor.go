@@ -0,0 +1,116 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+
+	admin "sofastack.io/sofa-mosn/pkg/admin/server"
+	"sofastack.io/sofa-mosn/pkg/log"
+)
+
+// GOMAXPROCS tuning. In a container with a cpu quota smaller than the host
+// core count, the Go default (runtime.NumCPU) makes the runtime schedule more
+// threads than the quota allows, so the kernel throttles them and tail
+// latency inflates. A configured processor count > 0 is always used as is;
+// processor == 0 derives the count from the cgroup cpu quota when one is set,
+// and otherwise keeps the Go default.
+
+// cgroup cpu quota files, v1 and v2 layouts
+var (
+	cgroupV1QuotaPath  = "/sys/fs/cgroup/cpu/cpu.cfs_quota_us"
+	cgroupV1PeriodPath = "/sys/fs/cgroup/cpu/cpu.cfs_period_us"
+	cgroupV2MaxPath    = "/sys/fs/cgroup/cpu.max"
+)
+
+func init() {
+	admin.RegisterAdminHandleFunc("/api/v1/runtime", runtimeDump)
+}
+
+// setGoMaxProcs sets GOMAXPROCS from the server config processor count,
+// falling back to the cgroup cpu quota when processor is 0
+func setGoMaxProcs(processor int) {
+	if processor > 0 {
+		runtime.GOMAXPROCS(processor)
+		return
+	}
+	if quota := cgroupCPUQuota(); quota > 0 {
+		procs := int(math.Ceil(quota))
+		if procs < 1 {
+			procs = 1
+		}
+		if procs < runtime.NumCPU() {
+			runtime.GOMAXPROCS(procs)
+			log.StartLogger.Infof("[server] GOMAXPROCS set to %d by cgroup cpu quota %.2f", procs, quota)
+			return
+		}
+	}
+	// no quota, keep the Go default
+	runtime.GOMAXPROCS(runtime.NumCPU())
+}
+
+// cgroupCPUQuota returns the cpu quota of the cgroup the process runs in,
+// in cores, or a value <= 0 if no quota is set
+func cgroupCPUQuota() float64 {
+	// cgroup v2: "max 100000" or "25000 100000"
+	if data, err := ioutil.ReadFile(cgroupV2MaxPath); err == nil {
+		fields := strings.Fields(string(data))
+		if len(fields) == 2 && fields[0] != "max" {
+			quota, err1 := strconv.ParseFloat(fields[0], 64)
+			period, err2 := strconv.ParseFloat(fields[1], 64)
+			if err1 == nil && err2 == nil && period > 0 {
+				return quota / period
+			}
+		}
+		return -1
+	}
+	// cgroup v1: quota is -1 when unlimited
+	quota, err := readCgroupValue(cgroupV1QuotaPath)
+	if err != nil || quota <= 0 {
+		return -1
+	}
+	period, err := readCgroupValue(cgroupV1PeriodPath)
+	if err != nil || period <= 0 {
+		return -1
+	}
+	return quota / period
+}
+
+func readCgroupValue(path string) (float64, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
+}
+
+// runtimeDump is an admin api that exposes the effective runtime tuning
+func runtimeDump(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	fmt.Fprintf(w, "gomaxprocs=%d&numcpu=%d&cgroup_cpu_quota=%.2f&goroutines=%d\n",
+		runtime.GOMAXPROCS(0), runtime.NumCPU(), cgroupCPUQuota(), runtime.NumGoroutine())
+}
@@ -0,0 +1,101 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"runtime"
+	"testing"
+)
+
+func withCgroupFiles(t *testing.T, v2Max, v1Quota, v1Period string) func() {
+	dir, err := ioutil.TempDir("", "cgroup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldV2, oldQuota, oldPeriod := cgroupV2MaxPath, cgroupV1QuotaPath, cgroupV1PeriodPath
+	cgroupV2MaxPath = path.Join(dir, "cpu.max")
+	cgroupV1QuotaPath = path.Join(dir, "cpu.cfs_quota_us")
+	cgroupV1PeriodPath = path.Join(dir, "cpu.cfs_period_us")
+	if v2Max != "" {
+		ioutil.WriteFile(cgroupV2MaxPath, []byte(v2Max), 0644)
+	}
+	if v1Quota != "" {
+		ioutil.WriteFile(cgroupV1QuotaPath, []byte(v1Quota), 0644)
+		ioutil.WriteFile(cgroupV1PeriodPath, []byte(v1Period), 0644)
+	}
+	return func() {
+		cgroupV2MaxPath, cgroupV1QuotaPath, cgroupV1PeriodPath = oldV2, oldQuota, oldPeriod
+		os.RemoveAll(dir)
+	}
+}
+
+func TestCgroupCPUQuota(t *testing.T) {
+	// cgroup v2 with a quota of 2.5 cores
+	cleanup := withCgroupFiles(t, "250000 100000\n", "", "")
+	if quota := cgroupCPUQuota(); quota != 2.5 {
+		t.Errorf("expected quota 2.5, got %f", quota)
+	}
+	cleanup()
+
+	// cgroup v2 without a quota
+	cleanup = withCgroupFiles(t, "max 100000\n", "", "")
+	if quota := cgroupCPUQuota(); quota > 0 {
+		t.Errorf("expected no quota, got %f", quota)
+	}
+	cleanup()
+
+	// cgroup v1 with a quota of half a core
+	cleanup = withCgroupFiles(t, "", "50000\n", "100000\n")
+	if quota := cgroupCPUQuota(); quota != 0.5 {
+		t.Errorf("expected quota 0.5, got %f", quota)
+	}
+	cleanup()
+
+	// cgroup v1 without a quota
+	cleanup = withCgroupFiles(t, "", "-1\n", "100000\n")
+	if quota := cgroupCPUQuota(); quota > 0 {
+		t.Errorf("expected no quota, got %f", quota)
+	}
+	cleanup()
+}
+
+func TestSetGoMaxProcs(t *testing.T) {
+	old := runtime.GOMAXPROCS(0)
+	defer runtime.GOMAXPROCS(old)
+
+	// explicit processor count wins over the cgroup quota
+	cleanup := withCgroupFiles(t, "250000 100000\n", "", "")
+	defer cleanup()
+	setGoMaxProcs(1)
+	if got := runtime.GOMAXPROCS(0); got != 1 {
+		t.Errorf("expected GOMAXPROCS 1, got %d", got)
+	}
+
+	// quota smaller than NumCPU caps GOMAXPROCS, fractions round up
+	setGoMaxProcs(0)
+	expected := 3
+	if expected > runtime.NumCPU() {
+		expected = runtime.NumCPU()
+	}
+	if got := runtime.GOMAXPROCS(0); got != expected {
+		t.Errorf("expected GOMAXPROCS %d, got %d", expected, got)
+	}
+}
@@ -28,6 +28,7 @@ import (
 	"sofastack.io/sofa-mosn/pkg/admin/store"
 	"sofastack.io/sofa-mosn/pkg/config"
 	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/network"
 	"sofastack.io/sofa-mosn/pkg/server/keeper"
 	"sofastack.io/sofa-mosn/pkg/types"
 )
@@ -41,6 +42,15 @@ func init() {
 
 var GracefulTimeout = time.Second * 30 //default 30s
 
+// ListenerBleedOffTime enables deferred listener deletion, established
+// connections get this long to finish before the force close, 0 deletes
+// listeners immediately
+var ListenerBleedOffTime time.Duration
+
+// GlobalConnectionLimit is the connection budget over all listeners, connections
+// accepted beyond it are closed immediately to protect the fd budget, 0 means no limit
+var GlobalConnectionLimit uint64
+
 func startNewMosn() error {
 	execSpec := &syscall.ProcAttr{
 		Env:   os.Environ(),
@@ -103,6 +113,10 @@ func reconfigure(start bool) {
 	// Wait for all connections to be finished
 	WaitConnectionsDone(GracefulTimeout)
 
+	// Hand the idle upstream connections to the new mosn so upstream
+	// hosts don't see a reconnect storm on every deployment
+	network.TransferUpstreamConnections()
+
 	log.DefaultLogger.Infof("[server] [reconfigure] process %d gracefully shutdown", os.Getpid())
 
 	keeper.ExecuteShutdownCallbacks("")
@@ -19,7 +19,6 @@ package server
 
 import (
 	"os"
-	"runtime"
 	"time"
 
 	"sofastack.io/sofa-mosn/pkg/api/v2"
@@ -51,13 +50,17 @@ type server struct {
 
 func NewConfig(c *v2.ServerConfig) *Config {
 	return &Config{
-		ServerName:      c.ServerName,
-		LogPath:         c.DefaultLogPath,
-		LogLevel:        config.ParseLogLevel(c.DefaultLogLevel),
-		LogRoller:       c.DefaultLogRoller,
-		GracefulTimeout: c.GracefulTimeout.Duration,
-		Processor:       c.Processor,
-		UseNetpollMode:  c.UseNetpollMode,
+		ServerName:           c.ServerName,
+		LogPath:              c.DefaultLogPath,
+		LogLevel:             config.ParseLogLevel(c.DefaultLogLevel),
+		LogRoller:            c.DefaultLogRoller,
+		GracefulTimeout:      c.GracefulTimeout.Duration,
+		ListenerBleedOffTime: c.ListenerBleedOffTime.Duration,
+		Processor:            c.Processor,
+		UseNetpollMode:       c.UseNetpollMode,
+		MaxConnections:       c.MaxConnections,
+		AcceptPoolSize:       c.AcceptPoolSize,
+		AcceptPoolOverflow:   c.AcceptPoolOverflow,
 	}
 }
 
@@ -68,13 +71,25 @@ func NewServer(config *Config, cmFilter types.ClusterManagerFilter, clMng types.
 			GracefulTimeout = config.GracefulTimeout
 		}
 
+		ListenerBleedOffTime = config.ListenerBleedOffTime
+
 		network.UseNetpollMode = config.UseNetpollMode
 		if config.UseNetpollMode {
 			log.StartLogger.Infof("[server] [reconfigure] [new server] Netpoll mode enabled.")
 		}
+
+		GlobalConnectionLimit = config.MaxConnections
+		if config.MaxConnections > 0 {
+			log.StartLogger.Infof("[server] [new server] global connection limit: %d", config.MaxConnections)
+		}
+
+		if config.AcceptPoolSize > 0 {
+			network.InitAcceptPool(config.AcceptPoolSize, config.AcceptPoolOverflow)
+			log.StartLogger.Infof("[server] [new server] accept pool size: %d, overflow policy: %s", config.AcceptPoolSize, config.AcceptPoolOverflow)
+		}
 	}
 
-	runtime.GOMAXPROCS(config.Processor)
+	setGoMaxProcs(config.Processor)
 
 	keeper.OnProcessShutDown(log.CloseAll)
 
@@ -0,0 +1,115 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+
+	"sofastack.io/sofa-mosn/pkg/log"
+)
+
+// Socket activation, as an alternative to the hot-restart InheritListener
+// path. Listening sockets can be received from a socket-activated supervisor,
+// either via the systemd LISTEN_FDS protocol or via an arbitrary fd list
+// specified in the mosn config. The returned listeners are matched to the
+// configured listener addresses the same way inherited listeners are.
+
+// systemd socket activation protocol
+const (
+	listenFdsStart = 3
+
+	envListenPid = "LISTEN_PID"
+	envListenFds = "LISTEN_FDS"
+)
+
+// GetActivatedListeners returns the listeners received via systemd socket
+// activation and the listeners created from the inherited fds configured in
+// the mosn config.
+func GetActivatedListeners(inheritFds []uint) []net.Listener {
+	var listeners []net.Listener
+
+	listeners = append(listeners, systemdActivatedListeners()...)
+
+	for _, fd := range inheritFds {
+		l, err := listenerFromFd(uintptr(fd), fmt.Sprintf("inherit_fd_%d", fd))
+		if err != nil {
+			log.StartLogger.Errorf("[server] [socket activation] invalid inherited fd %d: %v", fd, err)
+			continue
+		}
+		listeners = append(listeners, l)
+	}
+
+	return listeners
+}
+
+// systemdActivatedListeners implements the receiving side of the systemd
+// socket activation protocol (sd_listen_fds)
+func systemdActivatedListeners() []net.Listener {
+	defer func() {
+		// the fds are only valid for this process, avoid passing them on
+		os.Unsetenv(envListenPid)
+		os.Unsetenv(envListenFds)
+	}()
+
+	pidStr := os.Getenv(envListenPid)
+	fdsStr := os.Getenv(envListenFds)
+	if pidStr == "" || fdsStr == "" {
+		return nil
+	}
+
+	pid, err := strconv.Atoi(pidStr)
+	if err != nil || pid != os.Getpid() {
+		return nil
+	}
+	nfds, err := strconv.Atoi(fdsStr)
+	if err != nil || nfds <= 0 {
+		return nil
+	}
+
+	listeners := make([]net.Listener, 0, nfds)
+	for fd := listenFdsStart; fd < listenFdsStart+nfds; fd++ {
+		l, err := listenerFromFd(uintptr(fd), fmt.Sprintf("listen_fd_%d", fd))
+		if err != nil {
+			log.StartLogger.Errorf("[server] [socket activation] LISTEN_FDS fd %d is not a listening socket: %v", fd, err)
+			continue
+		}
+		listeners = append(listeners, l)
+	}
+
+	log.StartLogger.Infof("[server] [socket activation] received %d listeners from LISTEN_FDS", len(listeners))
+	return listeners
+}
+
+func listenerFromFd(fd uintptr, name string) (net.Listener, error) {
+	file := os.NewFile(fd, name)
+	defer file.Close()
+
+	l, err := net.FileListener(file)
+	if err != nil {
+		return nil, err
+	}
+	// only tcp listeners can be matched to listener configs
+	if _, ok := l.(*net.TCPListener); !ok {
+		l.Close()
+		return nil, fmt.Errorf("unsupported listener type %T", l)
+	}
+	return l, nil
+}
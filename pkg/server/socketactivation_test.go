@@ -0,0 +1,66 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"net"
+	"os"
+	"testing"
+)
+
+func TestGetActivatedListenersFromFds(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	file, err := l.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	listeners := GetActivatedListeners([]uint{uint(file.Fd())})
+	if len(listeners) != 1 {
+		t.Fatalf("expected 1 listener, got %d", len(listeners))
+	}
+	defer listeners[0].Close()
+
+	if listeners[0].Addr().String() != l.Addr().String() {
+		t.Errorf("listener addr not match, expected %s, got %s", l.Addr(), listeners[0].Addr())
+	}
+
+	// invalid fds are skipped
+	if got := GetActivatedListeners([]uint{65000}); len(got) != 0 {
+		t.Errorf("expected no listener for invalid fd, got %d", len(got))
+	}
+}
+
+func TestSystemdActivatedListenersPidMismatch(t *testing.T) {
+	os.Setenv(envListenPid, "1")
+	os.Setenv(envListenFds, "1")
+
+	if got := systemdActivatedListeners(); got != nil {
+		t.Errorf("expected no listener for foreign LISTEN_PID, got %d", len(got))
+	}
+	// env must be cleared either way
+	if os.Getenv(envListenPid) != "" || os.Getenv(envListenFds) != "" {
+		t.Error("LISTEN_PID/LISTEN_FDS not cleared")
+	}
+}
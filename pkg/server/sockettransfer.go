@@ -0,0 +1,201 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"runtime/debug"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+// ListenerSocketMeta describes a listener fd transferred over SCM_RIGHTS,
+// metadata entries are parallel to the transferred fds
+type ListenerSocketMeta struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+}
+
+// listenerTransferFiles collects the fds and metadata of all active listeners
+func listenerTransferFiles() ([]*os.File, []ListenerSocketMeta) {
+	var files []*os.File
+	var metas []ListenerSocketMeta
+	for _, srv := range servers {
+		ch, ok := srv.handler.(*connHandler)
+		if !ok {
+			continue
+		}
+		for _, al := range ch.listeners {
+			f, err := al.listener.ListenerFile()
+			if err != nil {
+				log.DefaultLogger.Errorf("[server] [socket transfer] fail to get listener %s file descriptor: %v", al.listener.Name(), err)
+				continue
+			}
+			files = append(files, f)
+			metas = append(metas, ListenerSocketMeta{
+				Name:    al.listener.Name(),
+				Address: al.listener.Addr().String(),
+			})
+		}
+	}
+	return files, metas
+}
+
+// SocketTransferHandler serves the active listener fds over SCM_RIGHTS.
+// Unlike the SIGHUP inherit flow the requesting process dials this endpoint,
+// so a new mosn can take the sockets over without being a forked child
+func SocketTransferHandler() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.DefaultLogger.Errorf("[server] [socket transfer] SocketTransferHandler panic %v\n%s", r, string(debug.Stack()))
+		}
+	}()
+
+	syscall.Unlink(types.SocketTransferDomainSocket)
+
+	l, err := net.Listen("unix", types.SocketTransferDomainSocket)
+	if err != nil {
+		log.StartLogger.Errorf("[server] [socket transfer] net listen error: %v", err)
+		return
+	}
+	defer l.Close()
+
+	log.DefaultLogger.Infof("[server] [socket transfer] SocketTransferHandler start")
+
+	ul := l.(*net.UnixListener)
+	for {
+		uc, err := ul.AcceptUnix()
+		if err != nil {
+			log.DefaultLogger.Errorf("[server] [socket transfer] Accept error: %v", err)
+			return
+		}
+		transferListenerSockets(uc)
+	}
+}
+
+// StopSocketTransferHandler removes the socket transfer endpoint
+func StopSocketTransferHandler() {
+	syscall.Unlink(types.SocketTransferDomainSocket)
+}
+
+func transferListenerSockets(uc *net.UnixConn) {
+	defer uc.Close()
+
+	files, metas := listenerTransferFiles()
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+	if len(files) > 100 {
+		log.DefaultLogger.Errorf("[server] [socket transfer] listener fd too many: %d", len(files))
+		return
+	}
+
+	buf, err := json.Marshal(metas)
+	if err != nil {
+		log.DefaultLogger.Errorf("[server] [socket transfer] marshal metadata failed: %v", err)
+		return
+	}
+	fds := make([]int, len(files))
+	for i, f := range files {
+		fds[i] = int(f.Fd())
+	}
+	rights := syscall.UnixRights(fds...)
+
+	uc.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	n, oobn, err := uc.WriteMsgUnix(buf, rights, nil)
+	if err != nil {
+		log.DefaultLogger.Errorf("[server] [socket transfer] WriteMsgUnix: %v", err)
+		return
+	}
+	if n != len(buf) || oobn != len(rights) {
+		log.DefaultLogger.Errorf("[server] [socket transfer] WriteMsgUnix = %d, %d; want %d, %d", n, oobn, len(buf), len(rights))
+		return
+	}
+	log.DefaultLogger.Infof("[server] [socket transfer] transferred %d listener fds to %s", len(fds), uc.RemoteAddr())
+}
+
+// RequestTransferListeners asks a running mosn for its active listener
+// sockets and their metadata, used by a new process taking over the
+// listen sockets without inheriting them on fork
+func RequestTransferListeners() ([]net.Listener, []ListenerSocketMeta, error) {
+	unixConn, err := net.DialTimeout("unix", types.SocketTransferDomainSocket, 1*time.Second)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer unixConn.Close()
+
+	uc := unixConn.(*net.UnixConn)
+	uc.SetReadDeadline(time.Now().Add(10 * time.Second))
+	buf := make([]byte, 64*1024)
+	oob := make([]byte, 4096)
+	bn, oobn, _, _, err := uc.ReadMsgUnix(buf, oob)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	scms, err := unix.ParseSocketControlMessage(oob[0:oobn])
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(scms) != 1 {
+		return nil, nil, errors.New("expected 1 SocketControlMessage")
+	}
+	gotFds, err := unix.ParseUnixRights(&scms[0])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var metas []ListenerSocketMeta
+	if err := json.Unmarshal(buf[0:bn], &metas); err != nil {
+		return nil, nil, err
+	}
+	if len(metas) != len(gotFds) {
+		return nil, nil, errors.New("listener metadata does not match transferred fds")
+	}
+
+	listeners := make([]net.Listener, len(gotFds))
+	for i := 0; i < len(gotFds); i++ {
+		fd := uintptr(gotFds[i])
+		file := os.NewFile(fd, "")
+		if file == nil {
+			return nil, nil, errors.New("create file from transferred fd failed")
+		}
+		defer file.Close()
+
+		fileListener, err := net.FileListener(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		listener, ok := fileListener.(*net.TCPListener)
+		if !ok {
+			return nil, nil, errors.New("listener recovered from transferred fd is not a tcp listener")
+		}
+		listeners[i] = listener
+	}
+
+	return listeners, metas, nil
+}
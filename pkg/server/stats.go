@@ -18,19 +18,25 @@
 package server
 
 import (
-	"sofastack.io/sofa-mosn/pkg/metrics"
 	gometrics "github.com/rcrowley/go-metrics"
+	"sofastack.io/sofa-mosn/pkg/metrics"
 )
 
 type listenerStats struct {
-	DownstreamBytesReadTotal  gometrics.Counter
-	DownstreamBytesWriteTotal gometrics.Counter
+	DownstreamBytesReadTotal            gometrics.Counter
+	DownstreamBytesWriteTotal           gometrics.Counter
+	DownstreamConnectionOverflow        gometrics.Counter
+	DownstreamAcceptToFirstByteDuration gometrics.Histogram
+	DownstreamTLSHandshakeDuration      gometrics.Histogram
 }
 
 func newListenerStats(listenerName string) *listenerStats {
 	s := metrics.NewListenerStats(listenerName)
 	return &listenerStats{
-		DownstreamBytesReadTotal:  s.Counter(metrics.DownstreamBytesReadTotal),
-		DownstreamBytesWriteTotal: s.Counter(metrics.DownstreamBytesWriteTotal),
+		DownstreamBytesReadTotal:            s.Counter(metrics.DownstreamBytesReadTotal),
+		DownstreamBytesWriteTotal:           s.Counter(metrics.DownstreamBytesWriteTotal),
+		DownstreamConnectionOverflow:        s.Counter(metrics.DownstreamConnectionOverflow),
+		DownstreamAcceptToFirstByteDuration: s.Histogram(metrics.DownstreamAcceptToFirstByteDuration),
+		DownstreamTLSHandshakeDuration:      s.Histogram(metrics.DownstreamTLSHandshakeDuration),
 	}
 }
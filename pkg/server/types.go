@@ -26,13 +26,17 @@ import (
 )
 
 type Config struct {
-	ServerName      string
-	LogPath         string
-	LogLevel        log.Level
-	LogRoller       string
-	GracefulTimeout time.Duration
-	Processor       int
-	UseNetpollMode  bool
+	ServerName           string
+	LogPath              string
+	LogLevel             log.Level
+	LogRoller            string
+	GracefulTimeout      time.Duration
+	ListenerBleedOffTime time.Duration
+	Processor            int
+	UseNetpollMode       bool
+	MaxConnections       uint64
+	AcceptPoolSize       int
+	AcceptPoolOverflow   string
 }
 
 type Server interface {
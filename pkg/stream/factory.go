@@ -31,6 +31,9 @@ func init() {
 
 func Register(prot types.Protocol, factory ProtocolStreamFactory) {
 	streamFactories[prot] = factory
+	// the factory's own match joins the Auto protocol sniffing, a protocol
+	// can override it with RegisterProtocolMatcher for an explicit priority
+	RegisterProtocolMatcher(prot, DefaultMatchPriority, factory.ProtocolMatch)
 }
 
 func CreateServerStreamConnection(context context.Context, prot types.Protocol, connection types.Connection,
@@ -42,21 +45,3 @@ func CreateServerStreamConnection(context context.Context, prot types.Protocol,
 
 	return nil
 }
-
-func SelectStreamFactoryProtocol(ctx context.Context, prot string, peek []byte) (types.Protocol, error) {
-	var err error
-	var again bool
-	for p, factory := range streamFactories {
-		err = factory.ProtocolMatch(ctx, prot, peek)
-		if err == nil {
-			return p, nil
-		} else if err == EAGAIN {
-			again = true
-		}
-	}
-	if again {
-		return "", EAGAIN
-	} else {
-		return "", FAILED
-	}
-}
@@ -32,8 +32,6 @@ import (
 	"sofastack.io/sofa-mosn/pkg/utils"
 )
 
-//const defaultIdleTimeout = time.Second * 60 // not used yet
-
 func init() {
 	network.RegisterNewPoolFactory(protocol.HTTP1, NewConnPool)
 	types.RegisterConnPoolFactory(protocol.HTTP1, true)
@@ -101,28 +99,50 @@ func (p *connPool) NewStream(ctx context.Context, receiver types.StreamReceiveLi
 }
 
 func (p *connPool) getAvailableClient(ctx context.Context) (*activeClient, types.PoolFailureReason) {
-	p.clientMux.Lock()
-	defer p.clientMux.Unlock()
+	var idleTimeout time.Duration
+	if h1 := p.host.ClusterInfo().Http1Settings(); h1 != nil {
+		idleTimeout = h1.IdleTimeout.Duration
+	}
 
-	n := len(p.availableClients)
-	// no available client
-	if n == 0 {
-		maxConns := p.host.ClusterInfo().ResourceManager().Connections().Max()
-		if p.totalClientCount < maxConns {
-			p.totalClientCount++
-			return newActiveClient(ctx, p)
-		} else {
-			p.host.HostStats().UpstreamRequestPendingOverflow.Inc(1)
-			p.host.ClusterInfo().Stats().UpstreamRequestPendingOverflow.Inc(1)
-			return nil, types.Overflow
+	var client *activeClient
+	var reason types.PoolFailureReason
+	// clients idle past the timeout are closed after the lock is released,
+	// the close event re-enters clientMux
+	var expired []*activeClient
+
+	p.clientMux.Lock()
+	for {
+		n := len(p.availableClients)
+		// no available client
+		if n == 0 {
+			maxConns := p.host.ClusterInfo().ResourceManager().Connections().Max()
+			if p.totalClientCount < maxConns {
+				p.totalClientCount++
+				client, reason = newActiveClient(ctx, p)
+			} else {
+				p.host.HostStats().UpstreamRequestPendingOverflow.Inc(1)
+				p.host.ClusterInfo().Stats().UpstreamRequestPendingOverflow.Inc(1)
+				reason = types.Overflow
+			}
+			break
 		}
-	} else {
 		n--
 		c := p.availableClients[n]
 		p.availableClients[n] = nil
 		p.availableClients = p.availableClients[:n]
-		return c, ""
+		if idleTimeout > 0 && !c.returnedAt.IsZero() && time.Since(c.returnedAt) > idleTimeout {
+			expired = append(expired, c)
+			continue
+		}
+		client = c
+		break
 	}
+	p.clientMux.Unlock()
+
+	for _, c := range expired {
+		c.client.Close()
+	}
+	return client, reason
 }
 
 func (p *connPool) Close() {
@@ -166,21 +186,63 @@ func (p *connPool) onConnectionEvent(client *activeClient, event types.Connectio
 	} else if event == types.ConnectTimeout {
 		p.host.HostStats().UpstreamRequestTimeout.Inc(1)
 		p.host.ClusterInfo().Stats().UpstreamRequestTimeout.Inc(1)
+		network.ReportConnectFailure(p.host.AddressString())
 		client.client.Close()
 	} else if event == types.ConnectFailed {
 		p.host.HostStats().UpstreamConnectionConFail.Inc(1)
 		p.host.ClusterInfo().Stats().UpstreamConnectionConFail.Inc(1)
+		network.ReportConnectFailure(p.host.AddressString())
+	} else if event == types.Connected {
+		network.ReportConnectSuccess(p.host.AddressString())
 	}
 }
 
+// PoolStatus implements types.PoolStatusProvider, only the idle clients
+// kept in the pool are listed, in-flight ones are returned on stream destroy
+func (p *connPool) PoolStatus() types.PoolStatus {
+	p.clientMux.Lock()
+	defer p.clientMux.Unlock()
+	status := types.PoolStatus{
+		Protocol:    string(protocol.HTTP1),
+		Connections: make([]types.PoolConnectionStatus, 0, len(p.availableClients)),
+	}
+	for _, c := range p.availableClients {
+		connStatus := types.PoolConnectionStatus{
+			State:        "available",
+			TotalStreams: c.totalStream,
+		}
+		if c.client != nil {
+			connStatus.ActiveStreams = c.client.ActiveRequestsNum()
+		}
+		if !c.createdAt.IsZero() {
+			connStatus.Age = time.Since(c.createdAt).String()
+		}
+		status.Connections = append(status.Connections, connStatus)
+	}
+	return status
+}
+
 func (p *connPool) onStreamDestroy(client *activeClient) {
 	p.host.HostStats().UpstreamRequestActive.Dec(1)
 	p.host.ClusterInfo().Stats().UpstreamRequestActive.Dec(1)
 	p.host.ClusterInfo().ResourceManager().Requests().Decrease()
 
+	var maxIdle uint32
+	if h1 := p.host.ClusterInfo().Http1Settings(); h1 != nil {
+		maxIdle = h1.MaxIdleConnsPerHost
+	}
+
 	// return to pool
 	p.clientMux.Lock()
 	if !client.closed {
+		if maxIdle > 0 && uint32(len(p.availableClients)) >= maxIdle {
+			// the pool already keeps enough idle connections, close this
+			// one outside the lock, the close event re-enters clientMux
+			p.clientMux.Unlock()
+			client.client.Close()
+			return
+		}
+		client.returnedAt = time.Now()
 		p.availableClients = append(p.availableClients, client)
 	}
 	p.clientMux.Unlock()
@@ -227,11 +289,14 @@ type activeClient struct {
 	pendingReset       uint32 // FIXME: temp fix for http concurrent problem, which is caused by downstream reset
 	closeWithActiveReq bool
 	closed             bool
+	createdAt          time.Time
+	returnedAt         time.Time // last time the client went back to the pool
 }
 
 func newActiveClient(ctx context.Context, pool *connPool) (*activeClient, types.PoolFailureReason) {
 	ac := &activeClient{
-		pool: pool,
+		pool:      pool,
+		createdAt: time.Now(),
 	}
 
 	data := pool.host.CreateConnection(ctx)
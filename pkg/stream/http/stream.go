@@ -170,6 +170,7 @@ type clientStreamConnection struct {
 
 	stream                        *clientStream
 	requestSent                   chan bool
+	served                        uint64 // responses completed on this connection, only touched by serve()
 	mutex                         sync.RWMutex
 	connectionEventListener       types.ConnectionEventListener
 	streamConnectionEventListener types.StreamConnectionEventListener
@@ -217,12 +218,32 @@ func (conn *clientStreamConnection) serve() {
 		err := s.response.Read(conn.br)
 		if err != nil {
 			if s != nil {
+				reason := types.StreamRemoteReset
+				// io.EOF before the first response byte on a connection that
+				// already served responses means the upstream dropped a stale
+				// keep-alive connection, the request is safe to resend
+				if err == io.EOF && conn.served > 0 {
+					reason = types.StreamReusedConnectionFailed
+				}
 				log.Proxy.Errorf(s.connection.context, "[stream] [http] client stream connection wait response error: %s", err)
-				s.ResetStream(types.StreamRemoteReset)
+				s.ResetStream(reason)
 			}
 			return
 		}
 
+		// fasthttp only swallows a single interim 100 response on its own,
+		// drain any further informational responses (103 early hints etc.)
+		// until the final one arrives
+		for code := s.response.StatusCode(); code >= 100 && code < 200 && code != http.StatusSwitchingProtocols; code = s.response.StatusCode() {
+			if err := s.response.Read(conn.br); err != nil {
+				log.Proxy.Errorf(s.connection.context, "[stream] [http] client stream connection wait final response error: %s", err)
+				s.ResetStream(types.StreamRemoteReset)
+				return
+			}
+		}
+
+		conn.served++
+
 		if log.Proxy.GetLogLevel() >= log.INFO {
 			log.Proxy.Infof(s.stream.ctx, "[stream] [http] receive response, requestId = %v", s.stream.id)
 		}
@@ -22,6 +22,7 @@ import (
 
 	"net"
 
+	"bufio"
 	"bytes"
 	"fmt"
 	"sofastack.io/sofa-mosn/pkg/network"
@@ -253,3 +254,46 @@ func convertHeader(payload protocol.CommonHeader) http.RequestHeader {
 
 	return header
 }
+
+func Test_response_chunked_trailer(t *testing.T) {
+	// upstreams sending a trailer section after the last chunk used to
+	// break the response read with a broken chunk error
+	raw := "HTTP/1.1 200 OK\r\n" +
+		"Transfer-Encoding: chunked\r\n" +
+		"\r\n" +
+		"5\r\nhello\r\n" +
+		"0\r\n" +
+		"X-Checksum: 12345\r\n" +
+		"\r\n"
+
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(resp)
+
+	if err := resp.Read(bufio.NewReader(bytes.NewBufferString(raw))); err != nil {
+		t.Fatalf("read chunked response with trailer failed: %v", err)
+	}
+	if string(resp.Body()) != "hello" {
+		t.Errorf("unexpected body: %q", resp.Body())
+	}
+}
+
+func Test_request_chunked_trailer(t *testing.T) {
+	raw := "POST /upload HTTP/1.1\r\n" +
+		"Host: example.com\r\n" +
+		"Transfer-Encoding: chunked\r\n" +
+		"\r\n" +
+		"3\r\nfoo\r\n" +
+		"0\r\n" +
+		"X-Checksum: abcde\r\n" +
+		"\r\n"
+
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+
+	if err := req.Read(bufio.NewReader(bytes.NewBufferString(raw))); err != nil {
+		t.Fatalf("read chunked request with trailer failed: %v", err)
+	}
+	if string(req.Body()) != "foo" {
+		t.Errorf("unexpected body: %q", req.Body())
+	}
+}
@@ -55,6 +55,18 @@ func (spanBuilder *SpanBuilder) BuildSpan(args ...interface{}) types.Span {
 	span := trace.Tracer().Start(time.Now())
 
 	traceId := string(request.Header.Peek(models.TRACER_ID_KEY))
+	if traceId == "" {
+		// fallback to B3 propagation headers
+		traceId = string(request.Header.Peek(trace.B3TraceID))
+	}
+	if sw8 := string(request.Header.Peek(trace.Sw8Header)); sw8 != "" {
+		// fallback to the SkyWalking sw8 propagation header
+		span.SetTag(trace.SW8_PARENT_CONTEXT, sw8)
+		if swTraceId, _, swParentSpanId := trace.DecodeSw8(sw8); traceId == "" && swTraceId != "" {
+			traceId = swTraceId
+			span.SetTag(trace.PARENT_SPAN_ID, swParentSpanId)
+		}
+	}
 	if traceId == "" {
 		traceId = trace.IdGen().GenerateTraceId()
 	}
@@ -62,6 +74,12 @@ func (spanBuilder *SpanBuilder) BuildSpan(args ...interface{}) types.Span {
 	lType := mosnctx.Get(ctx, types.ContextKeyListenerType)
 
 	spanId := string(request.Header.Peek(models.RPC_ID_KEY))
+	if spanId == "" {
+		spanId = string(request.Header.Peek(trace.B3SpanID))
+	}
+	if parentSpanId := string(request.Header.Peek(trace.B3ParentSpanID)); parentSpanId != "" {
+		span.SetTag(trace.PARENT_SPAN_ID, parentSpanId)
+	}
 	if spanId == "" {
 		spanId = "0" // Generate a new span id
 	} else {
@@ -23,6 +23,8 @@ import (
 	"sync/atomic"
 
 	mosnctx "sofastack.io/sofa-mosn/pkg/context"
+	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/mtls"
 	"sofastack.io/sofa-mosn/pkg/network"
 	"sofastack.io/sofa-mosn/pkg/protocol"
 	str "sofastack.io/sofa-mosn/pkg/stream"
@@ -77,6 +79,16 @@ func (p *connPool) NewStream(ctx context.Context,
 		return
 	}
 
+	// the configured stream cap guards the multiplexed connection, requests
+	// beyond it overflow instead of queueing behind the window
+	if h2 := p.host.ClusterInfo().Http2Settings(); h2 != nil && h2.MaxConcurrentStreams > 0 &&
+		uint32(activeClient.client.ActiveRequestsNum()) >= h2.MaxConcurrentStreams {
+		listener.OnFailure(types.Overflow, p.host)
+		p.host.HostStats().UpstreamRequestPendingOverflow.Inc(1)
+		p.host.ClusterInfo().Stats().UpstreamRequestPendingOverflow.Inc(1)
+		return
+	}
+
 	if !p.host.ClusterInfo().ResourceManager().Requests().CanCreate() {
 		listener.OnFailure(types.Overflow, p.host)
 		p.host.HostStats().UpstreamRequestPendingOverflow.Inc(1)
@@ -119,12 +131,16 @@ func (p *connPool) onConnectionEvent(client *activeClient, event types.Connectio
 	} else if event == types.ConnectTimeout {
 		p.host.HostStats().UpstreamRequestTimeout.Inc(1)
 		p.host.ClusterInfo().Stats().UpstreamRequestTimeout.Inc(1)
+		network.ReportConnectFailure(p.host.AddressString())
 		client.client.Close()
 		p.activeClient = nil
 	} else if event == types.ConnectFailed {
 		p.host.HostStats().UpstreamConnectionConFail.Inc(1)
 		p.host.ClusterInfo().Stats().UpstreamConnectionConFail.Inc(1)
+		network.ReportConnectFailure(p.host.AddressString())
 		p.activeClient = nil
+	} else if event == types.Connected {
+		network.ReportConnectSuccess(p.host.AddressString())
 	}
 }
 
@@ -175,7 +191,21 @@ func newActiveClient(ctx context.Context, pool *connPool) *activeClient {
 		return nil
 	}
 
+	// a tls upstream that negotiated an application protocol other than h2
+	// must not be sent the h2 preface (RFC 7540, section 3.3), cleartext
+	// connections use prior knowledge and skip this check
+	if tlsConn, ok := data.Connection.RawConn().(*mtls.TLSConn); ok {
+		if alpn := tlsConn.ConnectionState().NegotiatedProtocol; alpn != "" && alpn != "h2" {
+			log.DefaultLogger.Errorf("[stream] [http2] upstream %s negotiated alpn %q, not h2", pool.host.AddressString(), alpn)
+			data.Connection.Close(types.NoFlush, types.LocalClose)
+			return nil
+		}
+	}
+
 	connCtx := mosnctx.WithValue(context.Background(), types.ContextKeyConnectionID, data.Connection.ID())
+	if h2 := pool.host.ClusterInfo().Http2Settings(); h2 != nil {
+		connCtx = mosnctx.WithValue(connCtx, types.ContextKeyUpstreamHttp2Settings, h2)
+	}
 	codecClient := pool.createStreamClient(connCtx, data)
 	codecClient.AddConnectionEventListener(ac)
 	codecClient.SetStreamConnectionEventListener(ac)
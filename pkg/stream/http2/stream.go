@@ -27,7 +27,9 @@ import (
 	"reflect"
 	"strconv"
 	"sync"
+	"time"
 
+	"sofastack.io/sofa-mosn/pkg/api/v2"
 	"sofastack.io/sofa-mosn/pkg/buffer"
 	mosnctx "sofastack.io/sofa-mosn/pkg/context"
 	"sofastack.io/sofa-mosn/pkg/log"
@@ -37,6 +39,7 @@ import (
 	mhttp2 "sofastack.io/sofa-mosn/pkg/protocol/http2"
 	str "sofastack.io/sofa-mosn/pkg/stream"
 	"sofastack.io/sofa-mosn/pkg/types"
+	"sofastack.io/sofa-mosn/pkg/utils"
 )
 
 func init() {
@@ -102,6 +105,12 @@ func (conn *streamConnection) GoAway() {
 	// todo
 }
 
+// GoAway tells the client to stop creating streams on this connection,
+// used when the connection drains during a smooth upgrade
+func (conn *serverStreamConnection) GoAway() {
+	conn.sc.GoAway()
+}
+
 // types.Stream
 // types.StreamSender
 type stream struct {
@@ -185,6 +194,8 @@ func newServerStreamConnection(ctx context.Context, connection types.Connection,
 	sc.conn.SetTransferEventListener(func() bool {
 		return false
 	})
+	// send GOAWAY and drain in-flight streams during a smooth upgrade
+	sc.conn.SetGracefulDrainListener(sc.GoAway)
 
 	sc.streams = make(map[uint32]*serverStream, 32)
 	log.DefaultLogger.Tracef("new http2 server stream connection")
@@ -492,12 +503,26 @@ type clientStreamConnection struct {
 	streams                       map[uint32]*clientStream
 	mClientConn                   *http2.MClientConn
 	streamConnectionEventListener types.StreamConnectionEventListener
+	keepaliveStop                 chan struct{}
+	keepaliveStopOnce             sync.Once
 }
 
 func newClientStreamConnection(ctx context.Context, connection types.Connection,
 	clientCallbacks types.StreamConnectionEventListener) types.ClientStreamConnection {
 
-	h2cc := http2.NewClientConn(connection)
+	var opts *http2.ConnSettings
+	var keepaliveInterval, keepaliveTimeout time.Duration
+	if settings, ok := mosnctx.Get(ctx, types.ContextKeyUpstreamHttp2Settings).(*v2.Http2Settings); ok {
+		opts = &http2.ConnSettings{
+			MaxConcurrentStreams:    settings.MaxConcurrentStreams,
+			InitialStreamWindowSize: settings.InitialStreamWindowSize,
+			InitialConnWindowSize:   settings.InitialConnWindowSize,
+		}
+		keepaliveInterval = settings.KeepaliveInterval.Duration
+		keepaliveTimeout = settings.KeepaliveTimeout.Duration
+	}
+
+	h2cc := http2.NewClientConnWithSettings(connection, opts)
 
 	sc := &clientStreamConnection{
 		streamConnection: streamConnection{
@@ -515,10 +540,56 @@ func newClientStreamConnection(ctx context.Context, connection types.Connection,
 	sc.cm.Next()
 
 	sc.streams = make(map[uint32]*clientStream, 32)
+
+	if keepaliveInterval > 0 {
+		sc.keepaliveStop = make(chan struct{})
+		connection.AddConnectionEventListener(sc)
+		utils.GoWithRecover(func() {
+			sc.keepalive(keepaliveInterval, keepaliveTimeout)
+		}, nil)
+	}
+
 	log.DefaultLogger.Tracef("new http2 client stream connection")
 	return sc
 }
 
+// OnEvent stops the keepalive loop once the connection goes away
+func (conn *clientStreamConnection) OnEvent(event types.ConnectionEvent) {
+	if event.IsClose() {
+		conn.keepaliveStopOnce.Do(func() {
+			close(conn.keepaliveStop)
+		})
+	}
+}
+
+// keepalive periodically pings the upstream so a dead connection is detected
+// and replaced even while no streams are in flight
+func (conn *clientStreamConnection) keepalive(interval, timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = interval
+	}
+	for {
+		select {
+		case <-conn.keepaliveStop:
+			return
+		case <-time.After(interval):
+		}
+		ack, err := conn.mClientConn.Ping()
+		if err != nil {
+			return
+		}
+		select {
+		case <-ack:
+		case <-conn.keepaliveStop:
+			return
+		case <-time.After(timeout):
+			log.DefaultLogger.Errorf("[stream] [http2] keepalive ping timeout, close connection %d", conn.conn.ID())
+			conn.conn.Close(types.NoFlush, types.LocalClose)
+			return
+		}
+	}
+}
+
 // types.StreamConnection
 func (conn *clientStreamConnection) Dispatch(buf types.IoBuffer) {
 	for {
@@ -0,0 +1,106 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stream
+
+import (
+	"bytes"
+	"context"
+
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+// DefaultMatchPriority is the priority stream factories get when they are
+// registered without an explicit one
+const DefaultMatchPriority = 0
+
+// ProtocolMatch reports whether the peeked bytes belong to the protocol,
+// it returns nil on a match, EAGAIN when more bytes are needed to decide
+// and FAILED when the bytes can not be this protocol
+type ProtocolMatch func(ctx context.Context, prot string, magic []byte) error
+
+type protocolMatcher struct {
+	prot     types.Protocol
+	priority int
+	match    ProtocolMatch
+}
+
+// matchers are kept sorted by descending priority, ties keep registration order
+var protocolMatchers []protocolMatcher
+
+// RegisterProtocolMatcher adds a matcher for prot to the Auto protocol sniffing,
+// matchers with a higher priority are tried first. Registering the same protocol
+// again replaces its matcher, so a protocol can upgrade the default one its
+// stream factory registration installed.
+func RegisterProtocolMatcher(prot types.Protocol, priority int, match ProtocolMatch) {
+	for i, matcher := range protocolMatchers {
+		if matcher.prot == prot {
+			protocolMatchers = append(protocolMatchers[:i], protocolMatchers[i+1:]...)
+			break
+		}
+	}
+	pos := len(protocolMatchers)
+	for i, matcher := range protocolMatchers {
+		if priority > matcher.priority {
+			pos = i
+			break
+		}
+	}
+	protocolMatchers = append(protocolMatchers, protocolMatcher{})
+	copy(protocolMatchers[pos+1:], protocolMatchers[pos:])
+	protocolMatchers[pos] = protocolMatcher{
+		prot:     prot,
+		priority: priority,
+		match:    match,
+	}
+}
+
+// MagicBytesMatcher returns a matcher for protocols that are identified by a
+// fixed magic prefix, which covers most binary protocols (Dubbo, Thrift, Redis)
+func MagicBytesMatcher(magic []byte) ProtocolMatch {
+	return func(ctx context.Context, prot string, peek []byte) error {
+		if len(peek) < len(magic) {
+			if bytes.HasPrefix(magic, peek) {
+				return EAGAIN
+			}
+			return FAILED
+		}
+		if bytes.HasPrefix(peek, magic) {
+			return nil
+		}
+		return FAILED
+	}
+}
+
+// SelectStreamFactoryProtocol sniffs the protocol of a connection from its
+// first bytes, trying the registered matchers in priority order
+func SelectStreamFactoryProtocol(ctx context.Context, prot string, peek []byte) (types.Protocol, error) {
+	var err error
+	var again bool
+	for _, matcher := range protocolMatchers {
+		err = matcher.match(ctx, prot, peek)
+		if err == nil {
+			return matcher.prot, nil
+		} else if err == EAGAIN {
+			again = true
+		}
+	}
+	if again {
+		return "", EAGAIN
+	}
+	return "", FAILED
+}
@@ -0,0 +1,93 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stream
+
+import (
+	"context"
+	"testing"
+
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+func resetProtocolMatchers() {
+	protocolMatchers = nil
+}
+
+func TestMagicBytesMatcher(t *testing.T) {
+	match := MagicBytesMatcher([]byte("*1\r\n"))
+	testcases := []struct {
+		peek     string
+		expected error
+	}{
+		{"*1\r\n$4\r\nPING\r\n", nil},
+		{"*1\r\n", nil},
+		{"*1", EAGAIN},
+		{"", EAGAIN},
+		{"GET / HTTP/1.1", FAILED},
+		{"*2\r\n", FAILED},
+	}
+	for i, tc := range testcases {
+		if err := match(context.Background(), "", []byte(tc.peek)); err != tc.expected {
+			t.Errorf("#%d match %q got %v, expected %v", i, tc.peek, err, tc.expected)
+		}
+	}
+}
+
+func TestSelectStreamFactoryProtocolPriority(t *testing.T) {
+	defer resetProtocolMatchers()
+	resetProtocolMatchers()
+	// both matchers accept the same magic, the higher priority wins
+	// regardless of registration order
+	magic := []byte("magic")
+	RegisterProtocolMatcher(types.Protocol("low"), 0, MagicBytesMatcher(magic))
+	RegisterProtocolMatcher(types.Protocol("high"), 10, MagicBytesMatcher(magic))
+	prot, err := SelectStreamFactoryProtocol(context.Background(), "", magic)
+	if err != nil {
+		t.Fatalf("select failed: %v", err)
+	}
+	if prot != types.Protocol("high") {
+		t.Errorf("expected the high priority protocol, got %v", prot)
+	}
+	// re-register replaces a protocol's matcher instead of duplicating it
+	RegisterProtocolMatcher(types.Protocol("high"), -1, MagicBytesMatcher(magic))
+	if len(protocolMatchers) != 2 {
+		t.Fatalf("expected 2 matchers after re-register, got %d", len(protocolMatchers))
+	}
+	if prot, _ := SelectStreamFactoryProtocol(context.Background(), "", magic); prot != types.Protocol("low") {
+		t.Errorf("expected the low protocol after demotion, got %v", prot)
+	}
+}
+
+func TestSelectStreamFactoryProtocolErrors(t *testing.T) {
+	defer resetProtocolMatchers()
+	resetProtocolMatchers()
+	RegisterProtocolMatcher(types.Protocol("dubbo"), 0, MagicBytesMatcher([]byte{0xda, 0xbb}))
+	RegisterProtocolMatcher(types.Protocol("redis"), 0, MagicBytesMatcher([]byte("*")))
+	// no matcher claims the bytes
+	if _, err := SelectStreamFactoryProtocol(context.Background(), "", []byte("PUT")); err != FAILED {
+		t.Errorf("expected FAILED, got %v", err)
+	}
+	// one matcher needs more bytes
+	if _, err := SelectStreamFactoryProtocol(context.Background(), "", []byte{0xda}); err != EAGAIN {
+		t.Errorf("expected EAGAIN, got %v", err)
+	}
+	// a full magic matches
+	if prot, err := SelectStreamFactoryProtocol(context.Background(), "", []byte{0xda, 0xbb, 0x00}); err != nil || prot != types.Protocol("dubbo") {
+		t.Errorf("expected dubbo, got %v, %v", prot, err)
+	}
+}
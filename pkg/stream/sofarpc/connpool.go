@@ -19,6 +19,7 @@ package sofarpc
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -53,6 +54,9 @@ type connPool struct {
 	activeClients sync.Map //sub protocol -> activeClient
 	host          types.Host
 
+	// lastError keeps the most recent connect failure for introspection
+	lastError atomic.Value // string
+
 	mux sync.Mutex
 }
 
@@ -61,6 +65,7 @@ func NewConnPool(host types.Host) types.ConnectionPool {
 	p := &connPool{
 		host: host,
 	}
+	boltConnPools.Store(p, struct{}{})
 	return p
 }
 
@@ -158,6 +163,7 @@ func (p *connPool) NewStream(ctx context.Context,
 }
 
 func (p *connPool) Close() {
+	boltConnPools.Delete(p)
 	f := func(k, v interface{}) bool {
 		ac, _ := v.(*activeClient)
 		// fakeclient
@@ -208,11 +214,58 @@ func (p *connPool) onConnectionEvent(client *activeClient, event types.Connectio
 	} else if event == types.ConnectTimeout {
 		p.host.HostStats().UpstreamRequestTimeout.Inc(1)
 		p.host.ClusterInfo().Stats().UpstreamRequestTimeout.Inc(1)
+		network.ReportConnectFailure(p.host.AddressString())
+		p.recordError("connect timeout")
 		client.client.Close()
 	} else if event == types.ConnectFailed {
 		p.host.HostStats().UpstreamConnectionConFail.Inc(1)
 		p.host.ClusterInfo().Stats().UpstreamConnectionConFail.Inc(1)
+		network.ReportConnectFailure(p.host.AddressString())
+		p.recordError("connect failed")
+	} else if event == types.Connected {
+		network.ReportConnectSuccess(p.host.AddressString())
+	}
+}
+
+func (p *connPool) recordError(reason string) {
+	p.lastError.Store(fmt.Sprintf("%s at %s", reason, time.Now().Format("2006-01-02 15:04:05.000")))
+}
+
+// PoolStatus implements types.PoolStatusProvider
+func (p *connPool) PoolStatus() types.PoolStatus {
+	status := types.PoolStatus{
+		Protocol:    string(protocol.SofaRPC),
+		Connections: make([]types.PoolConnectionStatus, 0, 4),
 	}
+	if v := p.lastError.Load(); v != nil {
+		status.LastError = v.(string)
+	}
+	p.activeClients.Range(func(k, v interface{}) bool {
+		ac, _ := v.(*activeClient)
+		if ac == nil {
+			return true
+		}
+		connStatus := types.PoolConnectionStatus{
+			TotalStreams: atomic.LoadUint64(&ac.totalStream),
+		}
+		switch atomic.LoadUint32(&ac.state) {
+		case Init:
+			connStatus.State = "init"
+		case Connecting:
+			connStatus.State = "connecting"
+		case Connected:
+			connStatus.State = "connected"
+		}
+		if ac.client != nil {
+			connStatus.ActiveStreams = ac.client.ActiveRequestsNum()
+		}
+		if !ac.createdAt.IsZero() {
+			connStatus.Age = time.Since(ac.createdAt).String()
+		}
+		status.Connections = append(status.Connections, connStatus)
+		return true
+	})
+	return status
 }
 
 func (p *connPool) onStreamDestroy(client *activeClient) {
@@ -262,12 +315,14 @@ type activeClient struct {
 	closeWithActiveReq bool
 	totalStream        uint64
 	state              uint32
+	createdAt          time.Time
 }
 
 func newActiveClient(ctx context.Context, subProtocol byte, pool *connPool) *activeClient {
 	ac := &activeClient{
 		subProtocol: subProtocol,
 		pool:        pool,
+		createdAt:   time.Now(),
 	}
 
 	data := pool.host.CreateConnection(ctx)
@@ -93,6 +93,7 @@ type streamConnection struct {
 	mutex                               sync.RWMutex
 	currStreamID                        uint64
 	streams                             map[uint64]*stream // client conn fields
+	serverStreams                       map[uint64]byte    // in-flight server request id -> protocol code
 	codecEngine                         types.ProtocolEngine
 	streamConnectionEventListener       types.StreamConnectionEventListener
 	serverStreamConnectionEventListener types.ServerStreamConnectionEventListener
@@ -118,6 +119,13 @@ func newStreamConnection(ctx context.Context, connection types.Connection, clien
 		sc.streams = make(map[uint64]*stream, 32)
 	}
 
+	if sc.serverStreamConnectionEventListener != nil {
+		sc.serverStreams = make(map[uint64]byte, 32)
+		// answer in-flight requests before a graceful close, the bolt
+		// equivalent of a http2 goaway
+		sc.conn.SetGracefulDrainListener(sc.GoAway)
+	}
+
 	// set support transfer connection
 	sc.conn.SetTransferEventListener(func() bool {
 		return true
@@ -154,8 +162,37 @@ func (conn *streamConnection) Protocol() types.Protocol {
 	return protocol.SofaRPC
 }
 
+// GoAway answers every in-flight request with a CONNECTION_CLOSED error
+// response, so clients distinguish a graceful drain from a failure.
+// Bolt has no dedicated goaway frame, the per-request error response is
+// the strongest signal the protocol offers
 func (conn *streamConnection) GoAway() {
-	// unsupported
+	if conn.serverStreams == nil {
+		return
+	}
+
+	conn.mutex.Lock()
+	inflight := conn.serverStreams
+	conn.serverStreams = make(map[uint64]byte)
+	conn.mutex.Unlock()
+
+	for id, protocolCode := range inflight {
+		resp := sofarpc.NewResponse(protocolCode, sofarpc.RESPONSE_STATUS_CONNECTION_CLOSED)
+		if resp == nil {
+			continue
+		}
+		resp.SetRequestID(id)
+		buf, err := conn.codecEngine.Encode(conn.ctx, resp)
+		if err != nil {
+			log.Proxy.Errorf(conn.ctx, "[stream] [sofarpc] goaway encode error, requestId = %d: %v", id, err)
+			continue
+		}
+		conn.conn.Write(buf)
+
+		if log.Proxy.GetLogLevel() >= log.INFO {
+			log.Proxy.Infof(conn.ctx, "[stream] [sofarpc] goaway sent connection closed response, requestId = %d", id)
+		}
+	}
 }
 
 func (conn *streamConnection) ActiveStreamsNum() int {
@@ -280,6 +317,11 @@ func (conn *streamConnection) onNewStreamDetect(ctx context.Context, cmd sofarpc
 		stream.receiver = conn.serverStreamConnectionEventListener.NewStreamDetect(stream.ctx, nil, span)
 	} else {
 		stream.receiver = conn.serverStreamConnectionEventListener.NewStreamDetect(stream.ctx, stream, span)
+
+		// track the in-flight request for a graceful goaway
+		conn.mutex.Lock()
+		conn.serverStreams[stream.id] = cmd.ProtocolCode()
+		conn.mutex.Unlock()
 	}
 
 	return stream
@@ -347,13 +389,19 @@ func (s *stream) AppendHeaders(ctx context.Context, headers types.HeaderMap, end
 
 	switch s.direction {
 	case ClientStream:
-		// use origin request from downstream
-		s.sendCmd = cmd
+		// use origin request from downstream, legacy TR requests are
+		// bridged to bolt since upstreams no longer speak TR
+		if trReq, ok := cmd.(*sofarpc.TRRequest); ok {
+			s.sendCmd = sofarpc.TRRequestToBolt(trReq)
+		} else {
+			s.sendCmd = cmd
+		}
 	case ServerStream:
 		switch cmd.CommandType() {
 		case sofarpc.RESPONSE:
-			// use origin response from upstream
-			s.sendCmd = cmd
+			// use origin response from upstream, translated back to TR
+			// when the downstream request came in speaking TR
+			s.sendCmd = s.translateResponse(cmd)
 		case sofarpc.REQUEST, sofarpc.REQUEST_ONEWAY:
 			// the command type is request, indicates the invocation is under hijack scene
 			s.sendCmd, err = s.buildHijackResp(cmd)
@@ -371,6 +419,24 @@ func (s *stream) AppendHeaders(ctx context.Context, headers types.HeaderMap, end
 	return err
 }
 
+// translateResponse converts a bolt response back to TR when the
+// downstream spoke TR, the reverse of the request bridging done on the
+// client stream. The downstream protocol is looked up from the in-flight
+// request bookkeeping
+func (s *stream) translateResponse(cmd sofarpc.SofaRpcCmd) sofarpc.SofaRpcCmd {
+	s.sc.mutex.RLock()
+	downstreamProto := s.sc.serverStreams[s.id]
+	s.sc.mutex.RUnlock()
+
+	if downstreamProto != sofarpc.PROTOCOL_CODE_TR {
+		return cmd
+	}
+	if resp, ok := cmd.(*sofarpc.BoltResponse); ok {
+		return sofarpc.BoltResponseToTR(resp)
+	}
+	return cmd
+}
+
 func (s *stream) buildHijackResp(request sofarpc.SofaRpcCmd) (sofarpc.SofaRpcCmd, error) {
 	if status, ok := request.Get(types.HeaderStatus); ok {
 		request.Del(types.HeaderStatus)
@@ -415,6 +481,9 @@ func (s *stream) AppendTrailers(context context.Context, trailers types.HeaderMa
 func (s *stream) endStream() {
 	defer func() {
 		if s.direction == ServerStream {
+			s.sc.mutex.Lock()
+			delete(s.sc.serverStreams, s.id)
+			s.sc.mutex.Unlock()
 			s.DestroyStream()
 		}
 	}()
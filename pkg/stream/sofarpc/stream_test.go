@@ -0,0 +1,98 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sofarpc
+
+import (
+	"context"
+	"testing"
+
+	"sofastack.io/sofa-mosn/pkg/buffer"
+	"sofastack.io/sofa-mosn/pkg/protocol/rpc/sofarpc"
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+// drainConn records written buffers, the rest of types.Connection is unused
+type drainConn struct {
+	types.Connection
+	written types.IoBuffer
+}
+
+func (c *drainConn) Write(buffers ...types.IoBuffer) error {
+	for _, buf := range buffers {
+		c.written.Write(buf.Bytes())
+	}
+	return nil
+}
+
+func (c *drainConn) SetTransferEventListener(listener func() bool) {}
+
+func (c *drainConn) SetGracefulDrainListener(listener func()) {}
+
+func TestGoAwayAnswersInflightRequests(t *testing.T) {
+	rawc := &drainConn{written: buffer.NewIoBuffer(1024)}
+	sc := newStreamConnection(context.Background(), rawc, nil, &mockServerConnListener{}).(*streamConnection)
+
+	sc.mutex.Lock()
+	sc.serverStreams[7] = sofarpc.PROTOCOL_CODE_V1
+	sc.serverStreams[9] = sofarpc.PROTOCOL_CODE_V1
+	sc.mutex.Unlock()
+
+	sc.GoAway()
+
+	engine := sofarpc.Engine()
+	seen := map[uint64]bool{}
+	for rawc.written.Len() > 0 {
+		cmd, err := engine.Decode(context.Background(), rawc.written)
+		if err != nil || cmd == nil {
+			t.Fatalf("decode goaway response failed: %v", err)
+		}
+		resp, ok := cmd.(*sofarpc.BoltResponse)
+		if !ok {
+			t.Fatalf("unexpected goaway command: %T", cmd)
+		}
+		if resp.ResponseStatus != sofarpc.RESPONSE_STATUS_CONNECTION_CLOSED {
+			t.Errorf("unexpected response status: %d", resp.ResponseStatus)
+		}
+		seen[resp.RequestID()] = true
+	}
+	if !seen[7] || !seen[9] {
+		t.Errorf("in-flight requests not answered: %v", seen)
+	}
+
+	sc.mutex.RLock()
+	remaining := len(sc.serverStreams)
+	sc.mutex.RUnlock()
+	if remaining != 0 {
+		t.Errorf("in-flight streams not cleared after goaway: %d", remaining)
+	}
+
+	// a second goaway must not answer anything again
+	sc.GoAway()
+	if rawc.written.Len() != 0 {
+		t.Error("goaway answered requests twice")
+	}
+}
+
+// mockServerConnListener accepts every new stream without handling it
+type mockServerConnListener struct{}
+
+func (m *mockServerConnListener) NewStreamDetect(ctx context.Context, sender types.StreamSender, span types.Span) types.StreamReceiveListener {
+	return nil
+}
+
+func (m *mockServerConnListener) OnGoAway() {}
@@ -0,0 +1,73 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sofarpc
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/network"
+)
+
+// boltConnPools tracks the live bolt pools so the old process can hand
+// their idle connections to the new process during hot upgrade
+var boltConnPools sync.Map
+
+func init() {
+	network.RegisterUpstreamTransferFunc(transferIdleConnections)
+}
+
+// transferIdleConnections sends the FD of every idle pooled bolt connection
+// to the new process, a connection with active streams stays and drains as
+// usual. Idle bolt connections carry no partial frame state so only the FD
+// has to move
+func transferIdleConnections() {
+	boltConnPools.Range(func(k, _ interface{}) bool {
+		p := k.(*connPool)
+		p.mux.Lock()
+		defer p.mux.Unlock()
+		p.activeClients.Range(func(sub, v interface{}) bool {
+			ac, _ := v.(*activeClient)
+			if ac == nil || ac.client == nil {
+				return true
+			}
+			if atomic.LoadUint32(&ac.state) != Connected {
+				return true
+			}
+			if ac.client.ActiveRequestsNum() != 0 {
+				return true
+			}
+			rawc := ac.host.Connection.RawConn()
+			if rawc == nil {
+				return true
+			}
+			if err := network.TransferUpstreamConn(rawc); err != nil {
+				log.DefaultLogger.Errorf("[stream] [sofarpc] transfer idle connection to %s failed: %v",
+					p.host.AddressString(), err)
+				return true
+			}
+			// the new process owns the connection now, drop it from the pool
+			// without closing the socket
+			p.activeClients.Delete(sub)
+			log.DefaultLogger.Infof("[stream] [sofarpc] transferred idle connection to %s", p.host.AddressString())
+			return true
+		})
+		return true
+	})
+}
@@ -136,10 +136,14 @@ func (p *connPool) onConnectionEvent(client *activeClient, event types.Connectio
 	} else if event == types.ConnectTimeout {
 		p.host.HostStats().UpstreamRequestTimeout.Inc(1)
 		p.host.ClusterInfo().Stats().UpstreamRequestTimeout.Inc(1)
+		network.ReportConnectFailure(p.host.AddressString())
 		client.client.Close()
 	} else if event == types.ConnectFailed {
 		p.host.HostStats().UpstreamConnectionConFail.Inc(1)
 		p.host.ClusterInfo().Stats().UpstreamConnectionConFail.Inc(1)
+		network.ReportConnectFailure(p.host.AddressString())
+	} else if event == types.Connected {
+		network.ReportConnectSuccess(p.host.AddressString())
 	}
 }
 
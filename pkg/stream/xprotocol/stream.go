@@ -119,6 +119,17 @@ func (conn *streamConnection) Dispatch(buffer types.IoBuffer) {
 	// get sub protocol codec
 	requestList := conn.codec.SplitFrame(buffer.Bytes())
 	for _, request := range requestList {
+		// protocol level heartbeats are answered locally on the server side,
+		// they never occupy an upstream stream
+		if hbCodec, ok := conn.codec.(xprotocol.HeartbeatHandler); ok && conn.serverStreamConnectionEventListener != nil && hbCodec.IsHeartbeatFrame(request) {
+			log.DefaultLogger.Tracef("xprotocol handle heartbeat frame locally, len = %d", len(request))
+			if resp := hbCodec.HeartbeatResponseFrame(request); resp != nil {
+				conn.connection.Write(networkbuffer.NewIoBufferBytes(resp))
+			}
+			buffer.Drain(len(request))
+			continue
+		}
+
 		headers := make(map[string]string)
 		// support dynamic route
 		headers[strings.ToLower(protocol.MosnHeaderHostKey)] = conn.connection.RemoteAddr().String()
@@ -58,4 +58,8 @@ type WorkerPool interface {
 	ScheduleAlways(task func())
 
 	ScheduleAuto(task func())
+
+	// TrySchedule tries to acquire a pooled worker goroutine to execute the specified task,
+	// returns false without executing the task if no worker goroutine is available
+	TrySchedule(task func()) bool
 }
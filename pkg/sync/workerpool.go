@@ -154,6 +154,18 @@ func (p *workerPool) ScheduleAuto(task func()) {
 	}
 }
 
+func (p *workerPool) TrySchedule(task func()) bool {
+	select {
+	case p.work <- task:
+		return true
+	case p.sem <- struct{}{}:
+		go p.spawnWorker(task)
+		return true
+	default:
+		return false
+	}
+}
+
 func (p *workerPool) spawnWorker(task func()) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -237,6 +237,37 @@ func BenchmarkUnboundChannel(b *testing.B) {
 	}
 }
 
+func TestTrySchedule(t *testing.T) {
+	pool := NewWorkerPool(2)
+	block := make(chan struct{})
+	done := make(chan struct{})
+
+	// saturate both workers
+	for i := 0; i < 2; i++ {
+		if !pool.TrySchedule(func() {
+			<-block
+		}) {
+			t.Fatal("TrySchedule should succeed while workers are available")
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	if pool.TrySchedule(func() {}) {
+		t.Error("TrySchedule should fail when the pool is saturated")
+	}
+
+	close(block)
+	time.Sleep(10 * time.Millisecond)
+	if !pool.TrySchedule(func() { close(done) }) {
+		t.Error("TrySchedule should succeed after workers are released")
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("scheduled task did not run")
+	}
+}
+
 func TestScheduleAuto(t *testing.T) {
 	size := 5
 	pool := NewWorkerPool(size)
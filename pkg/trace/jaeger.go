@@ -0,0 +1,177 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trace
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+func init() {
+	RegisterTracerBuilder("Jaeger", newJaegerTracer)
+}
+
+// JaegerEndpoint is where finished spans are reported. An http:// endpoint
+// selects the collector mode (thrift batch POST to /api/traces), any other
+// value is used as the agent UDP address (binary thrift emitBatch).
+// Configured via the tracing section of the mosn config.
+var JaegerEndpoint = "127.0.0.1:6832"
+
+// SetJaegerEndpoint sets the jaeger agent address or collector endpoint
+func SetJaegerEndpoint(endpoint string) {
+	JaegerEndpoint = endpoint
+}
+
+const jaegerServiceName = "mosn"
+
+// -------- JaegerSpan --------
+
+// JaegerSpan is a types.Span reported to a jaeger agent or collector
+type JaegerSpan struct {
+	tracer        *JaegerTracer
+	startTime     time.Time
+	endTime       time.Time
+	tags          [TRACE_END]string
+	traceId       string
+	spanId        string
+	parentSpanId  string
+	operationName string
+	sampled       bool
+}
+
+func (s *JaegerSpan) TraceId() string {
+	return s.traceId
+}
+
+func (s *JaegerSpan) SpanId() string {
+	return s.spanId
+}
+
+func (s *JaegerSpan) ParentSpanId() string {
+	return s.parentSpanId
+}
+
+func (s *JaegerSpan) SetOperation(operation string) {
+	s.operationName = operation
+}
+
+func (s *JaegerSpan) SetTag(key uint64, value string) {
+	if key == TRACE_ID {
+		s.traceId = value
+	} else if key == SPAN_ID {
+		s.spanId = value
+	} else if key == PARENT_SPAN_ID {
+		s.parentSpanId = value
+	}
+
+	s.tags[key] = value
+}
+
+func (s *JaegerSpan) SetRequestInfo(reqinfo types.RequestInfo) {
+}
+
+func (s *JaegerSpan) Tag(key uint64) string {
+	return s.tags[key]
+}
+
+func (s *JaegerSpan) FinishSpan() {
+	s.endTime = time.Now()
+	s.tracer.PrintSpan(s)
+}
+
+// InjectContext propagates the span context in the uber-trace-id header
+// format: traceId:spanId:parentSpanId:flags
+func (s *JaegerSpan) InjectContext(requestHeaders map[string]string) {
+	flags := "0"
+	if s.sampled {
+		flags = "1"
+	}
+	requestHeaders["uber-trace-id"] = strings.Join([]string{s.traceId, s.spanId, s.parentSpanId, flags}, ":")
+}
+
+func (s *JaegerSpan) SpawnChild(operationName string, startTime time.Time) types.Span {
+	return nil
+}
+
+func (s *JaegerSpan) SetStartTime(startTime time.Time) {
+	s.startTime = startTime
+}
+
+func (s *JaegerSpan) EndTime() time.Time {
+	return s.endTime
+}
+
+func (s *JaegerSpan) StartTime() time.Time {
+	return s.startTime
+}
+
+// -------- JaegerTracer --------
+
+// JaegerTracer reports finished spans in jaeger.thrift format, either to
+// the jaeger agent over UDP or to the collector over HTTP
+type JaegerTracer struct {
+	reporter *jaegerReporter
+}
+
+func newJaegerTracer() types.Tracer {
+	return &JaegerTracer{
+		reporter: newJaegerReporter(JaegerEndpoint),
+	}
+}
+
+func (tracer *JaegerTracer) Start(startTime time.Time) types.Span {
+	return &JaegerSpan{
+		tracer:    tracer,
+		startTime: startTime,
+		sampled:   IsSampled(),
+	}
+}
+
+func (tracer *JaegerTracer) PrintSpan(spanP types.Span) error {
+	span, ok := spanP.(*JaegerSpan)
+	if !ok || !span.sampled {
+		return nil
+	}
+	return tracer.reporter.report(span)
+}
+
+// jaegerIdToInt64 converts a hex trace/span id into the int64 the
+// jaeger.thrift model uses; ids longer than 16 hex chars keep the low 64
+// bits, non-hex ids (e.g. sofa trace ids) are hashed
+func jaegerIdToInt64(id string) int64 {
+	if id == "" {
+		return 0
+	}
+	hexPart := id
+	if len(hexPart) > 16 {
+		hexPart = hexPart[len(hexPart)-16:]
+	}
+	if v, err := strconv.ParseUint(hexPart, 16, 64); err == nil {
+		return int64(v)
+	}
+	// FNV-1a over the raw id
+	var hash uint64 = 14695981039346656037
+	for i := 0; i < len(id); i++ {
+		hash ^= uint64(id[i])
+		hash *= 1099511628211
+	}
+	return int64(hash)
+}
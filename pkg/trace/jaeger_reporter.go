@@ -0,0 +1,189 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trace
+
+import (
+	"bytes"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/types"
+	"sofastack.io/sofa-mosn/pkg/utils"
+)
+
+const (
+	jaegerSpanChanSize  = 1000
+	jaegerBatchSize     = 100
+	jaegerFlushInterval = time.Second
+	jaegerReportTimeout = 5 * time.Second
+
+	jaegerCollectorPath = "/api/traces"
+	jaegerThriftMime    = "application/vnd.apache.thrift.binary"
+
+	// keep emitBatch datagrams under the usual 65000 byte UDP limit
+	jaegerAgentBatchSize = 20
+)
+
+// jaegerReporter batches finished spans and reports them in jaeger.thrift
+// format, over UDP to the agent or over HTTP to the collector
+type jaegerReporter struct {
+	endpoint  string
+	collector bool
+	client    *http.Client
+	spanChan  chan *JaegerSpan
+	seqId     int32
+}
+
+func newJaegerReporter(endpoint string) *jaegerReporter {
+	reporter := &jaegerReporter{
+		endpoint:  endpoint,
+		collector: strings.HasPrefix(endpoint, "http://") || strings.HasPrefix(endpoint, "https://"),
+		client:    &http.Client{Timeout: jaegerReportTimeout},
+		spanChan:  make(chan *JaegerSpan, jaegerSpanChanSize),
+	}
+
+	utils.GoWithRecover(reporter.loop, nil)
+
+	return reporter
+}
+
+func (r *jaegerReporter) report(span *JaegerSpan) error {
+	select {
+	case r.spanChan <- span:
+		return nil
+	default:
+		return types.ErrChanFull
+	}
+}
+
+func (r *jaegerReporter) batchSize() int {
+	if r.collector {
+		return jaegerBatchSize
+	}
+	return jaegerAgentBatchSize
+}
+
+func (r *jaegerReporter) loop() {
+	batch := make([]*JaegerSpan, 0, r.batchSize())
+	ticker := time.NewTicker(jaegerFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case span := <-r.spanChan:
+			batch = append(batch, span)
+			if len(batch) >= r.batchSize() {
+				r.send(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				r.send(batch)
+				batch = batch[:0]
+			}
+		}
+	}
+}
+
+func (r *jaegerReporter) send(batch []*JaegerSpan) {
+	spans := make([]*jaegerThriftSpan, 0, len(batch))
+	for _, span := range batch {
+		spans = append(spans, convertJaegerSpan(span))
+	}
+
+	if r.collector {
+		r.sendToCollector(spans)
+	} else {
+		r.sendToAgent(spans)
+	}
+}
+
+func (r *jaegerReporter) sendToCollector(spans []*jaegerThriftSpan) {
+	data := marshalJaegerBatch(jaegerServiceName, spans)
+	resp, err := r.client.Post(r.endpoint+jaegerCollectorPath, jaegerThriftMime, bytes.NewReader(data))
+	if err != nil {
+		log.DefaultLogger.Errorf("[trace] [jaeger] report spans to collector %s failed: %v", r.endpoint, err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		log.DefaultLogger.Errorf("[trace] [jaeger] collector %s rejected spans, status: %d", r.endpoint, resp.StatusCode)
+	}
+}
+
+func (r *jaegerReporter) sendToAgent(spans []*jaegerThriftSpan) {
+	r.seqId++
+	data := marshalJaegerEmitBatch(jaegerServiceName, spans, r.seqId)
+
+	conn, err := net.DialTimeout("udp", r.endpoint, jaegerReportTimeout)
+	if err != nil {
+		log.DefaultLogger.Errorf("[trace] [jaeger] dial agent %s failed: %v", r.endpoint, err)
+		return
+	}
+	defer conn.Close()
+	if _, err := conn.Write(data); err != nil {
+		log.DefaultLogger.Errorf("[trace] [jaeger] emit batch to agent %s failed: %v", r.endpoint, err)
+	}
+}
+
+// convertJaegerSpan maps the span tags into the jaeger.thrift span model
+func convertJaegerSpan(span *JaegerSpan) *jaegerThriftSpan {
+	operationName := span.operationName
+	if operationName == "" {
+		operationName = span.tags[METHOD_NAME]
+	}
+
+	var flags int32
+	if span.sampled {
+		flags = 1
+	}
+
+	tSpan := &jaegerThriftSpan{
+		traceIdLow:    jaegerIdToInt64(span.traceId),
+		spanId:        jaegerIdToInt64(span.spanId),
+		parentSpanId:  jaegerIdToInt64(span.parentSpanId),
+		operationName: operationName,
+		flags:         flags,
+		startTime:     span.startTime.UnixNano() / 1000,
+		duration:      span.endTime.Sub(span.startTime).Nanoseconds() / 1000,
+	}
+	if len(span.traceId) > 16 {
+		tSpan.traceIdHigh = jaegerIdToInt64(span.traceId[:len(span.traceId)-16])
+	}
+
+	for _, tag := range []struct {
+		key    string
+		tagKey uint64
+	}{
+		{"span.type", SPAN_TYPE},
+		{"protocol", PROTOCOL},
+		{"service.name", SERVICE_NAME},
+		{"result.status", RESULT_STATUS},
+		{"upstream.address", UPSTREAM_HOST_ADDRESS},
+		{"downstream.address", DOWNSTEAM_HOST_ADDRESS},
+	} {
+		if v := span.tags[tag.tagKey]; v != "" {
+			tSpan.tags = append(tSpan.tags, jaegerTag{key: tag.key, value: v})
+		}
+	}
+
+	return tSpan
+}
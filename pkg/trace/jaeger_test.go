@@ -0,0 +1,106 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trace
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestJaegerIdToInt64(t *testing.T) {
+	if got := jaegerIdToInt64("00000000000000ff"); got != 255 {
+		t.Errorf("expected 255, got %d", got)
+	}
+	// ids longer than 16 hex chars keep the low 64 bits
+	if got := jaegerIdToInt64("ffffffffffffffff00000000000000ff"); got != 255 {
+		t.Errorf("expected 255, got %d", got)
+	}
+	if got := jaegerIdToInt64(""); got != 0 {
+		t.Errorf("expected 0 for empty id, got %d", got)
+	}
+	// non-hex ids are hashed, deterministically and non-zero
+	hashed := jaegerIdToInt64("0a0b0c0d1552550025100rpc")
+	if hashed == 0 || hashed != jaegerIdToInt64("0a0b0c0d1552550025100rpc") {
+		t.Errorf("hashed id not stable: %d", hashed)
+	}
+}
+
+func TestMarshalJaegerEmitBatch(t *testing.T) {
+	span := &jaegerThriftSpan{
+		traceIdLow:    1,
+		spanId:        2,
+		operationName: "test",
+		flags:         1,
+	}
+	data := marshalJaegerEmitBatch(jaegerServiceName, []*jaegerThriftSpan{span}, 1)
+
+	// strict binary protocol CALL header followed by the method name
+	if binary.BigEndian.Uint32(data[:4]) != thriftVersion1Call {
+		t.Errorf("unexpected protocol header: %x", data[:4])
+	}
+	if !bytes.Contains(data, []byte("emitBatch")) {
+		t.Error("emitBatch method name not found in message")
+	}
+	if !bytes.Contains(data, []byte(jaegerServiceName)) {
+		t.Error("process service name not found in message")
+	}
+}
+
+func TestJaegerReporterAgentMode(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	reporter := newJaegerReporter(conn.LocalAddr().String())
+	if reporter.collector {
+		t.Fatal("udp address should select agent mode")
+	}
+
+	span := &JaegerSpan{
+		startTime: time.Now(),
+		endTime:   time.Now().Add(time.Millisecond),
+		sampled:   true,
+	}
+	span.SetTag(TRACE_ID, "00000000000000ff")
+	span.SetTag(SPAN_ID, "0000000000000001")
+	span.SetTag(SPAN_TYPE, "egress")
+	span.SetTag(PROTOCOL, "HTTP")
+
+	if err := reporter.report(span); err != nil {
+		t.Fatalf("report span failed: %v", err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(3 * jaegerFlushInterval))
+	buf := make([]byte, 65536)
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("no datagram received from reporter: %v", err)
+	}
+	if !bytes.Contains(buf[:n], []byte("emitBatch")) {
+		t.Error("datagram is not an emitBatch message")
+	}
+
+	if !newJaegerReporter("http://127.0.0.1:14268").collector {
+		t.Error("http endpoint should select collector mode")
+	}
+}
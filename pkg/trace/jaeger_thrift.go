@@ -0,0 +1,188 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trace
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// Minimal thrift binary protocol writer for the jaeger.thrift data model.
+// Only the subset needed to serialize a Batch and the agent emitBatch
+// message is implemented, which avoids pulling a full thrift dependency
+// for span reporting.
+
+// thrift binary protocol type ids
+const (
+	thriftTypeI32    = 8
+	thriftTypeI64    = 10
+	thriftTypeString = 11
+	thriftTypeStruct = 12
+	thriftTypeList   = 15
+
+)
+
+// strict binary protocol version mask with message type CALL
+const thriftVersion1Call uint32 = 0x80010001
+
+type thriftWriter struct {
+	buf bytes.Buffer
+}
+
+func (w *thriftWriter) writeByte(b byte) {
+	w.buf.WriteByte(b)
+}
+
+func (w *thriftWriter) writeI16(v int16) {
+	var b [2]byte
+	binary.BigEndian.PutUint16(b[:], uint16(v))
+	w.buf.Write(b[:])
+}
+
+func (w *thriftWriter) writeI32(v int32) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], uint32(v))
+	w.buf.Write(b[:])
+}
+
+func (w *thriftWriter) writeU32(v uint32) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	w.buf.Write(b[:])
+}
+
+func (w *thriftWriter) writeI64(v int64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(v))
+	w.buf.Write(b[:])
+}
+
+func (w *thriftWriter) writeString(s string) {
+	w.writeI32(int32(len(s)))
+	w.buf.WriteString(s)
+}
+
+func (w *thriftWriter) writeFieldBegin(typeId byte, id int16) {
+	w.writeByte(typeId)
+	w.writeI16(id)
+}
+
+func (w *thriftWriter) writeFieldStop() {
+	w.writeByte(0)
+}
+
+func (w *thriftWriter) writeListBegin(elemType byte, size int) {
+	w.writeByte(elemType)
+	w.writeI32(int32(size))
+}
+
+// jaegerTag is a jaeger.thrift Tag with a string value
+type jaegerTag struct {
+	key   string
+	value string
+}
+
+// jaegerThriftSpan is the jaeger.thrift Span subset reported by mosn
+type jaegerThriftSpan struct {
+	traceIdLow    int64
+	traceIdHigh   int64
+	spanId        int64
+	parentSpanId  int64
+	operationName string
+	flags         int32
+	startTime     int64 // microseconds
+	duration      int64 // microseconds
+	tags          []jaegerTag
+}
+
+func writeJaegerTag(w *thriftWriter, tag jaegerTag) {
+	w.writeFieldBegin(thriftTypeString, 1)
+	w.writeString(tag.key)
+	// vType 0 is a string value
+	w.writeFieldBegin(thriftTypeI32, 2)
+	w.writeI32(0)
+	w.writeFieldBegin(thriftTypeString, 3)
+	w.writeString(tag.value)
+	w.writeFieldStop()
+}
+
+func writeJaegerSpan(w *thriftWriter, span *jaegerThriftSpan) {
+	w.writeFieldBegin(thriftTypeI64, 1)
+	w.writeI64(span.traceIdLow)
+	w.writeFieldBegin(thriftTypeI64, 2)
+	w.writeI64(span.traceIdHigh)
+	w.writeFieldBegin(thriftTypeI64, 3)
+	w.writeI64(span.spanId)
+	w.writeFieldBegin(thriftTypeI64, 4)
+	w.writeI64(span.parentSpanId)
+	w.writeFieldBegin(thriftTypeString, 5)
+	w.writeString(span.operationName)
+	w.writeFieldBegin(thriftTypeI32, 7)
+	w.writeI32(span.flags)
+	w.writeFieldBegin(thriftTypeI64, 8)
+	w.writeI64(span.startTime)
+	w.writeFieldBegin(thriftTypeI64, 9)
+	w.writeI64(span.duration)
+	if len(span.tags) > 0 {
+		w.writeFieldBegin(thriftTypeList, 10)
+		w.writeListBegin(thriftTypeStruct, len(span.tags))
+		for _, tag := range span.tags {
+			writeJaegerTag(w, tag)
+		}
+	}
+	w.writeFieldStop()
+}
+
+func writeJaegerProcess(w *thriftWriter, serviceName string) {
+	w.writeFieldBegin(thriftTypeString, 1)
+	w.writeString(serviceName)
+	w.writeFieldStop()
+}
+
+func writeJaegerBatch(w *thriftWriter, serviceName string, spans []*jaegerThriftSpan) {
+	w.writeFieldBegin(thriftTypeStruct, 1)
+	writeJaegerProcess(w, serviceName)
+	w.writeFieldBegin(thriftTypeList, 2)
+	w.writeListBegin(thriftTypeStruct, len(spans))
+	for _, span := range spans {
+		writeJaegerSpan(w, span)
+	}
+	w.writeFieldStop()
+}
+
+// marshalJaegerBatch serializes a Batch struct, the payload accepted by the
+// jaeger collector at /api/traces
+func marshalJaegerBatch(serviceName string, spans []*jaegerThriftSpan) []byte {
+	w := &thriftWriter{}
+	writeJaegerBatch(w, serviceName, spans)
+	return w.buf.Bytes()
+}
+
+// marshalJaegerEmitBatch serializes the one-way Agent.emitBatch call, the
+// datagram accepted by the jaeger agent on its binary thrift UDP port
+func marshalJaegerEmitBatch(serviceName string, spans []*jaegerThriftSpan, seqId int32) []byte {
+	w := &thriftWriter{}
+	w.writeU32(thriftVersion1Call)
+	w.writeString("emitBatch")
+	w.writeI32(seqId)
+	// args struct: 1: batch
+	w.writeFieldBegin(thriftTypeStruct, 1)
+	writeJaegerBatch(w, serviceName, spans)
+	w.writeFieldStop()
+	return w.buf.Bytes()
+}
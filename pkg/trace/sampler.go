@@ -0,0 +1,64 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trace
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+)
+
+// sampler makes the head-based sampling decision for tracers that report
+// spans to an external collector. The rate is configured in the tracing
+// section of the mosn config and defaults to sampling everything.
+
+var (
+	sampleMutex sync.RWMutex
+	sampleRate  = 1.0
+)
+
+// SetSampleRate sets the global trace sample rate, rate is in [0, 1].
+func SetSampleRate(rate float64) {
+	if math.IsNaN(rate) || rate < 0 {
+		rate = 0
+	} else if rate > 1 {
+		rate = 1
+	}
+	sampleMutex.Lock()
+	sampleRate = rate
+	sampleMutex.Unlock()
+}
+
+// SampleRate returns the global trace sample rate.
+func SampleRate() float64 {
+	sampleMutex.RLock()
+	defer sampleMutex.RUnlock()
+	return sampleRate
+}
+
+// IsSampled makes a sampling decision for a new trace.
+func IsSampled() bool {
+	rate := SampleRate()
+	if rate >= 1.0 {
+		return true
+	}
+	if rate <= 0 {
+		return false
+	}
+	return rand.Float64() < rate
+}
@@ -0,0 +1,241 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trace
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+func init() {
+	RegisterTracerBuilder("SkyWalking", newSkyWalkingTracer)
+}
+
+// Sw8Header is the SkyWalking cross process propagation header (v3 protocol)
+const Sw8Header = "sw8"
+
+// SkyWalkingBackendEndpoint is the OAP backend HTTP receiver address,
+// configured via the tracing section of the mosn config
+var SkyWalkingBackendEndpoint = "http://127.0.0.1:12800"
+
+// SetSkyWalkingBackendEndpoint sets the OAP backend HTTP receiver address
+func SetSkyWalkingBackendEndpoint(endpoint string) {
+	SkyWalkingBackendEndpoint = endpoint
+}
+
+const skyWalkingServiceName = "mosn"
+
+// skyWalkingInstanceName identifies this mosn process to the OAP backend
+var skyWalkingInstanceName = func() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s@%d", hostname, os.Getpid())
+}()
+
+// EncodeSw8 builds a sw8 header value from the trace context.
+// Format: sample-traceId-segmentId-spanId-service-instance-endpoint-peer,
+// string fields base64 encoded.
+func EncodeSw8(sampled bool, traceId, segmentId, spanId, endpoint, peer string) string {
+	sample := "0"
+	if sampled {
+		sample = "1"
+	}
+	b64 := func(s string) string {
+		return base64.StdEncoding.EncodeToString([]byte(s))
+	}
+	return strings.Join([]string{
+		sample,
+		b64(traceId),
+		b64(segmentId),
+		spanId,
+		b64(skyWalkingServiceName),
+		b64(skyWalkingInstanceName),
+		b64(endpoint),
+		b64(peer),
+	}, "-")
+}
+
+// sw8Context is the decoded content of a sw8 header
+type sw8Context struct {
+	sampled         bool
+	traceId         string
+	parentSegmentId string
+	parentSpanId    string
+	parentService   string
+	parentInstance  string
+	parentEndpoint  string
+	peer            string
+}
+
+// DecodeSw8 parses a sw8 header received from downstream, returns the trace
+// id, the parent segment id and the parent span id. An empty trace id means
+// the header is absent or malformed.
+func DecodeSw8(header string) (traceId, parentSegmentId, parentSpanId string) {
+	ctx := decodeSw8Context(header)
+	if ctx == nil {
+		return "", "", ""
+	}
+	return ctx.traceId, ctx.parentSegmentId, ctx.parentSpanId
+}
+
+func decodeSw8Context(header string) *sw8Context {
+	parts := strings.Split(header, "-")
+	if len(parts) != 8 {
+		return nil
+	}
+	decoded := make([]string, 8)
+	decoded[0] = parts[0]
+	decoded[3] = parts[3]
+	for _, i := range []int{1, 2, 4, 5, 6, 7} {
+		data, err := base64.StdEncoding.DecodeString(parts[i])
+		if err != nil {
+			return nil
+		}
+		decoded[i] = string(data)
+	}
+	return &sw8Context{
+		sampled:         decoded[0] == "1",
+		traceId:         decoded[1],
+		parentSegmentId: decoded[2],
+		parentSpanId:    decoded[3],
+		parentService:   decoded[4],
+		parentInstance:  decoded[5],
+		parentEndpoint:  decoded[6],
+		peer:            decoded[7],
+	}
+}
+
+// -------- SkyWalkingSpan --------
+
+// SkyWalkingSpan is a types.Span reported to the SkyWalking OAP backend as
+// a single-span trace segment
+type SkyWalkingSpan struct {
+	tracer        *SkyWalkingTracer
+	startTime     time.Time
+	endTime       time.Time
+	tags          [TRACE_END]string
+	traceId       string
+	spanId        string
+	parentSpanId  string
+	segmentId     string
+	operationName string
+	sampled       bool
+}
+
+func (s *SkyWalkingSpan) TraceId() string {
+	return s.traceId
+}
+
+func (s *SkyWalkingSpan) SpanId() string {
+	return s.spanId
+}
+
+func (s *SkyWalkingSpan) ParentSpanId() string {
+	return s.parentSpanId
+}
+
+func (s *SkyWalkingSpan) SetOperation(operation string) {
+	s.operationName = operation
+}
+
+func (s *SkyWalkingSpan) SetTag(key uint64, value string) {
+	if key == TRACE_ID {
+		s.traceId = value
+	} else if key == SPAN_ID {
+		s.spanId = value
+	} else if key == PARENT_SPAN_ID {
+		s.parentSpanId = value
+	}
+
+	s.tags[key] = value
+}
+
+func (s *SkyWalkingSpan) SetRequestInfo(reqinfo types.RequestInfo) {
+}
+
+func (s *SkyWalkingSpan) Tag(key uint64) string {
+	return s.tags[key]
+}
+
+func (s *SkyWalkingSpan) FinishSpan() {
+	s.endTime = time.Now()
+	s.tracer.PrintSpan(s)
+}
+
+// InjectContext propagates the trace context to upstream in sw8 format
+func (s *SkyWalkingSpan) InjectContext(requestHeaders map[string]string) {
+	endpoint := s.operationName
+	if endpoint == "" {
+		endpoint = s.tags[METHOD_NAME]
+	}
+	requestHeaders[Sw8Header] = EncodeSw8(s.sampled, s.traceId, s.segmentId, "0",
+		endpoint, s.tags[UPSTREAM_HOST_ADDRESS])
+}
+
+func (s *SkyWalkingSpan) SpawnChild(operationName string, startTime time.Time) types.Span {
+	return nil
+}
+
+func (s *SkyWalkingSpan) SetStartTime(startTime time.Time) {
+	s.startTime = startTime
+}
+
+func (s *SkyWalkingSpan) EndTime() time.Time {
+	return s.endTime
+}
+
+func (s *SkyWalkingSpan) StartTime() time.Time {
+	return s.startTime
+}
+
+// -------- SkyWalkingTracer --------
+
+// SkyWalkingTracer reports trace segments to the SkyWalking OAP backend
+type SkyWalkingTracer struct {
+	reporter *skyWalkingReporter
+}
+
+func newSkyWalkingTracer() types.Tracer {
+	return &SkyWalkingTracer{
+		reporter: newSkyWalkingReporter(SkyWalkingBackendEndpoint),
+	}
+}
+
+func (tracer *SkyWalkingTracer) Start(startTime time.Time) types.Span {
+	return &SkyWalkingSpan{
+		tracer:    tracer,
+		startTime: startTime,
+		segmentId: IdGen().GenerateTraceId(),
+		sampled:   IsSampled(),
+	}
+}
+
+func (tracer *SkyWalkingTracer) PrintSpan(spanP types.Span) error {
+	span, ok := spanP.(*SkyWalkingSpan)
+	if !ok || !span.sampled {
+		return nil
+	}
+	return tracer.reporter.report(span)
+}
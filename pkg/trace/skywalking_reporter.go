@@ -0,0 +1,267 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trace
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/types"
+	"sofastack.io/sofa-mosn/pkg/utils"
+)
+
+const (
+	skyWalkingSpanChanSize  = 1000
+	skyWalkingBatchSize     = 100
+	skyWalkingFlushInterval = time.Second
+	skyWalkingReportTimeout = 5 * time.Second
+
+	skyWalkingSegmentsPath   = "/v3/segments"
+	skyWalkingPropertiesPath = "/v3/management/reportProperties"
+)
+
+// swKeyValue is a tag or an instance property in SkyWalking v3 JSON format
+type swKeyValue struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// swSegmentRef links a span to its parent segment across processes
+type swSegmentRef struct {
+	RefType                  string `json:"refType"`
+	TraceID                  string `json:"traceId"`
+	ParentTraceSegmentID     string `json:"parentTraceSegmentId"`
+	ParentSpanID             int    `json:"parentSpanId"`
+	ParentService            string `json:"parentService"`
+	ParentServiceInstance    string `json:"parentServiceInstance"`
+	ParentEndpoint           string `json:"parentEndpoint"`
+	NetworkAddressUsedAtPeer string `json:"networkAddressUsedAtPeer"`
+}
+
+// swSpanObject is a span in SkyWalking v3 JSON format
+type swSpanObject struct {
+	SpanID        int             `json:"spanId"`
+	ParentSpanID  int             `json:"parentSpanId"`
+	StartTime     int64           `json:"startTime"` // milliseconds
+	EndTime       int64           `json:"endTime"`   // milliseconds
+	Refs          []*swSegmentRef `json:"refs,omitempty"`
+	OperationName string          `json:"operationName"`
+	Peer          string          `json:"peer,omitempty"`
+	SpanType      string          `json:"spanType"`  // Entry / Exit
+	SpanLayer     string          `json:"spanLayer"` // Http / RPCFramework
+	ComponentID   int             `json:"componentId"`
+	IsError       bool            `json:"isError"`
+	Tags          []swKeyValue    `json:"tags,omitempty"`
+}
+
+// swSegmentObject is a trace segment in SkyWalking v3 JSON format
+type swSegmentObject struct {
+	TraceID         string          `json:"traceId"`
+	TraceSegmentID  string          `json:"traceSegmentId"`
+	Service         string          `json:"service"`
+	ServiceInstance string          `json:"serviceInstance"`
+	Spans           []*swSpanObject `json:"spans"`
+}
+
+// swInstanceProperties registers the mosn instance with the OAP backend
+type swInstanceProperties struct {
+	Service         string       `json:"service"`
+	ServiceInstance string       `json:"serviceInstance"`
+	Properties      []swKeyValue `json:"properties"`
+}
+
+// skyWalkingReporter batches finished spans into trace segments and posts
+// them to the SkyWalking OAP HTTP receiver
+type skyWalkingReporter struct {
+	endpoint string
+	client   *http.Client
+	spanChan chan *SkyWalkingSpan
+}
+
+func newSkyWalkingReporter(endpoint string) *skyWalkingReporter {
+	reporter := &skyWalkingReporter{
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: skyWalkingReportTimeout},
+		spanChan: make(chan *SkyWalkingSpan, skyWalkingSpanChanSize),
+	}
+
+	utils.GoWithRecover(func() {
+		reporter.register()
+		reporter.loop()
+	}, nil)
+
+	return reporter
+}
+
+func (r *skyWalkingReporter) report(span *SkyWalkingSpan) error {
+	select {
+	case r.spanChan <- span:
+		return nil
+	default:
+		return types.ErrChanFull
+	}
+}
+
+// register reports the instance properties to the OAP backend, best effort
+func (r *skyWalkingReporter) register() {
+	properties := &swInstanceProperties{
+		Service:         skyWalkingServiceName,
+		ServiceInstance: skyWalkingInstanceName,
+		Properties: []swKeyValue{
+			{Key: "language", Value: "go"},
+			{Key: "process_no", Value: skyWalkingInstanceName},
+		},
+	}
+	data, err := json.Marshal(properties)
+	if err != nil {
+		return
+	}
+	resp, err := r.client.Post(r.endpoint+skyWalkingPropertiesPath, "application/json", bytes.NewReader(data))
+	if err != nil {
+		log.DefaultLogger.Errorf("[trace] [skywalking] register instance to %s failed: %v", r.endpoint, err)
+		return
+	}
+	resp.Body.Close()
+	log.DefaultLogger.Infof("[trace] [skywalking] registered instance %s to %s", skyWalkingInstanceName, r.endpoint)
+}
+
+func (r *skyWalkingReporter) loop() {
+	batch := make([]*SkyWalkingSpan, 0, skyWalkingBatchSize)
+	ticker := time.NewTicker(skyWalkingFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case span := <-r.spanChan:
+			batch = append(batch, span)
+			if len(batch) >= skyWalkingBatchSize {
+				r.send(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				r.send(batch)
+				batch = batch[:0]
+			}
+		}
+	}
+}
+
+func (r *skyWalkingReporter) send(batch []*SkyWalkingSpan) {
+	segments := make([]*swSegmentObject, 0, len(batch))
+	for _, span := range batch {
+		segments = append(segments, convertSkyWalkingSegment(span))
+	}
+
+	data, err := json.Marshal(segments)
+	if err != nil {
+		log.DefaultLogger.Errorf("[trace] [skywalking] marshal segments failed: %v", err)
+		return
+	}
+
+	resp, err := r.client.Post(r.endpoint+skyWalkingSegmentsPath, "application/json", bytes.NewReader(data))
+	if err != nil {
+		log.DefaultLogger.Errorf("[trace] [skywalking] report segments to %s failed: %v", r.endpoint, err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		log.DefaultLogger.Errorf("[trace] [skywalking] backend %s rejected segments, status: %d", r.endpoint, resp.StatusCode)
+	}
+}
+
+// convertSkyWalkingSegment maps a span into a single-span trace segment
+func convertSkyWalkingSegment(span *SkyWalkingSpan) *swSegmentObject {
+	operationName := span.operationName
+	if operationName == "" {
+		operationName = span.tags[METHOD_NAME]
+	}
+
+	swSpan := &swSpanObject{
+		SpanID:        0,
+		ParentSpanID:  -1,
+		StartTime:     span.startTime.UnixNano() / int64(time.Millisecond),
+		EndTime:       span.endTime.UnixNano() / int64(time.Millisecond),
+		OperationName: operationName,
+		SpanLayer:     "RPCFramework",
+		// private component id for mosn, not in the SkyWalking official list
+		ComponentID: 6000,
+	}
+
+	if span.tags[SPAN_TYPE] == "egress" {
+		swSpan.SpanType = "Exit"
+		swSpan.Peer = span.tags[UPSTREAM_HOST_ADDRESS]
+	} else {
+		swSpan.SpanType = "Entry"
+		swSpan.Peer = span.tags[DOWNSTEAM_HOST_ADDRESS]
+	}
+	if span.tags[PROTOCOL] == "HTTP" {
+		swSpan.SpanLayer = "Http"
+	}
+
+	// link to the downstream segment if a sw8 context was received
+	if parent := decodeSw8Context(span.tags[SW8_PARENT_CONTEXT]); parent != nil {
+		swSpan.Refs = append(swSpan.Refs, &swSegmentRef{
+			RefType:                  "CrossProcess",
+			TraceID:                  parent.traceId,
+			ParentTraceSegmentID:     parent.parentSegmentId,
+			ParentSpanID:             atoiOrZero(parent.parentSpanId),
+			ParentService:            parent.parentService,
+			ParentServiceInstance:    parent.parentInstance,
+			ParentEndpoint:           parent.parentEndpoint,
+			NetworkAddressUsedAtPeer: parent.peer,
+		})
+	}
+
+	for _, tag := range []struct {
+		key    string
+		tagKey uint64
+	}{
+		{"protocol", PROTOCOL},
+		{"service.name", SERVICE_NAME},
+		{"result.status", RESULT_STATUS},
+		{"upstream.address", UPSTREAM_HOST_ADDRESS},
+		{"downstream.address", DOWNSTEAM_HOST_ADDRESS},
+	} {
+		if v := span.tags[tag.tagKey]; v != "" {
+			swSpan.Tags = append(swSpan.Tags, swKeyValue{Key: tag.key, Value: v})
+		}
+	}
+
+	return &swSegmentObject{
+		TraceID:         span.traceId,
+		TraceSegmentID:  span.segmentId,
+		Service:         skyWalkingServiceName,
+		ServiceInstance: skyWalkingInstanceName,
+		Spans:           []*swSpanObject{swSpan},
+	}
+}
+
+func atoiOrZero(s string) int {
+	n := 0
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return 0
+		}
+		n = n*10 + int(c-'0')
+	}
+	return n
+}
@@ -0,0 +1,115 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trace
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSw8RoundTrip(t *testing.T) {
+	header := EncodeSw8(true, "trace-1", "segment-1", "0", "/service", "127.0.0.1:8080")
+
+	traceId, parentSegmentId, parentSpanId := DecodeSw8(header)
+	if !(traceId == "trace-1" && parentSegmentId == "segment-1" && parentSpanId == "0") {
+		t.Errorf("sw8 round trip failed: %s %s %s", traceId, parentSegmentId, parentSpanId)
+	}
+
+	// malformed headers are rejected
+	if traceId, _, _ := DecodeSw8("1-only-three"); traceId != "" {
+		t.Errorf("malformed sw8 should not decode, got trace id %s", traceId)
+	}
+}
+
+func TestSkyWalkingSpanInjectContext(t *testing.T) {
+	span := &SkyWalkingSpan{
+		startTime: time.Now(),
+		segmentId: "segment-1",
+		sampled:   true,
+	}
+	span.SetTag(TRACE_ID, "trace-1")
+	span.SetTag(METHOD_NAME, "/service")
+
+	headers := make(map[string]string)
+	span.InjectContext(headers)
+
+	traceId, parentSegmentId, _ := DecodeSw8(headers[Sw8Header])
+	if !(traceId == "trace-1" && parentSegmentId == "segment-1") {
+		t.Errorf("sw8 header not injected as expected: %s", headers[Sw8Header])
+	}
+}
+
+func TestSkyWalkingReporter(t *testing.T) {
+	received := make(chan []*swSegmentObject, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != skyWalkingSegmentsPath {
+			// ignore the instance registration
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		var segments []*swSegmentObject
+		if err := json.Unmarshal(body, &segments); err != nil {
+			t.Errorf("backend received invalid segments: %v", err)
+		}
+		received <- segments
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	reporter := newSkyWalkingReporter(server.URL)
+
+	span := &SkyWalkingSpan{
+		startTime: time.Now(),
+		endTime:   time.Now().Add(time.Millisecond),
+		segmentId: "segment-2",
+		sampled:   true,
+	}
+	span.SetTag(TRACE_ID, "trace-1")
+	span.SetTag(SPAN_TYPE, "ingress")
+	span.SetTag(PROTOCOL, "HTTP")
+	span.SetTag(SW8_PARENT_CONTEXT, EncodeSw8(true, "trace-1", "segment-1", "0", "/service", "127.0.0.1:8080"))
+
+	if err := reporter.report(span); err != nil {
+		t.Fatalf("report span failed: %v", err)
+	}
+
+	select {
+	case segments := <-received:
+		if len(segments) != 1 {
+			t.Fatalf("unexpected segments count: %d", len(segments))
+		}
+		segment := segments[0]
+		if !(segment.TraceID == "trace-1" && segment.TraceSegmentID == "segment-2" && len(segment.Spans) == 1) {
+			t.Fatalf("unexpected segment reported: %+v", segment)
+		}
+		swSpan := segment.Spans[0]
+		if !(swSpan.SpanType == "Entry" && swSpan.SpanLayer == "Http") {
+			t.Errorf("unexpected span reported: %+v", swSpan)
+		}
+		if len(swSpan.Refs) != 1 || swSpan.Refs[0].ParentTraceSegmentID != "segment-1" {
+			t.Errorf("segment ref to downstream not reported: %+v", swSpan.Refs)
+		}
+	case <-time.After(3 * skyWalkingFlushInterval):
+		t.Fatal("no segments received by backend")
+	}
+}
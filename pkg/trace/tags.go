@@ -41,5 +41,9 @@ const (
 	ROUTE_RECORD
 	//30-60 for other extends
 
+	// SW8_PARENT_CONTEXT holds the raw sw8 header received from downstream,
+	// used by the SkyWalking tracer to build the segment reference
+	SW8_PARENT_CONTEXT = 30
+
 	TRACE_END = 60
 )
@@ -0,0 +1,164 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trace
+
+import (
+	"time"
+
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+func init() {
+	RegisterTracerBuilder("Zipkin", newZipkinTracer)
+}
+
+// B3 propagation headers, used for HTTP1/HTTP2 trace context propagation
+const (
+	B3TraceID      = "X-B3-TraceId"
+	B3SpanID       = "X-B3-SpanId"
+	B3ParentSpanID = "X-B3-ParentSpanId"
+	B3Sampled      = "X-B3-Sampled"
+)
+
+// ZipkinCollectorEndpoint is the zipkin HTTP collector spans endpoint,
+// configured via the tracing section of the mosn config
+var ZipkinCollectorEndpoint = "http://127.0.0.1:9411/api/v2/spans"
+
+// SetZipkinCollectorEndpoint sets the zipkin HTTP collector endpoint
+func SetZipkinCollectorEndpoint(endpoint string) {
+	ZipkinCollectorEndpoint = endpoint
+}
+
+// -------- ZipkinSpan --------
+
+// ZipkinSpan is a types.Span reported to a zipkin collector
+type ZipkinSpan struct {
+	tracer        *ZipkinTracer
+	startTime     time.Time
+	endTime       time.Time
+	tags          [TRACE_END]string
+	traceId       string
+	spanId        string
+	parentSpanId  string
+	operationName string
+	sampled       bool
+}
+
+func (s *ZipkinSpan) TraceId() string {
+	return s.traceId
+}
+
+func (s *ZipkinSpan) SpanId() string {
+	return s.spanId
+}
+
+func (s *ZipkinSpan) ParentSpanId() string {
+	return s.parentSpanId
+}
+
+func (s *ZipkinSpan) SetOperation(operation string) {
+	s.operationName = operation
+}
+
+func (s *ZipkinSpan) SetTag(key uint64, value string) {
+	if key == TRACE_ID {
+		s.traceId = value
+	} else if key == SPAN_ID {
+		s.spanId = value
+	} else if key == PARENT_SPAN_ID {
+		s.parentSpanId = value
+	}
+
+	s.tags[key] = value
+}
+
+func (s *ZipkinSpan) SetRequestInfo(reqinfo types.RequestInfo) {
+}
+
+func (s *ZipkinSpan) Tag(key uint64) string {
+	return s.tags[key]
+}
+
+func (s *ZipkinSpan) FinishSpan() {
+	s.endTime = time.Now()
+	s.tracer.PrintSpan(s)
+}
+
+// InjectContext propagates the span context into the upstream request
+// headers in B3 format
+func (s *ZipkinSpan) InjectContext(requestHeaders map[string]string) {
+	if s.traceId != "" {
+		requestHeaders[B3TraceID] = s.traceId
+	}
+	if s.spanId != "" {
+		requestHeaders[B3SpanID] = s.spanId
+	}
+	if s.parentSpanId != "" {
+		requestHeaders[B3ParentSpanID] = s.parentSpanId
+	}
+	if s.sampled {
+		requestHeaders[B3Sampled] = "1"
+	} else {
+		requestHeaders[B3Sampled] = "0"
+	}
+}
+
+func (s *ZipkinSpan) SpawnChild(operationName string, startTime time.Time) types.Span {
+	return nil
+}
+
+func (s *ZipkinSpan) SetStartTime(startTime time.Time) {
+	s.startTime = startTime
+}
+
+func (s *ZipkinSpan) EndTime() time.Time {
+	return s.endTime
+}
+
+func (s *ZipkinSpan) StartTime() time.Time {
+	return s.startTime
+}
+
+// -------- ZipkinTracer --------
+
+// ZipkinTracer reports finished spans to a zipkin HTTP collector
+type ZipkinTracer struct {
+	reporter *zipkinReporter
+}
+
+func newZipkinTracer() types.Tracer {
+	return &ZipkinTracer{
+		reporter: newZipkinReporter(ZipkinCollectorEndpoint),
+	}
+}
+
+func (tracer *ZipkinTracer) Start(startTime time.Time) types.Span {
+	return &ZipkinSpan{
+		tracer:    tracer,
+		startTime: startTime,
+		sampled:   IsSampled(),
+	}
+}
+
+func (tracer *ZipkinTracer) PrintSpan(spanP types.Span) error {
+	span, ok := spanP.(*ZipkinSpan)
+	if !ok || !span.sampled {
+		return nil
+	}
+	return tracer.reporter.report(span)
+}
@@ -0,0 +1,175 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trace
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/types"
+	"sofastack.io/sofa-mosn/pkg/utils"
+)
+
+const (
+	zipkinSpanChanSize  = 1000
+	zipkinBatchSize     = 100
+	zipkinFlushInterval = time.Second
+	zipkinReportTimeout = 5 * time.Second
+)
+
+// zipkinEndpoint is the span's localEndpoint in zipkin v2 JSON format
+type zipkinEndpoint struct {
+	ServiceName string `json:"serviceName,omitempty"`
+}
+
+// zipkinJSONSpan is a span in zipkin v2 JSON format
+type zipkinJSONSpan struct {
+	TraceID       string            `json:"traceId"`
+	ID            string            `json:"id"`
+	ParentID      string            `json:"parentId,omitempty"`
+	Name          string            `json:"name,omitempty"`
+	Kind          string            `json:"kind,omitempty"`
+	Timestamp     int64             `json:"timestamp,omitempty"` // microseconds
+	Duration      int64             `json:"duration,omitempty"`  // microseconds
+	LocalEndpoint *zipkinEndpoint   `json:"localEndpoint,omitempty"`
+	Tags          map[string]string `json:"tags,omitempty"`
+}
+
+// zipkinReporter batches finished spans and posts them to the zipkin HTTP
+// collector in v2 JSON format
+type zipkinReporter struct {
+	endpoint string
+	client   *http.Client
+	spanChan chan *ZipkinSpan
+}
+
+func newZipkinReporter(endpoint string) *zipkinReporter {
+	reporter := &zipkinReporter{
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: zipkinReportTimeout},
+		spanChan: make(chan *ZipkinSpan, zipkinSpanChanSize),
+	}
+
+	utils.GoWithRecover(reporter.loop, nil)
+
+	return reporter
+}
+
+func (r *zipkinReporter) report(span *ZipkinSpan) error {
+	select {
+	case r.spanChan <- span:
+		return nil
+	default:
+		return types.ErrChanFull
+	}
+}
+
+func (r *zipkinReporter) loop() {
+	batch := make([]*ZipkinSpan, 0, zipkinBatchSize)
+	ticker := time.NewTicker(zipkinFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case span := <-r.spanChan:
+			batch = append(batch, span)
+			if len(batch) >= zipkinBatchSize {
+				r.send(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				r.send(batch)
+				batch = batch[:0]
+			}
+		}
+	}
+}
+
+func (r *zipkinReporter) send(batch []*ZipkinSpan) {
+	spans := make([]*zipkinJSONSpan, 0, len(batch))
+	for _, span := range batch {
+		spans = append(spans, convertZipkinSpan(span))
+	}
+
+	data, err := json.Marshal(spans)
+	if err != nil {
+		log.DefaultLogger.Errorf("[trace] [zipkin] marshal spans failed: %v", err)
+		return
+	}
+
+	resp, err := r.client.Post(r.endpoint, "application/json", bytes.NewReader(data))
+	if err != nil {
+		log.DefaultLogger.Errorf("[trace] [zipkin] report spans to %s failed: %v", r.endpoint, err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		log.DefaultLogger.Errorf("[trace] [zipkin] collector %s rejected spans, status: %d", r.endpoint, resp.StatusCode)
+	}
+}
+
+// convertZipkinSpan maps the span tags into a zipkin v2 JSON span
+func convertZipkinSpan(span *ZipkinSpan) *zipkinJSONSpan {
+	name := span.operationName
+	if name == "" {
+		name = span.tags[METHOD_NAME]
+	}
+
+	var kind string
+	switch span.tags[SPAN_TYPE] {
+	case "ingress":
+		kind = "SERVER"
+	case "egress":
+		kind = "CLIENT"
+	}
+
+	tags := make(map[string]string)
+	if v := span.tags[PROTOCOL]; v != "" {
+		tags["protocol"] = v
+	}
+	if v := span.tags[SERVICE_NAME]; v != "" {
+		tags["service.name"] = v
+	}
+	if v := span.tags[RESULT_STATUS]; v != "" {
+		tags["result.status"] = v
+	}
+	if v := span.tags[UPSTREAM_HOST_ADDRESS]; v != "" {
+		tags["upstream.address"] = v
+	}
+	if v := span.tags[DOWNSTEAM_HOST_ADDRESS]; v != "" {
+		tags["downstream.address"] = v
+	}
+
+	return &zipkinJSONSpan{
+		TraceID:   span.traceId,
+		ID:        span.spanId,
+		ParentID:  span.parentSpanId,
+		Name:      name,
+		Kind:      kind,
+		Timestamp: span.startTime.UnixNano() / 1000,
+		Duration:  span.endTime.Sub(span.startTime).Nanoseconds() / 1000,
+		LocalEndpoint: &zipkinEndpoint{
+			ServiceName: "mosn",
+		},
+		Tags: tags,
+	}
+}
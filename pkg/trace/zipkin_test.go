@@ -0,0 +1,122 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trace
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSampleRate(t *testing.T) {
+	defer SetSampleRate(1)
+
+	SetSampleRate(1)
+	for i := 0; i < 100; i++ {
+		if !IsSampled() {
+			t.Fatal("sample rate 1 should always sample")
+		}
+	}
+
+	SetSampleRate(0)
+	for i := 0; i < 100; i++ {
+		if IsSampled() {
+			t.Fatal("sample rate 0 should never sample")
+		}
+	}
+
+	// out of range rates are normalized
+	SetSampleRate(2)
+	if SampleRate() != 1 {
+		t.Errorf("sample rate should be normalized to 1, got %f", SampleRate())
+	}
+	SetSampleRate(-1)
+	if SampleRate() != 0 {
+		t.Errorf("sample rate should be normalized to 0, got %f", SampleRate())
+	}
+}
+
+func TestZipkinSpanInjectContext(t *testing.T) {
+	tracer := &ZipkinTracer{}
+	span := &ZipkinSpan{
+		tracer:    tracer,
+		startTime: time.Now(),
+		sampled:   true,
+	}
+	span.SetTag(TRACE_ID, "0123456789abcdef")
+	span.SetTag(SPAN_ID, "abcdef0123456789")
+	span.SetTag(PARENT_SPAN_ID, "fedcba9876543210")
+
+	headers := make(map[string]string)
+	span.InjectContext(headers)
+
+	if !(headers[B3TraceID] == "0123456789abcdef" &&
+		headers[B3SpanID] == "abcdef0123456789" &&
+		headers[B3ParentSpanID] == "fedcba9876543210" &&
+		headers[B3Sampled] == "1") {
+		t.Errorf("B3 headers not injected as expected: %v", headers)
+	}
+}
+
+func TestZipkinReporter(t *testing.T) {
+	received := make(chan []*zipkinJSONSpan, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		var spans []*zipkinJSONSpan
+		if err := json.Unmarshal(body, &spans); err != nil {
+			t.Errorf("collector received invalid spans: %v", err)
+		}
+		received <- spans
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	reporter := newZipkinReporter(server.URL)
+
+	span := &ZipkinSpan{
+		startTime: time.Now(),
+		endTime:   time.Now().Add(time.Millisecond),
+		sampled:   true,
+	}
+	span.SetTag(TRACE_ID, "0123456789abcdef")
+	span.SetTag(SPAN_ID, "abcdef0123456789")
+	span.SetTag(SPAN_TYPE, "ingress")
+	span.SetTag(PROTOCOL, "HTTP")
+
+	if err := reporter.report(span); err != nil {
+		t.Fatalf("report span failed: %v", err)
+	}
+
+	select {
+	case spans := <-received:
+		if len(spans) != 1 {
+			t.Fatalf("unexpected spans count: %d", len(spans))
+		}
+		if !(spans[0].TraceID == "0123456789abcdef" &&
+			spans[0].ID == "abcdef0123456789" &&
+			spans[0].Kind == "SERVER" &&
+			spans[0].Tags["protocol"] == "HTTP") {
+			t.Errorf("unexpected span reported: %+v", spans[0])
+		}
+	case <-time.After(3 * zipkinFlushInterval):
+		t.Fatal("no spans received by collector")
+	}
+}
@@ -53,10 +53,13 @@ const (
 	LogResponseCode               string = "ResponseCode"
 	LogDuration                   string = "Duration"
 	LogResponseFlag               string = "ResponseFlag"
+	LogErrorCode                  string = "ErrorCode"
 	LogUpstreamLocalAddress       string = "UpstreamLocalAddress"
 	LogDownstreamLocalAddress     string = "DownstreamLocalAddress"
 	LogDownstreamRemoteAddress    string = "DownstreamRemoteAddress"
 	LogUpstreamHostSelectedGetter string = "UpstreamHostSelected"
+	LogGrpcStatus                 string = "GrpcStatus"
+	LogGrpcMessage                string = "GrpcMessage"
 )
 
 const (
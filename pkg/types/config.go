@@ -34,10 +34,12 @@ var (
 
 	MosnConfigPath = MosnBasePath + string(os.PathSeparator) + "conf"
 
-	ReconfigureDomainSocket    = MosnConfigPath + string(os.PathSeparator) + "reconfig.sock"
-	TransferConnDomainSocket   = MosnConfigPath + string(os.PathSeparator) + "conn.sock"
-	TransferStatsDomainSocket  = MosnConfigPath + string(os.PathSeparator) + "stats.sock"
-	TransferListenDomainSocket = MosnConfigPath + string(os.PathSeparator) + "listen.sock"
+	ReconfigureDomainSocket          = MosnConfigPath + string(os.PathSeparator) + "reconfig.sock"
+	TransferConnDomainSocket         = MosnConfigPath + string(os.PathSeparator) + "conn.sock"
+	TransferUpstreamConnDomainSocket = MosnConfigPath + string(os.PathSeparator) + "upstream.sock"
+	TransferStatsDomainSocket        = MosnConfigPath + string(os.PathSeparator) + "stats.sock"
+	TransferListenDomainSocket       = MosnConfigPath + string(os.PathSeparator) + "listen.sock"
+	SocketTransferDomainSocket       = MosnConfigPath + string(os.PathSeparator) + "sockettransfer.sock"
 )
 
 func InitDefaultPath(path string) {
@@ -68,8 +70,10 @@ func InitDefaultPath(path string) {
 
 	ReconfigureDomainSocket = MosnConfigPath + string(os.PathSeparator) + "reconfig.sock"
 	TransferConnDomainSocket = MosnConfigPath + string(os.PathSeparator) + "conn.sock"
+	TransferUpstreamConnDomainSocket = MosnConfigPath + string(os.PathSeparator) + "upstream.sock"
 	TransferStatsDomainSocket = MosnConfigPath + string(os.PathSeparator) + "stats.sock"
 	TransferListenDomainSocket = MosnConfigPath + string(os.PathSeparator) + "listen.sock"
+	SocketTransferDomainSocket = MosnConfigPath + string(os.PathSeparator) + "sockettransfer.sock"
 
 end:
 	os.MkdirAll(MosnLogBasePath, 0755)
@@ -40,6 +40,8 @@ const (
 	ContextKeyTraceSpanKey
 	ContextKeyActiveSpan
 	ContextKeyTraceId
+	ContextKeyAcceptTime
+	ContextKeyUpstreamHttp2Settings
 	ContextKeyEnd
 )
 
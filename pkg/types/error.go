@@ -0,0 +1,92 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package types
+
+// ProxyErrorCode is a machine readable reason for a request the proxy failed,
+// attached to the request info and exposed in access logs and metrics.
+// Protocols map it through the usual status conversion: HTTPCode gives the
+// http standard status, rpc protocols translate that with their own mapping
+// (e.g. sofarpc MappingFromHttpStatus)
+type ProxyErrorCode string
+
+// Proxy error codes
+const (
+	ErrCodeNone                ProxyErrorCode = ""
+	ErrCodeRouteNotFound       ProxyErrorCode = "route_not_found"
+	ErrCodeNoHealthyUpstream   ProxyErrorCode = "no_healthy_upstream"
+	ErrCodeClusterOverflow     ProxyErrorCode = "cluster_overflow"
+	ErrCodeUpstreamTimeout     ProxyErrorCode = "upstream_timeout"
+	ErrCodeUpstreamReset       ProxyErrorCode = "upstream_reset"
+	ErrCodeUpstreamConnectFail ProxyErrorCode = "upstream_connect_fail"
+	ErrCodeCodecError          ProxyErrorCode = "codec_error"
+	ErrCodePermissionDenied    ProxyErrorCode = "permission_denied"
+	ErrCodeLimitExceeded       ProxyErrorCode = "limit_exceeded"
+	ErrCodeFaultInjected       ProxyErrorCode = "fault_injected"
+	ErrCodeInvalidResponse     ProxyErrorCode = "invalid_response"
+	ErrCodeTLSHandshakeFailed  ProxyErrorCode = "tls_handshake_failed"
+)
+
+// HTTPCode maps the error code to the http standard status used by the
+// hijack reply, rpc protocols convert it with their own status mapping
+func (c ProxyErrorCode) HTTPCode() int {
+	switch c {
+	case ErrCodeNone:
+		return SuccessCode
+	case ErrCodeRouteNotFound:
+		return RouterUnavailableCode
+	case ErrCodeNoHealthyUpstream, ErrCodeUpstreamReset, ErrCodeUpstreamConnectFail, ErrCodeTLSHandshakeFailed, ErrCodeInvalidResponse:
+		return NoHealthUpstreamCode
+	case ErrCodeClusterOverflow:
+		return UpstreamOverFlowCode
+	case ErrCodeUpstreamTimeout:
+		return TimeoutExceptionCode
+	case ErrCodeCodecError:
+		return CodecExceptionCode
+	case ErrCodePermissionDenied, ErrCodeFaultInjected:
+		return PermissionDeniedCode
+	case ErrCodeLimitExceeded:
+		return LimitExceededCode
+	default:
+		return UnknownCode
+	}
+}
+
+// ErrorCodeForResponseFlag converts a response flag into the matching error
+// code, so the flag based call sites get a consistent code for free
+func ErrorCodeForResponseFlag(flag ResponseFlag) ProxyErrorCode {
+	switch flag {
+	case NoRouteFound:
+		return ErrCodeRouteNotFound
+	case NoHealthyUpstream:
+		return ErrCodeNoHealthyUpstream
+	case UpstreamOverflow:
+		return ErrCodeClusterOverflow
+	case UpstreamRequestTimeout:
+		return ErrCodeUpstreamTimeout
+	case UpstreamLocalReset, UpstreamRemoteReset, UpstreamConnectionTermination:
+		return ErrCodeUpstreamReset
+	case UpstreamConnectionFailure:
+		return ErrCodeUpstreamConnectFail
+	case DelayInjected, FaultInjected:
+		return ErrCodeFaultInjected
+	case RateLimited:
+		return ErrCodeLimitExceeded
+	default:
+		return ErrCodeNone
+	}
+}
@@ -0,0 +1,64 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package types
+
+import "testing"
+
+func TestErrorCodeHTTPMapping(t *testing.T) {
+	cases := []struct {
+		code     ProxyErrorCode
+		httpCode int
+	}{
+		{ErrCodeNone, SuccessCode},
+		{ErrCodeRouteNotFound, RouterUnavailableCode},
+		{ErrCodeNoHealthyUpstream, NoHealthUpstreamCode},
+		{ErrCodeClusterOverflow, UpstreamOverFlowCode},
+		{ErrCodeUpstreamTimeout, TimeoutExceptionCode},
+		{ErrCodeUpstreamConnectFail, NoHealthUpstreamCode},
+		{ErrCodeTLSHandshakeFailed, NoHealthUpstreamCode},
+		{ErrCodeLimitExceeded, LimitExceededCode},
+		{ErrCodePermissionDenied, PermissionDeniedCode},
+		{ProxyErrorCode("something_else"), UnknownCode},
+	}
+	for _, c := range cases {
+		if got := c.code.HTTPCode(); got != c.httpCode {
+			t.Errorf("HTTPCode(%q) = %d, want %d", c.code, got, c.httpCode)
+		}
+	}
+}
+
+func TestErrorCodeForResponseFlag(t *testing.T) {
+	cases := []struct {
+		flag ResponseFlag
+		code ProxyErrorCode
+	}{
+		{NoRouteFound, ErrCodeRouteNotFound},
+		{NoHealthyUpstream, ErrCodeNoHealthyUpstream},
+		{UpstreamOverflow, ErrCodeClusterOverflow},
+		{UpstreamRequestTimeout, ErrCodeUpstreamTimeout},
+		{UpstreamRemoteReset, ErrCodeUpstreamReset},
+		{UpstreamConnectionFailure, ErrCodeUpstreamConnectFail},
+		{RateLimited, ErrCodeLimitExceeded},
+		{ResponseFlag(0), ErrCodeNone},
+	}
+	for _, c := range cases {
+		if got := ErrorCodeForResponseFlag(c.flag); got != c.code {
+			t.Errorf("ErrorCodeForResponseFlag(%#x) = %q, want %q", c.flag, got, c.code)
+		}
+	}
+}
@@ -297,6 +297,11 @@ type Connection interface {
 
 	// SetTransferEventListener set a method will be called when connection transfer occur
 	SetTransferEventListener(listener func() bool)
+
+	// SetGracefulDrainListener set a method called once during a smooth upgrade
+	// when the connection cannot be transferred, so the protocol layer can
+	// signal a graceful close (e.g. http2 GOAWAY) before the drain timeout
+	SetGracefulDrainListener(listener func())
 }
 
 // ConnectionStats is a group of connection metrics
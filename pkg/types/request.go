@@ -111,6 +111,12 @@ type RequestInfo interface {
 	// SetResponseFlag sets request's response flag
 	SetResponseFlag(flag ResponseFlag)
 
+	// ErrorCode reports the machine readable failure reason, ErrCodeNone on success
+	ErrorCode() ProxyErrorCode
+
+	// SetErrorCode sets the failure reason, the first code set wins
+	SetErrorCode(code ProxyErrorCode)
+
 	//UpstreamHost reports  the selected upstream's host information
 	UpstreamHost() HostInfo
 
@@ -141,9 +147,40 @@ type RequestInfo interface {
 	// SetDownstreamRemoteAddress sets the downstream's remote network address.
 	SetDownstreamRemoteAddress(addr net.Addr)
 
+	// UpstreamCluster reports the name of the cluster the request was
+	// routed to, set once routing has determined the cluster snapshot
+	UpstreamCluster() string
+
+	// SetUpstreamCluster sets the selected upstream cluster name
+	SetUpstreamCluster(cluster string)
+
 	// RouteEntry reports the route rule
 	RouteEntry() RouteRule
 
 	// SetRouteEntry sets the route rule
 	SetRouteEntry(routerRule RouteRule)
+
+	// AttemptCount reports how many times the request was sent upstream,
+	// the first attempt counts as 1, every retry increments it
+	AttemptCount() uint32
+
+	// SetAttemptCount sets the upstream attempt count
+	SetAttemptCount(count uint32)
+
+	// UpstreamServerTiming reports the raw server-timing header the
+	// upstream responded with, empty if the upstream sent none
+	UpstreamServerTiming() string
+
+	// SetUpstreamServerTiming stores the upstream's server-timing header
+	SetUpstreamServerTiming(timing string)
+
+	// GrpcStatus reports the grpc-status the upstream responded with,
+	// empty for non-gRPC requests or when the upstream sent none
+	GrpcStatus() string
+
+	// GrpcMessage reports the grpc-message paired with GrpcStatus
+	GrpcMessage() string
+
+	// SetGrpcStatus stores the gRPC result parsed from the response
+	SetGrpcStatus(status, message string)
 }
@@ -33,6 +33,8 @@ const (
 	GlobalTimeout                  = 60 * time.Second
 	DefaultRouteTimeout            = 15 * time.Second
 	SofaRouteMatchKey              = "service"
+	SofaRouteMatchClassKey         = "sofa_head_target_service"
+	SofaRouteMatchUniqueIdKey      = "sofa_head_unique_id"
 	RouterMetadataKey              = "filter_metadata"
 	RouterMetadataKeyLb            = "mosn.lb"
 	SofaRouterType      RouterType = "sofa"
@@ -108,6 +110,10 @@ type RouteRule interface {
 	// If it is configured, the protocol will replace the proxy config's upstream protocol
 	UpstreamProtocol() string
 
+	// SupportWebSocket reports whether the route allows a websocket upgrade
+	// to pass through as a raw connection splice
+	SupportWebSocket() bool
+
 	// GlobalTimeout returns the global timeout
 	GlobalTimeout() time.Duration
 
@@ -158,6 +164,10 @@ type RetryPolicy interface {
 	TryTimeout() time.Duration
 
 	NumRetries() uint32
+
+	// RetryableMethods is the route annotation marking methods idempotent,
+	// empty means the default idempotent http methods
+	RetryableMethods() []string
 }
 
 type DoRetryCallback func()
@@ -173,6 +183,10 @@ type ShadowPolicy interface {
 	ClusterName() string
 
 	RuntimeKey() string
+
+	// Percent returns how many of the matched requests are mirrored,
+	// an empty cluster name disables shadowing regardless of the percent
+	Percent() uint32
 }
 
 type VirtualHost interface {
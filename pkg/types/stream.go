@@ -99,12 +99,16 @@ type StreamResetReason string
 const (
 	StreamConnectionTermination StreamResetReason = "ConnectionTermination"
 	StreamConnectionFailed      StreamResetReason = "ConnectionFailed"
-	StreamLocalReset            StreamResetReason = "StreamLocalReset"
-	StreamOverflow              StreamResetReason = "StreamOverflow"
-	StreamRemoteReset           StreamResetReason = "StreamRemoteReset"
-	UpstreamReset               StreamResetReason = "UpstreamReset"
-	UpstreamGlobalTimeout       StreamResetReason = "UpstreamGlobalTimeout"
-	UpstreamPerTryTimeout       StreamResetReason = "UpstreamPerTryTimeout"
+	// StreamReusedConnectionFailed means a reused keep-alive connection was
+	// closed by the peer before any response byte arrived, the request was
+	// never processed and is safe to resend
+	StreamReusedConnectionFailed StreamResetReason = "ReusedConnectionFailed"
+	StreamLocalReset             StreamResetReason = "StreamLocalReset"
+	StreamOverflow               StreamResetReason = "StreamOverflow"
+	StreamRemoteReset            StreamResetReason = "StreamRemoteReset"
+	UpstreamReset                StreamResetReason = "UpstreamReset"
+	UpstreamGlobalTimeout        StreamResetReason = "UpstreamGlobalTimeout"
+	UpstreamPerTryTimeout        StreamResetReason = "UpstreamPerTryTimeout"
 )
 
 // Stream is a generic protocol stream, it is the core model in stream layer
@@ -365,3 +369,25 @@ type PoolEventListener interface {
 
 	OnReady(sender StreamSender, host Host)
 }
+
+// PoolConnectionStatus describes one connection inside a connection pool
+type PoolConnectionStatus struct {
+	State         string `json:"state"`
+	ActiveStreams int    `json:"active_streams"`
+	TotalStreams  uint64 `json:"total_streams"`
+	Age           string `json:"age,omitempty"`
+}
+
+// PoolStatus is a snapshot of a connection pool's internal state for
+// admin introspection
+type PoolStatus struct {
+	Protocol    string                 `json:"protocol"`
+	LastError   string                 `json:"last_error,omitempty"`
+	Connections []PoolConnectionStatus `json:"connections"`
+}
+
+// PoolStatusProvider is optionally implemented by connection pools that
+// expose their internal state, pools without it are reported as opaque
+type PoolStatusProvider interface {
+	PoolStatus() PoolStatus
+}
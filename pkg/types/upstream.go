@@ -22,8 +22,8 @@ import (
 	"net"
 	"sort"
 
-	"sofastack.io/sofa-mosn/pkg/api/v2"
 	metrics "github.com/rcrowley/go-metrics"
+	"sofastack.io/sofa-mosn/pkg/api/v2"
 )
 
 //   Below is the basic relation between clusterManager, cluster, hostSet, and hosts:
@@ -90,6 +90,12 @@ type ClusterSnapshot interface {
 	LoadBalancer() LoadBalancer
 
 	IsExistsHosts(metadata MetadataMatchCriteria) bool
+
+	// HostsWithMetadata returns the hosts matching the metadata criteria
+	// without choosing one, leveraging the subset load balancer indexes.
+	// A nil metadata returns all hosts of the cluster, so extensions can
+	// implement fan-out patterns to a labeled subset.
+	HostsWithMetadata(metadata MetadataMatchCriteria) []Host
 }
 
 // Cluster is a group of upstream hosts
@@ -264,6 +270,21 @@ type ClusterInfo interface {
 	LbSubsetInfo() LBSubsetInfo
 
 	LBInstance() LoadBalancer
+
+	// ConnPoolRetry returns the host selection retry config on pool acquisition failure
+	ConnPoolRetry() v2.ConnPoolRetry
+
+	// SocketOptions returns the socket options applied when dialing the cluster's
+	// hosts, nil keeps the kernel defaults
+	SocketOptions() *v2.SocketOptions
+
+	// Http1Settings returns the http1 connection pool tuning of the cluster,
+	// nil keeps the built-in defaults
+	Http1Settings() *v2.Http1Settings
+
+	// Http2Settings returns the http2 upstream connection tuning of the
+	// cluster, nil keeps the built-in defaults
+	Http2Settings() *v2.Http2Settings
 }
 
 // ResourceManager manages different types of Resource
@@ -316,6 +337,8 @@ type ClusterStats struct {
 	UpstreamRequestDurationTotal                   metrics.Counter
 	UpstreamResponseSuccess                        metrics.Counter
 	UpstreamResponseFailed                         metrics.Counter
+	UpstreamRequestLocalZone                       metrics.Counter
+	UpstreamRequestCrossZone                       metrics.Counter
 	LBSubSetsFallBack                              metrics.Counter
 	LBSubSetsActive                                metrics.Counter
 	LBSubsetsCreated                               metrics.Counter
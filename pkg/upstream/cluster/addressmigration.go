@@ -0,0 +1,109 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/metrics"
+	"sofastack.io/sofa-mosn/pkg/network"
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+// detectAddressChanges compares host lists by hostname and returns the
+// moves of logical hosts whose resolved address changed (dns cluster or
+// registry update), old address -> new address
+func detectAddressChanges(oldHosts, newHosts []v2.Host) map[string]string {
+	oldAddrs := make(map[string]string, len(oldHosts))
+	for _, h := range oldHosts {
+		if h.Hostname != "" {
+			oldAddrs[h.Hostname] = h.Address
+		}
+	}
+	var moves map[string]string
+	for _, h := range newHosts {
+		if h.Hostname == "" {
+			continue
+		}
+		if oldAddr, ok := oldAddrs[h.Hostname]; ok && oldAddr != h.Address {
+			if moves == nil {
+				moves = make(map[string]string)
+			}
+			moves[oldAddr] = h.Address
+		}
+	}
+	return moves
+}
+
+// migrateConnPools drains the connection pools of moved addresses and
+// eagerly establishes pools to the new addresses, so traffic switches
+// before connection errors start piling up on the stale address
+func migrateConnPools(clusterName string, moves map[string]string, newHosts []types.Host) {
+	instanceMutex.Lock()
+	cm := clusterMangerInstance
+	instanceMutex.Unlock()
+	if cm == nil || len(moves) == 0 {
+		return
+	}
+
+	metrics.NewClusterStats(clusterName).Counter(metrics.UpstreamAddressFlap).Inc(int64(len(moves)))
+
+	hostsByAddr := make(map[string]types.Host, len(newHosts))
+	for _, h := range newHosts {
+		hostsByAddr[h.AddressString()] = h
+	}
+
+	cm.protocolConnPool.Range(func(protocolKey, poolsValue interface{}) bool {
+		protocol := protocolKey.(types.Protocol)
+		pools := poolsValue.(*sync.Map)
+		pools.Range(func(poolKey, poolValue interface{}) bool {
+			key := poolKey.(string)
+			addr, partition := key, ""
+			if idx := strings.Index(key, "~"); idx >= 0 {
+				addr, partition = key[:idx], key[idx:]
+			}
+			newAddr, moved := moves[addr]
+			if !moved {
+				return true
+			}
+			// drop the stale pool first so new streams never pick it up,
+			// then close its connections
+			pools.Delete(poolKey)
+			poolValue.(types.ConnectionPool).Close()
+			// pre-establish the pool towards the new address, reusing the
+			// partition suffix of the drained pool
+			if host, ok := hostsByAddr[newAddr]; ok {
+				if factory, ok := network.ConnNewPoolFactories[protocol]; ok {
+					newKey := newAddr + partition
+					if _, loaded := pools.Load(newKey); !loaded {
+						newPool := factory(host)
+						pools.Store(newKey, newPool)
+						newPool.CheckAndInit(context.Background())
+					}
+				}
+			}
+			log.DefaultLogger.Infof("[upstream] [cluster manager] cluster %s host address changed %s -> %s, pool drained", clusterName, addr, newAddr)
+			return true
+		})
+		return true
+	})
+}
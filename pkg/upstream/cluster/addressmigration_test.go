@@ -0,0 +1,119 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+	"sofastack.io/sofa-mosn/pkg/network"
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+func TestDetectAddressChanges(t *testing.T) {
+	oldHosts := []v2.Host{
+		{HostConfig: v2.HostConfig{Hostname: "h1", Address: "127.0.0.1:8080"}},
+		{HostConfig: v2.HostConfig{Hostname: "h2", Address: "127.0.0.1:8081"}},
+		{HostConfig: v2.HostConfig{Address: "127.0.0.1:8082"}}, // no hostname, ignored
+	}
+	newHosts := []v2.Host{
+		{HostConfig: v2.HostConfig{Hostname: "h1", Address: "127.0.0.1:9090"}}, // moved
+		{HostConfig: v2.HostConfig{Hostname: "h2", Address: "127.0.0.1:8081"}}, // unchanged
+		{HostConfig: v2.HostConfig{Hostname: "h3", Address: "127.0.0.1:9091"}}, // added
+	}
+	moves := detectAddressChanges(oldHosts, newHosts)
+	if len(moves) != 1 {
+		t.Fatalf("expected 1 move, got %v", moves)
+	}
+	if moves["127.0.0.1:8080"] != "127.0.0.1:9090" {
+		t.Errorf("unexpected move result: %v", moves)
+	}
+	if moves := detectAddressChanges(newHosts, newHosts); moves != nil {
+		t.Errorf("identical host lists should report no moves, got %v", moves)
+	}
+}
+
+type migrateFakePool struct {
+	protocol types.Protocol
+	closed   bool
+	inited   bool
+}
+
+func (p *migrateFakePool) Protocol() types.Protocol { return p.protocol }
+func (p *migrateFakePool) NewStream(ctx context.Context, receiver types.StreamReceiveListener, listener types.PoolEventListener) {
+}
+func (p *migrateFakePool) CheckAndInit(ctx context.Context) bool { p.inited = true; return true }
+func (p *migrateFakePool) Close()                                { p.closed = true }
+
+func TestMigrateConnPools(t *testing.T) {
+	const protocol = types.Protocol("TestMigrateConnPools")
+	var created []*migrateFakePool
+	network.RegisterNewPoolFactory(protocol, func(host types.Host) types.ConnectionPool {
+		pool := &migrateFakePool{protocol: protocol}
+		created = append(created, pool)
+		return pool
+	})
+	defer delete(network.ConnNewPoolFactories, protocol)
+
+	oldPool := &migrateFakePool{protocol: protocol}
+	partitionPool := &migrateFakePool{protocol: protocol}
+	pools := &sync.Map{}
+	pools.Store("127.0.0.1:8080", oldPool)
+	pools.Store("127.0.0.1:8080~p1", partitionPool)
+
+	instanceMutex.Lock()
+	saved := clusterMangerInstance
+	clusterMangerInstance = &clusterManager{}
+	clusterMangerInstance.protocolConnPool.Store(protocol, pools)
+	instanceMutex.Unlock()
+	defer func() {
+		instanceMutex.Lock()
+		clusterMangerInstance = saved
+		instanceMutex.Unlock()
+	}()
+
+	newHost := &host{
+		hostInfo: newHostInfo(nil, v2.Host{
+			HostConfig: v2.HostConfig{Hostname: "h1", Address: "127.0.0.1:9090"},
+		}, nil),
+	}
+	migrateConnPools("test", map[string]string{"127.0.0.1:8080": "127.0.0.1:9090"}, []types.Host{newHost})
+
+	if !oldPool.closed || !partitionPool.closed {
+		t.Errorf("stale pools should be closed, got %v %v", oldPool.closed, partitionPool.closed)
+	}
+	if _, ok := pools.Load("127.0.0.1:8080"); ok {
+		t.Errorf("stale pool key should be removed")
+	}
+	if len(created) != 2 {
+		t.Fatalf("expected pools pre-established for both partitions, got %d", len(created))
+	}
+	if _, ok := pools.Load("127.0.0.1:9090"); !ok {
+		t.Errorf("new address pool not stored")
+	}
+	if _, ok := pools.Load("127.0.0.1:9090~p1"); !ok {
+		t.Errorf("new address partition pool not stored")
+	}
+	for _, pool := range created {
+		if !pool.inited {
+			t.Errorf("pre-established pool should be initialized")
+		}
+	}
+}
@@ -70,6 +70,10 @@ func newCluster(clusterConfig v2.Cluster, sourceAddr net.Addr, addedViaAPI bool,
 			connBufferLimitBytes: clusterConfig.ConnBufferLimitBytes,
 			stats:                newClusterStats(clusterConfig.Name),
 			lbSubsetInfo:         NewLBSubsetInfo(&clusterConfig.LBSubSetConfig), // new subset load balancer info
+			connPoolRetry:        clusterConfig.ConnPoolRetry,
+			socketOptions:        clusterConfig.SocketOptions,
+			http1Settings:        clusterConfig.Http1Settings,
+			http2Settings:        clusterConfig.Http2Settings,
 		},
 		initHelper: initHelper,
 	}
@@ -98,6 +102,11 @@ func newCluster(clusterConfig v2.Cluster, sourceAddr net.Addr, addedViaAPI bool,
 		lb = NewLoadBalancer(cluster.Info().LbType(), cluster.PrioritySet())
 	}
 
+	// bias the selection by zone when locality lb is configured
+	if clusterConfig.LocalityLb != nil {
+		lb = newLocalityAwareLoadBalancer(clusterConfig.LocalityLb, lb, cluster.PrioritySet(), cluster.info.stats)
+	}
+
 	cluster.info.lbInstance = lb
 
 	mgr, err := mtls.NewTLSClientContextManager(&clusterConfig.TLS, cluster.info)
@@ -105,6 +114,30 @@ func newCluster(clusterConfig v2.Cluster, sourceAddr net.Addr, addedViaAPI bool,
 		log.DefaultLogger.Errorf("[upstream] [cluster] [new cluster] create tls context manager failed, %v", err)
 	}
 	cluster.info.tlsMng = mgr
+	if clusterConfig.TLS.Status {
+		// rebuild the client tls context when certificate files rotate on disk
+		tlsConfig := clusterConfig.TLS
+		mtls.WatchCertificates("cluster."+clusterConfig.Name, []*v2.TLSConfig{&tlsConfig}, func() error {
+			mgr, err := mtls.NewTLSClientContextManager(&tlsConfig, cluster.info)
+			if err != nil {
+				return err
+			}
+			cluster.info.tlsMng = mgr
+			return nil
+		})
+	}
+	if len(clusterConfig.TLSOverrides) > 0 {
+		cluster.info.tlsOverrides = make(map[string]types.TLSContextManager, len(clusterConfig.TLSOverrides))
+		for name, overrideConfig := range clusterConfig.TLSOverrides {
+			overrideConfig := overrideConfig
+			mgr, err := mtls.NewTLSClientContextManager(&overrideConfig, cluster.info)
+			if err != nil {
+				log.DefaultLogger.Errorf("[upstream] [cluster] [new cluster] create tls override %s failed, %v", name, err)
+				continue
+			}
+			cluster.info.tlsOverrides[name] = mgr
+		}
+	}
 	// add health check, should have a service name for stats
 	if clusterConfig.HealthCheck.ServiceName != "" {
 		log.DefaultLogger.Infof("[upstream] [cluster] [new cluster] cluster %s have health check", clusterConfig.Name)
@@ -194,7 +227,12 @@ type clusterInfo struct {
 	stats                types.ClusterStats
 	healthCheckProtocol  string
 	tlsMng               types.TLSContextManager
+	tlsOverrides         map[string]types.TLSContextManager
 	lbSubsetInfo         types.LBSubsetInfo
+	connPoolRetry        v2.ConnPoolRetry
+	socketOptions        *v2.SocketOptions
+	http1Settings        *v2.Http1Settings
+	http2Settings        *v2.Http2Settings
 }
 
 func NewClusterInfo() types.ClusterInfo {
@@ -269,6 +307,22 @@ func (ci *clusterInfo) LBInstance() types.LoadBalancer {
 	return ci.lbInstance
 }
 
+func (ci *clusterInfo) ConnPoolRetry() v2.ConnPoolRetry {
+	return ci.connPoolRetry
+}
+
+func (ci *clusterInfo) SocketOptions() *v2.SocketOptions {
+	return ci.socketOptions
+}
+
+func (ci *clusterInfo) Http1Settings() *v2.Http1Settings {
+	return ci.http1Settings
+}
+
+func (ci *clusterInfo) Http2Settings() *v2.Http2Settings {
+	return ci.http2Settings
+}
+
 type prioritySet struct {
 	hostSets        []types.HostSet // Note: index is the priority
 	updateCallbacks []types.MemberUpdateCallback
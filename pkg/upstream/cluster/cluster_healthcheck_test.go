@@ -187,7 +187,6 @@ func TestHealthCheckWithDynamicCluster(t *testing.T) {
 	}
 }
 
-//
 type healthCheckTestServer struct {
 	server     *httptest.Server
 	hostConfig v2.Host
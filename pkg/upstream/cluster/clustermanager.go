@@ -29,6 +29,7 @@ import (
 
 	admin "sofastack.io/sofa-mosn/pkg/admin/store"
 	"sofastack.io/sofa-mosn/pkg/api/v2"
+	"sofastack.io/sofa-mosn/pkg/journal"
 	"sofastack.io/sofa-mosn/pkg/log"
 	"sofastack.io/sofa-mosn/pkg/network"
 	"sofastack.io/sofa-mosn/pkg/rcu"
@@ -60,6 +61,9 @@ type clusterSnapshot struct {
 	config       interface{}
 }
 
+// NewClusterManager returns the process-wide cluster manager, creating it
+// on the first call. Later calls ignore their arguments and return the
+// existing instance
 func NewClusterManager(sourceAddr net.Addr, clusters []v2.Cluster,
 	clusterMap map[string][]v2.Host, autoDiscovery bool, useHealthCheck bool) types.ClusterManager {
 	instanceMutex.Lock()
@@ -68,7 +72,27 @@ func NewClusterManager(sourceAddr net.Addr, clusters []v2.Cluster,
 		return clusterMangerInstance
 	}
 
-	clusterMangerInstance = &clusterManager{
+	clusterMangerInstance = newClusterManager(sourceAddr, clusters, clusterMap, autoDiscovery, useHealthCheck)
+
+	//init clusterMngInstance when run app
+	initClusterMngAdapterInstance(clusterMangerInstance)
+
+	return clusterMangerInstance
+}
+
+// NewClusterManagerInstance returns a standalone cluster manager together
+// with an adapter bound to it, the process-wide singleton is not touched.
+// Tests and processes embedding several mosn instances need managers that
+// do not share cluster state
+func NewClusterManagerInstance(sourceAddr net.Addr, clusters []v2.Cluster,
+	clusterMap map[string][]v2.Host, autoDiscovery bool, useHealthCheck bool) (types.ClusterManager, *MngAdapter) {
+	cm := newClusterManager(sourceAddr, clusters, clusterMap, autoDiscovery, useHealthCheck)
+	return cm, &MngAdapter{clusterMng: cm}
+}
+
+func newClusterManager(sourceAddr net.Addr, clusters []v2.Cluster,
+	clusterMap map[string][]v2.Host, autoDiscovery bool, useHealthCheck bool) *clusterManager {
+	cm := &clusterManager{
 		sourceAddr:       sourceAddr,
 		primaryClusters:  sync.Map{},
 		protocolConnPool: sync.Map{},
@@ -76,17 +100,14 @@ func NewClusterManager(sourceAddr net.Addr, clusters []v2.Cluster,
 	}
 
 	for k := range types.ConnPoolFactories {
-		clusterMangerInstance.protocolConnPool.Store(k, &sync.Map{})
+		cm.protocolConnPool.Store(k, &sync.Map{})
 	}
 
-	//init clusterMngInstance when run app
-	initClusterMngAdapterInstance(clusterMangerInstance)
-
 	//Add cluster to cm
 	//Register upstream update type
 	for _, cluster := range clusters {
 
-		if !clusterMangerInstance.AddOrUpdatePrimaryCluster(cluster) {
+		if !cm.AddOrUpdatePrimaryCluster(cluster) {
 			log.DefaultLogger.Errorf("[upstream] [cluster manager] NewClusterManager: AddOrUpdatePrimaryCluster failure, cluster name = %s", cluster.Name)
 		}
 	}
@@ -94,10 +115,10 @@ func NewClusterManager(sourceAddr net.Addr, clusters []v2.Cluster,
 	// Add hosts to cluster
 	// Note: currently, use priority = 0
 	for clusterName, hosts := range clusterMap {
-		clusterMangerInstance.UpdateClusterHosts(clusterName, 0, hosts)
+		cm.UpdateClusterHosts(clusterName, 0, hosts)
 	}
 
-	return clusterMangerInstance
+	return cm
 }
 
 func (cs *clusterSnapshot) PrioritySet() types.PrioritySet {
@@ -131,6 +152,24 @@ func (cs *clusterSnapshot) IsExistsHosts(metadata types.MetadataMatchCriteria) b
 	return false
 }
 
+func (cs *clusterSnapshot) HostsWithMetadata(metadata types.MetadataMatchCriteria) []types.Host {
+	if metadata == nil {
+		var hosts []types.Host
+		for _, hostSet := range cs.PrioritySet().HostSetsByPriority() {
+			hosts = append(hosts, hostSet.Hosts()...)
+		}
+
+		return hosts
+	}
+
+	if subsetLB, ok := cs.loadbalancer.(*subSetLoadBalancer); ok {
+		return subsetLB.GetHostsWithMetadata(metadata)
+	}
+
+	log.DefaultLogger.Errorf("[upstream] [cluster snapshot] Call HostsWithMetadata error,metadata isn't nil, but subsetLB doesn't exist")
+	return nil
+}
+
 type primaryCluster struct {
 	cluster     types.Cluster
 	addedViaAPI bool
@@ -158,13 +197,19 @@ func (pc *primaryCluster) UpdateCluster(cluster types.Cluster, config *v2.Cluste
 	pc.configUsed = deepCopyCluster(config)
 	pc.addedViaAPI = addedViaAPI
 	if err := pc.configLock.Update(pc.configUsed, 0); err == rcu.Block {
-		return err
+		// a concurrent update holds the rcu lock, reapply once readers release
+		getConfigRetrier().Enqueue(pc)
 	}
 	return nil
 }
 func (pc *primaryCluster) UpdateHosts(hosts []types.Host) error {
 	pc.updateLock.Lock()
 	defer pc.updateLock.Unlock()
+	if err := checkHostUpdateGuard(pc.configUsed.HostUpdateGuard, pc.cluster.Info().Name(), len(pc.configUsed.Hosts), len(hosts)); err != nil {
+		log.DefaultLogger.Errorf("[upstream] [cluster manager] %v", err)
+		journal.Record(journal.TypeConfigReject, "%v", err)
+		return err
+	}
 	if c, ok := pc.cluster.(*simpleInMemCluster); ok {
 		c.UpdateHosts(hosts)
 		hosts = c.hosts // set the final host
@@ -174,10 +219,14 @@ func (pc *primaryCluster) UpdateHosts(hosts []types.Host) error {
 	for _, h := range hosts {
 		hostsConfig = append(hostsConfig, h.Config())
 	}
+	if moves := detectAddressChanges(pc.configUsed.Hosts, hostsConfig); len(moves) > 0 {
+		migrateConnPools(pc.cluster.Info().Name(), moves, hosts)
+	}
 	config.Hosts = hostsConfig
 	pc.configUsed = config
 	if err := pc.configLock.Update(pc.configUsed, 0); err == rcu.Block {
-		return err
+		// a concurrent update holds the rcu lock, reapply once readers release
+		getConfigRetrier().Enqueue(pc)
 	}
 	admin.SetHosts(pc.cluster.Info().Name(), hostsConfig)
 	log.DefaultLogger.Infof("[cluster] [primaryCluster] [UpdateHosts] cluster %s update hosts: %v", pc.cluster.Info().Name(), hosts)
@@ -439,24 +488,87 @@ func (cm *clusterManager) ConnPoolForCluster(balancerContext types.LoadBalancerC
 	return pool
 }
 
+// ConnPoolPartitionKey is the route metadata key that assigns streams to a
+// dedicated connection pool partition of the cluster, separating critical
+// rpcs from bulk traffic sharing multiplexed upstream connections
+const ConnPoolPartitionKey = "conn_pool_partition"
+
+// connPoolPartition reads the pool partition name from the route's
+// metadata match criteria, empty means the shared default partition
+func connPoolPartition(balancerContext types.LoadBalancerContext) string {
+	criteria := balancerContext.MetadataMatchCriteria()
+	if criteria == nil {
+		return ""
+	}
+	for _, criterion := range criteria.MetadataMatchCriteria() {
+		if criterion.MetadataKeyName() == ConnPoolPartitionKey {
+			return string(criterion.MetadataValue())
+		}
+	}
+	return ""
+}
+
 func (cm *clusterManager) getActiveConnectionPool(balancerContext types.LoadBalancerContext, clusterSnapshot *clusterSnapshot, protocol types.Protocol) (types.ConnectionPool, error) {
 	var pool types.ConnectionPool
-	var pools [cycleTimes]types.ConnectionPool
 
-	for i := 0; i < cycleTimes; i++ {
+	// pools are keyed by host address plus the route's partition so
+	// partitioned routes never share connections with bulk traffic
+	partition := connPoolPartition(balancerContext)
+	// a tls override also gets its own pool keys, connections carrying
+	// different tls identities must never be shared
+	tlsOverride := connPoolTLSOverride(balancerContext)
+	if tlsOverride != "" {
+		partition = partition + "@tls=" + tlsOverride
+	}
+
+	retry := clusterSnapshot.clusterInfo.ConnPoolRetry()
+	attempts := retry.Attempts
+	if attempts <= 0 {
+		attempts = cycleTimes
+	}
+	pools := make([]types.ConnectionPool, attempts)
+	var tried map[string]struct{}
+	if retry.ExcludeTried {
+		tried = make(map[string]struct{}, attempts)
+	}
+
+	for i := 0; i < attempts; i++ {
 		host := clusterSnapshot.loadbalancer.ChooseHost(balancerContext)
 		if host == nil {
 			return nil, fmt.Errorf("clusterSnapshot.loadbalancer.ChooseHost is nil")
 		}
+		if i > 0 {
+			clusterSnapshot.clusterInfo.Stats().UpstreamConnectionRetry.Inc(1)
+		}
+		if tlsOverride != "" {
+			overrideHost, err := applyTLSOverride(clusterSnapshot.clusterInfo, host, tlsOverride)
+			if err != nil {
+				return nil, err
+			}
+			host = overrideHost
+		}
 
 		addr := host.AddressString()
+		poolKey := addr
+		if partition != "" {
+			poolKey = addr + "~" + partition
+		}
 		if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
-			log.DefaultLogger.Debugf("[upstream] [cluster manager] clusterSnapshot.loadbalancer.ChooseHost result is %s, cluster name = %s", addr, clusterSnapshot.clusterInfo.Name())
+			log.DefaultLogger.Debugf("[upstream] [cluster manager] clusterSnapshot.loadbalancer.ChooseHost result is %s, cluster name = %s, attempt = %d", addr, clusterSnapshot.clusterInfo.Name(), i+1)
+		}
+		if retry.ExcludeTried {
+			if _, ok := tried[addr]; ok {
+				if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
+					log.DefaultLogger.Debugf("[upstream] [cluster manager] cluster host %s already tried, skip attempt %d", addr, i+1)
+				}
+				continue
+			}
+			tried[addr] = struct{}{}
 		}
 		value, _ := cm.protocolConnPool.Load(protocol)
 
 		connectionPool := value.(*sync.Map)
-		if connPool, ok := connectionPool.Load(addr); ok {
+		if connPool, ok := connectionPool.Load(poolKey); ok {
 			pool = connPool.(types.ConnectionPool)
 			if pool.CheckAndInit(balancerContext.DownstreamContext()) {
 				return pool, nil
@@ -471,10 +583,10 @@ func (cm *clusterManager) getActiveConnectionPool(balancerContext types.LoadBala
 				cm.mux.Lock()
 				defer cm.mux.Unlock()
 
-				if _, ok := connectionPool.Load(addr); !ok {
+				if _, ok := connectionPool.Load(poolKey); !ok {
 					if factory, ok := network.ConnNewPoolFactories[protocol]; ok {
 						newPool := factory(host) //call NewBasicRoute
-						connectionPool.Store(addr, newPool)
+						connectionPool.Store(poolKey, newPool)
 						newPool.CheckAndInit(balancerContext.DownstreamContext())
 						pools[i] = newPool
 					} else {
@@ -491,22 +603,50 @@ func (cm *clusterManager) getActiveConnectionPool(balancerContext types.LoadBala
 		}
 	}
 
-	// perhaps the first request, wait for tcp handshaking. total wait time: 1ms + 10ms + 100ms + 1000ms
-	waitTime := time.Millisecond
-	for t := 0; t < 4; t++ {
-		time.Sleep(waitTime)
-		for i := 0; i < cycleTimes; i++ {
+	// perhaps the first request, wait for tcp handshaking.
+	// default total wait time: 1ms + 10ms + 100ms + 1000ms,
+	// a configured attempt timeout replaces the ladder with
+	// a 10ms poll bounded by the timeout
+	if pool := waitConnectionPools(balancerContext, pools, retry.AttemptTimeout.Duration); pool != nil {
+		return pool, nil
+	}
+
+	return nil, errors.New("no health hosts")
+}
+
+// waitConnectionPools polls the tried pools until one finishes
+// tcp handshaking or the wait budget runs out
+func waitConnectionPools(balancerContext types.LoadBalancerContext, pools []types.ConnectionPool, timeout time.Duration) types.ConnectionPool {
+	checkPools := func() types.ConnectionPool {
+		for i := 0; i < len(pools); i++ {
 			if pools[i] == nil {
 				continue
 			}
 			if pools[i].CheckAndInit(balancerContext.DownstreamContext()) {
-				return pools[i], nil
+				return pools[i]
 			}
 		}
+		return nil
+	}
+	if timeout > 0 {
+		deadline := time.Now().Add(timeout)
+		for time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+			if pool := checkPools(); pool != nil {
+				return pool
+			}
+		}
+		return nil
+	}
+	waitTime := time.Millisecond
+	for t := 0; t < 4; t++ {
+		time.Sleep(waitTime)
+		if pool := checkPools(); pool != nil {
+			return pool
+		}
 		waitTime *= 10
 	}
-
-	return nil, errors.New("no health hosts")
+	return nil
 }
 
 func (cm *clusterManager) Shutdown() error {
@@ -525,11 +665,12 @@ func (cm *clusterManager) LocalClusterName() string {
 	return ""
 }
 
-// Destory the cluster manager instance
+// Destory the cluster manager instance, the singleton is only released
+// when the destroyed manager is the singleton itself
 func (cm *clusterManager) Destory() {
 	instanceMutex.Lock()
 	defer instanceMutex.Unlock()
-	if clusterMangerInstance != nil {
+	if clusterMangerInstance == cm {
 		clusterMangerInstance = nil
 	}
 }
@@ -18,9 +18,13 @@
 package cluster
 
 import (
+	"context"
+	"net"
 	"testing"
+	"time"
 
 	"sofastack.io/sofa-mosn/pkg/api/v2"
+	"sofastack.io/sofa-mosn/pkg/protocol"
 	"sofastack.io/sofa-mosn/pkg/types"
 )
 
@@ -39,3 +43,136 @@ func TestPrimaryCluster(t *testing.T) {
 		t.Error("update hosts failed")
 	}
 }
+
+type fakeConnPool struct {
+	checkCount int
+	readyAfter int
+}
+
+func (p *fakeConnPool) Protocol() types.Protocol { return protocol.SofaRPC }
+func (p *fakeConnPool) NewStream(ctx context.Context, receiver types.StreamReceiveListener, listener types.PoolEventListener) {
+}
+func (p *fakeConnPool) CheckAndInit(ctx context.Context) bool {
+	p.checkCount++
+	return p.checkCount > p.readyAfter
+}
+func (p *fakeConnPool) Close() {}
+
+type fakeLbContext struct{}
+
+func (c *fakeLbContext) ComputeHashKey() types.HashedValue                  { return "" }
+func (c *fakeLbContext) MetadataMatchCriteria() types.MetadataMatchCriteria { return nil }
+func (c *fakeLbContext) DownstreamConnection() net.Conn                     { return nil }
+func (c *fakeLbContext) DownstreamHeaders() types.HeaderMap                 { return nil }
+func (c *fakeLbContext) DownstreamContext() context.Context                 { return context.Background() }
+
+func TestWaitConnectionPools(t *testing.T) {
+	// pool becomes ready on the second poll
+	pool := &fakeConnPool{readyAfter: 1}
+	if got := waitConnectionPools(&fakeLbContext{}, []types.ConnectionPool{nil, pool}, 200*time.Millisecond); got != pool {
+		t.Errorf("expected the ready pool, got %v", got)
+	}
+	// the wait budget runs out before the pool gets ready
+	pool = &fakeConnPool{readyAfter: 1000}
+	if got := waitConnectionPools(&fakeLbContext{}, []types.ConnectionPool{pool}, 30*time.Millisecond); got != nil {
+		t.Errorf("expected nil after timeout, got %v", got)
+	}
+}
+
+type fakeMatchCriterion struct {
+	name  string
+	value string
+}
+
+func (m *fakeMatchCriterion) MetadataKeyName() string          { return m.name }
+func (m *fakeMatchCriterion) MetadataValue() types.HashedValue { return types.HashedValue(m.value) }
+
+type fakeMatchCriteria struct {
+	criteria []types.MetadataMatchCriterion
+}
+
+func (m *fakeMatchCriteria) MetadataMatchCriteria() []types.MetadataMatchCriterion {
+	return m.criteria
+}
+func (m *fakeMatchCriteria) MergeMatchCriteria(metadataMatches map[string]interface{}) types.MetadataMatchCriteria {
+	return m
+}
+
+type partitionLbContext struct {
+	fakeLbContext
+	criteria types.MetadataMatchCriteria
+}
+
+func (c *partitionLbContext) MetadataMatchCriteria() types.MetadataMatchCriteria { return c.criteria }
+
+func TestConnPoolPartition(t *testing.T) {
+	// no criteria means the shared default partition
+	if got := connPoolPartition(&fakeLbContext{}); got != "" {
+		t.Errorf("expected empty partition, got %s", got)
+	}
+	// the reserved metadata key selects a dedicated partition
+	ctx := &partitionLbContext{
+		criteria: &fakeMatchCriteria{
+			criteria: []types.MetadataMatchCriterion{
+				&fakeMatchCriterion{name: "version", value: "v1"},
+				&fakeMatchCriterion{name: ConnPoolPartitionKey, value: "critical"},
+			},
+		},
+	}
+	if got := connPoolPartition(ctx); got != "critical" {
+		t.Errorf("expected partition critical, got %s", got)
+	}
+}
+
+func TestNewClusterManagerInstance(t *testing.T) {
+	savedInstance := clusterMangerInstance
+	savedAdapter := clusterMngAdapterInstance
+	defer func() {
+		clusterMangerInstance = savedInstance
+		clusterMngAdapterInstance = savedAdapter
+	}()
+	clusterMangerInstance = nil
+
+	config := v2.Cluster{
+		Name:        "standalone_cluster",
+		ClusterType: v2.SIMPLE_CLUSTER,
+		LbType:      v2.LB_RANDOM,
+	}
+	cm1, adapter1 := NewClusterManagerInstance(nil, []v2.Cluster{config}, nil, false, false)
+	cm2, _ := NewClusterManagerInstance(nil, nil, nil, false, false)
+	if clusterMangerInstance != nil {
+		t.Fatal("standalone managers must not install the singleton")
+	}
+	if cm1 == cm2 {
+		t.Fatal("each call should build a fresh manager")
+	}
+	// cluster state is per instance
+	if !cm1.ClusterExist("standalone_cluster") {
+		t.Error("cm1 should know its configured cluster")
+	}
+	if cm2.ClusterExist("standalone_cluster") {
+		t.Error("cm2 should not share cm1's clusters")
+	}
+	// the returned adapter is bound to its own manager
+	added := v2.Cluster{
+		Name:        "added_via_adapter",
+		ClusterType: v2.SIMPLE_CLUSTER,
+		LbType:      v2.LB_RANDOM,
+	}
+	if err := adapter1.TriggerClusterAddOrUpdate(added); err != nil {
+		t.Fatalf("adapter add cluster failed: %v", err)
+	}
+	if !cm1.ClusterExist("added_via_adapter") || cm2.ClusterExist("added_via_adapter") {
+		t.Error("adapter should only update its own manager")
+	}
+	// destroying a standalone manager leaves the singleton alone
+	singleton := NewClusterManager(nil, nil, nil, false, false)
+	cm1.Destory()
+	if clusterMangerInstance == nil {
+		t.Error("destroying a standalone manager must not release the singleton")
+	}
+	singleton.Destory()
+	if clusterMangerInstance != nil {
+		t.Error("destroying the singleton should release it")
+	}
+}
@@ -0,0 +1,129 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"sync"
+	"time"
+
+	gometrics "github.com/rcrowley/go-metrics"
+	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/metrics"
+	"sofastack.io/sofa-mosn/pkg/rcu"
+)
+
+const (
+	configRetryInitialBackoff = 100 * time.Millisecond
+	configRetryMaxBackoff     = 5 * time.Second
+)
+
+var (
+	configRetrier     *configUpdateRetrier
+	configRetrierOnce sync.Once
+)
+
+// configUpdateRetrier reapplies rcu config updates that returned rcu.Block,
+// so a blocked update is delayed instead of lost. Retries always reapply the
+// cluster's newest configUsed, so at most one entry per cluster is pending
+// and superseded configs are dropped naturally.
+type configUpdateRetrier struct {
+	mux     sync.Mutex
+	pending map[*primaryCluster]*pendingConfigUpdate
+	blocked gometrics.Counter
+	maxAge  gometrics.Gauge
+}
+
+type pendingConfigUpdate struct {
+	since   time.Time
+	backoff time.Duration
+}
+
+func getConfigRetrier() *configUpdateRetrier {
+	configRetrierOnce.Do(func() {
+		r := &configUpdateRetrier{
+			pending: make(map[*primaryCluster]*pendingConfigUpdate),
+		}
+		if m, err := metrics.NewMetrics("cluster", map[string]string{"component": "config_update"}); err == nil {
+			r.blocked = m.Counter("config_update_blocked")
+			r.maxAge = m.Gauge("config_update_max_retry_age_ms")
+		}
+		configRetrier = r
+	})
+	return configRetrier
+}
+
+// Enqueue schedules a retry for a cluster whose rcu update was blocked
+func (r *configUpdateRetrier) Enqueue(pc *primaryCluster) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if r.blocked != nil {
+		r.blocked.Inc(1)
+	}
+	if _, ok := r.pending[pc]; ok {
+		// a retry is already scheduled, it picks up the newest config
+		return
+	}
+	p := &pendingConfigUpdate{
+		since:   time.Now(),
+		backoff: configRetryInitialBackoff,
+	}
+	r.pending[pc] = p
+	time.AfterFunc(p.backoff, func() { r.retry(pc) })
+	r.updateMaxAgeLocked()
+}
+
+func (r *configUpdateRetrier) retry(pc *primaryCluster) {
+	pc.updateLock.Lock()
+	err := pc.configLock.Update(pc.configUsed, 0)
+	pc.updateLock.Unlock()
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	p := r.pending[pc]
+	if p == nil {
+		return
+	}
+	if err == rcu.Block {
+		p.backoff *= 2
+		if p.backoff > configRetryMaxBackoff {
+			p.backoff = configRetryMaxBackoff
+		}
+		log.DefaultLogger.Warnf("[upstream] [cluster manager] config update still blocked for cluster %s, retry in %v", pc.cluster.Info().Name(), p.backoff)
+		time.AfterFunc(p.backoff, func() { r.retry(pc) })
+		r.updateMaxAgeLocked()
+		return
+	}
+	delete(r.pending, pc)
+	log.DefaultLogger.Infof("[upstream] [cluster manager] blocked config update applied for cluster %s after %v", pc.cluster.Info().Name(), time.Since(p.since))
+	r.updateMaxAgeLocked()
+}
+
+// updateMaxAgeLocked refreshes the max retry age gauge, callers hold r.mux
+func (r *configUpdateRetrier) updateMaxAgeLocked() {
+	if r.maxAge == nil {
+		return
+	}
+	var max time.Duration
+	now := time.Now()
+	for _, p := range r.pending {
+		if age := now.Sub(p.since); age > max {
+			max = age
+		}
+	}
+	r.maxAge.Update(max.Nanoseconds() / int64(time.Millisecond))
+}
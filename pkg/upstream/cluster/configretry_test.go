@@ -0,0 +1,56 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+)
+
+func TestBlockedConfigUpdateRetried(t *testing.T) {
+	info := &clusterInfo{name: "retry"}
+	pc := NewPrimaryCluster(&cluster{info: info}, &v2.Cluster{Name: "retry"}, true)
+
+	// hold a reader so the running update below cannot finish
+	held := pc.configLock.Load()
+	go pc.configLock.Update(&v2.Cluster{Name: "retry"}, time.Second)
+	time.Sleep(50 * time.Millisecond)
+
+	// this update hits rcu.Block, it must not surface an error and must
+	// be reapplied by the retrier once the running update returns
+	newCfg := &v2.Cluster{Name: "retry", MaxRequestPerConn: 100}
+	if err := pc.UpdateCluster(&cluster{info: info}, newCfg, true); err != nil {
+		t.Fatalf("blocked update should not surface an error, got %v", err)
+	}
+	pc.configLock.Put(held)
+
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		loaded := pc.configLock.Load()
+		c, ok := loaded.(*v2.Cluster)
+		applied := ok && c.MaxRequestPerConn == 100
+		pc.configLock.Put(loaded)
+		if applied {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Error("blocked config update was not reapplied")
+}
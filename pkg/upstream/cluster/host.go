@@ -19,10 +19,14 @@ package cluster
 
 import (
 	"context"
+	"hash/fnv"
 	"net"
+	"sort"
 	"sync"
 
 	"sofastack.io/sofa-mosn/pkg/api/v2"
+	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/mtls"
 	"sofastack.io/sofa-mosn/pkg/network"
 	"sofastack.io/sofa-mosn/pkg/types"
 )
@@ -82,7 +86,12 @@ type host struct {
 
 // NewHost used to create types.Host
 func NewHost(config v2.Host, clusterInfo types.ClusterInfo) types.Host {
-	addr, _ := net.ResolveTCPAddr("tcp", config.Address)
+	addr, err := network.ParseTCPAddress(config.Address)
+	if err != nil {
+		log.DefaultLogger.Errorf("[upstream] [host] invalid host address %s: %v", config.Address, err)
+	}
+	// map v4 upstreams into the nat64 prefix on ipv6-only nodes
+	addr = network.SynthesizeNAT64(addr)
 
 	return &host{
 		hostInfo: newHostInfo(addr, config, clusterInfo),
@@ -94,10 +103,20 @@ func (h *host) CreateConnection(context context.Context) types.CreateConnectionD
 	var tlsMng types.TLSContextManager
 	if !h.tlsDisable {
 		tlsMng = h.clusterInfo.TLSMng()
+		if h.hostname != "" {
+			// dns discovered hosts present and verify their own hostname
+			// instead of a cluster-wide server name
+			tlsMng = mtls.NewServerNameContextManager(tlsMng, h.hostname)
+		}
 	}
 
 	clientConn := network.NewClientConnection(h.clusterInfo.SourceAddress(), tlsMng, h.address, nil)
 	clientConn.SetBufferLimit(h.clusterInfo.ConnBufferLimitBytes())
+	if opts := h.clusterInfo.SocketOptions(); opts != nil {
+		if sc, ok := clientConn.(interface{ SetSocketOptions(*v2.SocketOptions) }); ok {
+			sc.SetSocketOptions(opts)
+		}
+	}
 
 	return types.CreateConnectionData{
 		Connection: clientConn,
@@ -152,6 +171,7 @@ type hostInfo struct {
 	clusterInfo    types.ClusterInfo
 	stats          types.HostStats
 	metaData       types.RouteMetaData
+	metaDataHash   uint64
 	originMetaData v2.Metadata
 	tlsDisable     bool
 	config         v2.Host
@@ -171,6 +191,7 @@ func newHostInfo(addr net.Addr, config v2.Host, clusterInfo types.ClusterInfo) h
 		clusterInfo:    clusterInfo,
 		stats:          newHostStats(name, config.Address),
 		metaData:       GenerateHostMetadata(config.MetaData),
+		metaDataHash:   HashMetadata(config.MetaData),
 		originMetaData: config.MetaData,
 		tlsDisable:     config.TLSDisable,
 		config:         config,
@@ -193,6 +214,12 @@ func (hi *hostInfo) OriginMetaData() v2.Metadata {
 	return hi.originMetaData
 }
 
+// MetadataHash returns the precomputed hash over the host's metadata,
+// hosts carrying identical metadata share the same hash
+func (hi *hostInfo) MetadataHash() uint64 {
+	return hi.metaDataHash
+}
+
 func (hi *hostInfo) ClusterInfo() types.ClusterInfo {
 	return hi.clusterInfo
 }
@@ -224,3 +251,27 @@ func GenerateHostMetadata(metadata v2.Metadata) types.RouteMetaData {
 
 	return rm
 }
+
+// HashMetadata returns a stable hash over the sorted metadata kv pairs.
+// It is computed once when a host is created, so subset classification
+// can reuse extraction results for hosts with identical metadata
+func HashMetadata(metadata v2.Metadata) uint64 {
+	if len(metadata) == 0 {
+		return 0
+	}
+
+	keys := make([]string, 0, len(metadata))
+	for k := range metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	h := fnv.New64a()
+	for _, k := range keys {
+		h.Write([]byte(k))
+		h.Write([]byte{0})
+		h.Write([]byte(metadata[k]))
+		h.Write([]byte{0})
+	}
+	return h.Sum64()
+}
@@ -0,0 +1,53 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"fmt"
+
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+	"sofastack.io/sofa-mosn/pkg/log"
+)
+
+// checkHostUpdateGuard validates a host update against the cluster's guard
+// config, an error means the update must be rejected and the current hosts
+// kept. guard may be nil which accepts everything
+func checkHostUpdateGuard(guard *v2.HostUpdateGuard, clusterName string, oldNum, newNum int) error {
+	if guard == nil {
+		return nil
+	}
+	if guard.MaxHosts > 0 && newNum > int(guard.MaxHosts) {
+		return fmt.Errorf("host update rejected on cluster %s: %d hosts exceeds max_hosts %d", clusterName, newNum, guard.MaxHosts)
+	}
+	if guard.EmptyUpdateProtection && oldNum > 0 && newNum == 0 {
+		return fmt.Errorf("host update rejected on cluster %s: update empties a cluster with %d hosts", clusterName, oldNum)
+	}
+	if guard.MaxRemovalPercent > 0 && oldNum > 0 && newNum < oldNum {
+		percent := (oldNum - newNum) * 100 / oldNum
+		if percent > int(guard.MaxRemovalPercent) {
+			if guard.WarnOnly {
+				log.DefaultLogger.Warnf("[upstream] [cluster] host update on cluster %s removes %d%% of hosts (%d -> %d), exceeds max_removal_percent %d",
+					clusterName, percent, oldNum, newNum, guard.MaxRemovalPercent)
+				return nil
+			}
+			return fmt.Errorf("host update rejected on cluster %s: removes %d%% of hosts (%d -> %d), exceeds max_removal_percent %d",
+				clusterName, percent, oldNum, newNum, guard.MaxRemovalPercent)
+		}
+	}
+	return nil
+}
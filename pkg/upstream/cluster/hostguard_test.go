@@ -0,0 +1,74 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"testing"
+
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+func TestCheckHostUpdateGuard(t *testing.T) {
+	cases := []struct {
+		name   string
+		guard  *v2.HostUpdateGuard
+		old    int
+		new    int
+		reject bool
+	}{
+		{"nil guard accepts", nil, 10, 0, false},
+		{"max hosts exceeded", &v2.HostUpdateGuard{MaxHosts: 5}, 3, 6, true},
+		{"max hosts within", &v2.HostUpdateGuard{MaxHosts: 5}, 3, 5, false},
+		{"empty protection", &v2.HostUpdateGuard{EmptyUpdateProtection: true}, 3, 0, true},
+		{"empty protection on empty cluster", &v2.HostUpdateGuard{EmptyUpdateProtection: true}, 0, 0, false},
+		{"churn exceeded", &v2.HostUpdateGuard{MaxRemovalPercent: 50}, 10, 4, true},
+		{"churn within", &v2.HostUpdateGuard{MaxRemovalPercent: 50}, 10, 5, false},
+		{"churn warn only", &v2.HostUpdateGuard{MaxRemovalPercent: 50, WarnOnly: true}, 10, 1, false},
+		{"growth ignores churn guard", &v2.HostUpdateGuard{MaxRemovalPercent: 50}, 2, 10, false},
+	}
+	for _, c := range cases {
+		err := checkHostUpdateGuard(c.guard, "test", c.old, c.new)
+		if c.reject && err == nil {
+			t.Errorf("%s: expected rejection, got nil", c.name)
+		}
+		if !c.reject && err != nil {
+			t.Errorf("%s: expected acceptance, got %v", c.name, err)
+		}
+	}
+}
+
+func TestUpdateHostsGuarded(t *testing.T) {
+	info := &clusterInfo{name: "guarded"}
+	config := &v2.Cluster{
+		Name:            "guarded",
+		Hosts:           []v2.Host{host1, host2, host3},
+		HostUpdateGuard: &v2.HostUpdateGuard{EmptyUpdateProtection: true},
+	}
+	pc := NewPrimaryCluster(&cluster{info: info}, config, true)
+
+	if err := pc.UpdateHosts(nil); err == nil {
+		t.Error("emptying update should be rejected")
+	}
+	if len(pc.configUsed.Hosts) != 3 {
+		t.Errorf("rejected update must keep current hosts, got %d", len(pc.configUsed.Hosts))
+	}
+	if err := pc.UpdateHosts([]types.Host{NewHost(host1, info)}); err != nil {
+		t.Errorf("partial update should be accepted, got %v", err)
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"sofastack.io/sofa-mosn/pkg/network"
 	"sofastack.io/sofa-mosn/pkg/types"
 )
 
@@ -56,6 +57,26 @@ type loadbalancer struct {
 	prioritySet types.PrioritySet
 }
 
+// hostSaturated returns whether the host reaches its per host concurrent
+// request limit. A host configured with max_concurrent_requests is skipped
+// at selection time once its in-flight requests reach the limit, so that a
+// slow host cannot accumulate most of the in-flight requests.
+func hostSaturated(host types.Host) bool {
+	maxConcurrent := host.Config().MaxConcurrentRequests
+	if maxConcurrent == 0 {
+		return false
+	}
+
+	return host.HostStats().UpstreamRequestActive.Count() >= int64(maxConcurrent)
+}
+
+// hostUnavailable returns whether the host should be skipped at selection
+// time, either because it is saturated or because its address is currently
+// blacklisted after repeated connect failures.
+func hostUnavailable(host types.Host) bool {
+	return hostSaturated(host) || network.IsAddressBlacklisted(host.AddressString())
+}
+
 type randomLoadBalancer struct {
 	loadbalancer
 	randInstance *rand.Rand
@@ -91,7 +112,15 @@ func (l *randomLoadBalancer) ChooseHost(context types.LoadBalancerContext) types
 
 	hostIdx := l.randInstance.Intn(len(hosts))
 
-	return hosts[hostIdx]
+	// skip saturated hosts, at most traverse over all hosts once
+	for i := 0; i < len(hosts); i++ {
+		host := hosts[(hostIdx+i)%len(hosts)]
+		if !hostUnavailable(host) {
+			return host
+		}
+	}
+
+	return nil
 }
 
 // TODO: more loadbalancers@boqin
@@ -136,10 +165,16 @@ func (l *roundRobinLoadBalancer) ChooseHost(context types.LoadBalancerContext) t
 		return nil
 	}
 
-	selectedHost := selectedHostSet[l.rrIndex%uint32(len(selectedHostSet))]
-	atomic.AddUint32(&l.rrIndex, 1)
+	// skip saturated hosts, at most traverse over all hosts once
+	for i := 0; i < len(selectedHostSet); i++ {
+		selectedHost := selectedHostSet[l.rrIndex%uint32(len(selectedHostSet))]
+		atomic.AddUint32(&l.rrIndex, 1)
+		if !hostUnavailable(selectedHost) {
+			return selectedHost
+		}
+	}
 
-	return selectedHost
+	return nil
 }
 
 /*
@@ -248,6 +283,9 @@ func (l *smoothWeightedRRLoadBalancer) ChooseHost(context types.LoadBalancerCont
 	hostSets := l.prioritySet.HostSetsByPriority()
 	for _, hosts := range hostSets {
 		for _, host := range hosts.HealthyHosts() {
+			if hostUnavailable(host) {
+				continue
+			}
 
 			if _, ok := l.hostsWeighted[host.AddressString()]; !ok {
 				// insert new health-host in case UpdateHost not timely
@@ -353,6 +353,64 @@ func TestSmoothWeightedRRLoadBalancer_UpdateHost(t *testing.T) {
 	}
 }
 
+func Test_loadBalancer_SkipSaturatedHost(t *testing.T) {
+	hostCfg1 := v2.Host{
+		HostConfig: v2.HostConfig{
+			Address:               "127.0.2.1",
+			Hostname:              "saturated",
+			MaxConcurrentRequests: 1,
+		},
+	}
+	hostCfg2 := v2.Host{
+		HostConfig: v2.HostConfig{
+			Address:               "127.0.2.2",
+			Hostname:              "available",
+			MaxConcurrentRequests: 1,
+		},
+	}
+
+	host1 := NewHost(hostCfg1, nil)
+	host2 := NewHost(hostCfg2, nil)
+
+	hosts := []types.Host{host1, host2}
+	hs := hostSet{
+		hosts:        hosts,
+		healthyHosts: hosts,
+	}
+	prioritySet := &prioritySet{
+		hostSets: []types.HostSet{&hs},
+	}
+
+	// host1 reaches its concurrent request limit
+	host1.HostStats().UpstreamRequestActive.Inc(1)
+	defer host1.HostStats().UpstreamRequestActive.Dec(1)
+
+	lbs := map[string]types.LoadBalancer{
+		"random":     newRandomLoadbalancer(prioritySet),
+		"roundrobin": newRoundRobinLoadBalancer(prioritySet),
+		"smoothwrr":  newSmoothWeightedRRLoadBalancer(prioritySet),
+	}
+
+	for name, lb := range lbs {
+		for i := 0; i < 10; i++ {
+			got := lb.ChooseHost(nil)
+			if got != host2 {
+				t.Errorf("%s lb choose saturated host, got %+v", name, got)
+			}
+		}
+	}
+
+	// all hosts saturated, no host can be chosen
+	host2.HostStats().UpstreamRequestActive.Inc(1)
+	defer host2.HostStats().UpstreamRequestActive.Dec(1)
+
+	for name, lb := range lbs {
+		if got := lb.ChooseHost(nil); got != nil {
+			t.Errorf("%s lb chose host %+v, but all hosts are saturated", name, got)
+		}
+	}
+}
+
 func MockRouter(names []string) v2.Router {
 	r := v2.Router{}
 	if len(names) < 2 {
@@ -0,0 +1,147 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+// hostZoneKey is the host metadata key carrying the zone of a host
+const hostZoneKey = "zone"
+
+// localityAwareLoadBalancer wraps the cluster's load balancer and biases
+// host selection by zone, so that traffic can prefer the local zone and
+// avoid cross-AZ transfer costs. Hosts without a zone label, or clusters
+// where no zoned host is available, fall through to the wrapped balancer
+type localityAwareLoadBalancer struct {
+	config       *v2.LocalityLbConfig
+	fallback     types.LoadBalancer
+	prioritySet  types.PrioritySet
+	stats        types.ClusterStats
+	randInstance *rand.Rand
+	randMutex    sync.Mutex
+}
+
+func newLocalityAwareLoadBalancer(config *v2.LocalityLbConfig, fallback types.LoadBalancer,
+	prioritySet types.PrioritySet, stats types.ClusterStats) types.LoadBalancer {
+	return &localityAwareLoadBalancer{
+		config:       config,
+		fallback:     fallback,
+		prioritySet:  prioritySet,
+		stats:        stats,
+		randInstance: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+func hostZone(host types.Host) string {
+	return host.OriginMetaData()[hostZoneKey]
+}
+
+func (l *localityAwareLoadBalancer) ChooseHost(context types.LoadBalancerContext) types.Host {
+	zones := make(map[string][]types.Host)
+	for _, hostSet := range l.prioritySet.HostSetsByPriority() {
+		for _, host := range hostSet.HealthyHosts() {
+			if hostUnavailable(host) {
+				continue
+			}
+			if zone := hostZone(host); zone != "" {
+				zones[zone] = append(zones[zone], host)
+			}
+		}
+	}
+
+	host := l.chooseZonedHost(zones)
+	if host == nil {
+		host = l.fallback.ChooseHost(context)
+	}
+	l.recordZone(host)
+	return host
+}
+
+// zoneWeight returns the configured preference weight, 1 when unset
+func (l *localityAwareLoadBalancer) zoneWeight(zone string) uint32 {
+	if w, ok := l.config.ZoneWeights[zone]; ok {
+		return w
+	}
+	return 1
+}
+
+// chooseZonedHost picks a zone by preference weight scaled with the number
+// of available hosts there, then a random host within the zone. With equal
+// weights every host gets an even share regardless of zone sizes. Zones
+// weighted 0 are a last resort used only when no weighted zone has hosts
+func (l *localityAwareLoadBalancer) chooseZonedHost(zones map[string][]types.Host) types.Host {
+	var total int64
+	for zone, hosts := range zones {
+		total += int64(l.zoneWeight(zone)) * int64(len(hosts))
+	}
+
+	l.randMutex.Lock()
+	defer l.randMutex.Unlock()
+
+	if total == 0 {
+		// every candidate zone is weighted 0, use them evenly
+		for _, hosts := range zones {
+			total += int64(len(hosts))
+		}
+		if total == 0 {
+			return nil
+		}
+		r := l.randInstance.Int63n(total)
+		for _, hosts := range zones {
+			if r < int64(len(hosts)) {
+				return hosts[r]
+			}
+			r -= int64(len(hosts))
+		}
+		return nil
+	}
+
+	r := l.randInstance.Int63n(total)
+	for zone, hosts := range zones {
+		span := int64(l.zoneWeight(zone)) * int64(len(hosts))
+		if r < span {
+			return hosts[int(r)%len(hosts)]
+		}
+		r -= span
+	}
+	return nil
+}
+
+// recordZone counts local against cross-zone selections so the cross-zone
+// request share per cluster can be derived from the stats
+func (l *localityAwareLoadBalancer) recordZone(host types.Host) {
+	if host == nil || l.config.LocalZone == "" {
+		return
+	}
+	zone := hostZone(host)
+	if zone == "" {
+		// unknown locality, neither local nor provably cross-zone
+		return
+	}
+	if zone == l.config.LocalZone {
+		l.stats.UpstreamRequestLocalZone.Inc(1)
+	} else {
+		l.stats.UpstreamRequestCrossZone.Inc(1)
+	}
+}
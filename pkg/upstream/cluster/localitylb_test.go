@@ -0,0 +1,122 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"testing"
+
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+func mockZonedPrioritySet() *prioritySet {
+	hostA1 := NewHost(newHostV2("10.0.1.1", "a1", 0, v2.Metadata{"zone": "zone-a"}), nil)
+	hostA2 := NewHost(newHostV2("10.0.1.2", "a2", 0, v2.Metadata{"zone": "zone-a"}), nil)
+	hostB1 := NewHost(newHostV2("10.0.2.1", "b1", 0, v2.Metadata{"zone": "zone-b"}), nil)
+
+	hosts := []types.Host{hostA1, hostA2, hostB1}
+	hs := hostSet{
+		hosts:        hosts,
+		healthyHosts: hosts,
+	}
+	return &prioritySet{
+		hostSets: []types.HostSet{&hs},
+	}
+}
+
+func newTestLocalityLb(config *v2.LocalityLbConfig, ps *prioritySet) *localityAwareLoadBalancer {
+	fallback := newRandomLoadbalancer(ps)
+	return newLocalityAwareLoadBalancer(config, fallback, ps, newClusterStats("locality_test")).(*localityAwareLoadBalancer)
+}
+
+func TestLocalityLbPrefersWeightedZone(t *testing.T) {
+	ps := mockZonedPrioritySet()
+	lb := newTestLocalityLb(&v2.LocalityLbConfig{
+		LocalZone: "zone-a",
+		ZoneWeights: map[string]uint32{
+			"zone-a": 100,
+			"zone-b": 1,
+		},
+	}, ps)
+
+	selected := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		host := lb.ChooseHost(nil)
+		if host == nil {
+			t.Fatal("no host selected")
+		}
+		selected[hostZone(host)]++
+	}
+	if selected["zone-a"] <= selected["zone-b"]*10 {
+		t.Errorf("zone-a should dominate the selection, got %v", selected)
+	}
+}
+
+func TestLocalityLbZeroWeightLastResort(t *testing.T) {
+	ps := mockZonedPrioritySet()
+	lb := newTestLocalityLb(&v2.LocalityLbConfig{
+		ZoneWeights: map[string]uint32{
+			"zone-a": 0,
+			"zone-b": 0,
+		},
+	}, ps)
+
+	// all zones weighted 0 still serve traffic as a last resort
+	for i := 0; i < 10; i++ {
+		if host := lb.ChooseHost(nil); host == nil {
+			t.Fatal("zero weighted zones should still serve as last resort")
+		}
+	}
+}
+
+func TestLocalityLbFallbackWithoutZones(t *testing.T) {
+	host1 := NewHost(newHostV2("10.0.3.1", "nozone", 0, nil), nil)
+	hosts := []types.Host{host1}
+	hs := hostSet{
+		hosts:        hosts,
+		healthyHosts: hosts,
+	}
+	ps := &prioritySet{
+		hostSets: []types.HostSet{&hs},
+	}
+	lb := newTestLocalityLb(&v2.LocalityLbConfig{LocalZone: "zone-a"}, ps)
+
+	if host := lb.ChooseHost(nil); host == nil || host.AddressString() != "10.0.3.1" {
+		t.Errorf("unlabeled hosts should be served via the fallback lb, got %v", host)
+	}
+}
+
+func TestLocalityLbCrossZoneStats(t *testing.T) {
+	ps := mockZonedPrioritySet()
+	lb := newTestLocalityLb(&v2.LocalityLbConfig{LocalZone: "zone-a"}, ps)
+
+	local := lb.stats.UpstreamRequestLocalZone.Count()
+	cross := lb.stats.UpstreamRequestCrossZone.Count()
+	rounds := int64(300)
+	for i := int64(0); i < rounds; i++ {
+		lb.ChooseHost(nil)
+	}
+	localDelta := lb.stats.UpstreamRequestLocalZone.Count() - local
+	crossDelta := lb.stats.UpstreamRequestCrossZone.Count() - cross
+	if localDelta+crossDelta != rounds {
+		t.Errorf("every selection should be counted, local %d cross %d", localDelta, crossDelta)
+	}
+	if localDelta == 0 || crossDelta == 0 {
+		t.Errorf("both zones should receive traffic with default weights, local %d cross %d", localDelta, crossDelta)
+	}
+}
@@ -0,0 +1,68 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"testing"
+
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+func TestHashMetadata(t *testing.T) {
+	a := HashMetadata(v2.Metadata{"zone": "a", "version": "1.0"})
+	b := HashMetadata(v2.Metadata{"version": "1.0", "zone": "a"})
+	if a != b {
+		t.Error("hash should not depend on map iteration order")
+	}
+	if c := HashMetadata(v2.Metadata{"zone": "b", "version": "1.0"}); c == a {
+		t.Error("different metadata should hash differently")
+	}
+	if HashMetadata(nil) != 0 {
+		t.Error("empty metadata should hash to zero")
+	}
+}
+
+func TestExtractSubsetMetadataCached(t *testing.T) {
+	sslb := &subSetLoadBalancer{}
+	makeHost := func(addr string, meta v2.Metadata) types.Host {
+		return NewHost(v2.Host{
+			HostConfig: v2.HostConfig{Address: addr},
+			MetaData:   meta,
+		}, nil)
+	}
+	h1 := makeHost("127.0.0.1:8080", v2.Metadata{"zone": "a", "version": "1.0"})
+	h2 := makeHost("127.0.0.1:8081", v2.Metadata{"zone": "a", "version": "1.0"})
+	keys := []string{"version", "zone"}
+
+	kvs1 := sslb.ExtractSubsetMetadata(keys, h1)
+	if len(kvs1) != 2 || kvs1[0].T1 != "version" || kvs1[1].T1 != "zone" {
+		t.Fatalf("unexpected extracted metadata: %+v", kvs1)
+	}
+	// the second host has identical metadata and must hit the cache
+	kvs2 := sslb.ExtractSubsetMetadata(keys, h2)
+	if &kvs1[0] != &kvs2[0] {
+		t.Error("hosts with identical metadata should share the cached extraction")
+	}
+
+	// a missing subset key yields an empty result
+	h3 := makeHost("127.0.0.1:8082", v2.Metadata{"zone": "a"})
+	if kvs := sslb.ExtractSubsetMetadata(keys, h3); len(kvs) != 0 {
+		t.Errorf("incomplete metadata should extract nothing, got %+v", kvs)
+	}
+}
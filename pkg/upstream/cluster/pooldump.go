@@ -0,0 +1,72 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"sofastack.io/sofa-mosn/pkg/admin/server"
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+func init() {
+	server.RegisterAdminHandleFunc("/api/v1/pools", dumpConnPools)
+}
+
+// dumpConnPools is the admin endpoint exposing per protocol connection pool
+// state so a "no healthy host" from getActiveConnectionPool can be diagnosed
+// without a debug build. Pools not implementing types.PoolStatusProvider are
+// reported as opaque
+func dumpConnPools(w http.ResponseWriter, r *http.Request) {
+	// protocol -> pool key (address, optionally with a partition suffix) -> status
+	dump := make(map[string]map[string]types.PoolStatus)
+
+	instanceMutex.Lock()
+	cm := clusterMangerInstance
+	instanceMutex.Unlock()
+
+	if cm != nil {
+		cm.protocolConnPool.Range(func(protocolKey, poolsValue interface{}) bool {
+			protocolName := string(protocolKey.(types.Protocol))
+			pools := poolsValue.(*sync.Map)
+			statuses := make(map[string]types.PoolStatus)
+			pools.Range(func(poolKey, poolValue interface{}) bool {
+				if provider, ok := poolValue.(types.PoolStatusProvider); ok {
+					statuses[poolKey.(string)] = provider.PoolStatus()
+				} else {
+					statuses[poolKey.(string)] = types.PoolStatus{Protocol: protocolName, LastError: "", Connections: nil}
+				}
+				return true
+			})
+			if len(statuses) > 0 {
+				dump[protocolName] = statuses
+			}
+			return true
+		})
+	}
+
+	buf, err := json.Marshal(dump)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf)
+}
@@ -0,0 +1,87 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+type fakeStatusPool struct {
+	status types.PoolStatus
+}
+
+func (p *fakeStatusPool) PoolStatus() types.PoolStatus {
+	return p.status
+}
+
+type opaquePool struct{}
+
+func TestDumpConnPools(t *testing.T) {
+	instanceMutex.Lock()
+	oldInstance := clusterMangerInstance
+	cm := &clusterManager{}
+	pools := &sync.Map{}
+	pools.Store("127.0.0.1:8080", &fakeStatusPool{
+		status: types.PoolStatus{
+			Protocol:  "SofaRpc",
+			LastError: "connect timeout at 2019-01-01 14:03:00.000",
+			Connections: []types.PoolConnectionStatus{
+				{State: "connected", ActiveStreams: 2, TotalStreams: 10, Age: "3s"},
+			},
+		},
+	})
+	pools.Store("127.0.0.1:9090", &opaquePool{})
+	cm.protocolConnPool.Store(types.Protocol("SofaRpc"), pools)
+	clusterMangerInstance = cm
+	instanceMutex.Unlock()
+	defer func() {
+		instanceMutex.Lock()
+		clusterMangerInstance = oldInstance
+		instanceMutex.Unlock()
+	}()
+
+	w := httptest.NewRecorder()
+	dumpConnPools(w, httptest.NewRequest("GET", "/api/v1/pools", nil))
+	if w.Code != 200 {
+		t.Fatalf("dump status %d", w.Code)
+	}
+	var dump map[string]map[string]types.PoolStatus
+	if err := json.Unmarshal(w.Body.Bytes(), &dump); err != nil {
+		t.Fatalf("unmarshal dump: %v", err)
+	}
+	statuses, ok := dump["SofaRpc"]
+	if !ok || len(statuses) != 2 {
+		t.Fatalf("unexpected dump: %v", dump)
+	}
+	status := statuses["127.0.0.1:8080"]
+	if status.LastError == "" || len(status.Connections) != 1 {
+		t.Errorf("unexpected pool status: %+v", status)
+	}
+	if status.Connections[0].State != "connected" || status.Connections[0].ActiveStreams != 2 {
+		t.Errorf("unexpected connection status: %+v", status.Connections[0])
+	}
+	// pools without introspection support appear as opaque entries
+	if opaque := statuses["127.0.0.1:9090"]; opaque.Connections != nil {
+		t.Errorf("opaque pool should have no connections: %+v", opaque)
+	}
+}
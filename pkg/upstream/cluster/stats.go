@@ -60,6 +60,8 @@ func newClusterStats(clustername string) types.ClusterStats {
 		UpstreamRequestDurationTotal:                   s.Counter(metrics.UpstreamRequestDurationTotal),
 		UpstreamResponseSuccess:                        s.Counter(metrics.UpstreamResponseSuccess),
 		UpstreamResponseFailed:                         s.Counter(metrics.UpstreamResponseFailed),
+		UpstreamRequestLocalZone:                       s.Counter(metrics.UpstreamRequestLocalZone),
+		UpstreamRequestCrossZone:                       s.Counter(metrics.UpstreamRequestCrossZone),
 		LBSubSetsFallBack:                              s.Counter(metrics.UpstreamLBSubSetsFallBack),
 		LBSubSetsActive:                                s.Counter(metrics.UpstreamLBSubSetsActive),
 		LBSubsetsCreated:                               s.Counter(metrics.UpstreamLBSubsetsCreated),
@@ -0,0 +1,139 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"sofastack.io/sofa-mosn/pkg/admin/server"
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+func init() {
+	server.RegisterAdminHandleFunc("/api/v1/subsets", dumpSubsets)
+}
+
+// SubsetDump describes one materialized subset: the metadata it matches,
+// the hosts it currently contains and how often it was selected
+type SubsetDump struct {
+	Subset        map[string]string `json:"subset"`
+	Hosts         []string          `json:"hosts"`
+	SelectedCount uint64            `json:"selected_count"`
+}
+
+// ClusterSubsetsDump is the per cluster view of the subset load balancer
+type ClusterSubsetsDump struct {
+	FallbackHits  int64        `json:"fallback_hits"`
+	FallbackHosts []string     `json:"fallback_hosts,omitempty"`
+	Subsets       []SubsetDump `json:"subsets"`
+}
+
+// dumpSubsets is the admin endpoint exposing subset load balancer internals
+// so operators can verify that metadata based routing matches the intended
+// hosts. Clusters without a subset load balancer are omitted
+func dumpSubsets(w http.ResponseWriter, r *http.Request) {
+	dump := make(map[string]ClusterSubsetsDump)
+
+	instanceMutex.Lock()
+	cm := clusterMangerInstance
+	instanceMutex.Unlock()
+
+	if cm != nil {
+		cm.primaryClusters.Range(func(key, value interface{}) bool {
+			pc := value.(*primaryCluster)
+			if sslb, ok := pc.cluster.Info().LBInstance().(*subSetLoadBalancer); ok {
+				dump[key.(string)] = sslb.DumpSubsets()
+			}
+			return true
+		})
+	}
+
+	buf, err := json.Marshal(dump)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf)
+}
+
+// DumpSubsets walks the subset trie and reports every initialized subset
+// along with the fallback state
+func (sslb *subSetLoadBalancer) DumpSubsets() ClusterSubsetsDump {
+	dump := ClusterSubsetsDump{
+		FallbackHits: sslb.stats.LBSubSetsFallBack.Count(),
+		Subsets:      []SubsetDump{},
+	}
+	if sslb.fallbackSubset != nil {
+		dump.FallbackHosts = subsetHostAddresses(sslb.fallbackSubset.prioritySubset)
+	}
+
+	collectSubsets(sslb.subSets, nil, &dump.Subsets)
+
+	// deterministic order for operators diffing successive dumps
+	sort.Slice(dump.Subsets, func(i, j int) bool {
+		return subsetSortKey(dump.Subsets[i].Subset) < subsetSortKey(dump.Subsets[j].Subset)
+	})
+	return dump
+}
+
+func collectSubsets(subsets types.LbSubsetMap, path []types.Pair, out *[]SubsetDump) {
+	for name, vsMap := range subsets {
+		for value, entry := range vsMap {
+			kvs := make([]types.Pair, len(path), len(path)+1)
+			copy(kvs, path)
+			kvs = append(kvs, types.Pair{T1: name, T2: value})
+
+			if lbEntry, ok := entry.(*LBSubsetEntry); ok && lbEntry.Initialized() {
+				subset := make(map[string]string, len(kvs))
+				for _, kv := range kvs {
+					subset[kv.T1] = string(kv.T2)
+				}
+				*out = append(*out, SubsetDump{
+					Subset:        subset,
+					Hosts:         subsetHostAddresses(lbEntry.prioritySubset),
+					SelectedCount: atomic.LoadUint64(&lbEntry.selected),
+				})
+			}
+			collectSubsets(entry.Children(), kvs, out)
+		}
+	}
+}
+
+func subsetHostAddresses(ps types.PrioritySubset) []string {
+	hosts := ps.GetOrCreateHostSubset(0).Hosts()
+	addrs := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		addrs = append(addrs, host.AddressString())
+	}
+	sort.Strings(addrs)
+	return addrs
+}
+
+func subsetSortKey(subset map[string]string) string {
+	kvs := make([]string, 0, len(subset))
+	for k, v := range subset {
+		kvs = append(kvs, k+"="+v)
+	}
+	sort.Strings(kvs)
+	return strings.Join(kvs, ",")
+}
@@ -0,0 +1,80 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"testing"
+
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+func TestDumpSubsets(t *testing.T) {
+	ps := createPrioritySet(ExampleHostConfigs())
+	cfg := &v2.LBSubsetConfig{
+		FallBackPolicy:  1, // AnyEndPoint
+		SubsetSelectors: ExampleSubsetConfig().SubsetSelectors,
+	}
+	lb := NewSubsetLoadBalancer(types.RoundRobin, ps, newClusterStats("TestDumpSubsets"), NewLBSubsetInfo(cfg))
+	sslb := lb.(*subSetLoadBalancer)
+
+	// a matched choose bumps the subset's selection count
+	ctx := newMockLbContext(map[string]string{
+		"stage": "prod",
+		"type":  "bigmem",
+	})
+	if host := lb.ChooseHost(ctx); host == nil {
+		t.Fatal("choose host with matched metadata failed")
+	}
+	// an unmatched choose falls back and bumps the fallback counter
+	fallbackCtx := newMockLbContext(map[string]string{
+		"stage": "unknown",
+	})
+	if host := lb.ChooseHost(fallbackCtx); host == nil {
+		t.Fatal("choose host with fallback failed")
+	}
+
+	dump := sslb.DumpSubsets()
+	if dump.FallbackHits != 1 {
+		t.Errorf("expected 1 fallback hit, got %d", dump.FallbackHits)
+	}
+	if len(dump.FallbackHosts) != 7 {
+		t.Errorf("expected all 7 hosts in fallback subset, got %v", dump.FallbackHosts)
+	}
+	// 10 subsets, see ExampleResult
+	if len(dump.Subsets) != 10 {
+		t.Fatalf("expected 10 subsets, got %d", len(dump.Subsets))
+	}
+	found := false
+	for _, s := range dump.Subsets {
+		if s.Subset["stage"] == "prod" && s.Subset["type"] == "bigmem" && len(s.Subset) == 2 {
+			found = true
+			if len(s.Hosts) != 2 {
+				t.Errorf("expected 2 hosts in stage=prod,type=bigmem, got %v", s.Hosts)
+			}
+			if s.SelectedCount != 1 {
+				t.Errorf("expected selected_count 1 for stage=prod,type=bigmem, got %d", s.SelectedCount)
+			}
+		} else if s.SelectedCount != 0 {
+			t.Errorf("unselected subset %v has selected_count %d", s.Subset, s.SelectedCount)
+		}
+	}
+	if !found {
+		t.Errorf("subset stage=prod,type=bigmem not found in dump")
+	}
+}
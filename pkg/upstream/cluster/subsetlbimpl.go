@@ -21,6 +21,10 @@ import (
 	"math/rand"
 	"reflect"
 	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 
 	"sofastack.io/sofa-mosn/pkg/api/v2"
 	"sofastack.io/sofa-mosn/pkg/log"
@@ -38,6 +42,13 @@ type subSetLoadBalancer struct {
 	originalPrioritySet   types.PrioritySet
 	fallbackSubset        *LBSubsetEntry    // subset entry generated according to fallback policy
 	subSets               types.LbSubsetMap // final trie-like structure used to stored easily searched subset
+	extractCache          sync.Map          // extracted subset metadata keyed by host metadata hash and subset keys
+}
+
+// metadataHasher is implemented by hosts that precompute a hash over their
+// metadata, see hostInfo.MetadataHash
+type metadataHasher interface {
+	MetadataHash() uint64
 }
 
 func NewSubsetLoadBalancer(lbType types.LoadBalancerType, prioritySet types.PrioritySet, stats types.ClusterStats,
@@ -121,6 +132,27 @@ func (sslb *subSetLoadBalancer) GetHostsNumber(metadata types.MetadataMatchCrite
 	return uint32(len(entry.PrioritySubset().GetOrCreateHostSubset(0).Hosts()))
 }
 
+// GetHostsWithMetadata used to get the hosts for given matchCriterias without choosing one
+func (sslb *subSetLoadBalancer) GetHostsWithMetadata(metadata types.MetadataMatchCriteria) []types.Host {
+	matchCriteria := metadata.MetadataMatchCriteria()
+
+	if len(matchCriteria) == 0 {
+		return nil
+	}
+
+	entry := sslb.FindSubset(matchCriteria)
+
+	if entry == nil || !entry.Active() {
+		return nil
+	}
+
+	hosts := entry.PrioritySubset().GetOrCreateHostSubset(0).Hosts()
+	// copy the subset's host list, it is updated in place on host changes
+	result := make([]types.Host, len(hosts))
+	copy(result, hosts)
+	return result
+}
+
 // create or update subsets for this priority
 func (sslb *subSetLoadBalancer) Update(priority uint32, hostAdded []types.Host, hostsRemoved []types.Host) {
 
@@ -175,6 +207,10 @@ func (sslb *subSetLoadBalancer) TryChooseHostFromContext(context types.LoadBalan
 		return nil, false
 	}
 
+	if lbEntry, ok := entry.(*LBSubsetEntry); ok {
+		atomic.AddUint64(&lbEntry.selected, 1)
+	}
+
 	return entry.PrioritySubset().LB().ChooseHost(context), true
 }
 
@@ -347,30 +383,38 @@ func (sslb *subSetLoadBalancer) FindOrCreateSubset(subsets types.LbSubsetMap,
 // 生成规则：subsetkeys中所有的key均在host的metadata中出现
 
 func (sslb *subSetLoadBalancer) ExtractSubsetMetadata(subsetKeys []string, host types.Host) types.SubsetMetadata {
-	metadata := host.Metadata()
-	var kvs types.SubsetMetadata
+	// hosts with identical metadata share the extraction result, large
+	// eds-driven rebuilds mostly hit the cache instead of rebuilding kvs
+	hasher, cacheable := host.(metadataHasher)
+	if cacheable {
+		key := extractCacheKey(hasher.MetadataHash(), subsetKeys)
+		if cached, ok := sslb.extractCache.Load(key); ok {
+			return cached.(types.SubsetMetadata)
+		}
+		kvs := extractSubsetMetadata(subsetKeys, host.Metadata())
+		sslb.extractCache.Store(key, kvs)
+		return kvs
+	}
+	return extractSubsetMetadata(subsetKeys, host.Metadata())
+}
 
-	for _, key := range subsetKeys {
-		exist := false
-		var value types.HashedValue
+func extractCacheKey(metadataHash uint64, subsetKeys []string) string {
+	// subset keys are already sorted, see GenerateSubsetKeys
+	return strconv.FormatUint(metadataHash, 16) + "|" + strings.Join(subsetKeys, ",")
+}
 
-		for keyM, valueM := range metadata {
-			if keyM == key {
-				value = valueM
-				exist = true
-				break
-			}
-		}
+func extractSubsetMetadata(subsetKeys []string, metadata types.RouteMetaData) types.SubsetMetadata {
+	var kvs types.SubsetMetadata
 
+	for _, key := range subsetKeys {
+		value, exist := metadata[key]
 		if !exist {
 			break
-		} else {
-			v := types.Pair{
-				T1: key,
-				T2: value,
-			}
-			kvs = append(kvs, v)
 		}
+		kvs = append(kvs, types.Pair{
+			T1: key,
+			T2: value,
+		})
 	}
 
 	if len(kvs) != len(subsetKeys) {
@@ -383,6 +427,7 @@ func (sslb *subSetLoadBalancer) ExtractSubsetMetadata(subsetKeys []string, host
 type LBSubsetEntry struct {
 	children       types.LbSubsetMap
 	prioritySubset types.PrioritySubset
+	selected       uint64 // times this subset was selected by a match, accessed atomically
 }
 
 func (lbbe *LBSubsetEntry) Initialized() bool {
@@ -788,6 +788,37 @@ func TestSubsetGetHostsNumber(t *testing.T) {
 	}
 }
 
+// HostsWithMetadata returns the matched subset's hosts without choosing one
+// stage:prod, version:1.0 should find e1,e2,e5
+// stage:prod should find nothing (no such subset key)
+func TestSubsetGetHostsWithMetadata(t *testing.T) {
+	ps := createPrioritySet(ExampleHostConfigs())
+	lb := NewSubsetLoadBalancer(types.RoundRobin, ps, newClusterStats("TestSubsetGetHostsWithMetadata"), NewLBSubsetInfo(ExampleSubsetConfig()))
+	if lb == nil {
+		t.Fatal("create subset lb failed")
+	}
+	sslb := lb.(*subSetLoadBalancer)
+	ctx := newMockLbContext(map[string]string{
+		"stage":   "prod",
+		"version": "1.0",
+	})
+	hosts := sslb.GetHostsWithMetadata(ctx.MetadataMatchCriteria())
+	if len(hosts) != 3 {
+		t.Fatalf("expected 3 hosts in the subset, got %d", len(hosts))
+	}
+	for _, h := range hosts {
+		if !strInSlice(h.Hostname(), []string{"e1", "e2", "e5"}) {
+			t.Error("host found, but not the expected subset", h.Hostname())
+		}
+	}
+	noMatch := newMockLbContext(map[string]string{
+		"stage": "prod",
+	})
+	if hosts := sslb.GetHostsWithMetadata(noMatch.MetadataMatchCriteria()); len(hosts) != 0 {
+		t.Errorf("expected no hosts for an unknown subset, got %d", len(hosts))
+	}
+}
+
 func TestGetFinalHost(t *testing.T) {
 	pool := makePool(100)
 	hosts := pool.MakeHosts(10)
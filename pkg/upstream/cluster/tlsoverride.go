@@ -0,0 +1,94 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+	"sofastack.io/sofa-mosn/pkg/network"
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+// TLSOverrideKey is the route metadata key that selects a named tls
+// override of the cluster for the upstream connection, see the cluster's
+// tls_overrides config
+const TLSOverrideKey = "tls_override"
+
+// TLSOverrideDisabled is the reserved override name forcing a plaintext
+// upstream connection regardless of the cluster's tls context
+const TLSOverrideDisabled = "disable"
+
+// connPoolTLSOverride reads the tls override name from the route's
+// metadata match criteria, empty means the cluster's default tls context
+func connPoolTLSOverride(balancerContext types.LoadBalancerContext) string {
+	criteria := balancerContext.MetadataMatchCriteria()
+	if criteria == nil {
+		return ""
+	}
+	for _, criterion := range criteria.MetadataMatchCriteria() {
+		if criterion.MetadataKeyName() == TLSOverrideKey {
+			return string(criterion.MetadataValue())
+		}
+	}
+	return ""
+}
+
+// tlsOverrideHost wraps a chosen host so connections are established with
+// a different tls context than the cluster default. Connection pools built
+// from the wrapper are keyed separately, so distinct tls identities never
+// share upstream connections
+type tlsOverrideHost struct {
+	types.Host
+	tlsMng types.TLSContextManager
+}
+
+// applyTLSOverride resolves the named override against the cluster config.
+// An unknown name is an error rather than a fallback, silently connecting
+// with the default identity would defeat the override
+func applyTLSOverride(info types.ClusterInfo, host types.Host, override string) (types.Host, error) {
+	if override == TLSOverrideDisabled {
+		return &tlsOverrideHost{Host: host}, nil
+	}
+	ci, ok := info.(*clusterInfo)
+	if !ok {
+		return nil, fmt.Errorf("cluster %s does not support tls overrides", info.Name())
+	}
+	mgr, ok := ci.tlsOverrides[override]
+	if !ok || mgr == nil {
+		return nil, fmt.Errorf("cluster %s has no tls override named %s", ci.name, override)
+	}
+	return &tlsOverrideHost{Host: host, tlsMng: mgr}, nil
+}
+
+func (h *tlsOverrideHost) CreateConnection(context context.Context) types.CreateConnectionData {
+	info := h.ClusterInfo()
+	clientConn := network.NewClientConnection(info.SourceAddress(), h.tlsMng, h.Address(), nil)
+	clientConn.SetBufferLimit(info.ConnBufferLimitBytes())
+	if opts := info.SocketOptions(); opts != nil {
+		if sc, ok := clientConn.(interface{ SetSocketOptions(*v2.SocketOptions) }); ok {
+			sc.SetSocketOptions(opts)
+		}
+	}
+
+	return types.CreateConnectionData{
+		Connection: clientConn,
+		HostInfo:   h.Host,
+	}
+}
@@ -0,0 +1,89 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"net"
+	"testing"
+
+	"sofastack.io/sofa-mosn/pkg/mtls/crypto/tls"
+
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+	"sofastack.io/sofa-mosn/pkg/types"
+)
+
+type fakeTLSContextManager struct{}
+
+func (f *fakeTLSContextManager) Enabled() bool { return true }
+func (f *fakeTLSContextManager) Conn(c net.Conn) net.Conn {
+	return c
+}
+func (f *fakeTLSContextManager) Config() *tls.Config { return nil }
+
+func TestConnPoolTLSOverride(t *testing.T) {
+	// no criteria means the cluster's default tls context
+	if got := connPoolTLSOverride(&fakeLbContext{}); got != "" {
+		t.Errorf("expected empty override, got %s", got)
+	}
+	ctx := &partitionLbContext{
+		criteria: &fakeMatchCriteria{
+			criteria: []types.MetadataMatchCriterion{
+				&fakeMatchCriterion{name: TLSOverrideKey, value: "payments"},
+			},
+		},
+	}
+	if got := connPoolTLSOverride(ctx); got != "payments" {
+		t.Errorf("expected override payments, got %s", got)
+	}
+}
+
+func TestApplyTLSOverride(t *testing.T) {
+	var mgr types.TLSContextManager = &fakeTLSContextManager{}
+	info := &clusterInfo{
+		name:         "test",
+		tlsOverrides: map[string]types.TLSContextManager{"payments": mgr},
+	}
+	h := &host{hostInfo: newHostInfo(nil, v2.Host{HostConfig: v2.HostConfig{Address: "127.0.0.1:8080"}}, info)}
+
+	// the reserved name forces a plaintext connection
+	plain, err := applyTLSOverride(info, h, TLSOverrideDisabled)
+	if err != nil {
+		t.Fatalf("disable override failed: %v", err)
+	}
+	if oh, ok := plain.(*tlsOverrideHost); !ok || oh.tlsMng != nil {
+		t.Errorf("disable override should wrap the host without a tls manager")
+	}
+
+	// a named override wraps the host with its own tls manager
+	named, err := applyTLSOverride(info, h, "payments")
+	if err != nil {
+		t.Fatalf("named override failed: %v", err)
+	}
+	if oh, ok := named.(*tlsOverrideHost); !ok || oh.tlsMng != mgr {
+		t.Errorf("named override should carry the override tls manager")
+	}
+	// the wrapper keeps the original host identity for lb and stats
+	if named.AddressString() != h.AddressString() {
+		t.Errorf("override host changed the address")
+	}
+
+	// unknown overrides fail instead of falling back to the default identity
+	if _, err := applyTLSOverride(info, h, "unknown"); err == nil {
+		t.Errorf("unknown override should be rejected")
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"runtime/debug"
 	"sync/atomic"
 
+	"sofastack.io/sofa-mosn/pkg/journal"
 	"sofastack.io/sofa-mosn/pkg/log"
 	"sofastack.io/sofa-mosn/pkg/types"
 	"sofastack.io/sofa-mosn/pkg/utils"
@@ -126,6 +127,7 @@ func (c *sessionChecker) HandleSuccess() {
 		if c.healthCount == c.HealthChecker.healthyThreshold {
 			changed = true
 			c.Host.ClearHealthFlag(types.FAILED_ACTIVE_HC)
+			journal.Record(journal.TypeHealthChange, "host %s back to healthy", c.Host.AddressString())
 		}
 	}
 	c.HealthChecker.incHealthy(c.Host, changed)
@@ -140,6 +142,7 @@ func (c *sessionChecker) HandleFailure(reason types.FailureType) {
 		if c.unHealthCount == c.HealthChecker.unhealthyThreshold {
 			changed = true
 			c.Host.SetHealthFlag(types.FAILED_ACTIVE_HC)
+			journal.Record(journal.TypeHealthChange, "host %s marked unhealthy, reason = %v", c.Host.AddressString(), reason)
 		}
 	}
 	c.HealthChecker.decHealthy(c.Host, reason, changed)
@@ -0,0 +1,70 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Clock is the wall clock source used for request timing. Production code
+// uses the system clock, tests can inject a fake one via SetClock to make
+// timeout and retry timing deterministic
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) Since(t time.Time) time.Duration {
+	return time.Since(t)
+}
+
+// clockHolder keeps atomic.Value happy when different Clock implementations are stored
+type clockHolder struct {
+	clock Clock
+}
+
+var clock atomic.Value
+
+func init() {
+	clock.Store(clockHolder{clock: systemClock{}})
+}
+
+// SetClock replaces the process clock source, nil restores the system clock
+func SetClock(c Clock) {
+	if c == nil {
+		c = systemClock{}
+	}
+	clock.Store(clockHolder{clock: c})
+}
+
+// Now returns the current time from the configured clock source
+func Now() time.Time {
+	return clock.Load().(clockHolder).clock.Now()
+}
+
+// Since returns the time elapsed since t based on the configured clock source
+func Since(t time.Time) time.Duration {
+	return clock.Load().(clockHolder).clock.Since(t)
+}
@@ -0,0 +1,54 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+type fixedClock struct {
+	now time.Time
+}
+
+func (c *fixedClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fixedClock) Since(t time.Time) time.Duration {
+	return c.now.Sub(t)
+}
+
+func TestSetClock(t *testing.T) {
+	base := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+	SetClock(&fixedClock{now: base})
+	defer SetClock(nil)
+
+	if got := Now(); !got.Equal(base) {
+		t.Errorf("Now() = %v, want %v", got, base)
+	}
+	if got := Since(base.Add(-time.Minute)); got != time.Minute {
+		t.Errorf("Since() = %v, want 1m", got)
+	}
+
+	// nil restores the system clock
+	SetClock(nil)
+	if Since(time.Now()) > time.Second {
+		t.Error("system clock not restored")
+	}
+}
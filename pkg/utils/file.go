@@ -22,8 +22,23 @@ import (
 	"os"
 )
 
+// writeGuard is consulted before every runtime file write, the sandbox
+// package installs one when hardening is enabled
+var writeGuard func(filename string) error
+
+// SetWriteGuard installs a check that can refuse runtime file writes,
+// passing nil removes it
+func SetWriteGuard(guard func(filename string) error) {
+	writeGuard = guard
+}
+
 // WriteFileSafety trys to over write a file safety.
 func WriteFileSafety(filename string, data []byte, perm os.FileMode) (err error) {
+	if writeGuard != nil {
+		if err = writeGuard(filename); err != nil {
+			return err
+		}
+	}
 	tempFile := filename + ".tmp"
 Try:
 	for i := 0; i < 5; i++ {
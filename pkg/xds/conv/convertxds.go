@@ -23,13 +23,6 @@ import (
 	"strings"
 	"time"
 
-	"sofastack.io/sofa-mosn/pkg/api/v2"
-	"sofastack.io/sofa-mosn/pkg/config"
-	"sofastack.io/sofa-mosn/pkg/log"
-	"sofastack.io/sofa-mosn/pkg/protocol"
-	"sofastack.io/sofa-mosn/pkg/router"
-	xdsxproxy "sofastack.io/sofa-mosn/pkg/xds/model/filter/network/x_proxy/v2"
-	"sofastack.io/sofa-mosn/pkg/xds/v2/rds"
 	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
 	xdsauth "github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
 	xdscluster "github.com/envoyproxy/go-control-plane/envoy/api/v2/cluster"
@@ -45,6 +38,13 @@ import (
 	"github.com/gogo/protobuf/types"
 	"github.com/golang/protobuf/jsonpb"
 	"istio.io/api/mixer/v1/config/client"
+	"sofastack.io/sofa-mosn/pkg/api/v2"
+	"sofastack.io/sofa-mosn/pkg/config"
+	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/protocol"
+	"sofastack.io/sofa-mosn/pkg/router"
+	xdsxproxy "sofastack.io/sofa-mosn/pkg/xds/model/filter/network/x_proxy/v2"
+	"sofastack.io/sofa-mosn/pkg/xds/v2/rds"
 )
 
 // support network filter list
@@ -76,13 +76,13 @@ func ConvertListenerConfig(xdsListener *xdsapi.Listener) *v2.Listener {
 
 	listenerConfig := &v2.Listener{
 		ListenerConfig: v2.ListenerConfig{
-			Name:       xdsListener.GetName(),
-			BindToPort: convertBindToPort(xdsListener.GetDeprecatedV1()),
-			Inspector:  true,
+			Name:                                  xdsListener.GetName(),
+			BindToPort:                            convertBindToPort(xdsListener.GetDeprecatedV1()),
+			Inspector:                             true,
 			HandOffRestoredDestinationConnections: xdsListener.GetUseOriginalDst().GetValue(),
 			AccessLogs:                            convertAccessLogs(xdsListener),
 		},
-		Addr: convertAddress(&xdsListener.Address),
+		Addr:                    convertAddress(&xdsListener.Address),
 		PerConnBufferLimitBytes: xdsListener.GetPerConnectionBufferLimitBytes().GetValue(),
 	}
 
@@ -91,7 +91,10 @@ func ConvertListenerConfig(xdsListener *xdsapi.Listener) *v2.Listener {
 		return listenerConfig
 	}
 
-	listenerConfig.FilterChains = convertFilterChains(xdsListener.GetFilterChains())
+	// re-converting a listener resets its rds subscriptions so route config
+	// names no longer referenced get unsubscribed
+	rds.ClearListenerRouters(listenerConfig.Name)
+	listenerConfig.FilterChains = convertFilterChains(listenerConfig.Name, xdsListener.GetFilterChains())
 
 	if listenerConfig.FilterChains != nil &&
 		len(listenerConfig.FilterChains) == 1 &&
@@ -383,7 +386,7 @@ func convertMixerConfig(s *types.Struct) (map[string]interface{}, error) {
 	return config, nil
 }
 
-func convertFilterChains(xdsFilterChains []xdslistener.FilterChain) []v2.FilterChain {
+func convertFilterChains(listenerName string, xdsFilterChains []xdslistener.FilterChain) []v2.FilterChain {
 	if xdsFilterChains == nil {
 		return nil
 	}
@@ -394,7 +397,8 @@ func convertFilterChains(xdsFilterChains []xdslistener.FilterChain) []v2.FilterC
 		filterChain := v2.FilterChain{
 			FilterChainConfig: v2.FilterChainConfig{
 				FilterChainMatch: xdsFilterChain.GetFilterChainMatch().String(),
-				Filters:          convertFilters(xdsFilterChain.GetFilters()),
+				Matches:          convertFilterChainMatch(xdsFilterChain.GetFilterChainMatch()),
+				Filters:          convertFilters(listenerName, xdsFilterChain.GetFilters()),
 				TLSConfig:        &tlsConfig,
 			},
 			TLSContexts: []v2.TLSConfig{
@@ -406,7 +410,39 @@ func convertFilterChains(xdsFilterChains []xdslistener.FilterChain) []v2.FilterC
 	return filterChains
 }
 
-func convertFilters(xdsFilters []xdslistener.Filter) []v2.Filter {
+func convertFilterChainMatch(xdsMatch *xdslistener.FilterChainMatch) *v2.FilterChainMatchCriteria {
+	if xdsMatch == nil {
+		return nil
+	}
+	serverNames := xdsMatch.GetServerNames()
+	if len(serverNames) == 0 {
+		// older control planes still send sni_domains
+		serverNames = xdsMatch.GetSniDomains()
+	}
+	var sourceRanges []string
+	for _, cidr := range xdsMatch.GetSourcePrefixRanges() {
+		sourceRanges = append(sourceRanges, fmt.Sprintf("%s/%d", cidr.GetAddressPrefix(), cidr.GetPrefixLen().GetValue()))
+	}
+	var transportProtocols []string
+	if tp := xdsMatch.GetTransportProtocol(); tp != "" {
+		transportProtocols = []string{tp}
+	}
+	matches := &v2.FilterChainMatchCriteria{
+		ServerNames:          serverNames,
+		ApplicationProtocols: xdsMatch.GetApplicationProtocols(),
+		TransportProtocols:   transportProtocols,
+		SourcePrefixRanges:   sourceRanges,
+		DestinationPort:      xdsMatch.GetDestinationPort().GetValue(),
+	}
+	if len(matches.ServerNames) == 0 && len(matches.ApplicationProtocols) == 0 &&
+		len(matches.TransportProtocols) == 0 &&
+		len(matches.SourcePrefixRanges) == 0 && matches.DestinationPort == 0 {
+		return nil
+	}
+	return matches
+}
+
+func convertFilters(listenerName string, xdsFilters []xdslistener.Filter) []v2.Filter {
 	if xdsFilters == nil {
 		return nil
 	}
@@ -414,7 +450,7 @@ func convertFilters(xdsFilters []xdslistener.Filter) []v2.Filter {
 	filters := make([]v2.Filter, 0, len(xdsFilters))
 
 	for _, xdsFilter := range xdsFilters {
-		filterMaps := convertFilterConfig(xdsFilter.GetName(), xdsFilter.GetConfig())
+		filterMaps := convertFilterConfig(listenerName, xdsFilter.GetName(), xdsFilter.GetConfig())
 
 		for typeKey, configValue := range filterMaps {
 			filters = append(filters, v2.Filter{
@@ -435,7 +471,7 @@ func toMap(in interface{}) map[string]interface{} {
 }
 
 // TODO: more filter config support
-func convertFilterConfig(name string, s *types.Struct) map[string]map[string]interface{} {
+func convertFilterConfig(listenerName string, name string, s *types.Struct) map[string]map[string]interface{} {
 	if s == nil {
 		return nil
 	}
@@ -480,6 +516,7 @@ func convertFilterConfig(name string, s *types.Struct) map[string]map[string]int
 		tcpProxyConfig := v2.TCPProxy{
 			StatPrefix:         filterConfig.GetStatPrefix(),
 			Cluster:            filterConfig.GetCluster(),
+			WeightedClusters:   convertTCPWeightedClusters(s),
 			IdleTimeout:        filterConfig.GetIdleTimeout(),
 			MaxConnectAttempts: filterConfig.GetMaxConnectAttempts().GetValue(),
 			Routes:             convertTCPRoute(filterConfig.GetDeprecatedV1()),
@@ -501,7 +538,7 @@ func convertFilterConfig(name string, s *types.Struct) map[string]map[string]int
 	if routerConfig != nil {
 		routerConfigName = routerConfig.RouterConfigName
 		if isRds {
-			rds.AppendRouterName(routerConfigName)
+			rds.AppendRouterName(listenerName, routerConfigName)
 		}
 		if routersMngIns := router.GetRoutersMangerInstance(); routersMngIns == nil {
 			log.DefaultLogger.Errorf("xds AddOrUpdateRouters error: router manager in nil")
@@ -538,6 +575,30 @@ func convertTCPRoute(deprecatedV1 *xdstcp.TcpProxy_DeprecatedV1) []*v2.TCPRoute
 	return tcpRoutes
 }
 
+// convertTCPWeightedClusters reads the weighted_clusters field from the raw
+// filter config, the vendored tcp_proxy proto predates the field so it is
+// picked out of the struct directly
+func convertTCPWeightedClusters(s *types.Struct) []*v2.WeightedCluster {
+	wcField, ok := s.GetFields()["weighted_clusters"]
+	if !ok {
+		return nil
+	}
+	clustersField, ok := wcField.GetStructValue().GetFields()["clusters"]
+	if !ok {
+		return nil
+	}
+	values := clustersField.GetListValue().GetValues()
+	weightedClusters := make([]*v2.WeightedCluster, 0, len(values))
+	for _, value := range values {
+		fields := value.GetStructValue().GetFields()
+		weightedCluster := &v2.WeightedCluster{}
+		weightedCluster.Cluster.Name = fields["name"].GetStringValue()
+		weightedCluster.Cluster.Weight = uint32(fields["weight"].GetNumberValue())
+		weightedClusters = append(weightedClusters, weightedCluster)
+	}
+	return weightedClusters
+}
+
 func convertCidrRange(cidr []*xdscore.CidrRange) []v2.CidrRange {
 	if cidr == nil {
 		return nil
@@ -658,7 +719,23 @@ func convertPerRouteConfig(xdsPerRouteConfig map[string]*types.Struct) map[strin
 			log.DefaultLogger.Debugf("add a fault inject stream filter in router")
 			perRouteConfig[v2.FaultStream] = cfg
 		default:
-			log.DefaultLogger.Warnf("unknown per route config: %s", key)
+			if parser, ok := perRouteConfigParsers[key]; ok {
+				cfg, err := parser(config)
+				if err != nil {
+					log.DefaultLogger.Infof("convertPerRouteConfig[%s] error: %v", key, err)
+					continue
+				}
+				perRouteConfig[key] = cfg
+				continue
+			}
+			// keep unknown configs as a generic map, so script/wasm filters
+			// can consume control plane delivered per route config
+			cfg, err := convertUntypedStruct(config)
+			if err != nil {
+				log.DefaultLogger.Infof("convertPerRouteConfig[%s] error: %v", key, err)
+				continue
+			}
+			perRouteConfig[key] = cfg
 		}
 	}
 
@@ -793,8 +870,11 @@ func convertWeightedCluster(xdsWeightedCluster *xdsroute.WeightedCluster_Cluster
 	}
 	return v2.ClusterWeight{
 		ClusterWeightConfig: v2.ClusterWeightConfig{
-			Name:   xdsWeightedCluster.GetName(),
-			Weight: xdsWeightedCluster.GetWeight().GetValue(),
+			Name:                    xdsWeightedCluster.GetName(),
+			Weight:                  xdsWeightedCluster.GetWeight().GetValue(),
+			RequestHeadersToAdd:     convertHeadersToAdd(xdsWeightedCluster.GetRequestHeadersToAdd()),
+			ResponseHeadersToAdd:    convertHeadersToAdd(xdsWeightedCluster.GetResponseHeadersToAdd()),
+			ResponseHeadersToRemove: xdsWeightedCluster.GetResponseHeadersToRemove(),
 		},
 		MetadataMatch: convertMeta(xdsWeightedCluster.GetMetadataMatch()),
 	}
@@ -717,3 +717,78 @@ func Test_convertPerRouteConfig(t *testing.T) {
 	}
 
 }
+
+func Test_convertPerRouteConfig_Extension(t *testing.T) {
+	RegisterPerRouteConfigParser("test.parsed", func(config *types.Struct) (interface{}, error) {
+		return "parsed", nil
+	})
+	defer delete(perRouteConfigParsers, "test.parsed")
+
+	scriptStruct := &types.Struct{
+		Fields: map[string]*types.Value{
+			"source": {Kind: &types.Value_StringValue{StringValue: "function envoy_on_request() end"}},
+			"enable": {Kind: &types.Value_BoolValue{BoolValue: true}},
+		},
+	}
+	configs := map[string]*types.Struct{
+		"mosn.filters.lua": scriptStruct,
+		"test.parsed":      scriptStruct,
+	}
+	perRouteConfig := convertPerRouteConfig(configs)
+	if len(perRouteConfig) != 2 {
+		t.Fatalf("want to get %d configs, but got %d", 2, len(perRouteConfig))
+	}
+	// a registered parser wins over the generic conversion
+	if parsed, ok := perRouteConfig["test.parsed"]; !ok || parsed != "parsed" {
+		t.Errorf("registered parser not used: %v", parsed)
+	}
+	// unknown filter config is kept as a generic map
+	if luaPer, ok := perRouteConfig["mosn.filters.lua"].(map[string]interface{}); !ok {
+		t.Error("script filter config not kept")
+	} else {
+		if !(luaPer["source"] == "function envoy_on_request() end" && luaPer["enable"] == true) {
+			t.Errorf("script filter config is not expected, %v", luaPer)
+		}
+	}
+}
+
+func Test_convertTCPWeightedClusters(t *testing.T) {
+	s := &types.Struct{
+		Fields: map[string]*types.Value{
+			"weighted_clusters": {Kind: &types.Value_StructValue{StructValue: &types.Struct{
+				Fields: map[string]*types.Value{
+					"clusters": {Kind: &types.Value_ListValue{ListValue: &types.ListValue{
+						Values: []*types.Value{
+							{Kind: &types.Value_StructValue{StructValue: &types.Struct{
+								Fields: map[string]*types.Value{
+									"name":   {Kind: &types.Value_StringValue{StringValue: "cluster-a"}},
+									"weight": {Kind: &types.Value_NumberValue{NumberValue: 60}},
+								},
+							}}},
+							{Kind: &types.Value_StructValue{StructValue: &types.Struct{
+								Fields: map[string]*types.Value{
+									"name":   {Kind: &types.Value_StringValue{StringValue: "cluster-b"}},
+									"weight": {Kind: &types.Value_NumberValue{NumberValue: 40}},
+								},
+							}}},
+						},
+					}}},
+				},
+			}}},
+		},
+	}
+	got := convertTCPWeightedClusters(s)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 weighted clusters, got %d", len(got))
+	}
+	if got[0].Cluster.Name != "cluster-a" || got[0].Cluster.Weight != 60 {
+		t.Errorf("unexpected first cluster: %+v", got[0].Cluster)
+	}
+	if got[1].Cluster.Name != "cluster-b" || got[1].Cluster.Weight != 40 {
+		t.Errorf("unexpected second cluster: %+v", got[1].Cluster)
+	}
+
+	if got := convertTCPWeightedClusters(&types.Struct{}); got != nil {
+		t.Errorf("missing field should convert to nil, got %v", got)
+	}
+}
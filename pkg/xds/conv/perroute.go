@@ -0,0 +1,59 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package conv
+
+import (
+	"bytes"
+
+	gogojsonpb "github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/types"
+)
+
+// Per route filter config delivered via RDS. Filters with a well-known
+// config message register a parser for their key; config for any other
+// filter (e.g. script/wasm extensions steered by the control plane) is kept
+// as a generic map, so route.PerFilterConfig() exposes it unmodified.
+
+// PerRouteConfigParser parses a filter's per route config struct into the
+// value stored in the route's PerFilterConfig map
+type PerRouteConfigParser func(config *types.Struct) (interface{}, error)
+
+var perRouteConfigParsers = make(map[string]PerRouteConfigParser)
+
+// RegisterPerRouteConfigParser registers a parser for a filter's per route
+// config, keyed by the filter name used in the RDS per_filter_config map
+func RegisterPerRouteConfigParser(filterName string, parser PerRouteConfigParser) {
+	perRouteConfigParsers[filterName] = parser
+}
+
+// convertUntypedStruct converts a protobuf struct into a generic
+// map[string]interface{} via its JSON form
+func convertUntypedStruct(config *types.Struct) (map[string]interface{}, error) {
+	if config == nil {
+		return nil, nil
+	}
+	buf := &bytes.Buffer{}
+	if err := (&gogojsonpb.Marshaler{}).Marshal(buf, config); err != nil {
+		return nil, err
+	}
+	untyped := make(map[string]interface{})
+	if err := json.Unmarshal(buf.Bytes(), &untyped); err != nil {
+		return nil, err
+	}
+	return untyped, nil
+}
@@ -19,42 +19,70 @@ package conv
 
 import (
 	"fmt"
+	"sync"
+	"time"
 
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	jsoniter "github.com/json-iterator/go"
 	"sofastack.io/sofa-mosn/pkg/api/v2"
 	"sofastack.io/sofa-mosn/pkg/config"
 	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/metrics"
 	"sofastack.io/sofa-mosn/pkg/router"
 	"sofastack.io/sofa-mosn/pkg/server"
 	"sofastack.io/sofa-mosn/pkg/types"
 	clusterAdapter "sofastack.io/sofa-mosn/pkg/upstream/cluster"
-	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
-	jsoniter "github.com/json-iterator/go"
+	"sofastack.io/sofa-mosn/pkg/utils"
+	"sofastack.io/sofa-mosn/pkg/xds/v2/rds"
 )
 
 var json = jsoniter.ConfigCompatibleWithStandardLibrary
 
 // ConvertXXX Function converts protobuf to mosn config, and makes the config effects
 
-// ConvertAddOrUpdateRouters converts router configurationm, used to add or update routers
-func ConvertAddOrUpdateRouters(routers []*envoy_api_v2.RouteConfiguration) {
-	if routersMngIns := router.GetRoutersMangerInstance(); routersMngIns == nil {
+// ConvertAddOrUpdateRouters converts router configurationm, used to add or update routers.
+// The returned error carries every route config that could not be applied so the
+// caller can nack the response with details
+func ConvertAddOrUpdateRouters(routers []*envoy_api_v2.RouteConfiguration) error {
+	routersMngIns := router.GetRoutersMangerInstance()
+	if routersMngIns == nil {
 		log.DefaultLogger.Errorf("xds OnAddOrUpdateRouters error: router manager in nil")
-	} else {
+		return fmt.Errorf("router manager is nil")
+	}
 
-		for _, router := range routers {
-			if jsonStr, err := json.Marshal(router); err == nil {
-				log.DefaultLogger.Tracef("raw router config: %s", string(jsonStr))
-			}
+	var errGlobal error
+	for _, router := range routers {
+		if jsonStr, err := json.Marshal(router); err == nil {
+			log.DefaultLogger.Tracef("raw router config: %s", string(jsonStr))
+		}
 
-			mosnRouter, _ := ConvertRouterConf("", router)
-			log.DefaultLogger.Tracef("mosnRouter config: %+v", mosnRouter)
-			routersMngIns.AddOrUpdateRouters(mosnRouter)
+		mosnRouter, _ := ConvertRouterConf("", router)
+		if mosnRouter == nil {
+			log.DefaultLogger.Errorf("xds OnAddOrUpdateRouters error: convert router %s failed", router.Name)
+			errGlobal = appendError(errGlobal, fmt.Errorf("convert router %s failed", router.Name))
+			continue
+		}
+		log.DefaultLogger.Tracef("mosnRouter config: %+v", mosnRouter)
+		if err := routersMngIns.AddOrUpdateRouters(mosnRouter); err != nil {
+			log.DefaultLogger.Errorf("xds OnAddOrUpdateRouters error: apply router %s failed: %v", router.Name, err)
+			errGlobal = appendError(errGlobal, fmt.Errorf("apply router %s failed: %v", router.Name, err))
 		}
 	}
+	return errGlobal
 }
 
-// ConvertAddOrUpdateListeners converts listener configuration, used to  add or update listeners
-func ConvertAddOrUpdateListeners(listeners []*envoy_api_v2.Listener) {
+func appendError(errGlobal, err error) error {
+	if errGlobal == nil {
+		return err
+	}
+	return fmt.Errorf("%v; %v", errGlobal, err)
+}
+
+// ConvertAddOrUpdateListeners converts listener configuration, used to  add or update listeners.
+// Per-listener failures are collected into the returned error so the caller can
+// nack the response while the listeners that applied stay active
+func ConvertAddOrUpdateListeners(listeners []*envoy_api_v2.Listener) error {
+	var errGlobal error
 	for _, listener := range listeners {
 		if jsonStr, err := json.Marshal(listener); err == nil {
 			log.DefaultLogger.Tracef("raw listener config: %s", string(jsonStr))
@@ -77,6 +105,7 @@ func ConvertAddOrUpdateListeners(listeners []*envoy_api_v2.Listener) {
 
 			if len(networkFilters) == 0 {
 				log.DefaultLogger.Errorf("xds client update listener error: proxy needed in network filters")
+				errGlobal = appendError(errGlobal, fmt.Errorf("listener %s has no network filters", mosnListener.Name))
 				continue
 			}
 		}
@@ -85,7 +114,7 @@ func ConvertAddOrUpdateListeners(listeners []*envoy_api_v2.Listener) {
 		if listenerAdapter == nil {
 			// if listenerAdapter is nil, return directly
 			log.DefaultLogger.Errorf("listenerAdapter is nil and hasn't been initiated at this time")
-			return
+			return appendError(errGlobal, fmt.Errorf("listener adapter is nil"))
 		}
 		log.DefaultLogger.Debugf("listenerAdapter.AddOrUpdateListener called, with mosn Listener:%+v, networkFilters:%+v, streamFilters: %+v",
 			mosnListener, networkFilters, streamFilters)
@@ -95,9 +124,11 @@ func ConvertAddOrUpdateListeners(listeners []*envoy_api_v2.Listener) {
 		} else {
 			log.DefaultLogger.Errorf("xds AddOrUpdateListener failure,listener address = %s, msg = %s ",
 				mosnListener.Addr.String(), err.Error())
+			errGlobal = appendError(errGlobal, fmt.Errorf("apply listener %s failed: %v", mosnListener.Name, err))
 		}
 	}
 
+	return errGlobal
 }
 
 // ConvertDeleteListeners converts listener configuration, used to delete listener
@@ -114,6 +145,8 @@ func ConvertDeleteListeners(listeners []*envoy_api_v2.Listener) {
 			return
 		}
 		if err := listenerAdapter.DeleteListener("", mosnListener.Name); err == nil {
+			// release the rds subscriptions held by the deleted listener
+			rds.RemoveListenerRouters(mosnListener.Name)
 			log.DefaultLogger.Debugf("xds OnDeleteListeners success,listener address = %s", mosnListener.Addr.String())
 		} else {
 			log.DefaultLogger.Errorf("xds OnDeleteListeners failure,listener address = %s, mag = %s ",
@@ -123,8 +156,10 @@ func ConvertDeleteListeners(listeners []*envoy_api_v2.Listener) {
 	}
 }
 
-// ConvertUpdateClusters converts cluster configuration, used to udpate cluster
-func ConvertUpdateClusters(clusters []*envoy_api_v2.Cluster) {
+// ConvertUpdateClusters converts cluster configuration, used to udpate cluster.
+// Per-cluster failures are collected into the returned error for nack reporting
+func ConvertUpdateClusters(clusters []*envoy_api_v2.Cluster) error {
+	var errGlobal error
 	for _, cluster := range clusters {
 		if jsonStr, err := json.Marshal(cluster); err == nil {
 			log.DefaultLogger.Tracef("raw cluster config: %s", string(jsonStr))
@@ -144,12 +179,14 @@ func ConvertUpdateClusters(clusters []*envoy_api_v2.Cluster) {
 
 		if err != nil {
 			log.DefaultLogger.Errorf("xds OnUpdateClusters failed,cluster name = %s, error: %v", cluster.Name, err.Error())
+			errGlobal = appendError(errGlobal, fmt.Errorf("apply cluster %s failed: %v", cluster.Name, err))
 
 		} else {
 			log.DefaultLogger.Debugf("xds OnUpdateClusters success,cluster name = %s", cluster.Name)
 		}
 	}
 
+	return errGlobal
 }
 
 // ConvertDeleteClusters converts cluster configuration, used to delete cluster
@@ -172,13 +209,69 @@ func ConvertDeleteClusters(clusters []*envoy_api_v2.Cluster) {
 	}
 }
 
-// ConverUpdateEndpoints converts cluster configuration, used to udpate hosts
+// edsApplyWorkers bounds the number of goroutines applying endpoint updates
+// concurrently when the control plane pushes a large batch of load assignments
+const edsApplyWorkers = 8
+
+// ConverUpdateEndpoints converts cluster configuration, used to udpate hosts.
+// Updates for different clusters are applied concurrently by a bounded worker
+// pool, updates for the same cluster stay in push order
 func ConvertUpdateEndpoints(loadAssignments []*envoy_api_v2.ClusterLoadAssignment) error {
-	var errGlobal error
+	start := time.Now()
 
+	// group assignments by cluster so one worker applies a cluster's updates serially
+	clusterNames := make([]string, 0, len(loadAssignments))
+	byCluster := make(map[string][]*envoy_api_v2.ClusterLoadAssignment, len(loadAssignments))
 	for _, loadAssignment := range loadAssignments {
-		clusterName := loadAssignment.ClusterName
+		name := loadAssignment.ClusterName
+		if _, ok := byCluster[name]; !ok {
+			clusterNames = append(clusterNames, name)
+		}
+		byCluster[name] = append(byCluster[name], loadAssignment)
+	}
+
+	workers := edsApplyWorkers
+	if workers > len(clusterNames) {
+		workers = len(clusterNames)
+	}
+
+	var (
+		errMux    sync.Mutex
+		errGlobal error
+		wg        sync.WaitGroup
+	)
+	tasks := make(chan string, len(clusterNames))
+	for _, name := range clusterNames {
+		tasks <- name
+	}
+	close(tasks)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		utils.GoWithRecover(func() {
+			defer wg.Done()
+			for name := range tasks {
+				if err := applyClusterEndpoints(name, byCluster[name]); err != nil {
+					errMux.Lock()
+					errGlobal = appendError(errGlobal, err)
+					errMux.Unlock()
+				}
+			}
+		}, nil)
+	}
+	wg.Wait()
+
+	duration := time.Since(start)
+	updateEndpointsApplyDuration(duration)
+	log.DefaultLogger.Infof("xds client applied endpoints for %d clusters in %v", len(clusterNames), duration)
 
+	return errGlobal
+}
+
+// applyClusterEndpoints applies all pushed load assignments of one cluster in order
+func applyClusterEndpoints(clusterName string, loadAssignments []*envoy_api_v2.ClusterLoadAssignment) error {
+	var errGlobal error
+	for _, loadAssignment := range loadAssignments {
 		for _, endpoints := range loadAssignment.Endpoints {
 			hosts := ConvertEndpointsConfig(&endpoints)
 			log.DefaultLogger.Debugf("xds client update endpoints: cluster: %s, priority: %d", loadAssignment.ClusterName, endpoints.Priority)
@@ -201,6 +294,14 @@ func ConvertUpdateEndpoints(loadAssignments []*envoy_api_v2.ClusterLoadAssignmen
 			}
 		}
 	}
-
 	return errGlobal
 }
+
+// updateEndpointsApplyDuration exports how long the last eds batch took to apply
+func updateEndpointsApplyDuration(duration time.Duration) {
+	m, err := metrics.NewMetrics("xds", map[string]string{"api": "eds"})
+	if err != nil {
+		return
+	}
+	m.Gauge("endpoints_apply_duration_ms").Update(duration.Nanoseconds() / int64(time.Millisecond))
+}
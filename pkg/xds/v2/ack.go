@@ -0,0 +1,178 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v2
+
+import (
+	"errors"
+
+	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/xds/v2/rds"
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoy_api_v2_core1 "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	ads "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
+	google_rpc "github.com/gogo/googleapis/google/rpc"
+)
+
+// AckInfo records the latest accepted version and nonce of one type url,
+// all types share the single ads stream so the state is tracked per type.
+// PrevVersionInfo keeps the version accepted before the current response
+// so a nack can fall back to it
+type AckInfo struct {
+	VersionInfo     string
+	PrevVersionInfo string
+	ResponseNonce   string
+	// ResourceNames keeps the names the subscription covers so they can
+	// be re-issued after a stream reconnect
+	ResourceNames []string
+}
+
+// StoreAck records the version and nonce of a received response, the
+// following requests of the same type url carry them so the management
+// server can distinguish an ack from a new subscription
+func (c *ClientV2) StoreAck(resp *envoy_api_v2.DiscoveryResponse) {
+	c.AckMutex.Lock()
+	defer c.AckMutex.Unlock()
+	if c.Acks == nil {
+		c.Acks = make(map[string]*AckInfo)
+	}
+	prev := ""
+	if ack, ok := c.Acks[resp.TypeUrl]; ok {
+		prev = ack.VersionInfo
+	}
+	c.Acks[resp.TypeUrl] = &AckInfo{
+		VersionInfo:     resp.VersionInfo,
+		PrevVersionInfo: prev,
+		ResponseNonce:   resp.Nonce,
+		ResourceNames:   ackResourceNames(resp),
+	}
+}
+
+// ackInfo returns the recorded version and nonce of a type url,
+// empty strings mean no response of the type is accepted yet
+func (c *ClientV2) ackInfo(typeURL string) (string, string) {
+	c.AckMutex.Lock()
+	defer c.AckMutex.Unlock()
+	if ack, ok := c.Acks[typeURL]; ok {
+		return ack.VersionInfo, ack.ResponseNonce
+	}
+	return "", ""
+}
+
+// ackedResourceNames returns the resource names of the last accepted
+// response of a type url, used to re-issue the subscription after reconnect
+func (c *ClientV2) ackedResourceNames(typeURL string) []string {
+	c.AckMutex.Lock()
+	defer c.AckMutex.Unlock()
+	if ack, ok := c.Acks[typeURL]; ok {
+		return ack.ResourceNames
+	}
+	return nil
+}
+
+// ResetAckNonces clears the recorded nonces when the ads stream reconnects,
+// a nonce is only valid within one stream while versions persist across streams
+func (c *ClientV2) ResetAckNonces() {
+	c.AckMutex.Lock()
+	defer c.AckMutex.Unlock()
+	for _, ack := range c.Acks {
+		ack.ResponseNonce = ""
+	}
+}
+
+// AckResponse sends an ack of a received response on the ads stream,
+// repeating the current subscription with the accepted version and nonce
+func (c *ClientV2) AckResponse(streamClient ads.AggregatedDiscoveryService_StreamAggregatedResourcesClient, resp *envoy_api_v2.DiscoveryResponse) error {
+	if streamClient == nil {
+		return errors.New("stream client is nil")
+	}
+	err := streamClient.Send(&envoy_api_v2.DiscoveryRequest{
+		VersionInfo:   resp.VersionInfo,
+		ResourceNames: ackResourceNames(resp),
+		TypeUrl:       resp.TypeUrl,
+		ResponseNonce: resp.Nonce,
+		ErrorDetail:   nil,
+		Node: &envoy_api_v2_core1.Node{
+			Id:      c.ServiceNode,
+			Cluster: c.ServiceCluster,
+		},
+	})
+	if err != nil {
+		log.DefaultLogger.Errorf("ack %s fail: %v", resp.TypeUrl, err)
+		return err
+	}
+	return nil
+}
+
+// NackResponse rejects a received response on the ads stream, the request
+// carries the response nonce, the last accepted version and the error detail
+// so the management server knows the update was not applied
+func (c *ClientV2) NackResponse(streamClient ads.AggregatedDiscoveryService_StreamAggregatedResourcesClient, resp *envoy_api_v2.DiscoveryResponse, detail string) error {
+	if streamClient == nil {
+		return errors.New("stream client is nil")
+	}
+	c.AckMutex.Lock()
+	version := ""
+	if ack, ok := c.Acks[resp.TypeUrl]; ok {
+		// roll the recorded version back to the last accepted one,
+		// following requests must not claim the rejected version
+		if ack.VersionInfo == resp.VersionInfo {
+			ack.VersionInfo = ack.PrevVersionInfo
+		}
+		version = ack.VersionInfo
+	}
+	c.AckMutex.Unlock()
+	err := streamClient.Send(&envoy_api_v2.DiscoveryRequest{
+		VersionInfo:   version,
+		ResourceNames: ackResourceNames(resp),
+		TypeUrl:       resp.TypeUrl,
+		ResponseNonce: resp.Nonce,
+		ErrorDetail: &google_rpc.Status{
+			Code:    int32(13), // internal error
+			Message: detail,
+		},
+		Node: &envoy_api_v2_core1.Node{
+			Id:      c.ServiceNode,
+			Cluster: c.ServiceCluster,
+		},
+	})
+	if err != nil {
+		log.DefaultLogger.Errorf("nack %s fail: %v", resp.TypeUrl, err)
+		return err
+	}
+	return nil
+}
+
+// ackResourceNames rebuilds the resource names an ack should repeat,
+// cds and lds are wildcard subscriptions while eds and rds subscribe names
+func ackResourceNames(resp *envoy_api_v2.DiscoveryResponse) []string {
+	switch resp.TypeUrl {
+	case EnvoyClusterLoadAssignment:
+		names := make([]string, 0, len(resp.Resources))
+		for _, res := range resp.Resources {
+			lbAssignment := envoy_api_v2.ClusterLoadAssignment{}
+			if err := lbAssignment.Unmarshal(res.GetValue()); err == nil {
+				names = append(names, lbAssignment.ClusterName)
+			}
+		}
+		return names
+	case EnvoyRouteConfiguration:
+		return rds.GetRouterNames()
+	default:
+		return []string{}
+	}
+}
@@ -0,0 +1,53 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v2
+
+import (
+	"testing"
+
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+)
+
+func TestAckVersionAndNonceTracking(t *testing.T) {
+	c := &ClientV2{}
+	// no response accepted yet
+	if version, nonce := c.ackInfo(EnvoyCluster); version != "" || nonce != "" {
+		t.Errorf("expected empty ack state, got %s/%s", version, nonce)
+	}
+	c.StoreAck(&envoy_api_v2.DiscoveryResponse{
+		TypeUrl:     EnvoyCluster,
+		VersionInfo: "v1",
+		Nonce:       "n1",
+	})
+	c.StoreAck(&envoy_api_v2.DiscoveryResponse{
+		TypeUrl:     EnvoyListener,
+		VersionInfo: "v2",
+		Nonce:       "n2",
+	})
+	if version, nonce := c.ackInfo(EnvoyCluster); version != "v1" || nonce != "n1" {
+		t.Errorf("unexpected cds ack state: %s/%s", version, nonce)
+	}
+	if version, nonce := c.ackInfo(EnvoyListener); version != "v2" || nonce != "n2" {
+		t.Errorf("unexpected lds ack state: %s/%s", version, nonce)
+	}
+	// a reconnect invalidates nonces but keeps versions
+	c.ResetAckNonces()
+	if version, nonce := c.ackInfo(EnvoyCluster); version != "v1" || nonce != "" {
+		t.Errorf("unexpected cds ack state after reconnect: %s/%s", version, nonce)
+	}
+}
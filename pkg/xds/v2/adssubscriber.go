@@ -18,10 +18,20 @@
 package v2
 
 import (
+	"math/rand"
 	"time"
 
 	"sofastack.io/sofa-mosn/pkg/log"
 	"sofastack.io/sofa-mosn/pkg/utils"
+	envoy_api_v2_core1 "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+)
+
+// reconnect backoff bounds, the sleep between two attempts doubles up to
+// the max and carries a random jitter to avoid thundering herds on a
+// management server restart
+var (
+	reconnectBaseInterval = time.Second
+	reconnectMaxInterval  = 30 * time.Second
 )
 
 // Start adsClient send goroutine and receive goroutine
@@ -35,6 +45,11 @@ func (adsClient *ADSClient) Start() {
 	utils.GoWithRecover(func() {
 		adsClient.receiveThread()
 	}, nil)
+	adsClient.LrsClient = NewLRSClient(&envoy_api_v2_core1.Node{
+		Id:      adsClient.V2Client.ServiceNode,
+		Cluster: adsClient.V2Client.ServiceCluster,
+	}, adsClient.AdsConfig)
+	adsClient.LrsClient.Start()
 }
 
 func (adsClient *ADSClient) sendThread() {
@@ -44,6 +59,10 @@ func (adsClient *ADSClient) sendThread() {
 		log.DefaultLogger.Warnf("[xds] [ads client] send thread request cds fail!auto retry next period")
 		adsClient.reconnect()
 	}
+	// runtime layers are optional, a failed request is retried next period
+	if err := adsClient.V2Client.reqRuntimes(adsClient.StreamClient); err != nil {
+		log.DefaultLogger.Warnf("[xds] [ads client] send thread request rtds fail!auto retry next period")
+	}
 
 	refreshDelay := adsClient.AdsConfig.RefreshDelay
 	t1 := time.NewTimer(*refreshDelay)
@@ -60,6 +79,9 @@ func (adsClient *ADSClient) sendThread() {
 				log.DefaultLogger.Warnf("[xds] [ads client] send thread request cds fail!auto retry next period")
 				adsClient.reconnect()
 			}
+			if err := adsClient.V2Client.reqRuntimes(adsClient.StreamClient); err != nil {
+				log.DefaultLogger.Warnf("[xds] [ads client] send thread request rtds fail!auto retry next period")
+			}
 			t1.Reset(*refreshDelay)
 		}
 	}
@@ -85,6 +107,12 @@ func (adsClient *ADSClient) receiveThread() {
 				continue
 			}
 			typeURL := resp.TypeUrl
+			// record the per type version/nonce then ack before handling,
+			// keeps the cds -> eds -> lds -> rds request chain ordered on the stream
+			adsClient.V2Client.StoreAck(resp)
+			if err := adsClient.V2Client.AckResponse(adsClient.StreamClient, resp); err != nil {
+				log.DefaultLogger.Warnf("[xds] [ads client] ack %s fail: %v", typeURL, err)
+			}
 			HandleTypeURL(typeURL, adsClient, resp)
 		}
 	}
@@ -96,20 +124,60 @@ func (adsClient *ADSClient) reconnect() {
 	adsClient.StreamClient = nil
 	log.DefaultLogger.Infof("[xds] [ads client] stream client closed")
 
+	backoff := reconnectBaseInterval
 	for {
+		// each attempt fails over to the next configured endpoint
 		adsClient.StreamClient = adsClient.AdsConfig.GetStreamClient()
 		if adsClient.StreamClient == nil {
-			log.DefaultLogger.Warnf("[xds] [ads client] stream client reconnect failed, retry after 1s")
-			time.Sleep(time.Second)
+			sleep := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+			log.DefaultLogger.Warnf("[xds] [ads client] stream client reconnect failed, retry after %v", sleep)
+			time.Sleep(sleep)
+			if backoff *= 2; backoff > reconnectMaxInterval {
+				backoff = reconnectMaxInterval
+			}
 			continue
 		}
+		adsClient.V2Client.ResetAckNonces()
 		log.DefaultLogger.Infof("[xds] [ads client] stream client reconnected")
+		adsClient.resubscribe()
 		break
 	}
 }
 
+// resubscribe re-issues all subscriptions on a fresh stream with the
+// previously acked versions so the state converges quickly after a
+// management server restart
+func (adsClient *ADSClient) resubscribe() {
+	if err := adsClient.V2Client.reqClusters(adsClient.StreamClient); err != nil {
+		log.DefaultLogger.Warnf("[xds] [ads client] resubscribe cds fail: %v", err)
+		return
+	}
+	if names := adsClient.V2Client.ackedResourceNames(EnvoyClusterLoadAssignment); len(names) > 0 {
+		if err := adsClient.V2Client.reqEndpoints(adsClient.StreamClient, names); err != nil {
+			log.DefaultLogger.Warnf("[xds] [ads client] resubscribe eds fail: %v", err)
+			return
+		}
+	}
+	if err := adsClient.V2Client.reqListeners(adsClient.StreamClient); err != nil {
+		log.DefaultLogger.Warnf("[xds] [ads client] resubscribe lds fail: %v", err)
+		return
+	}
+	// rds resource names come from the current listeners, reqRoutes skips
+	// the request when no router is subscribed yet
+	if err := adsClient.V2Client.reqRoutes(adsClient.StreamClient); err != nil {
+		log.DefaultLogger.Warnf("[xds] [ads client] resubscribe rds fail: %v", err)
+		return
+	}
+	if err := adsClient.V2Client.reqRuntimes(adsClient.StreamClient); err != nil {
+		log.DefaultLogger.Warnf("[xds] [ads client] resubscribe rtds fail: %v", err)
+	}
+}
+
 // Stop adsClient wait for send/receive goroutine graceful exit
 func (adsClient *ADSClient) Stop() {
+	if adsClient.LrsClient != nil {
+		adsClient.LrsClient.Stop()
+	}
 	adsClient.SendControlChan <- 1
 	adsClient.RecvControlChan <- 1
 	for i := 0; i < 2; i++ {
@@ -30,11 +30,12 @@ func (c *ClientV2) reqClusters(streamClient ads.AggregatedDiscoveryService_Strea
 	if streamClient == nil {
 		return errors.New("stream client is nil")
 	}
+	version, nonce := c.ackInfo(EnvoyCluster)
 	err := streamClient.Send(&envoy_api_v2.DiscoveryRequest{
-		VersionInfo:   "",
+		VersionInfo:   version,
 		ResourceNames: []string{},
-		TypeUrl:       "type.googleapis.com/envoy.api.v2.Cluster",
-		ResponseNonce: "",
+		TypeUrl:       EnvoyCluster,
+		ResponseNonce: nonce,
 		ErrorDetail:   nil,
 		Node: &envoy_api_v2_core1.Node{
 			Id: c.ServiceNode,
@@ -18,7 +18,11 @@
 package v2
 
 import (
+	"fmt"
+
+	"sofastack.io/sofa-mosn/pkg/admin/audit"
 	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/runtime"
 	"sofastack.io/sofa-mosn/pkg/xds/conv"
 	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
 )
@@ -29,6 +33,7 @@ const (
 	EnvoyCluster               = "type.googleapis.com/envoy.api.v2.Cluster"
 	EnvoyClusterLoadAssignment = "type.googleapis.com/envoy.api.v2.ClusterLoadAssignment"
 	EnvoyRouteConfiguration    = "type.googleapis.com/envoy.api.v2.RouteConfiguration"
+	EnvoyRuntime               = "type.googleapis.com/envoy.service.discovery.v2.Runtime"
 )
 
 func init() {
@@ -36,6 +41,7 @@ func init() {
 	RegisterTypeURLHandleFunc(EnvoyCluster, HandleEnvoyCluster)
 	RegisterTypeURLHandleFunc(EnvoyClusterLoadAssignment, HandleEnvoyClusterLoadAssignment)
 	RegisterTypeURLHandleFunc(EnvoyRouteConfiguration, HandleEnvoyRouteConfiguration)
+	RegisterTypeURLHandleFunc(EnvoyRuntime, HandleEnvoyRuntime)
 }
 
 // HandleEnvoyListener parse envoy data to mosn listener config
@@ -43,7 +49,7 @@ func HandleEnvoyListener(client *ADSClient, resp *envoy_api_v2.DiscoveryResponse
 	log.DefaultLogger.Tracef("get lds resp,handle it")
 	listeners := client.V2Client.handleListenersResp(resp)
 	log.DefaultLogger.Infof("get %d listeners from LDS", len(listeners))
-	conv.ConvertAddOrUpdateListeners(listeners)
+	nackOnError(client, resp, conv.ConvertAddOrUpdateListeners(listeners))
 	if err := client.V2Client.reqRoutes(client.StreamClient); err != nil {
 		log.DefaultLogger.Warnf("send thread request rds fail!auto retry next period")
 	}
@@ -54,7 +60,7 @@ func HandleEnvoyCluster(client *ADSClient, resp *envoy_api_v2.DiscoveryResponse)
 	log.DefaultLogger.Tracef("get cds resp,handle it")
 	clusters := client.V2Client.handleClustersResp(resp)
 	log.DefaultLogger.Infof("get %d clusters from CDS", len(clusters))
-	conv.ConvertUpdateClusters(clusters)
+	nackOnError(client, resp, conv.ConvertUpdateClusters(clusters))
 	clusterNames := make([]string, 0)
 
 	for _, cluster := range clusters {
@@ -79,7 +85,7 @@ func HandleEnvoyClusterLoadAssignment(client *ADSClient, resp *envoy_api_v2.Disc
 	log.DefaultLogger.Tracef("get eds resp,handle it ")
 	endpoints := client.V2Client.handleEndpointsResp(resp)
 	log.DefaultLogger.Infof("get %d endpoints from EDS", len(endpoints))
-	conv.ConvertUpdateEndpoints(endpoints)
+	nackOnError(client, resp, conv.ConvertUpdateEndpoints(endpoints))
 
 	if err := client.V2Client.reqListeners(client.StreamClient); err != nil {
 		log.DefaultLogger.Warnf("send thread request lds fail!auto retry next period")
@@ -91,5 +97,30 @@ func HandleEnvoyRouteConfiguration(client *ADSClient, resp *envoy_api_v2.Discove
 	log.DefaultLogger.Tracef("get rds resp,handle it")
 	routes := client.V2Client.handleRoutesResp(resp)
 	log.DefaultLogger.Infof("get %d routes from RDS", len(routes))
-	conv.ConvertAddOrUpdateRouters(routes)
+	nackOnError(client, resp, conv.ConvertAddOrUpdateRouters(routes))
+}
+
+// HandleEnvoyRuntime parses envoy data to mosn runtime layers
+func HandleEnvoyRuntime(client *ADSClient, resp *envoy_api_v2.DiscoveryResponse) {
+	log.DefaultLogger.Tracef("get rtds resp,handle it")
+	layers := client.V2Client.handleRuntimesResp(resp)
+	log.DefaultLogger.Infof("get %d runtime layers from RTDS", len(layers))
+	for _, layer := range layers {
+		runtime.ApplyLayer(layer.Name, layer.Values)
+	}
+}
+
+// nackOnError rejects a response whose conversion failed so the management
+// server sees the error detail, the last-known-good config stays active.
+// The per type rejected gauge reports the current state either way
+func nackOnError(client *ADSClient, resp *envoy_api_v2.DiscoveryResponse, err error) {
+	markConfigRejected(resp.TypeUrl, err != nil)
+	audit.Record(audit.SourceXDS, "push_"+apiName(resp.TypeUrl), resp.VersionInfo,
+		fmt.Sprintf("%d resources", len(resp.Resources)), err)
+	if err == nil {
+		return
+	}
+	if nackErr := client.V2Client.NackResponse(client.StreamClient, resp, err.Error()); nackErr != nil {
+		log.DefaultLogger.Warnf("nack %s fail: %v", resp.TypeUrl, nackErr)
+	}
 }
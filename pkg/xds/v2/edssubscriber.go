@@ -30,11 +30,12 @@ func (c *ClientV2) reqEndpoints(streamClient ads.AggregatedDiscoveryService_Stre
 	if streamClient == nil {
 		return errors.New("stream client is nil")
 	}
+	version, nonce := c.ackInfo(EnvoyClusterLoadAssignment)
 	err := streamClient.Send(&envoy_api_v2.DiscoveryRequest{
-		VersionInfo:   "",
+		VersionInfo:   version,
 		ResourceNames: clusterNames,
-		TypeUrl:       "type.googleapis.com/envoy.api.v2.ClusterLoadAssignment",
-		ResponseNonce: "",
+		TypeUrl:       EnvoyClusterLoadAssignment,
+		ResponseNonce: nonce,
 		ErrorDetail:   nil,
 		Node: &envoy_api_v2_core1.Node{
 			Id:      c.ServiceNode,
@@ -0,0 +1,307 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v2
+
+import (
+	"fmt"
+	"time"
+
+	"sofastack.io/sofa-mosn/pkg/log"
+	"sofastack.io/sofa-mosn/pkg/metrics"
+	"sofastack.io/sofa-mosn/pkg/utils"
+	envoy_api_v2_core1 "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	envoy_api_v2_endpoint "github.com/envoyproxy/go-control-plane/envoy/api/v2/endpoint"
+	"github.com/gogo/protobuf/proto"
+	google_protobuf "github.com/gogo/protobuf/types"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// lrsMethod is the full grpc method of envoy's load reporting service
+const lrsMethod = "/envoy.service.load_stats.v2.LoadReportingService/StreamLoadStats"
+
+var lrsStreamDesc = grpc.StreamDesc{
+	StreamName:    "StreamLoadStats",
+	ClientStreams: true,
+	ServerStreams: true,
+}
+
+// defaultLoadReportInterval is used until the management server answers
+// with its own load_reporting_interval
+var defaultLoadReportInterval = 10 * time.Second
+
+// lrsRetryInterval is the wait before a failed load stats stream is
+// re-established
+var lrsRetryInterval = 30 * time.Second
+
+// loadStatsRequest mirrors envoy.service.load_stats.v2.LoadStatsRequest.
+// The generated load_stats stubs are not vendored, the two messages are
+// small enough to encode by hand on top of the vendored sub messages
+type loadStatsRequest struct {
+	Node         *envoy_api_v2_core1.Node              `protobuf:"bytes,1,opt,name=node" json:"node,omitempty"`
+	ClusterStats []*envoy_api_v2_endpoint.ClusterStats `protobuf:"bytes,2,rep,name=cluster_stats,json=clusterStats" json:"cluster_stats,omitempty"`
+}
+
+func (m *loadStatsRequest) Reset()         { *m = loadStatsRequest{} }
+func (m *loadStatsRequest) String() string { return proto.CompactTextString(m) }
+func (*loadStatsRequest) ProtoMessage()    {}
+
+// Marshal implements the Marshaler interface used by the grpc proto codec
+func (m *loadStatsRequest) Marshal() ([]byte, error) {
+	var buf []byte
+	if m.Node != nil {
+		b, err := m.Node.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, 0x0a) // field 1, wire type 2
+		buf = appendVarint(buf, uint64(len(b)))
+		buf = append(buf, b...)
+	}
+	for _, cs := range m.ClusterStats {
+		b, err := cs.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, 0x12) // field 2, wire type 2
+		buf = appendVarint(buf, uint64(len(b)))
+		buf = append(buf, b...)
+	}
+	return buf, nil
+}
+
+// loadStatsResponse mirrors envoy.service.load_stats.v2.LoadStatsResponse
+type loadStatsResponse struct {
+	Clusters                  []string
+	LoadReportingInterval     *google_protobuf.Duration
+	ReportEndpointGranularity bool
+}
+
+func (m *loadStatsResponse) Reset()         { *m = loadStatsResponse{} }
+func (m *loadStatsResponse) String() string { return fmt.Sprintf("%+v", *m) }
+func (*loadStatsResponse) ProtoMessage()    {}
+
+// Unmarshal implements the Unmarshaler interface used by the grpc proto codec
+func (m *loadStatsResponse) Unmarshal(data []byte) error {
+	for len(data) > 0 {
+		key, n := readVarint(data)
+		if n <= 0 {
+			return fmt.Errorf("lrs response: invalid field key")
+		}
+		data = data[n:]
+		fieldNum, wireType := key>>3, key&0x7
+		switch wireType {
+		case 0: // varint
+			v, n := readVarint(data)
+			if n <= 0 {
+				return fmt.Errorf("lrs response: invalid varint field %d", fieldNum)
+			}
+			data = data[n:]
+			if fieldNum == 3 {
+				m.ReportEndpointGranularity = v != 0
+			}
+		case 2: // length delimited
+			l, n := readVarint(data)
+			if n <= 0 || uint64(len(data)-n) < l {
+				return fmt.Errorf("lrs response: invalid length of field %d", fieldNum)
+			}
+			value := data[n : n+int(l)]
+			data = data[n+int(l):]
+			switch fieldNum {
+			case 1:
+				m.Clusters = append(m.Clusters, string(value))
+			case 2:
+				d := &google_protobuf.Duration{}
+				if err := d.Unmarshal(value); err != nil {
+					return err
+				}
+				m.LoadReportingInterval = d
+			}
+		default:
+			return fmt.Errorf("lrs response: unsupported wire type %d", wireType)
+		}
+	}
+	return nil
+}
+
+func appendVarint(buf []byte, v uint64) []byte {
+	for v >= 0x80 {
+		buf = append(buf, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(buf, byte(v))
+}
+
+func readVarint(data []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < len(data) && i < 10; i++ {
+		v |= uint64(data[i]&0x7f) << uint(7*i)
+		if data[i] < 0x80 {
+			return v, i + 1
+		}
+	}
+	return 0, -1
+}
+
+// lrsCounts is the absolute counter state of a cluster at the last report,
+// reports send the delta since then
+type lrsCounts struct {
+	success int64
+	failed  int64
+}
+
+// LRSClient periodically reports per cluster request counts to the
+// management server's load reporting service, enabling global load
+// balancing decisions. The report interval follows the server's
+// load_reporting_interval answer
+type LRSClient struct {
+	node      *envoy_api_v2_core1.Node
+	adsConfig *ADSConfig
+	interval  time.Duration
+	last      map[string]lrsCounts
+	stopChan  chan int
+}
+
+// NewLRSClient returns a load report client sharing the ads connection,
+// including its reconnect failover across endpoints
+func NewLRSClient(node *envoy_api_v2_core1.Node, adsConfig *ADSConfig) *LRSClient {
+	return &LRSClient{
+		node:      node,
+		adsConfig: adsConfig,
+		interval:  defaultLoadReportInterval,
+		last:      make(map[string]lrsCounts),
+		stopChan:  make(chan int),
+	}
+}
+
+// Start establishes the load stats stream in the background, a broken
+// stream is re-established after lrsRetryInterval
+func (lrs *LRSClient) Start() {
+	utils.GoWithRecover(func() {
+		for {
+			select {
+			case <-lrs.stopChan:
+				return
+			default:
+			}
+			if err := lrs.streamLoadStats(); err != nil {
+				log.DefaultLogger.Warnf("[xds] [lrs] load stats stream failed: %v, retry after %v", err, lrsRetryInterval)
+			}
+			select {
+			case <-lrs.stopChan:
+				return
+			case <-time.After(lrsRetryInterval):
+			}
+		}
+	}, nil)
+}
+
+// Stop ends the report loop
+func (lrs *LRSClient) Stop() {
+	close(lrs.stopChan)
+}
+
+func (lrs *LRSClient) streamLoadStats() error {
+	sc := lrs.adsConfig.StreamClient
+	if sc == nil || sc.Conn == nil {
+		return fmt.Errorf("ads connection not established")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := sc.Conn.NewStream(ctx, &lrsStreamDesc, lrsMethod)
+	if err != nil {
+		return err
+	}
+	// the initial request carries the node only, the server answers with
+	// the clusters it wants reported and the report interval
+	if err := stream.SendMsg(&loadStatsRequest{Node: lrs.node}); err != nil {
+		return err
+	}
+	resp := &loadStatsResponse{}
+	if err := stream.RecvMsg(resp); err != nil {
+		return err
+	}
+	lrs.applyResponse(resp)
+
+	for {
+		select {
+		case <-lrs.stopChan:
+			return nil
+		case <-time.After(lrs.interval):
+		}
+		req := &loadStatsRequest{
+			Node:         lrs.node,
+			ClusterStats: lrs.collectClusterStats(resp.Clusters),
+		}
+		if err := stream.SendMsg(req); err != nil {
+			return err
+		}
+	}
+}
+
+func (lrs *LRSClient) applyResponse(resp *loadStatsResponse) {
+	if resp.LoadReportingInterval != nil {
+		interval := time.Duration(resp.LoadReportingInterval.Seconds)*time.Second +
+			time.Duration(resp.LoadReportingInterval.Nanos)*time.Nanosecond
+		if interval > 0 {
+			lrs.interval = interval
+		}
+	}
+	log.DefaultLogger.Infof("[xds] [lrs] load reporting started, interval %v, clusters %v", lrs.interval, resp.Clusters)
+}
+
+// collectClusterStats reads the upstream metrics of every cluster and
+// builds the delta report since the last one. Localities are not modelled
+// by mosn, counts are reported under a single unnamed locality
+func (lrs *LRSClient) collectClusterStats(wanted []string) []*envoy_api_v2_endpoint.ClusterStats {
+	wantedSet := make(map[string]bool, len(wanted))
+	for _, name := range wanted {
+		wantedSet[name] = true
+	}
+	var result []*envoy_api_v2_endpoint.ClusterStats
+	for _, m := range metrics.GetAll() {
+		if m.Type() != metrics.UpstreamType {
+			continue
+		}
+		labels := m.Labels()
+		name, ok := labels["cluster"]
+		if !ok || labels["host"] != "" {
+			continue
+		}
+		// an empty cluster list means report everything
+		if len(wantedSet) > 0 && !wantedSet[name] {
+			continue
+		}
+		success := m.Counter(metrics.UpstreamResponseSuccess).Count()
+		failed := m.Counter(metrics.UpstreamResponseFailed).Count()
+		inProgress := m.Counter(metrics.UpstreamRequestActive).Count()
+		lastCounts := lrs.last[name]
+		lrs.last[name] = lrsCounts{success: success, failed: failed}
+		result = append(result, &envoy_api_v2_endpoint.ClusterStats{
+			ClusterName: name,
+			UpstreamLocalityStats: []*envoy_api_v2_endpoint.UpstreamLocalityStats{
+				{
+					TotalSuccessfulRequests: uint64(success - lastCounts.success),
+					TotalErrorRequests:      uint64(failed - lastCounts.failed),
+					TotalRequestsInProgress: uint64(inProgress),
+				},
+			},
+		})
+	}
+	return result
+}
@@ -0,0 +1,126 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v2
+
+import (
+	"testing"
+	"time"
+
+	"sofastack.io/sofa-mosn/pkg/metrics"
+	envoy_api_v2_core1 "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	google_protobuf "github.com/gogo/protobuf/types"
+)
+
+func TestLoadStatsRequestMarshal(t *testing.T) {
+	req := &loadStatsRequest{
+		Node: &envoy_api_v2_core1.Node{Id: "node0", Cluster: "mosn"},
+	}
+	data, err := req.Marshal()
+	if err != nil {
+		t.Fatalf("marshal load stats request: %v", err)
+	}
+	// the request is wire compatible with the generated node message
+	// nested under field 1
+	node := &envoy_api_v2_core1.Node{}
+	if data[0] != 0x0a {
+		t.Fatalf("expected field 1 wire type 2 key, got %#x", data[0])
+	}
+	l, n := readVarint(data[1:])
+	if err := node.Unmarshal(data[1+n : 1+n+int(l)]); err != nil {
+		t.Fatalf("nested node does not unmarshal: %v", err)
+	}
+	if node.Id != "node0" || node.Cluster != "mosn" {
+		t.Errorf("unexpected node after round trip: %+v", node)
+	}
+}
+
+func TestLoadStatsResponseUnmarshal(t *testing.T) {
+	// clusters=["c1","c2"], load_reporting_interval=2s, granularity=true
+	interval := &google_protobuf.Duration{Seconds: 2}
+	ib, err := interval.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data []byte
+	data = append(data, 0x0a, byte(len("c1")))
+	data = append(data, "c1"...)
+	data = append(data, 0x0a, byte(len("c2")))
+	data = append(data, "c2"...)
+	data = append(data, 0x12, byte(len(ib)))
+	data = append(data, ib...)
+	data = append(data, 0x18, 0x01)
+
+	resp := &loadStatsResponse{}
+	if err := resp.Unmarshal(data); err != nil {
+		t.Fatalf("unmarshal load stats response: %v", err)
+	}
+	if len(resp.Clusters) != 2 || resp.Clusters[0] != "c1" || resp.Clusters[1] != "c2" {
+		t.Errorf("unexpected clusters: %v", resp.Clusters)
+	}
+	if resp.LoadReportingInterval == nil || resp.LoadReportingInterval.Seconds != 2 {
+		t.Errorf("unexpected interval: %v", resp.LoadReportingInterval)
+	}
+	if !resp.ReportEndpointGranularity {
+		t.Errorf("granularity flag not parsed")
+	}
+
+	lrs := &LRSClient{interval: defaultLoadReportInterval}
+	lrs.applyResponse(resp)
+	if lrs.interval != 2*time.Second {
+		t.Errorf("server interval not applied, got %v", lrs.interval)
+	}
+}
+
+func TestCollectClusterStats(t *testing.T) {
+	metrics.ResetAll()
+	defer metrics.ResetAll()
+
+	stats := metrics.NewClusterStats("lrs_cluster")
+	stats.Counter(metrics.UpstreamResponseSuccess).Inc(10)
+	stats.Counter(metrics.UpstreamResponseFailed).Inc(3)
+	stats.Counter(metrics.UpstreamRequestActive).Inc(2)
+	// host level metrics of the same cluster must not be double counted
+	metrics.NewHostStats("lrs_cluster", "127.0.0.1:8080").Counter(metrics.UpstreamResponseSuccess).Inc(100)
+
+	lrs := &LRSClient{last: make(map[string]lrsCounts)}
+	report := lrs.collectClusterStats(nil)
+	if len(report) != 1 {
+		t.Fatalf("expected one cluster stats entry, got %d", len(report))
+	}
+	cs := report[0]
+	if cs.ClusterName != "lrs_cluster" || len(cs.UpstreamLocalityStats) != 1 {
+		t.Fatalf("unexpected cluster stats: %+v", cs)
+	}
+	ls := cs.UpstreamLocalityStats[0]
+	if ls.TotalSuccessfulRequests != 10 || ls.TotalErrorRequests != 3 || ls.TotalRequestsInProgress != 2 {
+		t.Errorf("unexpected locality stats: %+v", ls)
+	}
+
+	// the second report carries only the delta since the first one
+	stats.Counter(metrics.UpstreamResponseSuccess).Inc(5)
+	report = lrs.collectClusterStats(nil)
+	ls = report[0].UpstreamLocalityStats[0]
+	if ls.TotalSuccessfulRequests != 5 || ls.TotalErrorRequests != 0 {
+		t.Errorf("second report is not a delta: %+v", ls)
+	}
+
+	// a non empty cluster list from the server filters the report
+	if report := lrs.collectClusterStats([]string{"other"}); len(report) != 0 {
+		t.Errorf("cluster filter not applied: %+v", report)
+	}
+}
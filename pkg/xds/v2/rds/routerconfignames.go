@@ -22,33 +22,59 @@ import (
 )
 
 /*
-rds store the router config names which need to fetch virtualhosts configuration from RDS
+rds store the router config names which need to fetch virtualhosts configuration
+from RDS, tracked per listener so a deleted or updated listener releases the
+subscriptions it no longer references
 */
 
 var (
-	mu          sync.Mutex
-	routerNames map[string]bool
+	mu              sync.Mutex
+	listenerRouters map[string]map[string]bool
 )
 
-// AppendRouterName use to append rds router configname to subscript
-func AppendRouterName(name string) {
+// AppendRouterName records that a listener references a rds router config name
+func AppendRouterName(listenerName, routerName string) {
 	mu.Lock()
 	defer mu.Unlock()
-	if routerNames == nil {
-		routerNames = make(map[string]bool)
+	if listenerRouters == nil {
+		listenerRouters = make(map[string]map[string]bool)
 	}
-	routerNames[name] = true
+	names := listenerRouters[listenerName]
+	if names == nil {
+		names = make(map[string]bool)
+		listenerRouters[listenerName] = names
+	}
+	names[routerName] = true
+}
+
+// ClearListenerRouters resets the names referenced by a listener, called
+// before a listener is converted again so renamed route configs are dropped
+func ClearListenerRouters(listenerName string) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(listenerRouters, listenerName)
 }
 
-// GetRouterNames return disctict router config names
+// RemoveListenerRouters releases the subscriptions held by a deleted listener
+func RemoveListenerRouters(listenerName string) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(listenerRouters, listenerName)
+}
+
+// GetRouterNames return disctict router config names referenced by active listeners
 func GetRouterNames() []string {
 	mu.Lock()
 	defer mu.Unlock()
-	names := make([]string, len(routerNames))
-	i := 0
-	for name, _ := range routerNames {
-		names[i] = name
-		i++
+	distinct := make(map[string]bool)
+	for _, names := range listenerRouters {
+		for name := range names {
+			distinct[name] = true
+		}
+	}
+	names := make([]string, 0, len(distinct))
+	for name := range distinct {
+		names = append(names, name)
 	}
 	return names
 }
@@ -23,26 +23,29 @@ import (
 )
 
 func Test_AppendRouterName(t *testing.T) {
+	listenerRouters = nil
 	type args struct {
-		routerName string
+		listenerName string
+		routerName   string
 	}
 	tests := []struct {
 		name string
 		args args
-		want map[string]bool
+		want map[string]map[string]bool
 	}{
 		{
 			name: "case1",
 			args: args{
-				routerName: "http.80",
+				listenerName: "0.0.0.0_80",
+				routerName:   "http.80",
 			},
-			want: map[string]bool{"http.80": true},
+			want: map[string]map[string]bool{"0.0.0.0_80": {"http.80": true}},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			AppendRouterName(tt.args.routerName)
-			if got := routerNames; !reflect.DeepEqual(got, tt.want) {
+			AppendRouterName(tt.args.listenerName, tt.args.routerName)
+			if got := listenerRouters; !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("AppendRouterName() = %v, want %v", got, tt.want)
 			}
 		})
@@ -50,8 +53,9 @@ func Test_AppendRouterName(t *testing.T) {
 }
 
 func Test_GetRouterNames(t *testing.T) {
-	routerNames = make(map[string]bool)
-	routerNames["http.80"] = true
+	listenerRouters = map[string]map[string]bool{
+		"0.0.0.0_80": {"http.80": true},
+	}
 
 	tests := []struct {
 		name string
@@ -70,3 +74,21 @@ func Test_GetRouterNames(t *testing.T) {
 		})
 	}
 }
+
+func Test_RemoveListenerRouters(t *testing.T) {
+	listenerRouters = map[string]map[string]bool{
+		"0.0.0.0_80":   {"http.80": true, "shared": true},
+		"0.0.0.0_8080": {"shared": true},
+	}
+
+	RemoveListenerRouters("0.0.0.0_80")
+	if got := GetRouterNames(); !reflect.DeepEqual(got, []string{"shared"}) {
+		t.Errorf("GetRouterNames() after remove = %v, want [shared]", got)
+	}
+
+	// the shared name stays subscribed until the last listener releases it
+	RemoveListenerRouters("0.0.0.0_8080")
+	if got := GetRouterNames(); len(got) != 0 {
+		t.Errorf("GetRouterNames() after remove all = %v, want empty", got)
+	}
+}
@@ -37,11 +37,12 @@ func (c *ClientV2) reqRoutes(streamClient ads.AggregatedDiscoveryService_StreamA
 		return nil
 	}
 	log.DefaultLogger.Tracef("routers to subcriber: %+v", routerNames)
+	version, nonce := c.ackInfo(EnvoyRouteConfiguration)
 	err := streamClient.Send(&envoy_api_v2.DiscoveryRequest{
-		VersionInfo:   "",
+		VersionInfo:   version,
 		ResourceNames: routerNames,
-		TypeUrl:       "type.googleapis.com/envoy.api.v2.RouteConfiguration",
-		ResponseNonce: "",
+		TypeUrl:       EnvoyRouteConfiguration,
+		ResponseNonce: nonce,
 		ErrorDetail:   nil,
 		Node: &envoy_api_v2_core1.Node{
 			Id:      c.ServiceNode,
@@ -0,0 +1,137 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v2
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"sofastack.io/sofa-mosn/pkg/log"
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoy_api_v2_core1 "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	ads "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
+	gogotypes "github.com/gogo/protobuf/types"
+)
+
+// runtimeLayer is one rtds layer: a named set of flat runtime key values
+type runtimeLayer struct {
+	Name   string
+	Values map[string]string
+}
+
+func (c *ClientV2) reqRuntimes(streamClient ads.AggregatedDiscoveryService_StreamAggregatedResourcesClient) error {
+	if streamClient == nil {
+		return errors.New("stream client is nil")
+	}
+	version, nonce := c.ackInfo(EnvoyRuntime)
+	err := streamClient.Send(&envoy_api_v2.DiscoveryRequest{
+		VersionInfo:   version,
+		ResourceNames: []string{},
+		TypeUrl:       EnvoyRuntime,
+		ResponseNonce: nonce,
+		ErrorDetail:   nil,
+		Node: &envoy_api_v2_core1.Node{
+			Id:      c.ServiceNode,
+			Cluster: c.ServiceCluster,
+		},
+	})
+	if err != nil {
+		log.DefaultLogger.Errorf("get runtimes fail: %v", err)
+		return err
+	}
+	return nil
+}
+
+func (c *ClientV2) handleRuntimesResp(resp *envoy_api_v2.DiscoveryResponse) []*runtimeLayer {
+	layers := make([]*runtimeLayer, 0, len(resp.Resources))
+	for _, res := range resp.Resources {
+		name, layer, err := unmarshalRuntime(res.GetValue())
+		if err != nil {
+			log.DefaultLogger.Errorf("unmarshal runtime layer fail: %v", err)
+			continue
+		}
+		values := make(map[string]string)
+		flattenStruct("", layer, values)
+		layers = append(layers, &runtimeLayer{Name: name, Values: values})
+	}
+	return layers
+}
+
+// unmarshalRuntime decodes an envoy.service.discovery.v2.Runtime message:
+//
+//	string name = 1;
+//	google.protobuf.Struct layer = 2;
+//
+// the proto is not generated into the vendored go-control-plane, both
+// fields are length delimited so the wire format is decoded directly
+func unmarshalRuntime(data []byte) (string, *gogotypes.Struct, error) {
+	name := ""
+	layer := &gogotypes.Struct{}
+	for i := 0; i < len(data); {
+		key, n := binary.Uvarint(data[i:])
+		if n <= 0 {
+			return "", nil, fmt.Errorf("invalid field key at %d", i)
+		}
+		i += n
+		if key&7 != 2 { // both fields are length delimited
+			return "", nil, fmt.Errorf("unexpected wire type %d", key&7)
+		}
+		size, n := binary.Uvarint(data[i:])
+		if n <= 0 || i+n+int(size) > len(data) {
+			return "", nil, fmt.Errorf("invalid field size at %d", i)
+		}
+		payload := data[i+n : i+n+int(size)]
+		switch key >> 3 {
+		case 1:
+			name = string(payload)
+		case 2:
+			if err := layer.Unmarshal(payload); err != nil {
+				return "", nil, err
+			}
+		}
+		i += n + int(size)
+	}
+	return name, layer, nil
+}
+
+// flattenStruct flattens a layer struct into dotted runtime keys
+func flattenStruct(prefix string, s *gogotypes.Struct, out map[string]string) {
+	if s == nil {
+		return
+	}
+	for k, v := range s.Fields {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		switch kind := v.GetKind().(type) {
+		case *gogotypes.Value_StringValue:
+			out[key] = kind.StringValue
+		case *gogotypes.Value_NumberValue:
+			out[key] = strconv.FormatFloat(kind.NumberValue, 'f', -1, 64)
+		case *gogotypes.Value_BoolValue:
+			out[key] = strconv.FormatBool(kind.BoolValue)
+		case *gogotypes.Value_StructValue:
+			flattenStruct(key, kind.StructValue, out)
+		default:
+			// lists and nulls are not runtime values, skip
+		}
+	}
+}
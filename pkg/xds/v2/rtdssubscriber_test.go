@@ -0,0 +1,89 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v2
+
+import (
+	"testing"
+
+	gogotypes "github.com/gogo/protobuf/types"
+)
+
+// marshalRuntime builds the wire format of envoy.service.discovery.v2.Runtime
+// by hand, the counterpart of unmarshalRuntime
+func marshalRuntime(t *testing.T, name string, layer *gogotypes.Struct) []byte {
+	t.Helper()
+	buf := []byte{0x0a, byte(len(name))}
+	buf = append(buf, name...)
+	layerBytes, err := layer.Marshal()
+	if err != nil {
+		t.Fatalf("marshal layer: %v", err)
+	}
+	buf = append(buf, 0x12, byte(len(layerBytes)))
+	return append(buf, layerBytes...)
+}
+
+func Test_unmarshalRuntime(t *testing.T) {
+	layer := &gogotypes.Struct{
+		Fields: map[string]*gogotypes.Value{
+			"flag": {Kind: &gogotypes.Value_BoolValue{BoolValue: true}},
+			"health_check": {Kind: &gogotypes.Value_StructValue{StructValue: &gogotypes.Struct{
+				Fields: map[string]*gogotypes.Value{
+					"interval": {Kind: &gogotypes.Value_StringValue{StringValue: "3s"}},
+					"retries":  {Kind: &gogotypes.Value_NumberValue{NumberValue: 5}},
+				},
+			}}},
+			"ignored": {Kind: &gogotypes.Value_NullValue{}},
+		},
+	}
+	data := marshalRuntime(t, "rtds_layer", layer)
+
+	name, decoded, err := unmarshalRuntime(data)
+	if err != nil {
+		t.Fatalf("unmarshal runtime: %v", err)
+	}
+	if name != "rtds_layer" {
+		t.Errorf("layer name %q", name)
+	}
+
+	values := make(map[string]string)
+	flattenStruct("", decoded, values)
+	expected := map[string]string{
+		"flag":                  "true",
+		"health_check.interval": "3s",
+		"health_check.retries":  "5",
+	}
+	if len(values) != len(expected) {
+		t.Errorf("flattened values %v", values)
+	}
+	for k, v := range expected {
+		if values[k] != v {
+			t.Errorf("key %s: got %q, want %q", k, values[k], v)
+		}
+	}
+}
+
+func Test_unmarshalRuntimeInvalid(t *testing.T) {
+	// wire type 0 is not valid for either field
+	if _, _, err := unmarshalRuntime([]byte{0x08, 0x01}); err == nil {
+		t.Errorf("expected wire type error")
+	}
+	// field size runs past the buffer
+	if _, _, err := unmarshalRuntime([]byte{0x0a, 0x10, 'x'}); err == nil {
+		t.Errorf("expected field size error")
+	}
+}
@@ -0,0 +1,60 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v2
+
+import (
+	"sofastack.io/sofa-mosn/pkg/metrics"
+)
+
+// XdsMetaType is the metrics type of xds client state
+const XdsMetaType = "xds"
+
+// gauge key suffix of a rejected config per type url
+const configRejectedGauge = "config_rejected"
+
+// apiName maps a type url to the short api name used as a metrics label
+func apiName(typeURL string) string {
+	switch typeURL {
+	case EnvoyListener:
+		return "lds"
+	case EnvoyCluster:
+		return "cds"
+	case EnvoyClusterLoadAssignment:
+		return "eds"
+	case EnvoyRouteConfiguration:
+		return "rds"
+	case EnvoyRuntime:
+		return "rtds"
+	default:
+		return "unknown"
+	}
+}
+
+// markConfigRejected exports whether the latest response of a type url was
+// rejected, 1 means mosn nacked it and keeps the last-known-good config active
+func markConfigRejected(typeURL string, rejected bool) {
+	m, err := metrics.NewMetrics(XdsMetaType, map[string]string{"api": apiName(typeURL)})
+	if err != nil {
+		return
+	}
+	value := int64(0)
+	if rejected {
+		value = 1
+	}
+	m.Gauge(configRejectedGauge).Update(value)
+}
@@ -18,6 +18,7 @@
 package v2
 
 import (
+	"sync"
 	"time"
 
 	"sofastack.io/sofa-mosn/pkg/config"
@@ -33,6 +34,9 @@ type ClientV2 struct {
 	ServiceCluster string
 	ServiceNode    string
 	Config         *XDSConfig
+	// AckMutex protects Acks, the per type url version/nonce state of the ads stream
+	AckMutex sync.Mutex
+	Acks     map[string]*AckInfo
 }
 
 // XDSConfig contains ADS config and clusters info
@@ -54,6 +58,9 @@ type ADSConfig struct {
 	RefreshDelay *time.Duration
 	Services     []*ServiceConfig
 	StreamClient *StreamClient
+	// endpointIndex rotates over all management server endpoints so a
+	// reconnect fails over to the next server
+	endpointIndex int
 }
 
 // ADSClient communicated with pilot
@@ -61,6 +68,7 @@ type ADSClient struct {
 	AdsConfig       *ADSConfig
 	StreamClient    ads.AggregatedDiscoveryService_StreamAggregatedResourcesClient
 	V2Client        *ClientV2
+	LrsClient       *LRSClient
 	MosnConfig      *config.MOSNConfig
 	SendControlChan chan int
 	RecvControlChan chan int
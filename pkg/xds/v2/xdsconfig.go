@@ -165,6 +165,25 @@ func (c *ClusterConfig) GetEndpoint() (string, *time.Duration) {
 	return c.Address[idx], c.ConnectTimeout
 }
 
+// nextEndpoint rotates over all configured management server endpoints,
+// each call returns the next one so a failed connection fails over to
+// another server instead of retrying the same address
+func (c *ADSConfig) nextEndpoint() string {
+	endpoints := make([]string, 0, 4)
+	for _, service := range c.Services {
+		if service.ClusterConfig == nil {
+			continue
+		}
+		endpoints = append(endpoints, service.ClusterConfig.Address...)
+	}
+	if len(endpoints) == 0 {
+		return ""
+	}
+	endpoint := endpoints[c.endpointIndex%len(endpoints)]
+	c.endpointIndex++
+	return endpoint
+}
+
 // GetStreamClient return a grpc stream client that connected to ads
 func (c *ADSConfig) GetStreamClient() ads.AggregatedDiscoveryService_StreamAggregatedResourcesClient {
 	if c.StreamClient != nil && c.StreamClient.Client != nil {
@@ -177,17 +196,7 @@ func (c *ADSConfig) GetStreamClient() ads.AggregatedDiscoveryService_StreamAggre
 		log.DefaultLogger.Errorf("no available ads service")
 		return nil
 	}
-	var endpoint string
-	//var timeout *time.Duration
-	for _, service := range c.Services {
-		if service.ClusterConfig == nil {
-			continue
-		}
-		endpoint, _ = service.ClusterConfig.GetEndpoint()
-		if len(endpoint) > 0 {
-			break
-		}
-	}
+	endpoint := c.nextEndpoint()
 	if len(endpoint) == 0 {
 		log.DefaultLogger.Errorf("no available ads endpoint")
 		return nil
@@ -0,0 +1,44 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v2
+
+import (
+	"testing"
+)
+
+func Test_nextEndpoint(t *testing.T) {
+	config := &ADSConfig{
+		Services: []*ServiceConfig{
+			{ClusterConfig: &ClusterConfig{Address: []string{"pilot-0:15010", "pilot-1:15010"}}},
+			{ClusterConfig: nil},
+			{ClusterConfig: &ClusterConfig{Address: []string{"pilot-2:15010"}}},
+		},
+	}
+	// rotates over all endpoints of all services, then wraps around
+	expected := []string{"pilot-0:15010", "pilot-1:15010", "pilot-2:15010", "pilot-0:15010"}
+	for i, want := range expected {
+		if got := config.nextEndpoint(); got != want {
+			t.Errorf("call %d: got %q, want %q", i, got, want)
+		}
+	}
+
+	empty := &ADSConfig{}
+	if got := empty.nextEndpoint(); got != "" {
+		t.Errorf("empty config should return no endpoint, got %q", got)
+	}
+}
@@ -0,0 +1,59 @@
+package util
+
+import (
+	"sync"
+	"time"
+
+	"sofastack.io/sofa-mosn/pkg/protocol"
+	"sofastack.io/sofa-mosn/pkg/utils"
+)
+
+// FakeClock implements utils.Clock with a manually advanced time, so
+// timeout/retry/upgrade timing cases can be driven deterministically.
+// Install it with utils.SetClock and restore with utils.SetClock(nil)
+type FakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+// Advance moves the fake time forward
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+// SetupFakeClock installs a fake clock starting at start and returns it with
+// a restore function, typical usage:
+//	clock, restore := util.SetupFakeClock(time.Now())
+//	defer restore()
+func SetupFakeClock(start time.Time) (*FakeClock, func()) {
+	clock := NewFakeClock(start)
+	utils.SetClock(clock)
+	return clock, func() {
+		utils.SetClock(nil)
+	}
+}
+
+// SetupSeededIDs makes stream/request ids start from the given seed so a
+// test run produces the same ids every time, returns a restore function
+func SetupSeededIDs(seed uint64) func() {
+	protocol.SetIDGenerator(protocol.NewIDGenerator(seed))
+	return func() {
+		protocol.SetIDGenerator(nil)
+	}
+}